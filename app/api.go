@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"opengl/life"
+)
+
+// maxAPISteps is the most generations one POST /step can ask for.
+const maxAPISteps = 100000
+
+// apiServer serves -api's JSON control endpoints, reaching the boards
+// through rc.
+type apiServer struct {
+	server *http.Server
+	rc     *remoteControl
+}
+
+// apiState is what GET /state and the requests that change the boards
+// reply with.
+type apiState struct {
+	Generation int    `json:"generation"`
+	Population int    `json:"population"`
+	Rule       string `json:"rule"`
+	Seed       int64  `json:"seed"`
+	Paused     bool   `json:"paused"`
+	Columns    int    `json:"columns"`
+	Rows       int    `json:"rows"`
+}
+
+type apiCell struct {
+	X     int  `json:"x"`
+	Y     int  `json:"y"`
+	Alive bool `json:"alive"`
+}
+
+func (c *boardControls) state() apiState {
+	sim := c.sims[0]
+	return apiState{
+		Generation: sim.Generation,
+		Population: sim.Cells.Population(),
+		Rule:       sim.Rule.String(),
+		Seed:       sim.Seed,
+		Paused:     c.paused(),
+		Columns:    c.rs.config.GridWidth,
+		Rows:       c.rs.config.GridHeight,
+	}
+}
+
+// startAPI serves the control endpoints on addr, returning a function that
+// shuts the server down.
+func (rs *runState) startAPI(addr string, rc *remoteControl) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("-api: %w", err)
+	}
+	s := &apiServer{rc: rc}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveViewer)
+	mux.HandleFunc("/stream", s.serveStream)
+	s.handle(mux, http.MethodGet, "/state", func(*http.Request) (func(*boardControls) (any, error), error) {
+		return func(c *boardControls) (any, error) { return c.state(), nil }, nil
+	})
+	s.handle(mux, http.MethodPost, "/pause", func(*http.Request) (func(*boardControls) (any, error), error) {
+		return func(c *boardControls) (any, error) {
+			c.setPaused(true)
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodPost, "/resume", func(*http.Request) (func(*boardControls) (any, error), error) {
+		return func(c *boardControls) (any, error) {
+			c.setPaused(false)
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodPost, "/step", func(r *http.Request) (func(*boardControls) (any, error), error) {
+		body := struct {
+			N int `json:"n"`
+		}{N: 1}
+		if err := decodeBody(r, &body); err != nil {
+			return nil, err
+		}
+		if body.N < 1 || body.N > maxAPISteps {
+			return nil, fmt.Errorf("n must be between 1 and %d", maxAPISteps)
+		}
+		return func(c *boardControls) (any, error) {
+			for i := 0; i < body.N; i++ {
+				c.step()
+			}
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodPost, "/reset", func(r *http.Request) (func(*boardControls) (any, error), error) {
+		var body struct {
+			Seed *int64 `json:"seed"`
+		}
+		if err := decodeBody(r, &body); err != nil {
+			return nil, err
+		}
+		seed := time.Now().UnixNano()
+		if body.Seed != nil {
+			seed = *body.Seed
+		}
+		return func(c *boardControls) (any, error) {
+			c.reseed(seed)
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodPut, "/cells", func(r *http.Request) (func(*boardControls) (any, error), error) {
+		var body struct {
+			Cells []apiCell `json:"cells"`
+		}
+		if err := decodeBody(r, &body); err != nil {
+			return nil, err
+		}
+		for _, cell := range body.Cells {
+			if cell.X < 0 || cell.X >= rs.config.GridWidth || cell.Y < 0 || cell.Y >= rs.config.GridHeight {
+				return nil, fmt.Errorf("cell (%d, %d) is off the %dx%d board", cell.X, cell.Y, rs.config.GridWidth, rs.config.GridHeight)
+			}
+		}
+		return func(c *boardControls) (any, error) {
+			c.edit(func() {
+				for _, cell := range body.Cells {
+					c.sims[0].Cells.Set(cell.X, cell.Y, cell.Alive)
+				}
+			})
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodPut, "/rule", func(r *http.Request) (func(*boardControls) (any, error), error) {
+		var body struct {
+			Rule string `json:"rule"`
+		}
+		if err := decodeBody(r, &body); err != nil {
+			return nil, err
+		}
+		rule, err := life.ParseRule(body.Rule)
+		if err != nil {
+			return nil, err
+		}
+		return func(c *boardControls) (any, error) {
+			if err := c.setRule(rule); err != nil {
+				return nil, err
+			}
+			return c.state(), nil
+		}, nil
+	})
+	s.handle(mux, http.MethodGet, "/stats", func(r *http.Request) (func(*boardControls) (any, error), error) {
+		q := r.URL.Query()
+		from, to, points := math.MinInt, math.MaxInt, 0
+		for _, p := range []struct {
+			name string
+			v    *int
+		}{{"from", &from}, {"to", &to}, {"points", &points}} {
+			if s := q.Get(p.name); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("%s must be a whole number", p.name)
+				}
+				*p.v = n
+			}
+		}
+		return func(c *boardControls) (any, error) {
+			return struct {
+				Capacity int           `json:"capacity"`
+				Samples  []StatsSample `json:"samples"`
+			}{c.stats.Capacity(), c.stats.RangeN(from, to, points)}, nil
+		}, nil
+	})
+	// The board comes back as a state file, which -load can carry on from.
+	s.handle(mux, http.MethodGet, "/board", func(*http.Request) (func(*boardControls) (any, error), error) {
+		return func(c *boardControls) (any, error) { return rs.captureState(c.sims, rs.newCamera()), nil }, nil
+	})
+	// Requests still waiting on the boards when the server shuts down, once
+	// their loop has gone, are cancelled rather than waited for.
+	base, cancel := context.WithCancel(context.Background())
+	s.server = &http.Server{Handler: mux, BaseContext: func(net.Listener) context.Context { return base }}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("-api", "err", err)
+		}
+	}()
+	slog.Info("serving the control API", "url", fmt.Sprintf("http://%s/", ln.Addr()))
+	return func() {
+		cancel()
+		ctx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+		defer cancelWait()
+		s.server.Shutdown(ctx)
+	}, nil
+}
+
+// handle serves method requests to path. parse checks the request and
+// returns what to do to the boards, which the loop owning them runs.
+func (s *apiServer) handle(mux *http.ServeMux, method, path string, parse func(*http.Request) (func(*boardControls) (any, error), error)) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSON(w, http.StatusMethodNotAllowed, apiErrorBody(fmt.Errorf("%s wants %s", path, method)))
+			return
+		}
+		do, err := parse(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiErrorBody(err))
+			return
+		}
+		body, err := s.rc.do(r.Context(), do)
+		switch {
+		case r.Context().Err() != nil:
+		case err != nil:
+			writeJSON(w, http.StatusBadRequest, apiErrorBody(err))
+		default:
+			writeJSON(w, http.StatusOK, body)
+		}
+	})
+}
+
+// decodeBody decodes r's JSON body into v, leaving v as it is if there's
+// no body.
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, 16<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("bad JSON body: %w", err)
+	}
+	return nil
+}
+
+func apiErrorBody(err error) any {
+	return struct {
+		Error string `json:"error"`
+	}{err.Error()}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
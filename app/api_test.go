@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// freeAddr returns a local address nothing's listening on, for servers
+// that take an address rather than a listener.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// pausedHeadless starts a headless run of cfg, paused by a scenario, with
+// serve started on it once it has a remote control, and returns the run.
+func pausedHeadless(t *testing.T, cfg Config, serve func(rs *runState) (func(), error)) *runState {
+	t.Helper()
+	cfg.Headless = true
+	cfg.Scenario = filepath.Join(t.TempDir(), "pause.json")
+	if err := os.WriteFile(cfg.Scenario, []byte(`[{"at": 0, "do": "pause"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rs := testRun(t, cfg)
+	rs.stdout = io.Discard
+	var err error
+	if rs.schedule, err = rs.loadScenario(cfg.Scenario); err != nil {
+		t.Fatal(err)
+	}
+	rs.remote = rs.newRemoteControl()
+	stop, err := serve(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shutdown := make(chan struct{})
+	rs.shutdown = shutdown
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- rs.runHeadless(seeds, rules, nil) }()
+	t.Cleanup(func() {
+		close(shutdown)
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+		stop()
+	})
+	return rs
+}
+
+// apiHeadless starts a paused headless run of cfg serving the control
+// API, and returns its URL.
+func apiHeadless(t *testing.T, cfg Config) string {
+	t.Helper()
+	addr := freeAddr(t)
+	pausedHeadless(t, cfg, func(rs *runState) (func(), error) { return rs.startAPI(addr, rs.remote) })
+	return "http://" + addr
+}
+
+// apiRequest makes a request with a JSON body, if there is one, decoding
+// the reply into v.
+func apiRequest(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if err := json.NewDecoder(bytes.NewReader(b)).Decode(v); err != nil {
+		t.Fatalf("%s %s replied %q: %v", method, url, b, err)
+	}
+	return resp.StatusCode
+}
+
+func TestAPI(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Density = 20, 10, 0
+	url := apiHeadless(t, cfg)
+
+	var st apiState
+	if code := apiRequest(t, http.MethodGet, url+"/state", "", &st); code != http.StatusOK || st != (apiState{Rule: "B3/S23", Paused: true, Columns: 20, Rows: 10}) {
+		t.Errorf("GET /state gave %d %+v", code, st)
+	}
+	apiRequest(t, http.MethodPut, url+"/cells", `{"cells": [{"x": 5, "y": 5, "alive": true}, {"x": 6, "y": 5, "alive": true}, {"x": 7, "y": 5, "alive": true}, {"x": 0, "y": 0}]}`, &st)
+	if st.Population != 3 {
+		t.Errorf("after PUT /cells, population %d, want the blinker's 3", st.Population)
+	}
+	if apiRequest(t, http.MethodPost, url+"/step", `{"n": 3}`, &st); st.Generation != 3 || st.Population != 3 {
+		t.Errorf("after POST /step, generation %d, population %d, want 3 and 3", st.Generation, st.Population)
+	}
+	if apiRequest(t, http.MethodPost, url+"/step", "", &st); st.Generation != 4 {
+		t.Errorf("POST /step with no body stepped to %d, want 4", st.Generation)
+	}
+	if apiRequest(t, http.MethodPut, url+"/rule", `{"rule": "B36/S23"}`, &st); st.Rule != "B36/S23" {
+		t.Errorf("after PUT /rule, the rule is %s", st.Rule)
+	}
+	if apiRequest(t, http.MethodPost, url+"/reset", `{"seed": 5}`, &st); st.Seed != 5 || st.Generation != 0 {
+		t.Errorf("after POST /reset, seed %d at generation %d, want 5 at 0", st.Seed, st.Generation)
+	}
+	if apiRequest(t, http.MethodPost, url+"/resume", "", &st); st.Paused {
+		t.Error("still paused after POST /resume")
+	}
+	if apiRequest(t, http.MethodPost, url+"/pause", "", &st); !st.Paused {
+		t.Error("not paused after POST /pause")
+	}
+
+	var board state
+	if code := apiRequest(t, http.MethodGet, url+"/board", "", &board); code != http.StatusOK || board.Columns != 20 || board.Rows != 10 || len(board.Boards) != 1 {
+		t.Errorf("GET /board gave %d, a %dx%d state of %d boards", code, board.Columns, board.Rows, len(board.Boards))
+	}
+}
+
+// TestAPIRejects checks bad requests get a 400, or a 405 for the wrong
+// method, saying what's wrong, and change nothing.
+func TestAPIRejects(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 20, 10
+	url := apiHeadless(t, cfg)
+
+	for _, c := range []struct {
+		method, path, body string
+		code               int
+		want               string
+	}{
+		{http.MethodPut, "/cells", `{"cells": [{"x": 20, "y": 0, "alive": true}]}`, http.StatusBadRequest, "cell (20, 0) is off the 20x10 board"},
+		{http.MethodPut, "/cells", `{"cells": [{"x": 0, "y": -1, "alive": true}]}`, http.StatusBadRequest, "cell (0, -1) is off the 20x10 board"},
+		{http.MethodPut, "/rule", `{"rule": "B9/S23"}`, http.StatusBadRequest, "B9/S23"},
+		{http.MethodPost, "/step", `{"n": 0}`, http.StatusBadRequest, "n must be between 1 and 100000"},
+		{http.MethodPost, "/step", `{"steps": 2}`, http.StatusBadRequest, "bad JSON body"},
+		{http.MethodPost, "/state", "", http.StatusMethodNotAllowed, "/state wants GET"},
+	} {
+		var reply struct{ Error string }
+		if code := apiRequest(t, c.method, url+c.path, c.body, &reply); code != c.code || !strings.Contains(reply.Error, c.want) {
+			t.Errorf("%s %s %s gave %d %q, want %d and an error containing %q", c.method, c.path, c.body, code, reply.Error, c.code, c.want)
+		}
+	}
+	var st apiState
+	if apiRequest(t, http.MethodGet, url+"/state", "", &st); st.Generation != 0 || st.Rule != "B3/S23" {
+		t.Errorf("the rejected requests left generation %d and rule %s", st.Generation, st.Rule)
+	}
+}
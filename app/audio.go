@@ -0,0 +1,231 @@
+package app
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os/exec"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"opengl/life"
+)
+
+const (
+	// audioRate is the samples a second sound is made at, and audioBlock
+	// how many are made at a time, between looks at what the boards did.
+	audioRate  = 44100
+	audioBlock = audioRate / 100
+	// audioAhead is how far ahead of the clock sound is made, so a note
+	// comes soon after its generation rather than after the player's pipe
+	// has filled.
+	audioAhead = 80 * time.Millisecond
+	// maxVoices is how many notes sound at once; more drop the oldest.
+	maxVoices = 16
+)
+
+// audioPlayers are the programs -audio plays through, the first on the
+// PATH, each taking mono 16-bit little-endian samples at audioRate on its
+// standard input.
+var audioPlayers = [][]string{
+	{"aplay", "-q", "-t", "raw", "-f", "S16_LE", "-c", "1", "-r", strconv.Itoa(audioRate)},
+	{"paplay", "--raw", "--format=s16le", "--channels=1", "--rate=" + strconv.Itoa(audioRate)},
+	{"ffplay", "-loglevel", "error", "-nodisp", "-f", "s16le", "-ch_layout", "mono", "-ar", strconv.Itoa(audioRate), "-i", "-"},
+}
+
+// checkAudioPlayer returns the first of audioPlayers on the PATH, so a
+// missing one is found at startup.
+func checkAudioPlayer() ([]string, error) {
+	for _, p := range audioPlayers {
+		if _, err := exec.LookPath(p[0]); err == nil {
+			return p, nil
+		}
+	}
+	return nil, errors.New("-audio needs aplay, paplay or ffplay, and none is on the PATH")
+}
+
+// soundEvent is what a generation did that's heard.
+type soundEvent struct {
+	births  int
+	extinct bool
+}
+
+// soundOut sonifies the first board's generations: a blip for the cells
+// born, higher the more there were, and a low tone when everything dies.
+// Samples are made on a goroutine of its own and piped to a player;
+// generations are handed over without waiting, so a slow player drops
+// notes rather than holding up the boards.
+type soundOut struct {
+	rs *runState
+
+	events chan soundEvent
+	muted  atomic.Bool
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	done   chan struct{}
+	// alive is whether the board had live cells last generation, for
+	// telling when it dies out.
+	alive bool
+}
+
+func (rs *runState) newSoundOut(player []string, volume float64) (*soundOut, error) {
+	cmd := exec.Command(player[0], player[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s := &soundOut{rs: rs, events: make(chan soundEvent, 64), cmd: cmd, stdin: stdin, done: make(chan struct{}), alive: true}
+	go s.run(newSynth(audioRate, volume))
+	return s, nil
+}
+
+func (s *soundOut) run(syn *synth) {
+	defer close(s.done)
+	block := make([]int16, audioBlock)
+	out := make([]byte, 2*audioBlock)
+	start, made := time.Now(), time.Duration(0)
+	for {
+		for more := true; more; {
+			select {
+			case e, ok := <-s.events:
+				if !ok {
+					return
+				}
+				syn.event(e)
+			default:
+				more = false
+			}
+		}
+		if s.muted.Load() {
+			syn.voices = syn.voices[:0]
+		}
+		syn.render(block)
+		for i, v := range block {
+			binary.LittleEndian.PutUint16(out[2*i:], uint16(v))
+		}
+		if _, err := s.stdin.Write(out); err != nil {
+			s.rs.reportError("the audio player stopped taking sound", err, "player", s.cmd.Path)
+			for range s.events {
+			}
+			return
+		}
+		made += audioBlock * time.Second / audioRate
+		if ahead := made - time.Since(start); ahead > audioAhead {
+			time.Sleep(ahead - audioAhead)
+		}
+	}
+}
+
+// stepped queues what sim, the first board, just did, if it's heard.
+func (s *soundOut) stepped(sim *life.Simulation) {
+	if s == nil {
+		return
+	}
+	alive := sim.Births > 0 || sim.Cells.Population() > 0
+	e := soundEvent{births: sim.Births, extinct: s.alive && !alive}
+	s.alive = alive
+	if e.births == 0 && !e.extinct {
+		return
+	}
+	select {
+	case s.events <- e:
+	default:
+	}
+}
+
+// toggleMute mutes or unmutes the sound, returning whether it's now muted.
+func (s *soundOut) toggleMute() bool {
+	muted := !s.muted.Load()
+	s.muted.Store(muted)
+	return muted
+}
+
+// close stops the sound and waits for the player to finish.
+func (s *soundOut) close() {
+	if s == nil {
+		return
+	}
+	close(s.events)
+	<-s.done
+	s.stdin.Close()
+	s.cmd.Wait()
+}
+
+// synth makes the notes: decaying sine waves, with a little noise at the
+// start of each blip for a click.
+type synth struct {
+	rate   float64
+	volume float64
+	voices []voice
+	noise  *rand.Rand
+}
+
+type voice struct {
+	frequency, phase float64
+	amplitude, decay float64
+	// click is how many more samples of noise the voice starts with.
+	click int
+}
+
+func newSynth(rate int, volume float64) *synth {
+	return &synth{rate: float64(rate), volume: volume, noise: rand.New(rand.NewSource(1))}
+}
+
+// birthPitch is the frequency of the blip for a generation with births
+// births: 220Hz for one, an octave up for every eightfold more, up to
+// 1760Hz.
+func birthPitch(births int) float64 {
+	return 220 * math.Pow(2, min(math.Log2(float64(births))/3, 3))
+}
+
+// event starts the notes for e.
+func (s *synth) event(e soundEvent) {
+	if e.births > 0 {
+		// Blips fade out in about a tenth of a second.
+		s.start(voice{frequency: birthPitch(e.births), amplitude: 0.3, decay: math.Exp(-1 / (0.02 * s.rate)), click: int(0.002 * s.rate)})
+	}
+	if e.extinct {
+		// The tone fades out in about two seconds.
+		s.start(voice{frequency: 55, amplitude: 0.6, decay: math.Exp(-1 / (0.3 * s.rate))})
+	}
+}
+
+func (s *synth) start(v voice) {
+	if len(s.voices) == maxVoices {
+		s.voices = s.voices[1:]
+	}
+	s.voices = append(s.voices, v)
+}
+
+// render fills out with the next samples, mixing the voices and dropping
+// those that have faded out.
+func (s *synth) render(out []int16) {
+	for i := range out {
+		var sum float64
+		for j := range s.voices {
+			v := &s.voices[j]
+			sum += v.amplitude * math.Sin(2*math.Pi*v.phase)
+			if v.click > 0 {
+				sum += v.amplitude * 0.5 * (2*s.noise.Float64() - 1)
+				v.click--
+			}
+			v.phase += v.frequency / s.rate
+			v.phase -= math.Floor(v.phase)
+			v.amplitude *= v.decay
+		}
+		out[i] = int16(math.Tanh(sum*s.volume) * math.MaxInt16)
+	}
+	kept := s.voices[:0]
+	for _, v := range s.voices {
+		if v.amplitude > 1e-3 {
+			kept = append(kept, v)
+		}
+	}
+	s.voices = kept
+}
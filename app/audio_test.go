@@ -0,0 +1,115 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os/exec"
+	"testing"
+	"time"
+
+	"opengl/life"
+)
+
+// energy is the power in samples at frequency, by the Goertzel algorithm.
+func energy(samples []int16, frequency float64) float64 {
+	k := 2 * math.Cos(2*math.Pi*frequency/audioRate)
+	var s1, s2 float64
+	for _, v := range samples {
+		s1, s2 = float64(v)/math.MaxInt16+k*s1-s2, s1
+	}
+	return (s1*s1 + s2*s2 - k*s1*s2) / float64(len(samples)*len(samples))
+}
+
+func TestBirthPitch(t *testing.T) {
+	for _, c := range []struct {
+		births int
+		want   float64
+	}{{1, 220}, {8, 440}, {64, 880}, {512, 1760}, {100000, 1760}} {
+		if got := birthPitch(c.births); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%d births are at %vHz, want %vHz", c.births, got, c.want)
+		}
+	}
+}
+
+// TestSonify runs a blinker under a rule without survival, for the sound
+// of its generations to have its energy where the notes for them are: a
+// blip for the two cells born, and then the low tone of it dying out.
+func TestSonify(t *testing.T) {
+	r, err := life.ParseRule("B3/S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := life.NewSimulation(life.NewGrid(16, 16), r, 1, 0, 0)
+	for _, c := range life.LibraryPattern("blinker").Placed(8, 8, 16, 16, false) {
+		sim.Cells.Set(c[0], c[1], true)
+	}
+	s := &soundOut{events: make(chan soundEvent, 64), alive: true}
+	syn := newSynth(audioRate, 1)
+	var generations [][]int16
+	for i := 0; i < 3; i++ {
+		sim.Step(false)
+		s.stepped(sim)
+		for more := true; more; {
+			select {
+			case e := <-s.events:
+				syn.event(e)
+			default:
+				more = false
+			}
+		}
+		samples := make([]int16, audioRate/10)
+		syn.render(samples)
+		generations = append(generations, samples)
+	}
+
+	blip, tone := birthPitch(2), 55.0
+	if b, low := energy(generations[0], blip), energy(generations[0], tone); b < 100*low || b < 1e-4 {
+		t.Errorf("with births, the sound has %g at the blip's %.0fHz and %g at the tone's, want the blip's", b, blip, low)
+	}
+	if b, low := energy(generations[1], blip), energy(generations[1], tone); low < 100*b || low < 1e-4 {
+		t.Errorf("on dying out, the sound has %g at the tone's 55Hz and %g at the blip's, want the tone's", low, b)
+	}
+	if e := energy(generations[2], 1000); e > 1e-6 {
+		t.Errorf("with nothing happening, there's %g at 1kHz", e)
+	}
+}
+
+// TestSoundOutMuted checks nothing's heard of a note while muted, and a
+// player that stops taking sound is reported without holding anything up.
+func TestSoundOutMuted(t *testing.T) {
+	rs := testRun(t, DefaultConfig())
+	reports := make(chan errorReport, 1)
+	rs.errorReports = reports
+	player, played := io.Pipe()
+	s := &soundOut{rs: rs, events: make(chan soundEvent, 64), cmd: &exec.Cmd{Path: "aplay"}, stdin: played, done: make(chan struct{})}
+	if !s.toggleMute() {
+		t.Fatal("toggling the sound didn't mute it")
+	}
+	s.events <- soundEvent{births: 40}
+	go s.run(newSynth(audioRate, 1))
+	buf := make([]byte, 2*audioRate/10)
+	if _, err := io.ReadFull(player, buf); err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range buf {
+		if b != 0 {
+			t.Fatalf("muted, byte %d of the sound is %d", i, b)
+		}
+	}
+
+	player.CloseWithError(errors.New("player gone"))
+	select {
+	case r := <-reports:
+		if r.message != "the audio player stopped taking sound" {
+			t.Errorf("reported %q", r.message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the player stopping wasn't reported")
+	}
+	for i := 0; i < 200; i++ {
+		s.events <- soundEvent{births: 1}
+	}
+	close(s.events)
+	<-s.done
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// spread is a toy automaton, registered as an embedder would: a dead cell
+// with at least the configured number of live neighbours is born, and no
+// cell dies.
+type spread int
+
+func (n spread) Step(g, next life.Grid, wrap bool) (births, deaths int) {
+	for y := 0; y < g.Rows(); y++ {
+		for x := 0; x < g.Columns(); x++ {
+			live := 0
+			g.Neighbours(x, y, wrap, func(c life.Cell) {
+				if c.Alive {
+					live++
+				}
+			})
+			born := !g.Alive(x, y) && live >= int(n)
+			if born {
+				births++
+			}
+			next.Set(x, y, g.Alive(x, y) || born)
+		}
+	}
+	return births, deaths
+}
+
+func init() {
+	life.Register(life.Automaton{
+		Name:        "spread",
+		Description: "cells are born next to enough live ones, and never die",
+		Default:     "1",
+		NewStepper: func(config string) (life.Stepper, error) {
+			n, err := strconv.Atoi(config)
+			if err != nil || n < 1 || n > 8 {
+				return nil, fmt.Errorf("want the live neighbours a cell's born with, 1 to 8, not %q", config)
+			}
+			return spread(n), nil
+		},
+		States:  2,
+		Palette: []color.RGBA{{200, 120, 40, 255}},
+	})
+}
+
+// TestAutomatonHeadless runs a registered automaton, by -automaton, from
+// start to end headless, as it would a built-in one.
+func TestAutomatonHeadless(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Density, cfg.Seed, cfg.Generations = 20, 20, 0.05, 1, 40
+	cfg.Automaton = "spread"
+	if got, want := runHeadless(t, cfg, nil), "board 1: generation 40, population 400, rule spread, hash "; !strings.HasPrefix(got, want) {
+		t.Errorf("spread ran to %q, want the whole board filled, %q", got, want)
+	}
+
+	cfg.Automaton = "spread:3"
+	r, err := life.ParseRule("spread:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := life.NewSimulation(life.NewGrid(20, 20), r, 1, 0.05, 0)
+	for i := 0; i < 40; i++ {
+		sim.Step(false)
+	}
+	if got, want := runHeadless(t, cfg, nil), summary(1, sim); got != want || sim.Rule.String() != "spread:3" {
+		t.Errorf("spread:3 ran to %q, want %q", got, want)
+	}
+
+	setPalette(r)
+	t.Cleanup(func() { render.Palette = nil })
+	if len(render.Palette) != 1 || render.Palette[0] != [3]float32{200.0 / 255, 120.0 / 255, 40.0 / 255} {
+		t.Errorf("spread is drawn in %v, want its palette", render.Palette)
+	}
+	if !strings.Contains(listAutomata(), "spread           2 states  cells are born next to enough live ones, and never die\n") {
+		t.Errorf("-automaton list doesn't list spread:\n%s", listAutomata())
+	}
+}
+
+func TestAutomatonRejects(t *testing.T) {
+	for _, c := range []struct{ automaton, want string }{
+		{"spread:9", `automaton spread: want the live neighbours a cell's born with, 1 to 8, not "9"`},
+		{"sprawl", `no automaton called "sprawl"; -automaton list lists them`},
+	} {
+		cfg := DefaultConfig()
+		cfg.Automaton = c.automaton
+		if _, _, _, err := cfg.boards(); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("-automaton %s: %v, want an error containing %q", c.automaton, err, c.want)
+		}
+	}
+}
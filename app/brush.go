@@ -0,0 +1,146 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// maxBrushRadius bounds the brush, in cells from its centre.
+const maxBrushRadius = 16
+
+// brush is the footprint painted around the cursor when editing, and the
+// outline that previews it. A radius of 0 is a single cell. While a pattern
+// is picked, its outline is previewed instead, ready to be stamped, and
+// while a shape is being dragged out it's shown as a ghost.
+type brush struct {
+	rs *runState
+
+	radius  int
+	square  bool
+	pattern *life.Pattern
+	tool    tool
+	shape   *shapeDrag
+
+	// hover is the board and cell the cursor is over, if any.
+	hover          *life.Simulation
+	hoverX, hoverY int
+
+	program *overlayProgram
+	outline *lines
+}
+
+func (rs *runState) newBrush(program *overlayProgram) *brush {
+	// The outline of any footprint is no longer than its bounding square's;
+	// larger patterns grow the buffer as needed.
+	return &brush{rs: rs, program: program, outline: rs.newLines("brush outline", 8*(2*maxBrushRadius+1))}
+}
+
+func (b *brush) resize(delta int) {
+	b.radius = min(max(b.radius+delta, 0), maxBrushRadius)
+}
+
+// footprint returns the cells the brush covers when centred on (cx, cy),
+// leaving out any that fall off the board.
+func (b *brush) footprint(cx, cy int) [][2]int {
+	var cells [][2]int
+	r := b.radius
+	for x := cx - r; x <= cx+r; x++ {
+		for y := cy - r; y <= cy+r; y++ {
+			if x < 0 || y < 0 || x >= b.rs.config.GridWidth || y >= b.rs.config.GridHeight {
+				continue
+			}
+			// r*(r+1) rather than r*r rounds the circle out, so small radii
+			// don't come out as diamonds.
+			if dx, dy := x-cx, y-cy; !b.square && dx*dx+dy*dy > r*(r+1) {
+				continue
+			}
+			cells = append(cells, [2]int{x, y})
+		}
+	}
+	return cells
+}
+
+// paint sets every cell under the brush centred on (cx, cy) alive or dead.
+func (b *brush) paint(sim *life.Simulation, cx, cy int, alive bool) {
+	for _, c := range b.footprint(cx, cy) {
+		sim.Cells.Set(c[0], c[1], alive)
+	}
+}
+
+// drawOutline outlines the brush's footprint, or the picked pattern, if the
+// cursor is over sim. It expects the viewport to be set to sim's view.
+func (b *brush) drawOutline(sim *life.Simulation, cam *camera) {
+	if b.shape != nil {
+		if b.shape.sim == sim {
+			b.drawGhost(b.shape.cells(), cam)
+		}
+		return
+	}
+	if b.hover != sim {
+		return
+	}
+	var footprint [][2]int
+	if b.pattern != nil {
+		footprint = b.pattern.Placed(b.hoverX, b.hoverY, b.rs.config.GridWidth, b.rs.config.GridHeight, b.rs.config.Wrap)
+	} else {
+		footprint = b.footprint(b.hoverX, b.hoverY)
+	}
+	in := make(map[[2]int]bool, len(footprint))
+	for _, c := range footprint {
+		in[c] = true
+	}
+
+	edge := func(x0, y0, x1, y1 int) {
+		b.outline.add(b.rs.cellCorner(cam, x0, y0))
+		b.outline.add(b.rs.cellCorner(cam, x1, y1))
+	}
+	b.outline.reset()
+	for _, c := range footprint {
+		x, y := c[0], c[1]
+		if !in[[2]int{x - 1, y}] {
+			edge(x, y, x, y+1)
+		}
+		if !in[[2]int{x + 1, y}] {
+			edge(x+1, y, x+1, y+1)
+		}
+		if !in[[2]int{x, y - 1}] {
+			edge(x, y, x+1, y)
+		}
+		if !in[[2]int{x, y + 1}] {
+			edge(x, y+1, x+1, y+1)
+		}
+	}
+	b.program.use(1, 0.8, 0.2, 1)
+	b.outline.draw(gl.LINES)
+}
+
+// drawGhost draws the cells translucently.
+func (b *brush) drawGhost(cells [][2]int, cam *camera) {
+	wasBlending := gl.IsEnabled(gl.BLEND)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	b.outline.reset()
+	for _, c := range cells {
+		x0, y0 := b.rs.cellCorner(cam, c[0], c[1])
+		x1, y1 := b.rs.cellCorner(cam, c[0]+1, c[1]+1)
+		b.outline.add(x0, y0)
+		b.outline.add(x1, y0)
+		b.outline.add(x1, y1)
+		b.outline.add(x0, y0)
+		b.outline.add(x1, y1)
+		b.outline.add(x0, y1)
+	}
+	// The colour is premultiplied, as shade's is.
+	b.program.use(0.5, 0.4, 0.1, 0.5)
+	b.outline.draw(gl.TRIANGLES)
+	if !wasBlending {
+		gl.Disable(gl.BLEND)
+	}
+}
+
+// cellCorner returns the bottom-left corner of cell (x, y) in normalized
+// device coordinates within its view.
+func (rs *runState) cellCorner(cam *camera, x, y int) (float32, float32) {
+	wx, wy := float32(x)*2/float32(rs.config.GridWidth)-1, float32(y)*2/float32(rs.config.GridHeight)-1
+	return (wx - cam.x) * cam.zoom, (wy - cam.y) * cam.zoom
+}
@@ -0,0 +1,52 @@
+package app
+
+import "testing"
+
+func TestBrushFootprint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 30, 20
+	b := &brush{rs: testRun(t, cfg)}
+	for _, c := range []struct {
+		radius int
+		square bool
+		cx, cy int
+		want   int
+	}{
+		{0, false, 5, 5, 1},
+		{0, true, 5, 5, 1},
+		{1, false, 5, 5, 9},
+		{2, false, 5, 5, 21},
+		{3, false, 10, 10, 37},
+		{2, true, 5, 5, 25},
+		// Clipped to the board at its corners and edges.
+		{2, true, 0, 0, 9},
+		{2, false, 0, 0, 8},
+		{2, true, 29, 19, 9},
+		{3, true, 15, 19, 28},
+		{0, false, 30, 5, 0},
+	} {
+		b.radius, b.square = c.radius, c.square
+		cells := b.footprint(c.cx, c.cy)
+		if len(cells) != c.want {
+			t.Errorf("radius %d, square %v at %d, %d covers %d cells, want %d", c.radius, c.square, c.cx, c.cy, len(cells), c.want)
+		}
+		for _, cell := range cells {
+			dx, dy := cell[0]-c.cx, cell[1]-c.cy
+			if cell[0] < 0 || cell[1] < 0 || cell[0] >= 30 || cell[1] >= 20 || abs(dx) > c.radius || abs(dy) > c.radius {
+				t.Errorf("radius %d at %d, %d covers cell %v", c.radius, c.cx, c.cy, cell)
+			}
+		}
+	}
+}
+
+func TestBrushResize(t *testing.T) {
+	b := &brush{rs: testRun(t, DefaultConfig())}
+	b.resize(-1)
+	if b.radius != 0 {
+		t.Fatalf("shrinking the smallest brush left radius %d", b.radius)
+	}
+	b.resize(maxBrushRadius + 5)
+	if b.radius != maxBrushRadius {
+		t.Fatalf("growing the brush past its largest left radius %d", b.radius)
+	}
+}
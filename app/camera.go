@@ -0,0 +1,221 @@
+package app
+
+import (
+	"math"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+const (
+	// panSpeed is how far the view moves per second, as a fraction of its
+	// own width, while a pan key is held.
+	panSpeed = 1.0
+	// zoomSpeed is the factor the zoom changes by per second while a zoom
+	// key is held.
+	zoomSpeed = 2.0
+	// scrollZoom is the factor one notch of the scroll wheel zooms by.
+	scrollZoom = 1.25
+	// minVisibleCells bounds how far in the camera can zoom.
+	minVisibleCells = 4
+	// fitDuration is how long, in seconds, the camera takes to frame a
+	// pattern.
+	fitDuration = 0.3
+	// fitMargin is the number of cells left around a framed pattern.
+	fitMargin = 2
+)
+
+// camera describes which part of the board is visible. The board spans
+// [-1, 1] on both axes in world space, so a zoom of 1 centred on the origin
+// shows exactly the whole board.
+type camera struct {
+	rs *runState
+
+	viewpoint
+
+	// from and to are the endpoints of an animated move, which has run for
+	// elapsed seconds. A nil to means no move is in progress.
+	from    viewpoint
+	to      *viewpoint
+	elapsed float64
+}
+
+// viewpoint is a view centre and zoom in world space.
+type viewpoint struct {
+	x, y float32
+	zoom float32
+}
+
+func (rs *runState) newCamera() *camera {
+	return &camera{rs: rs, viewpoint: viewpoint{zoom: 1}}
+}
+
+// projection returns the column-major orthographic projection matrix for the
+// camera's current view.
+func (c *camera) projection() mat4 {
+	return mat4{
+		c.zoom, 0, 0, 0,
+		0, c.zoom, 0, 0,
+		0, 0, -1, 0,
+		-c.x * c.zoom, -c.y * c.zoom, 0, 1,
+	}
+}
+
+// view returns the visible world-space rectangle.
+func (c *camera) view() (minX, minY, maxX, maxY float32) {
+	half := 1 / c.zoom
+	return c.x - half, c.y - half, c.x + half, c.y + half
+}
+
+// viewCells returns the inclusive range of cells in view, counting a cell as
+// in view if its centre is, and false if there are none.
+func (c *camera) viewCells() (minX, minY, maxX, maxY int, ok bool) {
+	viewMinX, viewMinY, viewMaxX, viewMaxY := c.view()
+	// Cell x's centre is at (x+0.5)*2/columns-1 in world space.
+	first := func(edge float32, n int) int {
+		return max(int(math.Ceil(float64((edge+1)*float32(n)/2-0.5))), 0)
+	}
+	last := func(edge float32, n int) int {
+		return min(int(math.Floor(float64((edge+1)*float32(n)/2-0.5))), n-1)
+	}
+	minX, maxX = first(viewMinX, c.rs.config.GridWidth), last(viewMaxX, c.rs.config.GridWidth)
+	minY, maxY = first(viewMinY, c.rs.config.GridHeight), last(viewMaxY, c.rs.config.GridHeight)
+	return minX, minY, maxX, maxY, minX <= maxX && minY <= maxY
+}
+
+// showsWholeBoard reports whether every cell is inside the view.
+func (c *camera) showsWholeBoard() bool {
+	minX, minY, maxX, maxY := c.view()
+	return minX <= -1 && minY <= -1 && maxX >= 1 && maxY >= 1
+}
+
+// toWorld applies the inverse of the projection to a point in normalized
+// device coordinates.
+func (c *camera) toWorld(x, y float32) (float32, float32) {
+	return x/c.zoom + c.x, y/c.zoom + c.y
+}
+
+// maxZoom is the zoom at which minVisibleCells cells fit across the view.
+func (rs *runState) maxZoom() float32 {
+	return float32(min(rs.config.GridHeight, rs.config.GridWidth)) / minVisibleCells
+}
+
+// clamp keeps the zoom between the whole board and maxZoom, and the view
+// entirely over the board.
+func (v *viewpoint) clamp(maxZoom float32) {
+	v.zoom = clamp(v.zoom, 1, maxZoom)
+	limit := 1 - 1/v.zoom
+	v.x = clamp(v.x, -limit, limit)
+	v.y = clamp(v.y, -limit, limit)
+}
+
+// clamp keeps the camera's view within the board.
+func (c *camera) clamp() {
+	c.viewpoint.clamp(c.rs.maxZoom())
+}
+
+// update pans and zooms the camera over the last dt seconds for the zoom
+// and pan commands that are held down.
+func (c *camera) update(isHeld func(command string) bool, dt float64) {
+	moved := false
+	held := func(command string) bool {
+		if isHeld(command) {
+			moved = true
+			return true
+		}
+		return false
+	}
+
+	if held("zoom-in") {
+		c.zoom *= float32(math.Pow(zoomSpeed, dt))
+	}
+	if held("zoom-out") {
+		c.zoom /= float32(math.Pow(zoomSpeed, dt))
+	}
+
+	step := float32(2*panSpeed*dt) / c.zoom
+	if held("pan-left") {
+		c.x -= step
+	}
+	if held("pan-right") {
+		c.x += step
+	}
+	if held("pan-down") {
+		c.y -= step
+	}
+	if held("pan-up") {
+		c.y += step
+	}
+
+	if moved {
+		c.to = nil
+	} else if c.to != nil {
+		c.animate(dt)
+	}
+	c.clamp()
+}
+
+// animate advances an animated move by dt seconds.
+func (c *camera) animate(dt float64) {
+	c.elapsed += dt
+	t := float32(math.Min(c.elapsed/fitDuration, 1))
+	t = t * t * (3 - 2*t)
+	c.x = c.from.x + (c.to.x-c.from.x)*t
+	c.y = c.from.y + (c.to.y-c.from.y)*t
+	c.zoom = c.from.zoom * float32(math.Pow(float64(c.to.zoom/c.from.zoom), float64(t)))
+	if t == 1 {
+		c.to = nil
+	}
+}
+
+// fit starts moving the camera to frame the given inclusive range of cells,
+// or the whole board if ok is false.
+func (c *camera) fit(minX, minY, maxX, maxY int, ok bool) {
+	to := &viewpoint{zoom: 1}
+	if ok {
+		cellW, cellH := 2/float32(c.rs.config.GridWidth), 2/float32(c.rs.config.GridHeight)
+		x0 := float32(minX-fitMargin)*cellW - 1
+		y0 := float32(minY-fitMargin)*cellH - 1
+		x1 := float32(maxX+1+fitMargin)*cellW - 1
+		y1 := float32(maxY+1+fitMargin)*cellH - 1
+		to.x, to.y = (x0+x1)/2, (y0+y1)/2
+		to.zoom = 2 / max(x1-x0, y1-y0)
+		to.clamp(c.rs.maxZoom())
+	}
+	c.from = c.viewpoint
+	c.to = to
+	c.elapsed = 0
+}
+
+// zoomAt multiplies the zoom by factor while keeping the world point under
+// the given normalized device coordinates fixed on screen.
+func (c *camera) zoomAt(x, y, factor float32) {
+	c.to = nil
+	wx, wy := c.toWorld(x, y)
+	c.zoom = clamp(c.zoom*factor, 1, c.rs.maxZoom())
+	c.x = wx - x/c.zoom
+	c.y = wy - y/c.zoom
+	c.clamp()
+}
+
+// drag moves the view so the world follows a cursor that moved by (dx, dy)
+// in normalized device coordinates.
+func (c *camera) drag(dx, dy float32) {
+	c.to = nil
+	c.x -= dx / c.zoom
+	c.y -= dy / c.zoom
+	c.clamp()
+}
+
+// cellAt returns the grid coordinates of the cell under a point in normalized
+// device coordinates, and false if the point is off the board.
+func (c *camera) cellAt(x, y float32) (int, int, bool) {
+	wx, wy := c.toWorld(x, y)
+	if wx < -1 || wy < -1 || wx >= 1 || wy >= 1 {
+		return 0, 0, false
+	}
+	if render.Tiling == life.Triangles {
+		return render.TriangleAt(wx, wy, c.rs.config.GridWidth, c.rs.config.GridHeight)
+	}
+	return int((wx + 1) / 2 * float32(c.rs.config.GridWidth)), int((wy + 1) / 2 * float32(c.rs.config.GridHeight)), true
+}
@@ -0,0 +1,52 @@
+package app
+
+import "testing"
+
+// TestViewCells checks the cells in view at the view's edges: a cell is in
+// view if its centre is, one on the edge included.
+func TestViewCells(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 32, 20
+	cam := testRun(t, cfg).newCamera()
+	for _, c := range []struct {
+		name                   string
+		x, y, zoom             float32
+		minX, minY, maxX, maxY int
+	}{
+		{"the whole board", 0, 0, 1, 0, 0, 31, 19},
+		{"the middle half", 0, 0, 2, 8, 5, 23, 14},
+		// Cell 7's centre is on the left edge and cell 15's on the right, as
+		// rows 7 and 12's are on the bottom and top.
+		{"edges on centres", -0.28125, 0, 4, 7, 7, 15, 12},
+		// A quarter of a cell to the right, cell 7's centre is out of view
+		// and cell 16's not yet in it.
+		{"edges past centres", -0.28125 + 1.0/64, 0, 4, 8, 7, 15, 12},
+		{"the bottom left", -0.75, -0.75, 4, 0, 0, 7, 4},
+	} {
+		cam.x, cam.y, cam.zoom = c.x, c.y, c.zoom
+		minX, minY, maxX, maxY, ok := cam.viewCells()
+		if !ok || minX != c.minX || minY != c.minY || maxX != c.maxX || maxY != c.maxY {
+			t.Errorf("%s: view has cells %d, %d to %d, %d, want %d, %d to %d, %d", c.name, minX, minY, maxX, maxY, c.minX, c.minY, c.maxX, c.maxY)
+		}
+	}
+}
+
+// TestViewCellsExport exports the view as export-view does, for a cell on
+// each edge of the view to be in it and the cells just past them not.
+func TestViewCellsExport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 32, 20
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 1)[0]
+	sim.Clear()
+	cam := rs.newCamera()
+	cam.x, cam.y, cam.zoom = -0.28125, 0, 4
+	minX, minY, maxX, maxY, _ := cam.viewCells()
+	for _, c := range [][2]int{{minX, minY}, {maxX, maxY}, {minX - 1, minY}, {maxX + 1, maxY}, {minX, minY - 1}, {maxX, maxY + 1}} {
+		sim.Cells.Set(c[0], c[1], true)
+	}
+	p := sim.Cells.Region(minX, minY, maxX, maxY)
+	if w, h := p.Size(); len(p.Cells) != 2 || w != maxX-minX+1 || h != maxY-minY+1 {
+		t.Fatalf("the view exports %d cells over %dx%d, want its two corners over %dx%d", len(p.Cells), w, h, maxX-minX+1, maxY-minY+1)
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/render"
+)
+
+// capability is one of render.Caps' features, named as -print-caps lists it.
+type capability struct {
+	name string
+	have bool
+}
+
+func capabilities(caps render.Caps) []capability {
+	return []capability{
+		{"object labels", caps.ObjectLabels},
+		{"debug output", caps.DebugOutput},
+		{"polygon mode", caps.PolygonMode},
+		{"program point size", caps.ProgramPointSize},
+		{"shader storage buffers", caps.ShaderStorage},
+		{"compute shaders", caps.Compute},
+		{"buffer storage", caps.BufferStorage},
+		{"timer queries", caps.TimerQuery},
+		{"sRGB framebuffers", caps.SRGBFramebuffer},
+		{"multisampling", caps.MaxSamples > 1},
+	}
+}
+
+// windowCaps is what the window, rather than the context, has of the
+// features asked for.
+type windowCaps struct {
+	debugContext, transparent bool
+}
+
+// feature is an optional feature, whether it's on, and why not if it's
+// off. asked is set if the flags asked for it, so that it being off is
+// warned about.
+type feature struct {
+	name      string
+	on, asked bool
+	why       string
+}
+
+// chooseFeatures works out which of the optional features c asks for,
+// or would use if it could, caps and the window have: each one is on, or
+// off with why.
+func chooseFeatures(c *Config, caps render.Caps, w windowCaps) []feature {
+	var fs []feature
+	add := func(name string, asked, on bool, why string) {
+		if on {
+			why = ""
+		}
+		fs = append(fs, feature{name: name, on: on, asked: asked, why: why})
+	}
+	add("wireframe (z)", false, caps.PolygonMode, "OpenGL ES has no polygon mode")
+	add("object labels", false, caps.ObjectLabels, "needs desktop OpenGL 4.3 or KHR_debug")
+	add("GPU timing", false, caps.TimerQuery, "needs desktop OpenGL 3.3 or ARB_timer_query")
+	if c.GLDebug {
+		if !caps.DebugOutput {
+			add("-gl-debug", true, false, "debug output needs desktop OpenGL 4.3 or KHR_debug")
+		} else {
+			add("-gl-debug", true, w.debugContext, "the driver didn't make a debug context")
+		}
+	}
+	if c.GLDebugPanic {
+		add("-gl-debug-panic", true, c.GLDebug && caps.DebugOutput && w.debugContext, "needs -gl-debug's debug output")
+	}
+	if c.Widget {
+		add("-widget", true, w.transparent, "the window system has no transparent framebuffers, so it's a normal window")
+	}
+	return fs
+}
+
+// warnFeatures logs the features asked for but off, and at info level the
+// ones off that weren't asked for.
+func warnFeatures(fs []feature) {
+	for _, f := range fs {
+		switch {
+		case f.on:
+		case f.asked:
+			slog.Warn(f.name+" is off", "why", f.why)
+		default:
+			slog.Info(f.name+" is off", "why", f.why)
+		}
+	}
+}
+
+// featureOn reports whether the named feature is on in fs.
+func featureOn(fs []feature, name string) bool {
+	for _, f := range fs {
+		if f.name == name {
+			return f.on
+		}
+	}
+	return false
+}
+
+// printCaps opens a hidden window to write what the driver offers, and what
+// the configuration would get of it, to w.
+func (rs *runState) printCaps(w io.Writer) error {
+	window, err := rs.initGlfw(false)
+	if err != nil {
+		return err
+	}
+	defer glfw.Terminate()
+	if err := rs.initOpenGL(); err != nil {
+		return err
+	}
+	env, caps := render.CurrentEnvironment(), render.CurrentCaps()
+	api := "core"
+	if caps.ES {
+		api = "ES"
+	}
+	fmt.Fprintf(w, "OpenGL %d.%d %s: %s, %s, %s\n", caps.Major, caps.Minor, api, env.Vendor, env.Renderer, env.Version)
+	fmt.Fprintf(w, "Asked for:   %s\n", strings.Join(rs.askedContextVersions(), ", "))
+	fmt.Fprintf(w, "GLSL:        %s; shaders get %s\n", env.GLSLVersion, strings.ReplaceAll(render.GLSLVersion(), "\n", " "))
+	fmt.Fprintf(w, "Renderer:    %s\n", rs.boardDrawing())
+	fmt.Fprintf(w, "Limits:      textures up to %d, viewports up to %dx%d, %d samples, %d extensions\n", env.MaxTextureSize, env.MaxViewport[0], env.MaxViewport[1], caps.MaxSamples, env.Extensions)
+	fmt.Fprintln(w, "\nCapabilities:")
+	wc := windowCaps{debugContext: render.DebugContext(), transparent: window.GetAttrib(glfw.TransparentFramebuffer) == glfw.True}
+	for _, c := range capabilities(caps) {
+		fmt.Fprintf(w, "  %-24s %s\n", c.name, yesNo(c.have))
+	}
+	fmt.Fprintf(w, "  %-24s %s\n", "transparent framebuffers", yesNo(wc.transparent))
+	fmt.Fprintln(w, "\nFeatures:")
+	for _, f := range chooseFeatures(&rs.config, caps, wc) {
+		state := "on"
+		if !f.on {
+			state = "off: " + f.why
+		}
+		fmt.Fprintf(w, "  %-24s %s\n", f.name, state)
+	}
+	return nil
+}
+
+// askedContextVersions are the contexts createWindow asks for, in order.
+func (rs *runState) askedContextVersions() []string {
+	versions, api := rs.contextVersions()
+	var names []string
+	for _, v := range versions {
+		names = append(names, fmt.Sprintf("%d.%d %s", v[0], v[1], api))
+	}
+	return names
+}
+
+// boardDrawing describes how the GL renderer will draw the boards.
+func (rs *runState) boardDrawing() string {
+	if rs.config.FragShader != "" {
+		return "gl: a quad per cell, shaded by " + rs.config.FragShader
+	}
+	return "gl: a quad per cell, shaded by " + rs.config.ShaderDir + "'s shaders or the built-in ones, or a point per live cell once cells are 2 pixels across or less"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"opengl/render"
+)
+
+// TestChooseFeatures checks what's asked for of made up contexts and
+// windows is turned off, with why, where they haven't got it, rather than
+// left to fail.
+func TestChooseFeatures(t *testing.T) {
+	desktop := render.NewCaps(4, 6, false, nil, 8)
+	old := render.NewCaps(3, 3, false, nil, 4)
+	es := render.NewCaps(3, 0, true, nil, 4)
+	for _, c := range []struct {
+		name     string
+		debug    bool
+		panics   bool
+		widget   bool
+		caps     render.Caps
+		window   windowCaps
+		on, off  []string
+		whyOff   string
+		askedOff string
+	}{
+		{"4.6 with everything", true, true, true, desktop, windowCaps{debugContext: true, transparent: true},
+			[]string{"wireframe (z)", "object labels", "GPU timing", "-gl-debug", "-gl-debug-panic", "-widget"}, nil, "", ""},
+		{"3.3", true, true, false, old, windowCaps{debugContext: true},
+			[]string{"wireframe (z)", "GPU timing"}, []string{"object labels", "-gl-debug", "-gl-debug-panic"},
+			"debug output needs desktop OpenGL 4.3 or KHR_debug", "-gl-debug"},
+		{"4.6 without a debug context", true, false, false, desktop, windowCaps{},
+			nil, []string{"-gl-debug"}, "the driver didn't make a debug context", "-gl-debug"},
+		{"ES", false, false, true, es, windowCaps{},
+			nil, []string{"wireframe (z)", "object labels", "GPU timing", "-widget"},
+			"the window system has no transparent framebuffers, so it's a normal window", "-widget"},
+	} {
+		cfg := DefaultConfig()
+		cfg.GLDebug, cfg.GLDebugPanic, cfg.Widget = c.debug, c.panics, c.widget
+		fs := chooseFeatures(&cfg, c.caps, c.window)
+		for _, name := range c.on {
+			if !featureOn(fs, name) {
+				t.Errorf("%s: %s is off", c.name, name)
+			}
+		}
+		for _, name := range c.off {
+			if featureOn(fs, name) {
+				t.Errorf("%s: %s is on", c.name, name)
+			}
+		}
+		for _, f := range fs {
+			if f.name == c.askedOff && (!f.asked || f.why != c.whyOff) {
+				t.Errorf("%s: %s is off because %q, asked %v, want because %q", c.name, f.name, f.why, f.asked, c.whyOff)
+			}
+			if f.on && f.why != "" {
+				t.Errorf("%s: %s is on, with a why", c.name, f.name)
+			}
+		}
+	}
+}
+
+// TestWarnFeatures checks features off that were asked for are warned
+// about, and the rest are only noted.
+func TestWarnFeatures(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg := DefaultConfig()
+	cfg.Widget = true
+	warnFeatures(chooseFeatures(&cfg, render.NewCaps(3, 0, true, nil, 4), windowCaps{}))
+	for _, want := range []string{
+		`level=WARN msg="-widget is off" why="the window system has no transparent framebuffers, so it's a normal window"`,
+		`level=INFO msg="wireframe (z) is off" why="OpenGL ES has no polygon mode"`,
+	} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("logged\n%s\nwant a line with %s", logged.String(), want)
+		}
+	}
+}
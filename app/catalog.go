@@ -0,0 +1,74 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"opengl/life"
+)
+
+// patternDirPath returns the directory the pattern picker lists.
+func (rs *runState) patternDirPath() (string, error) {
+	if rs.config.PatternDir != "" {
+		return rs.config.PatternDir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "patterns"), nil
+}
+
+// catalogEntry is a pattern file in the pattern directory. It isn't parsed
+// until it's first needed, so a large directory is quick to scan.
+type catalogEntry struct {
+	rs *runState
+
+	name string
+	path string
+
+	loaded  bool
+	pattern life.Pattern
+	err     error
+}
+
+// load parses the entry's file, if it hasn't been already.
+func (e *catalogEntry) load() {
+	if e.loaded {
+		return
+	}
+	e.loaded = true
+	e.pattern, _, e.err = e.rs.loadPattern(e.path)
+}
+
+// scanCatalog lists the .rle and .cells files in dir, by name. A directory
+// that doesn't exist has nothing in it.
+func (rs *runState) scanCatalog(dir string) ([]*catalogEntry, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*catalogEntry
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		if f.IsDir() || ext != ".rle" && ext != ".cells" {
+			continue
+		}
+		entries = append(entries, &catalogEntry{
+			rs:   rs,
+			name: strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())),
+			path: filepath.Join(dir, f.Name()),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].name) < strings.ToLower(entries[j].name)
+	})
+	return entries, nil
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkpointPath returns the directory checkpoints are written to.
+func (rs *runState) checkpointPath() (string, error) {
+	if rs.config.CheckpointDir != "" {
+		return rs.config.CheckpointDir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "checkpoints"), nil
+}
+
+// checkpointer writes checkpoints from a goroutine, one at a time, so a
+// slow disk doesn't hold up the simulation.
+type checkpointer struct {
+	rs *runState
+
+	dir  string
+	keep int
+	// busy holds a token while a checkpoint is being written.
+	busy chan struct{}
+
+	// mu guards written, the checkpoints written by this run, oldest first,
+	// for the timeline to seek from, and epoch, which counts the times the
+	// boards have been edited or started afresh. Only checkpoints written
+	// since then lead up to the boards.
+	mu      sync.Mutex
+	written []writtenCheckpoint
+	epoch   int
+}
+
+type writtenCheckpoint struct {
+	path              string
+	generation, epoch int
+}
+
+func (rs *runState) newCheckpointer(dir string, keep int) (*checkpointer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &checkpointer{rs: rs, dir: dir, keep: max(keep, 1), busy: make(chan struct{}, 1)}, nil
+}
+
+// save writes st to a checkpoint named after its generation, then deletes
+// all but the most recent ones. If the last checkpoint is still being
+// written, this one is skipped.
+func (c *checkpointer) save(st state) {
+	select {
+	case c.busy <- struct{}{}:
+	default:
+		slog.Warn("skipped a checkpoint; the last one is still being written", "generation", st.Boards[0].Generation)
+		return
+	}
+	c.mu.Lock()
+	epoch := c.epoch
+	c.mu.Unlock()
+	go func() {
+		defer func() { <-c.busy }()
+		generation := st.Boards[0].Generation
+		path := filepath.Join(c.dir, fmt.Sprintf("checkpoint-%09d.lifez", generation))
+		if err := writeState(path, st); err != nil {
+			c.rs.reportError("checkpoint failed", err, "path", path)
+			return
+		}
+		c.mu.Lock()
+		c.forget(path)
+		c.written = append(c.written, writtenCheckpoint{path, generation, epoch})
+		c.mu.Unlock()
+		if err := c.prune(); err != nil {
+			slog.Warn("removing old checkpoints failed", "err", err)
+		}
+	}()
+}
+
+// prune deletes all but the most recently written checkpoints.
+func (c *checkpointer) prune() error {
+	paths, err := checkpoints(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths[:max(len(paths)-c.keep, 0)] {
+		c.mu.Lock()
+		c.forget(path)
+		c.mu.Unlock()
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forget drops path from the checkpoints written, with mu held.
+func (c *checkpointer) forget(path string) {
+	kept := c.written[:0]
+	for _, w := range c.written {
+		if w.path != path {
+			kept = append(kept, w)
+		}
+	}
+	c.written = kept
+}
+
+// restart marks the checkpoints written so far as not leading up to the
+// boards, which have been edited or started afresh.
+func (c *checkpointer) restart() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epoch++
+}
+
+// latestBefore returns the latest checkpoint written since the boards were
+// last edited or started afresh that's no later than generation at,
+// reporting false if there's none.
+func (c *checkpointer) latestBefore(at int) (path string, generation int, ok bool) {
+	if c == nil {
+		return "", 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.written {
+		if w.epoch == c.epoch && w.generation <= at && (!ok || w.generation > generation) {
+			path, generation, ok = w.path, w.generation, true
+		}
+	}
+	return path, generation, ok
+}
+
+// wait waits for any checkpoint being written.
+func (c *checkpointer) wait() {
+	c.busy <- struct{}{}
+	<-c.busy
+}
+
+// checkpoints lists the checkpoints in dir, oldest first. Reseeding starts
+// the generations again, so they're ordered by when they were written
+// rather than by name.
+func checkpoints(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "checkpoint-*.lifez"))
+	if err != nil {
+		return nil, err
+	}
+	written := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			written[path] = fi.ModTime()
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return written[paths[i]].Before(written[paths[j]]) })
+	return paths, nil
+}
+
+// latestCheckpoint returns the most recently written checkpoint in dir.
+func latestCheckpoint(dir string) (string, error) {
+	paths, err := checkpoints(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no checkpoints in %s", dir)
+	}
+	return paths[len(paths)-1], nil
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	// paceReportEvery is how often a rate that can't be kept up is warned
+	// about.
+	paceReportEvery = 5 * time.Second
+	// idleWait is the longest the window waits for input while idle before
+	// drawing a frame anyway.
+	idleWait = 500 * time.Millisecond
+)
+
+// stepClock is the fixed timestep the boards step at. The real time that
+// passes between frames accumulates, and is spent a whole interval at a
+// time on generations, however many or few fall due each frame. After a
+// stall, no more than maxSteps generations' worth is kept to catch up on,
+// so a machine that can't keep up falls behind rather than spending ever
+// longer catching up.
+type stepClock struct {
+	interval    time.Duration
+	maxSteps    int
+	accumulated time.Duration
+	last        time.Time
+	// dropped is the time given up on since reported.
+	dropped  time.Duration
+	reported time.Time
+}
+
+func newStepClock(rate float64, maxSteps int, now time.Time) *stepClock {
+	return &stepClock{interval: interval(rate), maxSteps: maxSteps, last: now, reported: now}
+}
+
+// tick adds the time since the last tick, warning every so often if time
+// has had to be given up on.
+func (c *stepClock) tick(now time.Time) {
+	c.accumulated += now.Sub(c.last)
+	c.last = now
+	if limit := time.Duration(c.maxSteps) * c.interval; c.accumulated > limit {
+		c.dropped += c.accumulated - limit
+		c.accumulated = limit
+	}
+	if now.Sub(c.reported) >= paceReportEvery {
+		if c.dropped > 0 {
+			slog.Warn("can't keep up with the generation rate", "rate", float64(time.Second)/float64(c.interval), "behind", int(c.dropped/c.interval), "in", now.Sub(c.reported).Round(time.Second))
+		}
+		c.dropped, c.reported = 0, now
+	}
+}
+
+// due returns how many generations have fallen due.
+func (c *stepClock) due() int {
+	return int(c.accumulated / c.interval)
+}
+
+// spend spends n generations' worth of the time accumulated.
+func (c *stepClock) spend(n int) {
+	c.accumulated = max(c.accumulated-time.Duration(n)*c.interval, 0)
+}
+
+// alpha returns how far through the interval to the next generation the
+// clock is, from 0 to 1, to draw in-between frames at.
+func (c *stepClock) alpha() float64 {
+	return min(float64(c.accumulated)/float64(c.interval), 1)
+}
+
+// reset throws away the time accumulated, while paused or stepping by
+// other means.
+func (c *stepClock) reset(now time.Time) {
+	c.accumulated, c.last = 0, now
+}
+
+// setRate changes the interval to rate's, keeping no more than an
+// interval accumulated so the next generation isn't further off than one.
+func (c *stepClock) setRate(rate float64) {
+	c.interval = interval(rate)
+	c.accumulated = min(c.accumulated, c.interval)
+}
+
+// framePacer paces frames at a rate. Frames are due an interval after the
+// last was due, not after it finished, so the rate holds on average. A
+// frame that overruns isn't followed by a sleep, and once frames are more
+// than one behind the schedule starts again from now rather than rush
+// through frames to catch up.
+//
+// While idle, with nothing on screen changing but for input, or in the
+// background, if that's slower, frames instead wait for input with
+// waitEvents, which returns as soon as there is some.
+type framePacer struct {
+	interval time.Duration
+	// background is the interval between frames while the window is
+	// unfocused or iconified.
+	background   time.Duration
+	idle, hidden bool
+	// slow is set while frames wait for input rather than keep to interval.
+	slow       bool
+	waitEvents func(timeout time.Duration)
+	next       time.Time
+	// frames and overruns count the frames since reported, and those that
+	// took longer than the interval.
+	frames, overruns int
+	reported         time.Time
+}
+
+// newFramePacer paces frames at rate, or at backgroundRate in the
+// background, if it's more than 0, waiting for input with waitEvents while
+// idle or in the background.
+func newFramePacer(rate, backgroundRate float64, waitEvents func(time.Duration), now time.Time) *framePacer {
+	d := interval(rate)
+	p := &framePacer{interval: d, waitEvents: waitEvents, next: now.Add(d), reported: now}
+	if backgroundRate > 0 {
+		p.background = interval(backgroundRate)
+	}
+	return p
+}
+
+// slowInterval returns how long to wait for input before the next frame
+// while idle or in the background, or 0 if frames keep to interval.
+func (p *framePacer) slowInterval() time.Duration {
+	switch {
+	case p.idle:
+		return idleWait
+	case p.hidden && p.background > p.interval:
+		return p.background
+	}
+	return 0
+}
+
+// wait sleeps until the next frame is due, if it isn't already, warning
+// every so often if most frames have overrun.
+func (p *framePacer) wait() {
+	if d := p.pace(time.Now()); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// pace schedules the frame after the one finishing at now, returning how
+// long to sleep until it's due. Frames waiting for input have waited by the
+// time it returns, with nothing left to sleep.
+func (p *framePacer) pace(now time.Time) time.Duration {
+	if d := p.slowInterval(); d > 0 {
+		p.slow = true
+		p.waitEvents(d)
+		return 0
+	}
+	if p.slow {
+		// Frames start afresh, rather than make up for the wait.
+		p.slow = false
+		p.next, p.frames, p.overruns, p.reported = now.Add(p.interval), 0, 0, now
+	}
+	p.frames++
+	wait := max(p.next.Sub(now), 0)
+	if wait == 0 {
+		p.overruns++
+	}
+	p.next = p.next.Add(p.interval)
+	if p.next.Before(now) {
+		p.next = now.Add(p.interval)
+	}
+	if now.Sub(p.reported) >= paceReportEvery {
+		if p.overruns > p.frames/2 {
+			slog.Warn("can't keep up with the frame rate", "rate", float64(time.Second)/float64(p.interval), "overran", p.overruns, "frames", p.frames)
+		}
+		p.frames, p.overruns, p.reported = 0, 0, now
+	}
+	return wait
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStepClock ticks a 10 generation a second clock through uneven frames,
+// for the generations to fall due at the rate all the same.
+func TestStepClock(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := newStepClock(10, 5, start)
+	now := start
+	steps := 0
+	for i, d := range []time.Duration{16, 16, 70, 3, 250, 1, 45, 99} {
+		now = now.Add(d * time.Millisecond)
+		c.tick(now)
+		n := c.due()
+		c.spend(n)
+		steps += n
+		if want := int(now.Sub(start) / (100 * time.Millisecond)); steps != want {
+			t.Errorf("frame %d: %d generations after %v, want %d", i, steps, now.Sub(start), want)
+		}
+		if a, want := c.alpha(), float64(now.Sub(start)%(100*time.Millisecond))/float64(100*time.Millisecond); a < want-1e-9 || a > want+1e-9 {
+			t.Errorf("frame %d: alpha %v, want %v", i, a, want)
+		}
+	}
+}
+
+// TestStepClockCatchUp stalls the clock for a second and a half, for it to
+// catch up on no more than maxSteps generations, and to say so.
+func TestStepClockCatchUp(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	start := time.Unix(1000, 0)
+	c := newStepClock(10, 5, start)
+	c.tick(start.Add(1500 * time.Millisecond))
+	if n := c.due(); n != 5 {
+		t.Errorf("%d generations due after a stall, want the cap of 5", n)
+	}
+	c.spend(c.due())
+	if c.alpha() != 0 {
+		t.Errorf("alpha %v after catching up, want 0", c.alpha())
+	}
+	if logged.Len() != 0 {
+		t.Errorf("warned before a report was due: %s", logged.String())
+	}
+	for now := start.Add(1600 * time.Millisecond); !now.After(start.Add(paceReportEvery)); now = now.Add(100 * time.Millisecond) {
+		c.tick(now)
+		c.spend(c.due())
+	}
+	if !strings.Contains(logged.String(), "can't keep up with the generation rate") || !strings.Contains(logged.String(), "behind=10") {
+		t.Errorf("the stall was reported as %q, want 10 generations behind", logged.String())
+	}
+}
+
+func TestStepClockRate(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := newStepClock(10, 5, start)
+	c.tick(start.Add(350 * time.Millisecond))
+	// Going faster, what's accumulated is cut to an interval of the new
+	// rate, rather than turned into a burst of generations.
+	c.setRate(20)
+	if n := c.due(); n != 1 {
+		t.Errorf("%d generations due on speeding up, want 1", n)
+	}
+	c.reset(start.Add(time.Second))
+	c.tick(start.Add(1120 * time.Millisecond))
+	if n := c.due(); n != 2 {
+		t.Errorf("%d generations due 120ms after a reset, want 2", n)
+	}
+}
+
+// TestFramePacer runs frames of uneven lengths at 10 a second, for the
+// sleeps between them to keep to the rate, a slow frame to go unslept
+// after, and one more than a frame behind to start the schedule afresh.
+func TestFramePacer(t *testing.T) {
+	start := time.Unix(1000, 0)
+	p := newFramePacer(10, 0, nil, start)
+	now := start
+	for i, f := range []struct{ took, wait time.Duration }{
+		{30, 70},
+		{130, 0},
+		// Caught up, it's back on schedule.
+		{20, 50},
+		{350, 0},
+		// Afresh, it's an interval after the slow frame.
+		{10, 90},
+	} {
+		now = now.Add(f.took * time.Millisecond)
+		wait := p.pace(now)
+		if wait != f.wait*time.Millisecond {
+			t.Errorf("frame %d: slept %v, want %v", i, wait, f.wait*time.Millisecond)
+		}
+		now = now.Add(wait)
+	}
+	if p.overruns != 2 || p.frames != 5 {
+		t.Errorf("counted %d overruns in %d frames, want 2 in 5", p.overruns, p.frames)
+	}
+}
+
+func TestFramePacerWarns(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	start := time.Unix(1000, 0)
+	p := newFramePacer(10, 0, nil, start)
+	for now := start; now.Before(start.Add(paceReportEvery)); {
+		now = now.Add(150 * time.Millisecond)
+		now = now.Add(p.pace(now))
+	}
+	if !strings.Contains(logged.String(), "can't keep up with the frame rate") {
+		t.Errorf("slow frames weren't warned about: %q", logged.String())
+	}
+}
+
+// TestFramePacerIdle checks idle frames wait for input instead of
+// sleeping, and the schedule starts afresh after.
+func TestFramePacerIdle(t *testing.T) {
+	var waited []time.Duration
+	start := time.Unix(1000, 0)
+	p := newFramePacer(10, 2, func(d time.Duration) { waited = append(waited, d) }, start)
+	p.idle = true
+	if wait := p.pace(start.Add(10 * time.Millisecond)); wait != 0 || len(waited) != 1 || waited[0] != idleWait {
+		t.Errorf("idle, it slept %v and waited for input for %v", wait, waited)
+	}
+	p.idle, p.hidden = false, true
+	if p.pace(start.Add(time.Second)); len(waited) != 2 || waited[1] != 500*time.Millisecond {
+		t.Errorf("in the background, it waited for input for %v, want the background rate's 500ms", waited)
+	}
+	p.hidden = false
+	if wait := p.pace(start.Add(5 * time.Second)); wait != 100*time.Millisecond || p.overruns != 0 {
+		t.Errorf("back from waiting, it slept %v with %d overruns, want a whole interval", wait, p.overruns)
+	}
+}
+
+// TestFramePacerTransitions moves a pacer between running, paused, in the
+// background and back, for each frame to wait for input or sleep as the
+// state it's in has it, and to be back to full rate on the first frame
+// after.
+func TestFramePacerTransitions(t *testing.T) {
+	type paceStep struct {
+		idle, hidden  bool
+		waited, slept time.Duration
+	}
+	for _, c := range []struct {
+		name       string
+		background float64
+		steps      []paceStep
+	}{
+		{"background 2", 2, []paceStep{
+			{false, false, 0, 90 * time.Millisecond},
+			{true, false, idleWait, 0},
+			{true, true, idleWait, 0},
+			{false, true, 500 * time.Millisecond, 0},
+			{false, false, 0, 100 * time.Millisecond},
+			{false, false, 0, 90 * time.Millisecond},
+		}},
+		// A background rate faster than the rate leaves frames at the rate,
+		// as does none.
+		{"background 60", 60, []paceStep{
+			{false, true, 0, 90 * time.Millisecond},
+			{true, true, idleWait, 0},
+			{false, false, 0, 100 * time.Millisecond},
+		}},
+		{"no background", 0, []paceStep{
+			{false, true, 0, 90 * time.Millisecond},
+			{false, true, 0, 90 * time.Millisecond},
+		}},
+	} {
+		var waited time.Duration
+		start := time.Unix(1000, 0)
+		p := newFramePacer(10, c.background, func(d time.Duration) { waited = d }, start)
+		now := start
+		for i, s := range c.steps {
+			now = now.Add(10 * time.Millisecond)
+			waited = 0
+			p.idle, p.hidden = s.idle, s.hidden
+			slept := p.pace(now)
+			if waited != s.waited || slept != s.slept {
+				t.Errorf("%s, frame %d (idle %v, hidden %v): waited for input for %v and slept %v, want %v and %v", c.name, i, s.idle, s.hidden, waited, slept, s.waited, s.slept)
+			}
+			now = now.Add(waited + slept)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import "opengl/life"
+
+// clusterCounter counts the first board's clusters of touching live cells
+// every so many generations, as counting them scans the whole board.
+type clusterCounter struct {
+	rs *runState
+
+	every int
+	// count is the clusters there were when they were last counted, and
+	// counted whether they have been.
+	count   int
+	counted bool
+}
+
+// stepped counts sim's clusters if it's been -clusters-every generations,
+// or they've yet to be counted.
+func (c *clusterCounter) stepped(sim *life.Simulation) {
+	if c == nil || c.counted && sim.Generation%c.every != 0 {
+		return
+	}
+	c.count, c.counted = life.Components(sim.Cells, c.rs.config.Wrap), true
+}
+
+// latest returns the clusters last counted, reporting false if they aren't.
+func (c *clusterCounter) latest() (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	return c.count, c.counted
+}
@@ -0,0 +1,481 @@
+// Package app is the Game of Life as a program: a window of one or more
+// boards, with the overlays, recorders and console, configured by a Config
+// and started by Run.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// Config is everything about how the app runs. DefaultConfig returns one
+// with the defaults filled in, to be changed with options or by setting
+// fields directly before passing it to Run.
+type Config struct {
+	// GridWidth and GridHeight are the board's size in cells.
+	GridWidth, GridHeight int
+	// WindowWidth and WindowHeight are the window's size in screen
+	// coordinates.
+	WindowWidth, WindowHeight int
+	// ResetWindow opens the window where the window system puts it, rather
+	// than where it was when the game last quit.
+	ResetWindow bool
+	// TickRate is the generations a second the simulation starts at.
+	TickRate float64
+	// FrameRate is the frames a second the window is drawn and polled for
+	// input at, however fast the boards step. With VSync, frames are also
+	// kept in step with the display's refresh.
+	FrameRate float64
+	// BackgroundFPS is the frames a second the window is drawn at while
+	// it's unfocused or iconified, if that's slower; 0 keeps to FrameRate.
+	BackgroundFPS float64
+	VSync         bool
+	// LODThreshold is the on-screen cell size, in pixels, below which a
+	// board is drawn smoothly as how dense its cells are; 0 draws every
+	// cell however small.
+	LODThreshold float64
+	// ErrorTimeout is how long the error banner shows a failure the
+	// program carried on after; 0 keeps it up until it's dismissed.
+	ErrorTimeout time.Duration
+	// Windows is how many windows onto the boards to open, each after the
+	// first with a camera of its own.
+	Windows int
+	// Rules is the rule for every view, or a comma-separated rule per view.
+	Rules string
+	// RuleScript, if set, is a file with a rule written as an expression,
+	// as life.ParseRuleScript takes, for every view in place of Rules.
+	RuleScript string
+	// Automaton, if set, is a registered life.Automaton for every view to
+	// run in place of Rules, by name or as name:config, or "list" to list
+	// them instead of running.
+	Automaton string
+	// GollyRule, if set, is a Golly .rule file with a rule table, as
+	// life.ParseGollyRule takes, for every view to run in place of Rules.
+	GollyRule string
+	// Seed seeds the random board; 0 seeds it from the time.
+	Seed int64
+	// Density is the fraction of cells alive in a random board.
+	Density float64
+	// Wrap wraps the board's edges around into a torus.
+	Wrap bool
+	// Colour is the colour of live cells, other than a team's, and
+	// Background the colour behind them.
+	Colour, Background color.RGBA
+
+	// Diff, if set, is two state files whose difference is printed and
+	// shown on the board.
+	Diff [2]string
+	// ListPatterns prints the built-in patterns instead of running.
+	ListPatterns bool
+	// PrintCaps prints what the OpenGL driver offers instead of running.
+	PrintCaps bool
+	// Headless runs the boards without a window or OpenGL.
+	Headless bool
+	// Renderer is the backend the boards are drawn with, one of renderers.
+	Renderer string
+	// TerminalBraille draws with Braille dots rather than half blocks with
+	// the terminal renderer.
+	TerminalBraille bool
+	// TerminalGraphics is the graphics protocol the terminal renderer draws
+	// pictures with, one of terminalGraphics: auto picks sixel or kitty if
+	// the environment says the terminal has it, and off draws characters.
+	TerminalGraphics string
+
+	History           int
+	Rewind            int
+	Follow            bool
+	CompareSeeds      string
+	Views             string
+	RenderOut         string
+	RenderSize        string
+	Generations       int
+	Icon              string
+	FragShader        string
+	ShaderDir         string
+	StepPauses        bool
+	HelpPauses        bool
+	Edit              bool
+	EditPauses        bool
+	Bindings          string
+	ScreenshotScale   int
+	ScreenshotDir     string
+	Pattern           string
+	PatternRule       bool
+	PatternLimit      int
+	PatternDir        string
+	PatternCache      bool
+	ImportPBM         string
+	Load              string
+	Scenario          string
+	CensusEvery       int
+	ClustersEvery     int
+	CheckpointEvery   int
+	CheckpointDir     string
+	CheckpointKeep    int
+	Demo              bool
+	DemoDuration      time.Duration
+	LogFile           string
+	LogLevel          string
+	Versus            bool
+	VersusCells       int
+	VersusGenerations int
+	Gamepad           bool
+	GamepadDeadZone   float64
+	GamepadMap        string
+	GIFMaxFrames      int
+	GIFMaxSize        int
+	RecordFrames      string
+	RecordSize        string
+	RecordReplay      string
+	PlayReplay        string
+	SavesDir          string
+	SeedImage         string
+	SeedThreshold     float64
+	SeedDither        bool
+	SeedInvert        bool
+	SeedFit           string
+	Resume            bool
+	AutosaveInterval  time.Duration
+	StatsOut          string
+	LEDOut            string
+	LEDSize           string
+	LEDProtocol       string
+	LEDUniverse       int
+	Audio             bool
+	AudioVolume       float64
+	Timelapse         int
+	TorusMajor        int
+	TorusMinor        int
+	RecordVideo       string
+	VideoFPS          float64
+	VideoDrop         bool
+	Widget            bool
+	WidgetSize        string
+	WidgetPos         string
+	ClickThrough      bool
+	GLVersion         string
+	PProf             string
+	Metrics           string
+	API               string
+	GRPC              string
+	Host              string
+	Join              string
+	GLDebug           bool
+	GLDebugSeverity   string
+	GLDebugPanic      bool
+
+	// hooks are added by OnGeneration.
+	hooks []GenerationHook
+	// stats, if set by WithStats, is the history to keep.
+	stats *Stats
+}
+
+// renderers are the backends Config.Renderer can name.
+var renderers = []string{"gl", "terminal"}
+
+// terminalGraphics are the protocols Config.TerminalGraphics can name.
+var terminalGraphics = []string{"auto", "sixel", "kitty", "off"}
+
+// An Option changes a Config.
+type Option func(*Config)
+
+// WithGridSize sets the board's size in cells.
+func WithGridSize(width, height int) Option {
+	return func(c *Config) { c.GridWidth, c.GridHeight = width, height }
+}
+
+// WithWindowSize sets the window's size.
+func WithWindowSize(width, height int) Option {
+	return func(c *Config) { c.WindowWidth, c.WindowHeight = width, height }
+}
+
+// WithTickRate sets the generations a second the simulation starts at.
+func WithTickRate(rate float64) Option {
+	return func(c *Config) { c.TickRate = rate }
+}
+
+// WithRule sets the rule, e.g. B36/S23.
+func WithRule(rule string) Option {
+	return func(c *Config) { c.Rules = rule }
+}
+
+// WithRuleScript sets a file with a rule written as an expression, for
+// every view.
+func WithRuleScript(path string) Option {
+	return func(c *Config) { c.RuleScript = path }
+}
+
+// WithAutomaton sets a registered automaton, as name or name:config, for
+// every view.
+func WithAutomaton(automaton string) Option {
+	return func(c *Config) { c.Automaton = automaton }
+}
+
+// WithGollyRule sets a Golly .rule file for every view to run.
+func WithGollyRule(path string) Option {
+	return func(c *Config) { c.GollyRule = path }
+}
+
+// WithSeed sets the random board's seed.
+func WithSeed(seed int64) Option {
+	return func(c *Config) { c.Seed = seed }
+}
+
+// WithDensity sets the fraction of cells alive in a random board.
+func WithDensity(density float64) Option {
+	return func(c *Config) { c.Density = density }
+}
+
+// WithWrap sets whether the board's edges wrap around.
+func WithWrap(wrap bool) Option {
+	return func(c *Config) { c.Wrap = wrap }
+}
+
+// DefaultConfig returns the default configuration with opts applied.
+func DefaultConfig(opts ...Option) Config {
+	c := Config{
+		GridWidth:         30,
+		GridHeight:        30,
+		WindowWidth:       500,
+		WindowHeight:      500,
+		TickRate:          2,
+		FrameRate:         60,
+		BackgroundFPS:     5,
+		LODThreshold:      1,
+		ErrorTimeout:      10 * time.Second,
+		Windows:           1,
+		Rules:             life.Conway.String(),
+		Density:           0.5,
+		Renderer:          "gl",
+		TerminalGraphics:  "auto",
+		Colour:            color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Background:        color.RGBA{0, 0, 0, 0xff},
+		History:           500,
+		ClustersEvery:     10,
+		Rewind:            500,
+		Views:             "1x1",
+		RenderSize:        "2000x2000",
+		ShaderDir:         "render/shaders",
+		HelpPauses:        true,
+		EditPauses:        true,
+		ScreenshotScale:   1,
+		ScreenshotDir:     ".",
+		PatternRule:       true,
+		PatternLimit:      1000000,
+		PatternCache:      true,
+		CheckpointKeep:    5,
+		DemoDuration:      20 * time.Second,
+		LogLevel:          "info",
+		VersusCells:       20,
+		VersusGenerations: 200,
+		GamepadDeadZone:   0.2,
+		GamepadMap:        "a=pause,b=step,x=randomize,up=faster,down=slower",
+		GIFMaxFrames:      500,
+		GIFMaxSize:        480,
+		RecordSize:        "1000x1000",
+		LEDSize:           "32x32",
+		LEDProtocol:       "wled",
+		LEDUniverse:       1,
+		AudioVolume:       0.5,
+		SeedThreshold:     0.5,
+		SeedFit:           "crop",
+		AutosaveInterval:  5 * time.Minute,
+		Timelapse:         1,
+		VideoFPS:          30,
+		WidgetSize:        "500x500",
+		GLDebugSeverity:   "medium",
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// maxGridSide and maxGridCells bound the size of the boards, whether -size
+// or a state file gives it, so that each takes at most a few hundred
+// megabytes.
+const (
+	maxGridSide  = 1 << 16
+	maxGridCells = 1 << 26
+)
+
+// Validate reports the first thing wrong with c, if anything.
+func (c Config) Validate() error {
+	switch {
+	case c.GridWidth < 1 || c.GridHeight < 1:
+		return fmt.Errorf("invalid board size %dx%d: both must be positive", c.GridWidth, c.GridHeight)
+	case c.GridWidth > maxGridSide || c.GridHeight > maxGridSide || c.GridWidth*c.GridHeight > maxGridCells:
+		return fmt.Errorf("invalid board size %dx%d: boards can be up to %d cells a side and %d cells in all", c.GridWidth, c.GridHeight, maxGridSide, maxGridCells)
+	case c.WindowWidth < 1 || c.WindowHeight < 1:
+		return fmt.Errorf("invalid window size %dx%d: both must be positive", c.WindowWidth, c.WindowHeight)
+	case c.TickRate < minFPS || c.TickRate > maxFPS:
+		return fmt.Errorf("invalid speed %g: want between %g and %d generations a second", c.TickRate, minFPS, maxFPS)
+	case c.FrameRate < 1 || c.FrameRate > 1000:
+		return fmt.Errorf("invalid -frame-rate %g: want between 1 and 1000", c.FrameRate)
+	case c.LODThreshold < 0:
+		return fmt.Errorf("invalid -lod-threshold %g: want a cell size in pixels, or 0 to draw every cell", c.LODThreshold)
+	case c.ErrorTimeout < 0:
+		return fmt.Errorf("invalid -error-timeout %s: want how long to show an error, or 0 to keep it up until dismissed", c.ErrorTimeout)
+	case c.Windows < 1 || c.Windows > maxWindows:
+		return fmt.Errorf("invalid -windows %d: want from 1 to %d", c.Windows, maxWindows)
+	case c.BackgroundFPS != 0 && (c.BackgroundFPS < 1 || c.BackgroundFPS > 1000):
+		return fmt.Errorf("invalid -background-fps %g: want between 1 and 1000, or 0 to keep to -frame-rate", c.BackgroundFPS)
+	case c.Density < 0 || c.Density > 1:
+		return fmt.Errorf("invalid density %g: want between 0 and 1", c.Density)
+	case c.ScreenshotScale < 1:
+		return fmt.Errorf("invalid -screenshot-scale %d: want at least 1", c.ScreenshotScale)
+	case c.VideoFPS <= 0:
+		return fmt.Errorf("invalid -video-fps %g: want more than 0", c.VideoFPS)
+	case c.GamepadDeadZone < 0 || c.GamepadDeadZone >= 1:
+		return fmt.Errorf("invalid -gamepad-dead-zone %g: want from 0 up to 1", c.GamepadDeadZone)
+	case c.SeedThreshold < 0 || c.SeedThreshold > 1:
+		return fmt.Errorf("invalid -seed-threshold %g: want between 0 and 1", c.SeedThreshold)
+	case c.SeedFit != "crop" && c.SeedFit != "letterbox":
+		return fmt.Errorf("invalid -seed-fit %q: want crop or letterbox", c.SeedFit)
+	case c.RecordReplay != "" && c.PlayReplay != "":
+		return errors.New("-record-replay and -play-replay can't be used together")
+	case (c.RecordReplay != "" || c.PlayReplay != "") && (c.Demo || c.Versus):
+		return errors.New("-demo and -versus can't be recorded or played back as replays")
+	case c.Host != "" && c.Join != "":
+		return errors.New("-host and -join can't be used together")
+	case c.Join != "" && (c.Headless || c.Renderer == "terminal" || c.RenderOut != "" || c.Demo || c.Versus || c.PlayReplay != ""):
+		return errors.New("-join needs the window, and the host to run the board, so it can't be used with -headless, -renderer terminal, -render-out, -demo, -versus or -play-replay")
+	case (c.Headless || c.Renderer == "terminal") && (c.Demo || c.Versus || c.Edit || c.Widget || c.RenderOut != "" || c.RecordVideo != "" || c.RecordReplay != "" || c.PlayReplay != "" || c.Diff[0] != ""):
+		return errors.New("-headless and -renderer terminal have no window, so they can't be used with -demo, -versus, -edit, -widget, -render-out, -record-video, replays or diff")
+	}
+	if !slices.Contains(renderers, c.Renderer) {
+		return fmt.Errorf("unknown -renderer %q: want %s", c.Renderer, strings.Join(renderers, " or "))
+	}
+	switch {
+	case !slices.Contains(terminalGraphics, c.TerminalGraphics):
+		return fmt.Errorf("unknown -terminal-graphics %q: want %s", c.TerminalGraphics, strings.Join(terminalGraphics, ", "))
+	case c.TerminalBraille && (c.TerminalGraphics == "sixel" || c.TerminalGraphics == "kitty"):
+		return fmt.Errorf("-terminal-braille draws characters, so it can't be used with -terminal-graphics %s", c.TerminalGraphics)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("invalid -log-level %q: want debug, info, warn or error", c.LogLevel)
+	}
+	for _, addr := range []struct{ name, value, example string }{
+		{"pprof", c.PProf, ":6060"},
+		{"metrics", c.Metrics, ":9100"},
+		{"api", c.API, ":8080"},
+		{"grpc", c.GRPC, ":9090"},
+		{"host", c.Host, ":7777"},
+		{"join", c.Join, ":7777"},
+	} {
+		if _, _, err := net.SplitHostPort(addr.value); addr.value != "" && err != nil {
+			return fmt.Errorf("invalid -%s %q: want an address like %s or localhost%s", addr.name, addr.value, addr.example, addr.example)
+		}
+	}
+	if _, ok := ledProtocols[c.LEDProtocol]; !ok {
+		return fmt.Errorf("invalid -led-protocol %q: want wled or e131", c.LEDProtocol)
+	}
+	if c.AudioVolume < 0 || c.AudioVolume > 1 {
+		return fmt.Errorf("invalid -audio-volume %g: want 0 to 1", c.AudioVolume)
+	}
+	if c.LEDUniverse < 1 || c.LEDUniverse > 63999 {
+		return fmt.Errorf("invalid -led-universe %d: want 1 to 63999", c.LEDUniverse)
+	}
+	if c.GLVersion != "" && c.GLVersion != "es" && !slices.Contains(contextVersionNames(), c.GLVersion) {
+		return fmt.Errorf("invalid -gl %q: want %s or es", c.GLVersion, strings.Join(contextVersionNames(), ", "))
+	}
+	if !slices.Contains(render.DebugSeverities, c.GLDebugSeverity) {
+		return fmt.Errorf("invalid -gl-debug-severity %q: want %s", c.GLDebugSeverity, strings.Join(render.DebugSeverities, ", "))
+	}
+	for _, size := range []struct{ name, value, example string }{
+		{"render-size", c.RenderSize, "2000x2000"},
+		{"record-size", c.RecordSize, "1000x1000"},
+		{"widget-size", c.WidgetSize, "500x500"},
+		{"led-size", c.LEDSize, "32x32"},
+	} {
+		var w, h int
+		if _, err := fmt.Sscanf(size.value, "%dx%d", &w, &h); err != nil || w < 1 || h < 1 {
+			return fmt.Errorf("invalid -%s %q: want the form %s", size.name, size.value, size.example)
+		}
+	}
+	if c.WidgetPos != "" {
+		var x, y int
+		if _, err := fmt.Sscanf(c.WidgetPos, "%d,%d", &x, &y); err != nil {
+			return fmt.Errorf("invalid -widget-pos %q: want the form 100,100", c.WidgetPos)
+		}
+	}
+	if c.Scenario != "" && c.Join != "" {
+		return errors.New("-scenario runs on the board, which -join leaves to the host")
+	}
+	if c.Automaton != "" && c.RuleScript != "" {
+		return errors.New("-automaton and -rule-script both say what the boards run; give one")
+	}
+	if c.GollyRule != "" && (c.Automaton != "" || c.RuleScript != "") {
+		return errors.New("-golly-rule, -automaton and -rule-script all say what the boards run; give one")
+	}
+	_, _, _, err := c.boards()
+	return err
+}
+
+// boards returns the layout of views c runs and each view's seed and rule.
+func (c Config) boards() (layout, []int64, []life.Rule, error) {
+	columnsOfViews, rowsOfViews, err := parseViews(c.Views)
+	if err != nil {
+		return layout{}, nil, nil, err
+	}
+	views := layout{columnsOfViews, rowsOfViews}
+	seeds := []int64{c.Seed}
+	if c.CompareSeeds != "" {
+		if seeds, err = parseSeeds(c.CompareSeeds); err != nil {
+			return layout{}, nil, nil, err
+		}
+		views = layout{len(seeds), 1}
+	}
+	for len(seeds) < views.len() {
+		seeds = append(seeds, seeds[0])
+	}
+	list := c.Rules
+	if c.RuleScript != "" {
+		r, err := loadRuleScript(c.RuleScript)
+		if err != nil {
+			return layout{}, nil, nil, err
+		}
+		list = r.String()
+	}
+	if c.Automaton != "" && c.Automaton != "list" {
+		name, _, _ := strings.Cut(c.Automaton, ":")
+		if _, ok := life.LookupAutomaton(name); !ok {
+			return layout{}, nil, nil, fmt.Errorf("no automaton called %q; -automaton list lists them", name)
+		}
+		list = c.Automaton
+	}
+	if c.GollyRule != "" {
+		if list, err = loadGollyRule(c.GollyRule); err != nil {
+			return layout{}, nil, nil, err
+		}
+	}
+	rules, err := parseRules(list, views.len())
+	if err != nil {
+		return layout{}, nil, nil, err
+	}
+	for _, r := range rules[1:] {
+		if r.Tiling() != rules[0].Tiling() {
+			return layout{}, nil, nil, fmt.Errorf("the views are drawn alike, so can't run on both %s and %s", rules[0].Tiling(), r.Tiling())
+		}
+	}
+	if rules[0].Tiling() == life.Triangles && c.Wrap && (c.GridWidth%2 != 0 || c.GridHeight%2 != 0) {
+		return layout{}, nil, nil, errors.New("a wrapped board of triangles needs an even -size, for the triangles meeting across its edges to point opposite ways")
+	}
+	if c.Versus && views.len() > 1 {
+		return layout{}, nil, nil, errors.New("-versus is played on a single board")
+	}
+	if c.Demo && (views.len() > 1 || c.Versus) {
+		return layout{}, nil, nil, errors.New("-demo runs on its own, on a single board")
+	}
+	return views, seeds, rules, nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRejects(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  func(*Config)
+		want string
+	}{
+		{"empty board", func(c *Config) { c.GridWidth = 0 }, "invalid board size 0x"},
+		{"huge board", func(c *Config) { c.GridWidth, c.GridHeight = maxGridSide+1, 1 }, "boards can be up to"},
+		{"slow speed", func(c *Config) { c.TickRate = 0 }, "invalid speed 0"},
+		{"no windows", func(c *Config) { c.Windows = 0 }, "invalid -windows 0"},
+		{"density", func(c *Config) { c.Density = 1.5 }, "invalid density 1.5"},
+		{"renderer", func(c *Config) { c.Renderer = "vulkan" }, `unknown -renderer "vulkan"`},
+		{"log level", func(c *Config) { c.LogLevel = "loud" }, `invalid -log-level "loud"`},
+		{"address", func(c *Config) { c.API = "8080" }, `invalid -api "8080"`},
+		{"render size", func(c *Config) { c.RenderSize = "big" }, `invalid -render-size "big"`},
+		{"replays", func(c *Config) { c.RecordReplay, c.PlayReplay = "a", "b" }, "can't be used together"},
+		{"headless demo", func(c *Config) { c.Headless, c.Demo = true, true }, "-headless and -renderer terminal have no window"},
+		{"scenario joined", func(c *Config) { c.Scenario, c.Join = "s", ":7777" }, "-scenario runs on the board"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.set(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+			if rerr := Run(cfg); rerr == nil || rerr.Error() != err.Error() {
+				t.Fatalf("Run() = %v, want %v", rerr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsDefault(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunTwice runs the app twice over, for nothing of the first run to
+// carry on into the second.
+func TestRunTwice(t *testing.T) {
+	dir := t.TempDir()
+	for i, generations := range []int{5, 9} {
+		cfg := DefaultConfig()
+		cfg.Headless, cfg.Seed, cfg.Generations = true, 1, generations
+		cfg.GridWidth, cfg.GridHeight = 20+i*10, 20
+		cfg.StatsOut = filepath.Join(dir, fmt.Sprintf("stats%d.csv", i))
+		if err := Run(cfg); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		b, err := os.ReadFile(cfg.StatsOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows := strings.Count(string(b), "\n") - 1; rows != generations {
+			t.Errorf("run %d wrote %d rows, want %d", i+1, rows, generations)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A config file has the same settings as the flags, one per line in the
+// TOML form name = value, where the names are the flags' and strings are
+// quoted, e.g.
+//
+//	size = "100x100"
+//	speed = 10
+//	wrap = true
+//
+// Blank lines and # comments are ignored.
+
+// DefaultConfigFile is the config file used when none is named.
+func DefaultConfigFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "life.toml"), nil
+}
+
+// LoadConfigFile sets the flags in fs from the config file at path, leaving
+// alone those already set on the command line. Settings that aren't flags
+// are warned about and skipped.
+func LoadConfigFile(fs *flag.FlagSet, path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, raw, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		value, vok := configValue(strings.TrimSpace(raw))
+		if !ok || name == "" || !vok {
+			return fmt.Errorf("%s:%d: want name = value", path, n)
+		}
+		if fs.Lookup(name) == nil {
+			slog.Warn(fmt.Sprintf("%s:%d: no setting called %q%s", path, n, name, closestFlag(fs, name)))
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %v", path, n, value, name, err)
+		}
+	}
+	return sc.Err()
+}
+
+// configValue returns the value a config file line gives after its =,
+// unquoting it and dropping any comment after it.
+func configValue(raw string) (string, bool) {
+	var value, rest string
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		quoted, err := strconv.QuotedPrefix(raw)
+		if err != nil {
+			return "", false
+		}
+		value, _ = strconv.Unquote(quoted)
+		rest = raw[len(quoted):]
+	case strings.HasPrefix(raw, "'"):
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", false
+		}
+		value, rest = raw[1:end+1], raw[end+2:]
+	default:
+		value, _, _ = strings.Cut(raw, "#")
+		return strings.TrimSpace(value), strings.TrimSpace(value) != ""
+	}
+	rest = strings.TrimSpace(rest)
+	return value, rest == "" || rest[0] == '#'
+}
+
+// closestFlag suggests a flag in fs with a name like name, if there is one.
+func closestFlag(fs *flag.FlagSet, name string) string {
+	best, bestDistance := "", 3
+	fs.VisitAll(func(f *flag.Flag) {
+		if d := editDistance(name, f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	})
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %s?", best)
+}
+
+// WriteConfigFile writes the Config settings among fs's flags to path as a
+// config file, with their current values, in flagGroups' groups.
+func WriteConfigFile(fs *flag.FlagSet, path string) error {
+	var out bytes.Buffer
+	for i, g := range flagGroups {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		fmt.Fprintf(&out, "# %s\n", g.title)
+		for _, name := range g.flags {
+			f := fs.Lookup(name)
+			if f == nil || flagAliases[name] != "" {
+				continue
+			}
+			value := f.Value.String()
+			// Flags that take a number or nothing are written bare, and the
+			// rest as strings.
+			if kind, _ := flag.UnquoteUsage(f); kind != "" && kind != "int" && kind != "uint" && kind != "float" {
+				value = strconv.Quote(value)
+			}
+			fmt.Fprintf(&out, "%s = %s\n", name, value)
+		}
+	}
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
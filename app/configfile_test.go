@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes src to a file called name in a fresh directory.
+func writeFile(t *testing.T, name, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfigFile checks flags beat the file and the file beats the
+// defaults, for settings a partial file has and hasn't got.
+func TestLoadConfigFile(t *testing.T) {
+	path := writeFile(t, "life.toml", `# A kiosk.
+size = "200x100"
+speed = 30 # a comment
+wrap = true
+rules = 'B36/S23'
+
+colour = "#00ff00"
+`)
+	cfg, fs, err := parseArgs("-speed", "5", "-density", "0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigFile(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	d := DefaultConfig()
+	if cfg.GridWidth != 200 || cfg.GridHeight != 100 || !cfg.Wrap || cfg.Rules != "B36/S23" || cfg.Colour.G != 0xff || cfg.Colour.R != 0 {
+		t.Errorf("size %dx%d, wrap %v, rules %s and colour %v, want the file's", cfg.GridWidth, cfg.GridHeight, cfg.Wrap, cfg.Rules, cfg.Colour)
+	}
+	if cfg.TickRate != 5 || cfg.Density != 0.1 {
+		t.Errorf("speed %v and density %v, want the flags' 5 and 0.1", cfg.TickRate, cfg.Density)
+	}
+	if cfg.WindowWidth != d.WindowWidth || cfg.Seed != d.Seed || cfg.LogLevel != d.LogLevel {
+		t.Error("settings in neither the file nor the flags aren't the defaults")
+	}
+}
+
+func TestLoadConfigFileWarns(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg, fs, _ := parseArgs()
+	if err := LoadConfigFile(fs, writeFile(t, "life.toml", "speeed = 10\nwrap = true\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Wrap {
+		t.Error("the setting after the unknown one wasn't taken")
+	}
+	if !strings.Contains(logged.String(), `no setting called \"speeed\"; did you mean speed?`) {
+		t.Errorf("the unknown setting was warned about as %q", logged.String())
+	}
+}
+
+func TestLoadConfigFileRejects(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		{"size\n", "life.toml:1: want name = value"},
+		{"# size\n\nsize = \"10x\n", "life.toml:3: want name = value"},
+		{"wrap = true\nsize = \"big\"\n", `life.toml:2: invalid value "big" for size: want the form 30x30`},
+	} {
+		_, fs, _ := parseArgs()
+		err := LoadConfigFile(fs, writeFile(t, "life.toml", c.src))
+		if err == nil || !strings.HasSuffix(err.Error(), c.want) {
+			t.Errorf("%q: loaded with %v, want %q", c.src, err, c.want)
+		}
+	}
+}
+
+// TestWriteConfigFile writes out a Config and loads it back into the
+// defaults, for the same Config.
+func TestWriteConfigFile(t *testing.T) {
+	cfg, fs, err := parseArgs("-size", "64x32", "-rules", "B3/S23,B36/S23", "-speed", "2.5", "-wrap", "-pattern", `my "glider".rle`, "-v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "life.toml")
+	if err := WriteConfigFile(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := os.ReadFile(path)
+	if !strings.Contains(string(src), "# Board\nsize = \"64x32\"\n") || strings.Contains(string(src), "tick-rate") {
+		t.Errorf("the file written is\n%s", src)
+	}
+
+	loaded, fs, _ := parseArgs()
+	if err := LoadConfigFile(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GridWidth != cfg.GridWidth || loaded.GridHeight != cfg.GridHeight || loaded.Rules != cfg.Rules || loaded.TickRate != cfg.TickRate ||
+		!loaded.Wrap || loaded.Pattern != cfg.Pattern || loaded.LogLevel != "debug" || loaded.Colour != cfg.Colour {
+		t.Errorf("the settings loaded back aren't those written:\n%s", src)
+	}
+}
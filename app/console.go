@@ -0,0 +1,231 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	// consoleHeight is how far down the window the console reaches, in
+	// normalized device coordinates.
+	consoleHeight = 1.0
+	// consoleScrollback is how many lines of output the console keeps.
+	consoleScrollback = 100
+	consolePrompt     = "> "
+)
+
+// consoleCommand is a command that can be typed into the console. run
+// returns the text to print, if any.
+type consoleCommand struct {
+	name  string
+	usage string
+	run   func(args []string) (string, error)
+}
+
+// commandLine keeps the commands the console understands and runs typed
+// lines against them. It knows nothing of windows or rendering.
+type commandLine struct {
+	commands map[string]consoleCommand
+}
+
+func newCommandLine() *commandLine {
+	cl := &commandLine{commands: make(map[string]consoleCommand)}
+	cl.add("help", "help", func([]string) (string, error) {
+		var usages []string
+		for _, c := range cl.commands {
+			usages = append(usages, c.usage)
+		}
+		sort.Strings(usages)
+		return strings.Join(usages, "\n"), nil
+	})
+	return cl
+}
+
+func (cl *commandLine) add(name, usage string, run func(args []string) (string, error)) {
+	cl.commands[name] = consoleCommand{name, usage, run}
+}
+
+// execute runs a typed line, returning what it printed.
+func (cl *commandLine) execute(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	c, ok := cl.commands[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q; try help", fields[0])
+	}
+	out, err := c.run(fields[1:])
+	if err != nil {
+		return "", fmt.Errorf("%v (usage: %s)", err, c.usage)
+	}
+	return out, nil
+}
+
+// complete returns the command names starting with prefix, sorted.
+func (cl *commandLine) complete(prefix string) []string {
+	var names []string
+	for name := range cl.commands {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// console is a drop-down text console over the top of the window. While it
+// is open it takes the keyboard.
+type console struct {
+	rs *runState
+
+	visible bool
+	cl      *commandLine
+
+	line       []rune
+	cursor     int
+	history    []string
+	historyPos int
+	scrollback []string
+	// ignoreChar swallows the character typed by the key that opened the
+	// console.
+	ignoreChar bool
+
+	program    *overlayProgram
+	background *lines
+	text       *text
+}
+
+func (rs *runState) newConsole(cl *commandLine, program *overlayProgram) *console {
+	return &console{
+		rs:         rs,
+		cl:         cl,
+		program:    program,
+		background: rs.newLines("console background", 6),
+		text:       rs.newText(program, 1024),
+	}
+}
+
+// install hooks the console into window's character input and in's key
+// handling.
+func (c *console) install(window *glfw.Window, in *input) {
+	window.SetCharCallback(func(w *glfw.Window, char rune) {
+		if !c.visible {
+			return
+		}
+		if c.ignoreChar {
+			c.ignoreChar = false
+			return
+		}
+		c.line = append(c.line[:c.cursor], append([]rune{char}, c.line[c.cursor:]...)...)
+		c.cursor++
+	})
+	in.capture = func(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool {
+		if !c.visible {
+			return false
+		}
+		c.key(key)
+		return true
+	}
+}
+
+func (c *console) open() {
+	c.visible = true
+	c.ignoreChar = true
+	c.historyPos = len(c.history)
+}
+
+func (c *console) print(s string) {
+	c.scrollback = append(c.scrollback, strings.Split(s, "\n")...)
+	if n := len(c.scrollback) - consoleScrollback; n > 0 {
+		c.scrollback = c.scrollback[n:]
+	}
+}
+
+// key handles an editing key pressed or repeated while the console is open.
+func (c *console) key(key glfw.Key) {
+	switch key {
+	case glfw.KeyEscape, glfw.KeyGraveAccent:
+		c.visible = false
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		line := string(c.line)
+		c.line, c.cursor = c.line[:0], 0
+		c.print(consolePrompt + line)
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+		c.history = append(c.history, line)
+		c.historyPos = len(c.history)
+		out, err := c.cl.execute(line)
+		if err != nil {
+			c.print("error: " + err.Error())
+		} else if out != "" {
+			c.print(out)
+		}
+	case glfw.KeyBackspace:
+		if c.cursor > 0 {
+			c.line = append(c.line[:c.cursor-1], c.line[c.cursor:]...)
+			c.cursor--
+		}
+	case glfw.KeyDelete:
+		if c.cursor < len(c.line) {
+			c.line = append(c.line[:c.cursor], c.line[c.cursor+1:]...)
+		}
+	case glfw.KeyLeft:
+		c.cursor = max(c.cursor-1, 0)
+	case glfw.KeyRight:
+		c.cursor = min(c.cursor+1, len(c.line))
+	case glfw.KeyHome:
+		c.cursor = 0
+	case glfw.KeyEnd:
+		c.cursor = len(c.line)
+	case glfw.KeyUp, glfw.KeyDown:
+		if key == glfw.KeyUp {
+			c.historyPos = max(c.historyPos-1, 0)
+		} else {
+			c.historyPos = min(c.historyPos+1, len(c.history))
+		}
+		c.line = c.line[:0]
+		if c.historyPos < len(c.history) {
+			c.line = append(c.line, []rune(c.history[c.historyPos])...)
+		}
+		c.cursor = len(c.line)
+	case glfw.KeyTab:
+		// Only the command name is completed, and only when there's one
+		// match; otherwise the candidates are listed.
+		word := string(c.line)
+		if strings.ContainsRune(word, ' ') {
+			return
+		}
+		switch names := c.cl.complete(word); len(names) {
+		case 0:
+		case 1:
+			c.line = []rune(names[0] + " ")
+			c.cursor = len(c.line)
+		default:
+			c.print(strings.Join(names, " "))
+		}
+	}
+}
+
+func (c *console) draw() {
+	if !c.visible {
+		return
+	}
+	top, bottom := float32(1), float32(1-consoleHeight)
+	shade(c.background, c.program, -1, bottom, 1, top, 0.85)
+
+	_, lineHeight := c.rs.textSize("")
+	rows := int((top-bottom)/lineHeight) - 1
+	c.text.reset()
+	shown := c.scrollback[max(len(c.scrollback)-rows, 0):]
+	for i, line := range shown {
+		c.text.print(line, -1, top-float32(i)*lineHeight)
+	}
+	input := consolePrompt + string(c.line[:c.cursor]) + "_" + string(c.line[c.cursor:])
+	c.text.print(input, -1, top-float32(len(shown))*lineHeight)
+	c.text.draw(0.9, 0.9, 0.6, 1)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// testCommandLine understands seed n and save name, keeping what they're
+// given in got.
+func testCommandLine(got *[]string) *commandLine {
+	cl := newCommandLine()
+	cl.add("seed", "seed n", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", errors.New("want a seed")
+		}
+		if _, err := strconv.ParseInt(args[0], 10, 64); err != nil {
+			return "", err
+		}
+		*got = append(*got, "seed "+args[0])
+		return "", nil
+	})
+	cl.add("save", "save name", func(args []string) (string, error) {
+		*got = append(*got, "save "+strings.Join(args, " "))
+		return "Saved " + strings.Join(args, " "), nil
+	})
+	return cl
+}
+
+func TestCommandLineExecute(t *testing.T) {
+	var got []string
+	cl := testCommandLine(&got)
+	for _, c := range []struct {
+		line, out, err string
+	}{
+		{"", "", ""},
+		{"   ", "", ""},
+		{"seed 42", "", ""},
+		{"  save \t my  soup ", "Saved my soup", ""},
+		{"seed", "", "want a seed (usage: seed n)"},
+		{"seed x", "", `strconv.ParseInt: parsing "x": invalid syntax (usage: seed n)`},
+		{"sed 42", "", `unknown command "sed"; try help`},
+		{"help", "help\nsave name\nseed n", ""},
+	} {
+		out, err := cl.execute(c.line)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if out != c.out || msg != c.err {
+			t.Errorf("%q printed %q with error %q, want %q with %q", c.line, out, msg, c.out, c.err)
+		}
+	}
+	if want := []string{"seed 42", "save my soup"}; !slices.Equal(got, want) {
+		t.Errorf("commands ran with %v, want %v", got, want)
+	}
+}
+
+func TestCommandLineComplete(t *testing.T) {
+	cl := testCommandLine(new([]string))
+	for prefix, want := range map[string][]string{
+		"":     {"help", "save", "seed"},
+		"s":    {"save", "seed"},
+		"se":   {"seed"},
+		"seed": {"seed"},
+		"x":    nil,
+	} {
+		if got := cl.complete(prefix); !slices.Equal(got, want) {
+			t.Errorf("%q completes to %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+// typeInto types s at the console's cursor, as its character callback does.
+func typeInto(c *console, s string) {
+	for _, r := range s {
+		c.line = append(c.line[:c.cursor], append([]rune{r}, c.line[c.cursor:]...)...)
+		c.cursor++
+	}
+}
+
+func TestConsoleKeys(t *testing.T) {
+	var got []string
+	c := &console{rs: testRun(t, DefaultConfig()), cl: testCommandLine(&got)}
+	c.open()
+
+	typeInto(c, "sed 7")
+	c.key(glfw.KeyHome)
+	c.key(glfw.KeyRight)
+	c.key(glfw.KeyRight)
+	typeInto(c, "e")
+	c.key(glfw.KeyEnd)
+	c.key(glfw.KeyBackspace)
+	typeInto(c, "9")
+	c.key(glfw.KeyEnter)
+	typeInto(c, "bogus")
+	c.key(glfw.KeyEnter)
+	if want := []string{"seed 9"}; !slices.Equal(got, want) {
+		t.Fatalf("the console ran %v, want %v", got, want)
+	}
+	if want := []string{"> seed 9", "> bogus", `error: unknown command "bogus"; try help`}; !slices.Equal(c.scrollback, want) {
+		t.Fatalf("scrollback is %q, want %q", c.scrollback, want)
+	}
+
+	// History goes back through what was typed, and forward to a blank
+	// line.
+	c.key(glfw.KeyUp)
+	c.key(glfw.KeyUp)
+	c.key(glfw.KeyUp)
+	if string(c.line) != "seed 9" || c.cursor != len(c.line) {
+		t.Fatalf("back past the start of history is %q", string(c.line))
+	}
+	c.key(glfw.KeyDown)
+	c.key(glfw.KeyDown)
+	if len(c.line) != 0 {
+		t.Fatalf("forward out of history is %q", string(c.line))
+	}
+
+	typeInto(c, "sa")
+	c.key(glfw.KeyTab)
+	if string(c.line) != "save " {
+		t.Fatalf("tab completed sa to %q", string(c.line))
+	}
+	c.line, c.cursor = c.line[:0], 0
+	typeInto(c, "s")
+	c.key(glfw.KeyTab)
+	if string(c.line) != "s" || c.scrollback[len(c.scrollback)-1] != "save seed" {
+		t.Fatalf("tab on s left %q and listed %q", string(c.line), c.scrollback[len(c.scrollback)-1])
+	}
+
+	c.key(glfw.KeyEscape)
+	if c.visible {
+		t.Fatal("escape left the console open")
+	}
+}
+
+func TestConsoleScrollback(t *testing.T) {
+	c := &console{}
+	for i := 0; i < consoleScrollback; i++ {
+		c.print(strconv.Itoa(i))
+	}
+	c.print("a\nb")
+	if len(c.scrollback) != consoleScrollback || c.scrollback[0] != "2" || c.scrollback[consoleScrollback-1] != "b" {
+		t.Fatalf("scrollback runs %q to %q over %d lines", c.scrollback[0], c.scrollback[len(c.scrollback)-1], len(c.scrollback))
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// control is a labelled box in a panel overlay that can be clicked or
+// picked with the keyboard. Its rectangle, in normalized device
+// coordinates, is where it's drawn and where it's hit.
+type control struct {
+	label                  string
+	minX, minY, maxX, maxY float32
+	// on shows the control lit, for a toggle that's set.
+	on bool
+}
+
+func (c control) contains(x, y float32) bool {
+	return x >= c.minX && x <= c.maxX && y >= c.minY && y <= c.maxY
+}
+
+// controls is a set of controls laid out afresh each frame over the text
+// overlay, drawn all at once: boxes that are on filled, the focused one
+// outlined brighter.
+type controls struct {
+	rs *runState
+
+	all []control
+
+	program *overlayProgram
+	boxes   *lines
+	text    *text
+	lit     *text
+}
+
+func (rs *runState) newControls(label string, program *overlayProgram, maxChars int) *controls {
+	return &controls{
+		rs:      rs,
+		program: program,
+		boxes:   rs.newLines(label, 64),
+		text:    rs.newText(program, maxChars),
+		lit:     rs.newText(program, maxChars),
+	}
+}
+
+func (cs *controls) reset() {
+	cs.all = cs.all[:0]
+}
+
+// add lays out a control with its top-left corner at (x, y), sized to fit
+// its label with a font pixel's padding around it, and returns its right
+// edge.
+func (cs *controls) add(label string, x, y float32, on bool) float32 {
+	w, h := cs.rs.textSize(label)
+	px, py := cs.rs.textPixel()
+	c := control{label: label, minX: x, minY: y - h - 2*py, maxX: x + w + 2*px, maxY: y, on: on}
+	cs.all = append(cs.all, c)
+	return c.maxX
+}
+
+// at returns the index of the control at (x, y), or -1 if there's none.
+func (cs *controls) at(x, y float32) int {
+	for i, c := range cs.all {
+		if c.contains(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// draw draws the controls laid out, outlining focus, if it's one of them,
+// brighter than the rest.
+func (cs *controls) draw(focus int) {
+	px, py := cs.rs.textPixel()
+	cs.boxes.reset()
+	for _, c := range cs.all {
+		if c.on {
+			cs.boxes.add(c.minX, c.minY)
+			cs.boxes.add(c.maxX, c.minY)
+			cs.boxes.add(c.maxX, c.maxY)
+			cs.boxes.add(c.minX, c.minY)
+			cs.boxes.add(c.maxX, c.maxY)
+			cs.boxes.add(c.minX, c.maxY)
+		}
+	}
+	cs.program.use(0.2, 0.45, 0.8, 1)
+	cs.boxes.draw(gl.TRIANGLES)
+
+	outline := func(c control) {
+		cs.boxes.add(c.minX, c.minY)
+		cs.boxes.add(c.maxX, c.minY)
+		cs.boxes.add(c.maxX, c.minY)
+		cs.boxes.add(c.maxX, c.maxY)
+		cs.boxes.add(c.maxX, c.maxY)
+		cs.boxes.add(c.minX, c.maxY)
+		cs.boxes.add(c.minX, c.maxY)
+		cs.boxes.add(c.minX, c.minY)
+	}
+	cs.boxes.reset()
+	for i, c := range cs.all {
+		if i != focus {
+			outline(c)
+		}
+	}
+	cs.program.use(0.5, 0.5, 0.5, 1)
+	cs.boxes.draw(gl.LINES)
+	if focus >= 0 && focus < len(cs.all) {
+		cs.boxes.reset()
+		outline(cs.all[focus])
+		cs.program.use(1, 0.8, 0.2, 1)
+		cs.boxes.draw(gl.LINES)
+	}
+
+	cs.text.reset()
+	cs.lit.reset()
+	for _, c := range cs.all {
+		if c.on {
+			cs.lit.print(c.label, c.minX+px, c.maxY-py)
+		} else {
+			cs.text.print(c.label, c.minX+px, c.maxY-py)
+		}
+	}
+	cs.text.draw(0.7, 0.7, 0.7, 1)
+	cs.lit.draw(1, 1, 1, 1)
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// editCursor is a cell highlighted and moved with the keyboard, for editing
+// without the mouse. It stays hidden until first moved.
+type editCursor struct {
+	rs *runState
+
+	sim  *life.Simulation
+	x, y int
+
+	program *overlayProgram
+	outline *lines
+}
+
+func (rs *runState) newEditCursor(program *overlayProgram) *editCursor {
+	return &editCursor{rs: rs, program: program, outline: rs.newLines("edit cursor", 4)}
+}
+
+// move shows the cursor on sim if it's hidden, otherwise moves it by
+// (dx, dy) cells, and pans the camera to keep it in view. With paint set,
+// the cell it lands on comes alive.
+func (c *editCursor) move(sim *life.Simulation, cam *camera, dx, dy int, paint bool) {
+	if c.sim == nil {
+		c.sim, c.x, c.y = sim, c.rs.config.GridWidth/2, c.rs.config.GridHeight/2
+	} else {
+		c.x = min(max(c.x+dx, 0), c.rs.config.GridWidth-1)
+		c.y = min(max(c.y+dy, 0), c.rs.config.GridHeight-1)
+	}
+	if paint {
+		c.sim.Cells.Set(c.x, c.y, true)
+	}
+
+	cellW, cellH := 2/float32(c.rs.config.GridWidth), 2/float32(c.rs.config.GridHeight)
+	x0, y0 := float32(c.x)*cellW-1, float32(c.y)*cellH-1
+	minX, minY, maxX, maxY := cam.view()
+	if x0 < minX {
+		cam.x += x0 - minX
+	} else if x0+cellW > maxX {
+		cam.x += x0 + cellW - maxX
+	}
+	if y0 < minY {
+		cam.y += y0 - minY
+	} else if y0+cellH > maxY {
+		cam.y += y0 + cellH - maxY
+	}
+	cam.to = nil
+	cam.clamp()
+}
+
+// toggle flips the cell under the cursor, if it's shown.
+func (c *editCursor) toggle() {
+	if c.sim != nil {
+		c.sim.Cells.Set(c.x, c.y, !c.sim.Cells.Alive(c.x, c.y))
+	}
+}
+
+// draw outlines the cursor's cell if it is on sim. It expects the viewport
+// to be set to sim's view.
+func (c *editCursor) draw(sim *life.Simulation, cam *camera) {
+	if c.sim != sim {
+		return
+	}
+	x0, y0 := c.rs.cellCorner(cam, c.x, c.y)
+	x1, y1 := c.rs.cellCorner(cam, c.x+1, c.y+1)
+	c.outline.reset()
+	c.outline.rect(x0, y0, x1, y1)
+	c.program.use(1, 0.3, 0.3, 1)
+	c.outline.draw(gl.LINE_LOOP)
+}
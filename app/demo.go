@@ -0,0 +1,126 @@
+package app
+
+import (
+	"time"
+
+	"opengl/life"
+)
+
+// demoFade is how long a demo scene takes to fade in from and out to black.
+const demoFade = time.Second
+
+// demoScene is one entry in the demo playlist: a rule and a way of setting
+// up a cleared board to run it on.
+type demoScene struct {
+	name  string
+	rule  string
+	wrap  bool
+	setup func(sim *life.Simulation, wrap bool)
+}
+
+// stamper sets up a scene by stamping the built-in pattern with the given
+// id at each of the given centres.
+func stamper(id string, centres ...[2]int) func(*life.Simulation, bool) {
+	p := life.LibraryPattern(id)
+	return func(sim *life.Simulation, wrap bool) {
+		for _, c := range centres {
+			sim.Cells.Stamp(p, c[0], c[1], wrap)
+		}
+	}
+}
+
+// centred sets up a scene by stamping the built-in pattern with the given
+// id in the middle of the board.
+func centred(id string) func(*life.Simulation, bool) {
+	p := life.LibraryPattern(id)
+	return func(sim *life.Simulation, wrap bool) {
+		sim.Cells.Stamp(p, sim.Cells.Columns()/2, sim.Cells.Rows()/2, wrap)
+	}
+}
+
+// soup sets up a scene with a fresh random board of the given density.
+func soup(density float64) func(*life.Simulation, bool) {
+	return func(sim *life.Simulation, _ bool) {
+		sim.Density = density
+		sim.Reseed(time.Now().UnixNano())
+	}
+}
+
+// demoScenes is the demo playlist. The Gosper gun is 36 cells wide, too
+// wide for the board, so it isn't in it.
+var demoScenes = []demoScene{
+	{"Pulsar", "B3/S23", false, centred("pulsar")},
+	{"R-pentomino", "B3/S23", true, centred("r-pentomino")},
+	{"Glider fleet", "B3/S23", true, stamper("glider", [2]int{5, 25}, [2]int{12, 18}, [2]int{19, 11}, [2]int{26, 4})},
+	{"LWSS flotilla", "B3/S23", true, stamper("lwss", [2]int{5, 6}, [2]int{5, 15}, [2]int{5, 24})},
+	{"HighLife soup (B36/S23)", "B36/S23", true, soup(0.3)},
+	{"Day & Night soup (B3678/S34678)", "B3678/S34678", true, soup(0.5)},
+}
+
+// demo plays the demo playlist on a board, one scene after another, with a
+// caption naming the scene playing.
+type demo struct {
+	rs *runState
+
+	sim      *life.Simulation
+	duration time.Duration
+	scene    int
+	started  time.Time
+
+	program *overlayProgram
+	fade    *lines
+	caption *text
+}
+
+func (rs *runState) newDemo(sim *life.Simulation, duration time.Duration, program *overlayProgram) *demo {
+	d := &demo{
+		rs:       rs,
+		sim:      sim,
+		duration: duration,
+		program:  program,
+		fade:     rs.newLines("demo fade", 6),
+		caption:  rs.newText(program, 64),
+	}
+	d.start(0, time.Now())
+	return d
+}
+
+// start sets the board up for scene i.
+func (d *demo) start(i int, now time.Time) {
+	s := demoScenes[i]
+	r, err := life.ParseRule(s.rule)
+	if err != nil {
+		panic(err)
+	}
+	d.scene, d.started = i, now
+	d.rs.config.Wrap = s.wrap
+	d.sim.Rule = r
+	d.sim.Clear()
+	s.setup(d.sim, s.wrap)
+}
+
+// update moves on to the next scene once the current one has run its
+// course, reporting whether it did.
+func (d *demo) update(now time.Time) bool {
+	if now.Sub(d.started) < d.duration {
+		return false
+	}
+	d.start((d.scene+1)%len(demoScenes), now)
+	return true
+}
+
+func (d *demo) draw() {
+	// Each scene fades in from black and back out to it, so the cut
+	// between scenes happens unseen.
+	elapsed, fade := time.Since(d.started), min(demoFade, d.duration/4)
+	alpha := max(1-float32(elapsed)/float32(fade), 1-float32(d.duration-elapsed)/float32(fade))
+	if alpha > 0 {
+		shade(d.fade, d.program, -1, -1, 1, 1, min(alpha, 1))
+	}
+
+	name := demoScenes[d.scene].name
+	w, h := d.rs.textSize(name)
+	d.caption.reset()
+	d.caption.print(name, -w/2, -1+2*h)
+	d.caption.draw(1, 1, 1, 1)
+}
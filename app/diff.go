@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// boardDiff is the live cells of two boards, split by which of them has
+// each one.
+type boardDiff struct {
+	onlyA, onlyB, both [][2]int
+}
+
+// diffCells compares two boards' cells, packed as in a state file, on a
+// board of the given size.
+func diffCells(a, b []byte, columns, rows int) boardDiff {
+	var d boardDiff
+	for i := 0; i < columns*rows; i++ {
+		inA, inB := a[i/8]&(1<<(i%8)) != 0, b[i/8]&(1<<(i%8)) != 0
+		c := [2]int{i / rows, i % rows}
+		switch {
+		case inA && inB:
+			d.both = append(d.both, c)
+		case inA:
+			d.onlyA = append(d.onlyA, c)
+		case inB:
+			d.onlyB = append(d.onlyB, c)
+		}
+	}
+	return d
+}
+
+// diffStates compares the first boards of two states, which must be the
+// same size.
+func diffStates(a, b state) (boardDiff, error) {
+	switch {
+	case a.Columns != b.Columns || a.Rows != b.Rows:
+		return boardDiff{}, fmt.Errorf("can't compare a %dx%d board with a %dx%d one", a.Columns, a.Rows, b.Columns, b.Rows)
+	case len(a.Boards) == 0 || len(b.Boards) == 0:
+		return boardDiff{}, fmt.Errorf("nothing to compare: no boards")
+	}
+	for _, st := range []state{a, b} {
+		if len(st.Boards[0].Cells) != packedSize(st.Columns, st.Rows) {
+			return boardDiff{}, fmt.Errorf("cells don't fit a %dx%d board", st.Columns, st.Rows)
+		}
+	}
+	return diffCells(a.Boards[0].Cells, b.Boards[0].Cells, a.Columns, a.Rows), nil
+}
+
+func (d boardDiff) String() string {
+	return fmt.Sprintf("%d only in the first, %d only in the second, %d in both", len(d.onlyA), len(d.onlyB), len(d.both))
+}
+
+var (
+	diffColourA    = [3]float32{1, 0.35, 0.3}
+	diffColourB    = [3]float32{0.3, 0.55, 1}
+	diffColourBoth = [3]float32{0.9, 0.9, 0.9}
+)
+
+// diffView shows a boardDiff in place of the board: cells only in the first
+// board red, only in the second blue, and in both white.
+type diffView struct {
+	rs *runState
+
+	diff    boardDiff
+	cam     *camera
+	program *overlayProgram
+	quads   *lines
+}
+
+func (rs *runState) newDiffView(d boardDiff, cam *camera, program *overlayProgram) *diffView {
+	return &diffView{rs: rs, diff: d, cam: cam, program: program, quads: rs.newLines("diff", 6*rs.config.GridWidth*rs.config.GridHeight)}
+}
+
+func (v *diffView) update(dt float64) {}
+
+func (v *diffView) draw(life.Grid) {
+	for _, set := range []struct {
+		cells  [][2]int
+		colour [3]float32
+	}{
+		{v.diff.onlyA, diffColourA},
+		{v.diff.onlyB, diffColourB},
+		{v.diff.both, diffColourBoth},
+	} {
+		v.quads.reset()
+		for _, c := range set.cells {
+			x0, y0 := v.rs.cellCorner(v.cam, c[0], c[1])
+			x1, y1 := v.rs.cellCorner(v.cam, c[0]+1, c[1]+1)
+			v.quads.add(x0, y0)
+			v.quads.add(x1, y0)
+			v.quads.add(x1, y1)
+			v.quads.add(x0, y0)
+			v.quads.add(x1, y1)
+			v.quads.add(x0, y1)
+		}
+		v.program.use(set.colour[0], set.colour[1], set.colour[2], 1)
+		v.quads.draw(gl.TRIANGLES)
+	}
+}
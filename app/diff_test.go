@@ -0,0 +1,61 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"opengl/life"
+)
+
+func TestDiffStates(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 13, 7
+	rs := testRun(t, cfg)
+	a := life.NewSimulation(life.NewGrid(13, 7), life.Conway, 1, 0, 0)
+	b := life.NewSimulation(life.NewGrid(13, 7), life.Conway, 1, 0, 0)
+	for _, c := range [][2]int{{0, 0}, {12, 6}, {5, 3}} {
+		a.Cells.Set(c[0], c[1], true)
+		b.Cells.Set(c[0], c[1], true)
+	}
+	a.Cells.Set(1, 0, true)
+	a.Cells.Set(12, 5, true)
+	b.Cells.Set(0, 6, true)
+
+	d, err := diffStates(rs.captureState([]*life.Simulation{a}, rs.newCamera()), rs.captureState([]*life.Simulation{b}, rs.newCamera()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want [][2]int
+	}{
+		{"only in the first", d.onlyA, [][2]int{{1, 0}, {12, 5}}},
+		{"only in the second", d.onlyB, [][2]int{{0, 6}}},
+		{"in both", d.both, [][2]int{{0, 0}, {5, 3}, {12, 6}}},
+	} {
+		if !slices.Equal(c.got, c.want) {
+			t.Errorf("cells %s are %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if got, want := d.String(), "2 only in the first, 1 only in the second, 3 in both"; got != want {
+		t.Errorf("summary %q, want %q", got, want)
+	}
+}
+
+func TestDiffStatesRejects(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 13, 7
+	rs := testRun(t, cfg)
+	st := rs.captureState(testSims(t, rs, 1), rs.newCamera())
+
+	wider := st
+	wider.Columns = 14
+	if _, err := diffStates(st, wider); err == nil || err.Error() != "can't compare a 13x7 board with a 14x7 one" {
+		t.Errorf("comparing different sizes: %v", err)
+	}
+	empty := st
+	empty.Boards = nil
+	if _, err := diffStates(empty, st); err == nil {
+		t.Error("comparing a state with no boards succeeded")
+	}
+}
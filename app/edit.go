@@ -0,0 +1,83 @@
+package app
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+)
+
+// cellUnderCursor returns the board and cell the cursor is over, and false
+// when it is over none, such as between views or while a 3D view is shown.
+func (s *scene) cellUnderCursor(window *glfw.Window) (*life.Simulation, int, int, bool) {
+	if s.view != nil {
+		return nil, 0, 0, false
+	}
+	x, y := cursorNDC(window)
+	fbWidth, fbHeight := window.GetFramebufferSize()
+	i, x, y, ok := s.views.viewAt(x, y, fbWidth, fbHeight)
+	if !ok {
+		return nil, 0, 0, false
+	}
+	cx, cy, ok := s.cam.cellAt(x, y)
+	if !ok {
+		return nil, 0, 0, false
+	}
+	return s.sims[i], cx, cy, true
+}
+
+// brushStroke paints cells along the path of a mouse drag. Cursor events
+// arrive at whatever rate the platform delivers them, so each new position
+// is joined to the last by a line to leave no gaps.
+type brushStroke struct {
+	brush *brush
+	sim   *life.Simulation
+	alive bool
+	x, y  int
+}
+
+// moveTo paints from the stroke's last cell to (x, y) on sim. A stroke that
+// wanders onto another view, or is nil, starts afresh there.
+func (b *brushStroke) moveTo(sim *life.Simulation, x, y int) {
+	if b.sim != sim {
+		b.sim, b.x, b.y = sim, x, y
+	}
+	line(b.x, b.y, x, y, func(x, y int) {
+		b.brush.paint(sim, x, y, b.alive)
+	})
+	b.x, b.y = x, y
+}
+
+// line calls plot for every cell on the line from (x0, y0) to (x1, y1),
+// both ends included, using Bresenham's algorithm.
+func line(x0, y0, x1, y1 int, plot func(x, y int)) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
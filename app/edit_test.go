@@ -0,0 +1,89 @@
+package app
+
+import (
+	"testing"
+
+	"opengl/life"
+)
+
+func TestLine(t *testing.T) {
+	for _, end := range [][4]int{
+		{0, 0, 0, 0}, {0, 0, 7, 0}, {3, 9, 3, 2}, {0, 0, 5, 5},
+		{0, 0, 9, 2}, {9, 2, 0, 0}, {-3, 4, 2, -7}, {10, 1, 1, 4},
+	} {
+		var cells [][2]int
+		line(end[0], end[1], end[2], end[3], func(x, y int) { cells = append(cells, [2]int{x, y}) })
+		if want := max(abs(end[2]-end[0]), abs(end[3]-end[1])) + 1; len(cells) != want {
+			t.Errorf("line %v plots %d cells, want %d", end, len(cells), want)
+		}
+		if first, last := cells[0], cells[len(cells)-1]; first != [2]int{end[0], end[1]} || last != [2]int{end[2], end[3]} {
+			t.Errorf("line %v runs from %v to %v", end, first, last)
+		}
+		for i := 1; i < len(cells); i++ {
+			if dx, dy := abs(cells[i][0]-cells[i-1][0]), abs(cells[i][1]-cells[i-1][1]); dx > 1 || dy > 1 || dx+dy == 0 {
+				t.Errorf("line %v jumps from %v to %v", end, cells[i-1], cells[i])
+			}
+		}
+	}
+}
+
+func TestCellAt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 40, 20
+	cam := testRun(t, cfg).newCamera()
+	for _, c := range []struct {
+		x, y   float32
+		cx, cy int
+		ok     bool
+	}{
+		{-1, -1, 0, 0, true},
+		{0, 0, 20, 10, true},
+		{0.99, 0.99, 39, 19, true},
+		{-0.96, 0.06, 0, 10, true},
+		{1, 0, 0, 0, false},
+		{0, -1.01, 0, 0, false},
+	} {
+		if cx, cy, ok := cam.cellAt(c.x, c.y); cx != c.cx || cy != c.cy || ok != c.ok {
+			t.Errorf("cellAt(%v, %v) = %d, %d, %v, want %d, %d, %v", c.x, c.y, cx, cy, ok, c.cx, c.cy, c.ok)
+		}
+	}
+
+	// Zoomed in 4 times on the top right quarter, the view's corners are
+	// the quarter's.
+	cam.x, cam.y, cam.zoom = 0.5, 0.5, 4
+	if cx, cy, _ := cam.cellAt(-1, -1); cx != 25 || cy != 12 {
+		t.Errorf("zoomed in, the bottom left is cell %d, %d, want 25, 12", cx, cy)
+	}
+	if cx, cy, _ := cam.cellAt(1-1e-4, 1-1e-4); cx != 34 || cy != 17 {
+		t.Errorf("zoomed in, the top right is cell %d, %d, want 34, 17", cx, cy)
+	}
+}
+
+// TestBrushStrokeLeavesNoGaps drags across a board in jumps, as cursor
+// events come, for a stroke with every cell between them painted.
+func TestBrushStrokeLeavesNoGaps(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 20, 20
+	rs := testRun(t, cfg)
+	sim := life.NewSimulation(life.NewGrid(20, 20), life.Conway, 1, 0, 0)
+	stroke := &brushStroke{brush: &brush{rs: rs}, alive: true}
+	for _, p := range [][2]int{{2, 2}, {9, 2}, {9, 2}, {9, 15}} {
+		stroke.moveTo(sim, p[0], p[1])
+	}
+	if got := sim.Cells.Population(); got != 8+13 {
+		t.Fatalf("the stroke painted %d cells, want 21", got)
+	}
+	for x := 2; x <= 9; x++ {
+		if !sim.Cells.Alive(x, 2) {
+			t.Fatalf("the stroke skipped cell %d, 2", x)
+		}
+	}
+
+	// Erasing down the upright clears it, corner included.
+	erase := &brushStroke{brush: stroke.brush}
+	erase.moveTo(sim, 9, 15)
+	erase.moveTo(sim, 9, 0)
+	if got := sim.Cells.Population(); got != 7 {
+		t.Fatalf("erasing the upright left %d cells, want the 7 of the first run", got)
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// errorQueueSize is how many failures the error banner holds waiting
+	// to be seen; past that they're only logged.
+	errorQueueSize = 8
+	// errorBannerLines is the most lines of a failure the banner shows.
+	errorBannerLines = 4
+)
+
+// errorReport is a failure the program carried on after: what failed, why,
+// and the details logged with it, as for slog.
+type errorReport struct {
+	message string
+	err     error
+	attrs   []any
+}
+
+// reportError logs a failure the program carries on after, such as a
+// screenshot that couldn't be written, and shows it in the window's error
+// banner. It can be called from any goroutine and never waits: with the
+// banner's queue full, or no window, the failure is only logged.
+func (rs *runState) reportError(message string, err error, attrs ...any) {
+	slog.Error(message, append([]any{"err", err}, attrs...)...)
+	rs.showError(message, err, attrs...)
+}
+
+// showError shows a failure that's been logged already in the error banner.
+func (rs *runState) showError(message string, err error, attrs ...any) {
+	select {
+	case rs.errorReports <- errorReport{message: message, err: err, attrs: attrs}:
+	default:
+	}
+}
+
+// String is the report in a line: the message, capitalised, why, and the
+// details as key=value.
+func (r errorReport) String() string {
+	var b strings.Builder
+	if first, size := utf8.DecodeRuneInString(r.message); size > 0 {
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(r.message[size:])
+	}
+	if r.err != nil {
+		b.WriteString(": " + shaderErrorSummary(r.err))
+	}
+	record := slog.NewRecord(time.Time{}, slog.LevelError, "", 0)
+	record.Add(r.attrs...)
+	record.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&b, "  %s=%s", a.Key, a.Value)
+		return true
+	})
+	return b.String()
+}
+
+// queuedError is a failure waiting in the error banner, and how many times
+// it's been reported.
+type queuedError struct {
+	line  string
+	count int
+}
+
+// errorBanner shows failures the program carried on after in a red band
+// across the top of the window, one at a time, oldest first, each until
+// it's dismissed or has been up for -error-timeout. A failure reported
+// again while it's waiting is counted rather than queued twice, and coming
+// up again while showing keeps it up.
+type errorBanner struct {
+	rs *runState
+
+	queue []queuedError
+	// shown is when the first in the queue came up.
+	shown time.Time
+
+	program    *overlayProgram
+	background *lines
+	text       *text
+}
+
+func (rs *runState) newErrorBanner(program *overlayProgram) *errorBanner {
+	return &errorBanner{
+		rs:         rs,
+		program:    program,
+		background: rs.newLines("error banner background", 6),
+		text:       rs.newText(program, 512),
+	}
+}
+
+// collect takes the failures reported since the last frame.
+func (b *errorBanner) collect(now time.Time) {
+	for {
+		select {
+		case r := <-b.rs.errorReports:
+			b.add(r.String(), now)
+		default:
+			return
+		}
+	}
+}
+
+func (b *errorBanner) add(line string, now time.Time) {
+	for i := range b.queue {
+		if b.queue[i].line == line {
+			b.queue[i].count++
+			if i == 0 {
+				b.shown = now
+			}
+			return
+		}
+	}
+	if len(b.queue) == errorQueueSize {
+		return
+	}
+	if len(b.queue) == 0 {
+		b.shown = now
+	}
+	b.queue = append(b.queue, queuedError{line: line, count: 1})
+}
+
+// dismiss takes down the failure showing, bringing up the next.
+func (b *errorBanner) dismiss() {
+	if len(b.queue) > 0 {
+		b.queue = b.queue[1:]
+		b.shown = time.Now()
+	}
+}
+
+func (b *errorBanner) draw() {
+	now := time.Now()
+	b.collect(now)
+	if b.rs.config.ErrorTimeout > 0 && len(b.queue) > 0 && now.Sub(b.shown) > b.rs.config.ErrorTimeout {
+		b.dismiss()
+	}
+	if len(b.queue) == 0 {
+		return
+	}
+
+	q := b.queue[0]
+	line := q.line
+	if q.count > 1 {
+		line += fmt.Sprintf(" (%d times)", q.count)
+	}
+	charWidth, lineHeight := b.rs.textSize(" ")
+	lines := wrapLine(line, int((2-lineHeight)/charWidth), "  ")
+	if len(lines) > errorBannerLines {
+		lines = append(lines[:errorBannerLines-1], "  ... (the rest is in the log)")
+	}
+	hint := "X: dismiss"
+	if n := len(b.queue) - 1; n > 0 {
+		hint += fmt.Sprintf("  (%d more)", n)
+	}
+	lines = append(lines, hint)
+
+	minY := 1 - float32(len(lines))*lineHeight - lineHeight/2
+	fill(b.background, b.program, -1, minY, 1, 1, 0.6, 0.05, 0.05, 0.9)
+	b.text.reset()
+	for i, l := range lines {
+		b.text.print(l, -1+lineHeight/2, 1-lineHeight/4-float32(i)*lineHeight)
+	}
+	b.text.draw(1, 1, 1, 1)
+}
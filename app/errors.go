@@ -0,0 +1,14 @@
+package app
+
+// A WindowError is a failure to open the window or create its OpenGL
+// context, e.g. with no display or no driver for OpenGL 3.3 or later.
+type WindowError struct{ Err error }
+
+func (e *WindowError) Error() string { return "couldn't open a window: " + e.Err.Error() }
+func (e *WindowError) Unwrap() error { return e.Err }
+
+// A GLError is a failure to load OpenGL's functions once there's a context.
+type GLError struct{ Err error }
+
+func (e *GLError) Error() string { return "couldn't load OpenGL: " + e.Err.Error() }
+func (e *GLError) Unwrap() error { return e.Err }
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"opengl/life"
+)
+
+// cycleWindow is how many generations back a board is looked for in to
+// find it repeating.
+const cycleWindow = 256
+
+// eventLog logs notable things happening to the boards, each with the
+// generation it happened at, through slog so it can be read back by a
+// program.
+type eventLog struct {
+	log    *slog.Logger
+	sims   []*life.Simulation
+	boards []boardEvents
+}
+
+// boardEvents is what the detectors remember of a board.
+type boardEvents struct {
+	alive  bool
+	record int
+	// seen maps the hashes of the last cycleWindow generations to the
+	// latest generation each was seen at, and recent holds each hash and
+	// generation in order.
+	seen   map[uint64]int
+	recent []seenHash
+	// period is that of the cycle last logged, or 0 if the board isn't in
+	// one.
+	period int
+}
+
+type seenHash struct {
+	hash       uint64
+	generation int
+}
+
+// newEventLog logs sims' events to the logger startLogging set up.
+func newEventLog(sims []*life.Simulation) *eventLog {
+	e := &eventLog{log: slog.Default(), sims: sims, boards: make([]boardEvents, len(sims))}
+	e.reset()
+	return e
+}
+
+// event logs something that happened to sim, or to every board if sim is
+// nil.
+func (e *eventLog) event(level slog.Level, sim *life.Simulation, msg string, args ...any) {
+	if sim == nil {
+		sim = e.sims[0]
+	} else if len(e.sims) > 1 {
+		for i, s := range e.sims {
+			if s == sim {
+				args = append(args, "board", i)
+			}
+		}
+	}
+	e.log.Log(context.Background(), level, msg, append([]any{"generation", sim.Generation}, args...)...)
+}
+
+func (e *eventLog) info(sim *life.Simulation, msg string, args ...any) {
+	e.event(slog.LevelInfo, sim, msg, args...)
+}
+
+// reset starts the detectors afresh, for when the boards have been
+// replaced rather than stepped.
+func (e *eventLog) reset() {
+	for i, sim := range e.sims {
+		pop := sim.Cells.Population()
+		e.boards[i] = boardEvents{alive: pop > 0, record: pop, seen: make(map[uint64]int)}
+	}
+}
+
+// stepped runs the detectors over the boards after a step: whether they've
+// died out, reached a new population or started repeating.
+func (e *eventLog) stepped() {
+	for i, sim := range e.sims {
+		b := &e.boards[i]
+		pop := sim.Cells.Population()
+		if b.alive && pop == 0 {
+			e.info(sim, "extinction")
+		}
+		b.alive = pop > 0
+		// Growing boards set records most generations, so they're only
+		// put together to be logged when they will be.
+		if pop > b.record {
+			b.record = pop
+			if e.log.Enabled(context.Background(), slog.LevelDebug) {
+				e.event(slog.LevelDebug, sim, "population record", "population", pop)
+			}
+		}
+		if pop == 0 {
+			continue
+		}
+
+		h := sim.Cells.Hash()
+		if then, ok := b.seen[h]; ok && then < sim.Generation {
+			if period := sim.Generation - then; period != b.period {
+				b.period = period
+				e.info(sim, "cycle", "period", period, "population", pop)
+			}
+		} else {
+			b.period = 0
+		}
+		if len(b.recent) == cycleWindow {
+			if old := b.recent[0]; b.seen[old.hash] == old.generation {
+				delete(b.seen, old.hash)
+			}
+			b.recent = b.recent[1:]
+		}
+		b.seen[h] = sim.Generation
+		b.recent = append(b.recent, seenHash{h, sim.Generation})
+	}
+}
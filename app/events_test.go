@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestEventLogScripted runs a scenario headless and checks the events it
+// logs, and the order they come in: a blinker repeats, dies out under a
+// rule without survival, and the board is reseeded, back to generation 0.
+func TestEventLogScripted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Density, cfg.Generations = 16, 16, 0, 100
+	cfg.Scenario = filepath.Join(t.TempDir(), "script.json")
+	script := `[
+		{"at": 0, "do": "stamp blinker 8 8"},
+		{"at": 4, "do": "rule B3/S"},
+		{"at": 8, "do": "seed 9"},
+		{"at": 10, "do": "quit"}
+	]`
+	if err := os.WriteFile(cfg.Scenario, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logged, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg.Headless = true
+	rs := testRun(t, cfg)
+	var out bytes.Buffer
+	rs.stdout = &out
+	var err error
+	if rs.schedule, err = rs.loadScenario(cfg.Scenario); err != nil {
+		t.Fatal(err)
+	}
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.runHeadless(seeds, rules, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	lines := bufio.NewScanner(&logged)
+	for lines.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &record); err != nil {
+			t.Fatalf("log line %q isn't JSON: %v", lines.Text(), err)
+		}
+		if gen, ok := record["generation"]; ok {
+			got = append(got, fmt.Sprintf("%v %v", gen, record["msg"]))
+		}
+	}
+	want := []string{
+		"0 Stamped blinker at 8,8",
+		"1 population record",
+		"3 cycle",
+		"4 rule change",
+		"4 Rule B3/S",
+		"6 extinction",
+		"0 reseed",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("the run logged\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxFetchSize bounds a downloaded pattern file.
+const maxFetchSize = 8 << 20
+
+// fetchTimeout is how long a pattern has to download, a variable for tests
+// not to wait it out.
+var fetchTimeout = 15 * time.Second
+
+// isURL reports whether a -pattern argument is a URL to fetch rather than a
+// file.
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// fetchPattern downloads the pattern at rawURL to a file, returning its path.
+// The file keeps the URL's extension, or gets one from the content type, so
+// loadPattern can tell its format; failing both, loadPattern sniffs it.
+// With -pattern-cache off, loadPattern removes the file once it's read it.
+func (rs *runState) fetchPattern(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	ext := strings.ToLower(path.Ext(u.Path))
+	dir, err := os.UserCacheDir()
+	if err != nil || !rs.config.PatternCache {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "golang-opengl", "patterns")
+	name := filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	if rs.config.PatternCache {
+		matches, _ := filepath.Glob(name + "*")
+		for _, m := range matches {
+			// A .tmp file is a download that never finished.
+			if filepath.Ext(m) != ".tmp" {
+				return m, nil
+			}
+		}
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: %s", rawURL, resp.Status)
+	}
+	if resp.ContentLength > maxFetchSize {
+		return "", fmt.Errorf("fetching %s: %d bytes is more than the %d allowed", rawURL, resp.ContentLength, maxFetchSize)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return "", fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	if len(body) > maxFetchSize {
+		return "", fmt.Errorf("fetching %s: more than the %d bytes allowed", rawURL, maxFetchSize)
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "<") {
+		return "", errors.New("fetching " + rawURL + ": got a web page, not a pattern; link to the raw file instead")
+	}
+
+	switch ext {
+	case ".rle", ".cells", ".life", ".lif", ".mc":
+	default:
+		ext = ""
+		if strings.Contains(resp.Header.Get("Content-Type"), "rle") {
+			ext = ".rle"
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	// The file is written alongside first and then moved into place, so
+	// that one half written is never taken for a cached pattern.
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, name+ext); err != nil {
+		return "", err
+	}
+	if !rs.config.PatternCache {
+		rs.download = name + ext
+	}
+	return name + ext, nil
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const gliderRLE = "x = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"
+
+// testFetch returns a run fetching patterns into a cache directory, or a
+// temporary one with cache off, of the test's own.
+func testFetch(t *testing.T, cache bool) *runState {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.PatternCache = cache
+	return testRun(t, cfg)
+}
+
+// TestFetchFails checks a pattern that isn't found, takes too long, is too
+// big or is a web page isn't fetched, and says why.
+func TestFetchFails(t *testing.T) {
+	defer func(timeout time.Duration) { fetchTimeout = timeout }(fetchTimeout)
+	fetchTimeout = 100 * time.Millisecond
+	big := strings.Repeat("o", maxFetchSize+1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow.rle":
+			<-r.Context().Done()
+		case "/big.rle":
+			w.Header().Set("Content-Length", fmt.Sprint(len(big)))
+			fmt.Fprint(w, big)
+		case "/big-unsized.rle":
+			// Flushing first sends no length, so only reading finds it's
+			// too big.
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, big)
+		case "/glider.html":
+			fmt.Fprint(w, "\n<!DOCTYPE html>\n<html><pre>"+gliderRLE+"</pre></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	rs := testFetch(t, true)
+	for _, c := range []struct{ path, want string }{
+		{"/missing.rle", "404 Not Found"},
+		{"/big.rle", fmt.Sprintf("%d bytes is more than the %d allowed", maxFetchSize+1, maxFetchSize)},
+		{"/big-unsized.rle", fmt.Sprintf("more than the %d bytes allowed", maxFetchSize)},
+		{"/glider.html", "got a web page, not a pattern"},
+	} {
+		if _, err := rs.fetchPattern(srv.URL + c.path); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("fetching %s: %v, want an error saying %q", c.path, err, c.want)
+		}
+	}
+	_, err := rs.fetchPattern(srv.URL + "/slow.rle")
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("fetching a pattern that never comes: %v, want a timeout", err)
+	}
+	dir, _ := os.UserCacheDir()
+	if files, _ := filepath.Glob(filepath.Join(dir, "golang-opengl", "patterns", "*")); len(files) > 0 {
+		t.Errorf("failed fetches left %v", files)
+	}
+}
+
+// TestFetchCaches checks a fetched pattern is kept, under the URL's
+// extension or else one from its content type, and a second fetch of it
+// served from the cache without asking again.
+func TestFetchCaches(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/x-life-rle")
+		fmt.Fprint(w, gliderRLE)
+	}))
+	defer srv.Close()
+
+	rs := testFetch(t, true)
+	for _, c := range []struct{ path, ext string }{{"/glider.rle", ".rle"}, {"/raw?id=glider", ".rle"}, {"/glider.cells", ".cells"}} {
+		requests.Store(0)
+		first, err := rs.fetchPattern(srv.URL + c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := rs.fetchPattern(srv.URL + c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second || filepath.Ext(first) != c.ext {
+			t.Errorf("%s: fetched to %s, then %s, want the same %s file", c.path, first, second, c.ext)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("%s: fetching twice made %d requests, want 1", c.path, n)
+		}
+		if src, err := os.ReadFile(first); err != nil || string(src) != gliderRLE {
+			t.Errorf("%s: cached %q, %v, want the pattern served", c.path, src, err)
+		}
+		if _, err := os.Stat(strings.TrimSuffix(first, c.ext) + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: the download was left alongside: %v", c.path, err)
+		}
+	}
+
+	// A download cut short is fetched afresh rather than taken from the
+	// cache.
+	path, err := rs.fetchPattern(srv.URL + "/glider.rle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, strings.TrimSuffix(path, ".rle")+".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	requests.Store(0)
+	if again, err := rs.fetchPattern(srv.URL + "/glider.rle"); err != nil || again != path || requests.Load() != 1 {
+		t.Errorf("after a download was cut short, fetched to %s, %v, in %d requests, want %s in 1", again, err, requests.Load(), path)
+	}
+}
+
+// TestFetchUncached checks that with -pattern-cache off every fetch asks
+// again, and the download goes once the pattern's loaded.
+func TestFetchUncached(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, gliderRLE)
+	}))
+	defer srv.Close()
+
+	rs := testFetch(t, false)
+	for i := 0; i < 2; i++ {
+		path, err := rs.fetchPattern(srv.URL + "/glider.rle")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _, err := rs.loadPattern(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Cells) != 5 {
+			t.Errorf("loaded a pattern of %d cells, want the glider's 5", len(p.Cells))
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the download is still there once loaded: %v", err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("fetching twice made %d requests, want 2", n)
+	}
+}
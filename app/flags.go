@@ -0,0 +1,236 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"opengl/render"
+)
+
+// RegisterFlags defines a flag on fs for each of c's command-line settings,
+// defaulting to its current value.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(sizeValue{&c.GridWidth, &c.GridHeight}, "size", "board size in cells, as `WxH`")
+	fs.Var(sizeValue{&c.WindowWidth, &c.WindowHeight}, "window-size", "window size, as `WxH`")
+	fs.BoolVar(&c.ResetWindow, "reset-window", c.ResetWindow, "open the window where the window system puts it, not where it was last time")
+	fs.StringVar(&c.Renderer, "renderer", c.Renderer, "what to draw the board with: "+strings.Join(renderers, " or ")+"; terminal draws in this terminal, with keys space to pause, n to step, + and - to change speed and q to quit")
+	fs.BoolVar(&c.TerminalBraille, "terminal-braille", c.TerminalBraille, "draw with Braille dots, fitting eight cells in a character, rather than half blocks with -renderer terminal")
+	fs.StringVar(&c.TerminalGraphics, "terminal-graphics", c.TerminalGraphics, "draw -renderer terminal's board as a picture with a terminal graphics protocol: "+strings.Join(terminalGraphics, ", ")+"; auto uses sixel or kitty if the environment says the terminal has it, unless -terminal-braille")
+	fs.Float64Var(&c.TickRate, "speed", c.TickRate, "generations a second to start at")
+	fs.Float64Var(&c.TickRate, "tick-rate", c.TickRate, "the same as -speed")
+	fs.Float64Var(&c.FrameRate, "frame-rate", c.FrameRate, "frames a second to draw the window and take input at, whatever the speed")
+	fs.IntVar(&c.Windows, "windows", c.Windows, "windows to open onto the boards, each after the first with its own camera")
+	fs.Float64Var(&c.BackgroundFPS, "background-fps", c.BackgroundFPS, "frames a second to draw the window at while it's unfocused or minimised, without slowing the boards; 0 keeps to -frame-rate")
+	fs.BoolVar(&c.VSync, "vsync", c.VSync, "draw in step with the display's refresh instead, up to -frame-rate")
+	fs.Float64Var(&c.LODThreshold, "lod-threshold", c.LODThreshold, "on-screen cell size, in pixels, below which the board is drawn smoothly as how dense its cells are, rather than cell by cell; 0 draws every cell")
+	fs.DurationVar(&c.ErrorTimeout, "error-timeout", c.ErrorTimeout, "how long the red banner shows an error the game carried on after, such as a failed screenshot; 0 keeps it up until dismissed with X")
+	fs.Float64Var(&c.Density, "density", c.Density, "fraction of cells alive in a random board, from 0 to 1")
+	fs.Var(colourValue{&c.Colour}, "colour", "colour of live cells, as `#rrggbb`")
+	fs.Var(colourValue{&c.Background}, "background", "colour behind the cells, as `#rrggbb`")
+	fs.IntVar(&c.History, "history", c.History, "number of generations of statistics kept, for the population graph and the API's GET /stats")
+	fs.IntVar(&c.Rewind, "rewind", c.Rewind, "number of generations that can be rewound with Backspace")
+	fs.BoolVar(&c.Follow, "follow", c.Follow, "keep the live pattern framed every generation")
+	fs.BoolVar(&c.Wrap, "wrap", c.Wrap, "wrap the board's edges around into a torus")
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "seed for the initial random board (default: time-based)")
+	fs.StringVar(&c.CompareSeeds, "compare-seeds", c.CompareSeeds, "run two boards side by side from a comma-separated pair of seeds")
+	fs.StringVar(&c.Views, "views", c.Views, "run a grid of independent boards, e.g. 2x2")
+	fs.StringVar(&c.Rules, "rules", c.Rules, "comma-separated rule for every view, or one rule per view")
+	fs.StringVar(&c.Scenario, "scenario", c.Scenario, "JSON file of console commands to run at given generations, e.g. to stamp patterns or change the rule")
+	fs.StringVar(&c.Automaton, "automaton", c.Automaton, "registered automaton for every view to run in place of -rules, as `name` or name:config, e.g. brians-brain or life:B36/S23, or list to list them and exit")
+	fs.StringVar(&c.RuleScript, "rule-script", c.RuleScript, "file with a rule written as an expression of alive, neighbors and age, for every view in place of -rules")
+	fs.StringVar(&c.GollyRule, "golly-rule", c.GollyRule, "Golly .rule file with a rule table for every view to run in place of -rules, e.g. examples/golly/WireWorld.rule")
+	fs.StringVar(&c.RenderOut, "render-out", c.RenderOut, "render the board to this PNG file without showing a window, then exit")
+	fs.StringVar(&c.RenderSize, "render-size", c.RenderSize, "image size for -render-out")
+	fs.IntVar(&c.Generations, "generations", c.Generations, "generations to run before rendering with -render-out, or before exiting with -headless, which otherwise runs until interrupted")
+	fs.StringVar(&c.Icon, "icon", c.Icon, "PNG to use as the window icon instead of the built-in glider")
+	fs.StringVar(&c.FragShader, "frag-shader", c.FragShader, "GLSL fragment shader file to draw the cells with")
+	fs.StringVar(&c.ShaderDir, "shader-dir", c.ShaderDir, "directory the cell.vert and cell.frag shaders are loaded and hot-reloaded from")
+	fs.BoolVar(&c.StepPauses, "step-pauses", c.StepPauses, "make the step key pause a running simulation instead of being ignored")
+	fs.BoolVar(&c.HelpPauses, "help-pauses", c.HelpPauses, "pause the simulation while the help is shown")
+	fs.BoolVar(&c.Edit, "edit", c.Edit, "start paused on an empty board with the grid shown, for building patterns")
+	fs.BoolVar(&c.EditPauses, "edit-pauses", c.EditPauses, "pause the simulation when a cell is edited with the mouse")
+	fs.StringVar(&c.Bindings, "bindings", c.Bindings, "JSON file of key bindings to use instead of the defaults (default bindings.json, if there is one)")
+	fs.IntVar(&c.ScreenshotScale, "screenshot-scale", c.ScreenshotScale, "render screenshots at this multiple of the window resolution")
+	fs.StringVar(&c.ScreenshotDir, "screenshot-dir", c.ScreenshotDir, "directory screenshots are saved in")
+	fs.StringVar(&c.Pattern, "pattern", c.Pattern, "pattern file (.rle, .cells or .life) to start from, centred on the board")
+	fs.BoolVar(&c.PatternRule, "pattern-rule", c.PatternRule, "switch to the rule named in a loaded pattern's header")
+	fs.IntVar(&c.PatternLimit, "pattern-limit", c.PatternLimit, "refuse to load patterns with more live cells than this")
+	fs.StringVar(&c.PatternDir, "pattern-dir", c.PatternDir, "directory of .rle and .cells files for the pattern picker to list (default patterns in your config directory)")
+	fs.BoolVar(&c.PatternCache, "pattern-cache", c.PatternCache, "keep patterns fetched from URLs in your cache directory and reuse them")
+	fs.StringVar(&c.ImportPBM, "import-pbm", c.ImportPBM, "PBM or PGM image to start from, a pixel per cell, as written by export-pbm")
+	fs.StringVar(&c.Load, "load", c.Load, "named save, state file written by the console's save command, or checkpoint (or directory of them, for the latest) to carry on from")
+	fs.BoolVar(&c.PrintCaps, "print-caps", c.PrintCaps, "print the OpenGL context the driver makes, what it can do, and which optional features the other flags would get, and exit")
+	fs.BoolVar(&c.ListPatterns, "list-patterns", c.ListPatterns, "list the built-in patterns -pattern can load by name, and exit")
+	fs.IntVar(&c.ClustersEvery, "clusters-every", c.ClustersEvery, "count the first board's clusters of touching live cells, for the title and -stats-out, every this many generations, or 0 not to")
+	fs.IntVar(&c.CensusEvery, "census-every", c.CensusEvery, "log a census of the objects on the board every this many generations, or 0 not to")
+	fs.IntVar(&c.CheckpointEvery, "checkpoint-every", c.CheckpointEvery, "write a compressed state file every this many generations, or 0 not to")
+	fs.StringVar(&c.CheckpointDir, "checkpoint-dir", c.CheckpointDir, "directory -checkpoint-every writes to (default checkpoints in your config directory)")
+	fs.IntVar(&c.CheckpointKeep, "checkpoint-keep", c.CheckpointKeep, "how many of the most recent checkpoints to keep")
+	fs.BoolVar(&c.Headless, "headless", c.Headless, "run the boards flat out without a window or OpenGL, printing where they got to on exit")
+	fs.BoolVar(&c.Demo, "demo", c.Demo, "cycle through a playlist of showcase patterns and rules, for leaving running on a screen")
+	fs.DurationVar(&c.DemoDuration, "demo-duration", c.DemoDuration, "how long each -demo scene runs for")
+	fs.StringVar(&c.LogFile, "log-file", c.LogFile, "write the log, events included, to this file as JSON lines, instead of to standard error as text")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "the least severe messages logged: debug, info, warn or error; debug adds a report of the OpenGL driver and monitors")
+	fs.BoolFunc("v", "the same as -log-level debug", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if v {
+			c.LogLevel = "debug"
+		}
+		return err
+	})
+	fs.StringVar(&c.API, "api", c.API, "serve a JSON API to control the boards at this address, e.g. :8080: GET /state and /board, POST /pause, /resume, /step and /reset, and PUT /cells and /rule")
+	fs.StringVar(&c.GRPC, "grpc", c.GRPC, "serve the gRPC control service in proto/life.proto at this address, e.g. :9090: the -api requests, the board in chunks, a stream of every generation, and named saves")
+	fs.StringVar(&c.Host, "host", c.Host, "let other instances -join the first board at this address, e.g. :7777, running it for them and making their edits")
+	fs.StringVar(&c.Join, "join", c.Join, "draw and edit the first board of the instance run with -host at this address, e.g. localhost:7777, instead of running one")
+	fs.StringVar(&c.Metrics, "metrics", c.Metrics, "serve Prometheus metrics of the generation, population, births, deaths, frame times and GL uploads at this address's /metrics, e.g. :9100")
+	fs.StringVar(&c.PProf, "pprof", c.PProf, "serve net/http/pprof's CPU, heap and other profiles at this address, e.g. :6060")
+	fs.StringVar(&c.GLVersion, "gl", c.GLVersion, "the newest OpenGL core version to ask for, one of "+strings.Join(contextVersionNames(), ", ")+" (default the newest the driver has), or es for OpenGL ES 3")
+	fs.BoolVar(&c.GLDebug, "gl-debug", c.GLDebug, "ask for an OpenGL debug context and log the driver's debug messages")
+	fs.StringVar(&c.GLDebugSeverity, "gl-debug-severity", c.GLDebugSeverity, "the least severe -gl-debug messages logged: "+strings.Join(render.DebugSeverities, ", "))
+	fs.BoolVar(&c.GLDebugPanic, "gl-debug-panic", c.GLDebugPanic, "panic on the OpenGL errors -gl-debug reports, with the stack of the call that caused them")
+	fs.BoolVar(&c.Versus, "versus", c.Versus, "play two-player Life: take turns placing cells, then see whose survive")
+	fs.IntVar(&c.VersusCells, "versus-cells", c.VersusCells, "how many cells each player places in a -versus game")
+	fs.IntVar(&c.VersusGenerations, "versus-generations", c.VersusGenerations, "how many generations a -versus game runs for")
+	fs.BoolVar(&c.Gamepad, "gamepad", c.Gamepad, "control the board with the first connected gamepad")
+	fs.Float64Var(&c.GamepadDeadZone, "gamepad-dead-zone", c.GamepadDeadZone, "stick and trigger travel ignored around the rest position, from 0 to 1")
+	fs.StringVar(&c.GamepadMap, "gamepad-map", c.GamepadMap, "comma-separated gamepad button=command pairs, using the command names from -bindings")
+	fs.IntVar(&c.GIFMaxFrames, "gif-max-frames", c.GIFMaxFrames, "stop recording a GIF after this many frames")
+	fs.IntVar(&c.GIFMaxSize, "gif-max-size", c.GIFMaxSize, "largest width or height of a recorded GIF in pixels; bigger boards are scaled down")
+	fs.StringVar(&c.RecordFrames, "record-frames", c.RecordFrames, "record a PNG per generation to files named by this pattern, e.g. out/frame_%05d.png, while the record key is toggled on")
+	fs.StringVar(&c.RecordSize, "record-size", c.RecordSize, "resolution recorded frames are rendered at")
+	fs.StringVar(&c.RecordReplay, "record-replay", c.RecordReplay, "log the session to this file for -play-replay to reproduce")
+	fs.StringVar(&c.PlayReplay, "play-replay", c.PlayReplay, "reproduce a session logged by -record-replay, failing if it comes out differently")
+	fs.StringVar(&c.SavesDir, "saves-dir", c.SavesDir, "directory named saves are kept in (default saves in your config directory)")
+	fs.StringVar(&c.SeedImage, "seed-image", c.SeedImage, "PNG or JPEG image to start from, scaled to the board and thresholded into live and dead cells")
+	fs.Float64Var(&c.SeedThreshold, "seed-threshold", c.SeedThreshold, "brightness, from 0 to 1, above which -seed-image pixels become live cells")
+	fs.BoolVar(&c.SeedDither, "seed-dither", c.SeedDither, "dither -seed-image rather than thresholding it outright, to keep its shading")
+	fs.BoolVar(&c.SeedInvert, "seed-invert", c.SeedInvert, "make the dark parts of -seed-image live instead of the bright ones")
+	fs.StringVar(&c.SeedFit, "seed-fit", c.SeedFit, "how -seed-image is fitted to the board: crop to fill it, or letterbox to show all of it")
+	fs.BoolVar(&c.Resume, "resume", c.Resume, "carry on from where the last run left off, saving the state on exit and every -autosave-interval")
+	fs.DurationVar(&c.AutosaveInterval, "autosave-interval", c.AutosaveInterval, "how often -resume saves the state while running, or 0 to only save on exit")
+	fs.StringVar(&c.StatsOut, "stats-out", c.StatsOut, "append a CSV row of generation, population, births, deaths, board hash, activity and block entropy to this file every generation")
+	fs.BoolVar(&c.Audio, "audio", c.Audio, "sonify the first board: a blip for each generation's births, higher the more there are, and a low tone when it dies out, played through aplay, paplay or ffplay; shift+m mutes it")
+	fs.Float64Var(&c.AudioVolume, "audio-volume", c.AudioVolume, "-audio's volume, from 0 to 1")
+	fs.StringVar(&c.LEDOut, "led-out", c.LEDOut, "send the board to an LED matrix at this UDP `address`, such as 192.168.1.50:21324, at up to -speed frames a second")
+	fs.StringVar(&c.LEDSize, "led-size", c.LEDSize, "the LED matrix's size in LEDs, as `WxH`; the board is scaled to fit")
+	fs.StringVar(&c.LEDProtocol, "led-protocol", c.LEDProtocol, "how to send to the LEDs: wled, for WLED's UDP realtime protocol, or e131, for E1.31 (sACN)")
+	fs.IntVar(&c.LEDUniverse, "led-universe", c.LEDUniverse, "the first E1.31 universe, with 170 LEDs to a universe")
+	fs.IntVar(&c.Timelapse, "timelapse", c.Timelapse, "while recording frames, a GIF or video, capture only every this many generations, running flat out in between")
+	fs.IntVar(&c.TorusMajor, "torus-major", c.TorusMajor, "segments around the torus ring in the torus view (default 4 per column)")
+	fs.IntVar(&c.TorusMinor, "torus-minor", c.TorusMinor, "segments around the torus tube in the torus view (default 2 per row)")
+	fs.StringVar(&c.RecordVideo, "record-video", c.RecordVideo, "record the session to a video file, e.g. out.mp4, through ffmpeg, which must be on the PATH")
+	fs.Float64Var(&c.VideoFPS, "video-fps", c.VideoFPS, "frames a second captured for -record-video")
+	fs.BoolVar(&c.VideoDrop, "video-drop", c.VideoDrop, "drop frames when ffmpeg falls behind with -record-video, instead of waiting for it")
+	fs.BoolVar(&c.Widget, "widget", c.Widget, "float the board over the desktop in a transparent, undecorated, always-on-top window")
+	fs.StringVar(&c.WidgetSize, "widget-size", c.WidgetSize, "size of the -widget window")
+	fs.StringVar(&c.WidgetPos, "widget-pos", c.WidgetPos, "screen position of the -widget window, e.g. 100,100 (default: left to the window manager)")
+	fs.BoolVar(&c.ClickThrough, "click-through", c.ClickThrough, "let mouse clicks pass through the -widget window to whatever is behind it")
+}
+
+// flagGroups is the order flags are listed in by PrintFlags. Flags in no
+// group are listed last.
+var flagGroups = []struct {
+	title string
+	flags []string
+}{
+	{"Board", []string{"size", "rules", "automaton", "rule-script", "golly-rule", "seed", "density", "wrap", "views", "compare-seeds", "rewind"}},
+	{"Starting point", []string{"pattern", "pattern-rule", "pattern-limit", "pattern-cache", "list-patterns", "load", "resume", "autosave-interval", "seed-image", "seed-threshold", "seed-dither", "seed-invert", "seed-fit", "import-pbm", "edit"}},
+	{"Display", []string{"renderer", "terminal-braille", "terminal-graphics", "window-size", "reset-window", "windows", "speed", "tick-rate", "frame-rate", "background-fps", "vsync", "lod-threshold", "error-timeout", "colour", "background", "follow", "history", "icon", "frag-shader", "shader-dir", "torus-major", "torus-minor", "widget", "widget-size", "widget-pos", "click-through", "audio", "audio-volume"}},
+	{"Controls", []string{"bindings", "step-pauses", "help-pauses", "edit-pauses", "gamepad", "gamepad-dead-zone", "gamepad-map"}},
+	{"Modes", []string{"headless", "scenario", "demo", "demo-duration", "versus", "versus-cells", "versus-generations"}},
+	{"Output", []string{"render-out", "render-size", "generations", "screenshot-scale", "screenshot-dir", "record-frames", "record-size", "record-video", "video-fps", "video-drop", "gif-max-frames", "gif-max-size", "timelapse", "stats-out", "led-out", "led-size", "led-protocol", "led-universe"}},
+	{"Saves and logs", []string{"saves-dir", "pattern-dir", "checkpoint-every", "checkpoint-dir", "checkpoint-keep", "census-every", "clusters-every", "log-file", "log-level", "v", "record-replay", "play-replay"}},
+	{"Remote control", []string{"api", "grpc", "host", "join"}},
+	{"Debugging", []string{"gl", "print-caps", "gl-debug", "gl-debug-severity", "gl-debug-panic", "pprof", "metrics"}},
+}
+
+// flagAliases are flags that are other names for another's setting, and
+// so aren't written to config files.
+var flagAliases = map[string]string{"tick-rate": "speed", "v": "log-level"}
+
+// PrintFlags writes fs's flags to w as flag.PrintDefaults does, but in
+// flagGroups' groups.
+func PrintFlags(w io.Writer, fs *flag.FlagSet) {
+	listed := make(map[string]bool)
+	group := func(title string, flags []*flag.Flag) {
+		if len(flags) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, f := range flags {
+			listed[f.Name] = true
+			name, usage := flag.UnquoteUsage(f)
+			line := "  -" + f.Name
+			if name != "" {
+				line += " " + name
+			}
+			line += "\n    \t" + strings.ReplaceAll(usage, "\n", "\n    \t")
+			switch {
+			case f.DefValue == "" || f.DefValue == "0" || f.DefValue == "false" || f.DefValue == "0s":
+			case name == "string":
+				line += fmt.Sprintf(" (default %q)", f.DefValue)
+			default:
+				line += fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	for _, g := range flagGroups {
+		var flags []*flag.Flag
+		for _, name := range g.flags {
+			if f := fs.Lookup(name); f != nil {
+				flags = append(flags, f)
+			}
+		}
+		group(g.title, flags)
+	}
+	var rest []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		if !listed[f.Name] {
+			rest = append(rest, f)
+		}
+	})
+	group("Other", rest)
+}
+
+// sizeValue is a flag.Value for a width and height written as 30x30.
+type sizeValue struct{ w, h *int }
+
+func (v sizeValue) String() string {
+	if v.w == nil {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", *v.w, *v.h)
+}
+
+func (v sizeValue) Set(s string) error {
+	var w, h int
+	if _, err := fmt.Sscanf(strings.ToLower(s), "%dx%d", &w, &h); err != nil || w < 1 || h < 1 {
+		return fmt.Errorf("want the form 30x30")
+	}
+	*v.w, *v.h = w, h
+	return nil
+}
+
+// colourValue is a flag.Value for a colour written as #rrggbb.
+type colourValue struct{ c *color.RGBA }
+
+func (v colourValue) String() string {
+	if v.c == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", v.c.R, v.c.G, v.c.B)
+}
+
+func (v colourValue) Set(s string) error {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil || len(s) != 7 {
+		return fmt.Errorf("want the form #rrggbb")
+	}
+	*v.c = color.RGBA{r, g, b, 0xff}
+	return nil
+}
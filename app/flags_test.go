@@ -0,0 +1,118 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+)
+
+// parseArgs makes a Config from the default and an argv, as the life
+// command does.
+func parseArgs(args ...string) (*Config, *flag.FlagSet, error) {
+	cfg := new(Config)
+	*cfg = DefaultConfig()
+	fs := flag.NewFlagSet("life", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg.RegisterFlags(fs)
+	return cfg, fs, fs.Parse(args)
+}
+
+func TestRegisterFlags(t *testing.T) {
+	cfg, _, err := parseArgs("-size", "120X80", "-window-size=640x480", "-tick-rate", "12.5", "-colour", "#ff8000", "-wrap", "-seed", "-3", "-density", "0.25", "-renderer", "terminal", "-v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.GridWidth, want.GridHeight = 120, 80
+	want.WindowWidth, want.WindowHeight = 640, 480
+	want.TickRate = 12.5
+	want.Colour = color.RGBA{0xff, 0x80, 0x00, 0xff}
+	want.Wrap, want.Seed, want.Density = true, -3, 0.25
+	want.Renderer, want.LogLevel = "terminal", "debug"
+	for _, c := range []struct {
+		name      string
+		got, want any
+	}{
+		{"size", [2]int{cfg.GridWidth, cfg.GridHeight}, [2]int{want.GridWidth, want.GridHeight}},
+		{"window size", [2]int{cfg.WindowWidth, cfg.WindowHeight}, [2]int{want.WindowWidth, want.WindowHeight}},
+		{"speed", cfg.TickRate, want.TickRate},
+		{"colour", cfg.Colour, want.Colour},
+		{"wrap", cfg.Wrap, want.Wrap},
+		{"seed", cfg.Seed, want.Seed},
+		{"density", cfg.Density, want.Density},
+		{"renderer", cfg.Renderer, want.Renderer},
+		{"log level", cfg.LogLevel, want.LogLevel},
+		{"rules", cfg.Rules, want.Rules},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s is %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRegisterFlagsRejects checks bad flags fail to parse, and values that
+// parse but make no sense fail validation, before anything's opened.
+func TestRegisterFlagsRejects(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-sise", "10x10"}, "flag provided but not defined: -sise"},
+		{[]string{"-size", "10"}, "want the form 30x30"},
+		{[]string{"-size", "0x10"}, "want the form 30x30"},
+		{[]string{"-colour", "red"}, "want the form #rrggbb"},
+		{[]string{"-speed", "fast"}, `invalid value "fast" for flag -speed`},
+		{[]string{"-wrap=maybe"}, `invalid boolean value "maybe" for -wrap`},
+		{[]string{"-seed"}, "flag needs an argument: -seed"},
+	} {
+		if _, _, err := parseArgs(c.args...); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: parsing gave %v, want an error containing %q", c.args, err, c.want)
+		}
+	}
+	cfg, _, err := parseArgs("-density", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid density 2") {
+		t.Errorf("-density 2 validated as %v", err)
+	}
+}
+
+// TestPrintFlags checks the help lists every flag once, in its group, with
+// its default.
+func TestPrintFlags(t *testing.T) {
+	_, fs, _ := parseArgs()
+	var out strings.Builder
+	PrintFlags(&out, fs)
+	help := out.String()
+
+	last := -1
+	for _, g := range flagGroups {
+		i := strings.Index(help, "\n"+g.title+":\n")
+		if i < 0 || i < last {
+			t.Errorf("group %s is missing or out of order", g.title)
+		}
+		last = i
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if n := strings.Count(help, "\n  -"+f.Name+" ") + strings.Count(help, "\n  -"+f.Name+"\n"); n != 1 {
+			t.Errorf("-%s is listed %d times", f.Name, n)
+		}
+	})
+	d := DefaultConfig()
+	for _, want := range []string{
+		fmt.Sprintf("\n  -size WxH\n    \tboard size in cells, as WxH (default %dx%d)\n", d.GridWidth, d.GridHeight),
+		"(default \"info\")",
+		"\n  -wrap\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("the help doesn't contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//go:build egl
+
+package app
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/render"
+	"opengl/render/offscreen"
+)
+
+// TestFrameDrawsWithoutWindow draws a frame of two boards offscreen, as the
+// main loop does between taking input and swapping, through a mock
+// renderer. The run has no window, so drawing can neither swap nor poll.
+func TestFrameDrawsWithoutWindow(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	c, err := offscreen.New()
+	if errors.Is(err, offscreen.ErrUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 12, 10
+	rs := testRun(t, cfg)
+	defer rs.freeGL()
+	sims := testSims(t, rs, 1, 2)
+	stepAll(rs, sims, 3)
+	renderer := render.NewGL("../render/shaders", "")
+	if err := renderer.Init(12, 10); err != nil {
+		t.Fatal(err)
+	}
+	defer renderer.Shutdown()
+	mock := &mockRenderer{}
+	cam := rs.newCamera()
+	run := &windowRun{runState: rs, sims: sims, cells: sims[0].Cells, renderer: renderer, board: rs.newBoardTexture(), cam: cam}
+	run.sc = &scene{
+		rs: rs, sims: sims, views: layout{columns: 2, rows: 1}, renderer: mock,
+		brush: &brush{rs: rs}, selection: &selection{}, cursor: &editCursor{}, grid: &grid{}, cam: cam,
+	}
+
+	target, err := render.NewTarget(64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	target.Bind()
+	run.draw(64, 32)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if len(mock.frames) != 2 {
+		t.Fatalf("the renderer drew %d frames, want one per board", len(mock.frames))
+	}
+	for i, f := range mock.frames {
+		if !f.cells.Same(sims[i].Cells) {
+			t.Errorf("frame %d isn't board %d", i, i)
+		}
+		if f.view.Projection != [16]float32(cam.projection()) {
+			t.Errorf("frame %d was seen through %v, want the camera's", i, f.view)
+		}
+	}
+}
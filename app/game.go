@@ -0,0 +1,128 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+var teamNames = [...]string{"", "Blue", "Red"}
+
+// versus is a hot-seat game for two players. Blue owns the left half of the
+// board and Red the right; they take turns placing a cell each in their own
+// half until both have placed all of theirs, then the board runs for a set
+// number of generations and whoever has more cells left wins.
+type versus struct {
+	rs *runState
+
+	sim    *life.Simulation
+	budget int
+	turn   int
+	// left is how many cells each team still has to place.
+	left [3]int
+	text *text
+}
+
+func (rs *runState) newVersus(sim *life.Simulation, budget int, program *overlayProgram) *versus {
+	v := &versus{rs: rs, sim: sim, budget: budget, text: rs.newText(program, 96)}
+	v.reset()
+	return v
+}
+
+// reset clears the board for a new game, Blue to place first.
+func (v *versus) reset() {
+	v.sim.Clear()
+	v.turn = life.BlueTeam
+	v.left = [3]int{0, v.budget, v.budget}
+}
+
+func (v *versus) placing() bool {
+	return v.left[life.BlueTeam] > 0 || v.left[life.RedTeam] > 0
+}
+
+// running reports whether the board should be stepping: placing is done
+// and the generations haven't run out.
+func (v *versus) running() bool {
+	return !v.placing() && !v.over()
+}
+
+func (v *versus) over() bool {
+	return !v.placing() && v.sim.Generation >= v.rs.config.VersusGenerations
+}
+
+// half returns the team whose half of the board column x is in.
+func (rs *runState) half(x int) int {
+	if x < rs.config.GridWidth/2 {
+		return life.BlueTeam
+	}
+	return life.RedTeam
+}
+
+// place puts down a cell at (x, y) for the player whose turn it is, or says
+// why they can't.
+func (v *versus) place(x, y int) error {
+	if !v.placing() {
+		return errors.New("all the cells have been placed")
+	}
+	if v.rs.half(x) != v.turn {
+		return fmt.Errorf("%s places cells in the other half", teamNames[v.turn])
+	}
+	if v.sim.Cells.Alive(x, y) {
+		return errors.New("that cell is taken")
+	}
+	v.sim.Cells.SetCell(x, y, life.Cell{Alive: true, Age: 1, Team: v.turn})
+	v.left[v.turn]--
+	// A player with nothing left to place misses their turn.
+	if other := life.BlueTeam + life.RedTeam - v.turn; v.left[other] > 0 {
+		v.turn = other
+	}
+	return nil
+}
+
+func (v *versus) score() [3]int {
+	var n [3]int
+	for x := 0; x < v.sim.Cells.Columns(); x++ {
+		for y := 0; y < v.sim.Cells.Rows(); y++ {
+			if c := v.sim.Cells.At(x, y); c.Alive {
+				n[c.Team]++
+			}
+		}
+	}
+	return n
+}
+
+// summary describes how the game ended.
+func (v *versus) summary() string {
+	n := v.score()
+	result := "It's a draw"
+	if n[life.BlueTeam] > n[life.RedTeam] {
+		result = "Blue wins"
+	} else if n[life.RedTeam] > n[life.BlueTeam] {
+		result = "Red wins"
+	}
+	return fmt.Sprintf("%s, %d to %d", result, max(n[life.BlueTeam], n[life.RedTeam]), min(n[life.BlueTeam], n[life.RedTeam]))
+}
+
+// draw shows the scoreboard at the top of the window.
+func (v *versus) draw() {
+	n := v.score()
+	board := fmt.Sprintf("Blue %d  Red %d\n", n[life.BlueTeam], n[life.RedTeam])
+	switch {
+	case v.placing():
+		board += fmt.Sprintf("%s to place, %d left", teamNames[v.turn], v.left[v.turn])
+	case v.over():
+		board += v.summary() + " - C for a new game"
+	default:
+		board += fmt.Sprintf("Generation %d of %d", v.sim.Generation, v.rs.config.VersusGenerations)
+	}
+	w, _ := v.rs.textSize(board)
+	v.text.reset()
+	v.text.print(board, -w/2, 1)
+	r, g, b := render.CellColour(life.Cell{Team: v.turn})
+	if !v.placing() {
+		r, g, b = 1, 1, 1
+	}
+	v.text.draw(r, g, b, 1)
+}
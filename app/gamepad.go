@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// gamepadButtons names the buttons -gamepad-map can remap.
+var gamepadButtons = map[string]glfw.GamepadButton{
+	"a": glfw.ButtonA, "b": glfw.ButtonB, "x": glfw.ButtonX, "y": glfw.ButtonY,
+	"lb": glfw.ButtonLeftBumper, "rb": glfw.ButtonRightBumper,
+	"back": glfw.ButtonBack, "start": glfw.ButtonStart,
+	"up": glfw.ButtonDpadUp, "down": glfw.ButtonDpadDown,
+	"left": glfw.ButtonDpadLeft, "right": glfw.ButtonDpadRight,
+}
+
+// parseGamepadMap parses the -gamepad-map list against the commands in in.
+func parseGamepadMap(list string, in *input) (map[glfw.GamepadButton]string, error) {
+	m := make(map[glfw.GamepadButton]string)
+	for _, pair := range strings.Split(list, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		button, name, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		b, bok := gamepadButtons[strings.ToLower(strings.TrimSpace(button))]
+		_, cok := in.byName[name]
+		if !ok || !bok || !cok {
+			return nil, fmt.Errorf("invalid -gamepad-map entry %q: want button=command, e.g. a=pause", pair)
+		}
+		m[b] = name
+	}
+	return m, nil
+}
+
+// gamepad pans and zooms the camera with the sticks and triggers, and runs
+// input's commands for the buttons.
+type gamepad struct {
+	rs *runState
+
+	joy     glfw.Joystick
+	present bool
+
+	buttons map[glfw.GamepadButton]string
+	last    [len(glfw.GamepadState{}.Buttons)]glfw.Action
+}
+
+func (rs *runState) newGamepad(buttons map[glfw.GamepadButton]string) *gamepad {
+	g := &gamepad{rs: rs, buttons: buttons}
+	g.find()
+	glfw.SetJoystickCallback(func(joy glfw.Joystick, event glfw.PeripheralEvent) {
+		switch {
+		case event == glfw.Connected && !g.present:
+			g.find()
+		case event == glfw.Disconnected && g.present && joy == g.joy:
+			slog.Info("gamepad disconnected")
+			g.present = false
+			g.find()
+		}
+	})
+	return g
+}
+
+// find picks the first connected gamepad.
+func (g *gamepad) find() {
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		if joy.Present() && joy.IsGamepad() {
+			slog.Info("using gamepad", "name", joy.GetGamepadName())
+			g.joy, g.present = joy, true
+			g.last = [len(g.last)]glfw.Action{}
+			return
+		}
+	}
+}
+
+// update polls the gamepad, moving cam for the last dt seconds of stick and
+// trigger input and running the commands for any buttons pressed or
+// released.
+func (g *gamepad) update(cam *camera, in *input, dt float64) {
+	if !g.present {
+		return
+	}
+	state := g.joy.GetGamepadState()
+	if state == nil {
+		return
+	}
+
+	axis := func(a glfw.GamepadAxis) float32 {
+		v := state.Axes[a]
+		if math.Abs(float64(v)) < g.rs.config.GamepadDeadZone {
+			return 0
+		}
+		return v
+	}
+	// trigger maps a trigger's -1 (released) to 1 (pulled) onto 0 to 1.
+	trigger := func(a glfw.GamepadAxis) float32 {
+		return max(axis(a)+1, 0) / 2
+	}
+	panX, panY := axis(glfw.AxisLeftX), -axis(glfw.AxisLeftY)
+	zoom := -axis(glfw.AxisRightY) + trigger(glfw.AxisRightTrigger) - trigger(glfw.AxisLeftTrigger)
+	if panX != 0 || panY != 0 || zoom != 0 {
+		cam.to = nil
+		cam.zoom *= float32(math.Pow(zoomSpeed, dt*float64(zoom)))
+		step := float32(2*panSpeed*dt) / cam.zoom
+		cam.x += panX * step
+		cam.y += panY * step
+		cam.clamp()
+	}
+
+	for button, name := range g.buttons {
+		action := state.Buttons[button]
+		if action != g.last[button] {
+			in.runNamed(name, action)
+			g.last[button] = action
+		}
+	}
+}
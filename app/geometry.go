@@ -0,0 +1,194 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// windowSaveDelay is how long the window has to stay put after moving for
+// where it is to be saved, so dragging it doesn't write on every event.
+const windowSaveDelay = time.Second
+
+// windowGeometry is where the window was, kept between runs for it to open
+// there again.
+type windowGeometry struct {
+	// X and Y are the window's content area's top-left corner, and Width
+	// and Height its size, in screen coordinates.
+	X, Y          int
+	Width, Height int
+	// Monitor is the name of the monitor it was mostly on.
+	Monitor string `json:",omitempty"`
+}
+
+// windowGeometryPath is where the window's geometry is kept.
+func windowGeometryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "window.json"), nil
+}
+
+// loadWindowGeometry reads the saved geometry, reporting false if there is
+// none.
+func loadWindowGeometry() (windowGeometry, bool) {
+	var g windowGeometry
+	path, err := windowGeometryPath()
+	if err != nil {
+		return g, false
+	}
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return g, false
+	}
+	if err == nil {
+		err = json.Unmarshal(src, &g)
+	}
+	if err != nil {
+		slog.Warn("ignoring the saved window position", "path", path, "err", err)
+		return g, false
+	}
+	return g, g.Width > 0 && g.Height > 0
+}
+
+func saveWindowGeometry(g windowGeometry) error {
+	path, err := windowGeometryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(out, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// A monitorArea is a monitor's name and work area, the part of it not
+// taken by task bars and docks, in screen coordinates.
+type monitorArea struct {
+	name                string
+	x, y, width, height int
+}
+
+func monitorAreas() []monitorArea {
+	var areas []monitorArea
+	for _, m := range glfw.GetMonitors() {
+		x, y, w, h := m.GetWorkarea()
+		areas = append(areas, monitorArea{m.GetName(), x, y, w, h})
+	}
+	return areas
+}
+
+// overlap is how much of the rectangle at (x, y), w by h, is on a.
+func (a monitorArea) overlap(x, y, w, h int) int {
+	ow := min(x+w, a.x+a.width) - max(x, a.x)
+	oh := min(y+h, a.y+a.height) - max(y, a.y)
+	return max(ow, 0) * max(oh, 0)
+}
+
+// monitorOf returns the index of the monitor g is mostly on, or -1 if it's
+// on none.
+func monitorOf(g windowGeometry, monitors []monitorArea) int {
+	best, bestOverlap := -1, 0
+	for i, m := range monitors {
+		if o := m.overlap(g.X, g.Y, g.Width, g.Height); o > bestOverlap {
+			best, bestOverlap = i, o
+		}
+	}
+	return best
+}
+
+// placeOnMonitors returns where to put a window last at g, now w by h and
+// with a frame of left, top, right and bottom around it, so that it's on
+// a monitor as they're arranged now: the one it was on if that's still
+// there, or else the one it's mostly on, or else the primary one, moved
+// to be all on it if it fits or its top-left corner if not. It reports
+// false if there are no monitors.
+func placeOnMonitors(g windowGeometry, w, h int, frame [4]int, monitors []monitorArea) (x, y int, ok bool) {
+	if len(monitors) == 0 {
+		return 0, 0, false
+	}
+	i := -1
+	for j, m := range monitors {
+		if g.Monitor != "" && m.name == g.Monitor {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		i = max(monitorOf(windowGeometry{X: g.X, Y: g.Y, Width: w, Height: h}, monitors), 0)
+	}
+	m := monitors[i]
+	left, top, right, bottom := frame[0], frame[1], frame[2], frame[3]
+	fit := func(at, size, start, length, before, after int) int {
+		return max(min(at, start+length-size-after), start+before)
+	}
+	return fit(g.X, w, m.x, m.width, left, right), fit(g.Y, h, m.y, m.height, top, bottom), true
+}
+
+// restoreWindowGeometry moves window to where it was last run, if it was
+// saved and -reset-window isn't set.
+func (rs *runState) restoreWindowGeometry(window *glfw.Window) {
+	g, ok := loadWindowGeometry()
+	if !ok || rs.config.ResetWindow {
+		return
+	}
+	w, h := window.GetSize()
+	var frame [4]int
+	frame[0], frame[1], frame[2], frame[3] = window.GetFrameSize()
+	if x, y, ok := placeOnMonitors(g, w, h, frame, monitorAreas()); ok {
+		window.SetPos(x, y)
+	}
+}
+
+// windowTracker saves where the window is once it's stopped moving, and
+// when the game quits.
+type windowTracker struct {
+	window *glfw.Window
+	// moved is when the window last moved, or zero if it's been saved
+	// since.
+	moved time.Time
+}
+
+func trackWindowGeometry(window *glfw.Window) *windowTracker {
+	t := &windowTracker{window: window}
+	window.SetPosCallback(func(_ *glfw.Window, _, _ int) { t.moved = time.Now() })
+	return t
+}
+
+// update saves the geometry if the window has moved and stayed put for
+// windowSaveDelay.
+func (t *windowTracker) update(now time.Time) {
+	if !t.moved.IsZero() && now.Sub(t.moved) >= windowSaveDelay {
+		t.save()
+	}
+}
+
+// save saves the window's geometry now.
+func (t *windowTracker) save() {
+	t.moved = time.Time{}
+	var g windowGeometry
+	g.X, g.Y = t.window.GetPos()
+	g.Width, g.Height = t.window.GetSize()
+	monitors := monitorAreas()
+	if i := monitorOf(g, monitors); i >= 0 {
+		g.Monitor = monitors[i].name
+	}
+	if err := saveWindowGeometry(g); err != nil {
+		slog.Warn("couldn't save the window position", "err", err)
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import "testing"
+
+// testMonitors is a primary monitor with a task bar along its bottom and a
+// smaller one to its right, as their work areas.
+var testMonitors = []monitorArea{
+	{"DP-1", 0, 0, 1920, 1040},
+	{"HDMI-1", 1920, 0, 1280, 1024},
+}
+
+// TestMonitorOf checks a window is on the monitor most of it is on, or on
+// none if it's off all of them.
+func TestMonitorOf(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		g    windowGeometry
+		want int
+	}{
+		{"within the primary", windowGeometry{X: 100, Y: 100, Width: 800, Height: 600}, 0},
+		{"mostly on the right", windowGeometry{X: 1800, Y: 100, Width: 800, Height: 600}, 1},
+		{"mostly on the primary", windowGeometry{X: 1500, Y: 100, Width: 800, Height: 600}, 0},
+		{"below them both", windowGeometry{X: 100, Y: 1100, Width: 800, Height: 600}, -1},
+		{"left of them both", windowGeometry{X: -900, Y: 100, Width: 800, Height: 600}, -1},
+	} {
+		if got := monitorOf(c.g, testMonitors); got != c.want {
+			t.Errorf("%s: on monitor %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+// TestPlaceOnMonitors checks where a window saved at g opens as the
+// monitors are now: on the one it was on if that's still there, else the
+// one it's mostly on, else the primary, moved to be all on it, or its
+// top-left corner when it's too big, inside the window's frame.
+func TestPlaceOnMonitors(t *testing.T) {
+	frame := [4]int{2, 30, 2, 2}
+	for _, c := range []struct {
+		name     string
+		g        windowGeometry
+		w, h     int
+		monitors []monitorArea
+		x, y     int
+		ok       bool
+	}{
+		{"still on its monitor", windowGeometry{X: 2000, Y: 100, Monitor: "HDMI-1"}, 800, 600, testMonitors, 2000, 100, true},
+		{"back onto its monitor", windowGeometry{X: 100, Y: 100, Monitor: "HDMI-1"}, 800, 600, testMonitors, 1922, 100, true},
+		{"over the edge of its monitor", windowGeometry{X: 1500, Y: 900, Monitor: "DP-1"}, 800, 600, testMonitors, 1118, 438, true},
+		{"monitor gone, mostly on another", windowGeometry{X: 1800, Y: 100, Monitor: "DP-9"}, 800, 600, testMonitors, 1922, 100, true},
+		{"monitor gone, on none", windowGeometry{X: 100, Y: 1100, Monitor: "DP-9"}, 800, 600, testMonitors, 100, 438, true},
+		{"too big for its monitor", windowGeometry{X: 100, Y: 100, Monitor: "DP-1"}, 2500, 1200, testMonitors, 2, 30, true},
+		{"off to the top left", windowGeometry{X: -900, Y: -700}, 800, 600, testMonitors, 2, 30, true},
+		{"far off to the right", windowGeometry{X: 9000, Y: 100}, 800, 600, testMonitors, 1118, 100, true},
+		{"no monitors", windowGeometry{X: 100, Y: 100}, 800, 600, nil, 0, 0, false},
+	} {
+		x, y, ok := placeOnMonitors(c.g, c.w, c.h, frame, c.monitors)
+		if x != c.x || y != c.y || ok != c.ok {
+			t.Errorf("%s: placed at (%d, %d), %v, want (%d, %d), %v", c.name, x, y, ok, c.x, c.y, c.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+
+	"opengl/life"
+)
+
+// gifPalette has a colour for dead cells and each colour render.CellColour
+// gives a live one.
+func (rs *runState) gifPalette() color.Palette {
+	return color.Palette{
+		rs.config.Background,
+		rs.config.Colour,
+		color.RGBA{0x4c, 0x8c, 0xff, 0xff},
+		color.RGBA{0xff, 0x59, 0x4c, 0xff},
+	}
+}
+
+// gifIndex is the palette index a cell is drawn in.
+func gifIndex(c life.Cell) uint8 {
+	if !c.Alive {
+		return 0
+	}
+	return uint8(1 + c.Team)
+}
+
+// gifRecorder records the board as an animated GIF, drawing each generation
+// straight from the cells rather than reading back a rendered frame. While
+// recording it shows an indicator in the top-right corner.
+type gifRecorder struct {
+	rs *runState
+
+	recording bool
+	anim      gif.GIF
+	text      *text
+}
+
+func (rs *runState) newGIFRecorder(program *overlayProgram) *gifRecorder {
+	return &gifRecorder{rs: rs, text: rs.newText(program, 32)}
+}
+
+func (g *gifRecorder) start() {
+	g.recording = true
+	g.anim = gif.GIF{}
+}
+
+// add appends the board as the next frame, shown for one generation at
+// rate. It reports whether there is room for more.
+func (g *gifRecorder) add(cells life.Grid, rate float64) bool {
+	// Each cell is drawn as a square of pixels, or, on a board too big
+	// for that, pixels sample the cells.
+	scale := max(1, g.rs.config.GIFMaxSize/max(g.rs.config.GridWidth, g.rs.config.GridHeight))
+	w, h := min(g.rs.config.GridWidth*scale, g.rs.config.GIFMaxSize), min(g.rs.config.GridHeight*scale, g.rs.config.GIFMaxSize)
+	img := image.NewPaletted(image.Rect(0, 0, w, h), g.rs.gifPalette())
+	for py := 0; py < h; py++ {
+		y := g.rs.config.GridHeight - 1 - py*g.rs.config.GridHeight/h
+		for px := 0; px < w; px++ {
+			img.Pix[py*img.Stride+px] = gifIndex(cells.At(px*g.rs.config.GridWidth/w, y))
+		}
+	}
+	g.anim.Image = append(g.anim.Image, img)
+	// GIF delays are in hundredths of a second, and many viewers slow down
+	// anything under two.
+	g.anim.Delay = append(g.anim.Delay, max(2, int(100/rate)))
+	return len(g.anim.Image) < g.rs.config.GIFMaxFrames
+}
+
+// stop ends the recording and saves it to path.
+func (g *gifRecorder) stop(path string) error {
+	g.recording = false
+	if len(g.anim.Image) == 0 {
+		return errors.New("no generations were recorded")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &g.anim); err != nil {
+		f.Close()
+		return err
+	}
+	g.anim = gif.GIF{}
+	return f.Close()
+}
+
+func (g *gifRecorder) draw() {
+	if !g.recording {
+		return
+	}
+	label := fmt.Sprintf("REC %d", len(g.anim.Image))
+	w, _ := g.rs.textSize(label)
+	g.text.reset()
+	g.text.print(label, 1-w, 1)
+	g.text.draw(1, 0.2, 0.2, 1)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	graphHeight = 0.25
+	// graphPoints is the most points the graph draws; a longer history is
+	// downsampled to fit.
+	graphPoints = 1000
+)
+
+// graph renders a population history as a sparkline along the bottom of the
+// window, scaled so the highest observed population touches the top of the
+// graph area, with the activity over it likewise scaled to its highest.
+type graph struct {
+	stats   *Stats
+	visible bool
+
+	program  *overlayProgram
+	line     *lines
+	activity *lines
+}
+
+func (rs *runState) newGraph(s *Stats, program *overlayProgram) *graph {
+	return &graph{
+		stats:    s,
+		program:  program,
+		line:     rs.newLines("population graph", min(s.Capacity(), graphPoints)),
+		activity: rs.newLines("activity graph", min(s.Capacity(), graphPoints)),
+	}
+}
+
+func (g *graph) draw() {
+	if !g.visible {
+		return
+	}
+	width := min(g.stats.Capacity(), graphPoints)
+	samples := g.stats.RangeN(math.MinInt, math.MaxInt, width)
+	if len(samples) < 2 {
+		return
+	}
+
+	top, topActivity := 1, 0.0
+	for _, s := range samples {
+		top, topActivity = max(top, s.Population), math.Max(topActivity, s.Activity)
+	}
+	if topActivity == 0 {
+		topActivity = 1
+	}
+	// A full history is the graph's whole width, however it's downsampled.
+	step := 2 / float32(g.stats.Capacity()-1) * float32(g.stats.Len()-1) / float32(len(samples)-1)
+	g.line.reset()
+	g.activity.reset()
+	for i, s := range samples {
+		x := -1 + float32(i)*step
+		g.line.add(x, -1+graphHeight*float32(s.Population)/float32(top))
+		g.activity.add(x, -1+graphHeight*float32(s.Activity/topActivity))
+	}
+
+	g.program.use(0.9, 0.6, 0.2, 1)
+	g.activity.draw(gl.LINE_STRIP)
+	g.program.use(0.2, 0.9, 0.3, 1)
+	g.line.draw(gl.LINE_STRIP)
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+)
+
+// gridLabelEvery is the spacing, in cells, of the grid's axis labels.
+const gridLabelEvery = 5
+
+// grid draws lines between the cells and labels the axes along the bottom
+// and left edges, for building patterns cell by cell.
+type grid struct {
+	rs *runState
+
+	visible bool
+
+	program *overlayProgram
+	lines   *lines
+	labels  *text
+}
+
+func (rs *runState) newGrid(program *overlayProgram) *grid {
+	labels := rs.newText(program, 4*(rs.config.GridWidth+rs.config.GridHeight)/gridLabelEvery)
+	labels.scale = 1
+	return &grid{
+		rs:      rs,
+		program: program,
+		lines:   rs.newLines("grid lines", 2*(rs.config.GridWidth+rs.config.GridHeight+2)),
+		labels:  labels,
+	}
+}
+
+// draw draws the grid for a board seen through cam. It expects the viewport
+// to be set to the board's view.
+func (g *grid) draw(cam *camera) {
+	if !g.visible {
+		return
+	}
+	g.lines.reset()
+	if render.Tiling == life.Triangles {
+		g.triangleLines(cam)
+	} else {
+		for x := 0; x <= g.rs.config.GridWidth; x++ {
+			g.lines.add(g.rs.cellCorner(cam, x, 0))
+			g.lines.add(g.rs.cellCorner(cam, x, g.rs.config.GridHeight))
+		}
+		for y := 0; y <= g.rs.config.GridHeight; y++ {
+			g.lines.add(g.rs.cellCorner(cam, 0, y))
+			g.lines.add(g.rs.cellCorner(cam, g.rs.config.GridWidth, y))
+		}
+	}
+	g.program.use(0.25, 0.25, 0.25, 1)
+	g.lines.draw(gl.LINES)
+
+	g.labels.reset()
+	_, lineHeight := g.rs.textSize("")
+	lineHeight /= textScale
+	for x := 0; x < g.rs.config.GridWidth; x += gridLabelEvery {
+		lx, ly := g.rs.cellCorner(cam, x, 0)
+		g.labels.print(fmt.Sprint(x), lx+0.005, ly+lineHeight)
+	}
+	for y := gridLabelEvery; y < g.rs.config.GridHeight; y += gridLabelEvery {
+		lx, ly := g.rs.cellCorner(cam, 0, y)
+		g.labels.print(fmt.Sprint(y), lx+0.005, ly+lineHeight)
+	}
+	g.labels.draw(0.6, 0.6, 0.6, 1)
+}
+
+// triangleLines queues the lines between a board of triangles' cells: along
+// the rows, and slanting across them both ways, as render.TriangleCorners
+// lays them out.
+func (g *grid) triangleLines(cam *camera) {
+	columns, rows := g.rs.config.GridWidth, g.rs.config.GridHeight
+	// at is the point u half-triangles along the bottom of row y.
+	at := func(u, y int) (float32, float32) {
+		wx, wy := float32(u)*2/float32(columns+1)-1, float32(y)*2/float32(rows)-1
+		return (wx - cam.x) * cam.zoom, (wy - cam.y) * cam.zoom
+	}
+	for y := 0; y <= rows; y++ {
+		g.lines.add(at(0, y))
+		g.lines.add(at(columns+1, y))
+	}
+	// Edges slanting up to the right are along u-y even, and those
+	// slanting down along u+y even, each clipped to the board.
+	for k := -rows - rows&1; k <= columns+1; k += 2 {
+		if lo, hi := max(0, -k), min(rows, columns+1-k); lo < hi {
+			g.lines.add(at(k+lo, lo))
+			g.lines.add(at(k+hi, hi))
+		}
+	}
+	for k := 0; k <= columns+1+rows; k += 2 {
+		if lo, hi := max(0, k-columns-1), min(rows, k); lo < hi {
+			g.lines.add(at(k-lo, lo))
+			g.lines.add(at(k-hi, hi))
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"opengl/life"
+	"opengl/lifepb"
+)
+
+const (
+	// grpcChunk is how many bytes of cells a GetBoard chunk carries unless
+	// the client asks for another size, and maxGRPCChunk the most it can
+	// ask for: well under the 4 MiB gRPC allows a message.
+	grpcChunk    = 64 << 10
+	maxGRPCChunk = 1 << 20
+	// generationFeedBuffer is how many generations a SubscribeGenerations
+	// client can fall behind by before it misses some.
+	generationFeedBuffer = 1024
+)
+
+// grpcServer serves -grpc's control service, reaching the boards through
+// rc as the JSON API does.
+type grpcServer struct {
+	lifepb.UnimplementedLifeServer
+	rs *runState
+	rc *remoteControl
+}
+
+// startGRPC serves the control service on addr, returning a function that
+// shuts the server down.
+func (rs *runState) startGRPC(addr string, rc *remoteControl) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("-grpc: %w", err)
+	}
+	slog.Info("serving the gRPC control service", "addr", ln.Addr().String())
+	return rs.serveGRPC(ln, rc), nil
+}
+
+// serveGRPC serves the control service on ln, returning a function that
+// shuts the server down, ending any streams.
+func (rs *runState) serveGRPC(ln net.Listener, rc *remoteControl) func() {
+	s := grpc.NewServer()
+	lifepb.RegisterLifeServer(s, &grpcServer{rs: rs, rc: rc})
+	go func() {
+		if err := s.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Warn("-grpc", "err", err)
+		}
+	}()
+	return s.Stop
+}
+
+// do has the loop owning the boards run f, turning what goes wrong into a
+// gRPC status.
+func (s *grpcServer) do(ctx context.Context, f func(c *boardControls) (any, error)) (any, error) {
+	body, err := s.rc.do(ctx, f)
+	switch {
+	case ctx.Err() != nil:
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return body, nil
+}
+
+// change runs f on the boards, replying with the state they're left in.
+func (s *grpcServer) change(ctx context.Context, f func(c *boardControls) error) (*lifepb.State, error) {
+	body, err := s.do(ctx, func(c *boardControls) (any, error) {
+		if err := f(c); err != nil {
+			return nil, err
+		}
+		return c.state(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	st := body.(apiState)
+	return &lifepb.State{
+		Generation: int64(st.Generation),
+		Population: int64(st.Population),
+		Rule:       st.Rule,
+		Seed:       st.Seed,
+		Paused:     st.Paused,
+		Columns:    int32(st.Columns),
+		Rows:       int32(st.Rows),
+	}, nil
+}
+
+func (s *grpcServer) GetState(ctx context.Context, _ *lifepb.GetStateRequest) (*lifepb.State, error) {
+	return s.change(ctx, func(*boardControls) error { return nil })
+}
+
+func (s *grpcServer) Pause(ctx context.Context, _ *lifepb.PauseRequest) (*lifepb.State, error) {
+	return s.change(ctx, func(c *boardControls) error {
+		c.setPaused(true)
+		return nil
+	})
+}
+
+func (s *grpcServer) Resume(ctx context.Context, _ *lifepb.ResumeRequest) (*lifepb.State, error) {
+	return s.change(ctx, func(c *boardControls) error {
+		c.setPaused(false)
+		return nil
+	})
+}
+
+func (s *grpcServer) StepN(ctx context.Context, req *lifepb.StepNRequest) (*lifepb.State, error) {
+	if req.N < 1 || req.N > maxAPISteps {
+		return nil, status.Errorf(codes.InvalidArgument, "n must be between 1 and %d", maxAPISteps)
+	}
+	return s.change(ctx, func(c *boardControls) error {
+		for i := int32(0); i < req.N; i++ {
+			c.step()
+		}
+		return nil
+	})
+}
+
+func (s *grpcServer) SetCells(ctx context.Context, req *lifepb.SetCellsRequest) (*lifepb.State, error) {
+	return s.change(ctx, func(c *boardControls) error {
+		cells := c.sims[0].Cells
+		for _, cell := range req.Cells {
+			if x, y := int(cell.X), int(cell.Y); x < 0 || x >= cells.Columns() || y < 0 || y >= cells.Rows() {
+				return fmt.Errorf("cell (%d, %d) is off the %dx%d board", x, y, cells.Columns(), cells.Rows())
+			}
+		}
+		c.edit(func() {
+			for _, cell := range req.Cells {
+				cells.Set(int(cell.X), int(cell.Y), cell.Alive)
+			}
+		})
+		return nil
+	})
+}
+
+func (s *grpcServer) GetBoard(req *lifepb.GetBoardRequest, stream lifepb.Life_GetBoardServer) error {
+	size := int(req.ChunkSize)
+	switch {
+	case size == 0:
+		size = grpcChunk
+	case size < 0 || size > maxGRPCChunk:
+		return status.Errorf(codes.InvalidArgument, "chunk_size must be between 1 and %d, or 0 for %d", maxGRPCChunk, grpcChunk)
+	}
+	body, err := s.do(stream.Context(), func(c *boardControls) (any, error) {
+		sim := c.sims[0]
+		chunk := &lifepb.BoardChunk{Generation: int64(sim.Generation), Columns: int32(sim.Cells.Columns()), Rows: int32(sim.Cells.Rows())}
+		for _, word := range c.rs.newSavestate(sim).alive {
+			chunk.Cells = binary.LittleEndian.AppendUint64(chunk.Cells, word)
+		}
+		return chunk, nil
+	})
+	if err != nil {
+		return err
+	}
+	board := body.(*lifepb.BoardChunk)
+	packed := board.Cells
+	for offset := 0; offset == 0 || offset < len(packed); offset += size {
+		chunk := &lifepb.BoardChunk{Generation: board.Generation, Columns: board.Columns, Rows: board.Rows, Offset: int64(offset)}
+		chunk.Cells = packed[offset:min(offset+size, len(packed))]
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *grpcServer) SubscribeGenerations(_ *lifepb.SubscribeRequest, stream lifepb.Life_SubscribeGenerationsServer) error {
+	generations := s.rc.generations.subscribe()
+	defer s.rc.generations.unsubscribe(generations)
+	// The header tells the client it's subscribed, so that it can wait
+	// for it before stepping the boards.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case g := <-generations:
+			if err := stream.Send(g); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func (s *grpcServer) Save(ctx context.Context, req *lifepb.SaveRequest) (*lifepb.SaveReply, error) {
+	path, err := s.rs.savePath(req.Name)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !req.Overwrite && saveExists(path) {
+		return nil, status.Errorf(codes.AlreadyExists, "there's already a save called %q", req.Name)
+	}
+	if _, err := s.do(ctx, func(c *boardControls) (any, error) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return nil, writeState(path, c.rs.captureState(c.sims, c.rs.newCamera()))
+	}); err != nil {
+		return nil, err
+	}
+	return &lifepb.SaveReply{Path: path}, nil
+}
+
+func (s *grpcServer) Load(ctx context.Context, req *lifepb.LoadRequest) (*lifepb.State, error) {
+	path, err := s.rs.savePath(req.Name)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !saveExists(path) {
+		return nil, status.Error(codes.NotFound, s.rs.missingSave(req.Name).Error())
+	}
+	return s.change(ctx, func(c *boardControls) error { return c.load(path) })
+}
+
+// generationFeed sends a summary of every generation of the first board to
+// its subscribers. One too far behind to take a generation misses it, so a
+// slow subscriber never holds the boards up.
+type generationFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan *lifepb.Generation]bool
+}
+
+func newGenerationFeed() *generationFeed {
+	return &generationFeed{subscribers: make(map[chan *lifepb.Generation]bool)}
+}
+
+func (f *generationFeed) subscribe() chan *lifepb.Generation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan *lifepb.Generation, generationFeedBuffer)
+	f.subscribers[ch] = true
+	return ch
+}
+
+func (f *generationFeed) unsubscribe(ch chan *lifepb.Generation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscribers, ch)
+}
+
+// send sends the subscribers sim's latest generation. It's for the loop
+// owning the boards to call after every step.
+func (f *generationFeed) send(sim *life.Simulation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.subscribers) == 0 {
+		return
+	}
+	g := &lifepb.Generation{
+		Generation: int64(sim.Generation),
+		Population: int64(sim.Cells.Population()),
+		Births:     int64(sim.Births),
+		Deaths:     int64(sim.Deaths),
+		Hash:       sim.Cells.Hash(),
+	}
+	for ch := range f.subscribers {
+		select {
+		case ch <- g:
+		default:
+		}
+	}
+}
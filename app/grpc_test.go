@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"opengl/life"
+	"opengl/lifepb"
+)
+
+// grpcHeadless starts a headless run of cfg, paused by a scenario, serving
+// the control service, and returns a client of it.
+func grpcHeadless(t *testing.T, cfg Config) (lifepb.LifeClient, []life.Rule) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg.Headless = true
+	cfg.Scenario = filepath.Join(dir, "pause.json")
+	cfg.SavesDir = filepath.Join(dir, "saves")
+	if err := os.WriteFile(cfg.Scenario, []byte(`[{"at": 0, "do": "pause"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rs := testRun(t, cfg)
+	rs.stdout = io.Discard
+	var err error
+	if rs.schedule, err = rs.loadScenario(cfg.Scenario); err != nil {
+		t.Fatal(err)
+	}
+	rs.remote = rs.newRemoteControl()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := rs.serveGRPC(ln, rs.remote)
+	shutdown := make(chan struct{})
+	rs.shutdown = shutdown
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- rs.runHeadless(seeds, rules, nil) }()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		close(shutdown)
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+		stop()
+	})
+	return lifepb.NewLifeClient(conn), rules
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("got %v, want %s", err, code)
+	}
+}
+
+func TestGRPCDrivesHeadlessRun(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Seed = 48, 32, 3
+	client, rules := grpcHeadless(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	st, err := client.GetState(ctx, &lifepb.GetStateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Generation != 0 || !st.Paused || st.Columns != 48 || st.Rows != 32 || st.Seed != 3 {
+		t.Fatalf("the run starts %v, want generation 0 of a paused 48x32 board seeded 3", st)
+	}
+
+	sub, err := client.SubscribeGenerations(ctx, &lifepb.SubscribeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.Header(); err != nil {
+		t.Fatal(err)
+	}
+	if st, err = client.StepN(ctx, &lifepb.StepNRequest{N: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if st.Generation != 10 {
+		t.Fatalf("StepN(10) left the board at generation %d", st.Generation)
+	}
+	sim := life.NewSimulation(life.NewGrid(48, 32), rules[0], 3, cfg.Density, 0)
+	for i := 1; i <= 10; i++ {
+		sim.Step(cfg.Wrap)
+		g, err := sub.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Generation != int64(i) || g.Population != int64(sim.Cells.Population()) || g.Hash != sim.Cells.Hash() {
+			t.Fatalf("subscription sent %v for generation %d, want population %d and hash %016x", g, i, sim.Cells.Population(), sim.Cells.Hash())
+		}
+	}
+	if st.Population != int64(sim.Cells.Population()) {
+		t.Fatalf("population %d after 10 generations, want %d", st.Population, sim.Cells.Population())
+	}
+
+	cells := []*lifepb.Cell{{X: 0, Y: 0, Alive: true}, {X: 47, Y: 31, Alive: true}, {X: 1, Y: 0, Alive: false}}
+	if st, err = client.SetCells(ctx, &lifepb.SetCellsRequest{Cells: cells}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.SetCells(ctx, &lifepb.SetCellsRequest{Cells: []*lifepb.Cell{{X: 48, Y: 0, Alive: true}}})
+	wantCode(t, err, codes.InvalidArgument)
+
+	board, err := client.GetBoard(ctx, &lifepb.GetBoardRequest{ChunkSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packed []byte
+	for {
+		chunk, err := board.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Offset != int64(len(packed)) || len(chunk.Cells) > 16 || chunk.Generation != 10 || chunk.Columns != 48 {
+			t.Fatalf("chunk at offset %d of %d bytes, for generation %d of %d columns, doesn't follow on", chunk.Offset, len(chunk.Cells), chunk.Generation, chunk.Columns)
+		}
+		packed = append(packed, chunk.Cells...)
+	}
+	if len(packed) != 48*32/8 {
+		t.Fatalf("GetBoard sent %d bytes, want %d", len(packed), 48*32/8)
+	}
+	population := 0
+	for i := 0; i < len(packed); i += 8 {
+		population += bits.OnesCount64(binary.LittleEndian.Uint64(packed[i:]))
+	}
+	// Cells are packed column by column from the bottom left.
+	alive := func(x, y int) bool { i := x*32 + y; return packed[i/8]&(1<<(i%8)) != 0 }
+	if int64(population) != st.Population || !alive(0, 0) || !alive(47, 31) || alive(1, 0) {
+		t.Fatalf("the board GetBoard sent doesn't have the cells set, or the population of %d", st.Population)
+	}
+
+	_, err = client.StepN(ctx, &lifepb.StepNRequest{N: 0})
+	wantCode(t, err, codes.InvalidArgument)
+	bad, err := client.GetBoard(ctx, &lifepb.GetBoardRequest{ChunkSize: -1})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestGRPCSaves(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Seed = 20, 20, 9
+	client, _ := grpcHeadless(t, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	saved, err := client.StepN(ctx, &lifepb.StepNRequest{N: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := client.Save(ctx, &lifepb.SaveRequest{Name: "four"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(reply.Path); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Save(ctx, &lifepb.SaveRequest{Name: "four"})
+	wantCode(t, err, codes.AlreadyExists)
+	if _, err = client.Save(ctx, &lifepb.SaveRequest{Name: "four", Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.StepN(ctx, &lifepb.StepNRequest{N: 6}); err != nil {
+		t.Fatal(err)
+	}
+	st, err := client.Load(ctx, &lifepb.LoadRequest{Name: "four"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Generation != 4 || st.Population != saved.Population {
+		t.Fatalf("loading the save left generation %d with population %d, want generation 4 with %d", st.Generation, st.Population, saved.Population)
+	}
+	_, err = client.Load(ctx, &lifepb.LoadRequest{Name: "five"})
+	wantCode(t, err, codes.NotFound)
+	_, err = client.Save(ctx, &lifepb.SaveRequest{Name: "../"})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestGenerationFeedDropsWhenBehind(t *testing.T) {
+	feed := newGenerationFeed()
+	ch := feed.subscribe()
+	sim := life.NewSimulation(life.NewGrid(8, 8), life.Conway, 1, 0.5, 0)
+	for i := 0; i < generationFeedBuffer+10; i++ {
+		sim.Step(true)
+		feed.send(sim)
+	}
+	if len(ch) != generationFeedBuffer {
+		t.Fatalf("a subscriber that took nothing has %d queued, want %d", len(ch), generationFeedBuffer)
+	}
+	if g := <-ch; g.Generation != 1 {
+		t.Fatalf("the first generation queued is %d, want 1", g.Generation)
+	}
+	feed.unsubscribe(ch)
+	feed.send(sim)
+	if len(ch) != generationFeedBuffer-1 {
+		t.Fatal("an unsubscribed channel was sent a generation")
+	}
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"opengl/life"
+)
+
+// bareRun is the boards run without a window, by -headless or a renderer
+// other than gl, with what's recorded as they step going to the same places
+// it would with a window: the event log, -stats-out, checkpoints, the census
+// and the -resume autosave.
+type bareRun struct {
+	*runState
+
+	sims []*life.Simulation
+	// cam is only kept for state files, which record one.
+	cam          *camera
+	events       *eventLog
+	stats        *statsWriter
+	history      *Stats
+	checkpoint   *checkpointer
+	nextAutosave time.Time
+	// paused is set by a remote request, a hook or the scenario to stop
+	// the boards stepping on their own, and stopped by a hook or the
+	// scenario to end the run.
+	paused, stopped bool
+}
+
+// newBareRun sets the boards up from wherever the configuration says they
+// start from.
+func (rs *runState) newBareRun(seeds []int64, rules []life.Rule, stdinPattern []byte) (*bareRun, error) {
+	b := &bareRun{runState: rs, sims: rs.newSimulations(seeds, rules), cam: rs.newCamera()}
+	b.events = newEventLog(b.sims)
+	if err := b.start(stdinPattern); err != nil {
+		return nil, err
+	}
+	b.events.reset()
+	if b.history = rs.config.stats; b.history == nil {
+		b.history = NewStats(rs.config.History)
+	}
+	b.history.add(b.sims[0])
+	if rs.config.StatsOut != "" {
+		var err error
+		if b.stats, err = rs.newStatsWriter(rs.config.StatsOut); err != nil {
+			return nil, err
+		}
+	}
+	if rs.config.CheckpointEvery > 0 {
+		dir, err := rs.checkpointPath()
+		if err == nil {
+			b.checkpoint, err = rs.newCheckpointer(dir, rs.config.CheckpointKeep)
+		}
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+	}
+	if rs.schedule != nil {
+		rs.schedule.commands = newCommandLine()
+		rs.addBoardCommands(rs.schedule.commands, b.controls())
+		rs.schedule.begin(b.sims[0].Generation)
+	}
+	b.nextAutosave = time.Now().Add(rs.config.AutosaveInterval)
+	return b, nil
+}
+
+func (b *bareRun) start(stdinPattern []byte) error {
+	sim := b.sims[0]
+	place := func(p life.Pattern, ruleText string) error {
+		r, cx, cy, err := b.fitPattern(p, ruleText, sim, b.config.GridWidth/2, b.config.GridHeight/2)
+		if err != nil {
+			return err
+		}
+		sim.Clear()
+		sim.Cells.Stamp(p, cx, cy, b.config.Wrap)
+		sim.Rule = r
+		b.events.info(sim, "pattern loaded", "pattern", p.Name, "x", cx, "y", cy)
+		return nil
+	}
+	switch {
+	case stdinPattern != nil:
+		p, ruleText, err := life.ParsePattern(string(stdinPattern), b.config.PatternLimit)
+		if err != nil {
+			return fmt.Errorf("stdin: %w", err)
+		}
+		if p.Name == "" {
+			p.Name = "stdin"
+		}
+		if err := place(p, ruleText); err != nil {
+			return err
+		}
+	case b.config.Pattern != "":
+		p, ruleText, err := b.loadPattern(b.config.Pattern)
+		if err != nil {
+			return err
+		}
+		if err := place(p, ruleText); err != nil {
+			return err
+		}
+	}
+	if b.config.SeedImage != "" {
+		if err := b.loadSeedImage(sim, b.config.SeedImage); err != nil {
+			return err
+		}
+	}
+	if b.config.ImportPBM != "" {
+		img, err := b.readPNM(b.config.ImportPBM)
+		if err != nil {
+			return err
+		}
+		img.apply(sim)
+	}
+	if b.config.Load != "" {
+		path, err := b.loadPath(b.config.Load)
+		if err != nil {
+			return err
+		}
+		if err := b.loadState(path); err != nil {
+			return err
+		}
+	}
+	if b.config.Resume {
+		path, err := autosavePath()
+		if err == nil {
+			err = b.loadState(path)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			// Autosaving would overwrite what may be a long run.
+			b.config.Resume = false
+			slog.Warn("starting afresh, with autosaving off", "err", err)
+		}
+	}
+	return nil
+}
+
+func (b *bareRun) loadState(path string) error {
+	st, err := b.readState(path, len(b.sims))
+	if err != nil {
+		return err
+	}
+	st.restore(b.runState, b.sims, b.cam)
+	b.events.info(nil, "state loaded", "path", path)
+	return nil
+}
+
+// step moves every board on a generation.
+func (b *bareRun) step() {
+	for _, sim := range b.sims {
+		sim.Step(b.config.Wrap)
+	}
+	action := b.runHooks(b.sims[0])
+	b.paused, b.stopped = b.paused || action.Pause, b.stopped || action.Stop
+	b.schedule.run(b.sims[0].Generation)
+	b.events.stepped()
+	b.recordStep(b.sims[0])
+	b.clusterCount.stepped(b.sims[0])
+	b.remote.stepped(b.sims[0])
+	b.led.stepped(b.sims[0])
+	b.sound.stepped(b.sims[0])
+	gen := b.sims[0].Generation
+	b.history.add(b.sims[0])
+	if b.stats != nil {
+		b.stats.add(b.sims[0])
+	}
+	if b.checkpoint != nil && gen%b.config.CheckpointEvery == 0 {
+		b.checkpoint.save(b.captureState(b.sims, b.cam))
+	}
+	if b.config.CensusEvery > 0 && gen%b.config.CensusEvery == 0 {
+		slog.Info("census", "generation", gen, "census", life.TakeCensus(b.sims[0].Cells, b.config.Wrap).String())
+	}
+	if b.config.Resume && b.config.AutosaveInterval > 0 && time.Now().After(b.nextAutosave) {
+		b.autosave()
+		b.nextAutosave = time.Now().Add(b.config.AutosaveInterval)
+	}
+}
+
+// reseed starts every board afresh from seed, as the window's r key does.
+func (b *bareRun) reseed(seed int64) {
+	for i, sim := range b.sims {
+		if b.config.CompareSeeds != "" {
+			sim.Reseed(seed + int64(i))
+		} else {
+			sim.Reseed(seed)
+		}
+		slog.Info("seed", "seed", sim.Seed)
+	}
+	b.events.reset()
+	b.history.reset()
+	b.history.add(b.sims[0])
+	for _, sim := range b.sims {
+		b.events.info(sim, "reseed", "seed", sim.Seed)
+	}
+}
+
+// controls are what remote requests do to the boards.
+func (b *bareRun) controls() *boardControls {
+	return &boardControls{
+		rs:        b.runState,
+		sims:      b.sims,
+		paused:    func() bool { return b.paused },
+		setPaused: func(p bool) { b.paused = p },
+		step:      b.step,
+		reseed:    b.reseed,
+		stop:      func() { b.stopped = true },
+		edit:      func(f func()) { f() },
+		setRule: func(r life.Rule) error {
+			for _, sim := range b.sims {
+				sim.Rule = r
+			}
+			b.events.info(nil, "rule change", "rule", r.String())
+			return nil
+		},
+		stats: b.history,
+		load:  b.loadState,
+	}
+}
+
+// autosave saves the state for -resume to carry on from next time.
+func (b *bareRun) autosave() {
+	path, err := autosavePath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err == nil {
+		err = writeState(path, b.captureState(b.sims, b.cam))
+	}
+	if err != nil {
+		slog.Warn("couldn't autosave", "err", err)
+	}
+}
+
+// close autosaves, if resuming, and finishes writing everything else.
+func (b *bareRun) close() error {
+	if b.config.Resume {
+		b.autosave()
+	}
+	if b.checkpoint != nil {
+		b.checkpoint.wait()
+	}
+	if b.stats != nil {
+		if err := b.stats.close(); err != nil {
+			return fmt.Errorf("writing -stats-out: %w", err)
+		}
+	}
+	return nil
+}
+
+// runHeadless runs the boards flat out without a window or OpenGL, for
+// -generations generations or until interrupted, then prints where each
+// board got to.
+func (rs *runState) runHeadless(seeds []int64, rules []life.Rule, stdinPattern []byte) error {
+	b, err := rs.newBareRun(seeds, rules, stdinPattern)
+	if err != nil {
+		return err
+	}
+	start := b.sims[0].Generation
+	controls := b.controls()
+run:
+	for !b.stopped && (rs.config.Generations <= 0 || b.sims[0].Generation-start < rs.config.Generations) {
+		select {
+		case <-rs.shutdown:
+			break run
+		default:
+		}
+		rs.remote.serve(controls)
+		// Paused, only a request can move the boards on.
+		if b.paused {
+			select {
+			case <-rs.shutdown:
+				break run
+			case req := <-rs.remote.pending():
+				rs.remote.run(req, controls)
+			}
+			continue
+		}
+		b.step()
+	}
+
+	for i, sim := range b.sims {
+		fmt.Fprintf(rs.stdout, "board %d: generation %d, population %d, rule %s, hash %016x\n", i+1, sim.Generation, sim.Cells.Population(), sim.Rule, sim.Cells.Hash())
+	}
+	return b.close()
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// runHeadless runs cfg without a window, returning what it wrote to stdout.
+func runHeadless(t *testing.T, cfg Config, stdinPattern []byte) string {
+	t.Helper()
+	cfg.Headless = true
+	rs := testRun(t, cfg)
+	var out bytes.Buffer
+	rs.stdout = &out
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.runHeadless(seeds, rules, stdinPattern); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func summary(board int, sim *life.Simulation) string {
+	return fmt.Sprintf("board %d: generation %d, population %d, rule %s, hash %016x\n", board, sim.Generation, sim.Cells.Population(), sim.Rule, sim.Cells.Hash())
+}
+
+func TestHeadlessHash(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 64, 48, true
+	cfg.Seed, cfg.Generations = 7, 100
+	cfg.StatsOut = filepath.Join(t.TempDir(), "stats.csv")
+	got := runHeadless(t, cfg, nil)
+
+	sim := life.NewSimulation(life.NewGrid(64, 48), life.Conway, 7, cfg.Density, 0)
+	for i := 0; i < 100; i++ {
+		sim.Step(true)
+	}
+	if want := summary(1, sim); got != want {
+		t.Errorf("headless run wrote %q, want %q", got, want)
+	}
+	b, err := os.ReadFile(cfg.StatsOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(rows) != 101 {
+		t.Fatalf("-stats-out has %d lines, want a header and 100 rows", len(rows))
+	}
+	if fields := strings.Split(rows[100], ","); fields[0] != "100" || fields[4] != fmt.Sprintf("%016x", sim.Cells.Hash()) {
+		t.Errorf("last -stats-out row is %s, want generation 100 with hash %016x", rows[100], sim.Cells.Hash())
+	}
+}
+
+// TestHeadlessGliderWraps runs a glider once round a wrapped board, for it
+// to end up where it started.
+func TestHeadlessGliderWraps(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 32, 32, true
+	cfg.Seed, cfg.Generations = 1, 4*32
+	glider := ".O\n..O\nOOO\n"
+	got := runHeadless(t, cfg, []byte(glider))
+
+	p, _, err := life.ParsePattern(glider, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := life.NewGrid(32, 32)
+	cells.Stamp(p, 16, 16, true)
+	want := fmt.Sprintf("board 1: generation %d, population 5, rule %s, hash %016x\n", 4*32, life.Conway, cells.Hash())
+	if got != want {
+		t.Errorf("headless run wrote %q, want %q", got, want)
+	}
+}
+
+// TestHeadlessBirthsDeaths checks -stats-out has a blinker's two births and
+// two deaths on every generation.
+func TestHeadlessBirthsDeaths(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Generations = 16, 16, 20
+	cfg.StatsOut = filepath.Join(t.TempDir(), "stats.csv")
+	runHeadless(t, cfg, []byte("OOO\n"))
+	b, err := os.ReadFile(cfg.StatsOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(rows) != 21 {
+		t.Fatalf("-stats-out has %d lines, want a header and 20 rows", len(rows))
+	}
+	for i, row := range rows[1:] {
+		if fields := strings.Split(row, ","); fields[0] != fmt.Sprint(i+1) || fields[1] != "3" || fields[2] != "2" || fields[3] != "2" {
+			t.Errorf("row %s, want generation %d with population 3, births 2 and deaths 2", row, i+1)
+		}
+	}
+}
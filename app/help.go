@@ -0,0 +1,99 @@
+package app
+
+import (
+	"strings"
+)
+
+const (
+	// helpScale is the help text's font pixel size, smaller than other text
+	// so the whole list fits.
+	helpScale = 1
+	// helpColumns is how many columns the help is laid out in.
+	helpColumns = 2
+)
+
+// helpOverlay lists every key binding and the current settings over a
+// darkened board. The bindings come from input, so they match any the user
+// has remapped.
+type helpOverlay struct {
+	rs *runState
+
+	visible bool
+
+	input    *input
+	settings func() []string
+
+	program    *overlayProgram
+	background *lines
+	text       *text
+}
+
+func (rs *runState) newHelpOverlay(in *input, settings func() []string, program *overlayProgram) *helpOverlay {
+	t := rs.newText(program, 512)
+	t.scale = helpScale
+	return &helpOverlay{
+		rs:         rs,
+		input:      in,
+		settings:   settings,
+		program:    program,
+		background: rs.newLines("help background", 6),
+		text:       t,
+	}
+}
+
+// lines returns the help's lines, each no longer than width characters.
+func (h *helpOverlay) lines(width int) []string {
+	var out []string
+	for _, s := range h.settings() {
+		out = append(out, wrapLine(s, width, "  ")...)
+	}
+	out = append(out, "")
+	for _, c := range h.input.help() {
+		out = append(out, wrapLine(c.chordList()+"  "+c.help, width, "    ")...)
+	}
+	return out
+}
+
+// wrapLine breaks s at spaces into lines of at most width characters,
+// starting each continuation line with indent.
+func wrapLine(s string, width int, indent string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = indent + word
+		}
+	}
+	return append(lines, line)
+}
+
+func (h *helpOverlay) draw() {
+	if !h.visible {
+		return
+	}
+
+	shade(h.background, h.program, -1, -1, 1, 1, 0.8)
+
+	px, py := 2*h.text.scale/float32(h.rs.config.WindowWidth), 2*h.text.scale/float32(h.rs.config.WindowHeight)
+	charWidth, lineHeight := textAdvance*px, textLineHeight*py
+	columnChars := int(2/charWidth)/helpColumns - 2
+	linesPerColumn := int((2 - 2*lineHeight) / lineHeight)
+
+	h.text.reset()
+	for i, line := range h.lines(columnChars) {
+		column := i / linesPerColumn
+		if column >= helpColumns {
+			break
+		}
+		x := -1 + charWidth + float32(column)*2/helpColumns
+		y := 1 - lineHeight - float32(i%linesPerColumn)*lineHeight
+		h.text.print(line, x, y)
+	}
+	h.text.draw(1, 1, 1, 1)
+}
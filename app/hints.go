@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"opengl/life"
+)
+
+// builtinPatterns can be stamped with the number keys, starting from 1.
+var builtinPatterns = []life.Pattern{
+	life.LibraryPattern("glider"),
+	life.LibraryPattern("lwss"),
+	life.LibraryPattern("blinker"),
+	life.LibraryPattern("pulsar"),
+	life.LibraryPattern("r-pentomino"),
+	life.LibraryPattern("gosper-gun"),
+}
+
+// patternHints lists the number key for each built-in pattern along the
+// bottom of the window while stamping is possible.
+type patternHints struct {
+	rs *runState
+
+	visible bool
+	hints   string
+	text    *text
+}
+
+func (rs *runState) newPatternHints(program *overlayProgram) *patternHints {
+	var b strings.Builder
+	for i, p := range builtinPatterns {
+		switch {
+		case i == len(builtinPatterns)/2:
+			b.WriteString("\n")
+		case i > 0:
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "%d %s", i+1, p.Name)
+	}
+	return &patternHints{rs: rs, hints: b.String(), text: rs.newText(program, b.Len())}
+}
+
+func (h *patternHints) draw() {
+	if !h.visible {
+		return
+	}
+	_, textHeight := h.rs.textSize(h.hints)
+	h.text.reset()
+	h.text.print(h.hints, -1, -1+textHeight)
+	h.text.draw(0.7, 0.7, 0.7, 1)
+}
@@ -0,0 +1,18 @@
+package app
+
+import "testing"
+
+func TestBuiltinPatterns(t *testing.T) {
+	want := []struct {
+		name  string
+		cells int
+	}{{"glider", 5}, {"LWSS", 9}, {"blinker", 3}, {"pulsar", 48}, {"R-pentomino", 5}, {"Gosper gun", 36}}
+	if len(builtinPatterns) != len(want) {
+		t.Fatalf("%d built-in patterns, want %d", len(builtinPatterns), len(want))
+	}
+	for i, p := range builtinPatterns {
+		if p.Name != want[i].name || len(p.Cells) != want[i].cells {
+			t.Errorf("key %d stamps the %s of %d cells, want the %s of %d", i+1, p.Name, len(p.Cells), want[i].name, want[i].cells)
+		}
+	}
+}
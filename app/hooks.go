@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"opengl/life"
+)
+
+// slowHook is how long a generation hook can take before it's warned about.
+const slowHook = 10 * time.Millisecond
+
+// GenerationInfo is what a GenerationHook is told after each generation of
+// the first board.
+type GenerationInfo struct {
+	Generation, Population int
+	// Births and Deaths count the cells that came to life and died this
+	// generation.
+	Births, Deaths int
+	Board          BoardView
+}
+
+// BoardView is a read-only view of a board, only valid during the hook call
+// it's passed to.
+type BoardView struct {
+	cells life.Grid
+}
+
+// Columns returns the board's width in cells.
+func (v BoardView) Columns() int { return v.cells.Columns() }
+
+// Rows returns the board's height in cells.
+func (v BoardView) Rows() int { return v.cells.Rows() }
+
+// Alive reports whether the cell at (x, y), with (0, 0) at the bottom
+// left, is alive.
+func (v BoardView) Alive(x, y int) bool { return v.cells.Alive(x, y) }
+
+// A CellEdit brings the cell at (X, Y) to life or kills it.
+type CellEdit struct {
+	X, Y  int
+	Alive bool
+}
+
+// An Action is what a GenerationHook asks for once it returns: edits to the
+// first board, made before the next hook is called, and whether to pause,
+// as the pause key does, or stop, as closing the window does. Without a
+// window, a paused run waits for an -api or -host request to resume it.
+type Action struct {
+	Edits []CellEdit
+	Pause bool
+	Stop  bool
+}
+
+// A GenerationHook is called after every generation, with the window's
+// boards or without one.
+//
+// Hooks run on the goroutine that owns the boards, in the order they were
+// added, straight after the step and before it's recorded or drawn, so they
+// see every generation and never race the run. They mustn't block, call Run
+// or keep the BoardView, and should be quick, since the boards wait for
+// them; one that takes longer than 10ms is logged.
+type GenerationHook func(GenerationInfo) Action
+
+// OnGeneration adds a hook to be called after every generation.
+func OnGeneration(hook GenerationHook) Option {
+	return func(c *Config) { c.hooks = append(c.hooks, hook) }
+}
+
+// WithStats keeps the first board's statistics in s, for them to be read
+// while it runs, in place of a history of -history generations.
+func WithStats(s *Stats) Option {
+	return func(c *Config) { c.stats = s }
+}
+
+// runHooks calls the hooks on sim, the first board, after it's stepped,
+// making the edits they ask for, and returns what they asked for between
+// them.
+func (rs *runState) runHooks(sim *life.Simulation) Action {
+	var all Action
+	for i, hook := range rs.config.hooks {
+		start := time.Now()
+		action := hook(GenerationInfo{
+			Generation: sim.Generation,
+			Population: sim.Cells.Population(),
+			Births:     sim.Births,
+			Deaths:     sim.Deaths,
+			Board:      BoardView{sim.Cells},
+		})
+		if d := time.Since(start); d > slowHook && time.Since(rs.slowHookWarned) >= paceReportEvery {
+			rs.slowHookWarned = time.Now()
+			slog.Warn("slow generation hook", "hook", i+1, "took", d.Round(time.Millisecond), "generation", sim.Generation)
+		}
+		for _, e := range action.Edits {
+			if e.X < 0 || e.X >= rs.config.GridWidth || e.Y < 0 || e.Y >= rs.config.GridHeight {
+				slog.Warn("generation hook edited a cell off the board", "hook", i+1, "x", e.X, "y", e.Y, "size", fmt.Sprintf("%dx%d", rs.config.GridWidth, rs.config.GridHeight))
+				continue
+			}
+			sim.Cells.Set(e.X, e.Y, e.Alive)
+			all.Edits = append(all.Edits, e)
+		}
+		all.Pause = all.Pause || action.Pause
+		all.Stop = all.Stop || action.Stop
+	}
+	return all
+}
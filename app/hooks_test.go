@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"opengl/life"
+)
+
+// TestHookStopsAtPopulation embeds a run with a hook that stops it once
+// the soup has thinned out to a target population.
+func TestHookStopsAtPopulation(t *testing.T) {
+	sim := life.NewSimulation(life.NewGrid(64, 48), life.Conway, 7, DefaultConfig().Density, 0)
+	target := sim.Cells.Population() / 3
+	for sim.Cells.Population() > target {
+		sim.Step(true)
+		if sim.Generation > 5000 {
+			t.Fatalf("the soup never thins out to %d", target)
+		}
+	}
+
+	var seen []int
+	cfg := DefaultConfig(WithGridSize(64, 48), WithSeed(7), WithWrap(true), OnGeneration(func(g GenerationInfo) Action {
+		seen = append(seen, g.Generation)
+		return Action{Stop: g.Population <= target}
+	}))
+	if got, want := runHeadless(t, cfg, nil), summary(1, sim); got != want {
+		t.Errorf("the run ended with %q, want %q", got, want)
+	}
+	if len(seen) != sim.Generation || seen[0] != 1 || seen[len(seen)-1] != sim.Generation {
+		t.Errorf("the hook saw %d generations, from %d to %d, want 1 to %d", len(seen), seen[0], seen[len(seen)-1], sim.Generation)
+	}
+}
+
+// TestHooksCompose checks hooks are called in the order they were added,
+// each seeing the edits those before it asked for.
+func TestHooksCompose(t *testing.T) {
+	var calls []string
+	first := func(g GenerationInfo) Action {
+		calls = append(calls, "first")
+		return Action{Edits: []CellEdit{{0, 0, true}, {1, 0, true}, {2, 0, true}, {-1, 0, true}}}
+	}
+	second := func(g GenerationInfo) Action {
+		calls = append(calls, "second")
+		if !g.Board.Alive(0, 0) || !g.Board.Alive(2, 0) {
+			t.Errorf("generation %d: the second hook doesn't see the first's edits", g.Generation)
+		}
+		return Action{Stop: g.Generation == 2}
+	}
+	cfg := DefaultConfig(WithGridSize(16, 16), WithDensity(0), OnGeneration(first), OnGeneration(second))
+	runHeadless(t, cfg, nil)
+	if got := strings.Join(calls, " "); got != "first second first second" {
+		t.Errorf("hooks were called %s", got)
+	}
+}
+
+func TestSlowHookWarned(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg := DefaultConfig(WithGridSize(16, 16), OnGeneration(func(g GenerationInfo) Action { return Action{} }), OnGeneration(func(g GenerationInfo) Action {
+		if g.Generation == 3 {
+			time.Sleep(2 * slowHook)
+		}
+		return Action{Stop: g.Generation == 5}
+	}))
+	runHeadless(t, cfg, nil)
+	if !strings.Contains(logged.String(), "slow generation hook") || !strings.Contains(logged.String(), "hook=2") || !strings.Contains(logged.String(), "generation=3") {
+		t.Errorf("the slow hook was logged as %q", logged.String())
+	}
+	if strings.Count(logged.String(), "slow generation hook") != 1 {
+		t.Error("a quick hook was warned about")
+	}
+}
+
+// TestHooksEndTurbo checks a hook pausing, or stopping, the boards ends
+// the stepping turbo and time-lapses do within a frame, there and then.
+func TestHooksEndTurbo(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		act  func(gen int) Action
+	}{
+		{"pause", func(gen int) Action { return Action{Pause: gen == 5} }},
+		{"stop", func(gen int) Action { return Action{Stop: gen == 5} }},
+	} {
+		var seen []int
+		rs := testRun(t, DefaultConfig(WithGridSize(16, 16), OnGeneration(func(g GenerationInfo) Action {
+			seen = append(seen, g.Generation)
+			return c.act(g.Generation)
+		})))
+		sim := testSims(t, rs, 1)[0]
+		// As the window's boards step, a stop closes the window and a
+		// pause pauses them.
+		paused, closing := false, false
+		stepWithin(turboBudget, func() {
+			sim.Step(rs.config.Wrap)
+			if action := rs.runHooks(sim); action.Stop {
+				closing = true
+			} else if action.Pause {
+				paused = true
+			}
+		}, func() bool { return paused || closing })
+		if sim.Generation != 5 || len(seen) != 5 {
+			t.Errorf("%s: stepped to generation %d, the hook seeing %d, want it to end at 5", c.name, sim.Generation, len(seen))
+		}
+	}
+}
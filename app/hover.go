@@ -0,0 +1,34 @@
+package app
+
+import (
+	"fmt"
+)
+
+// hoverReadout shows the coordinates, state and age of the cell under the
+// cursor in the bottom-right corner.
+type hoverReadout struct {
+	rs *runState
+
+	brush *brush
+	text  *text
+}
+
+func (rs *runState) newHoverReadout(b *brush, program *overlayProgram) *hoverReadout {
+	return &hoverReadout{rs: rs, brush: b, text: rs.newText(program, 32)}
+}
+
+func (h *hoverReadout) draw() {
+	sim := h.brush.hover
+	if sim == nil {
+		return
+	}
+	c := sim.Cells.At(h.brush.hoverX, h.brush.hoverY)
+	s := fmt.Sprintf("%d,%d dead", h.brush.hoverX, h.brush.hoverY)
+	if c.Alive {
+		s = fmt.Sprintf("%d,%d alive age %d", h.brush.hoverX, h.brush.hoverY, c.Age)
+	}
+	w, ht := h.rs.textSize(s)
+	h.text.reset()
+	h.text.print(s, 1-w, -1+ht)
+	h.text.draw(0.8, 0.8, 0.8, 1)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	_ "image/png"
+	"log/slog"
+	"os"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/render"
+)
+
+const iconBoardSize = 5
+
+var (
+	iconSizes = []int{16, 32, 48}
+	// iconGlider is a glider on a 5x5 board, with y = 0 at the bottom like
+	// the game's own cells.
+	iconGlider = [][2]int{{2, 3}, {3, 2}, {1, 1}, {2, 1}, {3, 1}}
+
+	iconBackground = color.RGBA{0x10, 0x18, 0x30, 0xff}
+	iconForeground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// setIcon sets the window icon from the PNG at path, or to the built-in
+// glider if path is empty or can't be read.
+func setIcon(window *glfw.Window, path string) {
+	if path != "" {
+		img, err := loadImage(path)
+		if err == nil {
+			window.SetIcon([]image.Image{img})
+			return
+		}
+		slog.Warn("using the default icon", "err", err)
+	}
+
+	icons := make([]image.Image, len(iconSizes))
+	for i, size := range iconSizes {
+		icons[i] = gliderIcon(size)
+	}
+	window.SetIcon(icons)
+}
+
+// gliderIcon draws a glider into a size by size image, laying the cells out
+// with the same vertex math as the board itself.
+func gliderIcon(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			img.SetRGBA(px, py, iconBackground)
+		}
+	}
+
+	inset := 1 / float32(size)
+	for _, c := range iconGlider {
+		minX, minY, maxX, maxY := bounds2D(render.CellPoints(c[0], c[1], iconBoardSize, iconBoardSize))
+		for py := 0; py < size; py++ {
+			for px := 0; px < size; px++ {
+				// Pixel centres in normalized device coordinates, y up.
+				x := (float32(px)+0.5)/float32(size)*2 - 1
+				y := 1 - (float32(py)+0.5)/float32(size)*2
+				if x > minX+inset && x < maxX-inset && y > minY+inset && y < maxY-inset {
+					img.SetRGBA(px, py, iconForeground)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// bounds2D returns the bounding rectangle of x, y, z vertices.
+func bounds2D(points []float32) (minX, minY, maxX, maxY float32) {
+	minX, minY, maxX, maxY = points[0], points[1], points[0], points[1]
+	for i := 0; i < len(points); i += 3 {
+		minX, maxX = min(minX, points[i]), max(maxX, points[i])
+		minY, maxY = min(minY, points[i+1]), max(maxY, points[i+1])
+	}
+	return minX, minY, maxX, maxY
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
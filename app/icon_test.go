@@ -0,0 +1,60 @@
+package app
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGliderIcon checks the middle of every cell of each icon size, which is
+// the cell layout's mapping turned into pixels.
+func TestGliderIcon(t *testing.T) {
+	alive := make(map[[2]int]bool)
+	for _, c := range iconGlider {
+		alive[c] = true
+	}
+	for _, size := range iconSizes {
+		img := gliderIcon(size).(*image.RGBA)
+		if img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+			t.Fatalf("%d px icon is %v", size, img.Bounds())
+		}
+		for y := 0; y < iconBoardSize; y++ {
+			for x := 0; x < iconBoardSize; x++ {
+				// The image's rows run down, the board's up.
+				px := (2*x + 1) * size / (2 * iconBoardSize)
+				py := (2*(iconBoardSize-1-y) + 1) * size / (2 * iconBoardSize)
+				want := iconBackground
+				if alive[[2]int{x, y}] {
+					want = iconForeground
+				}
+				if got := img.RGBAAt(px, py); got != want {
+					t.Errorf("%d px icon has %v in the middle of cell %d, %d, want %v", size, got, x, y, want)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "icon.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, gliderIcon(16)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	img, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 16 {
+		t.Fatalf("loaded a %v icon, want 16 px", img.Bounds())
+	}
+	if _, err := loadImage(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Fatal("loading a missing icon succeeded")
+	}
+}
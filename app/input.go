@@ -0,0 +1,313 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// lockMods are ignored when matching chords, so Caps Lock and Num Lock
+// don't stop shortcuts working.
+const lockMods = glfw.ModCapsLock | glfw.ModNumLock
+
+// chord is a key pressed with a particular set of modifiers.
+type chord struct {
+	key  glfw.Key
+	mods glfw.ModifierKey
+}
+
+// command is something the user can do from the keyboard, bound to one or
+// more chords.
+type command struct {
+	name string
+	help string
+	// keys lists the default chords, separated by spaces, e.g. "ctrl+c".
+	keys   string
+	chords []chord
+	// anyMods makes the chords match whatever modifiers are held. The
+	// handlers are given the modifiers, so they can change what they do.
+	anyMods bool
+
+	press, repeat, release func(glfw.ModifierKey)
+}
+
+// input dispatches key events to the commands bound to them.
+type input struct {
+	commands []*command
+	byName   map[string]*command
+	down     map[glfw.Key]bool
+
+	// capture, if set, sees key presses and repeats first. Events it
+	// reports handling go no further, so a text field can take the
+	// keyboard.
+	capture func(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool
+}
+
+func newInput() *input {
+	return &input{byName: make(map[string]*command), down: make(map[glfw.Key]bool)}
+}
+
+// install makes the input handle window's key events.
+func (in *input) install(window *glfw.Window) {
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		in.dispatch(key, action, mods)
+	})
+}
+
+// add registers a command bound to its default chords.
+func (in *input) add(c *command) {
+	chords, err := parseChords(strings.Fields(c.keys))
+	if err != nil {
+		panic(fmt.Sprintf("command %s: %v", c.name, err))
+	}
+	c.chords = chords
+	in.commands = append(in.commands, c)
+	in.byName[c.name] = c
+}
+
+// on registers a command that runs handle when one of keys is pressed.
+func (in *input) on(name, help, keys string, handle func()) {
+	in.add(&command{name: name, help: help, keys: keys, press: func(glfw.ModifierKey) { handle() }})
+}
+
+// held reports whether a key bound to the named command is down.
+func (in *input) held(name string) bool {
+	for _, ch := range in.byName[name].chords {
+		if in.down[ch.key] {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch runs the handlers bound to a key event and reports whether there
+// were any. Releases go to every command bound to the key, whatever the
+// modifiers, since they may have changed while it was held.
+func (in *input) dispatch(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool {
+	mods &^= lockMods
+	if action != glfw.Release && in.capture != nil && in.capture(key, action, mods) {
+		return true
+	}
+	switch action {
+	case glfw.Press:
+		in.down[key] = true
+	case glfw.Release:
+		delete(in.down, key)
+	}
+
+	handled := false
+	for _, c := range in.commands {
+		for _, ch := range c.chords {
+			if ch.key != key || action != glfw.Release && !c.anyMods && ch.mods != mods {
+				continue
+			}
+			if in.run(c, action, mods) {
+				handled = true
+			}
+			break
+		}
+	}
+	return handled
+}
+
+// run calls the command's handler for the action, if it has one.
+func (in *input) run(c *command, action glfw.Action, mods glfw.ModifierKey) bool {
+	var handle func(glfw.ModifierKey)
+	switch action {
+	case glfw.Press:
+		handle = c.press
+	case glfw.Repeat:
+		handle = c.repeat
+	case glfw.Release:
+		handle = c.release
+	}
+	if handle == nil {
+		return false
+	}
+	handle(mods)
+	return true
+}
+
+// runNamed runs the named command as if one of its keys had the action.
+func (in *input) runNamed(name string, action glfw.Action) {
+	if c, ok := in.byName[name]; ok {
+		in.run(c, action, 0)
+	}
+}
+
+// loadBindings rebinds commands from a JSON file mapping command names to a
+// chord or list of chords, e.g. {"pause": "p", "quit": ["escape", "ctrl+q"]}.
+// Commands the file doesn't mention keep their defaults. With no path,
+// bindings.json is used if there is one.
+func (in *input) loadBindings(path string) error {
+	required := path != ""
+	if !required {
+		path = "bindings.json"
+	}
+	src, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var bindings map[string]json.RawMessage
+	if err := json.Unmarshal(src, &bindings); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for name, raw := range bindings {
+		c, ok := in.byName[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown command %q", path, name)
+		}
+		var keys []string
+		if err := json.Unmarshal(raw, &keys); err != nil {
+			var key string
+			if err := json.Unmarshal(raw, &key); err != nil {
+				return fmt.Errorf("%s: %s: want a chord or a list of chords", path, name)
+			}
+			keys = []string{key}
+		}
+		if c.chords, err = parseChords(keys); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+	}
+	in.warnConflicts()
+	return nil
+}
+
+// warnConflicts logs any chord bound to more than one command.
+func (in *input) warnConflicts() {
+	bound := make(map[chord][]string)
+	for _, c := range in.commands {
+		for _, ch := range c.chords {
+			bound[ch] = append(bound[ch], c.name)
+		}
+	}
+	for ch, names := range bound {
+		if len(names) > 1 {
+			slog.Warn("key bound to several commands", "key", ch, "commands", strings.Join(names, ", "))
+		}
+	}
+}
+
+// help returns the documented commands, in the order they were added.
+func (in *input) help() []*command {
+	var commands []*command
+	for _, c := range in.commands {
+		if c.help != "" {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+// keyNames are the keys chords can name besides single characters.
+var keyNames = map[string]glfw.Key{
+	"space": glfw.KeySpace, "enter": glfw.KeyEnter, "backspace": glfw.KeyBackspace,
+	"escape": glfw.KeyEscape, "tab": glfw.KeyTab,
+	"up": glfw.KeyUp, "down": glfw.KeyDown, "left": glfw.KeyLeft, "right": glfw.KeyRight,
+	"kp_add": glfw.KeyKPAdd, "kp_subtract": glfw.KeyKPSubtract,
+	"f1": glfw.KeyF1, "f2": glfw.KeyF2, "f3": glfw.KeyF3, "f4": glfw.KeyF4,
+	"f5": glfw.KeyF5, "f6": glfw.KeyF6, "f7": glfw.KeyF7, "f8": glfw.KeyF8,
+	"f9": glfw.KeyF9, "f10": glfw.KeyF10, "f11": glfw.KeyF11, "f12": glfw.KeyF12,
+}
+
+var modNames = []struct {
+	name string
+	mod  glfw.ModifierKey
+}{
+	{"ctrl", glfw.ModControl},
+	{"shift", glfw.ModShift},
+	{"alt", glfw.ModAlt},
+	{"super", glfw.ModSuper},
+}
+
+// parseKey parses a key name: one of keyNames, or a single letter, digit or
+// punctuation key.
+func parseKey(name string) (glfw.Key, bool) {
+	name = strings.ToLower(name)
+	if k, ok := keyNames[name]; ok {
+		return k, true
+	}
+	// Printable keys' codes are their upper-case ASCII characters.
+	if len(name) == 1 && name[0] > ' ' && name[0] < 0x7f {
+		return glfw.Key(strings.ToUpper(name)[0]), true
+	}
+	return 0, false
+}
+
+// parseChord parses modifiers and a key joined by +, e.g. "ctrl+shift+f1".
+func parseChord(s string) (chord, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	var ch chord
+	for _, part := range parts[:len(parts)-1] {
+		found := false
+		for _, m := range modNames {
+			if part == m.name {
+				ch.mods |= m.mod
+				found = true
+			}
+		}
+		if !found {
+			return chord{}, fmt.Errorf("unknown modifier %q in %q", part, s)
+		}
+	}
+	key, ok := parseKey(parts[len(parts)-1])
+	if !ok {
+		return chord{}, fmt.Errorf("unknown key %q in %q", parts[len(parts)-1], s)
+	}
+	ch.key = key
+	return ch, nil
+}
+
+func parseChords(list []string) ([]chord, error) {
+	chords := make([]chord, len(list))
+	for i, s := range list {
+		ch, err := parseChord(s)
+		if err != nil {
+			return nil, err
+		}
+		chords[i] = ch
+	}
+	return chords, nil
+}
+
+func (ch chord) String() string {
+	var parts []string
+	for _, m := range modNames {
+		if ch.mods&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	name := ""
+	for n, k := range keyNames {
+		if k == ch.key {
+			name = n
+		}
+	}
+	switch {
+	case name != "":
+	case ch.key > ' ' && ch.key < 0x7f:
+		name = strings.ToLower(string(rune(ch.key)))
+	default:
+		name = fmt.Sprintf("key%d", ch.key)
+	}
+	return strings.Join(append(parts, name), "+")
+}
+
+// chordList returns the command's chords in a readable, stable form.
+func (c *command) chordList() string {
+	names := make([]string, len(c.chords))
+	for i, ch := range c.chords {
+		names[i] = ch.String()
+	}
+	sort.Strings(names)
+	return strings.Join(names, " / ")
+}
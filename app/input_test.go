@@ -0,0 +1,147 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// testInput returns input with a pause command on p and space, and a quit
+// command on ctrl+q and escape, counting what runs.
+func testInput(t *testing.T) (*input, map[string]int) {
+	t.Helper()
+	in := newInput()
+	ran := make(map[string]int)
+	in.on("pause", "Pause", "p space", func() { ran["pause"]++ })
+	in.on("quit", "Quit", "ctrl+q escape", func() { ran["quit"]++ })
+	in.add(&command{
+		name: "step", keys: "n", anyMods: true,
+		press:   func(mods glfw.ModifierKey) { ran["step"]++; ran["step mods"] = int(mods) },
+		repeat:  func(glfw.ModifierKey) { ran["step repeat"]++ },
+		release: func(glfw.ModifierKey) { ran["step release"]++ },
+	})
+	return in, ran
+}
+
+func TestInputDispatch(t *testing.T) {
+	in, ran := testInput(t)
+	for _, e := range []struct {
+		key     glfw.Key
+		action  glfw.Action
+		mods    glfw.ModifierKey
+		handled bool
+	}{
+		{glfw.KeyP, glfw.Press, 0, true},
+		{glfw.KeyP, glfw.Release, 0, false},
+		{glfw.KeySpace, glfw.Press, glfw.ModCapsLock | glfw.ModNumLock, true},
+		{glfw.KeyP, glfw.Press, glfw.ModShift, false},
+		{glfw.KeyQ, glfw.Press, 0, false},
+		{glfw.KeyQ, glfw.Press, glfw.ModControl, true},
+		{glfw.KeyEscape, glfw.Press, 0, true},
+		{glfw.KeyN, glfw.Press, glfw.ModShift | glfw.ModCapsLock, true},
+		{glfw.KeyN, glfw.Repeat, glfw.ModShift, true},
+		// The release comes with shift let go first.
+		{glfw.KeyN, glfw.Release, 0, true},
+		{glfw.KeyX, glfw.Press, 0, false},
+	} {
+		if handled := in.dispatch(e.key, e.action, e.mods); handled != e.handled {
+			t.Errorf("key %v action %v mods %v: handled %v, want %v", e.key, e.action, e.mods, handled, e.handled)
+		}
+	}
+	want := map[string]int{"pause": 2, "quit": 2, "step": 1, "step mods": int(glfw.ModShift), "step repeat": 1, "step release": 1}
+	for name, n := range want {
+		if ran[name] != n {
+			t.Errorf("%s ran %d times, want %d", name, ran[name], n)
+		}
+	}
+}
+
+func TestInputHeld(t *testing.T) {
+	in, _ := testInput(t)
+	in.dispatch(glfw.KeyN, glfw.Press, glfw.ModAlt)
+	if !in.held("step") || in.held("pause") {
+		t.Fatal("holding n doesn't hold step alone")
+	}
+	in.dispatch(glfw.KeyN, glfw.Release, 0)
+	if in.held("step") {
+		t.Fatal("step is held after n is let go")
+	}
+}
+
+func TestInputCapture(t *testing.T) {
+	in, ran := testInput(t)
+	var typed []glfw.Key
+	in.capture = func(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool {
+		typed = append(typed, key)
+		return key != glfw.KeyEscape
+	}
+	in.dispatch(glfw.KeyP, glfw.Press, 0)
+	in.dispatch(glfw.KeyP, glfw.Release, 0)
+	in.dispatch(glfw.KeyEscape, glfw.Press, 0)
+	if ran["pause"] != 0 || ran["quit"] != 1 || len(typed) != 2 {
+		t.Fatalf("with the keyboard captured, pause ran %d times and quit %d, and the capture saw %v", ran["pause"], ran["quit"], typed)
+	}
+}
+
+func TestInputHelp(t *testing.T) {
+	in, _ := testInput(t)
+	help := in.help()
+	if len(help) != 2 || help[0].name != "pause" || help[1].name != "quit" {
+		t.Fatalf("help lists %v, want pause and quit in order", help)
+	}
+	if got := help[1].chordList(); got != "ctrl+q / escape" {
+		t.Errorf("quit is bound to %q", got)
+	}
+}
+
+func TestParseChord(t *testing.T) {
+	for _, s := range []string{"a", "ctrl+c", "ctrl+shift+f1", "alt+space", "super+]", "escape", "kp_add"} {
+		ch, err := parseChord(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ch.String() != s {
+			t.Errorf("%q parses to %v, which prints as %q", s, ch, ch.String())
+		}
+	}
+	if ch, _ := parseChord(" Shift+Ctrl+A "); ch.key != glfw.KeyA || ch.mods != glfw.ModShift|glfw.ModControl {
+		t.Errorf("Shift+Ctrl+A parses to %v", ch)
+	}
+	for _, s := range []string{"", "hyper+a", "ctrl+", "ctrl+pageup", "é"} {
+		if _, err := parseChord(s); err == nil {
+			t.Errorf("%q parses", s)
+		}
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	in, ran := testInput(t)
+	path := filepath.Join(t.TempDir(), "bindings.json")
+	if err := os.WriteFile(path, []byte(`{"pause": "k", "quit": ["ctrl+w", "f10"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.loadBindings(path); err != nil {
+		t.Fatal(err)
+	}
+	in.dispatch(glfw.KeyP, glfw.Press, 0)
+	in.dispatch(glfw.KeyK, glfw.Press, 0)
+	in.dispatch(glfw.KeyF10, glfw.Press, 0)
+	in.dispatch(glfw.KeyEscape, glfw.Press, 0)
+	if ran["pause"] != 1 || ran["quit"] != 1 {
+		t.Fatalf("after rebinding, pause ran %d times and quit %d, want once each", ran["pause"], ran["quit"])
+	}
+
+	for _, bad := range []string{`{"jump": "j"}`, `{"pause": 3}`, `{"pause": "ctrl+"}`, `[`} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := in.loadBindings(path); err == nil {
+			t.Errorf("bindings %s load", bad)
+		}
+	}
+	if err := in.loadBindings(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("a missing -bindings file loads")
+	}
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"net"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// ledProtocols are what -led-protocol can name: WLED's UDP realtime
+// protocol, or E1.31 (sACN), with the ports they're sent to by default.
+var ledProtocols = map[string]string{"wled": "21324", "e131": "5568"}
+
+const (
+	// wledTimeout is the seconds WLED waits without a frame before it
+	// goes back to its own effects, and ledKeepAlive how often the last
+	// frame is sent again, while the boards are paused, to keep it from.
+	wledTimeout  = 3
+	ledKeepAlive = time.Second
+	// wledMaxDRGB is the most LEDs a DRGB packet lights, and wledMaxDNRGB
+	// a DNRGB one, which starts at an index so a matrix can take several.
+	wledMaxDRGB  = 490
+	wledMaxDNRGB = 489
+	// e131Channels is the DMX channels in a universe, e131Pixels the RGB
+	// pixels they fit, and e131Header the bytes of an E1.31 data packet
+	// before its channels.
+	e131Channels = 512
+	e131Pixels   = e131Channels / 3
+	e131Header   = 126
+)
+
+// ledAddress returns where -led-out sends to, with the protocol's port if
+// it doesn't give one.
+func ledAddress(out, protocol string) string {
+	if _, _, err := net.SplitHostPort(out); err == nil {
+		return out
+	}
+	return net.JoinHostPort(out, ledProtocols[protocol])
+}
+
+// ledSink sends the first board to an LED matrix over UDP, from a
+// goroutine of its own, at most config.TickRate times a second. A frame
+// the network hasn't taken by the next is dropped rather than hold up the
+// simulation, and failures to send are logged, once until sending works
+// again, without stopping anything.
+type ledSink struct {
+	rs *runState
+
+	width, height int
+	protocol      string
+	universe      int
+	frames        chan []byte
+	done          chan struct{}
+	// sent is when the last frame was queued.
+	sent time.Time
+}
+
+func (rs *runState) newLEDSink(out, size, protocol string, universe int) (*ledSink, error) {
+	conn, err := net.Dial("udp", ledAddress(out, protocol))
+	if err != nil {
+		return nil, fmt.Errorf("-led-out: %w", err)
+	}
+	l := &ledSink{rs: rs, protocol: protocol, universe: universe, frames: make(chan []byte, 1), done: make(chan struct{})}
+	fmt.Sscanf(size, "%dx%d", &l.width, &l.height)
+	var cid [16]byte
+	rand.Read(cid[:])
+	go l.run(conn, cid)
+	return l, nil
+}
+
+func (l *ledSink) run(conn net.Conn, cid [16]byte) {
+	defer close(l.done)
+	defer conn.Close()
+	tick := time.NewTicker(ledKeepAlive)
+	defer tick.Stop()
+	var last []byte
+	var sequence byte
+	failing := false
+	send := func() {
+		var packets [][]byte
+		if l.protocol == "e131" {
+			packets = e131Packets(last, l.universe, sequence, cid)
+			sequence++
+		} else {
+			packets = wledPackets(last)
+		}
+		for _, p := range packets {
+			_, err := conn.Write(p)
+			switch {
+			case err != nil && !failing:
+				l.rs.reportError("couldn't send to the LEDs; carrying on", err, "addr", conn.RemoteAddr().String())
+				failing = true
+				return
+			case err != nil:
+				return
+			case failing:
+				slog.Info("sending to the LEDs again", "addr", conn.RemoteAddr().String())
+				failing = false
+			}
+		}
+	}
+	for {
+		select {
+		case pixels, ok := <-l.frames:
+			if !ok {
+				return
+			}
+			last = pixels
+			send()
+			tick.Reset(ledKeepAlive)
+		case <-tick.C:
+			if last != nil {
+				send()
+			}
+		}
+	}
+}
+
+// stepped sends sim, the first board, if it's been long enough since the
+// last frame.
+func (l *ledSink) stepped(sim *life.Simulation) {
+	if l == nil {
+		return
+	}
+	now := time.Now()
+	if l.rs.config.TickRate > 0 && now.Sub(l.sent) < time.Duration(float64(time.Second)/l.rs.config.TickRate) {
+		return
+	}
+	l.sent = now
+	pixels := ledPixels(sim.Cells, l.width, l.height, l.rs.config.Background)
+	select {
+	case l.frames <- pixels:
+	default:
+	}
+}
+
+// close stops sending, once the frame being sent has gone.
+func (l *ledSink) close() {
+	if l == nil {
+		return
+	}
+	close(l.frames)
+	<-l.done
+}
+
+// ledPixels maps cells onto a width by height matrix, as RGB bytes row by
+// row from the top left, each LED the average of the cells it covers in
+// the colours they're drawn in, dead ones in background. A board smaller
+// than the matrix has its cells spread over several LEDs.
+func ledPixels(cells life.Grid, width, height int, background color.RGBA) []byte {
+	columns, rows := cells.Columns(), cells.Rows()
+	dead := [3]float32{float32(background.R) / 255, float32(background.G) / 255, float32(background.B) / 255}
+	pixels := make([]byte, 0, 3*width*height)
+	for j := 0; j < height; j++ {
+		// Rows count down from the top; the board's up from the bottom.
+		y0, y1 := ledSpan(height-1-j, height, rows)
+		for i := 0; i < width; i++ {
+			x0, x1 := ledSpan(i, width, columns)
+			var sum [3]float32
+			for x := x0; x < x1; x++ {
+				for y := y0; y < y1; y++ {
+					colour := dead
+					if c := cells.At(x, y); c.Alive {
+						colour[0], colour[1], colour[2] = render.CellColour(c)
+					}
+					sum[0], sum[1], sum[2] = sum[0]+colour[0], sum[1]+colour[1], sum[2]+colour[2]
+				}
+			}
+			n := float32((x1 - x0) * (y1 - y0))
+			for _, v := range sum {
+				pixels = append(pixels, byte(v/n*255+0.5))
+			}
+		}
+	}
+	return pixels
+}
+
+// ledSpan returns the cells, from first up to but not including last, that
+// LED i of leds covers on a side of cells cells.
+func ledSpan(i, leds, cells int) (first, last int) {
+	first = i * cells / leds
+	last = max((i+1)*cells/leds, first+1)
+	return first, last
+}
+
+// wledPackets encodes pixels in WLED's UDP realtime protocol: a DRGB
+// packet if they fit in one, or else DNRGB packets of up to wledMaxDNRGB
+// LEDs each, starting at their first LED's index.
+func wledPackets(pixels []byte) [][]byte {
+	leds := len(pixels) / 3
+	if leds <= wledMaxDRGB {
+		return [][]byte{append([]byte{2, wledTimeout}, pixels...)}
+	}
+	var packets [][]byte
+	for start := 0; start < leds; start += wledMaxDNRGB {
+		end := min(start+wledMaxDNRGB, leds)
+		p := []byte{4, wledTimeout, byte(start >> 8), byte(start)}
+		packets = append(packets, append(p, pixels[3*start:3*end]...))
+	}
+	return packets
+}
+
+// e131Packets encodes pixels as E1.31 data packets, e131Pixels to a
+// universe from universe up, from the source cid.
+func e131Packets(pixels []byte, universe int, sequence byte, cid [16]byte) [][]byte {
+	var packets [][]byte
+	for start := 0; start < len(pixels); start += 3 * e131Pixels {
+		data := pixels[start:min(start+3*e131Pixels, len(pixels))]
+		packets = append(packets, e131Packet(data, universe+start/(3*e131Pixels), sequence, cid))
+	}
+	return packets
+}
+
+// e131Packet is an E1.31 data packet of the DMX channels data for
+// universe: a root layer, a framing layer and a DMP layer, all
+// big-endian, each starting with its length from there on.
+func e131Packet(data []byte, universe int, sequence byte, cid [16]byte) []byte {
+	p := make([]byte, e131Header+len(data))
+	be := binary.BigEndian
+	layer := func(at int) uint16 { return 0x7000 | uint16(len(p)-at) }
+	// Root layer.
+	be.PutUint16(p[0:], 0x0010)
+	copy(p[4:], "ASC-E1.17\x00\x00\x00")
+	be.PutUint16(p[16:], layer(16))
+	be.PutUint32(p[18:], 0x00000004)
+	copy(p[22:], cid[:])
+	// Framing layer.
+	be.PutUint16(p[38:], layer(38))
+	be.PutUint32(p[40:], 0x00000002)
+	copy(p[44:107], "Conway's Game of Life")
+	p[108] = 100
+	p[111] = sequence
+	be.PutUint16(p[113:], uint16(universe))
+	// DMP layer, with the channels after a zero start code.
+	be.PutUint16(p[115:], layer(115))
+	p[117] = 0x02
+	p[118] = 0xa1
+	be.PutUint16(p[121:], 1)
+	be.PutUint16(p[123:], uint16(1+len(data)))
+	copy(p[e131Header:], data)
+	return p
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// TestLEDPixels maps boards bigger and smaller than the matrix onto it,
+// for each LED to be the average of the cells it covers, top row first.
+func TestLEDPixels(t *testing.T) {
+	bg := color.RGBA{0, 0, 40, 255}
+	r, g, b := render.CellColour(life.Cell{Alive: true, Age: 1})
+	live := []byte{byte(r*255 + 0.5), byte(g*255 + 0.5), byte(b*255 + 0.5)}
+	half := []byte{byte(r*255/2 + 0.5), byte(g*255/2 + 0.5), byte((b+40.0/255)*255/2 + 0.5)}
+	dead := []byte{0, 0, 40}
+
+	// A 4x4 board onto 2x2: the bottom left quarter's all alive, the top
+	// right's half.
+	cells := life.NewGrid(4, 4)
+	for _, c := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {2, 3}, {3, 3}} {
+		cells.Set(c[0], c[1], true)
+	}
+	want := bytes.Join([][]byte{dead, half, live, dead}, nil)
+	if got := ledPixels(cells, 2, 2, bg); !bytes.Equal(got, want) {
+		t.Errorf("4x4 onto 2x2 is %v, want %v", got, want)
+	}
+
+	// A 2x1 board onto 4x2: each cell lights two LEDs across and both
+	// rows.
+	cells = life.NewGrid(2, 1)
+	cells.Set(1, 0, true)
+	want = bytes.Join([][]byte{dead, dead, live, live, dead, dead, live, live}, nil)
+	if got := ledPixels(cells, 4, 2, bg); !bytes.Equal(got, want) {
+		t.Errorf("2x1 onto 4x2 is %v, want %v", got, want)
+	}
+}
+
+func TestWLEDPackets(t *testing.T) {
+	small := bytes.Repeat([]byte{1, 2, 3}, 16*16)
+	packets := wledPackets(small)
+	if len(packets) != 1 || !bytes.Equal(packets[0][:2], []byte{2, wledTimeout}) || !bytes.Equal(packets[0][2:], small) {
+		t.Fatalf("16x16 is %d packets, want a DRGB one", len(packets))
+	}
+
+	// 32x32 is too many LEDs for DRGB, so goes in DNRGB packets, each
+	// saying the LED it starts at.
+	big := make([]byte, 3*32*32)
+	for i := range big {
+		big[i] = byte(i / 3)
+	}
+	packets = wledPackets(big)
+	if len(packets) != 3 {
+		t.Fatalf("32x32 is %d packets, want 3", len(packets))
+	}
+	for i, p := range packets {
+		start := i * wledMaxDNRGB
+		leds := min(wledMaxDNRGB, 32*32-start)
+		if p[0] != 4 || p[1] != wledTimeout || int(binary.BigEndian.Uint16(p[2:])) != start || len(p) != 4+3*leds || p[4] != byte(start) {
+			t.Errorf("packet %d starts %v and is %d bytes, want DNRGB from LED %d, %d bytes", i, p[:5], len(p), start, 4+3*leds)
+		}
+	}
+}
+
+func TestE131Packets(t *testing.T) {
+	var cid [16]byte
+	copy(cid[:], "0123456789abcdef")
+	pixels := bytes.Repeat([]byte{9, 8, 7}, 32*32)
+	packets := e131Packets(pixels, 5, 42, cid)
+	// 1024 pixels, 170 to a universe.
+	if len(packets) != 7 {
+		t.Fatalf("32x32 is %d packets, want 7", len(packets))
+	}
+	be := binary.BigEndian
+	for i, p := range packets {
+		channels := min(3*e131Pixels, len(pixels)-i*3*e131Pixels)
+		if len(p) != e131Header+channels {
+			t.Errorf("packet %d is %d bytes, want %d", i, len(p), e131Header+channels)
+			continue
+		}
+		for _, f := range []struct {
+			name      string
+			got, want uint32
+		}{
+			{"preamble", uint32(be.Uint16(p[0:])), 0x10},
+			{"root length", uint32(be.Uint16(p[16:])), 0x7000 | uint32(len(p)-16)},
+			{"root vector", be.Uint32(p[18:]), 4},
+			{"framing length", uint32(be.Uint16(p[38:])), 0x7000 | uint32(len(p)-38)},
+			{"framing vector", be.Uint32(p[40:]), 2},
+			{"priority", uint32(p[108]), 100},
+			{"sequence", uint32(p[111]), 42},
+			{"universe", uint32(be.Uint16(p[113:])), uint32(5 + i)},
+			{"DMP length", uint32(be.Uint16(p[115:])), 0x7000 | uint32(len(p)-115)},
+			{"DMP vector", uint32(p[117]), 2},
+			{"address type", uint32(p[118]), 0xa1},
+			{"increment", uint32(be.Uint16(p[121:])), 1},
+			{"values", uint32(be.Uint16(p[123:])), uint32(1 + channels)},
+			{"start code", uint32(p[125]), 0},
+		} {
+			if f.got != f.want {
+				t.Errorf("packet %d: %s is %#x, want %#x", i, f.name, f.got, f.want)
+			}
+		}
+		if string(p[4:16]) != "ASC-E1.17\x00\x00\x00" || !bytes.Equal(p[22:38], cid[:]) || !strings.HasPrefix(string(p[44:107]), "Conway's Game of Life\x00") {
+			t.Errorf("packet %d has the wrong identifier, source or name", i)
+		}
+		if !bytes.Equal(p[e131Header:], pixels[i*3*e131Pixels:i*3*e131Pixels+channels]) {
+			t.Errorf("packet %d has the wrong channels", i)
+		}
+	}
+}
+
+// TestLEDSink sends a board to a matrix listening on a local port, and
+// then to one that isn't there, for that to be reported and the run to
+// carry on.
+func TestLEDSink(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.TickRate = 8, 8, 240
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 1)[0]
+	l, err := rs.newLEDSink(listener.LocalAddr().String(), "4x4", "wled", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.stepped(sim)
+	buf := make([]byte, 1500)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := wledPackets(ledPixels(sim.Cells, 4, 4, cfg.Background))[0]; !bytes.Equal(buf[:n], want) {
+		t.Errorf("the matrix got %v, want %v", buf[:n], want)
+	}
+	l.close()
+
+	addr := listener.LocalAddr().String()
+	listener.Close()
+	reports := make(chan errorReport, 1)
+	rs.errorReports = reports
+	if l, err = rs.newLEDSink(addr, "4x4", "e131", 1); err != nil {
+		t.Fatal(err)
+	}
+	defer l.close()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		sim.Step(false)
+		l.stepped(sim)
+		select {
+		case r := <-reports:
+			if !strings.Contains(r.message, "couldn't send to the LEDs; carrying on") {
+				t.Errorf("reported %q", r.message)
+			}
+			return
+		default:
+		}
+	}
+	t.Error("sending to nowhere wasn't reported")
+}
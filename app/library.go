@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// findLibraryPattern looks a built-in pattern up by its id or name, ignoring
+// case, suggesting ones with similar names when there's no such pattern.
+func findLibraryPattern(name string) (life.LibraryEntry, error) {
+	want := strings.ToLower(name)
+	var close []string
+	for _, e := range life.Library() {
+		if want == e.ID || want == strings.ToLower(e.Pattern.Name) {
+			return e, nil
+		}
+		if editDistance(want, e.ID) <= 2 || strings.HasPrefix(e.ID, want) {
+			close = append(close, e.ID)
+		}
+	}
+	if len(close) == 0 {
+		return life.LibraryEntry{}, fmt.Errorf("no pattern file or built-in pattern called %q; -list-patterns lists them", name)
+	}
+	sort.Strings(close)
+	return life.LibraryEntry{}, fmt.Errorf("no pattern file or built-in pattern called %q; did you mean %s?", name, strings.Join(close, ", "))
+}
+
+// listLibrary describes the built-in patterns, one per line.
+func listLibrary() string {
+	var out strings.Builder
+	for _, e := range life.Library() {
+		w, h := e.Pattern.Size()
+		fmt.Fprintf(&out, "%-16s %4dx%-4d %s\n", e.ID, w, h, e.Description)
+	}
+	return out.String()
+}
+
+// listAutomata lists the registered automata, for -automaton list.
+func listAutomata() string {
+	var out strings.Builder
+	for _, a := range life.Automata() {
+		fmt.Fprintf(&out, "%-16s %d states  %s\n", a.Name, a.States, a.Description)
+	}
+	return out.String()
+}
+
+// setPalette colours live cells by the palette of the automaton r runs, if
+// it has one.
+func setPalette(r life.Rule) {
+	render.Palette = nil
+	a, ok := r.Automaton()
+	if !ok {
+		return
+	}
+	for _, c := range a.Palette {
+		render.Palette = append(render.Palette, [3]float32{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255})
+	}
+}
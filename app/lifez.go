@@ -0,0 +1,206 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"opengl/life"
+)
+
+// lifezMagic starts every .lifez file, once it's decompressed.
+const lifezMagic = "LIFZ"
+
+// maxLifezRule and maxLifezBoards bound what a .lifez file can claim, as
+// maxGridSide and maxGridCells do its board size, so a corrupt header
+// can't make the reader allocate without limit.
+const (
+	maxLifezRule   = 256
+	maxLifezBoards = 64
+)
+
+// lifezHeader is the fixed-size start of a .lifez file, after the magic.
+// Each board follows as its rule's length and text, a lifezBoard and its
+// packed cells.
+type lifezHeader struct {
+	Version    uint16
+	Columns    uint32
+	Rows       uint32
+	Wrap       bool
+	CameraX    float32
+	CameraY    float32
+	CameraZoom float32
+	Boards     uint16
+}
+
+type lifezBoard struct {
+	Generation uint64
+	Seed       int64
+	Density    float64
+	// RNG is the board's random number generator, as
+	// life.PCG.MarshalBinary has it. Version 1 files don't have it.
+	RNG [lifezRNGSize]byte
+}
+
+// lifezRNGSize is the size of a life.PCG's state.
+const lifezRNGSize = 20
+
+// encodeLifez encodes st in the compact binary state format, as the current
+// version: a header and each board with its cells packed a bit each, gzip
+// compressed.
+func encodeLifez(st state) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := &lifezWriter{w: zw}
+	w.write([]byte(lifezMagic))
+	w.write(lifezHeader{
+		Version:    stateVersion,
+		Columns:    uint32(st.Columns),
+		Rows:       uint32(st.Rows),
+		Wrap:       st.Wrap,
+		CameraX:    st.Camera.X,
+		CameraY:    st.Camera.Y,
+		CameraZoom: st.Camera.Zoom,
+		Boards:     uint16(len(st.Boards)),
+	})
+	for _, b := range st.Boards {
+		w.write(uint16(len(b.Rule)))
+		w.write([]byte(b.Rule))
+		board := lifezBoard{Generation: uint64(b.Generation), Seed: b.Seed, Density: b.Density}
+		rng := b.RNG
+		if rng == nil {
+			// A board from a version 1 file has a generator fresh from
+			// its seed, as it would be loaded.
+			rng, _ = life.NewPCG(b.Seed).MarshalBinary()
+		}
+		if len(rng) != lifezRNGSize {
+			return nil, fmt.Errorf("random number generator state is %d bytes, not %d", len(rng), lifezRNGSize)
+		}
+		copy(board.RNG[:], rng)
+		w.write(board)
+		w.write(b.Cells)
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lifezWriter writes little-endian values, keeping the first error.
+type lifezWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *lifezWriter) write(v any) {
+	if w.err == nil {
+		w.err = binary.Write(w.w, binary.LittleEndian, v)
+	}
+}
+
+// decodeLifez decodes a .lifez file's contents. The header is checked
+// before anything is allocated for the boards it describes.
+func decodeLifez(r io.Reader) (state, error) {
+	var st state
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return st, err
+	}
+	defer zr.Close()
+	read := func(v any) error {
+		err := binary.Read(zr, binary.LittleEndian, v)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	magic := make([]byte, len(lifezMagic))
+	if err := read(magic); err != nil {
+		return st, err
+	}
+	if string(magic) != lifezMagic {
+		return st, fmt.Errorf("not a .lifez state file")
+	}
+	var h lifezHeader
+	if err := read(&h); err != nil {
+		return st, err
+	}
+	switch {
+	case h.Version < 1 || h.Version > stateVersion:
+		return st, fmt.Errorf("state file version %d can't be loaded; want version %d or older", h.Version, stateVersion)
+	case h.Columns == 0 || h.Rows == 0 || h.Columns > maxGridSide || h.Rows > maxGridSide || uint64(h.Columns)*uint64(h.Rows) > maxGridCells:
+		return st, fmt.Errorf("invalid board size %dx%d", h.Columns, h.Rows)
+	case h.Boards > maxLifezBoards:
+		return st, fmt.Errorf("invalid number of boards %d", h.Boards)
+	case math.IsNaN(float64(h.CameraX)) || math.IsNaN(float64(h.CameraY)):
+		return st, fmt.Errorf("invalid camera position")
+	}
+	st = state{
+		Version: int(h.Version),
+		Columns: int(h.Columns),
+		Rows:    int(h.Rows),
+		Wrap:    h.Wrap,
+		Camera:  cameraState{h.CameraX, h.CameraY, h.CameraZoom},
+	}
+	for i := 0; i < int(h.Boards); i++ {
+		var n uint16
+		if err := read(&n); err != nil {
+			return st, err
+		}
+		if n > maxLifezRule {
+			return st, fmt.Errorf("board %d: rule is %d bytes long", i+1, n)
+		}
+		rule := make([]byte, n)
+		if err := read(rule); err != nil {
+			return st, err
+		}
+		var b lifezBoard
+		if h.Version == 1 {
+			err = read(&b.Generation)
+			if err == nil {
+				err = read(&b.Seed)
+			}
+			if err == nil {
+				err = read(&b.Density)
+			}
+		} else {
+			err = read(&b)
+		}
+		if err != nil {
+			return st, err
+		}
+		if b.Generation > math.MaxInt32 {
+			return st, fmt.Errorf("board %d: invalid generation %d", i+1, b.Generation)
+		}
+		cells := make([]byte, packedSize(st.Columns, st.Rows))
+		if err := read(cells); err != nil {
+			return st, err
+		}
+		st.Boards = append(st.Boards, boardState{
+			Rule:       string(rule),
+			Generation: int(b.Generation),
+			Seed:       b.Seed,
+			Density:    b.Density,
+			Cells:      cells,
+		})
+		if h.Version > 1 {
+			st.Boards[i].RNG = b.RNG[:]
+		}
+	}
+	// Reading to the end checks the gzip trailer, catching corruption in
+	// the cells that would otherwise go unnoticed.
+	if n, err := io.Copy(io.Discard, io.LimitReader(zr, 1)); err != nil {
+		return st, err
+	} else if n > 0 {
+		return st, fmt.Errorf("unexpected data after the last board")
+	}
+	return st, nil
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// testLifez returns a state of two small boards and its .lifez encoding.
+func testLifez(t *testing.T) (state, []byte) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 21, 13, true
+	rs := testRun(t, cfg)
+	sims := rs.newSimulations([]int64{3, 4}, []life.Rule{life.Conway, mustParseRule(t, "B36/S23")})
+	stepAll(rs, sims, 9)
+	st := rs.captureState(sims, rs.newCamera())
+	src, err := encodeLifez(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st, src
+}
+
+func TestLifezRoundTrip(t *testing.T) {
+	st, src := testLifez(t)
+	got, err := decodeLifez(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, st) {
+		t.Errorf("decoded state is %+v, want %+v", got, st)
+	}
+}
+
+func TestLifezTruncated(t *testing.T) {
+	_, src := testLifez(t)
+	for n := 0; n < len(src); n++ {
+		if _, err := decodeLifez(bytes.NewReader(src[:n])); err == nil {
+			t.Fatalf("decoding the first %d of %d bytes succeeded, want an error", n, len(src))
+		}
+	}
+	// Cut short before it's compressed too, so the stream ends cleanly
+	// part way through a board.
+	raw := gunzip(t, src)
+	for _, n := range []int{2, len(lifezMagic) + 5, len(raw) / 2, len(raw) - 1} {
+		if _, err := decodeLifez(bytes.NewReader(gzipped(t, raw[:n]))); err == nil {
+			t.Errorf("decoding the first %d of %d bytes of the state succeeded, want an error", n, len(raw))
+		}
+	}
+}
+
+func TestLifezCorrupted(t *testing.T) {
+	_, src := testLifez(t)
+	// Any byte changed after the gzip header breaks the compressed data or
+	// its checksum.
+	for i := 10; i < len(src); i++ {
+		bad := bytes.Clone(src)
+		bad[i] ^= 0x5a
+		if _, err := decodeLifez(bytes.NewReader(bad)); err == nil {
+			t.Fatalf("decoding with byte %d changed succeeded, want an error", i)
+		}
+	}
+
+	// After the magic, the header has the version at 0, columns at 2, rows
+	// at 6 and the number of boards at 23, and the first board's rule
+	// length follows it at 25.
+	raw := gunzip(t, src)
+	header := len(lifezMagic)
+	for _, c := range []struct {
+		name   string
+		change func(raw []byte) []byte
+		want   string
+	}{
+		{"magic", func(b []byte) []byte { b[0] = 'X'; return b }, "not a .lifez"},
+		{"version", func(b []byte) []byte { b[header] = 99; return b }, "version 99"},
+		{"too wide", func(b []byte) []byte { putUint32(b[header+2:], maxGridSide+1); return b }, "invalid board size"},
+		{"too many cells", func(b []byte) []byte {
+			putUint32(b[header+2:], maxGridSide)
+			putUint32(b[header+6:], maxGridSide)
+			return b
+		}, "invalid board size"},
+		{"too many boards", func(b []byte) []byte { b[header+24] = 0xff; return b }, "invalid number of boards"},
+		{"rule too long", func(b []byte) []byte { b[header+26] = 0xff; return b }, "rule is"},
+		{"trailing data", func(b []byte) []byte { return append(b, 0) }, "after the last board"},
+	} {
+		_, err := decodeLifez(bytes.NewReader(gzipped(t, c.change(bytes.Clone(raw)))))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: decoding gives %v, want an error saying %q", c.name, err, c.want)
+		}
+	}
+}
+
+func putUint32(b []byte, v uint32) {
+	b[0], b[1], b[2], b[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+}
+
+func gunzip(t *testing.T, src []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw bytes.Buffer
+	if _, err := raw.ReadFrom(zr); err != nil {
+		t.Fatal(err)
+	}
+	return raw.Bytes()
+}
+
+func gzipped(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	zw := gzip.NewWriter(&out)
+	zw.Write(raw)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/render"
+)
+
+// startLogging sends everything logged through slog, the event log and the
+// log package included, to standard error as text, or to -log-file as JSON
+// lines, at -log-level and up. The returned function closes the file and
+// puts the previous logger back.
+func (rs *runState) startLogging() (func(), error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(rs.config.LogLevel)); err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	var file *os.File
+	if rs.config.LogFile != "" {
+		f, err := os.OpenFile(rs.config.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+		handler = slog.NewJSONHandler(f, opts)
+	}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(handler))
+	return func() {
+		slog.SetDefault(previous)
+		if file != nil {
+			file.Close()
+		}
+	}, nil
+}
+
+// logEnvironment logs, at debug level, what a driver bug report needs:
+// the OpenGL implementation behind window's context, its limits, and the
+// monitors.
+func logEnvironment(window *glfw.Window) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	env, caps := render.CurrentEnvironment(), render.CurrentCaps()
+	slog.Debug("OpenGL environment",
+		"vendor", env.Vendor,
+		"renderer", env.Renderer,
+		"version", env.Version,
+		"glsl", env.GLSLVersion,
+		"context", fmt.Sprintf("%d.%d", env.Major, env.Minor),
+		"es", env.ES,
+		"extensions", env.Extensions,
+		"max_texture_size", env.MaxTextureSize,
+		"max_viewport", fmt.Sprintf("%dx%d", env.MaxViewport[0], env.MaxViewport[1]),
+		"max_samples", caps.MaxSamples,
+		"max_ssbo_size", env.MaxSSBOSize,
+		"debug_context", render.DebugContext(),
+	)
+	var have []any
+	for _, c := range capabilities(caps) {
+		have = append(have, c.name, c.have)
+	}
+	slog.Debug("OpenGL capabilities", have...)
+	for i, m := range glfw.GetMonitors() {
+		attrs := []any{"monitor", i + 1, "name", m.GetName()}
+		if mode := m.GetVideoMode(); mode != nil {
+			attrs = append(attrs, "mode", fmt.Sprintf("%dx%d@%dHz", mode.Width, mode.Height, mode.RefreshRate))
+		}
+		sx, sy := m.GetContentScale()
+		attrs = append(attrs, "content_scale", fmt.Sprintf("%gx%g", sx, sy))
+		if mw, mh := m.GetPhysicalSize(); mw > 0 && mh > 0 {
+			attrs = append(attrs, "size_mm", fmt.Sprintf("%dx%d", mw, mh))
+		}
+		slog.Debug("monitor", attrs...)
+	}
+	w, h := window.GetSize()
+	fw, fh := window.GetFramebufferSize()
+	sx, sy := window.GetContentScale()
+	slog.Debug("window",
+		"size", fmt.Sprintf("%dx%d", w, h),
+		"framebuffer", fmt.Sprintf("%dx%d", fw, fh),
+		"content_scale", fmt.Sprintf("%gx%g", sx, sy),
+		"glfw", glfw.GetVersionString(),
+	)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// TestGLFWErrorsLogged logs a GLFW platform error the way the bindings
+// do, through the log package, for it to reach -log-file with its code and
+// description.
+func TestGLFWErrorsLogged(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LogFile = filepath.Join(t.TempDir(), "life.log")
+	rs := testRun(t, cfg)
+	stop, err := rs.startLogging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Println(&glfw.Error{Code: glfw.ErrorCode(0x10008), Desc: "X11: Failed to read clipboard"})
+	stop()
+
+	b, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatalf("the log is %q: %v", b, err)
+	}
+	if record["msg"] != "PlatformError: X11: Failed to read clipboard" || record["level"] != "INFO" {
+		t.Errorf("the GLFW error was logged as %v", record)
+	}
+}
+
+func TestRecoverGLFW(t *testing.T) {
+	fatal := func() (err error) {
+		defer recoverGLFW(&err)
+		panic(&glfw.Error{Code: glfw.APIUnavailable, Desc: "no OpenGL"})
+	}
+	var glfwErr *glfw.Error
+	if err := fatal(); !errors.As(err, &glfwErr) || err.Error() != "GLFW: APIUnavailable: no OpenGL" {
+		t.Errorf("a fatal GLFW error came back as %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "not GLFW's" {
+			t.Errorf("other panics came out as %v", r)
+		}
+	}()
+	func() (err error) {
+		defer recoverGLFW(&err)
+		panic("not GLFW's")
+	}()
+}
@@ -0,0 +1,82 @@
+package app
+
+import "math"
+
+// mat4 is a column-major 4x4 matrix, laid out the way gl.UniformMatrix4fv
+// expects it.
+type mat4 [16]float32
+
+func identity() mat4 {
+	return mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// mul returns m * n.
+func (m mat4) mul(n mat4) mat4 {
+	var r mat4
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			var sum float32
+			for k := 0; k < 4; k++ {
+				sum += m[k*4+row] * n[col*4+k]
+			}
+			r[col*4+row] = sum
+		}
+	}
+	return r
+}
+
+// perspective returns a projection with the given vertical field of view in
+// radians.
+func perspective(fovy, aspect, near, far float32) mat4 {
+	f := float32(1 / math.Tan(float64(fovy)/2))
+	return mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), -1,
+		0, 0, 2 * far * near / (near - far), 0,
+	}
+}
+
+type vec3 [3]float32
+
+func (v vec3) sub(w vec3) vec3 {
+	return vec3{v[0] - w[0], v[1] - w[1], v[2] - w[2]}
+}
+
+func (v vec3) dot(w vec3) float32 {
+	return v[0]*w[0] + v[1]*w[1] + v[2]*w[2]
+}
+
+func (v vec3) cross(w vec3) vec3 {
+	return vec3{
+		v[1]*w[2] - v[2]*w[1],
+		v[2]*w[0] - v[0]*w[2],
+		v[0]*w[1] - v[1]*w[0],
+	}
+}
+
+func (v vec3) normalize() vec3 {
+	l := float32(math.Sqrt(float64(v.dot(v))))
+	if l == 0 {
+		return v
+	}
+	return vec3{v[0] / l, v[1] / l, v[2] / l}
+}
+
+// lookAt returns a view matrix for an eye looking at center.
+func lookAt(eye, center, up vec3) mat4 {
+	f := center.sub(eye).normalize()
+	s := f.cross(up).normalize()
+	u := s.cross(f)
+	return mat4{
+		s[0], u[0], -f[0], 0,
+		s[1], u[1], -f[1], 0,
+		s[2], u[2], -f[2], 0,
+		-s.dot(eye), -u.dot(eye), f.dot(eye), 1,
+	}
+}
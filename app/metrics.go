@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// frameBuckets are the upper bounds, in seconds, of the frame time
+// histograms' buckets, around the 16.7ms of a 60Hz frame.
+var frameBuckets = [...]float64{0.001, 0.0025, 0.005, 0.01, 0.0167, 0.025, 0.05, 0.1, 0.25}
+
+// histogram counts durations into frameBuckets.
+type histogram struct {
+	// counts[i] counts the durations up to frameBuckets[i], and the last
+	// the longer ones. nanos is their total.
+	counts [len(frameBuckets) + 1]atomic.Int64
+	nanos  atomic.Int64
+}
+
+func (h *histogram) record(d time.Duration) {
+	i := 0
+	for i < len(frameBuckets) && d.Seconds() > frameBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.nanos.Add(int64(d))
+}
+
+// write writes h to b as the histogram name, in seconds.
+func (h *histogram) write(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var count int64
+	for i := range h.counts {
+		count += h.counts[i].Load()
+		le := "+Inf"
+		if i < len(frameBuckets) {
+			le = strconv.FormatFloat(frameBuckets[i], 'g', -1, 64)
+		}
+		fmt.Fprintf(b, "%s_bucket{le=%q} %d\n", name, le, count)
+	}
+	fmt.Fprintf(b, "%s_sum %g\n%s_count %d\n", name, time.Duration(h.nanos.Load()).Seconds(), name, count)
+}
+
+// runMetrics are the numbers -metrics serves. The loops only ever add to
+// and store them atomically, and the handler loads them when scraped, so
+// neither waits on the other.
+type runMetrics struct {
+	enabled bool
+
+	generation, population      atomic.Int64
+	generations, births, deaths atomic.Int64
+
+	// frames are how long frames took on the CPU, and boardPasses how long
+	// the boards took to draw on the GPU.
+	frames, boardPasses histogram
+}
+
+// recordStep records that sim, the first board, stepped a generation.
+func (rs *runState) recordStep(sim *life.Simulation) {
+	if !rs.metrics.enabled {
+		return
+	}
+	rs.metrics.generation.Store(int64(sim.Generation))
+	rs.metrics.population.Store(int64(sim.Cells.Population()))
+	rs.metrics.generations.Add(1)
+	rs.metrics.births.Add(int64(sim.Births))
+	rs.metrics.deaths.Add(int64(sim.Deaths))
+}
+
+// recordFrame records that a frame took d, not counting the wait for the
+// next.
+func (rs *runState) recordFrame(d time.Duration) {
+	if !rs.metrics.enabled {
+		return
+	}
+	rs.metrics.frames.record(d)
+}
+
+// recordBoardPass records that drawing a board took d on the GPU.
+func (rs *runState) recordBoardPass(d time.Duration) {
+	if !rs.metrics.enabled {
+		return
+	}
+	rs.metrics.boardPasses.record(d)
+}
+
+// startMetrics serves the metrics on addr at /metrics, in Prometheus's text
+// format, returning a function that shuts the server down.
+func (rs *runState) startMetrics(addr string) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("-metrics: %w", err)
+	}
+	rs.metrics.enabled = true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, rs.exposition())
+	})
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("-metrics", "err", err)
+		}
+	}()
+	slog.Info("serving metrics", "url", fmt.Sprintf("http://%s/metrics", ln.Addr()))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
+
+// exposition returns the metrics in Prometheus's text exposition format.
+func (rs *runState) exposition() string {
+	var b strings.Builder
+	metric := func(name, kind, help string, value int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+	}
+	metric("life_generation", "gauge", "The first board's generation.", rs.metrics.generation.Load())
+	metric("life_population", "gauge", "Live cells on the first board.", rs.metrics.population.Load())
+	metric("life_generations_total", "counter", "Generations stepped.", rs.metrics.generations.Load())
+	metric("life_births_total", "counter", "Cells born on the first board.", rs.metrics.births.Load())
+	metric("life_deaths_total", "counter", "Cells that died on the first board.", rs.metrics.deaths.Load())
+	metric("life_gl_upload_bytes_total", "counter", "Bytes uploaded to OpenGL buffers and textures while drawing.", render.UploadedBytes())
+
+	rs.metrics.frames.write(&b, "life_frame_seconds", "Time taken to draw a frame and take input.")
+	rs.metrics.boardPasses.write(&b, "life_gpu_board_seconds", "Time the GPU took to draw a board, where the context has timer queries.")
+	return b.String()
+}
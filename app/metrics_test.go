@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// scrape fetches the metrics at addr, returning each sample by name.
+func scrape(t *testing.T, addr string) map[string]string {
+	t.Helper()
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("metrics served as %s", ct)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("metrics line %q has no value", line)
+		}
+		samples[name] = value
+	}
+	return samples
+}
+
+// TestMetricsHeadless scrapes -metrics while a headless run goes and after
+// it's done, for the numbers to be the run's.
+func TestMetricsHeadless(t *testing.T) {
+	addr := freeAddr(t)
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 64, 48, true
+	cfg.Seed, cfg.Generations, cfg.Headless = 7, 2000, true
+	rs := testRun(t, cfg)
+	var out bytes.Buffer
+	rs.stdout = &out
+	stop, err := rs.startMetrics(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uploads are counted for the whole process, which other tests may
+	// have drawn in.
+	uploaded := render.UploadedBytes()
+	done := make(chan error, 1)
+	go func() { done <- rs.runHeadless(seeds, rules, nil) }()
+	for running := true; running; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			running = false
+		default:
+			scrape(t, addr)
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	sim := life.NewSimulation(life.NewGrid(64, 48), life.Conway, 7, cfg.Density, 0)
+	births, deaths := 0, 0
+	for i := 0; i < 2000; i++ {
+		sim.Step(true)
+		births += sim.Births
+		deaths += sim.Deaths
+	}
+	got := scrape(t, addr)
+	for name, want := range map[string]int64{
+		"life_generation":        2000,
+		"life_generations_total": 2000,
+		"life_population":        int64(sim.Cells.Population()),
+		"life_births_total":      int64(births),
+		"life_deaths_total":      int64(deaths),
+		// Headless, nothing's drawn.
+		"life_frame_seconds_count":   0,
+		"life_gl_upload_bytes_total": uploaded,
+	} {
+		if got[name] != fmt.Sprint(want) {
+			t.Errorf("%s is %s, want %d", name, got[name], want)
+		}
+	}
+	if got[`life_frame_seconds_bucket{le="+Inf"}`] != "0" {
+		t.Errorf("the frame histogram's last bucket is %s", got[`life_frame_seconds_bucket{le="+Inf"}`])
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, d := range []time.Duration{500 * time.Microsecond, time.Millisecond, 14 * time.Millisecond, 20 * time.Millisecond, time.Second} {
+		h.record(d)
+	}
+	var b strings.Builder
+	h.write(&b, "frame_seconds", "Frames.")
+	for _, want := range []string{
+		"# TYPE frame_seconds histogram\n",
+		`frame_seconds_bucket{le="0.001"} 2` + "\n",
+		`frame_seconds_bucket{le="0.01"} 2` + "\n",
+		`frame_seconds_bucket{le="0.0167"} 3` + "\n",
+		`frame_seconds_bucket{le="0.025"} 4` + "\n",
+		`frame_seconds_bucket{le="0.25"} 4` + "\n",
+		`frame_seconds_bucket{le="+Inf"} 5` + "\n",
+		"frame_seconds_sum 1.0355\nframe_seconds_count 5\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("the histogram doesn't have %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/render"
+)
+
+const (
+	minimapMinX = 0.55
+	minimapMinY = 0.55
+	minimapMaxX = 0.95
+	minimapMaxY = 0.95
+)
+
+// minimap shows the whole board in a corner of the window with the camera's
+// view outlined. It is only drawn while part of the board is out of view.
+type minimap struct {
+	visible bool
+
+	camera  *camera
+	texture *boardTexture
+
+	program *render.ShaderProgram
+	quad    uint32
+	frame   *lines
+	overlay *overlayProgram
+}
+
+func (rs *runState) newMinimap(cam *camera, texture *boardTexture, overlay *overlayProgram) (*minimap, error) {
+	program, err := render.NewProgram("minimap")
+	if err != nil {
+		return nil, err
+	}
+
+	quad := []float32{
+		minimapMinX, minimapMinY, 0, 0,
+		minimapMaxX, minimapMinY, 1, 0,
+		minimapMinX, minimapMaxY, 0, 1,
+		minimapMaxX, minimapMaxY, 1, 1,
+	}
+	vbo := render.Gen(render.Buffer, "minimap quad")
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	render.SetSize(render.Buffer, vbo, 4*len(quad))
+
+	vao := render.Gen(render.VertexArray, "minimap quad")
+	gl.BindVertexArray(vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 16, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 16, gl.PtrOffset(8))
+	rs.freeOnClose(func() {
+		render.Delete(render.VertexArray, vao)
+		render.Delete(render.Buffer, vbo)
+		program.Delete()
+	})
+
+	return &minimap{
+		visible: true,
+		camera:  cam,
+		texture: texture,
+		program: program,
+		quad:    vao,
+		frame:   rs.newLines("minimap frame", 8),
+		overlay: overlay,
+	}, nil
+}
+
+func (m *minimap) active() bool {
+	return m.visible && !m.camera.showsWholeBoard()
+}
+
+// contains reports whether the point, in normalized device coordinates, lies
+// on the minimap.
+func (m *minimap) contains(x, y float32) bool {
+	return m.active() && x >= minimapMinX && x <= minimapMaxX && y >= minimapMinY && y <= minimapMaxY
+}
+
+// toWorld converts a point on the minimap to the world position it depicts.
+func (m *minimap) toWorld(x, y float32) (float32, float32) {
+	return (x-minimapMinX)/(minimapMaxX-minimapMinX)*2 - 1, (y-minimapMinY)/(minimapMaxY-minimapMinY)*2 - 1
+}
+
+func (m *minimap) fromWorld(x, y float32) (float32, float32) {
+	return minimapMinX + (clamp(x, -1, 1)+1)/2*(minimapMaxX-minimapMinX), minimapMinY + (clamp(y, -1, 1)+1)/2*(minimapMaxY-minimapMinY)
+}
+
+func (m *minimap) draw() {
+	if !m.active() {
+		return
+	}
+
+	m.program.Use()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, m.texture.id)
+	gl.BindVertexArray(m.quad)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+	minX, minY, maxX, maxY := m.camera.view()
+	minX, minY = m.fromWorld(minX, minY)
+	maxX, maxY = m.fromWorld(maxX, maxY)
+	m.frame.reset()
+	m.frame.rect(minX, minY, maxX, maxY)
+	m.overlay.use(1, 0.8, 0.2, 1)
+	m.frame.draw(gl.LINE_LOOP)
+}
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
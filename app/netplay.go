@@ -0,0 +1,494 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"opengl/life"
+)
+
+// -host and -join share a board between instances over TCP. The host runs
+// the board; instances that join it draw what it sends and send it their
+// edits, which the host makes as its own and sends back out like any other
+// change.
+//
+// Either end starts with a hello: netMagic and a uint16 version, and from
+// the host the board's columns and rows as uint32s too. After that,
+// everything is a message: a little-endian uint32 length and then that
+// many bytes. The host sends the frames of boardStream, and joiners send
+//
+//	'E', then x, y uint32 and alive byte per cell: an edit
+//	'R': a request for a keyframe, on finding its board has diverged
+
+const (
+	netMagic   = "LIFE"
+	netVersion = 1
+	// maxNetMessage is the largest message either end accepts.
+	maxNetMessage = 64 << 20
+	// netTimeout is how long a hello, or a write, can take.
+	netTimeout = 10 * time.Second
+	// maxRejoinDelay is the longest a joiner waits between tries to
+	// reconnect.
+	maxRejoinDelay = 30 * time.Second
+)
+
+// errNetRefused is wrapped by the reasons a host can't be joined at all,
+// which trying again won't fix.
+var errNetRefused = errors.New("can't join")
+
+func writeNetMessage(w *bufio.Writer, msg []byte) error {
+	w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(msg))))
+	w.Write(msg)
+	return w.Flush()
+}
+
+func readNetMessage(r *bufio.Reader) ([]byte, error) {
+	var n [4]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(n[:])
+	if size == 0 || size > maxNetMessage {
+		return nil, fmt.Errorf("bad message length %d", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (rs *runState) netHello(host bool) []byte {
+	hello := binary.LittleEndian.AppendUint16([]byte(netMagic), netVersion)
+	if host {
+		hello = binary.LittleEndian.AppendUint32(hello, uint32(rs.config.GridWidth))
+		hello = binary.LittleEndian.AppendUint32(hello, uint32(rs.config.GridHeight))
+	}
+	return hello
+}
+
+// readHello reads the other end's hello, returning its version.
+func readHello(r io.Reader) (int, error) {
+	var hello [6]byte
+	if _, err := io.ReadFull(r, hello[:]); err != nil {
+		return 0, err
+	}
+	if string(hello[:4]) != netMagic {
+		return 0, fmt.Errorf("%w: not a Game of Life host", errNetRefused)
+	}
+	return int(binary.LittleEndian.Uint16(hello[4:])), nil
+}
+
+// startHost lets other instances join the boards on addr, reaching them
+// through rc, returning a function that disconnects everyone.
+func (rs *runState) startHost(addr string, rc *remoteControl) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("-host: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var conns sync.WaitGroup
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("-host", "err", err)
+				}
+				return
+			}
+			conns.Add(1)
+			go func() {
+				defer conns.Done()
+				rs.hostJoiner(ctx, rc, conn)
+			}()
+		}
+	}()
+	slog.Info("hosting the board", "addr", ln.Addr().String())
+	return func() {
+		cancel()
+		ln.Close()
+		conns.Wait()
+	}, nil
+}
+
+// hostJoiner sends an instance that's joined the board until it leaves or
+// ctx is done, making the edits it sends.
+func (rs *runState) hostJoiner(ctx context.Context, rc *remoteControl, conn net.Conn) {
+	defer conn.Close()
+	who := conn.RemoteAddr().String()
+	conn.SetDeadline(time.Now().Add(netTimeout))
+	version, err := readHello(conn)
+	if err == nil {
+		_, err = conn.Write(rs.netHello(true))
+	}
+	if err != nil {
+		slog.Warn("-host", "peer", who, "err", err)
+		return
+	}
+	if version != netVersion {
+		slog.Warn("-host: wrong protocol version", "peer", who, "version", version, "want", netVersion)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	joined, err := rc.do(ctx, func(c *boardControls) (any, error) { return rc.stream.join(c.sims[0]), nil })
+	if err != nil {
+		return
+	}
+	client := joined.(*streamClient)
+	defer rc.stream.leave(client)
+	slog.Info("joined", "peer", who)
+
+	gone := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := readNetMessage(r)
+			if err == nil {
+				err = rs.hostMessage(ctx, rc, client, msg)
+			}
+			if err != nil {
+				gone <- err
+				return
+			}
+		}
+	}()
+	w := bufio.NewWriter(conn)
+	for {
+		select {
+		case frame := <-client.frames:
+			conn.SetWriteDeadline(time.Now().Add(netTimeout))
+			if err := writeNetMessage(w, frame); err != nil {
+				slog.Info("left", "peer", who, "err", err)
+				return
+			}
+		case err := <-gone:
+			if errors.Is(err, io.EOF) {
+				slog.Info("left", "peer", who)
+			} else {
+				slog.Info("left", "peer", who, "err", err)
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// hostMessage does what a joiner's message asks.
+func (rs *runState) hostMessage(ctx context.Context, rc *remoteControl, client *streamClient, msg []byte) error {
+	switch msg[0] {
+	case 'E':
+		cells, err := rs.decodeNetEdit(msg)
+		if err != nil {
+			return err
+		}
+		_, err = rc.do(ctx, func(c *boardControls) (any, error) {
+			c.edit(func() {
+				for _, cell := range cells {
+					c.sims[0].Cells.Set(cell.X, cell.Y, cell.Alive)
+				}
+			})
+			return nil, nil
+		})
+		return err
+	case 'R':
+		_, err := rc.do(ctx, func(c *boardControls) (any, error) {
+			rc.stream.resync(client, c.sims[0])
+			return nil, nil
+		})
+		return err
+	default:
+		return fmt.Errorf("unknown message %q", msg[0])
+	}
+}
+
+func encodeNetEdit(cells []apiCell) []byte {
+	msg := []byte{'E'}
+	for _, cell := range cells {
+		msg = binary.LittleEndian.AppendUint32(msg, uint32(cell.X))
+		msg = binary.LittleEndian.AppendUint32(msg, uint32(cell.Y))
+		alive := byte(0)
+		if cell.Alive {
+			alive = 1
+		}
+		msg = append(msg, alive)
+	}
+	return msg
+}
+
+func (rs *runState) decodeNetEdit(msg []byte) ([]apiCell, error) {
+	if (len(msg)-1)%9 != 0 {
+		return nil, fmt.Errorf("an edit of %d bytes doesn't divide into cells", len(msg))
+	}
+	var cells []apiCell
+	for b := msg[1:]; len(b) > 0; b = b[9:] {
+		x, y := int(binary.LittleEndian.Uint32(b)), int(binary.LittleEndian.Uint32(b[4:]))
+		if x >= rs.config.GridWidth || y >= rs.config.GridHeight {
+			return nil, fmt.Errorf("cell (%d, %d) is off the %dx%d board", x, y, rs.config.GridWidth, rs.config.GridHeight)
+		}
+		cells = append(cells, apiCell{x, y, b[8] != 0})
+	}
+	return cells, nil
+}
+
+// netJoiner is this instance joined to a -host. Its connection is kept on
+// goroutines of its own, reconnecting whenever it's lost, and hands what
+// the host sends to the main thread, which applies it to the first board.
+type netJoiner struct {
+	rs *runState
+
+	addr   string
+	frames chan []byte
+	out    chan []byte
+	// news is what's happened to the connection, for the status line.
+	news chan string
+	quit chan struct{}
+	// synced is whether the board has had a keyframe since joining or
+	// diverging. It's only used on the main thread.
+	synced bool
+}
+
+func (rs *runState) joinHost(addr string) *netJoiner {
+	j := &netJoiner{rs: rs, addr: addr, frames: make(chan []byte, 256), out: make(chan []byte, 64), news: make(chan string, 8), quit: make(chan struct{})}
+	go j.run()
+	return j
+}
+
+func (j *netJoiner) tell(news string) {
+	slog.Info(news)
+	select {
+	case j.news <- news:
+	default:
+	}
+}
+
+// run stays joined to the host, waiting longer between each try while it
+// can't be reached, until the host refuses it or close is called.
+func (j *netJoiner) run() {
+	delay := time.Second
+	for {
+		joined, err := j.session()
+		select {
+		case <-j.quit:
+			return
+		default:
+		}
+		if errors.Is(err, errNetRefused) {
+			j.tell(fmt.Sprintf("Can't join %s: %v", j.addr, err))
+			return
+		}
+		if joined {
+			delay = time.Second
+		}
+		j.tell(fmt.Sprintf("Lost %s: %v; trying again in %v", j.addr, err, delay))
+		select {
+		case <-time.After(delay):
+		case <-j.quit:
+			return
+		}
+		delay = min(2*delay, maxRejoinDelay)
+	}
+}
+
+// session joins the host and passes messages to and fro until the
+// connection's lost, reporting whether it got as far as joining.
+func (j *netJoiner) session() (bool, error) {
+	conn, err := net.DialTimeout("tcp", j.addr, netTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(netTimeout))
+	if _, err := conn.Write(j.rs.netHello(false)); err != nil {
+		return false, err
+	}
+	r := bufio.NewReader(conn)
+	version, err := readHello(r)
+	if err != nil {
+		return false, err
+	}
+	if version != netVersion {
+		return false, fmt.Errorf("%w: the host speaks version %d, not %d", errNetRefused, version, netVersion)
+	}
+	var size [8]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return false, err
+	}
+	if columns, rows := int(binary.LittleEndian.Uint32(size[:])), int(binary.LittleEndian.Uint32(size[4:])); columns != j.rs.config.GridWidth || rows != j.rs.config.GridHeight {
+		return false, fmt.Errorf("%w: the host's board is %dx%d; join with -size %dx%d", errNetRefused, columns, rows, columns, rows)
+	}
+	conn.SetDeadline(time.Time{})
+	j.tell("Joined " + j.addr)
+
+	lost := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := readNetMessage(r)
+			if err != nil {
+				lost <- err
+				return
+			}
+			select {
+			case j.frames <- msg:
+			case <-j.quit:
+				return
+			}
+		}
+	}()
+	w := bufio.NewWriter(conn)
+	for {
+		select {
+		case msg := <-j.out:
+			conn.SetWriteDeadline(time.Now().Add(netTimeout))
+			if err := writeNetMessage(w, msg); err != nil {
+				return true, err
+			}
+		case err := <-lost:
+			return true, err
+		case <-j.quit:
+			return true, nil
+		}
+	}
+}
+
+// send queues msg for the host, dropping it if the connection's backed up.
+func (j *netJoiner) send(msg []byte) {
+	select {
+	case j.out <- msg:
+	default:
+		j.tell("Couldn't reach " + j.addr + "; an edit was dropped")
+	}
+}
+
+// edited sends the host the cells an edit changed on sim, the first board,
+// as they were left after it or before, and puts them back, since the
+// host's frames list the cells that change and will make the edit here too.
+func (j *netJoiner) edited(sim *life.Simulation, edit []boardEdit, after bool) {
+	var cells []apiCell
+	for _, e := range edit {
+		if e.sim != sim {
+			continue
+		}
+		for _, ch := range e.changes {
+			state := ch.before
+			if after {
+				state = ch.after
+			}
+			cells = append(cells, apiCell{ch.x, ch.y, state.alive})
+		}
+	}
+	if len(cells) > 0 {
+		j.send(encodeNetEdit(cells))
+		apply(edit, !after)
+	}
+}
+
+// apply applies to sim, the first board, what the host has sent since the
+// last frame. A generation's delta is applied with step, given the board as
+// it is after the generation; for anything else, sim is changed in place and
+// replaced is called.
+func (j *netJoiner) apply(sim *life.Simulation, step func(next life.Grid, births, deaths int), replaced func()) {
+	for {
+		var frame []byte
+		select {
+		case frame = <-j.frames:
+		default:
+			return
+		}
+		if err := j.applyFrame(sim, frame, step, replaced); err != nil {
+			slog.Warn("asking for the whole board", "host", j.addr, "err", err)
+			j.synced = false
+			j.send([]byte{'R'})
+		}
+	}
+}
+
+func (j *netJoiner) applyFrame(sim *life.Simulation, frame []byte, step func(next life.Grid, births, deaths int), replaced func()) error {
+	cells := j.rs.config.GridWidth * j.rs.config.GridHeight
+	switch frame[0] {
+	case 'K':
+		if len(frame) != 13+packedSize(j.rs.config.GridWidth, j.rs.config.GridHeight) {
+			return fmt.Errorf("a keyframe of %d bytes doesn't fit the board", len(frame))
+		}
+		packed := frame[13:]
+		for i := 0; i < cells; i++ {
+			x, y := i/j.rs.config.GridHeight, i%j.rs.config.GridHeight
+			if alive := packed[i/8]&(1<<(i%8)) != 0; alive != sim.Cells.Alive(x, y) {
+				sim.Cells.Set(x, y, alive)
+			}
+		}
+		sim.Generation = int(binary.LittleEndian.Uint32(frame[9:]))
+		sim.Forget()
+		j.synced = true
+		replaced()
+	case 'D':
+		if !j.synced {
+			return nil
+		}
+		if (len(frame)-5)%4 != 0 {
+			return fmt.Errorf("a delta of %d bytes doesn't divide into cells", len(frame))
+		}
+		generation := int(binary.LittleEndian.Uint32(frame[1:]))
+		var changed []int
+		for b := frame[5:]; len(b) > 0; b = b[4:] {
+			i := int(binary.LittleEndian.Uint32(b))
+			if i >= cells {
+				return fmt.Errorf("cell %d is off the board", i)
+			}
+			changed = append(changed, i)
+		}
+		if generation != sim.Generation+1 {
+			for _, i := range changed {
+				x, y := i/j.rs.config.GridHeight, i%j.rs.config.GridHeight
+				sim.Cells.Set(x, y, !sim.Cells.Alive(x, y))
+			}
+			sim.Generation = generation
+			replaced()
+			return nil
+		}
+		next := sim.Cells.Clone()
+		for x := 0; x < next.Columns(); x++ {
+			for y := 0; y < next.Rows(); y++ {
+				if c := next.At(x, y); c.Alive {
+					c.Age++
+					next.SetCell(x, y, c)
+				}
+			}
+		}
+		births, deaths := 0, 0
+		for _, i := range changed {
+			x, y := i/j.rs.config.GridHeight, i%j.rs.config.GridHeight
+			alive := next.Alive(x, y)
+			if alive {
+				deaths++
+			} else {
+				births++
+			}
+			next.Set(x, y, !alive)
+		}
+		step(next, births, deaths)
+	case 'H':
+		if len(frame) != 13 {
+			return fmt.Errorf("a hash of %d bytes", len(frame))
+		}
+		generation, hash := int(binary.LittleEndian.Uint32(frame[1:])), binary.LittleEndian.Uint64(frame[5:])
+		if j.synced && generation == sim.Generation && sim.Cells.Hash() != hash {
+			return fmt.Errorf("the board diverged from the host's by generation %d", generation)
+		}
+	}
+	return nil
+}
+
+// close disconnects from the host.
+func (j *netJoiner) close() {
+	close(j.quit)
+}
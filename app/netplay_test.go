@@ -0,0 +1,182 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"opengl/life"
+)
+
+// netHost starts a paused headless run of cfg hosting the board, and
+// returns its address and run.
+func netHost(t *testing.T, cfg Config) (string, *runState) {
+	t.Helper()
+	addr := freeAddr(t)
+	rs := pausedHeadless(t, cfg, func(rs *runState) (func(), error) { return rs.startHost(addr, rs.remote) })
+	return addr, rs
+}
+
+// hostBoard steps the host's board n generations and returns a copy of it.
+func hostBoard(t *testing.T, host *runState, n int) *life.Simulation {
+	t.Helper()
+	board, err := host.remote.do(context.Background(), func(c *boardControls) (any, error) {
+		for i := 0; i < n; i++ {
+			c.step()
+		}
+		sim := *c.sims[0]
+		sim.Cells = sim.Cells.Clone()
+		return &sim, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return board.(*life.Simulation)
+}
+
+// follow applies what j's host sends to sim, as the window does, until
+// sim has caught up with want.
+func follow(t *testing.T, j *netJoiner, sim, want *life.Simulation) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.Generation != want.Generation || sim.Cells.Hash() != want.Cells.Hash() {
+		if time.Now().After(deadline) {
+			t.Fatalf("the joiner is at generation %d, hash %016x; the host at %d, %016x", sim.Generation, sim.Cells.Hash(), want.Generation, want.Cells.Hash())
+		}
+		j.apply(sim, func(next life.Grid, births, deaths int) { sim.StepTo(next, births, deaths) }, func() {})
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestNetplay joins a headless host in-process, for the joiner's board to
+// follow the host's as it steps, as the joiner edits it, and after it's
+// diverged.
+func TestNetplay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 40, 30, true
+	addr, host := netHost(t, cfg)
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 99)[0]
+	j := rs.joinHost(addr)
+	defer j.close()
+
+	follow(t, j, sim, hostBoard(t, host, 0))
+	follow(t, j, sim, hostBoard(t, host, 10))
+
+	// An edit is made on the host and comes back.
+	j.send(encodeNetEdit([]apiCell{{0, 0, true}, {1, 0, true}, {2, 0, true}, {39, 29, false}}))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := hostBoard(t, host, 0)
+		if st.Cells.Alive(0, 0) && st.Cells.Alive(1, 0) && st.Cells.Alive(2, 0) && !st.Cells.Alive(39, 29) {
+			follow(t, j, sim, st)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the joiner's edit didn't reach the host")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Diverged, the joiner finds out from the next hash and asks for the
+	// whole board.
+	sim.Cells.Set(20, 15, !sim.Cells.Alive(20, 15))
+	follow(t, j, sim, hostBoard(t, host, 2*streamHashEvery))
+}
+
+// TestNetplayRejoins restarts the host's server, for the joiner to
+// reconnect and catch up.
+func TestNetplayRejoins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 24, 16
+	addr := freeAddr(t)
+	var stop func()
+	host := pausedHeadless(t, cfg, func(rs *runState) (func(), error) {
+		var err error
+		stop, err = rs.startHost(addr, rs.remote)
+		return func() { stop() }, err
+	})
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 99)[0]
+	j := rs.joinHost(addr)
+	defer j.close()
+	follow(t, j, sim, hostBoard(t, host, 3))
+
+	stop()
+	want := hostBoard(t, host, 5)
+	var err error
+	if stop, err = host.startHost(addr, host.remote); err != nil {
+		t.Fatal(err)
+	}
+	follow(t, j, sim, want)
+}
+
+// TestNetplayRefused checks a joiner gives up on a host it can't join,
+// rather than trying again.
+func TestNetplayRefused(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 24, 16
+	for _, c := range []struct {
+		name  string
+		hello []byte
+		want  string
+	}{
+		{"version", binary.LittleEndian.AppendUint16([]byte(netMagic), netVersion+1), "the host speaks version 2, not 1"},
+		{"size", binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint16([]byte(netMagic), netVersion), 30), 16), "the host's board is 30x16; join with -size 30x16"},
+		{"not a host", []byte("HTTP/1.1 400 Bad Request\r\n"), "not a Game of Life host"},
+	} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			readHello(bufio.NewReader(conn))
+			conn.Write(c.hello)
+			time.Sleep(100 * time.Millisecond)
+		}()
+		j := testRun(t, cfg).joinHost(ln.Addr().String())
+		var news []string
+		for len(news) == 0 || !strings.HasPrefix(news[len(news)-1], "Can't join") {
+			select {
+			case n := <-j.news:
+				news = append(news, n)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: the joiner didn't give up: %q", c.name, news)
+			}
+		}
+		if last := news[len(news)-1]; !strings.HasSuffix(last, c.want) {
+			t.Errorf("%s: the joiner gave up with %q, want %q", c.name, last, c.want)
+		}
+		j.close()
+		ln.Close()
+	}
+}
+
+func TestDecodeNetEdit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 24, 16
+	rs := testRun(t, cfg)
+	cells := []apiCell{{0, 0, true}, {23, 15, false}, {5, 7, true}}
+	got, err := rs.decodeNetEdit(encodeNetEdit(cells))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(cells) || got[0] != cells[0] || got[1] != cells[1] || got[2] != cells[2] {
+		t.Errorf("decoded %v, want %v", got, cells)
+	}
+	if _, err := rs.decodeNetEdit(encodeNetEdit([]apiCell{{24, 0, true}})); err == nil || err.Error() != "cell (24, 0) is off the 24x16 board" {
+		t.Errorf("an edit off the board decoded with %v", err)
+	}
+	if _, err := rs.decodeNetEdit([]byte{'E', 1, 2}); err == nil {
+		t.Error("a short edit decoded")
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"opengl/render"
+)
+
+// noticeDuration is how long a notice stays on screen.
+const noticeDuration = 2 * time.Second
+
+// notice briefly shows a one-line message in the top-left corner, to confirm
+// actions that have no other visible effect.
+type notice struct {
+	message string
+	shown   time.Time
+	text    *text
+}
+
+func (rs *runState) newNotice(program *overlayProgram) *notice {
+	return &notice{text: rs.newText(program, 64)}
+}
+
+func (n *notice) show(message string) {
+	n.message = message
+	n.shown = time.Now()
+}
+
+// showing reports whether the notice is on screen.
+func (n *notice) showing() bool {
+	return n.message != "" && time.Since(n.shown) <= noticeDuration
+}
+
+func (n *notice) draw() {
+	if !n.showing() {
+		return
+	}
+	n.text.reset()
+	n.text.print(n.message, -1, 1)
+	n.text.draw(1, 1, 1, 1)
+}
+
+// shaderErrorSummary is err from building shaders in a line, for a notice:
+// a *render.ShaderError's first message, or err's text.
+func shaderErrorSummary(err error) string {
+	var se *render.ShaderError
+	if errors.As(err, &se) {
+		return se.Summary()
+	}
+	return err.Error()
+}
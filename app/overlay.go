@@ -0,0 +1,116 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/render"
+)
+
+// overlayProgram draws flat-coloured 2D geometry given directly in normalized
+// device coordinates, unaffected by the camera.
+type overlayProgram struct {
+	*render.ShaderProgram
+	colour render.Uniform
+}
+
+func (rs *runState) newOverlayProgram() (*overlayProgram, error) {
+	program, err := render.NewProgram("overlay")
+	if err != nil {
+		return nil, err
+	}
+	rs.freeOnClose(program.Delete)
+	return &overlayProgram{ShaderProgram: program, colour: program.Uniform("colour")}, nil
+}
+
+func (p *overlayProgram) use(r, g, b, a float32) {
+	p.Use()
+	p.colour.SetVec4(r, g, b, a)
+}
+
+// lines is a dynamic vertex buffer of 2D points that is refilled and drawn
+// every frame. It grows if more points are added than it was made for.
+type lines struct {
+	vao      uint32
+	vbo      uint32
+	capacity int
+	points   []float32
+}
+
+func (rs *runState) newLines(label string, capacity int) *lines {
+	l := &lines{capacity: capacity, points: make([]float32, 0, 2*capacity)}
+
+	l.vbo = render.Gen(render.Buffer, label)
+	gl.BindBuffer(gl.ARRAY_BUFFER, l.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 8*capacity, nil, gl.DYNAMIC_DRAW)
+	render.SetSize(render.Buffer, l.vbo, 8*capacity)
+
+	l.vao = render.Gen(render.VertexArray, label)
+	gl.BindVertexArray(l.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, nil)
+
+	rs.freeOnClose(func() {
+		render.Delete(render.VertexArray, l.vao)
+		render.Delete(render.Buffer, l.vbo)
+	})
+	return l
+}
+
+func (l *lines) reset() {
+	l.points = l.points[:0]
+}
+
+func (l *lines) add(x, y float32) {
+	l.points = append(l.points, x, y)
+}
+
+func (l *lines) rect(minX, minY, maxX, maxY float32) {
+	l.add(minX, minY)
+	l.add(maxX, minY)
+	l.add(maxX, maxY)
+	l.add(minX, maxY)
+}
+
+// draw uploads the accumulated points and draws them with the given primitive
+// mode, e.g. gl.LINE_STRIP or gl.LINE_LOOP.
+func (l *lines) draw(mode uint32) {
+	if len(l.points) == 0 {
+		return
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, l.vbo)
+	if n := len(l.points) / 2; n > l.capacity {
+		l.capacity = n
+		gl.BufferData(gl.ARRAY_BUFFER, 8*n, nil, gl.DYNAMIC_DRAW)
+		render.SetSize(render.Buffer, l.vbo, 8*n)
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(l.points), gl.Ptr(l.points))
+	render.Uploaded(4 * len(l.points))
+	gl.BindVertexArray(l.vao)
+	gl.DrawArrays(mode, 0, int32(len(l.points)/2))
+}
+
+// shade darkens the rectangle behind it by blending in black with the given
+// opacity.
+func shade(l *lines, program *overlayProgram, minX, minY, maxX, maxY, alpha float32) {
+	fill(l, program, minX, minY, maxX, maxY, 0, 0, 0, alpha)
+}
+
+// fill blends the colour into the rectangle with the given opacity. The
+// colour is premultiplied, so this also works with the transparent widget
+// window's blending.
+func fill(l *lines, program *overlayProgram, minX, minY, maxX, maxY, r, g, b, alpha float32) {
+	wasBlending := gl.IsEnabled(gl.BLEND)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	l.reset()
+	l.add(minX, minY)
+	l.add(maxX, minY)
+	l.add(maxX, maxY)
+	l.add(minX, minY)
+	l.add(maxX, maxY)
+	l.add(minX, maxY)
+	program.use(r*alpha, g*alpha, b*alpha, alpha)
+	l.draw(gl.TRIANGLES)
+	if !wasBlending {
+		gl.Disable(gl.BLEND)
+	}
+}
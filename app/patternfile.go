@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"opengl/life"
+)
+
+const (
+	// maxStdinSize bounds a pattern read from standard input.
+	maxStdinSize = 8 << 20
+	// stdinWait is how long to wait for a pattern to start arriving on
+	// standard input when it wasn't asked for with -pattern -.
+	stdinWait = 2 * time.Second
+)
+
+// errEmptyStdin is returned by readStdin when no pattern was piped in.
+var errEmptyStdin = errors.New("nothing on standard input")
+
+// stdinPiped reports whether standard input is a pipe or file rather than a
+// terminal, so may have a pattern piped into it.
+func stdinPiped() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+// readStdin reads a pattern's text from standard input. If wait isn't 0 and
+// nothing has arrived by then, it gives up rather than wait on a pipe that
+// may never be written to.
+func readStdin(wait time.Duration) ([]byte, error) {
+	type result struct {
+		src []byte
+		err error
+	}
+	arrived, done := make(chan bool), make(chan result, 1)
+	go func() {
+		r := bufio.NewReader(io.LimitReader(os.Stdin, maxStdinSize+1))
+		_, err := r.Peek(1)
+		close(arrived)
+		if err == io.EOF {
+			done <- result{}
+			return
+		}
+		src, err := io.ReadAll(r)
+		done <- result{src, err}
+	}()
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timeout = time.After(wait)
+	}
+	select {
+	case <-arrived:
+	case <-timeout:
+		return nil, errEmptyStdin
+	}
+	r := <-done
+	switch {
+	case r.err != nil:
+		return nil, r.err
+	case len(r.src) > maxStdinSize:
+		return nil, fmt.Errorf("standard input is over the %d bytes allowed for a pattern", maxStdinSize)
+	case len(bytes.TrimSpace(r.src)) == 0:
+		return nil, errEmptyStdin
+	}
+	return r.src, nil
+}
+
+// loadPattern reads a pattern file in RLE (.rle), plaintext (.cells), Life
+// 1.05/1.06 (.life) or Golly macrocell (.mc) format, returning the rule it names, if any. Files
+// with any other extension, and standard input when path is "-", are
+// sniffed to tell which format they're in.
+func (rs *runState) loadPattern(path string) (life.Pattern, string, error) {
+	var (
+		src []byte
+		err error
+	)
+	if path == "-" {
+		src, err = readStdin(0)
+		path = "stdin"
+	} else {
+		src, err = os.ReadFile(path)
+		if path == rs.download {
+			os.Remove(path)
+			rs.download = ""
+		}
+	}
+	// A name that isn't a file may be one of the built-in patterns.
+	if errors.Is(err, fs.ErrNotExist) && filepath.Base(path) == path {
+		e, err := findLibraryPattern(path)
+		if err != nil {
+			return life.Pattern{}, "", err
+		}
+		return e.Pattern, "", nil
+	}
+	if err != nil {
+		return life.Pattern{}, "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var (
+		p    life.Pattern
+		rule string
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rle":
+		p, rule, err = life.ParseRLE(string(src))
+	case ".cells":
+		p, err = life.ParseCells(string(src))
+	case ".life", ".lif":
+		p, err = life.ParseLife(string(src))
+	case ".mc":
+		p, rule, err = life.ParseMacrocell(string(src), rs.config.PatternLimit)
+	default:
+		p, rule, err = life.ParsePattern(string(src), rs.config.PatternLimit)
+	}
+	if err != nil {
+		return life.Pattern{}, "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	return p, rule, nil
+}
+
+// encodePatternFile encodes p in the format path's extension names: .cells
+// for plaintext, .life or .lif for Life 1.06, and RLE otherwise.
+func encodePatternFile(path string, p life.Pattern, rule string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cells":
+		return life.EncodeCells(p)
+	case ".life", ".lif":
+		return life.EncodeLife(p)
+	}
+	return life.EncodeRLE(p, rule)
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+func TestLoadPatternFitsTheBoard(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 30, 20
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 1)[0]
+
+	p, rule, err := rs.loadPattern("../life/testdata/replicator.rle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, cx, cy, err := rs.fitPattern(p, rule, sim, 15, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "B36/S23" || cx != 15 || cy != 10 {
+		t.Fatalf("the replicator goes at %d, %d in %s, want 15, 10 in its header's B36/S23", cx, cy, r)
+	}
+	// Near an edge, a pattern moves in to fit; with -pattern-rule off,
+	// the board keeps its rule.
+	rs.config.PatternRule = false
+	if r, cx, cy, _ = rs.fitPattern(p, rule, sim, 0, 19); r.String() != sim.Rule.String() || cx != 2 || cy != 17 {
+		t.Fatalf("the replicator at the top left goes at %d, %d in %s, want 2, 17 in %s", cx, cy, r, sim.Rule)
+	}
+
+	gun, _, err := rs.loadPattern("../life/testdata/gosperglidergun.rle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err = rs.fitPattern(gun, "", sim, 15, 10)
+	if err == nil || !strings.Contains(err.Error(), "36x9; it needs a board at least that big, not 30x20") {
+		t.Fatalf("fitting the gun on a 30x20 board: %v", err)
+	}
+}
+
+func TestLoadPatternByExtension(t *testing.T) {
+	rs := testRun(t, DefaultConfig())
+	for _, name := range []string{"glider.rle", "glider.cells", "glider.lif", "glider-1.05.lif", "glider.mc"} {
+		p, _, err := rs.loadPattern("../life/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := life.EncodeRLE(life.Pattern{Cells: p.Cells}, ""), life.EncodeRLE(life.Pattern{Cells: life.LibraryPattern("glider").Cells}, ""); got != want {
+			t.Errorf("%s loads as\n%s, want\n%s", name, got, want)
+		}
+	}
+	if _, _, err := rs.loadPattern("../life/testdata/missing.rle"); err == nil {
+		t.Error("a missing pattern file loads")
+	}
+}
+
+// TestSavePatternByExtension checks a pattern saved or exported in the
+// format its file's extension names loads back the same.
+func TestSavePatternByExtension(t *testing.T) {
+	rs := testRun(t, DefaultConfig())
+	glider := life.LibraryPattern("glider")
+	want := life.EncodeRLE(life.Pattern{Cells: glider.Cells}, "")
+	for _, name := range []string{"glider.rle", "glider.cells", "glider.lif", "glider.life", "glider.CELLS"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(encodePatternFile(path, glider, "B3/S23")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		p, _, err := rs.loadPattern(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := life.EncodeRLE(life.Pattern{Cells: p.Cells}, ""); got != want {
+			t.Errorf("%s loads back as\n%s, want\n%s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+	"opengl/render"
+)
+
+const (
+	// pickerRows is how many patterns the picker lists at once.
+	pickerRows = 24
+	// thumbnailCells is the most texels along either side of a thumbnail;
+	// larger patterns are scaled down to fit.
+	thumbnailCells = 128
+	// thumbnailSize is the size of the box a thumbnail is fitted into, in
+	// window pixels.
+	thumbnailSize = 200
+)
+
+// picker lists the patterns in the pattern directory over the left of the
+// window, with a thumbnail of the selected one in the bottom-right corner.
+// While it is open it takes the keyboard. Files that don't parse are greyed
+// out, with the error shown in place of the thumbnail.
+type picker struct {
+	rs *runState
+
+	visible  bool
+	dir      string
+	entries  []*catalogEntry
+	selected int
+	top      int
+	// choose is called with the pattern picked with Enter.
+	choose func(life.Pattern)
+
+	program    *overlayProgram
+	background *lines
+	text       *text
+	current    *text
+	broken     *text
+	thumbnail  *thumbnail
+}
+
+func (rs *runState) newPicker(dir string, entries []*catalogEntry, program *overlayProgram) (*picker, error) {
+	thumb, err := rs.newThumbnail()
+	if err != nil {
+		return nil, err
+	}
+	return &picker{
+		rs:         rs,
+		dir:        dir,
+		entries:    entries,
+		program:    program,
+		background: rs.newLines("picker background", 6),
+		text:       rs.newText(program, 40*pickerRows),
+		current:    rs.newText(program, 64),
+		broken:     rs.newText(program, 40*pickerRows),
+		thumbnail:  thumb,
+	}, nil
+}
+
+// install hooks the picker into in's key handling, ahead of anything that
+// was capturing keys already.
+func (p *picker) install(in *input) {
+	next := in.capture
+	in.capture = func(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool {
+		if p.visible {
+			p.key(key)
+			return true
+		}
+		return next != nil && next(key, action, mods)
+	}
+}
+
+func (p *picker) open() {
+	p.visible = true
+	p.show()
+}
+
+// key handles a key pressed or repeated while the picker is open.
+func (p *picker) key(key glfw.Key) {
+	switch key {
+	case glfw.KeyEscape:
+		p.visible = false
+		return
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		if len(p.entries) == 0 {
+			return
+		}
+		if e := p.entries[p.selected]; e.err == nil {
+			p.visible = false
+			p.choose(e.pattern)
+		}
+		return
+	case glfw.KeyUp, glfw.KeyK:
+		p.selected--
+	case glfw.KeyDown, glfw.KeyJ:
+		p.selected++
+	case glfw.KeyPageUp:
+		p.selected -= pickerRows
+	case glfw.KeyPageDown:
+		p.selected += pickerRows
+	case glfw.KeyHome:
+		p.selected = 0
+	case glfw.KeyEnd:
+		p.selected = len(p.entries) - 1
+	default:
+		return
+	}
+	p.selected = min(max(p.selected, 0), max(len(p.entries)-1, 0))
+	p.show()
+}
+
+// show scrolls the selected pattern into view and loads the thumbnail for it.
+func (p *picker) show() {
+	if len(p.entries) == 0 {
+		return
+	}
+	p.top = min(max(p.top, p.selected-pickerRows+1), p.selected)
+	e := p.entries[p.selected]
+	e.load()
+	if e.err == nil {
+		p.thumbnail.set(e.pattern)
+	}
+}
+
+func (p *picker) draw() {
+	if !p.visible {
+		return
+	}
+	shade(p.background, p.program, -1, -1, 1, 1, 0.8)
+
+	_, lineHeight := p.rs.textSize("")
+	x, y := float32(-1)+lineHeight/2, float32(1)-lineHeight/2
+	p.text.reset()
+	p.current.reset()
+	p.broken.reset()
+	if len(p.entries) == 0 {
+		p.text.print("No .rle or .cells files in "+p.dir, x, y)
+		p.text.draw(1, 1, 1, 1)
+		return
+	}
+	p.text.print(fmt.Sprintf("%s (%d/%d)", p.dir, p.selected+1, len(p.entries)), x, y)
+	for i := p.top; i < min(p.top+pickerRows, len(p.entries)); i++ {
+		e := p.entries[i]
+		e.load()
+		y -= lineHeight
+		line := "  " + e.name
+		if i == p.selected {
+			line = "> " + e.name
+		}
+		switch {
+		case e.err != nil:
+			p.broken.print(line, x, y)
+		case i == p.selected:
+			p.current.print(line, x, y)
+		default:
+			p.text.print(line, x, y)
+		}
+	}
+	p.text.draw(1, 1, 1, 1)
+	p.current.draw(1, 0.8, 0.2, 1)
+	p.broken.draw(0.5, 0.5, 0.5, 1)
+
+	e := p.entries[p.selected]
+	if e.err != nil {
+		charWidth, _ := p.rs.textSize(" ")
+		p.broken.reset()
+		for i, line := range wrapLine(e.err.Error(), int(0.9/charWidth), "  ") {
+			p.broken.print(line, 0, -0.1-float32(i)*lineHeight)
+		}
+		p.broken.draw(1, 0.4, 0.4, 1)
+		return
+	}
+	w, h := e.pattern.Size()
+	p.text.reset()
+	p.text.print(fmt.Sprintf("%dx%d, %d cells", w, h, len(e.pattern.Cells)), 0, -0.1)
+	p.text.draw(1, 1, 1, 1)
+	p.thumbnail.draw()
+}
+
+// thumbnail is a pattern rasterized into a small texture, drawn into the
+// bottom-right corner of the window.
+type thumbnail struct {
+	rs *runState
+
+	texture uint32
+	texels  []uint8
+	// width and height are the texture's size, in texels.
+	width, height int
+
+	program *render.ShaderProgram
+	vao     uint32
+	vbo     uint32
+}
+
+func (rs *runState) newThumbnail() (*thumbnail, error) {
+	program, err := render.NewProgram("minimap")
+	if err != nil {
+		return nil, err
+	}
+	t := &thumbnail{rs: rs, program: program}
+
+	t.texture = render.Gen(render.Texture, "pattern thumbnail")
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	t.vbo = render.Gen(render.Buffer, "pattern thumbnail")
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*16, nil, gl.DYNAMIC_DRAW)
+	render.SetSize(render.Buffer, t.vbo, 4*16)
+	t.vao = render.Gen(render.VertexArray, "pattern thumbnail")
+	gl.BindVertexArray(t.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 16, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 16, gl.PtrOffset(8))
+	rs.freeOnClose(func() {
+		render.Delete(render.VertexArray, t.vao)
+		render.Delete(render.Buffer, t.vbo)
+		render.Delete(render.Texture, t.texture)
+		t.program.Delete()
+	})
+	return t, nil
+}
+
+// set rasterizes p into the texture. A pattern too big for it is scaled
+// down, a texel standing for a square of cells and lit if any of them are.
+func (t *thumbnail) set(p life.Pattern) {
+	w, h := p.Size()
+	scale := (max(w, h) + thumbnailCells - 1) / thumbnailCells
+	t.width, t.height = max((w+scale-1)/scale, 1), max((h+scale-1)/scale, 1)
+	t.texels = make([]uint8, t.width*t.height)
+	for _, c := range p.Cells {
+		// Patterns count rows down from the top; textures up from the
+		// bottom.
+		x, y := c[0]/scale, t.height-1-c[1]/scale
+		t.texels[y*t.width+x] = 255
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(t.width), int32(t.height), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(t.texels))
+	render.Uploaded(len(t.texels))
+	render.SetSize(render.Texture, t.texture, t.width*t.height)
+}
+
+func (t *thumbnail) draw() {
+	// Fit the texture into the corner box, keeping its texels square.
+	side := float32(thumbnailSize) / float32(max(t.width, t.height))
+	w, h := 2*side*float32(t.width)/float32(t.rs.config.WindowWidth), 2*side*float32(t.height)/float32(t.rs.config.WindowHeight)
+	maxX, minY := float32(0.95), float32(-0.95)
+	minX, maxY := maxX-w, minY+h
+	quad := []float32{
+		minX, minY, 0, 0,
+		maxX, minY, 1, 0,
+		minX, maxY, 0, 1,
+		maxX, maxY, 1, 1,
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(quad), gl.Ptr(quad))
+	render.Uploaded(4 * len(quad))
+
+	t.program.Use()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.BindVertexArray(t.vao)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+}
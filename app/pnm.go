@@ -0,0 +1,175 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"opengl/life"
+)
+
+// encodePNM writes the board as a binary netpbm image, a pixel per cell
+// with the top row first: a PBM with a bit per cell, or with ages a PGM
+// whose grey levels are the cells' ages, up to 255. The generation and rule
+// go in a header comment.
+func encodePNM(cells life.Grid, generation int, r life.Rule, ages bool) []byte {
+	var out bytes.Buffer
+	magic := "P4"
+	if ages {
+		magic = "P5"
+	}
+	fmt.Fprintf(&out, "%s\n# generation %d rule %s\n%d %d\n", magic, generation, r, cells.Columns(), cells.Rows())
+	if ages {
+		out.WriteString("255\n")
+	}
+	for row := 0; row < cells.Rows(); row++ {
+		y := cells.Rows() - 1 - row
+		if ages {
+			for x := 0; x < cells.Columns(); x++ {
+				out.WriteByte(byte(min(cells.At(x, y).Age, 255)))
+			}
+			continue
+		}
+		// PBM rows are packed eight cells to a byte, most significant bit
+		// first, with 1 for alive.
+		packed := make([]byte, (cells.Columns()+7)/8)
+		for x := 0; x < cells.Columns(); x++ {
+			if cells.Alive(x, y) {
+				packed[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		out.Write(packed)
+	}
+	return out.Bytes()
+}
+
+// pnmImage is a decoded PBM or PGM: which pixels are set, top row first,
+// and the generation from a header comment written by encodePNM.
+type pnmImage struct {
+	width, height int
+	set           []bool
+	generation    int
+}
+
+// readPNM reads a PBM or PGM, plain or binary. Any non-zero grey level
+// counts as set.
+func (rs *runState) readPNM(path string) (pnmImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return pnmImage{}, err
+	}
+	defer f.Close()
+	img, err := rs.decodePNM(bufio.NewReader(f))
+	if err != nil {
+		return pnmImage{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+func (rs *runState) decodePNM(r *bufio.Reader) (pnmImage, error) {
+	var img pnmImage
+	// token reads the next header field, skipping whitespace and comments.
+	token := func() (string, error) {
+		var tok []byte
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				if len(tok) > 0 && err == io.EOF {
+					return string(tok), nil
+				}
+				return "", err
+			}
+			switch {
+			case b == '#':
+				comment, _ := r.ReadString('\n')
+				fmt.Sscanf(comment, " generation %d", &img.generation)
+			case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+				if len(tok) > 0 {
+					return string(tok), nil
+				}
+			default:
+				tok = append(tok, b)
+			}
+		}
+	}
+	number := func() (int, error) {
+		tok, err := token()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(tok)
+	}
+
+	magic, err := token()
+	if err != nil {
+		return img, err
+	}
+	if magic != "P1" && magic != "P2" && magic != "P4" && magic != "P5" {
+		return img, fmt.Errorf("not a PBM or PGM image")
+	}
+	if img.width, err = number(); err != nil {
+		return img, err
+	}
+	if img.height, err = number(); err != nil {
+		return img, err
+	}
+	if img.width <= 0 || img.height <= 0 || img.width > rs.config.GridWidth || img.height > rs.config.GridHeight {
+		return img, fmt.Errorf("image is %dx%d; it needs to fit a %dx%d board", img.width, img.height, rs.config.GridWidth, rs.config.GridHeight)
+	}
+	maxGrey := 1
+	if magic == "P2" || magic == "P5" {
+		if maxGrey, err = number(); err != nil {
+			return img, err
+		}
+		if maxGrey < 1 || maxGrey > 255 {
+			return img, errors.New("only 8-bit PGM images are supported")
+		}
+	}
+
+	img.set = make([]bool, img.width*img.height)
+	for i := range img.set {
+		switch magic {
+		case "P1", "P2":
+			v, err := number()
+			if err != nil {
+				return img, fmt.Errorf("pixel %d: %w", i, err)
+			}
+			img.set[i] = v != 0
+		case "P5":
+			b, err := r.ReadByte()
+			if err != nil {
+				return img, fmt.Errorf("pixel %d: %w", i, err)
+			}
+			img.set[i] = b != 0
+		}
+	}
+	if magic == "P4" {
+		packed := make([]byte, (img.width+7)/8)
+		for y := 0; y < img.height; y++ {
+			if _, err := io.ReadFull(r, packed); err != nil {
+				return img, fmt.Errorf("row %d: %w", y, err)
+			}
+			for x := 0; x < img.width; x++ {
+				img.set[y*img.width+x] = packed[x/8]&(0x80>>(x%8)) != 0
+			}
+		}
+	}
+	return img, nil
+}
+
+// apply replaces sim's board with the image, centred on it, carrying on
+// from the image's generation.
+func (img pnmImage) apply(sim *life.Simulation) {
+	sim.Clear()
+	ox, oy := (sim.Cells.Columns()-img.width)/2, (sim.Cells.Rows()-img.height)/2
+	for i, set := range img.set {
+		if set {
+			sim.Cells.Set(ox+i%img.width, sim.Cells.Rows()-1-oy-i/img.width, true)
+		}
+	}
+	sim.Generation = img.generation
+}
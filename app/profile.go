@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// startPProf serves net/http/pprof's profiles on addr, returning a function
+// that shuts the server down.
+func startPProf(addr string) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("-pprof: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("-pprof", "err", err)
+		}
+	}()
+	slog.Info("serving profiles", "url", fmt.Sprintf("http://%s/debug/pprof/", ln.Addr()))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
+
+// tracing is the execution trace being written, if there is one.
+var tracing struct {
+	sync.Mutex
+	file  *os.File
+	timer *time.Timer
+}
+
+// startTrace writes an execution trace to path for d.
+func startTrace(path string, d time.Duration) error {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.file != nil {
+		return fmt.Errorf("already tracing to %s", tracing.file.Name())
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := trace.Start(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	tracing.file = f
+	tracing.timer = time.AfterFunc(d, stopTrace)
+	return nil
+}
+
+// stopTrace finishes the trace being written, if there is one, as when its
+// time is up or the app exits.
+func stopTrace() {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.file == nil {
+		return
+	}
+	tracing.timer.Stop()
+	trace.Stop()
+	if err := tracing.file.Close(); err != nil {
+		slog.Warn("writing the trace failed", "err", err)
+	} else {
+		slog.Info("wrote a trace", "path", tracing.file.Name())
+	}
+	tracing.file = nil
+}
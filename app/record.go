@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"opengl/render"
+)
+
+// frameWorkers is how many frames are encoded at once, and frameQueue how
+// many more can wait. Once the queue is full, recording blocks until a
+// worker catches up, so memory use stays bounded however fast the board
+// runs.
+const (
+	frameWorkers = 4
+	frameQueue   = 8
+)
+
+type frameJob struct {
+	path string
+	img  *image.RGBA
+}
+
+// frameRecorder saves numbered frames as PNGs on a pool of workers.
+type frameRecorder struct {
+	pattern string
+	target  *render.Target
+	frames  int
+	jobs    chan frameJob
+	done    sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// newFrameRecorder starts a recording of frames of the given size to files
+// named by pattern, a format with one verb for the frame number.
+func newFrameRecorder(pattern string, width, height int) (*frameRecorder, error) {
+	if strings.Count(pattern, "%") != 1 || strings.Contains(fmt.Sprintf(pattern, 0), "%!") {
+		return nil, fmt.Errorf("invalid -record-frames pattern %q: want one verb for the frame number, like frame_%%05d.png", pattern)
+	}
+	if err := os.MkdirAll(filepath.Dir(pattern), 0o755); err != nil {
+		return nil, err
+	}
+	target, err := render.NewTarget(width, height)
+	if err != nil {
+		return nil, err
+	}
+	r := &frameRecorder{pattern: pattern, target: target, jobs: make(chan frameJob, frameQueue)}
+	for i := 0; i < frameWorkers; i++ {
+		r.done.Add(1)
+		go r.work()
+	}
+	return r, nil
+}
+
+func (r *frameRecorder) work() {
+	defer r.done.Done()
+	for job := range r.jobs {
+		if r.failed() != nil {
+			continue
+		}
+		if err := render.SavePNG(job.path, job.img); err != nil {
+			r.mu.Lock()
+			if r.err == nil {
+				r.err = err
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *frameRecorder) failed() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// record renders the scene as the next frame, reporting any failure to save
+// an earlier one.
+func (r *frameRecorder) record(sc *scene) error {
+	if err := r.failed(); err != nil {
+		return err
+	}
+	r.jobs <- frameJob{fmt.Sprintf(r.pattern, r.frames), sc.recordFrame(r.target)}
+	r.frames++
+	return nil
+}
+
+// stop waits for the frames still being saved and frees the render target,
+// returning the first error saving any of them.
+func (r *frameRecorder) stop() error {
+	close(r.jobs)
+	r.done.Wait()
+	r.target.Delete()
+	return r.failed()
+}
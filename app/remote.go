@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+
+	"opengl/life"
+)
+
+// remoteControl carries what servers want done to the boards to whichever
+// loop owns them. Servers never touch the boards themselves: each request is
+// sent over a channel, and the loop runs it between frames or steps and
+// sends back the reply. The loop also publishes the first board to stream as
+// it changes.
+type remoteControl struct {
+	requests chan remoteRequest
+	stream   *boardStream
+	// generations has a summary of every generation the first board steps
+	// sent to -grpc's subscribers.
+	generations *generationFeed
+	// wake, if set, wakes the loop should it be waiting for input.
+	wake atomic.Pointer[func()]
+}
+
+type remoteRequest struct {
+	do    func(c *boardControls) (any, error)
+	reply chan remoteReply
+}
+
+type remoteReply struct {
+	body any
+	err  error
+}
+
+// boardControls are what requests can do to the boards, as the loop owning
+// them does it, so that a remote edit is undoable and logged like one made
+// any other way.
+type boardControls struct {
+	rs *runState
+
+	sims      []*life.Simulation
+	paused    func() bool
+	setPaused func(bool)
+	// step steps every board a generation.
+	step   func()
+	reseed func(seed int64)
+	// stop ends the run, as closing the window does.
+	stop func()
+	// edit makes the changes f makes to the cells as one edit.
+	edit func(f func())
+	// setRule switches every board to a rule, or returns why it can't.
+	setRule func(life.Rule) error
+	// load restores the state file at path, as -load does.
+	load func(path string) error
+	// stats is the first board's history, for GET /stats.
+	stats *Stats
+}
+
+func (rs *runState) newRemoteControl() *remoteControl {
+	return &remoteControl{requests: make(chan remoteRequest), stream: rs.newBoardStream(), generations: newGenerationFeed()}
+}
+
+// do has the loop owning the boards run f, returning what it returns, or
+// ctx's error if ctx is done first.
+func (rc *remoteControl) do(ctx context.Context, f func(c *boardControls) (any, error)) (any, error) {
+	req := remoteRequest{do: f, reply: make(chan remoteReply, 1)}
+	if wake := rc.wake.Load(); wake != nil {
+		(*wake)()
+	}
+	select {
+	case rc.requests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case reply := <-req.reply:
+		return reply.body, reply.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pending returns the channel requests arrive on, which is nil, and so
+// never ready, without a server.
+func (rc *remoteControl) pending() <-chan remoteRequest {
+	if rc == nil {
+		return nil
+	}
+	return rc.requests
+}
+
+// serve runs every request waiting, if there's a server, and streams any
+// change since, for the loop owning the boards to call between frames.
+func (rc *remoteControl) serve(c *boardControls) {
+	if rc == nil {
+		return
+	}
+	for {
+		select {
+		case req := <-rc.requests:
+			rc.run(req, c)
+		default:
+			rc.stream.publish(c.sims[0])
+			return
+		}
+	}
+}
+
+// run runs req, streaming whatever it changed.
+func (rc *remoteControl) run(req remoteRequest, c *boardControls) {
+	body, err := req.do(c)
+	req.reply <- remoteReply{body, err}
+	rc.stream.publish(c.sims[0])
+}
+
+// stepped streams sim, the first board, after a step, if there's a server.
+func (rc *remoteControl) stepped(sim *life.Simulation) {
+	if rc != nil {
+		rc.stream.publish(sim)
+		rc.generations.send(sim)
+	}
+}
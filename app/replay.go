@@ -0,0 +1,352 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"opengl/life"
+)
+
+const (
+	replayVersion = 1
+	// replayCheckEvery is how many generations apart the board hashes are
+	// logged, for playback to check against.
+	replayCheckEvery = 100
+)
+
+// A replay file is a replayHeader followed by a replayEntry per line, each
+// something that happened to the boards in the order it happened. The
+// header has the whole starting state, so however the boards were set up
+// and whatever the random seeds, playback starts from the same place; after
+// that the only randomness is reseeding, which is logged as the cells it
+// changed and the random number generators' states it left.
+type replayHeader struct {
+	Version int     `json:"version"`
+	Rewind  int     `json:"rewind"`
+	Rate    float64 `json:"rate"`
+	State   state   `json:"state"`
+}
+
+// replayEntry is one of: every board stepped Steps generations; every board
+// rewound a generation; cells changed by an edit, undo or redo, with the
+// boards left with nothing to rewind to; each board's generation and rule,
+// and the edge wrapping, set; the speed changed; or each board's hash, to be
+// checked. An edit also has each board's random number generator's state
+// after it, as life.PCG.MarshalBinary has it, since a reseed changes it.
+type replayEntry struct {
+	Generation int           `json:"gen"`
+	Steps      int           `json:"steps,omitempty"`
+	Rewind     bool          `json:"rewind,omitempty"`
+	Cells      []replayCells `json:"cells,omitempty"`
+	Forget     []int         `json:"forget,omitempty"`
+	RNG        [][]byte      `json:"rng,omitempty"`
+	Boards     []replayBoard `json:"boards,omitempty"`
+	Wrap       bool          `json:"wrap,omitempty"`
+	Rate       float64       `json:"rate,omitempty"`
+	Check      []string      `json:"check,omitempty"`
+}
+
+type replayCells struct {
+	Board int `json:"board"`
+	// Cells are each x, y, 1 if alive or 0 if not, and age.
+	Cells [][4]int `json:"cells"`
+}
+
+type replayBoard struct {
+	Generation int    `json:"gen"`
+	Rule       string `json:"rule"`
+}
+
+// boardHashes returns each board's hash, as logged for checking.
+func boardHashes(sims []*life.Simulation) []string {
+	hashes := make([]string, len(sims))
+	for i, sim := range sims {
+		hashes[i] = fmt.Sprintf("%016x", sim.Cells.Hash())
+	}
+	return hashes
+}
+
+// replayRecorder logs a session to a replay file. Steps in a row are logged
+// as one entry, and changes to the boards' rules and generations and the
+// edge wrapping are noticed when something next happens, so nothing else
+// needs to tell it about them.
+type replayRecorder struct {
+	rs *runState
+
+	f    *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	sims []*life.Simulation
+	err  error
+
+	steps int
+	// boards and wrap are each board's generation and rule, and the edge
+	// wrapping, as playback will have them.
+	boards []replayBoard
+	wrap   bool
+}
+
+func (rs *runState) newReplayRecorder(path string, sims []*life.Simulation, cam *camera, rewind int, rate float64) (*replayRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	r := &replayRecorder{rs: rs, f: f, w: w, enc: json.NewEncoder(w), sims: sims, wrap: rs.config.Wrap}
+	for _, sim := range sims {
+		r.boards = append(r.boards, replayBoard{sim.Generation, sim.Rule.String()})
+	}
+	if err := r.enc.Encode(replayHeader{replayVersion, rewind, rate, rs.captureState(sims, cam)}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *replayRecorder) emit(e replayEntry) {
+	if r.err == nil {
+		e.Generation = r.sims[0].Generation
+		r.err = r.enc.Encode(e)
+	}
+}
+
+func (r *replayRecorder) flushSteps() {
+	if r.steps > 0 {
+		r.emit(replayEntry{Steps: r.steps})
+		r.steps = 0
+	}
+}
+
+// sync logs the boards' generations and rules and the edge wrapping if
+// they've changed.
+func (r *replayRecorder) sync() {
+	changed := r.wrap != r.rs.config.Wrap
+	r.wrap = r.rs.config.Wrap
+	for i, sim := range r.sims {
+		if b := (replayBoard{sim.Generation, sim.Rule.String()}); b != r.boards[i] {
+			r.boards[i], changed = b, true
+		}
+	}
+	if changed {
+		r.flushSteps()
+		r.emit(replayEntry{Boards: append([]replayBoard(nil), r.boards...), Wrap: r.wrap})
+	}
+}
+
+// step logs that the boards are about to step a generation.
+func (r *replayRecorder) step() {
+	r.sync()
+	if r.sims[0].Generation%replayCheckEvery == 0 {
+		r.flushSteps()
+		r.emit(replayEntry{Check: boardHashes(r.sims)})
+	}
+	r.steps++
+	for i := range r.boards {
+		r.boards[i].Generation++
+	}
+}
+
+// rewound logs that the boards were rewound a generation. Playback rewinds
+// to the same generation, so there's no need to log it.
+func (r *replayRecorder) rewound() {
+	r.flushSteps()
+	r.emit(replayEntry{Rewind: true})
+	for i, sim := range r.sims {
+		r.boards[i].Generation = sim.Generation
+	}
+}
+
+// edited logs the cells an edit changed, as they were after it or before.
+func (r *replayRecorder) edited(edit []boardEdit, after bool) {
+	r.flushSteps()
+	var cells []replayCells
+	for _, e := range edit {
+		board := 0
+		for i, sim := range r.sims {
+			if sim == e.sim {
+				board = i
+			}
+		}
+		rc := replayCells{Board: board}
+		for _, ch := range e.changes {
+			state := ch.before
+			if after {
+				state = ch.after
+			}
+			alive := 0
+			if state.alive {
+				alive = 1
+			}
+			rc.Cells = append(rc.Cells, [4]int{ch.x, ch.y, alive, state.age})
+		}
+		cells = append(cells, rc)
+	}
+	var forget []int
+	var rng [][]byte
+	for i, sim := range r.sims {
+		if !sim.CanRewind() {
+			forget = append(forget, i)
+		}
+		state, _ := sim.RNG.MarshalBinary()
+		rng = append(rng, state)
+	}
+	r.emit(replayEntry{Cells: cells, Forget: forget, RNG: rng})
+}
+
+// rate logs a change of speed.
+func (r *replayRecorder) rate(rate float64) {
+	r.flushSteps()
+	r.emit(replayEntry{Rate: rate})
+}
+
+// close logs the boards' final hashes and closes the file.
+func (r *replayRecorder) close() error {
+	r.sync()
+	r.flushSteps()
+	r.emit(replayEntry{Check: boardHashes(r.sims)})
+	if err := r.w.Flush(); r.err == nil {
+		r.err = err
+	}
+	if err := r.f.Close(); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// replayPlayer plays a replay file back onto the boards.
+type replayPlayer struct {
+	rs *runState
+
+	header  replayHeader
+	entries []replayEntry
+	pos     int
+	// pending is how many steps are left of the current entry.
+	pending int
+	checks  int
+}
+
+// loadReplay reads a replay file, checking it can be played onto the given
+// number of boards.
+func (rs *runState) loadReplay(path string, boards int) (*replayPlayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p := &replayPlayer{rs: rs}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if err := dec.Decode(&p.header); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.header.Version != replayVersion {
+		return nil, fmt.Errorf("%s: replay version %d can't be played; want version %d", path, p.header.Version, replayVersion)
+	}
+	if err := p.header.State.check(rs, boards); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for {
+		var e replayEntry
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", path, len(p.entries)+1, err)
+		}
+		for _, c := range e.Cells {
+			if c.Board < 0 || c.Board >= boards {
+				return nil, fmt.Errorf("%s: entry %d: no board %d", path, len(p.entries)+1, c.Board+1)
+			}
+			for _, cell := range c.Cells {
+				if cell[0] < 0 || cell[1] < 0 || cell[0] >= rs.config.GridWidth || cell[1] >= rs.config.GridHeight {
+					return nil, fmt.Errorf("%s: entry %d: cell %d,%d is off the board", path, len(p.entries)+1, cell[0], cell[1])
+				}
+			}
+		}
+		for _, i := range e.Forget {
+			if i < 0 || i >= boards {
+				return nil, fmt.Errorf("%s: entry %d: no board %d", path, len(p.entries)+1, i+1)
+			}
+		}
+		for _, rng := range e.RNG {
+			if err := new(life.PCG).UnmarshalBinary(rng); err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %w", path, len(p.entries)+1, err)
+			}
+		}
+		if len(e.Boards) > 0 && len(e.Boards) != boards || len(e.Check) > 0 && len(e.Check) != boards || len(e.RNG) > 0 && len(e.RNG) != boards {
+			return nil, fmt.Errorf("%s: entry %d: not %d boards", path, len(p.entries)+1, boards)
+		}
+		for _, b := range e.Boards {
+			if _, err := life.ParseRule(b.Rule); err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %w", path, len(p.entries)+1, err)
+			}
+		}
+		p.entries = append(p.entries, e)
+	}
+	return p, nil
+}
+
+// start puts the boards and camera back the way they were when recording
+// started.
+func (p *replayPlayer) start(sims []*life.Simulation, cam *camera) {
+	p.header.State.restore(p.rs, sims, cam)
+	for _, sim := range sims {
+		sim.SetRewind(p.header.Rewind)
+	}
+}
+
+// next plays the replay up to the next step, calling step to take it, and
+// reports whether there was one. Other entries are played through the
+// given functions and onto sims. It fails if the boards don't match a hash
+// logged with the replay.
+func (p *replayPlayer) next(sims []*life.Simulation, step, rewind func(), setRate func(float64)) (bool, error) {
+	for p.pending == 0 {
+		if p.pos == len(p.entries) {
+			return false, nil
+		}
+		e := p.entries[p.pos]
+		p.pos++
+		switch {
+		case e.Steps > 0:
+			p.pending = e.Steps
+		case e.Rewind:
+			rewind()
+		case e.Cells != nil:
+			for _, rc := range e.Cells {
+				for _, cell := range rc.Cells {
+					cells := sims[rc.Board].Cells
+					team := cells.At(cell[0], cell[1]).Team
+					cells.SetCell(cell[0], cell[1], life.Cell{Alive: cell[2] == 1, Age: cell[3], Team: team})
+				}
+			}
+			for _, i := range e.Forget {
+				sims[i].Forget()
+			}
+			for i, rng := range e.RNG {
+				sims[i].RNG.UnmarshalBinary(rng)
+			}
+		case e.Boards != nil:
+			p.rs.config.Wrap = e.Wrap
+			for i, b := range e.Boards {
+				sims[i].Generation = b.Generation
+				sims[i].Rule, _ = life.ParseRule(b.Rule)
+			}
+		case e.Rate > 0:
+			setRate(e.Rate)
+		case e.Check != nil:
+			for i, got := range boardHashes(sims) {
+				if got != e.Check[i] {
+					return false, fmt.Errorf("replay diverged at generation %d: board %d hashes to %s, not %s as recorded", e.Generation, i+1, got, e.Check[i])
+				}
+			}
+			p.checks++
+		}
+	}
+	p.pending--
+	step()
+	return true, nil
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// recordReplay records two noisy boards to path as the window would: they
+// step past a checkpoint, are edited, rewound, slowed down and stepped past
+// another, and the edit is undone; then they're reseeded, stepped, and the
+// reseed undone. It returns the boards' hashes at the end.
+func recordReplay(t *testing.T, rs *runState, path string) []string {
+	t.Helper()
+	sims := testSims(t, rs, 1, 2)
+	for _, sim := range sims {
+		sim.SetRewind(8)
+	}
+	r, err := rs.newReplayRecorder(path, sims, rs.newCamera(), 8, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := newUndoHistory(sims)
+	u.changed = r.edited
+	step := func(generations int) {
+		for i := 0; i < generations; i++ {
+			r.step()
+			u.stepped()
+			stepAll(rs, sims, 1)
+		}
+	}
+
+	step(150)
+	u.edit(func() {
+		for x := 10; x < 20; x++ {
+			sims[1].Cells.Set(x, 15, true)
+		}
+	})
+	step(20)
+	for i := 0; i < 3; i++ {
+		for _, sim := range sims {
+			sim.Rewind()
+		}
+		r.rewound()
+	}
+	r.rate(12)
+	step(60)
+	u.undoLast()
+	step(10)
+	// Reseeding, as the window does, is an edit, which leaves the random
+	// number generators where the new soups did.
+	u.edit(func() {
+		for i, sim := range sims {
+			sim.Reseed(int64(10 + i))
+		}
+	})
+	step(30)
+	u.undoLast()
+	step(20)
+	want := boardHashes(sims)
+	if err := r.close(); err != nil {
+		t.Fatal(err)
+	}
+	return want
+}
+
+// playReplay plays the replay at path onto two fresh boards, started from
+// other seeds, and returns them, the speed it was left at and how many
+// checkpoints passed.
+func playReplay(t *testing.T, rs *runState, path string) ([]*life.Simulation, float64, int, error) {
+	t.Helper()
+	p, err := rs.loadReplay(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sims := testSims(t, rs, 5, 6)
+	p.start(sims, rs.newCamera())
+	rate := p.header.Rate
+	step := func() { stepAll(rs, sims, 1) }
+	rewind := func() {
+		for _, sim := range sims {
+			sim.Rewind()
+		}
+	}
+	for {
+		more, err := p.next(sims, step, rewind, func(r float64) { rate = r })
+		if err != nil || !more {
+			return sims, rate, p.checks, err
+		}
+	}
+}
+
+// TestReplayPlaysBack checks a recording of steps, an edit and its undo, a
+// rewind, a change of speed, and a reseed and its undo plays back onto other
+// boards to the same hashes, passing every checkpoint on the way.
+func TestReplayPlaysBack(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 40, 30
+	rs := testRun(t, cfg)
+	path := filepath.Join(t.TempDir(), "run.replay")
+	want := recordReplay(t, rs, path)
+
+	sims, rate, checks, err := playReplay(t, rs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boardHashes(sims); !slices.Equal(got, want) {
+		t.Errorf("played back to hashes %v, want %v as recorded", got, want)
+	}
+	// Generations 0, 100 and 200, 0 again after the reseed, and the end.
+	if checks != 5 {
+		t.Errorf("%d checkpoints passed, want 5", checks)
+	}
+	if rate != 12 {
+		t.Errorf("played back at %g generations a second, want 12", rate)
+	}
+	if gen := sims[0].Generation; gen != 50 {
+		t.Errorf("played back to generation %d, want 50", gen)
+	}
+}
+
+// TestReplayDiverges checks playback stops with an error at a checkpoint
+// whose hash the boards don't match.
+func TestReplayDiverges(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 40, 30
+	rs := testRun(t, cfg)
+	path := filepath.Join(t.TempDir(), "run.replay")
+	recordReplay(t, rs, path)
+
+	// Tamper with the second board's hash at generation 100.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	tampered := false
+	for i, line := range lines[1:] {
+		var e replayEntry
+		if json.Unmarshal(line, &e) != nil || e.Check == nil || e.Generation != 100 {
+			continue
+		}
+		e.Check[1] = "0123456789abcdef"
+		if lines[i+1], err = json.Marshal(e); err != nil {
+			t.Fatal(err)
+		}
+		tampered = true
+	}
+	if !tampered {
+		t.Fatal("no checkpoint at generation 100 to tamper with")
+	}
+	if err := os.WriteFile(path, bytes.Join(lines, []byte("\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sims, _, checks, err := playReplay(t, rs, path)
+	if err == nil || !strings.Contains(err.Error(), "diverged at generation 100: board 2") {
+		t.Fatalf("playing a tampered replay gave %v, want it to diverge at board 2's checkpoint", err)
+	}
+	if checks != 1 || sims[0].Generation != 100 {
+		t.Errorf("stopped at generation %d after %d checkpoints, want 100 after 1", sims[0].Generation, checks)
+	}
+}
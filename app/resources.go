@@ -0,0 +1,14 @@
+package app
+
+// freeOnClose arranges for free to be called when the window closes.
+func (rs *runState) freeOnClose(free func()) {
+	rs.glFrees = append(rs.glFrees, free)
+}
+
+// freeGL calls everything given to freeOnClose, newest first.
+func (rs *runState) freeGL() {
+	for i := len(rs.glFrees) - 1; i >= 0; i-- {
+		rs.glFrees[i]()
+	}
+	rs.glFrees = nil
+}
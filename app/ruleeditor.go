@@ -0,0 +1,261 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+)
+
+// ruleEditorRows labels the editor's rows of toggles, births then
+// survivals.
+var ruleEditorRows = [2]string{"Born on  ", "Survive on  "}
+
+// ruleEditor is a panel over the top of the window showing the first
+// board's rule as two rows of toggles, the neighbour counts cells are born
+// on and the counts they survive on. Flipping one, picked with the arrow
+// keys and Space or Enter or clicked, switches every board to the new rule
+// there and then, while they keep running. The rulestring above can be
+// typed over with any rule ParseRule takes: typing B, S, a digit or a slash
+// starts one afresh, and Tab edits the one shown. While it's open it takes
+// the keyboard.
+type ruleEditor struct {
+	rs *runState
+
+	visible bool
+	sims    []*life.Simulation
+	setRule func(life.Rule) error
+
+	// row and column are the toggle picked with the keyboard: row 0 is
+	// births and row 1 survivals, and the column the neighbour count.
+	row, column int
+	// typing is set while a rulestring is being typed into line.
+	typing bool
+	line   []rune
+	// problem is why the last change was refused.
+	problem string
+
+	program    *overlayProgram
+	background *lines
+	toggles    *controls
+	text       *text
+	warning    *text
+}
+
+func (rs *runState) newRuleEditor(sims []*life.Simulation, setRule func(life.Rule) error, program *overlayProgram) *ruleEditor {
+	return &ruleEditor{
+		rs:         rs,
+		sims:       sims,
+		setRule:    setRule,
+		program:    program,
+		background: rs.newLines("rule editor background", 6),
+		toggles:    rs.newControls("rule editor", program, 2*9),
+		text:       rs.newText(program, 256),
+		warning:    rs.newText(program, 256),
+	}
+}
+
+// install hooks the editor into window's character input and in's key
+// handling, ahead of anything that was taking them already.
+func (e *ruleEditor) install(window *glfw.Window, in *input) {
+	var chars glfw.CharCallback
+	chars = window.SetCharCallback(func(w *glfw.Window, char rune) {
+		if !e.visible {
+			if chars != nil {
+				chars(w, char)
+			}
+			return
+		}
+		e.char(char)
+	})
+	next := in.capture
+	in.capture = func(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) bool {
+		if e.visible {
+			e.key(key)
+			return true
+		}
+		return next != nil && next(key, action, mods)
+	}
+}
+
+func (e *ruleEditor) open() {
+	e.visible = true
+	e.typing, e.problem = false, ""
+}
+
+// key handles a key pressed or repeated while the editor is open.
+func (e *ruleEditor) key(key glfw.Key) {
+	if e.typing {
+		switch key {
+		case glfw.KeyEscape:
+			e.typing = false
+		case glfw.KeyEnter, glfw.KeyKPEnter:
+			r, err := life.ParseRule(string(e.line))
+			if err == nil {
+				err = e.apply(r)
+			}
+			if err != nil {
+				e.problem = err.Error()
+				return
+			}
+			e.typing = false
+		case glfw.KeyBackspace:
+			if len(e.line) > 0 {
+				e.line = e.line[:len(e.line)-1]
+			}
+		}
+		return
+	}
+	switch key {
+	case glfw.KeyEscape, glfw.KeyE:
+		e.visible = false
+	case glfw.KeyTab:
+		e.typing, e.line = true, []rune(e.sims[0].Rule.String())
+	case glfw.KeyLeft:
+		e.column = max(e.column-1, 0)
+	case glfw.KeyRight:
+		e.column = min(e.column+1, 8)
+	case glfw.KeyUp:
+		e.row = 0
+	case glfw.KeyDown:
+		e.row = 1
+	case glfw.KeySpace, glfw.KeyEnter, glfw.KeyKPEnter:
+		e.flip(e.row, e.column)
+	}
+}
+
+// char handles a character typed while the editor is open. One that can
+// start a rulestring starts typing a fresh one.
+func (e *ruleEditor) char(char rune) {
+	if !e.typing {
+		if !strings.ContainsRune("BbSs012345678/", char) {
+			return
+		}
+		e.typing, e.line = true, e.line[:0]
+	}
+	e.line = append(e.line, char)
+}
+
+// flip puts a neighbour count into or out of the rule's births or
+// survivals.
+func (e *ruleEditor) flip(row, column int) {
+	r := e.sims[0].Rule
+	birth, survive, ok := r.Counts()
+	if !ok {
+		e.problem = fmt.Sprintf("%s has no neighbour counts to flip; type a rule like B3/S23 to switch to one", r)
+		return
+	}
+	if row == 0 {
+		birth[column] = !birth[column]
+	} else {
+		survive[column] = !survive[column]
+	}
+	if err := e.apply(life.NewRule(birth, survive)); err != nil {
+		e.problem = err.Error()
+	}
+}
+
+// apply switches the boards to r, unless they can't run it.
+func (e *ruleEditor) apply(r life.Rule) error {
+	if err := e.setRule(r); err != nil {
+		return err
+	}
+	e.problem = ""
+	return nil
+}
+
+// layout lays out the toggles for the rule as it is, returning the panel's
+// rectangle.
+func (e *ruleEditor) layout() (minX, minY, maxX, maxY float32) {
+	_, lineHeight := e.rs.textSize("")
+	px, _ := e.rs.textPixel()
+	labelWidth, _ := e.rs.textSize(ruleEditorRows[1])
+	toggleWidth, _ := e.rs.textSize("0")
+	hintWidth, _ := e.rs.textSize(e.hint())
+	width := max(labelWidth+9*(toggleWidth+2*px)+8*3*px, hintWidth) + lineHeight
+	minX, maxY = -width/2, 1
+	maxX, minY = width/2, maxY-float32(4+len(e.problemLines(width)))*lineHeight-lineHeight/2
+
+	birth, survive, ok := e.sims[0].Rule.Counts()
+	e.toggles.reset()
+	for row, counts := range [2][9]bool{birth, survive} {
+		x := minX + lineHeight/2 + labelWidth
+		y := maxY - lineHeight/2 - float32(row+1)*lineHeight
+		for n, on := range counts {
+			x = e.toggles.add(fmt.Sprint(n), x, y, ok && on) + 3*px
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+func (e *ruleEditor) hint() string {
+	if e.typing {
+		return "Enter: switch to it  Esc: stop typing"
+	}
+	return "Arrows, Space: flip  Tab or type: a rule  E: close"
+}
+
+// problemLines wraps why the last change was refused to fit a panel width
+// wide.
+func (e *ruleEditor) problemLines(width float32) []string {
+	if e.problem == "" {
+		return nil
+	}
+	charWidth, lineHeight := e.rs.textSize(" ")
+	return wrapLine(e.problem, int((width-lineHeight)/charWidth), "  ")
+}
+
+// contains reports whether the point, in normalized device coordinates, is
+// on the open panel.
+func (e *ruleEditor) contains(x, y float32) bool {
+	if !e.visible {
+		return false
+	}
+	minX, minY, maxX, maxY := e.layout()
+	return x >= minX && x <= maxX && y >= minY && y <= maxY
+}
+
+// click flips the toggle at the point, if there's one.
+func (e *ruleEditor) click(x, y float32) {
+	e.layout()
+	if i := e.toggles.at(x, y); i >= 0 {
+		e.typing = false
+		e.row, e.column = i/9, i%9
+		e.flip(e.row, e.column)
+	}
+}
+
+func (e *ruleEditor) draw() {
+	if !e.visible {
+		return
+	}
+	minX, minY, maxX, maxY := e.layout()
+	shade(e.background, e.program, minX, minY, maxX, maxY, 0.85)
+
+	_, lineHeight := e.rs.textSize("")
+	x, y := minX+lineHeight/2, maxY-lineHeight/2
+	e.text.reset()
+	if e.typing {
+		e.text.print("Rule "+string(e.line)+"_", x, y)
+	} else {
+		e.text.print("Rule "+e.sims[0].Rule.String(), x, y)
+	}
+	for row, label := range ruleEditorRows {
+		e.text.print(label, x, y-float32(row+1)*lineHeight)
+	}
+	e.text.print(e.hint(), x, y-3*lineHeight)
+	e.text.draw(1, 1, 1, 1)
+
+	focus := -1
+	if !e.typing {
+		focus = 9*e.row + e.column
+	}
+	e.toggles.draw(focus)
+
+	e.warning.reset()
+	for i, line := range e.problemLines(maxX - minX) {
+		e.warning.print(line, x, y-float32(4+i)*lineHeight)
+	}
+	e.warning.draw(1, 0.4, 0.4, 1)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+)
+
+// testRuleEditor returns an open rule editor onto two Conway boards, which
+// switches them as the window does.
+func testRuleEditor(t *testing.T) *ruleEditor {
+	t.Helper()
+	rs := testRun(t, DefaultConfig())
+	sims := []*life.Simulation{
+		life.NewSimulation(life.NewGrid(8, 8), life.Conway, 1, 0, 0),
+		life.NewSimulation(life.NewGrid(8, 8), life.Conway, 2, 0, 0),
+	}
+	e := &ruleEditor{rs: rs, sims: sims, setRule: func(r life.Rule) error {
+		for _, sim := range sims {
+			sim.Rule = r
+		}
+		return nil
+	}}
+	e.open()
+	return e
+}
+
+// checkRule fails the test unless every board runs want.
+func checkRule(t *testing.T, e *ruleEditor, want string) {
+	t.Helper()
+	for i, sim := range e.sims {
+		if got := sim.Rule.String(); got != want {
+			t.Errorf("board %d runs %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+// TestRuleEditorFlips checks flipping counts, with the arrow keys and Space
+// or directly, switches every board to the rule with them flipped, B0 and
+// all, whether or not the board wraps.
+func TestRuleEditorFlips(t *testing.T) {
+	e := testRuleEditor(t)
+	for _, k := range []glfw.Key{glfw.KeyRight, glfw.KeyRight, glfw.KeyRight, glfw.KeyRight, glfw.KeyRight, glfw.KeyRight, glfw.KeySpace} {
+		e.key(k)
+	}
+	checkRule(t, e, "B36/S23")
+	e.key(glfw.KeyDown)
+	e.key(glfw.KeyEnter)
+	checkRule(t, e, "B36/S236")
+	e.flip(1, 6)
+	e.flip(0, 0)
+	checkRule(t, e, "B036/S23")
+	if e.problem != "" {
+		t.Errorf("flipping B0 on a bounded board was refused: %s", e.problem)
+	}
+
+	// A rule without counts has nothing to flip.
+	noisy, err := life.ParseRule("noisy-life")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.setRule(noisy)
+	e.flip(0, 3)
+	checkRule(t, e, "noisy-life")
+	if e.problem == "" {
+		t.Error("flipping a count of a rule without them gave no reason it couldn't")
+	}
+}
+
+// TestRuleEditorTyping checks Tab loads the rule shown for editing, that
+// typing a rule's first character starts one afresh, and that a rule that
+// doesn't parse is refused, saying why, with the boards left as they were.
+func TestRuleEditorTyping(t *testing.T) {
+	e := testRuleEditor(t)
+	e.key(glfw.KeyTab)
+	if !e.typing || string(e.line) != "B3/S23" {
+		t.Fatalf("Tab left the line %q, typing %v, want the rule shown", string(e.line), e.typing)
+	}
+	e.key(glfw.KeyBackspace)
+	for _, c := range "345" {
+		e.char(c)
+	}
+	e.key(glfw.KeyEnter)
+	checkRule(t, e, "B3/S2345")
+	if e.typing {
+		t.Error("still typing after switching to the rule typed")
+	}
+
+	// Letters other than B and S don't start a rule.
+	e.char('x')
+	if e.typing {
+		t.Error("typing x started a rule")
+	}
+	for _, c := range "B36/S2x" {
+		e.char(c)
+	}
+	e.key(glfw.KeyEnter)
+	checkRule(t, e, "B3/S2345")
+	if e.problem == "" || !e.typing {
+		t.Errorf("a rule that doesn't parse left typing %v and no problem, want it refused", e.typing)
+	}
+	if string(e.line) != "B36/S2x" {
+		t.Errorf("the line typed is %q, want it kept to fix", string(e.line))
+	}
+
+	// Escape stops typing, leaving the rule alone.
+	e.key(glfw.KeyEscape)
+	if e.typing {
+		t.Error("still typing after Escape")
+	}
+	checkRule(t, e, "B3/S2345")
+}
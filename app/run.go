@@ -0,0 +1,2498 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+	"opengl/render"
+)
+
+const (
+	// The tick rate can be halved or doubled at runtime between minFPS and
+	// maxFPS.
+	minFPS = 0.5
+	maxFPS = 240
+	// turboBudget is how long each frame spends stepping while turbo is
+	// held, leaving the rest of the frame for rendering and input.
+	turboBudget = 10 * time.Millisecond
+
+	// A held step key starts repeating after stepRepeatDelay, then steps
+	// stepRepeatRate times a second.
+	stepRepeatDelay = 400 * time.Millisecond
+	stepRepeatRate  = 8
+
+	// maxGotoSteps is the most generations goto steps, all in one frame,
+	// so that it can't lock the window up for long.
+	maxGotoSteps = maxAPISteps
+)
+
+// GLFW, and on macOS Cocoa, must only be called from the main thread, and
+// OpenGL from the thread its context is current on, so the main goroutine
+// keeps the main thread for good.
+func init() {
+	runtime.LockOSThread()
+}
+
+// runState is everything one call of Run has to itself, from the
+// configuration it was given to the sinks the boards report to, so that Run
+// can be called again, and the tests can run it, without one run seeing
+// another's.
+type runState struct {
+	// config is the configuration Run was given, as the run has changed it
+	// since: a loaded state file sets the board, and a demo scene whether
+	// it wraps.
+	config Config
+
+	// schedule is the -scenario being run, if any.
+	schedule *scenario
+	// remote is how -api and -host reach the boards, if either is serving.
+	remote *remoteControl
+	// clusterCount counts the clusters for the title and -stats-out, if
+	// -clusters-every is set.
+	clusterCount *clusterCounter
+	// led is where -led-out frames go, if anywhere.
+	led *ledSink
+	// sound is where -audio's notes go, if anywhere.
+	sound   *soundOut
+	metrics runMetrics
+
+	// shutdown is closed on the first SIGINT or SIGTERM, for whichever loop
+	// owns the boards to finish the generation it's on and return, leaving
+	// Run to write everything out and shut the servers down. It's nil, and
+	// so never ready, until the signals are caught.
+	shutdown <-chan struct{}
+	// errorReports carries failures the run carries on after from wherever
+	// they happen to the window's error banner, which picks them up on its
+	// next frame.
+	errorReports chan errorReport
+	// slowHookWarned is when a slow hook was last warned about, so that one
+	// that's always slow is only warned about every paceReportEvery.
+	slowHookWarned time.Time
+
+	// glFrees free the OpenGL objects made for the window, when it closes,
+	// so nothing is left behind by the time GLFW terminates.
+	glFrees []func()
+	// wireframe draws the board's triangles as outlines, for debugging
+	// geometry. Overlays are always filled.
+	wireframe bool
+	// stdout is where a run without a window writes its summary.
+	stdout io.Writer
+	// download is the file a -pattern URL was fetched to with
+	// -pattern-cache off, for loadPattern to remove once it's read.
+	download string
+}
+
+// newRunState returns the state of a run of cfg.
+func newRunState(cfg Config) *runState {
+	return &runState{config: cfg, errorReports: make(chan errorReport, errorQueueSize), stdout: os.Stdout}
+}
+
+// Run runs the app with cfg until its window is closed, returning an error if
+// cfg isn't valid or it can't start.
+func Run(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	views, seeds, viewRules, _ := cfg.boards()
+	rs := newRunState(cfg)
+	stopLogging, err := rs.startLogging()
+	if err != nil {
+		return err
+	}
+	defer stopLogging()
+	defer rs.catchSignals()()
+	if rs.config.CompareSeeds == "" && rs.config.Seed == 0 {
+		for i := range seeds {
+			seeds[i] = time.Now().UnixNano()
+		}
+	}
+	if rs.config.RecordVideo != "" {
+		if err := checkFFmpeg(); err != nil {
+			return err
+		}
+	}
+	if rs.config.Scenario != "" {
+		var err error
+		if rs.schedule, err = rs.loadScenario(rs.config.Scenario); err != nil {
+			return err
+		}
+	}
+	if rs.config.PProf != "" {
+		stop, err := startPProf(rs.config.PProf)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if rs.config.Metrics != "" {
+		stop, err := rs.startMetrics(rs.config.Metrics)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if rs.config.API != "" || rs.config.GRPC != "" || rs.config.Host != "" {
+		rs.remote = rs.newRemoteControl()
+		defer rs.remote.stream.close()
+	}
+	if rs.config.API != "" {
+		stop, err := rs.startAPI(rs.config.API, rs.remote)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if rs.config.GRPC != "" {
+		stop, err := rs.startGRPC(rs.config.GRPC, rs.remote)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if rs.config.Host != "" {
+		stop, err := rs.startHost(rs.config.Host, rs.remote)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if rs.config.ClustersEvery > 0 {
+		rs.clusterCount = &clusterCounter{rs: rs, every: rs.config.ClustersEvery}
+	}
+	if rs.config.LEDOut != "" {
+		if rs.led, err = rs.newLEDSink(rs.config.LEDOut, rs.config.LEDSize, rs.config.LEDProtocol, rs.config.LEDUniverse); err != nil {
+			return err
+		}
+		defer rs.led.close()
+	}
+	if rs.config.Audio {
+		player, err := checkAudioPlayer()
+		if err == nil {
+			rs.sound, err = rs.newSoundOut(player, rs.config.AudioVolume)
+		}
+		if err != nil {
+			return err
+		}
+		defer rs.sound.close()
+	}
+	defer stopTrace()
+
+	// life diff a.json b.json compares two states, printing the counts and
+	// showing the difference.
+	var startDiff *boardDiff
+	if rs.config.Diff[0] != "" {
+		a, err := decodeState(rs.config.Diff[0])
+		if err != nil {
+			return err
+		}
+		b, err := decodeState(rs.config.Diff[1])
+		if err != nil {
+			return err
+		}
+		d, err := diffStates(a, b)
+		if err != nil {
+			return fmt.Errorf("%s and %s: %w", rs.config.Diff[0], rs.config.Diff[1], err)
+		}
+		fmt.Println(d)
+		if a.Columns != rs.config.GridWidth || a.Rows != rs.config.GridHeight {
+			return fmt.Errorf("%dx%d boards can't be shown on a %dx%d one", a.Columns, a.Rows, rs.config.GridWidth, rs.config.GridHeight)
+		}
+		startDiff = &d
+	}
+	if rs.config.ListPatterns {
+		fmt.Print(listLibrary())
+		return nil
+	}
+	if rs.config.Automaton == "list" {
+		fmt.Print(listAutomata())
+		return nil
+	}
+	setPalette(viewRules[0])
+	render.Tiling = viewRules[0].Tiling()
+	if rs.config.PrintCaps {
+		return rs.printCaps(os.Stdout)
+	}
+	// A pattern piped in is read before the window opens too. Without
+	// -pattern -, standard input is only read if nothing else says what to
+	// start from, and only if it isn't a terminal.
+	var stdinPattern []byte
+	wait := time.Duration(0)
+	if rs.config.Pattern == "" && rs.startsAfresh() && stdinPiped() {
+		rs.config.Pattern, wait = "-", stdinWait
+	}
+	if rs.config.Pattern == "-" {
+		stdinPattern, err = readStdin(wait)
+		switch {
+		case errors.Is(err, errEmptyStdin):
+			slog.Warn("no pattern on standard input; starting from a random board")
+			rs.config.Pattern = ""
+		case err != nil:
+			return fmt.Errorf("stdin: %w", err)
+		}
+	}
+	// A pattern at a URL is fetched before the window opens, so that a
+	// failure to fetch it is the only thing that happens.
+	if isURL(rs.config.Pattern) {
+		if rs.config.Pattern, err = rs.fetchPattern(rs.config.Pattern); err != nil {
+			return err
+		}
+	}
+
+	if err := rs.fitStateFile(len(viewRules)); err != nil {
+		return err
+	}
+	if rs.config.Headless {
+		return rs.runHeadless(seeds, viewRules, stdinPattern)
+	}
+	if rs.config.Renderer == "terminal" {
+		return rs.runTerminal(seeds, viewRules, stdinPattern)
+	}
+	return rs.runWindow(views, seeds, viewRules, stdinPattern, startDiff)
+}
+
+// windowRun is a run of the boards in a window, with everything the keys,
+// the mouse and the console work on. Its methods run on the main thread.
+type windowRun struct {
+	*runState
+	views    layout
+	window   *glfw.Window
+	geometry *windowTracker
+	renderer *render.GL
+	sims     []*life.Simulation
+	cells    life.Grid
+	cam      *camera
+	board    *boardTexture
+	flat     *overlayProgram
+	hist     *Stats
+	graph    *graph
+	timeline *timeline
+	minimap  *minimap
+	skyline  *skyline
+	torus    *torus
+	hints    *patternHints
+	status   *notice
+	banner   *errorBanner
+	brush    *brush
+	overlays []overlay
+	sc       *scene
+	paused   bool
+	// pausePending is set between a press and release of the pause key.
+	pausePending bool
+	// runStart is the boards as they were when last resumed, for stop
+	// to go back to.
+	runStart []savestate
+	// spacePanned records whether Space was used to pan since it was
+	// pressed, in which case releasing it doesn't toggle pause.
+	spacePanned bool
+	// stepHeld is set while the step key is down, and nextRepeat is when
+	// it next steps.
+	stepHeld   bool
+	nextRepeat time.Time
+	// rewinding is set while Backspace is held, and rewound counts the
+	// generations stepped back since the board last ran forward.
+	rewinding  bool
+	rewound    int
+	nextRewind time.Time
+	dragging   bool
+	dragX      float32
+	dragY      float32
+	stroke     *brushStroke
+	selecting  bool
+	clipboard  life.Pattern
+	rate       float64
+	// titleStale is set once the boards have stepped since the title was
+	// last updated, for it to be updated once a frame however many they
+	// step.
+	titleStale bool
+	// picked is the index of the built-in pattern being previewed, or -1.
+	picked int
+	// joined is the -host joined, if any, which runs the first board
+	// instead.
+	joined *netJoiner
+	// replay is the replay being recorded, if any, and playNext plays
+	// the next step of the one being played.
+	replay   *replayRecorder
+	playNext func()
+	// replayErr is why the replay being played stopped matching, if it
+	// did, for Run to return.
+	replayErr error
+	clock     *stepClock
+	undo      *undoHistory
+	recorder  *gifRecorder
+	// frames is the recording of -record-frames in progress, if any.
+	frames *frameRecorder
+	// stats is where -stats-out rows go, if anywhere.
+	stats      *statsWriter
+	events     *eventLog
+	checkpoint *checkpointer
+	// video is the -record-video recording in progress, if any.
+	video *videoRecorder
+	// game is the versus game being played, if any.
+	game *versus
+	show *demo
+	// hooksEdited is set when a generation hook or the scenario edits the
+	// board, so that generations stepped from before the edit aren't
+	// applied after it.
+	hooksEdited bool
+	keys        *input
+	picker      *picker
+	// Ctrl+F1 to Ctrl+F9 save every board to a slot, and F1 to F9 restore
+	// them.
+	slots [9][]savestate
+	// Screenshots are encoded in the background, so as not to hold up the
+	// board, and report back over shotDone.
+	shotDone chan string
+	help     *helpOverlay
+	// pausedBeforeHelp is whether the board was paused when the help was
+	// shown, so hiding it can leave things as they were.
+	pausedBeforeHelp bool
+	controls         *boardControls
+	// extras are the windows opened beside this one, each with a camera of
+	// its own, closed before the objects they share are freed.
+	extras []*extraWindow
+	// opened counts the windows opened beside this one, to number them.
+	opened int
+	// hidden is set while no window is both focused and not iconified, for
+	// frames to slow down in the background.
+	hidden bool
+	cl     *commandLine
+	// gpuTime is how long the GPU took over the latest board whose time
+	// came back, if the context has timer queries.
+	gpuTime      time.Duration
+	con          *console
+	editor       *ruleEditor
+	pad          *gamepad
+	steps        *simLoop
+	last         time.Time
+	pacer        *framePacer
+	nextAutosave time.Time
+}
+
+// recoverGLFW, deferred, turns a *glfw.Error panic into *err. The GLFW
+// bindings panic with one for the errors they deem fatal, such as running
+// out of memory, and log the rest. Other panics carry on.
+func recoverGLFW(err *error) {
+	if r := recover(); r != nil {
+		glfwErr, ok := r.(*glfw.Error)
+		if !ok {
+			panic(r)
+		}
+		*err = fmt.Errorf("GLFW: %w", glfwErr)
+	}
+}
+
+// runWindow runs the boards in a window until it's closed.
+func (rs *runState) runWindow(views layout, seeds []int64, viewRules []life.Rule, stdinPattern []byte, startDiff *boardDiff) (err error) {
+	// Fatal GLFW errors are returned once everything deferred has shut
+	// down cleanly.
+	defer recoverGLFW(&err)
+	window, err := rs.initGlfw(rs.config.RenderOut == "")
+	if err != nil {
+		return err
+	}
+	defer glfw.Terminate()
+	// Everything should have been freed by the time GLFW terminates.
+	defer func() {
+		if left := render.LiveObjects(); render.Debug && len(left) > 0 {
+			slog.Warn("GL objects weren't freed", "count", len(left))
+			for _, o := range left {
+				slog.Warn("not freed", "object", o.String())
+			}
+		}
+	}()
+	run := &windowRun{runState: rs, window: window, views: views}
+	if err := run.initGL(); err != nil {
+		return err
+	}
+	defer run.renderer.Shutdown()
+	defer run.freeGL()
+	if err := run.newScene(seeds, viewRules); err != nil {
+		return err
+	}
+	if rs.config.RenderOut != "" {
+		return run.renderToFile()
+	}
+	if err := run.setUp(); err != nil {
+		return err
+	}
+	run.bindKeys()
+	defer run.closeExtras()
+	if err := run.openWindows(); err != nil {
+		return err
+	}
+	run.addConsole()
+	defer func() {
+		if run.joined != nil {
+			run.joined.close()
+		}
+	}()
+	if err := run.start(stdinPattern, startDiff); err != nil {
+		return err
+	}
+	run.installCallbacks()
+	run.loop()
+	run.finish()
+	return run.replayErr
+}
+
+// initGL sets the window and OpenGL up, down to the renderer.
+func (run *windowRun) initGL() error {
+	setIcon(run.window, run.config.Icon)
+	if run.config.RenderOut == "" && !run.config.Widget {
+		run.geometry = trackWindowGeometry(run.window)
+	}
+
+	if err := run.initOpenGL(); err != nil {
+		return err
+	}
+	logEnvironment(run.window)
+	render.Debug = strings.EqualFold(run.config.LogLevel, "debug")
+	bg, fg := run.config.Background, run.config.Colour
+	gl.ClearColor(float32(bg.R)/255, float32(bg.G)/255, float32(bg.B)/255, 1)
+	render.LiveColour = [3]float32{float32(fg.R) / 255, float32(fg.G) / 255, float32(fg.B) / 255}
+	if run.window.GetAttrib(glfw.TransparentFramebuffer) == glfw.True {
+		enableTransparency()
+	}
+	if err := render.ValidateShaders(); err != nil {
+		return err
+	}
+	run.renderer = render.NewGL(run.config.ShaderDir, run.config.FragShader)
+	run.renderer.SetLODThreshold(float32(run.config.LODThreshold))
+	if err := run.renderer.Init(run.config.GridWidth, run.config.GridHeight); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newScene makes the boards and everything drawn over them.
+func (run *windowRun) newScene(seeds []int64, viewRules []life.Rule) error {
+	var err error
+	run.sims = run.newSimulations(seeds, viewRules)
+	run.cells = run.sims[0].Cells
+
+	run.cam = run.newCamera()
+	run.board = run.newBoardTexture()
+	run.flat, err = run.newOverlayProgram()
+	if err != nil {
+		return err
+	}
+	run.hist = run.config.stats
+	if run.hist == nil {
+		run.hist = NewStats(run.config.History)
+	}
+	run.graph = run.newGraph(run.hist, run.flat)
+	run.timeline = run.newTimeline(run.sims, run.flat)
+	run.minimap, err = run.newMinimap(run.cam, run.board, run.flat)
+	if err != nil {
+		return err
+	}
+	run.skyline, err = run.newSkyline()
+	if err != nil {
+		return err
+	}
+	run.torus, err = run.newTorus(run.board)
+	if err != nil {
+		return err
+	}
+	run.hints = run.newPatternHints(run.flat)
+	run.status = run.newNotice(run.flat)
+	run.banner = run.newErrorBanner(run.flat)
+	if err := run.renderer.ShaderError(); err != nil {
+		run.showError("using the built-in shaders", err)
+	}
+	run.brush = run.newBrush(run.flat)
+	run.overlays = []overlay{run.graph, run.timeline, run.minimap, run.hints, run.status, run.newHoverReadout(run.brush, run.flat)}
+	if run.views.len() > 1 {
+		names := make([]string, len(run.sims))
+		for i, sim := range run.sims {
+			if run.config.CompareSeeds != "" {
+				names[i] = fmt.Sprintf("seed %d", sim.Seed)
+			} else {
+				names[i] = sim.Rule.String()
+			}
+		}
+		run.overlays = append(run.overlays, run.newViewsOverlay(run.views, names, run.flat))
+	}
+
+	run.sc = &scene{
+		rs:        run.runState,
+		sims:      run.sims,
+		views:     run.views,
+		renderer:  run.renderer,
+		brush:     run.brush,
+		selection: run.newSelection(run.flat),
+		cursor:    run.newEditCursor(run.flat),
+		grid:      run.newGrid(run.flat),
+		cam:       run.cam,
+		overlays:  run.overlays,
+	}
+	return nil
+}
+
+// renderToFile renders the boards, -generations on, to -render-out.
+func (run *windowRun) renderToFile() error {
+	var width, height int
+	fmt.Sscanf(run.config.RenderSize, "%dx%d", &width, &height)
+	for i := 0; i < run.config.Generations; i++ {
+		for _, sim := range run.sims {
+			sim.Step(run.config.Wrap)
+		}
+	}
+	run.board.upload(run.cells)
+	return run.sc.renderToFile(run.config.RenderOut, width, height)
+}
+
+// setUp gets ready to run the boards interactively, with everything the
+// keys and the console work on.
+func (run *windowRun) setUp() error {
+	var err error
+	run.rate = run.config.TickRate
+	run.picked = -1
+	run.updateTitle()
+	// One frame catches up on no more generations than the fastest speed
+	// steps in a frame, so a stalled frame doesn't cause a burst of steps.
+	run.clock = newStepClock(run.rate, int(maxFPS/run.slowestFrameRate())+1, time.Now())
+	run.undo = newUndoHistory(run.sims)
+	run.recorder = run.newGIFRecorder(run.flat)
+	run.sc.overlays = append(run.sc.overlays, run.recorder)
+	if run.config.StatsOut != "" {
+		if run.stats, err = run.newStatsWriter(run.config.StatsOut); err != nil {
+			return err
+		}
+	}
+	run.events = newEventLog(run.sims)
+	if run.config.CheckpointEvery > 0 {
+		dir, err := run.checkpointPath()
+		if err == nil {
+			run.checkpoint, err = run.newCheckpointer(dir, run.config.CheckpointKeep)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	run.timeline.checkpoints = run.checkpoint
+	if run.config.Timelapse > 1 {
+		counter := run.newGenerationCounter(run.sims[0], run.flat)
+		run.sc.overlays = append(run.sc.overlays, counter)
+		run.sc.recorded = append(run.sc.recorded, counter)
+	}
+	if run.config.Versus {
+		run.game = run.newVersus(run.sims[0], max(run.config.VersusCells, 1), run.flat)
+		run.sc.overlays = append(run.sc.overlays, run.game)
+	}
+	if run.config.Demo {
+		run.show = run.newDemo(run.sims[0], max(run.config.DemoDuration, time.Second), run.flat)
+		run.sc.overlays = append(run.sc.overlays, run.show)
+	}
+
+	run.keys = newInput()
+	catalogDir, err := run.patternDirPath()
+	if err != nil {
+		return err
+	}
+	catalog, err := run.scanCatalog(catalogDir)
+	if err != nil {
+		slog.Warn("no pattern picker", "err", err)
+	}
+	run.picker, err = run.newPicker(catalogDir, catalog, run.flat)
+	if err != nil {
+		return err
+	}
+	run.picker.choose = func(p life.Pattern) { run.picked, run.sc.brush.pattern = -1, &p }
+	run.controls = &boardControls{
+		rs:        run.runState,
+		sims:      run.sims,
+		paused:    func() bool { return run.paused },
+		setPaused: run.setPaused,
+		step:      run.stepBoards,
+		reseed:    run.reseed,
+		stop:      func() { run.window.SetShouldClose(true) },
+		edit:      run.undo.edit,
+		setRule:   run.setRule,
+		stats:     run.hist,
+		load:      run.loadState,
+	}
+	return nil
+}
+
+// bindKeys binds the keys that don't belong to the console.
+func (run *windowRun) bindKeys() {
+	run.keys.on("quit", "Quit", "escape q", run.quit)
+	// The pause key also pans while held, so pause only toggles on a
+	// release that didn't.
+	run.keys.add(&command{
+		name: "pause", help: "Pause / resume", keys: "space",
+		press: func(glfw.ModifierKey) { run.spacePanned, run.pausePending = false, true },
+		release: func(glfw.ModifierKey) {
+			if run.pausePending && !run.spacePanned {
+				run.setPaused(!run.paused)
+			}
+			run.pausePending = false
+		},
+	})
+	run.keys.on("stop", "Stop and go back to the board as it was when resumed", "shift+space", func() {
+		if len(run.runStart) == 0 {
+			return
+		}
+		run.setPaused(true)
+		run.undo.edit(func() {
+			for i, sim := range run.sims {
+				run.runStart[i].restore(run.runState, sim)
+			}
+		})
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+		run.rewound = 0
+		run.updateTitle()
+	})
+	run.keys.add(&command{
+		name: "step", help: "Step one generation while paused", keys: "n .",
+		press: func(glfw.ModifierKey) {
+			if !run.paused {
+				if !run.config.StepPauses {
+					return
+				}
+				run.setPaused(true)
+			}
+			run.advance()
+			run.stepHeld = true
+			run.nextRepeat = time.Now().Add(stepRepeatDelay)
+		},
+		release: func(glfw.ModifierKey) { run.stepHeld = false },
+	})
+	for i, p := range builtinPatterns {
+		i := i // go.mod predates per-iteration loop variables
+		run.keys.add(&command{
+			name: fmt.Sprintf("pattern-%d", i+1), help: "Pick the " + p.Name + " to stamp",
+			keys: fmt.Sprint(i + 1), anyMods: true,
+			press: func(mods glfw.ModifierKey) {
+				if !run.paused {
+					return
+				}
+				// Picking a pattern previews it under the cursor. Picking it
+				// again turns it, and Shift and Ctrl mirror it either way.
+				p := builtinPatterns[i]
+				if run.picked == i {
+					p = run.sc.brush.pattern.Rotate90()
+				}
+				if mods&glfw.ModShift != 0 {
+					p = p.FlipX()
+				}
+				if mods&glfw.ModControl != 0 {
+					p = p.FlipY()
+				}
+				run.picked, run.sc.brush.pattern = i, &p
+			},
+		})
+	}
+	run.keys.add(&command{
+		name: "rewind", help: "Rewind while held", keys: "backspace", anyMods: true,
+		press: func(glfw.ModifierKey) {
+			run.setPaused(true)
+			run.rewind()
+			run.rewinding = true
+			run.nextRewind = time.Now().Add(interval(run.rate))
+		},
+		release: func(glfw.ModifierKey) { run.rewinding = false },
+	})
+	run.keys.on("grid", "Toggle the grid", "shift+g", func() { run.sc.grid.visible = !run.sc.grid.visible })
+	run.keys.on("graph", "Toggle the population graph", "g", func() { run.graph.visible = !run.graph.visible })
+	run.keys.on("minimap", "Toggle the minimap", "m", func() { run.minimap.visible = !run.minimap.visible })
+	run.keys.on("timeline", "Toggle the timeline, to seek by dragging its marker", "shift+t", func() {
+		run.timeline.visible = !run.timeline.visible
+		run.timeline.dragging = false
+	})
+	run.keys.on("fit", "Frame the live pattern", "f", func() { run.cam.fit(run.cells.Bounds()) })
+	run.keys.on("randomize", "Reseed with a fresh random board", "r", func() { run.reseed(time.Now().UnixNano()) })
+	run.keys.on("clear", "Clear the board", "c", func() {
+		run.undo.edit(func() {
+			if run.game != nil {
+				run.game.reset()
+				run.setPaused(true)
+				return
+			}
+			for _, sim := range run.sims {
+				sim.Clear()
+			}
+		})
+		run.events.reset()
+		run.events.info(nil, "clear")
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+		run.rewound = 0
+		run.updateTitle()
+	})
+	run.keys.on("copy", "Copy the selection", "ctrl+c", run.copySelection)
+	run.keys.on("cut", "Cut the selection", "ctrl+x", func() {
+		run.copySelection()
+		run.undo.edit(run.sc.selection.cut)
+	})
+	run.keys.on("paste", "Paste at the cursor", "ctrl+v", func() {
+		p := run.clipboard
+		if text, _, err := life.ParsePattern(glfw.GetClipboardString(), run.config.PatternLimit); err == nil && len(text.Cells) > 0 {
+			p = text
+		}
+		if len(p.Cells) > 0 {
+			run.picked, run.sc.brush.pattern = -1, &p
+		}
+	})
+	run.keys.on("patterns", "Pick a pattern to stamp from -pattern-dir", "shift+o", func() {
+		run.setPaused(true)
+		run.picker.open()
+	})
+	// The edit cursor moves with hjkl, painting as it goes if Shift is held.
+	for _, m := range []struct {
+		name, keys string
+		dx, dy     int
+	}{
+		{"left", "h", -1, 0},
+		{"down", "j", 0, -1},
+		{"up", "k", 0, 1},
+		{"right", "l", 1, 0},
+	} {
+		m := m // go.mod predates per-iteration loop variables
+		move := func(mods glfw.ModifierKey) {
+			sim := run.sc.brush.hover
+			if sim == nil {
+				sim = run.sims[0]
+			}
+			run.undo.edit(func() { run.sc.cursor.move(sim, run.cam, m.dx, m.dy, mods&glfw.ModShift != 0) })
+		}
+		run.keys.add(&command{
+			name: "cursor-" + m.name, help: "Move the edit cursor " + m.name, keys: m.keys, anyMods: true,
+			press: move, repeat: move,
+		})
+	}
+	run.keys.on("cursor-toggle", "Toggle the cell under the edit cursor", "enter", func() { run.undo.edit(run.sc.cursor.toggle) })
+	for i := range run.slots {
+		i := i // go.mod predates per-iteration loop variables
+		run.keys.on(fmt.Sprintf("save-%d", i+1), fmt.Sprintf("Save to slot %d", i+1), fmt.Sprintf("ctrl+f%d", i+1), func() {
+			run.slots[i] = run.slots[i][:0]
+			for _, sim := range run.sims {
+				run.slots[i] = append(run.slots[i], run.newSavestate(sim))
+			}
+			run.status.show(fmt.Sprintf("Saved slot %d", i+1))
+		})
+		run.keys.on(fmt.Sprintf("restore-%d", i+1), fmt.Sprintf("Restore slot %d", i+1), fmt.Sprintf("f%d", i+1), func() {
+			if run.slots[i] == nil {
+				run.status.show(fmt.Sprintf("Slot %d is empty", i+1))
+				return
+			}
+			run.setPaused(true)
+			run.undo.edit(func() {
+				for j, sim := range run.sims {
+					run.slots[i][j].restore(run.runState, sim)
+				}
+			})
+			run.hist.reset()
+			run.hist.add(run.sims[0])
+			run.timeline.restart()
+			run.rewound = 0
+			run.updateTitle()
+			run.status.show(fmt.Sprintf("Restored slot %d", i+1))
+		})
+	}
+	run.keys.add(&command{name: "turbo", help: "Run as fast as possible while held", keys: "tab", anyMods: true})
+	run.keys.on("undo", "Undo the last edit", "ctrl+z", func() {
+		if !run.undo.undoLast() {
+			run.status.show("Nothing to undo")
+		}
+	})
+	run.keys.on("redo", "Redo the last undone edit", "ctrl+shift+z ctrl+y", func() {
+		if !run.undo.redoLast() {
+			run.status.show("Nothing to redo")
+		}
+	})
+	run.keys.on("faster", "Double the speed", "up", func() { run.setRate(run.rate * 2) })
+	run.keys.on("slower", "Halve the speed", "down", func() { run.setRate(run.rate / 2) })
+	run.keys.on("wireframe", "Toggle wireframe", "z", func() { run.wireframe = !run.wireframe })
+	run.keys.on("mute", "Mute / unmute -audio", "shift+m", func() {
+		switch {
+		case run.sound == nil:
+			run.status.show("No sound without -audio")
+		case run.sound.toggleMute():
+			run.status.show("Sound muted")
+		default:
+			run.status.show("Sound on")
+		}
+	})
+	run.keys.on("brush-smaller", "Shrink the brush", "[", func() { run.sc.brush.resize(-1) })
+	run.keys.on("brush-larger", "Grow the brush", "]", func() { run.sc.brush.resize(1) })
+	run.keys.on("tool", "Switch between freehand and the line, rectangle and ellipse tools", "o", func() {
+		run.sc.brush.tool = (run.sc.brush.tool + 1) % tool(len(toolNames))
+		run.sc.brush.shape = nil
+		run.status.show("Drawing with the " + run.sc.brush.tool.String() + " tool")
+	})
+	run.keys.on("brush-shape", "Switch between a round and a square brush", "b", func() { run.sc.brush.square = !run.sc.brush.square })
+	run.shotDone = make(chan string, 4)
+	run.keys.on("screenshot", "Save a screenshot", "f12 p", func() {
+		path, img, err := run.sc.screenshot(run.window, max(run.config.ScreenshotScale, 1), run.config.ScreenshotDir)
+		if err != nil {
+			run.reportError("screenshot failed", err)
+			return
+		}
+		go func() {
+			if err := render.SavePNG(path, img); err != nil {
+				run.reportError("screenshot failed", err, "path", path)
+				return
+			}
+			slog.Info("saved", "path", path)
+			run.shotDone <- "Saved " + filepath.Base(path)
+		}()
+	})
+	run.keys.on("record", "Start / stop recording a PNG per generation", "ctrl+r", func() {
+		if run.frames != nil {
+			run.stopRecording()
+			return
+		}
+		if run.config.RecordFrames == "" {
+			run.status.show("Run with -record-frames to record")
+			return
+		}
+		var w, h int
+		if _, err := fmt.Sscanf(run.config.RecordSize, "%dx%d", &w, &h); err != nil {
+			run.status.show(fmt.Sprintf("invalid -record-size %q: want the form 1000x1000", run.config.RecordSize))
+			return
+		}
+		var err error
+		if run.frames, err = newFrameRecorder(run.config.RecordFrames, w, h); err != nil {
+			run.reportError("recording failed", err)
+			return
+		}
+		run.status.show("Recording")
+	})
+	run.keys.on("gif", "Start / stop recording an animated GIF", "ctrl+g", func() {
+		if run.recorder.recording {
+			run.stopGIF()
+		} else {
+			run.recorder.start()
+		}
+	})
+	run.keys.on("skyline", "Toggle the 3D skyline view", "v", func() { run.toggleView(run.skyline) })
+	run.keys.on("torus", "Toggle the torus view", "t", func() {
+		if !run.config.Wrap {
+			slog.Warn("the torus view needs a wrapped board; run with -wrap")
+			return
+		}
+		run.toggleView(run.torus)
+	})
+	run.help = run.newHelpOverlay(run.keys, func() []string {
+		var ruleNames, seedNames []string
+		for _, sim := range run.sims {
+			ruleNames = append(ruleNames, sim.Rule.String())
+			seedNames = append(seedNames, fmt.Sprint(sim.Seed))
+		}
+		boundary := "dead edges"
+		if run.config.Wrap {
+			boundary = "wrapped edges"
+		}
+		return []string{
+			"Rule " + strings.Join(ruleNames, ", "),
+			"Seed " + strings.Join(seedNames, ", "),
+			fmt.Sprintf("Speed %g/s, %s", run.rate, boundary),
+		}
+	}, run.flat)
+	run.sc.overlays = append(run.sc.overlays, run.help)
+	run.keys.on("help", "Show / hide this help", "/ shift+/", func() {
+		run.help.visible = !run.help.visible
+		if !run.config.HelpPauses {
+			return
+		}
+		if run.help.visible {
+			run.pausedBeforeHelp = run.paused
+			run.setPaused(true)
+		} else {
+			run.setPaused(run.pausedBeforeHelp)
+		}
+	})
+}
+
+// closeExtras closes the windows opened beside this one.
+func (run *windowRun) closeExtras() {
+	for _, e := range run.extras {
+		e.close(run.window)
+	}
+}
+
+// openWindows opens the -windows beside this one.
+func (run *windowRun) openWindows() error {
+	run.updateHidden()
+	run.window.SetFocusCallback(func(*glfw.Window, bool) { run.updateHidden() })
+	run.window.SetIconifyCallback(func(*glfw.Window, bool) { run.updateHidden() })
+	for len(run.extras) < run.config.Windows-1 {
+		if err := run.openWindow(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addConsole adds the command console, the rule editor and the keys
+// that open them.
+func (run *windowRun) addConsole() {
+	// The console has the commands that work without a window too, some
+	// replaced by its own below, and runs the scenario's.
+	run.cl = newCommandLine()
+	run.addBoardCommands(run.cl, run.controls)
+	if run.schedule != nil {
+		run.schedule.commands = run.cl
+	}
+	run.cl.add("load", "load glider.rle", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one save, pattern or state file")
+		}
+		// A bare name is one of the named saves.
+		if filepath.Ext(args[0]) == "" {
+			path, err := run.savePath(args[0])
+			if err != nil {
+				return "", err
+			}
+			if !saveExists(path) {
+				return "", run.missingSave(args[0])
+			}
+			if err := run.loadState(path); err != nil {
+				return "", err
+			}
+			return "Loaded " + args[0], nil
+		}
+		if isStateFile(args[0]) {
+			if err := run.loadState(args[0]); err != nil {
+				return "", err
+			}
+			return "Loaded " + args[0], nil
+		}
+		return "", run.openPattern(args[0], run.sims[0], run.config.GridWidth/2, run.config.GridHeight/2)
+	})
+	run.cl.add("diff", "diff mysoup", func(args []string) (string, error) {
+		if len(args) == 0 {
+			if _, ok := run.sc.view.(*diffView); ok {
+				run.sc.view = nil
+			}
+			return "", nil
+		}
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one save or state file")
+		}
+		path := args[0]
+		if filepath.Ext(path) == "" {
+			var err error
+			if path, err = run.savePath(args[0]); err != nil {
+				return "", err
+			}
+			if !saveExists(path) {
+				return "", run.missingSave(args[0])
+			}
+		}
+		saved, err := decodeState(path)
+		if err != nil {
+			return "", err
+		}
+		d, err := diffStates(run.captureState(run.sims, run.cam), saved)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", args[0], err)
+		}
+		run.setPaused(true)
+		run.sc.view = run.newDiffView(d, run.cam, run.flat)
+		return fmt.Sprintf("Red is only on the board, blue only in %s and white in both: %v. diff on its own hides it.", args[0], d), nil
+	})
+	run.cl.add("rule", "rule B3/S23", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one rule")
+		}
+		r, err := life.ParseRule(args[0])
+		if err != nil {
+			return "", err
+		}
+		if err := run.setRule(r); err != nil {
+			return "", err
+		}
+		return "Rule " + r.String(), nil
+	})
+	run.cl.add("seed", "seed 42", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one seed")
+		}
+		seed, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return "", err
+		}
+		run.reseed(seed)
+		return "", nil
+	})
+	run.cl.add("density", "density 0.1", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one density")
+		}
+		d, err := strconv.ParseFloat(args[0], 64)
+		if err != nil || d < 0 || d > 1 {
+			return "", fmt.Errorf("density must be between 0 and 1")
+		}
+		for _, sim := range run.sims {
+			sim.Density = d
+		}
+		run.reseed(run.sims[0].Seed)
+		return "", nil
+	})
+	run.cl.add("speed", "speed 10", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one speed")
+		}
+		r, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return "", err
+		}
+		run.setRate(r)
+		return fmt.Sprintf("Speed %g/s", run.rate), nil
+	})
+	run.cl.add("goto", "goto 500", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one generation")
+		}
+		gen, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", err
+		}
+		if run.joined != nil {
+			return "", fmt.Errorf("the host runs the board")
+		}
+		if run.game != nil && !run.game.running() {
+			return "", fmt.Errorf("the versus game isn't running")
+		}
+		if gen < run.sims[0].Generation {
+			return "", fmt.Errorf("generation %d has passed; rewind with Backspace instead", gen)
+		}
+		if gen-run.sims[0].Generation > maxGotoSteps {
+			return "", fmt.Errorf("goto goes at most %d generations on", maxGotoSteps)
+		}
+		for run.sims[0].Generation < gen {
+			was := run.sims[0].Generation
+			run.advance()
+			if run.sims[0].Generation == was {
+				return "", fmt.Errorf("stopped at generation %d", was)
+			}
+		}
+		return fmt.Sprintf("Generation %d", gen), nil
+	})
+	run.cl.add("save!", "save! mysoup", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one name")
+		}
+		return run.saveNamed(args[0], true)
+	})
+	run.cl.add("saves", "saves", func([]string) (string, error) {
+		saves, err := run.listSaves()
+		if err != nil {
+			return "", err
+		}
+		if len(saves) == 0 {
+			return "No saves yet", nil
+		}
+		var lines []string
+		for _, s := range saves {
+			lines = append(lines, fmt.Sprintf("%s  %dx%d  generation %d  population %d  %s",
+				s.name, s.columns, s.rows, s.generation, s.population, s.saved.Format("2006-01-02 15:04")))
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+	run.cl.add("save", "save mysoup", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one name")
+		}
+		path := args[0]
+		if filepath.Ext(path) == "" {
+			return run.saveNamed(path, false)
+		}
+		// A state file gets everything needed to carry on later, not just
+		// the pattern.
+		if isStateFile(path) {
+			if err := run.saveState(path); err != nil {
+				return "", err
+			}
+			return "Saved " + path, nil
+		}
+		p := run.cells.Pattern()
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := os.WriteFile(path, []byte(encodePatternFile(path, p, run.sims[0].Rule.String())), 0o644); err != nil {
+			return "", err
+		}
+		return "Saved " + path, nil
+	})
+	// export writes out the selection, or the whole board when nothing is
+	// selected.
+	run.cl.add("export", "export glider.rle", func(args []string) (string, error) {
+		if len(args) > 1 {
+			return "", fmt.Errorf("want a file name, or none to export to the clipboard")
+		}
+		p, sim, what := run.cells.Pattern(), run.sims[0], "board"
+		if run.sc.selection.sim != nil {
+			p, sim, what = run.sc.selection.copy(), run.sc.selection.sim, "selection"
+		}
+		if len(args) == 0 {
+			p.Name = ""
+			glfw.SetClipboardString(life.EncodeRLE(p, sim.Rule.String()))
+			return "Copied the " + what + " to the clipboard", nil
+		}
+		path := args[0]
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := os.WriteFile(path, []byte(encodePatternFile(path, p, sim.Rule.String())), 0o644); err != nil {
+			return "", err
+		}
+		return "Exported the " + what + " to " + path, nil
+	})
+	run.cl.add("export-view", "export-view corner.rle", func(args []string) (string, error) {
+		if len(args) > 1 {
+			return "", fmt.Errorf("want a file name, or none to export to the clipboard")
+		}
+		minX, minY, maxX, maxY, ok := run.cam.viewCells()
+		if !ok {
+			return "", fmt.Errorf("no cells are in view")
+		}
+		// Like the board, the view is trimmed to its live cells.
+		p := run.cells.Region(minX, minY, maxX, maxY).Transform(func(x, y, w, h int) (int, int) { return x, y })
+		if len(p.Cells) == 0 {
+			return "", fmt.Errorf("no live cells are in view")
+		}
+		if len(args) == 0 {
+			glfw.SetClipboardString(life.EncodeRLE(p, run.sims[0].Rule.String()))
+			return "Copied the view to the clipboard", nil
+		}
+		path := args[0]
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := os.WriteFile(path, []byte(encodePatternFile(path, p, run.sims[0].Rule.String())), 0o644); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Exported cells %d,%d to %d,%d to %s", minX, minY, maxX, maxY, path), nil
+	})
+	run.cl.add("census", "census", func(args []string) (string, error) {
+		if len(args) != 0 {
+			return "", fmt.Errorf("takes no arguments")
+		}
+		return life.TakeCensus(run.cells, run.config.Wrap).String(), nil
+	})
+	run.cl.add("gl", "gl", func(args []string) (string, error) {
+		if len(args) != 0 {
+			return "", fmt.Errorf("takes no arguments")
+		}
+		objects := render.LiveObjects()
+		counts := make(map[render.ObjectKind]int)
+		size := 0
+		for _, o := range objects {
+			slog.Info("GL object", "object", o.String())
+			counts[o.Kind]++
+			size += o.Size
+		}
+		var kinds []string
+		for kind := render.Buffer; kind <= render.Query; kind++ {
+			if counts[kind] > 0 {
+				kinds = append(kinds, fmt.Sprintf("%d %ss", counts[kind], kind))
+			}
+		}
+		out := fmt.Sprintf("%d GL objects live, %d bytes: %s (listed in the log)", len(objects), size, strings.Join(kinds, ", "))
+		if run.gpuTime > 0 {
+			out += fmt.Sprintf("\nThe latest board timed took %s on the GPU", run.gpuTime.Round(time.Microsecond))
+		}
+		return out, nil
+	})
+	run.cl.add("shaders", "shaders", func(args []string) (string, error) {
+		if len(args) != 0 {
+			return "", fmt.Errorf("takes no arguments")
+		}
+		if err := run.renderer.ShaderError(); err != nil {
+			return "The board shader files don't build:\n" + err.Error(), nil
+		}
+		return "The board shaders built cleanly", nil
+	})
+	run.cl.add("trace", "trace 5s [trace.out]", func(args []string) (string, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return "", fmt.Errorf("want a duration, and optionally a file name")
+		}
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("invalid duration %q", args[0])
+		}
+		path := time.Now().Format("trace-20060102-150405.out")
+		if len(args) == 2 {
+			path = args[1]
+		}
+		if err := startTrace(path, d); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Tracing to %s for %v", path, d), nil
+	})
+	run.cl.add("export-pbm", "export-pbm board.pbm", func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("want one file name")
+		}
+		// A .pgm keeps the cells' ages as well.
+		ages := strings.ToLower(filepath.Ext(args[0])) == ".pgm"
+		if err := os.WriteFile(args[0], encodePNM(run.cells, run.sims[0].Generation, run.sims[0].Rule, ages), 0o644); err != nil {
+			return "", err
+		}
+		return "Exported the board to " + args[0], nil
+	})
+	run.cl.add("export-svg", "export-svg board.svg [grid]", func(args []string) (string, error) {
+		if len(args) < 1 || len(args) > 2 || len(args) == 2 && args[1] != "grid" {
+			return "", fmt.Errorf("want a file name, and optionally grid")
+		}
+		if err := os.WriteFile(args[0], []byte(encodeSVG(run.cells, len(args) == 2)), 0o644); err != nil {
+			return "", err
+		}
+		return "Exported the board to " + args[0], nil
+	})
+	run.cl.add("window", "window new", func(args []string) (string, error) {
+		if len(args) != 1 || args[0] != "new" {
+			return "", fmt.Errorf("want new")
+		}
+		if err := run.openWindow(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Opened window %d", 1+run.opened), nil
+	})
+	run.cl.add("clear", "clear", func([]string) (string, error) {
+		run.keys.runNamed("clear", glfw.Press)
+		return "", nil
+	})
+	run.con = run.newConsole(run.cl, run.flat)
+	run.editor = run.newRuleEditor(run.sims, run.setRule, run.flat)
+	// Errors go over everything.
+	run.sc.overlays = append(run.sc.overlays, run.picker, run.con, run.editor, run.banner)
+	run.keys.on("dismiss-error", "Dismiss the error showing", "x", run.banner.dismiss)
+	run.keys.on("console", "Open the command console", "` shift+;", run.con.open)
+	run.keys.on("rule-editor", "Edit the rule, flipping the neighbour counts cells are born and survive on", "e", run.editor.open)
+	// The camera moves for as long as these are held.
+	run.keys.add(&command{name: "zoom-in", help: "Zoom in", keys: "= kp_add"})
+	run.keys.add(&command{name: "zoom-out", help: "Zoom out", keys: "- kp_subtract"})
+	run.keys.add(&command{name: "pan-left", help: "Pan left", keys: "a"})
+	run.keys.add(&command{name: "pan-right", help: "Pan right", keys: "d"})
+	run.keys.add(&command{name: "pan-down", help: "Pan down", keys: "s"})
+	run.keys.add(&command{name: "pan-up", help: "Pan up", keys: "w"})
+}
+
+// start puts the boards in their starting positions and gets the
+// recordings, replays and joined game going.
+func (run *windowRun) start(stdinPattern []byte, startDiff *boardDiff) error {
+	var err error
+	if err := run.keys.loadBindings(run.config.Bindings); err != nil {
+		return err
+	}
+	run.keys.install(run.window)
+	if run.game != nil {
+		run.sc.grid.visible = true
+		run.setPaused(true)
+	} else if run.config.Edit {
+		for _, sim := range run.sims {
+			sim.Clear()
+		}
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+		run.sc.grid.visible = true
+		run.setPaused(true)
+	}
+	switch {
+	case stdinPattern != nil:
+		p, ruleText, err := life.ParsePattern(string(stdinPattern), run.config.PatternLimit)
+		if err != nil {
+			return fmt.Errorf("stdin: %w", err)
+		}
+		if p.Name == "" {
+			p.Name = "stdin"
+		}
+		if err := run.placePattern(p, ruleText, run.sims[0], run.config.GridWidth/2, run.config.GridHeight/2); err != nil {
+			return err
+		}
+	case run.config.Pattern != "":
+		if err := run.openPattern(run.config.Pattern, run.sims[0], run.config.GridWidth/2, run.config.GridHeight/2); err != nil {
+			return err
+		}
+	}
+	if run.config.SeedImage != "" {
+		if err := run.loadSeedImage(run.sims[0], run.config.SeedImage); err != nil {
+			return err
+		}
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+	}
+	if run.config.ImportPBM != "" {
+		img, err := run.readPNM(run.config.ImportPBM)
+		if err != nil {
+			return err
+		}
+		img.apply(run.sims[0])
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+	}
+	if run.config.Load != "" {
+		path, err := run.loadPath(run.config.Load)
+		if err != nil {
+			return err
+		}
+		if err := run.loadState(path); err != nil {
+			return err
+		}
+	}
+	if run.config.Resume {
+		path, err := autosavePath()
+		if err == nil {
+			err = run.loadState(path)
+		}
+		switch {
+		case err == nil:
+			run.setPaused(false)
+		case !errors.Is(err, fs.ErrNotExist):
+			// Autosaving would overwrite what may be a long run.
+			run.config.Resume = false
+			slog.Warn("starting afresh, with autosaving off", "err", err)
+		}
+	}
+	run.con.install(run.window, run.keys)
+	run.picker.install(run.keys)
+	run.editor.install(run.window, run.keys)
+	if run.config.Gamepad {
+		buttons, err := parseGamepadMap(run.config.GamepadMap, run.keys)
+		if err != nil {
+			return err
+		}
+		run.pad = run.newGamepad(buttons)
+	}
+
+	if run.config.RecordVideo != "" {
+		fbWidth, fbHeight := run.window.GetFramebufferSize()
+		if run.video, err = run.newVideoRecorder(run.config.RecordVideo, fbWidth, fbHeight); err != nil {
+			return err
+		}
+	}
+
+	if startDiff != nil {
+		run.sc.view = run.newDiffView(*startDiff, run.cam, run.flat)
+		run.setPaused(true)
+		run.status.show("Red: only in " + filepath.Base(run.config.Diff[0]) + ", blue: only in " + filepath.Base(run.config.Diff[1]))
+	}
+	if run.config.PlayReplay != "" {
+		player, err := run.loadReplay(run.config.PlayReplay, len(run.sims))
+		if err != nil {
+			return err
+		}
+		player.start(run.sims, run.cam)
+		run.setRate(player.header.Rate)
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+		run.playNext = func() {
+			more, err := player.next(run.sims, run.stepBoards, run.rewind, run.setRate)
+			if err != nil {
+				// The run ends, with everything saved and shut down as
+				// on quitting, and Run returns why.
+				run.replayErr = fmt.Errorf("replay %s failed: %w", run.config.PlayReplay, err)
+				run.playNext = nil
+				run.quit()
+				return
+			}
+			if !more {
+				msg := fmt.Sprintf("Replay finished at generation %d, matching all %d checkpoints", run.sims[0].Generation, player.checks)
+				slog.Info(msg)
+				run.status.show(msg)
+				run.playNext = nil
+				run.setPaused(true)
+			}
+		}
+		run.setPaused(false)
+	}
+	if run.config.RecordReplay != "" {
+		if run.replay, err = run.newReplayRecorder(run.config.RecordReplay, run.sims, run.cam, max(run.config.Rewind, 0), run.rate); err != nil {
+			return err
+		}
+		run.undo.changed = run.replay.edited
+	}
+	if run.config.Join != "" {
+		run.joined = run.joinHost(run.config.Join)
+		changed := run.undo.changed
+		run.undo.changed = func(edit []boardEdit, after bool) {
+			if changed != nil {
+				changed(edit, after)
+			}
+			run.joined.edited(run.sims[0], edit, after)
+		}
+		run.setPaused(true)
+	}
+	// The boards as they're edited aren't what the generations before led
+	// up to, so the timeline starts again from them.
+	changed := run.undo.changed
+	run.undo.changed = func(edit []boardEdit, after bool) {
+		if changed != nil {
+			changed(edit, after)
+		}
+		run.timeline.restart()
+	}
+	return nil
+}
+
+// installCallbacks installs the mouse and file drop callbacks.
+func (run *windowRun) installCallbacks() {
+	run.window.SetDropCallback(func(w *glfw.Window, names []string) {
+		if len(names) > 1 {
+			slog.Warn("several files dropped; loading only the first", "files", len(names), "path", names[0])
+		}
+		sim, cx, cy, ok := run.sc.cellUnderCursor(w)
+		if !ok {
+			sim, cx, cy = run.sims[0], run.config.GridWidth/2, run.config.GridHeight/2
+		}
+		if err := run.openPattern(names[0], sim, cx, cy); err != nil {
+			run.reportError("opening the dropped file failed", err, "path", names[0])
+		}
+	})
+	run.window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		x, y := cursorNDC(w)
+		if action == glfw.Release {
+			if run.timeline.dragging && button == glfw.MouseButtonLeft {
+				run.seek(run.timeline.release())
+			}
+			run.dragging = false
+			if run.stroke != nil {
+				run.undo.commit()
+			}
+			if shape := run.sc.brush.shape; shape != nil && button == glfw.MouseButtonLeft {
+				run.undo.edit(shape.place)
+				run.sc.brush.shape = nil
+				if run.config.EditPauses {
+					run.setPaused(true)
+				}
+			}
+			run.stroke = nil
+			run.selecting = false
+			return
+		}
+		switch {
+		case run.editor.contains(x, y):
+			if button == glfw.MouseButtonLeft {
+				run.editor.click(x, y)
+			}
+		case button == glfw.MouseButtonLeft && run.timeline.contains(x, y):
+			run.timeline.press(x)
+		case button == glfw.MouseButtonLeft && run.minimap.contains(x, y):
+			run.cam.x, run.cam.y = run.minimap.toWorld(x, y)
+			run.cam.clamp()
+		case button == glfw.MouseButtonMiddle,
+			button == glfw.MouseButtonLeft && run.keys.held("pause"):
+			if button == glfw.MouseButtonLeft {
+				run.spacePanned = true
+			}
+			run.dragging = true
+			run.dragX, run.dragY = x, y
+		case run.game != nil:
+			// Versus players can only place cells, in turn.
+			if button != glfw.MouseButtonLeft || !run.game.placing() {
+				return
+			}
+			_, cx, cy, ok := run.sc.cellUnderCursor(w)
+			if !ok {
+				return
+			}
+			if err := run.game.place(cx, cy); err != nil {
+				run.status.show(err.Error())
+				return
+			}
+			if !run.game.placing() {
+				run.setPaused(false)
+			}
+		case button == glfw.MouseButtonLeft && mods&glfw.ModShift != 0:
+			if sim, cx, cy, ok := run.sc.cellUnderCursor(w); ok {
+				run.sc.selection.start(sim, cx, cy)
+				run.selecting = true
+			}
+		case button == glfw.MouseButtonLeft:
+			sim, cx, cy, ok := run.sc.cellUnderCursor(w)
+			if !ok {
+				return
+			}
+			run.sc.selection.clear()
+			if p := run.sc.brush.pattern; p != nil {
+				run.undo.edit(func() { sim.Cells.Stamp(*p, cx, cy, run.config.Wrap) })
+				run.events.info(sim, "pattern stamped", "pattern", p.Name, "x", cx, "y", cy)
+				run.picked, run.sc.brush.pattern = -1, nil
+				return
+			}
+			if run.sc.brush.tool != freehand {
+				run.sc.brush.shape = &shapeDrag{tool: run.sc.brush.tool, sim: sim, x0: cx, y0: cy, x1: cx, y1: cy}
+				return
+			}
+			// A whole stroke is undone at once.
+			run.undo.begin()
+			if run.sc.brush.radius == 0 {
+				sim.Cells.Set(cx, cy, !sim.Cells.Alive(cx, cy))
+				run.stroke = &brushStroke{brush: run.sc.brush, sim: sim, alive: true, x: cx, y: cy}
+			} else {
+				run.stroke = &brushStroke{brush: run.sc.brush, alive: true}
+				run.stroke.moveTo(sim, cx, cy)
+			}
+			if run.config.EditPauses {
+				run.setPaused(true)
+			}
+		case button == glfw.MouseButtonRight && run.sc.brush.pattern != nil:
+			run.picked, run.sc.brush.pattern = -1, nil
+		case button == glfw.MouseButtonRight && run.sc.brush.shape != nil:
+			run.sc.brush.shape = nil
+		case button == glfw.MouseButtonRight:
+			sim, cx, cy, ok := run.sc.cellUnderCursor(w)
+			if !ok {
+				return
+			}
+			run.undo.begin()
+			run.stroke = &brushStroke{brush: run.sc.brush, alive: false}
+			run.stroke.moveTo(sim, cx, cy)
+			if run.config.EditPauses {
+				run.setPaused(true)
+			}
+		}
+	})
+	run.window.SetCursorPosCallback(func(w *glfw.Window, xpos, ypos float64) {
+		if run.timeline.dragging {
+			x, _ := cursorNDC(w)
+			run.timeline.drag(x)
+			return
+		}
+		run.sc.brush.hover, run.sc.brush.hoverX, run.sc.brush.hoverY, _ = run.sc.cellUnderCursor(w)
+		if run.selecting {
+			if sim, cx, cy, ok := run.sc.cellUnderCursor(w); ok {
+				run.sc.selection.extend(sim, cx, cy)
+			}
+			return
+		}
+		if run.stroke != nil {
+			if sim, cx, cy, ok := run.sc.cellUnderCursor(w); ok && (sim != run.stroke.sim || cx != run.stroke.x || cy != run.stroke.y) {
+				run.stroke.moveTo(sim, cx, cy)
+			}
+			return
+		}
+		if shape := run.sc.brush.shape; shape != nil {
+			if sim, cx, cy, ok := run.sc.cellUnderCursor(w); ok && sim == shape.sim {
+				shape.x1, shape.y1 = cx, cy
+			}
+			return
+		}
+		if !run.dragging {
+			return
+		}
+		x, y := cursorNDC(w)
+		run.cam.drag(x-run.dragX, y-run.dragY)
+		run.dragX, run.dragY = x, y
+	})
+	run.window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		if w.GetKey(glfw.KeyLeftControl) == glfw.Press || w.GetKey(glfw.KeyRightControl) == glfw.Press {
+			if yoff > 0 {
+				run.sc.brush.resize(1)
+			} else if yoff < 0 {
+				run.sc.brush.resize(-1)
+			}
+			return
+		}
+		x, y := cursorNDC(w)
+		run.cam.zoomAt(x, y, float32(math.Pow(scrollZoom, yoff)))
+	})
+}
+
+// loop runs the boards until the window is closed.
+func (run *windowRun) loop() {
+	run.steps = run.newSimLoop()
+	defer run.steps.stop()
+	run.hist.add(run.sims[0])
+	run.timeline.restart()
+	run.schedule.begin(run.sims[0].Generation)
+	run.last = time.Now()
+	run.clock.reset(run.last)
+	run.pacer = newFramePacer(run.config.FrameRate, run.config.BackgroundFPS, func(d time.Duration) { glfw.WaitEventsTimeout(d.Seconds()) }, run.last)
+	if run.remote != nil {
+		// Requests wake the window if it's waiting for input.
+		wake := glfw.PostEmptyEvent
+		run.remote.wake.Store(&wake)
+		defer run.remote.wake.Store(nil)
+	}
+	run.nextAutosave = run.last.Add(run.config.AutosaveInterval)
+	for !run.window.ShouldClose() {
+		run.frame()
+	}
+}
+
+// frame takes the input, steps the boards and draws them, once a frame.
+func (run *windowRun) frame() {
+	// Input is taken every frame, however slowly the boards step.
+	glfw.PollEvents()
+	t := time.Now()
+	run.clock.tick(t)
+	select {
+	case <-run.shutdown:
+		run.window.SetShouldClose(true)
+	case msg := <-run.shotDone:
+		run.status.show(msg)
+	default:
+	}
+	run.remote.serve(run.controls)
+	if run.joined != nil {
+		run.joined.apply(run.sims[0], func(next life.Grid, births, deaths int) {
+			run.stepBoardsBy(func() { run.sims[0].StepTo(next, births, deaths) })
+		}, func() {
+			run.undo.stepped()
+			run.events.reset()
+			run.hist.reset()
+			run.hist.add(run.sims[0])
+			run.timeline.restart()
+		})
+		select {
+		case news := <-run.joined.news:
+			run.status.show(news)
+		default:
+		}
+	}
+	if run.config.Resume && run.config.AutosaveInterval > 0 && !t.Before(run.nextAutosave) {
+		run.autosave()
+		run.nextAutosave = t.Add(run.config.AutosaveInterval)
+	}
+	if run.geometry != nil {
+		run.geometry.update(t)
+	}
+	dt := t.Sub(run.last).Seconds()
+	run.cam.update(run.keys.held, dt)
+	if run.pad != nil {
+		run.pad.update(run.cam, run.keys, dt)
+	}
+	// The cell under the cursor changes as the camera moves, too.
+	run.brush.hover, run.brush.hoverX, run.brush.hoverY, _ = run.sc.cellUnderCursor(run.window)
+	if run.sc.view != nil {
+		run.sc.view.update(dt)
+	}
+	if run.show != nil && run.show.update(t) {
+		run.undo.stepped()
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.timeline.restart()
+		run.rewound = 0
+		run.updateTitle()
+	}
+	run.last = t
+
+	fbWidth, fbHeight := run.window.GetFramebufferSize()
+	run.draw(fbWidth, fbHeight)
+	run.window.SwapBuffers()
+	for i := 0; i < len(run.extras); i++ {
+		if e := run.extras[i]; e.window.ShouldClose() {
+			e.close(run.window)
+			run.extras = append(run.extras[:i], run.extras[i+1:]...)
+			i--
+			run.updateHidden()
+		} else {
+			e.show(run.window, dt)
+		}
+	}
+	if run.video != nil {
+		// The video's size was fixed when it started.
+		if fbWidth != run.video.target.Width || fbHeight != run.video.target.Height {
+			run.status.show("The window changed size, so the video recording stopped")
+			run.stopVideo()
+		} else if !run.timelapsing() {
+			// A time-lapse is captured as the boards step instead.
+			if err := run.video.capture(run.sc, t); err != nil {
+				run.reportError("video recording failed", err, "path", run.config.RecordVideo)
+				run.stopVideo()
+			}
+		}
+	}
+	if !run.paused && (run.keys.held("turbo") || run.timelapsing()) {
+		// A hook, a scenario or a replay ending can pause the boards or
+		// close the window partway through.
+		stepWithin(turboBudget, run.advance, func() bool { return run.paused || run.window.ShouldClose() })
+		run.clock.reset(t)
+	} else if !run.paused && run.playNext == nil {
+		// Generations are stepped on the simulation goroutine and
+		// applied as they come back. Ones that no longer follow on, after
+		// an edit, are stepped here instead.
+		job, fresh := run.steps.result(run.sims)
+		run.hooksEdited = false
+		for i := 0; fresh && i < job.n && !run.paused && !run.hooksEdited && job.follows(run.sims, i); i++ {
+			run.stepBoardsBy(func() { job.apply(run.sims, i) })
+			run.clock.spend(1)
+		}
+		if job != nil && !fresh {
+			for ; run.clock.due() > 0 && !run.paused; run.clock.spend(1) {
+				run.advance()
+			}
+		}
+		if !run.paused {
+			run.steps.request(run.sims, run.clock.due())
+		}
+	} else if !run.paused {
+		for ; run.clock.due() > 0 && !run.paused; run.clock.spend(1) {
+			run.advance()
+		}
+	} else {
+		run.clock.reset(t)
+	}
+	if run.rewinding && !t.Before(run.nextRewind) {
+		run.rewind()
+		run.nextRewind = t.Add(interval(run.rate))
+	}
+	if run.paused && run.stepHeld && !t.Before(run.nextRepeat) {
+		run.advance()
+		run.nextRepeat = t.Add(time.Second / stepRepeatRate)
+	}
+	if run.timeline.seeking && run.timeline.advance(turboBudget) {
+		run.undo.stepped()
+		run.events.reset()
+		run.hist.reset()
+		run.hist.add(run.sims[0])
+		run.rewound = 0
+		run.updateTitle()
+		run.status.show(fmt.Sprintf("Sought generation %d", run.sims[0].Generation))
+	}
+	if run.titleStale {
+		run.updateTitle()
+	}
+	run.recordFrame(time.Since(t))
+	for _, d := range run.renderer.GPUTimes() {
+		run.recordBoardPass(d)
+		run.gpuTime = d
+	}
+	run.pacer.hidden = run.hidden
+	// Paused, and with nothing moving, only input changes the picture.
+	run.pacer.idle = !run.extrasMoving() && run.paused && !run.rewinding && !run.stepHeld && !run.timeline.seeking && len(run.keys.down) == 0 && run.cam.to == nil &&
+		!run.status.showing() && run.sc.view == nil && run.show == nil && run.game == nil && run.joined == nil && run.pad == nil &&
+		run.frames == nil && run.video == nil && !run.recorder.recording && run.config.FragShader == ""
+	run.pacer.wait()
+}
+
+// draw draws a frame of the boards into the bound framebuffer, which is
+// fbWidth by fbHeight pixels. Taking input and showing the frame are left
+// to frame.
+func (run *windowRun) draw(fbWidth, fbHeight int) {
+	if err := run.renderer.Reload(); err != nil {
+		run.showError("keeping the previous shaders", err)
+	}
+	run.board.upload(run.cells)
+	run.sc.render(fbWidth, fbHeight)
+	render.CheckDebugError()
+}
+
+// finish stops the recordings and saves what's saved on quitting.
+func (run *windowRun) finish() {
+	if run.frames != nil {
+		run.stopRecording()
+	}
+	if run.recorder.recording {
+		run.stopGIF()
+	}
+	if run.video != nil {
+		run.stopVideo()
+	}
+	if run.config.Resume {
+		run.autosave()
+	}
+	if run.geometry != nil {
+		run.geometry.save()
+	}
+	if run.stats != nil {
+		if err := run.stats.close(); err != nil {
+			slog.Error("writing -stats-out failed", "err", err)
+		}
+	}
+	if run.checkpoint != nil {
+		run.checkpoint.wait()
+	}
+	if run.replay != nil {
+		if err := run.replay.close(); err != nil {
+			slog.Error("recording the replay failed", "err", err)
+		}
+	}
+}
+
+// toggleView shows p instead of the boards, or the boards again if it's
+// showing.
+func (run *windowRun) toggleView(p presentation) {
+	if run.sc.view == p {
+		run.sc.view = nil
+	} else {
+		run.sc.view = p
+	}
+}
+
+// updateTitle puts the rate, population and state in the window title.
+func (run *windowRun) updateTitle() {
+	run.titleStale = false
+	title := fmt.Sprintf("Conway's Game of Life - %g/s - population %d +%d \u2212%d",
+		run.rate, run.cells.Population(), run.sims[0].Births, run.sims[0].Deaths)
+	if n, ok := run.clusterCount.latest(); ok {
+		title += fmt.Sprintf(" - %d clusters", n)
+	}
+	if run.rewound > 0 {
+		title += fmt.Sprintf(" - REWOUND %d", run.rewound)
+	}
+	if run.paused {
+		title += " - PAUSED"
+	}
+	run.window.SetTitle(title)
+}
+
+// setPaused pauses or runs the boards, unless the joined host runs them.
+func (run *windowRun) setPaused(p bool) {
+	if run.joined != nil && !p {
+		run.status.show("The host at " + run.config.Join + " runs the board")
+		return
+	}
+	// Unpausing while seeking stops the seek where it's got to instead.
+	if run.timeline.seeking && !p {
+		run.timeline.cancel()
+		return
+	}
+	if run.paused && !p {
+		run.runStart = run.runStart[:0]
+		for _, sim := range run.sims {
+			run.runStart = append(run.runStart, run.newSavestate(sim))
+		}
+	}
+	run.paused = p
+	run.hints.visible = run.paused
+	if !run.paused {
+		run.picked, run.sc.brush.pattern = -1, nil
+	}
+	run.updateTitle()
+}
+
+// setRate sets how many generations a second the boards step.
+func (run *windowRun) setRate(r float64) {
+	run.rate = min(max(r, minFPS), maxFPS)
+	if run.replay != nil {
+		run.replay.rate(run.rate)
+	}
+	run.clock.setRate(run.rate)
+	run.updateTitle()
+}
+
+// stopGIF stops the GIF recording and saves it.
+func (run *windowRun) stopGIF() {
+	path := filepath.Join(run.config.ScreenshotDir, time.Now().Format("life-20060102-150405.gif"))
+	if err := run.recorder.stop(path); err != nil {
+		run.reportError("GIF failed", err, "path", path)
+		return
+	}
+	run.status.show("Saved " + filepath.Base(path))
+	slog.Info("saved", "path", path)
+}
+
+// stopRecording stops -record-frames.
+func (run *windowRun) stopRecording() {
+	if err := run.frames.stop(); err != nil {
+		run.reportError("recording failed", err)
+	} else {
+		run.status.show(fmt.Sprintf("Recorded %d frames", run.frames.frames))
+	}
+	run.frames = nil
+}
+
+// stopVideo stops -record-video.
+func (run *windowRun) stopVideo() {
+	if err := run.video.stop(); err != nil {
+		run.reportError("video recording failed", err, "path", run.config.RecordVideo)
+	} else {
+		slog.Info("saved", "path", run.config.RecordVideo)
+	}
+	if run.video.dropped > 0 {
+		slog.Warn("dropped frames that ffmpeg couldn't keep up with", "frames", run.video.dropped)
+	}
+	run.video = nil
+}
+
+// stepWithin calls step as often as it can in budget, stopping early
+// once stopped reports true.
+func stepWithin(budget time.Duration, step func(), stopped func() bool) {
+	for start := time.Now(); time.Since(start) < budget && !stopped(); {
+		step()
+	}
+}
+
+// timelapsing is whether something is being recorded a frame every
+// -timelapse generations.
+func (run *windowRun) timelapsing() bool {
+	return run.config.Timelapse > 1 && (run.frames != nil || run.recorder.recording || run.video != nil)
+}
+
+// stepBoardsBy steps the boards a generation with stepSims.
+func (run *windowRun) stepBoardsBy(stepSims func()) {
+	if run.game != nil && !run.game.running() {
+		return
+	}
+	if run.replay != nil {
+		run.replay.step()
+	}
+	run.undo.stepped()
+	if run.rewound > 0 {
+		run.rewound = 0
+		run.updateTitle()
+	}
+	stepSims()
+	run.titleStale = true
+	action := run.runHooks(run.sims[0])
+	scheduled := run.schedule.run(run.sims[0].Generation)
+	run.hooksEdited = run.hooksEdited || len(action.Edits) > 0 || scheduled
+	if len(action.Edits) > 0 || scheduled {
+		run.timeline.restart()
+	} else {
+		run.timeline.stepped()
+	}
+	if action.Stop {
+		run.window.SetShouldClose(true)
+	} else if action.Pause {
+		run.setPaused(true)
+	}
+	run.events.stepped()
+	run.recordStep(run.sims[0])
+	run.clusterCount.stepped(run.sims[0])
+	run.remote.stepped(run.sims[0])
+	run.led.stepped(run.sims[0])
+	run.sound.stepped(run.sims[0])
+	run.hist.add(run.sims[0])
+	if run.stats != nil {
+		run.stats.add(run.sims[0])
+	}
+	if run.checkpoint != nil && run.sims[0].Generation%run.config.CheckpointEvery == 0 {
+		run.checkpoint.save(run.captureState(run.sims, run.cam))
+	}
+	if run.config.CensusEvery > 0 && run.sims[0].Generation%run.config.CensusEvery == 0 {
+		slog.Info("census", "generation", run.sims[0].Generation, "census", life.TakeCensus(run.cells, run.config.Wrap).String())
+	}
+	if run.config.Follow {
+		run.cam.fit(run.cells.Bounds())
+	}
+	// A time-lapse only captures every -timelapse generations, playing
+	// them back at -video-fps.
+	capture, gifRate := true, run.rate
+	if run.timelapsing() {
+		capture, gifRate = run.sims[0].Generation%run.config.Timelapse == 0, run.config.VideoFPS
+	}
+	if capture && run.recorder.recording && !run.recorder.add(run.cells, gifRate) {
+		run.stopGIF()
+	}
+	if capture && run.frames != nil {
+		run.board.upload(run.cells)
+		if err := run.frames.record(run.sc); err != nil {
+			run.stopRecording()
+		}
+	}
+	if capture && run.video != nil && run.timelapsing() {
+		run.board.upload(run.cells)
+		if err := run.video.frame(run.sc); err != nil {
+			run.status.show(err.Error())
+			run.stopVideo()
+		}
+	}
+	if run.game != nil && run.game.over() {
+		run.setPaused(true)
+		run.status.show(run.game.summary())
+	}
+}
+
+// stepBoards steps the boards a generation here and now.
+func (run *windowRun) stepBoards() {
+	run.stepBoardsBy(func() {
+		for _, sim := range run.sims {
+			sim.Step(run.config.Wrap)
+		}
+	})
+}
+
+// advance steps the boards a generation, or the replay being played one
+// step on.
+func (run *windowRun) advance() {
+	if run.joined != nil {
+		return
+	}
+	if run.playNext != nil {
+		run.playNext()
+		return
+	}
+	run.stepBoards()
+}
+
+// quit closes the window, ending the run.
+func (run *windowRun) quit() { run.window.SetShouldClose(true) }
+
+// rewind steps every board back a generation, stopping at the oldest
+// one they all still have.
+func (run *windowRun) rewind() {
+	for _, sim := range run.sims {
+		if !sim.CanRewind() {
+			return
+		}
+	}
+	for _, sim := range run.sims {
+		sim.Rewind()
+	}
+	if run.replay != nil {
+		run.replay.rewound()
+	}
+	run.events.reset()
+	run.hist.pop()
+	if run.sims[0].Generation < run.timeline.start() {
+		run.timeline.restart()
+	}
+	run.rewound++
+	run.updateTitle()
+}
+
+// seek pauses the boards and starts them seeking target. Seeking steps
+// them where nothing else sees, so it's not for a replay or a game.
+func (run *windowRun) seek(target int) {
+	if run.replay != nil || run.joined != nil || run.game != nil {
+		run.status.show("Can't seek while recording a replay, joining a host or playing versus")
+		return
+	}
+	run.setPaused(true)
+	if err := run.timeline.seek(target); err != nil {
+		run.reportError("seek failed", err, "generation", target)
+	}
+}
+
+// reseed starts every board afresh from seed.
+func (run *windowRun) reseed(seed int64) {
+	run.undo.edit(func() {
+		for i, sim := range run.sims {
+			// Compared boards keep starting from different seeds.
+			if run.config.CompareSeeds != "" {
+				sim.Reseed(seed + int64(i))
+			} else {
+				sim.Reseed(seed)
+			}
+			slog.Info("seed", "seed", sim.Seed)
+		}
+	})
+	run.events.reset()
+	for _, sim := range run.sims {
+		run.events.info(sim, "reseed", "seed", sim.Seed)
+	}
+	run.hist.reset()
+	run.hist.add(run.sims[0])
+	run.timeline.restart()
+	run.rewound = 0
+	run.updateTitle()
+}
+
+// setRule switches every board to r, which must run on the cells
+// drawn.
+func (run *windowRun) setRule(r life.Rule) error {
+	if r.Tiling() != render.Tiling {
+		return fmt.Errorf("%s runs on %s, and the board is drawn as %s", r, r.Tiling(), render.Tiling)
+	}
+	for _, sim := range run.sims {
+		sim.Rule = r
+	}
+	run.events.info(nil, "rule change", "rule", r.String())
+	run.timeline.restart()
+	return nil
+}
+
+// Copies go to the system clipboard as RLE too, and pasting prefers an
+// RLE pattern found there.
+func (run *windowRun) copySelection() {
+	if run.sc.selection.sim == nil {
+		return
+	}
+	run.clipboard = run.sc.selection.copy()
+	glfw.SetClipboardString(life.EncodeRLE(run.clipboard, run.sc.selection.sim.Rule.String()))
+}
+
+// placePattern replaces sim's board with p, centred as near (cx, cy) as
+// it fits, switching to ruleText if it names a rule.
+func (run *windowRun) placePattern(p life.Pattern, ruleText string, sim *life.Simulation, cx, cy int) error {
+	r, cx, cy, err := run.fitPattern(p, ruleText, sim, cx, cy)
+	if err != nil {
+		return err
+	}
+	if r.Tiling() != render.Tiling {
+		return fmt.Errorf("the pattern's rule, %s, runs on %s, and the board is drawn as %s", r, r.Tiling(), render.Tiling)
+	}
+	run.undo.edit(func() {
+		sim.Clear()
+		sim.Cells.Stamp(p, cx, cy, run.config.Wrap)
+	})
+	if r != sim.Rule {
+		run.events.info(sim, "rule change", "rule", r.String())
+	}
+	sim.Rule = r
+	run.events.reset()
+	run.events.info(sim, "pattern loaded", "pattern", p.Name, "x", cx, "y", cy)
+	run.setPaused(true)
+	run.hist.reset()
+	run.hist.add(run.sims[0])
+	run.timeline.restart()
+	run.status.show(fmt.Sprintf("Loaded %s (%s)", p.Name, r))
+	return nil
+}
+
+// openPattern replaces sim's board with the pattern file at path.
+func (run *windowRun) openPattern(path string, sim *life.Simulation, cx, cy int) error {
+	p, ruleText, err := run.loadPattern(path)
+	if err != nil {
+		return err
+	}
+	return run.placePattern(p, ruleText, sim, cx, cy)
+}
+
+// loadState carries on from a state file written by save.
+func (run *windowRun) loadState(path string) error {
+	st, err := run.readState(path, len(run.sims))
+	if err != nil {
+		return err
+	}
+	run.undo.edit(func() { st.restore(run.runState, run.sims, run.cam) })
+	run.events.reset()
+	run.events.info(nil, "state loaded", "path", path)
+	run.setPaused(true)
+	run.hist.reset()
+	run.hist.add(run.sims[0])
+	run.timeline.restart()
+	run.rewound = 0
+	run.updateTitle()
+	return nil
+}
+
+// updateHidden sets hidden from the state of the windows.
+func (run *windowRun) updateHidden() {
+	run.hidden = !shown(run.window)
+	for _, e := range run.extras {
+		run.hidden = run.hidden && !shown(e.window)
+	}
+}
+
+// openWindow opens another window onto the boards.
+func (run *windowRun) openWindow() error {
+	if 1+len(run.extras) >= maxWindows {
+		return fmt.Errorf("there are already %d windows", maxWindows)
+	}
+	run.opened++
+	e, err := run.openExtraWindow(1+run.opened, run.window, run.renderer, run.sims, run.views, func(sim *life.Simulation, x, y int) {
+		if run.game != nil {
+			return
+		}
+		run.undo.edit(func() {
+			sim.Cells.Set(x, y, !sim.Cells.Alive(x, y))
+		})
+		if run.config.EditPauses {
+			run.setPaused(true)
+		}
+	}, run.keys.runNamed)
+	if err != nil {
+		return err
+	}
+	e.window.SetFocusCallback(func(*glfw.Window, bool) { run.updateHidden() })
+	e.window.SetIconifyCallback(func(*glfw.Window, bool) { run.updateHidden() })
+	run.extras = append(run.extras, e)
+	return nil
+}
+
+// extrasMoving is whether any window beside this one has a camera moving.
+func (run *windowRun) extrasMoving() bool {
+	for _, e := range run.extras {
+		if e.moving() {
+			return true
+		}
+	}
+	return false
+}
+
+// saveState saves the whole state to path.
+func (run *windowRun) saveState(path string) error {
+	if err := writeState(path, run.captureState(run.sims, run.cam)); err != nil {
+		return err
+	}
+	run.events.info(nil, "state saved", "path", path)
+	return nil
+}
+
+// saveNamed keeps the state as one of the named saves.
+func (run *windowRun) saveNamed(name string, overwrite bool) (string, error) {
+	path, err := run.savePath(name)
+	if err != nil {
+		return "", err
+	}
+	if !overwrite && saveExists(path) {
+		return "", fmt.Errorf("there's already a save called %q; save! %s replaces it", name, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := run.saveState(path); err != nil {
+		return "", err
+	}
+	return "Saved " + name, nil
+}
+
+// autosave saves the state for -resume to carry on from next time.
+func (run *windowRun) autosave() {
+	path, err := autosavePath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err == nil {
+		err = run.saveState(path)
+	}
+	if err != nil {
+		run.reportError("couldn't autosave", err)
+	}
+}
+
+// slowestFrameRate returns the fewest frames a second the window is drawn
+// at while the boards step, in the background if that's slower.
+func (rs *runState) slowestFrameRate() float64 {
+	if rs.config.BackgroundFPS > 0 {
+		return min(rs.config.FrameRate, rs.config.BackgroundFPS)
+	}
+	return rs.config.FrameRate
+}
+
+// newSimulations returns a simulation per view, from its seed and rule.
+func (rs *runState) newSimulations(seeds []int64, rules []life.Rule) []*life.Simulation {
+	sims := make([]*life.Simulation, len(rules))
+	for i := range sims {
+		cells := life.NewGrid(rs.config.GridWidth, rs.config.GridHeight)
+		slog.Info("board", "seed", seeds[i], "rule", rules[i].String())
+		sims[i] = life.NewSimulation(cells, rules[i], seeds[i], rs.config.Density, max(rs.config.Rewind, 0))
+	}
+	return sims
+}
+
+// fitPattern returns the rule sim should switch to for p, given the rule
+// text from its file, and where to centre it as near (cx, cy) as it fits.
+func (rs *runState) fitPattern(p life.Pattern, ruleText string, sim *life.Simulation, cx, cy int) (life.Rule, int, int, error) {
+	width, height := p.Size()
+	if width > rs.config.GridWidth || height > rs.config.GridHeight {
+		return life.Rule{}, 0, 0, fmt.Errorf("%s is %dx%d; it needs a board at least that big, not %dx%d", p.Name, width, height, rs.config.GridWidth, rs.config.GridHeight)
+	}
+	r := sim.Rule
+	if rs.config.PatternRule && ruleText != "" {
+		var err error
+		if r, err = life.ParseRule(ruleText); err != nil {
+			return life.Rule{}, 0, 0, fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	if !rs.config.Wrap {
+		// Keep the whole pattern on the board.
+		cx = min(max(cx, width/2), rs.config.GridWidth-width+width/2)
+		cy = min(max(cy, height-1-height/2), rs.config.GridHeight-1-height/2)
+	}
+	return r, cx, cy, nil
+}
+
+// loadPath returns the state file -load names: a state file or checkpoint,
+// a directory of checkpoints, of which it's the latest, or a named save.
+func (rs *runState) loadPath(name string) (string, error) {
+	if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+		return latestCheckpoint(name)
+	}
+	if filepath.Ext(name) != "" {
+		return name, nil
+	}
+	path, err := rs.savePath(name)
+	if err == nil && !saveExists(path) {
+		err = rs.missingSave(name)
+	}
+	return path, err
+}
+
+// startsAfresh reports whether nothing says what the board starts from, so
+// it would be randomized.
+func (rs *runState) startsAfresh() bool {
+	c := rs.config
+	return c.Pattern == "" && c.Load == "" && !c.Resume && c.SeedImage == "" && c.ImportPBM == "" && !c.Edit && !c.Demo && !c.Versus && c.RenderOut == ""
+}
+
+// interval is the time between generations at the given tick rate.
+func interval(rate float64) time.Duration {
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// esVersions are the OpenGL ES versions asked for instead with -gl es.
+var esVersions = [][2]int{{3, 2}, {3, 1}, {3, 0}}
+
+// contextVersionNames returns render.ContextVersions as -gl takes them.
+func contextVersionNames() []string {
+	var names []string
+	for _, v := range render.ContextVersions {
+		names = append(names, fmt.Sprintf("%d.%d", v[0], v[1]))
+	}
+	return names
+}
+
+// initGlfw opens the window, terminating GLFW again if it can't.
+func (rs *runState) initGlfw(visible bool) (*glfw.Window, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, &WindowError{err}
+	}
+
+	if !visible {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+	glfw.WindowHint(glfw.Resizable, glfw.False)
+	if rs.config.GLVersion == "es" {
+		// Through EGL, which has ES contexts wherever it is, unlike GLX.
+		glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLESAPI)
+		glfw.WindowHint(glfw.ContextCreationAPI, glfw.EGLContextAPI)
+	} else {
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	}
+	// Drawing is sized by the framebuffer, which on a Retina display has
+	// more pixels than the window has screen coordinates.
+	glfw.WindowHint(glfw.CocoaRetinaFramebuffer, glfw.True)
+	if rs.config.GLDebug {
+		glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
+	}
+	// -print-caps asks for a transparent framebuffer to see if there is one.
+	if rs.config.PrintCaps {
+		glfw.WindowHint(glfw.TransparentFramebuffer, glfw.True)
+	}
+
+	var window *glfw.Window
+	if rs.config.Widget && visible {
+		window = rs.createWidget()
+	}
+	// The window is moved to where it was last time before it's shown.
+	placed := visible && !rs.config.Widget
+	if placed {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+	if window == nil {
+		var err error
+		window, err = rs.createWindow(rs.config.WindowWidth, rs.config.WindowHeight)
+		if err != nil {
+			if best := bestContextVersion(); best != "" && rs.config.GLVersion != "es" {
+				err = fmt.Errorf("%w; the driver offers OpenGL %s at best", err, best)
+			}
+			glfw.Terminate()
+			return nil, &WindowError{err}
+		}
+	}
+	if placed {
+		glfw.WindowHint(glfw.Visible, glfw.True)
+		rs.restoreWindowGeometry(window)
+		window.Show()
+	}
+	window.MakeContextCurrent()
+	if rs.config.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
+	return window, nil
+}
+
+// createWindow creates a window with the newest of render.ContextVersions
+// the driver has, from -gl's down, or of esVersions with -gl es. On macOS,
+// whose core contexts stop at 4.1, it starts from 4.1.
+func (rs *runState) createWindow(width, height int) (*glfw.Window, error) {
+	versions, api := rs.contextVersions()
+	var err error
+	for _, v := range versions {
+		glfw.WindowHint(glfw.ContextVersionMajor, v[0])
+		glfw.WindowHint(glfw.ContextVersionMinor, v[1])
+		var window *glfw.Window
+		if window, err = glfw.CreateWindow(width, height, "Conway's Game of Life", nil, nil); err == nil {
+			return window, nil
+		}
+	}
+	oldest := versions[len(versions)-1]
+	return nil, fmt.Errorf("no OpenGL %s %d.%d context or later: %w", api, oldest[0], oldest[1], err)
+}
+
+// contextVersions returns the versions createWindow asks for, in order,
+// and whether they're core or ES.
+func (rs *runState) contextVersions() ([][2]int, string) {
+	switch {
+	case rs.config.GLVersion == "es":
+		return esVersions, "ES"
+	case slices.Contains(contextVersionNames(), rs.config.GLVersion):
+		return render.ContextVersions[slices.Index(contextVersionNames(), rs.config.GLVersion):], "core"
+	case runtime.GOOS == "darwin":
+		return render.ContextVersions[slices.Index(render.ContextVersions, [2]int{4, 1}):], "core"
+	}
+	return render.ContextVersions, "core"
+}
+
+// bestContextVersion returns the version of the context the driver makes
+// when asked for any version at all, normally the newest it has, or "" if
+// it can't make one. It changes the hints, so it's only for once no window
+// can be made.
+func bestContextVersion() string {
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 1)
+	glfw.WindowHint(glfw.ContextVersionMinor, 0)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLAnyProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.False)
+	window, err := glfw.CreateWindow(1, 1, "", nil, nil)
+	if err != nil {
+		return ""
+	}
+	defer window.Destroy()
+	return fmt.Sprintf("%d.%d", window.GetAttrib(glfw.ContextVersionMajor), window.GetAttrib(glfw.ContextVersionMinor))
+}
+
+func (rs *runState) initOpenGL() error {
+	load := gl.Init
+	if rs.config.GLVersion == "es" {
+		// The bindings are desktop OpenGL's, but the functions they share with
+		// ES, all that's called in an ES context, have the same names. EGL
+		// looks them up, and on Mesa the desktop-only ones the bindings insist
+		// on as well.
+		load = func() error { return gl.InitWithProcAddrFunc(glfw.GetProcAddress) }
+	}
+	if err := load(); err != nil {
+		context := glfw.GetCurrentContext()
+		major, minor := context.GetAttrib(glfw.ContextVersionMajor), context.GetAttrib(glfw.ContextVersionMinor)
+		return &GLError{fmt.Errorf("OpenGL %d.%d context: %w", major, minor, err)}
+	}
+	version := gl.GoStr(gl.GetString(gl.VERSION))
+	major, minor := render.ContextVersion()
+	api := "core"
+	if render.ES() {
+		api = "ES"
+	}
+	slog.Info("OpenGL", "version", version, "context", fmt.Sprintf("%d.%d %s", major, minor, api), "shaders", strings.ReplaceAll(render.GLSLVersion(), "\n", " "))
+	features := chooseFeatures(&rs.config, render.CurrentCaps(), windowCaps{
+		debugContext: render.DebugContext(),
+		transparent:  glfw.GetCurrentContext().GetAttrib(glfw.TransparentFramebuffer) == glfw.True,
+	})
+	warnFeatures(features)
+	if featureOn(features, "-gl-debug") {
+		render.EnableDebugOutput(rs.config.GLDebugSeverity, featureOn(features, "-gl-debug-panic"))
+	}
+	return nil
+}
+
+// shown reports whether window is focused and not iconified.
+func shown(window *glfw.Window) bool {
+	return window.GetAttrib(glfw.Focused) == glfw.True && window.GetAttrib(glfw.Iconified) == glfw.False
+}
+
+// cursorNDC returns the cursor position in normalized device coordinates.
+// Cursor positions and the window size are both in screen coordinates, so the
+// result is independent of the monitor's content scale.
+func cursorNDC(window *glfw.Window) (float32, float32) {
+	x, y := window.GetCursorPos()
+	w, h := window.GetSize()
+	return float32(2*x/float64(w) - 1), float32(1 - 2*y/float64(h))
+}
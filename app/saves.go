@@ -0,0 +1,142 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// savesPath returns the directory named saves are kept in.
+func (rs *runState) savesPath() (string, error) {
+	if rs.config.SavesDir != "" {
+		return rs.config.SavesDir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "saves"), nil
+}
+
+// savePath returns the file a named save is kept in. Anything in the name
+// but letters, digits, - and _ is replaced, so a name can't reach outside
+// the saves directory.
+func (rs *runState) savePath(name string) (string, error) {
+	safe := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(safe, "_") == "" {
+		return "", fmt.Errorf("invalid save name %q", name)
+	}
+	dir, err := rs.savesPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safe+".json"), nil
+}
+
+// saveInfo describes a named save, for listing.
+type saveInfo struct {
+	name          string
+	columns, rows int
+	generation    int
+	population    int
+	saved         time.Time
+}
+
+// listSaves describes the saves in the saves directory, by name. Files that
+// can't be read are left out.
+func (rs *runState) listSaves() ([]saveInfo, error) {
+	dir, err := rs.savesPath()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var saves []saveInfo
+	for _, path := range paths {
+		st, err := decodeState(path)
+		if err != nil || len(st.Boards) == 0 {
+			continue
+		}
+		info := saveInfo{
+			name:       strings.TrimSuffix(filepath.Base(path), ".json"),
+			columns:    st.Columns,
+			rows:       st.Rows,
+			generation: st.Boards[0].Generation,
+		}
+		if fi, err := os.Stat(path); err == nil {
+			info.saved = fi.ModTime()
+		}
+		for _, b := range st.Boards {
+			info.population += b.population()
+		}
+		saves = append(saves, info)
+	}
+	return saves, nil
+}
+
+func (b boardState) population() int {
+	n := 0
+	for _, c := range b.Cells {
+		n += bits.OnesCount8(c)
+	}
+	return n
+}
+
+// missingSave explains that there's no save with the given name, suggesting
+// any with similar names.
+func (rs *runState) missingSave(name string) error {
+	saves, _ := rs.listSaves()
+	var close []string
+	for _, s := range saves {
+		if strings.Contains(s.name, name) || strings.Contains(name, s.name) || editDistance(s.name, name) <= 2 {
+			close = append(close, s.name)
+		}
+	}
+	if len(close) == 0 {
+		return fmt.Errorf("no save called %q; saves lists them", name)
+	}
+	sort.Strings(close)
+	return fmt.Errorf("no save called %q; did you mean %s?", name, strings.Join(close, ", "))
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// saveExists reports whether path exists, treating errors other than its
+// not existing as though it did, so nothing is overwritten in doubt.
+func saveExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"opengl/life"
+)
+
+// A scenario file is a JSON list of console commands to run at given
+// generations, e.g.
+//
+//	[
+//		{"at": 0, "do": "stamp gosper-gun 10 10"},
+//		{"at": 500, "do": "rule B36/S23"},
+//		{"at": 1000, "do": "stamp glider 40 40 nw"},
+//		{"at": 2000, "do": "save end.json"},
+//		{"at": 2000, "do": "quit"}
+//	]
+//
+// Commands at the same generation run in the order they're listed. Only
+// boardCommands, which work with or without a window, can be scheduled.
+
+type scenarioEvent struct {
+	At int    `json:"at"`
+	Do string `json:"do"`
+}
+
+// scenario runs a scenario file's commands as the first board reaches
+// their generations. Its methods do nothing on a nil scenario, for runs
+// without one.
+type scenario struct {
+	name   string
+	events []scenarioEvent
+	next   int
+	// commands runs the events, as the console would.
+	commands *commandLine
+}
+
+// loadScenario reads the scenario file at path, checking every command
+// against the run's board so that mistakes are found before it starts. Its
+// commands are left for the loop that owns the boards to set.
+func (rs *runState) loadScenario(path string) (*scenario, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &scenario{name: filepath.Base(path)}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s.events); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+	for i, e := range s.events {
+		if e.At < 0 {
+			return nil, fmt.Errorf("%s: event %d: generation %d is before the start", s.name, i+1, e.At)
+		}
+		fields := strings.Fields(e.Do)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%s: event %d: no command", s.name, i+1)
+		}
+		bc, ok := boardCommands[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s: event %d: unknown command %q: want %s", s.name, i+1, fields[0], strings.Join(boardCommandNames(), ", "))
+		}
+		if _, err := bc.parse(fields[1:], rs); err != nil {
+			return nil, fmt.Errorf("%s: event %d: %v (usage: %s)", s.name, i+1, err, bc.usage)
+		}
+	}
+	sort.SliceStable(s.events, func(i, j int) bool { return s.events[i].At < s.events[j].At })
+	return s, nil
+}
+
+// begin skips the events before gen, the generation the first board starts
+// at, and runs those at it.
+func (s *scenario) begin(gen int) {
+	if s == nil {
+		return
+	}
+	for s.next < len(s.events) && s.events[s.next].At < gen {
+		s.next++
+	}
+	s.run(gen)
+}
+
+// run runs the events due by gen, the first board's generation, reporting
+// whether there were any.
+func (s *scenario) run(gen int) bool {
+	if s == nil {
+		return false
+	}
+	ran := false
+	for ; s.next < len(s.events) && s.events[s.next].At <= gen; s.next++ {
+		ran = true
+		out, err := s.commands.execute(s.events[s.next].Do)
+		switch {
+		case err != nil:
+			slog.Warn("scenario command failed", "scenario", s.name, "generation", gen, "err", err)
+		case out != "":
+			slog.Info(out, "scenario", s.name, "generation", gen)
+		}
+	}
+	return ran
+}
+
+// boardCommand is a console command that only needs the boards, and so
+// works with or without a window. parse checks args for a board configured
+// by c, returning what running the command does.
+type boardCommand struct {
+	usage string
+	parse func(args []string, rs *runState) (func(c *boardControls) (string, error), error)
+}
+
+// boardCommands are the commands every console has, and the ones a scenario
+// can run. The window's console replaces some with its own, which do more.
+var boardCommands = map[string]boardCommand{
+	"stamp": {"stamp glider 40 40 [ne|nw|se|sw]", parseStamp},
+	"rule": {"rule B3/S23", func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+		if len(args) != 1 {
+			return nil, errors.New("want one rule")
+		}
+		r, err := life.ParseRule(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(c *boardControls) (string, error) {
+			if err := c.setRule(r); err != nil {
+				return "", err
+			}
+			return "Rule " + r.String(), nil
+		}, nil
+	}},
+	"seed": {"seed 42", func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+		if len(args) != 1 {
+			return nil, errors.New("want one seed")
+		}
+		seed, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(c *boardControls) (string, error) {
+			c.reseed(seed)
+			return "", nil
+		}, nil
+	}},
+	"pause":  {"pause", parseSetPaused(true)},
+	"resume": {"resume", parseSetPaused(false)},
+	"save": {"save end.json", func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+		if len(args) != 1 || !isStateFile(args[0]) {
+			return nil, errors.New("want one .json or .lifez state file")
+		}
+		path := args[0]
+		return func(c *boardControls) (string, error) {
+			if err := writeState(path, c.rs.captureState(c.sims, c.rs.newCamera())); err != nil {
+				return "", err
+			}
+			return "Saved " + path, nil
+		}, nil
+	}},
+	"quit": {"quit", func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+		if len(args) != 0 {
+			return nil, errors.New("takes no arguments")
+		}
+		return func(c *boardControls) (string, error) {
+			c.stop()
+			return "", nil
+		}, nil
+	}},
+}
+
+func parseSetPaused(paused bool) func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+	return func(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+		if len(args) != 0 {
+			return nil, errors.New("takes no arguments")
+		}
+		return func(c *boardControls) (string, error) {
+			c.setPaused(paused)
+			return "", nil
+		}, nil
+	}
+}
+
+// parseStamp parses a stamp command, which draws a pattern file or built-in
+// pattern centred on a cell of the first board. A heading turns the pattern
+// as it's drawn, taken as heading se, the way the built-in glider does, to
+// head the way given.
+func parseStamp(args []string, rs *runState) (func(c *boardControls) (string, error), error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errors.New("want a pattern, a cell and optionally a heading")
+	}
+	p, _, err := rs.loadPattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	x, errX := strconv.Atoi(args[1])
+	y, errY := strconv.Atoi(args[2])
+	if errX != nil || errY != nil {
+		return nil, fmt.Errorf("invalid cell %s %s", args[1], args[2])
+	}
+	if x < 0 || x >= rs.config.GridWidth || y < 0 || y >= rs.config.GridHeight {
+		return nil, fmt.Errorf("cell %d,%d is off the %dx%d board", x, y, rs.config.GridWidth, rs.config.GridHeight)
+	}
+	if len(args) == 4 {
+		switch strings.ToLower(args[3]) {
+		case "se":
+		case "sw":
+			p = p.FlipX()
+		case "ne":
+			p = p.FlipY()
+		case "nw":
+			p = p.FlipX().FlipY()
+		default:
+			return nil, fmt.Errorf("invalid heading %q: want ne, nw, se or sw", args[3])
+		}
+	}
+	columns, rows, wrap := rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap
+	if cells := p.Placed(x, y, columns, rows, wrap); !wrap && len(cells) < len(p.Cells) {
+		return nil, fmt.Errorf("%s at %d,%d runs off the %dx%d board", p.Name, x, y, columns, rows)
+	}
+	return func(c *boardControls) (string, error) {
+		c.edit(func() {
+			for _, cell := range p.Placed(x, y, columns, rows, wrap) {
+				c.sims[0].Cells.Set(cell[0], cell[1], true)
+			}
+		})
+		return fmt.Sprintf("Stamped %s at %d,%d", p.Name, x, y), nil
+	}, nil
+}
+
+func boardCommandNames() []string {
+	var names []string
+	for name := range boardCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addBoardCommands adds boardCommands to cl, run on c.
+func (rs *runState) addBoardCommands(cl *commandLine, c *boardControls) {
+	for name, bc := range boardCommands {
+		bc := bc
+		cl.add(name, bc.usage, func(args []string) (string, error) {
+			run, err := bc.parse(args, rs)
+			if err != nil {
+				return "", err
+			}
+			return run(c)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// TestScenarioHeadless runs a scenario like examples/scenarios/guns.json
+// headless, and checks the board it ends on against one put through the
+// same by hand.
+func TestScenarioHeadless(t *testing.T) {
+	dir := t.TempDir()
+	end := filepath.Join(dir, "end.json")
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Density, cfg.Seed = 100, 100, 0, 1
+	cfg.Scenario = filepath.Join(dir, "guns.json")
+	script := `[
+		{"at": 0, "do": "stamp gosper-gun 30 80"},
+		{"at": 300, "do": "stamp glider 60 40 nw"},
+		{"at": 500, "do": "rule B36/S23"},
+		{"at": 1000, "do": "save ` + filepath.ToSlash(end) + `"},
+		{"at": 1000, "do": "quit"}
+	]`
+	if err := os.WriteFile(cfg.Scenario, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Headless = true
+	rs := testRun(t, cfg)
+	var err error
+	if rs.schedule, err = rs.loadScenario(cfg.Scenario); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	rs.stdout = &out
+	_, seeds, rules, _ := rs.config.boards()
+	if err := rs.runHeadless(seeds, rules, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sim := life.NewSimulation(life.NewGrid(100, 100), life.Conway, 1, 0, 0)
+	stamp := func(p life.Pattern, x, y int) {
+		for _, c := range p.Placed(x, y, 100, 100, false) {
+			sim.Cells.Set(c[0], c[1], true)
+		}
+	}
+	stamp(life.LibraryPattern("gosper-gun"), 30, 80)
+	for sim.Generation < 1000 {
+		sim.Step(false)
+		switch sim.Generation {
+		case 300:
+			stamp(life.LibraryPattern("glider").FlipX().FlipY(), 60, 40)
+		case 500:
+			sim.Rule, _ = life.ParseRule("B36/S23")
+		}
+	}
+	if want := summary(1, sim); out.String() != want {
+		t.Errorf("the scenario ended with %q, want %q", out.String(), want)
+	}
+	st, err := rs.readState(end, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Boards[0].Generation != 1000 || st.Boards[0].Rule != "B36/S23" {
+		t.Errorf("the scenario saved generation %d under %s, want 1000 under B36/S23", st.Boards[0].Generation, st.Boards[0].Rule)
+	}
+}
+
+// TestLoadScenarioRejects checks mistakes are found when the scenario's
+// loaded, before the run starts.
+func TestLoadScenarioRejects(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 50, 40
+	rs := testRun(t, cfg)
+	for _, c := range []struct{ src, want string }{
+		{`[{"at": 0, "do": "explode 3 3"}]`, `s.json: event 1: unknown command "explode": want pause, quit, resume, rule, save, seed, stamp`},
+		{`[{"at": 0, "do": "pause"}, {"at": 5, "do": "stamp glider 50 10"}]`, "s.json: event 2: cell 50,10 is off the 50x40 board (usage: stamp glider 40 40 [ne|nw|se|sw])"},
+		{`[{"at": 0, "do": "stamp glider 0 0"}]`, "glider at 0,0 runs off the 50x40 board"},
+		{`[{"at": 0, "do": "stamp glider 10 10 up"}]`, `invalid heading "up"`},
+		{`[{"at": 0, "do": "stamp nothing-at-all 10 10"}]`, "s.json: event 1: "},
+		{`[{"at": 0, "do": "rule B9"}]`, "s.json: event 1: "},
+		{`[{"at": 0, "do": "save end.png"}]`, "want one .json or .lifez state file"},
+		{`[{"at": -1, "do": "quit"}]`, "s.json: event 1: generation -1 is before the start"},
+		{`[{"at": 0, "do": "  "}]`, "s.json: event 1: no command"},
+		{`[{"at": 0, "run": "quit"}]`, `s.json: json: unknown field "run"`},
+	} {
+		path := filepath.Join(t.TempDir(), "s.json")
+		if err := os.WriteFile(path, []byte(c.src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rs.loadScenario(path); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: loaded with %v, want an error containing %q", c.src, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"image"
+	"path/filepath"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+	"opengl/render"
+)
+
+// overlay is anything drawn in screen space on top of the board.
+type overlay interface {
+	draw()
+}
+
+// presentation replaces the flat, top-down view of the board.
+type presentation interface {
+	update(dt float64)
+	draw(cells life.Grid)
+}
+
+// scene is everything that goes into a frame: the boards, how they're laid
+// out and viewed, and the overlays on top.
+type scene struct {
+	rs *runState
+
+	sims      []*life.Simulation
+	views     layout
+	renderer  render.Renderer
+	brush     *brush
+	selection *selection
+	cursor    *editCursor
+	grid      *grid
+	cam       *camera
+	view      presentation
+	overlays  []overlay
+	// recorded are the overlays drawn into recordings, too.
+	recorded []overlay
+}
+
+// render draws a frame into the currently bound framebuffer, which is
+// fbWidth by fbHeight pixels. It only draws: showing the frame, by swapping
+// a window's buffers, and taking input are up to the loop, so the same
+// frame can be drawn into a window or offscreen.
+func (s *scene) render(fbWidth, fbHeight int) {
+	gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	for i, sim := range s.sims {
+		gl.Viewport(s.views.viewport(i, fbWidth, fbHeight))
+		s.drawBoard(sim.Cells)
+		if s.view == nil {
+			s.grid.draw(s.cam)
+			s.selection.draw(sim, s.cam)
+			s.cursor.draw(sim, s.cam)
+			s.brush.drawOutline(sim, s.cam)
+		}
+	}
+	gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+
+	for _, o := range s.overlays {
+		o.draw()
+	}
+}
+
+// capture renders a single frame offscreen at the given size.
+func (s *scene) capture(width, height int) (*image.RGBA, error) {
+	target, err := render.NewTarget(width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Delete()
+
+	target.Bind()
+	s.render(width, height)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return target.Read(), nil
+}
+
+// recordFrame renders the boards into t with only the recorded overlays, so
+// notices and the like stay out of recordings, and reads them back.
+func (s *scene) recordFrame(t *render.Target) *image.RGBA {
+	overlays := s.overlays
+	s.overlays = s.recorded
+	t.Bind()
+	s.render(t.Width, t.Height)
+	s.overlays = overlays
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return t.Read()
+}
+
+// renderToFile renders a single frame offscreen at the given size and saves
+// it as a PNG.
+func (s *scene) renderToFile(path string, width, height int) error {
+	img, err := s.capture(width, height)
+	if err != nil {
+		return err
+	}
+	return render.SavePNG(path, img)
+}
+
+func (s *scene) drawBoard(cells life.Grid) {
+	if s.view != nil {
+		s.view.draw(cells)
+		return
+	}
+
+	if s.rs.wireframe && render.CurrentCaps().PolygonMode {
+		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		defer gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	}
+	if err := s.renderer.DrawFrame(cells, render.View{Projection: s.cam.projection(), Zoom: s.cam.zoom}); err != nil {
+		s.rs.reportError("drawing the board failed", err)
+	}
+}
+
+// screenshot captures the current frame at scale times the window's
+// framebuffer resolution, returning it with the timestamped path in dir to
+// save it to.
+func (s *scene) screenshot(window *glfw.Window, scale int, dir string) (string, *image.RGBA, error) {
+	fbWidth, fbHeight := window.GetFramebufferSize()
+	path := filepath.Join(dir, time.Now().Format("screenshot-20060102-150405.000.png"))
+	img, err := s.capture(scale*fbWidth, scale*fbHeight)
+	return path, img, err
+}
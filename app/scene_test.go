@@ -0,0 +1,96 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// mockRenderer is a render.Renderer that keeps what it's asked to draw, for
+// testing the loops that draw without a GL context.
+type mockRenderer struct {
+	columns, rows int
+	width, height int
+	frames        []mockFrame
+	err           error
+	shutdown      bool
+}
+
+type mockFrame struct {
+	cells life.Grid
+	view  render.View
+}
+
+func (m *mockRenderer) Init(columns, rows int) error {
+	m.columns, m.rows = columns, rows
+	return nil
+}
+
+func (m *mockRenderer) DrawFrame(cells life.Grid, view render.View) error {
+	m.frames = append(m.frames, mockFrame{cells.Clone(), view})
+	return m.err
+}
+
+func (m *mockRenderer) Resize(width, height int) { m.width, m.height = width, height }
+
+func (m *mockRenderer) Shutdown() { m.shutdown = true }
+
+var _ render.Renderer = (*mockRenderer)(nil)
+
+// TestSceneDrawsThroughRenderer checks the scene hands its renderer each
+// board, with the cells' ages for colouring by, and the camera's view.
+func TestSceneDrawsThroughRenderer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 12, 10
+	rs := testRun(t, cfg)
+	sims := testSims(t, rs, 1, 2)
+	stepAll(rs, sims, 3)
+	mock := &mockRenderer{}
+	cam := rs.newCamera()
+	cam.x, cam.y, cam.zoom = 0.25, -0.5, 3
+	s := &scene{rs: rs, sims: sims, renderer: mock, cam: cam}
+	for _, sim := range sims {
+		s.drawBoard(sim.Cells)
+	}
+
+	if len(mock.frames) != 2 {
+		t.Fatalf("the renderer drew %d frames, want one per board", len(mock.frames))
+	}
+	for i, f := range mock.frames {
+		if !f.cells.Same(sims[i].Cells) {
+			t.Errorf("frame %d isn't board %d", i, i)
+		}
+		if f.view.Zoom != 3 || f.view.Projection != [16]float32(cam.projection()) {
+			t.Errorf("frame %d was seen through %v, want the camera's", i, f.view)
+		}
+	}
+	aged := false
+	for x := 0; x < 12; x++ {
+		for y := 0; y < 10; y++ {
+			if c := mock.frames[0].cells.At(x, y); c.Alive && c.Age != sims[0].Cells.At(x, y).Age {
+				t.Fatalf("cell %d,%d drawn at age %d, want %d", x, y, c.Age, sims[0].Cells.At(x, y).Age)
+			} else if c.Age > 1 {
+				aged = true
+			}
+		}
+	}
+	if !aged {
+		t.Error("no cell drawn has lived more than a generation")
+	}
+
+	// A renderer failing is reported, and the loop carries on.
+	reports := make(chan errorReport, 1)
+	rs.errorReports = reports
+	mock.err = errors.New("lost the device")
+	s.drawBoard(sims[0].Cells)
+	select {
+	case r := <-reports:
+		if r.err != mock.err {
+			t.Errorf("reported %v, want the renderer's error", r.err)
+		}
+	default:
+		t.Error("the renderer's error wasn't reported")
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"opengl/life"
+)
+
+// imageOptions are how an image is turned into a board.
+type imageOptions struct {
+	threshold float64
+	dither    bool
+	invert    bool
+	letterbox bool
+}
+
+// imageToBoard scales img to a board of columns by rows cells and decides
+// which are alive, returning them a row at a time from the top. Each cell
+// takes the mean luminance of the pixels it covers. An image of a different
+// shape is cropped about its centre to fill the board or, letterboxed,
+// scaled to fit inside it with dead cells around it.
+func imageToBoard(img image.Image, columns, rows int, opts imageOptions) []bool {
+	bounds := img.Bounds()
+	// The board shows the source rectangle src, which is the whole image
+	// when letterboxing, and it lands on the cells in dst.
+	src := bounds
+	dst := image.Rect(0, 0, columns, rows)
+	wide := bounds.Dx()*rows > bounds.Dy()*columns
+	switch {
+	case opts.letterbox && wide:
+		h := max(1, columns*bounds.Dy()/bounds.Dx())
+		dst = image.Rect(0, (rows-h)/2, columns, (rows-h)/2+h)
+	case opts.letterbox:
+		w := max(1, rows*bounds.Dx()/bounds.Dy())
+		dst = image.Rect((columns-w)/2, 0, (columns-w)/2+w, rows)
+	case wide:
+		w := bounds.Dy() * columns / rows
+		src = image.Rect(bounds.Min.X+(bounds.Dx()-w)/2, bounds.Min.Y, bounds.Min.X+(bounds.Dx()-w)/2+w, bounds.Max.Y)
+	default:
+		h := bounds.Dx() * rows / columns
+		src = image.Rect(bounds.Min.X, bounds.Min.Y+(bounds.Dy()-h)/2, bounds.Max.X, bounds.Min.Y+(bounds.Dy()-h)/2+h)
+	}
+
+	lum := make([]float64, columns*rows)
+	for y := dst.Min.Y; y < dst.Max.Y; y++ {
+		for x := dst.Min.X; x < dst.Max.X; x++ {
+			x0 := src.Min.X + (x-dst.Min.X)*src.Dx()/dst.Dx()
+			x1 := max(x0+1, src.Min.X+(x-dst.Min.X+1)*src.Dx()/dst.Dx())
+			y0 := src.Min.Y + (y-dst.Min.Y)*src.Dy()/dst.Dy()
+			y1 := max(y0+1, src.Min.Y+(y-dst.Min.Y+1)*src.Dy()/dst.Dy())
+			sum := 0.0
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, _ := img.At(px, py).RGBA()
+					sum += (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+				}
+			}
+			l := sum / float64((x1-x0)*(y1-y0))
+			if opts.invert {
+				l = 1 - l
+			}
+			lum[y*columns+x] = l
+		}
+	}
+
+	alive := make([]bool, columns*rows)
+	for y := dst.Min.Y; y < dst.Max.Y; y++ {
+		for x := dst.Min.X; x < dst.Max.X; x++ {
+			i := y*columns + x
+			alive[i] = lum[i] > opts.threshold
+			if !opts.dither {
+				continue
+			}
+			// Floyd-Steinberg: pass the rounding error on to the cells
+			// still to come.
+			err := lum[i]
+			if alive[i] {
+				err--
+			}
+			spread := func(dx, dy int, weight float64) {
+				if nx, ny := x+dx, y+dy; nx >= dst.Min.X && nx < dst.Max.X && ny < dst.Max.Y {
+					lum[ny*columns+nx] += err * weight
+				}
+			}
+			spread(1, 0, 7.0/16)
+			spread(-1, 1, 3.0/16)
+			spread(0, 1, 5.0/16)
+			spread(1, 1, 1.0/16)
+		}
+	}
+	return alive
+}
+
+// loadSeedImage sets sim's board from the -seed-image file.
+func (rs *runState) loadSeedImage(sim *life.Simulation, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	alive := imageToBoard(img, rs.config.GridWidth, rs.config.GridHeight, imageOptions{
+		threshold: rs.config.SeedThreshold,
+		dither:    rs.config.SeedDither,
+		invert:    rs.config.SeedInvert,
+		letterbox: rs.config.SeedFit == "letterbox",
+	})
+	sim.Clear()
+	for i, a := range alive {
+		if a {
+			sim.Cells.Set(i%rs.config.GridWidth, rs.config.GridHeight-1-i/rs.config.GridWidth, true)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// testImage draws rows of a picture, # white and . black, as an image.
+func testImage(rows ...string) image.Image {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				img.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	return img
+}
+
+// boardRows draws what imageToBoard returns the same way.
+func boardRows(alive []bool, columns int) string {
+	var b strings.Builder
+	for i, a := range alive {
+		if i > 0 && i%columns == 0 {
+			b.WriteByte('/')
+		}
+		if a {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func TestImageToBoard(t *testing.T) {
+	for _, c := range []struct {
+		name          string
+		img           image.Image
+		columns, rows int
+		opts          imageOptions
+		want          string
+	}{
+		{"same size", testImage("#..#", ".##."), 4, 2, imageOptions{threshold: 0.5}, "#..#/.##."},
+		{"inverted", testImage("#..#", ".##."), 4, 2, imageOptions{threshold: 0.5, invert: true}, ".##./#..#"},
+		// Each cell averages the 2x2 pixels it covers: 1, 3/4, 1/4 and 0.
+		{"scaled down", testImage("####...#", "###....."), 4, 1, imageOptions{threshold: 0.5}, "##.."},
+		{"threshold", testImage("####...#", "###....."), 4, 1, imageOptions{threshold: 0.8}, "#..."},
+		// A wide image is cropped to its middle, a tall one likewise.
+		{"cropped wide", testImage("#..#...#", "#..#...#"), 2, 2, imageOptions{threshold: 0.5}, "#./#."},
+		{"cropped tall", testImage("#", ".", "#", "#", ".", "."), 1, 2, imageOptions{threshold: 0.5}, "#/#"},
+		// Letterboxed, it fits inside the board with dead cells around it.
+		{"letterboxed wide", testImage("########", "########"), 4, 4, imageOptions{threshold: 0.5, letterbox: true}, "..../####/..../...."},
+		{"letterboxed tall", testImage("##", "##", "##", "##"), 4, 2, imageOptions{threshold: 0.5, letterbox: true}, ".#../.#.."},
+	} {
+		if got := boardRows(imageToBoard(c.img, c.columns, c.rows, c.opts), c.columns); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+// TestImageToBoardDithers checks a mid grey comes out half alive when
+// dithered, where the threshold alone makes it all one or the other.
+func TestImageToBoardDithers(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 32, 32))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	count := func(opts imageOptions) int {
+		n := 0
+		for _, a := range imageToBoard(img, 32, 32, opts) {
+			if a {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(imageOptions{threshold: 0.5}); n != 32*32 {
+		t.Errorf("undithered, %d of the grey's cells are alive, want all", n)
+	}
+	if n := count(imageOptions{threshold: 0.5, dither: true}); n < 480 || n > 544 {
+		t.Errorf("dithered, %d of the grey's 1024 cells are alive, want about half", n)
+	}
+}
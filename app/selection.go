@@ -0,0 +1,83 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// selection is a rectangle of cells on one board, marked by dragging from
+// one corner cell to the other.
+type selection struct {
+	rs *runState
+
+	sim            *life.Simulation
+	x0, y0, x1, y1 int
+
+	program *overlayProgram
+	outline *lines
+}
+
+func (rs *runState) newSelection(program *overlayProgram) *selection {
+	return &selection{rs: rs, program: program, outline: rs.newLines("selection outline", 4)}
+}
+
+// start begins a new selection at cell (x, y) on sim.
+func (s *selection) start(sim *life.Simulation, x, y int) {
+	s.sim = sim
+	s.x0, s.y0, s.x1, s.y1 = x, y, x, y
+}
+
+// extend moves the selection's far corner to cell (x, y).
+func (s *selection) extend(sim *life.Simulation, x, y int) {
+	if sim == s.sim {
+		s.x1, s.y1 = x, y
+	}
+}
+
+func (s *selection) clear() {
+	s.sim = nil
+}
+
+// bounds returns the selected cells' inclusive range.
+func (s *selection) bounds() (minX, minY, maxX, maxY int) {
+	return min(s.x0, s.x1), min(s.y0, s.y1), max(s.x0, s.x1), max(s.y0, s.y1)
+}
+
+// copy returns the live cells in the selection as a pattern.
+func (s *selection) copy() life.Pattern {
+	p := life.Pattern{Name: "clipboard"}
+	if s.sim == nil {
+		return p
+	}
+	minX, minY, maxX, maxY := s.bounds()
+	p.Cells = s.sim.Cells.Region(minX, minY, maxX, maxY).Cells
+	return p
+}
+
+// cut kills every cell in the selection.
+func (s *selection) cut() {
+	if s.sim == nil {
+		return
+	}
+	minX, minY, maxX, maxY := s.bounds()
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			s.sim.Cells.Set(x, y, false)
+		}
+	}
+}
+
+// draw outlines the selection if it is on sim. It expects the viewport to
+// be set to sim's view.
+func (s *selection) draw(sim *life.Simulation, cam *camera) {
+	if s.sim != sim {
+		return
+	}
+	minX, minY, maxX, maxY := s.bounds()
+	x0, y0 := s.rs.cellCorner(cam, minX, minY)
+	x1, y1 := s.rs.cellCorner(cam, maxX+1, maxY+1)
+	s.outline.reset()
+	s.outline.rect(x0, y0, x1, y1)
+	s.program.use(0.3, 0.7, 1, 1)
+	s.outline.draw(gl.LINE_LOOP)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// TestCopyPasteRoundTrips copies a selection as the clipboard gets it,
+// pastes it back as copied elsewhere with comments and whitespace round
+// it, and stamps it on another board where the selection was.
+func TestCopyPasteRoundTrips(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 40, 30
+	rs := testRun(t, cfg)
+	for _, seed := range []int64{1, 2, 3} {
+		sim := testSims(t, rs, seed)[0]
+		const minX, minY, maxX, maxY = 5, 4, 24, 17
+		// Live corners keep the pattern the selection's size.
+		for _, c := range [][2]int{{minX, minY}, {maxX, maxY}} {
+			sim.Cells.Set(c[0], c[1], true)
+		}
+		s := &selection{rs: rs}
+		s.start(sim, maxX, minY)
+		s.extend(sim, minX, maxY)
+		copied := s.copy()
+		rle := life.EncodeRLE(copied, sim.Rule.String())
+		if header := fmt.Sprintf("x = %d, y = %d, rule = %s\n", maxX-minX+1, maxY-minY+1, sim.Rule); !strings.Contains(rle, header) {
+			t.Fatalf("copied RLE has no header %q:\n%s", header, rle)
+		}
+
+		pasted, rule, err := life.ParsePattern("\n  #C copied from a wiki\r\n#O someone\n"+strings.ReplaceAll(rle, "\n", "\r\n")+"\n\n", cfg.PatternLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rule != sim.Rule.String() {
+			t.Fatalf("pasted rule %q, want %q", rule, sim.Rule)
+		}
+		w, h := pasted.Size()
+		board := life.NewGrid(40, 30)
+		board.Stamp(pasted, minX+w/2, maxY-h/2, false)
+		for x := 0; x < 40; x++ {
+			for y := 0; y < 30; y++ {
+				inside := x >= minX && x <= maxX && y >= minY && y <= maxY
+				if want := inside && sim.Cells.Alive(x, y); board.Alive(x, y) != want {
+					t.Fatalf("seed %d: cell %d, %d pasted back %v, want %v", seed, x, y, board.Alive(x, y), want)
+				}
+			}
+		}
+	}
+}
+
+func TestCut(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 20, 20
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 1)[0]
+	before := sim.Cells.Population() - len(sim.Cells.Region(2, 3, 9, 12).Cells)
+	s := &selection{rs: rs}
+	s.start(sim, 2, 12)
+	s.extend(sim, 9, 3)
+	s.cut()
+	if got := sim.Cells.Population(); got != before || len(s.copy().Cells) != 0 {
+		t.Fatalf("cutting left %d cells, want the %d outside the selection", got, before)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"opengl/life"
+)
+
+// tool is what dragging the left mouse button over the board does.
+type tool int
+
+const (
+	freehand tool = iota
+	lineTool
+	rectTool
+	filledRectTool
+	ellipseTool
+)
+
+var toolNames = [...]string{"freehand", "line", "rectangle", "filled rectangle", "ellipse"}
+
+func (t tool) String() string {
+	return toolNames[t]
+}
+
+// rasterize returns the cells of the shape t draws between the corners
+// (x0, y0) and (x1, y1), leaving out any that fall off a board of the given
+// size. Each cell appears once.
+func (t tool) rasterize(x0, y0, x1, y1, columns, rows int) [][2]int {
+	var cells [][2]int
+	seen := make(map[[2]int]bool)
+	plot := func(x, y int) {
+		c := [2]int{x, y}
+		if x < 0 || y < 0 || x >= columns || y >= rows || seen[c] {
+			return
+		}
+		seen[c] = true
+		cells = append(cells, c)
+	}
+	switch t {
+	case freehand, lineTool:
+		line(x0, y0, x1, y1, plot)
+	case rectTool, filledRectTool:
+		rect(x0, y0, x1, y1, t == filledRectTool, plot)
+	case ellipseTool:
+		ellipse(x0, y0, x1, y1, plot)
+	}
+	return cells
+}
+
+// rect calls plot for every cell on the edge of the rectangle with corners
+// (x0, y0) and (x1, y1), or every cell inside it too if filled.
+func rect(x0, y0, x1, y1 int, filled bool, plot func(x, y int)) {
+	x0, x1 = min(x0, x1), max(x0, x1)
+	y0, y1 = min(y0, y1), max(y0, y1)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			if filled || x == x0 || x == x1 || y == y0 || y == y1 {
+				plot(x, y)
+			}
+		}
+	}
+}
+
+// ellipse calls plot for every cell on the outline of the ellipse that fits
+// the rectangle with corners (x0, y0) and (x1, y1), using the midpoint
+// algorithm. Working from the bounding box rather than a centre and radii
+// lets it draw ellipses an even number of cells across.
+func ellipse(x0, y0, x1, y1 int, plot func(x, y int)) {
+	a, b := abs(x1-x0), abs(y1-y0)
+	b1 := b & 1
+	dx, dy := 4*(1-a)*b*b, 4*(b1+1)*a*a
+	err := dx + dy + b1*a*a
+	if x0 > x1 {
+		x0, x1 = x1, x1+a
+	}
+	if y0 > y1 {
+		y0 = y1
+	}
+	y0 += (b + 1) / 2
+	y1 = y0 - b1
+	a *= 8 * a
+	b1 = 8 * b * b
+	for x0 <= x1 {
+		plot(x1, y0)
+		plot(x0, y0)
+		plot(x0, y1)
+		plot(x1, y1)
+		e2 := 2 * err
+		if e2 <= dy {
+			y0++
+			y1--
+			dy += a
+			err += dy
+		}
+		if e2 >= dx || 2*err > dy {
+			x0++
+			x1--
+			dx += b1
+			err += dx
+		}
+	}
+	// Very flat ellipses run out of x steps before reaching their tips.
+	for y0-y1 <= b {
+		plot(x0-1, y0)
+		plot(x1+1, y0)
+		y0++
+		plot(x0-1, y1)
+		plot(x1+1, y1)
+		y1--
+	}
+}
+
+// shapeDrag is a shape being dragged out on a board. Its cells are only
+// previewed until the drag ends.
+type shapeDrag struct {
+	tool           tool
+	sim            *life.Simulation
+	x0, y0, x1, y1 int
+}
+
+func (s *shapeDrag) cells() [][2]int {
+	return s.tool.rasterize(s.x0, s.y0, s.x1, s.y1, s.sim.Cells.Columns(), s.sim.Cells.Rows())
+}
+
+// place brings the shape's cells to life.
+func (s *shapeDrag) place() {
+	for _, c := range s.cells() {
+		s.sim.Cells.Set(c[0], c[1], true)
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRasterizeRect(t *testing.T) {
+	for _, c := range []struct {
+		tool           tool
+		x0, y0, x1, y1 int
+		want           int
+	}{
+		{rectTool, 2, 2, 6, 5, 14},
+		{rectTool, 6, 5, 2, 2, 14},
+		{filledRectTool, 2, 2, 6, 5, 20},
+		{rectTool, 3, 3, 3, 3, 1},
+		{rectTool, 3, 3, 8, 3, 6},
+		// Clipped to the 20x10 board, the edges past it go.
+		{rectTool, -2, -2, 4, 4, 9},
+		{filledRectTool, 15, 5, 25, 15, 25},
+		{rectTool, 21, 0, 30, 9, 0},
+	} {
+		cells := c.tool.rasterize(c.x0, c.y0, c.x1, c.y1, 20, 10)
+		if len(cells) != c.want {
+			t.Errorf("%s from %d, %d to %d, %d has %d cells, want %d", c.tool, c.x0, c.y0, c.x1, c.y1, len(cells), c.want)
+		}
+	}
+}
+
+func TestRasterizeLine(t *testing.T) {
+	var want [][2]int
+	line(1, 1, 15, 6, func(x, y int) { want = append(want, [2]int{x, y}) })
+	if got := lineTool.rasterize(1, 1, 15, 6, 20, 10); len(got) != len(want) {
+		t.Fatalf("line tool draws %d cells, want the line's %d", len(got), len(want))
+	}
+	if got := lineTool.rasterize(1, 1, 30, 1, 20, 10); len(got) != 19 {
+		t.Fatalf("line off the board's edge keeps %d cells, want 19", len(got))
+	}
+}
+
+// TestRasterizeEllipse checks the outline fills its bounding box exactly,
+// is symmetric, closes up with no gaps, and stays near the true ellipse.
+func TestRasterizeEllipse(t *testing.T) {
+	boxes := [][4]int{{2, 3, 20, 9}, {20, 9, 2, 3}, {30, 4, 5, 25}}
+	for w := 0; w < 12; w++ {
+		for h := 0; h < 12; h++ {
+			boxes = append(boxes, [4]int{1, 1, 1 + w, 1 + h})
+		}
+	}
+	for _, box := range boxes {
+		cells := ellipseTool.rasterize(box[0], box[1], box[2], box[3], 40, 40)
+		minX, minY := min(box[0], box[2]), min(box[1], box[3])
+		maxX, maxY := max(box[0], box[2]), max(box[1], box[3])
+		on := make(map[[2]int]bool)
+		for _, c := range cells {
+			if on[c] {
+				t.Fatalf("ellipse %v has cell %v twice", box, c)
+			}
+			on[c] = true
+		}
+		gotMinX, gotMinY, gotMaxX, gotMaxY := cells[0][0], cells[0][1], cells[0][0], cells[0][1]
+		for _, c := range cells {
+			gotMinX, gotMinY = min(gotMinX, c[0]), min(gotMinY, c[1])
+			gotMaxX, gotMaxY = max(gotMaxX, c[0]), max(gotMaxY, c[1])
+			if !on[[2]int{minX + maxX - c[0], c[1]}] || !on[[2]int{c[0], minY + maxY - c[1]}] {
+				t.Errorf("ellipse %v isn't symmetric about cell %v", box, c)
+			}
+		}
+		// Every cell is reachable from the first through touching cells.
+		reached := map[[2]int]bool{cells[0]: true}
+		for queue := [][2]int{cells[0]}; len(queue) > 0; queue = queue[1:] {
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					if n := [2]int{queue[0][0] + dx, queue[0][1] + dy}; on[n] && !reached[n] {
+						reached[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+		if len(reached) != len(cells) {
+			t.Errorf("ellipse %v has a gap: %d of its %d cells join up", box, len(reached), len(cells))
+		}
+		if gotMinX != minX || gotMinY != minY || gotMaxX != maxX || gotMaxY != maxY {
+			t.Errorf("ellipse %v spans %d, %d to %d, %d", box, gotMinX, gotMinY, gotMaxX, gotMaxY)
+		}
+		if maxX-minX < 4 || maxY-minY < 4 {
+			continue
+		}
+		cx, cy := float64(minX+maxX)/2, float64(minY+maxY)/2
+		a, b := float64(maxX-minX)/2, float64(maxY-minY)/2
+		for _, c := range cells {
+			x, y := (float64(c[0])-cx)/a, (float64(c[1])-cy)/b
+			if d := math.Hypot(x, y); d < 0.75 || d > 1.2 {
+				t.Errorf("ellipse %v's cell %v is %.2f of the way out", box, c, d)
+			}
+		}
+	}
+}
+
+func TestShapeDragPlaceClips(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 10, 10
+	sim := testSims(t, testRun(t, cfg), 1)[0]
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			sim.Cells.Set(x, y, false)
+		}
+	}
+	s := &shapeDrag{tool: filledRectTool, sim: sim, x0: 7, y0: 7, x1: 12, y1: -3}
+	s.place()
+	if got := sim.Cells.Population(); got != 3*8 {
+		t.Fatalf("placing a rectangle off the corner left %d cells, want 24", got)
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// catchSignals starts closing shutdown on the first SIGINT or SIGTERM and
+// exiting straight away on a second, for when cleaning up hangs. The
+// returned function stops catching them.
+func (rs *runState) catchSignals() func() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	closed, done := make(chan struct{}), make(chan struct{})
+	rs.shutdown = closed
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Info("shutting down; send the signal again to exit straight away", "signal", sig.String())
+			close(closed)
+		case <-done:
+			return
+		}
+		select {
+		case sig := <-signals:
+			slog.Warn("exiting", "signal", sig.String())
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		rs.shutdown = nil
+	}
+}
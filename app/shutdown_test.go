@@ -0,0 +1,60 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestShutdownHeadless interrupts a headless run that would otherwise go on
+// forever, for it to finish the generation it's on and leave an autosave
+// of exactly where it stopped.
+func TestShutdownHeadless(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap, cfg.Seed = 32, 32, true, 3
+	cfg.Headless, cfg.Resume = true, true
+	rs := testRun(t, cfg)
+	var out bytes.Buffer
+	rs.stdout = &out
+	_, seeds, rules, err := rs.config.boards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.catchSignals()()
+	done := make(chan error, 1)
+	go func() { done <- rs.runHeadless(seeds, rules, nil) }()
+	time.Sleep(50 * time.Millisecond)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run carried on after SIGINT")
+	}
+
+	path, err := autosavePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := rs.readState(path, 1)
+	if err != nil {
+		t.Fatalf("the autosave can't be read back: %v", err)
+	}
+	b := st.Boards[0]
+	if b.Generation == 0 {
+		t.Error("the autosave is of generation 0, before the run got going")
+	}
+	sims := testSims(t, rs, 0)
+	st.restore(rs, sims, rs.newCamera())
+	if want := fmt.Sprintf("board 1: generation %d, population %d, rule %s, hash %016x\n", b.Generation, sims[0].Cells.Population(), sims[0].Rule, sims[0].Cells.Hash()); out.String() != want {
+		t.Errorf("the run stopped at %q, but autosaved %q", out.String(), want)
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"slices"
+
+	"opengl/life"
+)
+
+// simLoop steps the boards on a goroutine of its own, so that a slow
+// generation doesn't hold up drawing and input. The main thread still owns
+// the boards; the loop only ever steps snapshots of them, taken by request,
+// while the main thread carries on drawing the boards as they are. It takes
+// the generations back with result, which drops them if the boards changed
+// in the meantime by an edit, a rewind or a step of its own.
+// It makes no GLFW or OpenGL calls, which must stay on the main thread.
+//
+// At most one request is in flight, so the channels never hold more than
+// one job each.
+type simLoop struct {
+	rs *runState
+
+	jobs, done chan *stepJob
+	pending    bool
+	stopped    chan struct{}
+}
+
+// stepJob is n generations of every board, stepped from snapshots of them
+// as they were when requested.
+type stepJob struct {
+	from        []life.Grid
+	generations []int
+	rules       []life.Rule
+	// rngs are the boards' random number generators when requested, the
+	// rules taking any chances they take from copies of them.
+	rngs []life.PCG
+	n    int
+	wrap bool
+
+	// steps[i][b] is board b after i+1 generations, births[i][b] and
+	// deaths[i][b] what that generation did, and stepRNGs[i][b] its
+	// generator after it.
+	steps          [][]life.Grid
+	births, deaths [][]int
+	stepRNGs       [][]life.PCG
+}
+
+func (rs *runState) newSimLoop() *simLoop {
+	l := &simLoop{rs: rs, jobs: make(chan *stepJob, 1), done: make(chan *stepJob, 1), stopped: make(chan struct{})}
+	go l.run()
+	return l
+}
+
+func (l *simLoop) run() {
+	defer close(l.stopped)
+	for job := range l.jobs {
+		boards := make([]life.Grid, len(job.from))
+		for b, cells := range job.from {
+			boards[b] = cells.Snapshot()
+		}
+		rngs := slices.Clone(job.rngs)
+		for i := 0; i < job.n; i++ {
+			steps, births, deaths := make([]life.Grid, len(boards)), make([]int, len(boards)), make([]int, len(boards))
+			for b, cells := range boards {
+				births[b], deaths[b] = cells.StepRandom(job.rules[b], job.wrap, &rngs[b])
+				steps[b] = cells.Snapshot()
+			}
+			job.steps = append(job.steps, steps)
+			job.births, job.deaths = append(job.births, births), append(job.deaths, deaths)
+			job.stepRNGs = append(job.stepRNGs, slices.Clone(rngs))
+		}
+		l.done <- job
+	}
+}
+
+// request asks for the next n generations of sims, unless a request is
+// already in flight.
+func (l *simLoop) request(sims []*life.Simulation, n int) {
+	if l.pending || n < 1 {
+		return
+	}
+	job := &stepJob{n: n, wrap: l.rs.config.Wrap}
+	for _, sim := range sims {
+		sim.Cells.Publish()
+		job.from = append(job.from, sim.Cells.Snapshot())
+		job.generations = append(job.generations, sim.Generation)
+		job.rules = append(job.rules, sim.Rule)
+		job.rngs = append(job.rngs, sim.RNG)
+	}
+	l.pending = true
+	l.jobs <- job
+}
+
+// result returns the request that's finished, if one has, and whether its
+// generations still follow on from sims.
+func (l *simLoop) result(sims []*life.Simulation) (*stepJob, bool) {
+	select {
+	case job := <-l.done:
+		l.pending = false
+		for b, sim := range sims {
+			if sim.Generation != job.generations[b] || sim.Rule != job.rules[b] || sim.RNG != job.rngs[b] || !sim.Cells.Same(job.from[b]) {
+				return job, false
+			}
+		}
+		return job, true
+	default:
+		return nil, false
+	}
+}
+
+// follows reports whether sims are on the generation before the job's
+// generation i.
+func (job *stepJob) follows(sims []*life.Simulation, i int) bool {
+	for b, sim := range sims {
+		if sim.Generation != job.generations[b]+i {
+			return false
+		}
+	}
+	return true
+}
+
+// apply moves sims on to the job's generation i.
+func (job *stepJob) apply(sims []*life.Simulation, i int) {
+	for b, sim := range sims {
+		sim.StepTo(job.steps[i][b], job.births[i][b], job.deaths[i][b])
+		sim.RNG = job.stepRNGs[i][b]
+	}
+}
+
+// stop stops the loop, once any request in flight has finished.
+func (l *simLoop) stop() {
+	close(l.jobs)
+	if l.pending {
+		<-l.done
+	}
+	<-l.stopped
+}
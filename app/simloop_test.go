@@ -0,0 +1,130 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"opengl/life"
+)
+
+// waitResult waits for the loop's request in flight to come back.
+func waitResult(t *testing.T, l *simLoop, sims []*life.Simulation) (*stepJob, bool) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if job, fresh := l.result(sims); job != nil {
+			return job, fresh
+		}
+	}
+	t.Fatal("the simulation loop never finished its request")
+	return nil, false
+}
+
+func sameSims(t *testing.T, got, want []*life.Simulation) {
+	t.Helper()
+	for i := range want {
+		if got[i].Generation != want[i].Generation || got[i].RNG != want[i].RNG || !got[i].Cells.Same(want[i].Cells) {
+			t.Fatalf("board %d is at generation %d, want it as stepped here at %d", i, got[i].Generation, want[i].Generation)
+		}
+	}
+}
+
+// TestSimLoopStepsAsTheBoardsWould reads and draws from the boards while
+// the loop steps them, as the main thread does, for -race to check that
+// only snapshots cross to the loop's goroutine.
+func TestSimLoopStepsAsTheBoardsWould(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 40, 30, true
+	rs := testRun(t, cfg)
+	direct, looped := testSims(t, rs, 1, 2), testSims(t, rs, 1, 2)
+	l := rs.newSimLoop()
+	defer l.stop()
+
+	for _, n := range []int{1, 7, 3, 20} {
+		l.request(looped, n)
+		for _, sim := range looped {
+			sim.Cells.Population()
+			sim.Cells.Alive(3, 4)
+		}
+		job, fresh := waitResult(t, l, looped)
+		if !fresh || job.n != n {
+			t.Fatalf("request for %d generations came back with %d, fresh %v", n, job.n, fresh)
+		}
+		for i := 0; i < job.n; i++ {
+			if !job.follows(looped, i) {
+				t.Fatalf("generation %d of the job doesn't follow on", i)
+			}
+			job.apply(looped, i)
+		}
+		stepAll(rs, direct, n)
+		sameSims(t, looped, direct)
+	}
+}
+
+// TestSimLoopDropsStaleResults edits a board while a request is in flight,
+// which the generations stepped from before the edit mustn't overwrite.
+func TestSimLoopDropsStaleResults(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 40, 30
+	rs := testRun(t, cfg)
+	sims := testSims(t, rs, 3)
+	l := rs.newSimLoop()
+	defer l.stop()
+
+	for _, edit := range []func(sim *life.Simulation){
+		func(sim *life.Simulation) { sim.Cells.Set(5, 5, !sim.Cells.Alive(5, 5)) },
+		func(sim *life.Simulation) { sim.Rule = life.Conway },
+		func(sim *life.Simulation) { sim.Step(rs.config.Wrap) },
+	} {
+		l.request(sims, 5)
+		edit(sims[0])
+		generation := sims[0].Generation
+		if _, fresh := waitResult(t, l, sims); fresh {
+			t.Fatal("a result from before an edit was fresh")
+		}
+		if sims[0].Generation != generation {
+			t.Fatalf("a stale result moved the board to generation %d", sims[0].Generation)
+		}
+	}
+}
+
+// TestSimLoopOneRequestInFlight checks requests don't queue up behind one
+// that hasn't come back, so neither side's channel grows.
+func TestSimLoopOneRequestInFlight(t *testing.T) {
+	rs := testRun(t, DefaultConfig())
+	sims := testSims(t, rs, 4)
+	l := rs.newSimLoop()
+	defer l.stop()
+
+	l.request(sims, 0)
+	if l.pending {
+		t.Fatal("a request for no generations is in flight")
+	}
+	l.request(sims, 2)
+	l.request(sims, 9)
+	if job, _ := waitResult(t, l, sims); job.n != 2 {
+		t.Fatalf("the request that came back is for %d generations, want the first's 2", job.n)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if job, _ := l.result(sims); job != nil {
+		t.Fatal("the second request while one was in flight was sent too")
+	}
+}
+
+func TestSimLoopStopsWithRequestInFlight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 200, 200
+	rs := testRun(t, cfg)
+	sims := testSims(t, rs, 5)
+	l := rs.newSimLoop()
+	l.request(sims, 50)
+	done := make(chan struct{})
+	go func() {
+		l.stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stop didn't return with a request in flight")
+	}
+}
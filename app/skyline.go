@@ -0,0 +1,143 @@
+package app
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+)
+
+const (
+	// orbitSpeed is how fast, in radians per second, the perspective camera
+	// circles the board.
+	orbitSpeed = 0.15
+	// ageHeight is how much taller a cell gets, in world units, for each
+	// generation it survives, up to maxAgeHeight generations.
+	ageHeight    = 0.01
+	maxAgeHeight = 30
+)
+
+// skyline draws the board on a plane tilted in 3D under a slowly orbiting
+// perspective camera, each live cell a box whose height grows with its age.
+// It is purely a presentation of the board; simulation and editing still
+// happen in grid space.
+type skyline struct {
+	rs *runState
+
+	angle float64
+
+	program *render.ShaderProgram
+
+	vao       uint32
+	mesh      uint32
+	instances uint32
+	data      []float32
+}
+
+// cube is a unit box [0, 1]^3 as triangles with per-face normals, x, y, z,
+// nx, ny, nz per vertex.
+var cube = []float32{
+	// bottom
+	0, 0, 0, 0, 0, -1, 1, 1, 0, 0, 0, -1, 1, 0, 0, 0, 0, -1,
+	0, 0, 0, 0, 0, -1, 0, 1, 0, 0, 0, -1, 1, 1, 0, 0, 0, -1,
+	// top
+	0, 0, 1, 0, 0, 1, 1, 0, 1, 0, 0, 1, 1, 1, 1, 0, 0, 1,
+	0, 0, 1, 0, 0, 1, 1, 1, 1, 0, 0, 1, 0, 1, 1, 0, 0, 1,
+	// front
+	0, 0, 0, 0, -1, 0, 1, 0, 0, 0, -1, 0, 1, 0, 1, 0, -1, 0,
+	0, 0, 0, 0, -1, 0, 1, 0, 1, 0, -1, 0, 0, 0, 1, 0, -1, 0,
+	// back
+	0, 1, 0, 0, 1, 0, 1, 1, 1, 0, 1, 0, 1, 1, 0, 0, 1, 0,
+	0, 1, 0, 0, 1, 0, 0, 1, 1, 0, 1, 0, 1, 1, 1, 0, 1, 0,
+	// left
+	0, 0, 0, -1, 0, 0, 0, 0, 1, -1, 0, 0, 0, 1, 1, -1, 0, 0,
+	0, 0, 0, -1, 0, 0, 0, 1, 1, -1, 0, 0, 0, 1, 0, -1, 0, 0,
+	// right
+	1, 0, 0, 1, 0, 0, 1, 1, 0, 1, 0, 0, 1, 1, 1, 1, 0, 0,
+	1, 0, 0, 1, 0, 0, 1, 1, 1, 1, 0, 0, 1, 0, 1, 1, 0, 0,
+}
+
+func (rs *runState) newSkyline() (*skyline, error) {
+	program, err := render.NewProgram("skyline")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &skyline{
+		rs:      rs,
+		program: program,
+		data:    make([]float32, 0, 3*rs.config.GridHeight*rs.config.GridWidth),
+	}
+
+	s.mesh = render.Gen(render.Buffer, "skyline cube")
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.mesh)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(cube), gl.Ptr(cube), gl.STATIC_DRAW)
+	render.SetSize(render.Buffer, s.mesh, 4*len(cube))
+
+	s.vao = render.Gen(render.VertexArray, "skyline")
+	gl.BindVertexArray(s.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 24, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 24, gl.PtrOffset(12))
+
+	s.instances = render.Gen(render.Buffer, "skyline instances")
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.instances)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*cap(s.data), nil, gl.DYNAMIC_DRAW)
+	render.SetSize(render.Buffer, s.instances, 4*cap(s.data))
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(2, 3, gl.FLOAT, false, 0, nil)
+	gl.VertexAttribDivisor(2, 1)
+	rs.freeOnClose(func() {
+		render.Delete(render.VertexArray, s.vao)
+		render.Delete(render.Buffer, s.mesh, s.instances)
+		s.program.Delete()
+	})
+
+	return s, nil
+}
+
+func (s *skyline) update(dt float64) {
+	s.angle += orbitSpeed * dt
+}
+
+func (s *skyline) draw(cells life.Grid) {
+	cellW, cellH := 2/float32(s.rs.config.GridWidth), 2/float32(s.rs.config.GridHeight)
+	s.data = s.data[:0]
+	for y := 0; y < cells.Rows(); y++ {
+		for x := 0; x < cells.Columns(); x++ {
+			c := cells.At(x, y)
+			if !c.Alive {
+				continue
+			}
+			height := ageHeight * float32(1+min(c.Age, maxAgeHeight))
+			s.data = append(s.data, float32(x)*cellW-1, float32(y)*cellH-1, height)
+		}
+	}
+
+	eye := vec3{
+		float32(2.2 * math.Cos(s.angle)),
+		float32(2.2 * math.Sin(s.angle)),
+		1.6,
+	}
+	mvp := perspective(math.Pi/4, float32(s.rs.config.WindowWidth)/float32(s.rs.config.WindowHeight), 0.1, 10).
+		mul(lookAt(eye, vec3{0, 0, 0}, vec3{0, 0, 1}))
+
+	if s.rs.wireframe && render.CurrentCaps().PolygonMode {
+		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		defer gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	}
+	gl.Enable(gl.DEPTH_TEST)
+	s.program.Use()
+	s.program.SetMat4("mvp", mvp)
+	s.program.SetVec2("cell_size", cellW, cellH)
+	gl.BindVertexArray(s.vao)
+	if len(s.data) > 0 {
+		gl.BindBuffer(gl.ARRAY_BUFFER, s.instances)
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(s.data), gl.Ptr(s.data))
+		render.Uploaded(4 * len(s.data))
+		gl.DrawArraysInstanced(gl.TRIANGLES, 0, int32(len(cube)/6), int32(len(s.data)/3))
+	}
+	gl.Disable(gl.DEPTH_TEST)
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"opengl/life"
+)
+
+// savestate is a copy of a board and the settings it ran under, packed one
+// bit per cell. Ages aren't kept; restored cells start out newborn.
+type savestate struct {
+	generation int
+	rule       life.Rule
+	wrap       bool
+	alive      []uint64
+}
+
+// newSavestate saves s and the settings it runs under.
+func (rs *runState) newSavestate(s *life.Simulation) savestate {
+	st := savestate{
+		generation: s.Generation,
+		rule:       s.Rule,
+		wrap:       rs.config.Wrap,
+		alive:      make([]uint64, (rs.config.GridWidth*rs.config.GridHeight+63)/64),
+	}
+	i := 0
+	for x := 0; x < s.Cells.Columns(); x++ {
+		for y := 0; y < s.Cells.Rows(); y++ {
+			if s.Cells.Alive(x, y) {
+				st.alive[i/64] |= 1 << (i % 64)
+			}
+			i++
+		}
+	}
+	return st
+}
+
+// restore puts the board s and its settings back the way they were saved.
+// The rewind buffer is emptied, since it no longer leads up to the board.
+func (st savestate) restore(rs *runState, s *life.Simulation) {
+	i := 0
+	for x := 0; x < s.Cells.Columns(); x++ {
+		for y := 0; y < s.Cells.Rows(); y++ {
+			s.Cells.Set(x, y, st.alive[i/64]&(1<<(i%64)) != 0)
+			i++
+		}
+	}
+	s.Generation = st.generation
+	s.Rule = st.rule
+	rs.config.Wrap = st.wrap
+	s.Forget()
+}
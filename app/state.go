@@ -0,0 +1,260 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"opengl/life"
+)
+
+// stateVersion is the version of the state file format, to be bumped by any
+// change that would stop older files loading correctly. Version 1 files,
+// from before the boards' random number generators were saved, still load,
+// their generators seeded afresh from their seeds.
+const stateVersion = 2
+
+// state is everything needed to carry on exactly where a run left off, as
+// written to a state file.
+type state struct {
+	Version int          `json:"version"`
+	Columns int          `json:"columns"`
+	Rows    int          `json:"rows"`
+	Wrap    bool         `json:"wrap"`
+	Camera  cameraState  `json:"camera"`
+	Boards  []boardState `json:"boards"`
+}
+
+type cameraState struct {
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Zoom float32 `json:"zoom"`
+}
+
+// boardState is one view's board.
+type boardState struct {
+	Rule       string  `json:"rule"`
+	Generation int     `json:"generation"`
+	Seed       int64   `json:"seed"`
+	Density    float64 `json:"density"`
+	// RNG is the board's random number generator's state, as
+	// life.PCG.MarshalBinary has it, so a rule that takes chances carries
+	// on taking the same ones.
+	RNG []byte `json:"rng,omitempty"`
+	// Cells has a bit per cell, set for live ones, column by column from
+	// the bottom left, packed little-endian. JSON has it base64 encoded.
+	Cells []byte `json:"cells"`
+}
+
+// packedSize is how many bytes a board's cells take up packed a bit each.
+func packedSize(columns, rows int) int {
+	return 8 * ((columns*rows + 63) / 64)
+}
+
+func (rs *runState) captureState(sims []*life.Simulation, cam *camera) state {
+	st := state{
+		Version: stateVersion,
+		Columns: rs.config.GridWidth,
+		Rows:    rs.config.GridHeight,
+		Wrap:    rs.config.Wrap,
+		Camera:  cameraState{cam.x, cam.y, cam.zoom},
+	}
+	for _, sim := range sims {
+		saved := rs.newSavestate(sim)
+		rng, _ := sim.RNG.MarshalBinary()
+		packed := make([]byte, 8*len(saved.alive))
+		for i, word := range saved.alive {
+			binary.LittleEndian.PutUint64(packed[8*i:], word)
+		}
+		st.Boards = append(st.Boards, boardState{
+			Rule:       sim.Rule.String(),
+			Generation: sim.Generation,
+			Seed:       sim.Seed,
+			Density:    sim.Density,
+			RNG:        rng,
+			Cells:      packed,
+		})
+	}
+	return st
+}
+
+// isStateFile reports whether path names a state file, in either format,
+// rather than a pattern.
+func isStateFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".json" || ext == ".lifez"
+}
+
+// writeState writes st to path, as JSON or, for a .lifez file, in the
+// compressed binary format. The file is written alongside first and then
+// moved into place, so a crash part way through leaves any earlier file
+// intact.
+func writeState(path string, st state) error {
+	var (
+		out []byte
+		err error
+	)
+	if filepath.Ext(path) == ".lifez" {
+		out, err = encodeLifez(st)
+	} else {
+		out, err = json.MarshalIndent(st, "", "\t")
+		out = append(out, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// autosavePath is where -resume keeps the state between runs.
+func autosavePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golang-opengl", "autosave.lifez"), nil
+}
+
+// decodeState reads a state file without checking it.
+func decodeState(path string) (state, error) {
+	var st state
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	if filepath.Ext(path) == ".lifez" {
+		st, err = decodeLifez(bytes.NewReader(src))
+	} else {
+		err = json.Unmarshal(src, &st)
+	}
+	if err != nil {
+		return st, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
+
+// fitStateFile makes the boards the size, and wraps their edges the way,
+// the state file -load or -resume carries on from says, ahead of making
+// them, so that it can be restored onto them. An autosave -resume can't
+// carry on from is moved aside, or if it can't be, autosaving is turned
+// off, so that it's never overwritten.
+func (rs *runState) fitStateFile(boards int) error {
+	if rs.config.Load != "" {
+		path, err := rs.loadPath(rs.config.Load)
+		if err != nil {
+			return err
+		}
+		return rs.fitState(path, boards)
+	}
+	if !rs.config.Resume {
+		return nil
+	}
+	path, err := autosavePath()
+	if err != nil {
+		return nil
+	}
+	err = rs.fitState(path, boards)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	aside := path + ".unusable-" + time.Now().Format("20060102-150405")
+	if moveErr := os.Rename(path, aside); moveErr != nil {
+		rs.config.Resume = false
+		slog.Warn("starting afresh, with autosaving off so as not to overwrite the autosave", "err", err, "path", path)
+		return nil
+	}
+	slog.Warn("starting afresh; the autosave has been kept", "err", err, "path", aside)
+	return nil
+}
+
+// fitState takes the board size and edge wrapping from the state file at
+// path, if they're valid and it can be restored onto them.
+func (rs *runState) fitState(path string, boards int) error {
+	st, err := decodeState(path)
+	if err != nil {
+		return err
+	}
+	was := rs.config
+	rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap = st.Columns, st.Rows, st.Wrap
+	if err = rs.config.Validate(); err == nil {
+		err = st.check(rs, boards)
+	}
+	if err != nil {
+		rs.config = was
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readState reads a state file, checking it can be restored onto the given
+// number of boards.
+func (rs *runState) readState(path string, boards int) (state, error) {
+	st, err := decodeState(path)
+	if err != nil {
+		return st, err
+	}
+	if err := st.check(rs, boards); err != nil {
+		return st, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
+
+// check reports why st can't be restored onto the given number of boards,
+// if it can't.
+func (st state) check(rs *runState, boards int) error {
+	switch {
+	case st.Version < 1 || st.Version > stateVersion:
+		return fmt.Errorf("state file version %d can't be loaded; want version %d or older", st.Version, stateVersion)
+	case st.Columns != rs.config.GridWidth || st.Rows != rs.config.GridHeight:
+		return fmt.Errorf("board is %dx%d, not %dx%d", st.Columns, st.Rows, rs.config.GridWidth, rs.config.GridHeight)
+	case len(st.Boards) != boards:
+		return fmt.Errorf("has %d boards, not %d", len(st.Boards), boards)
+	case st.Camera.Zoom <= 0:
+		return fmt.Errorf("invalid camera zoom %g", st.Camera.Zoom)
+	}
+	for i, b := range st.Boards {
+		if _, err := life.ParseRule(b.Rule); err != nil {
+			return fmt.Errorf("board %d: %w", i+1, err)
+		}
+		if len(b.Cells) != packedSize(rs.config.GridWidth, rs.config.GridHeight) {
+			return fmt.Errorf("board %d: cells don't fit a %dx%d board", i+1, rs.config.GridWidth, rs.config.GridHeight)
+		}
+		if b.RNG != nil {
+			if err := new(life.PCG).UnmarshalBinary(b.RNG); err != nil {
+				return fmt.Errorf("board %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// restore puts the boards and camera back the way st has them. It expects
+// st to have been checked already.
+func (st state) restore(rs *runState, sims []*life.Simulation, cam *camera) {
+	for i, b := range st.Boards {
+		saved := savestate{generation: b.Generation, wrap: st.Wrap, alive: make([]uint64, len(b.Cells)/8)}
+		for j := range saved.alive {
+			saved.alive[j] = binary.LittleEndian.Uint64(b.Cells[8*j:])
+		}
+		saved.rule, _ = life.ParseRule(b.Rule)
+		saved.restore(rs, sims[i])
+		sims[i].Seed, sims[i].Density = b.Seed, b.Density
+		if sims[i].RNG.UnmarshalBinary(b.RNG) != nil {
+			sims[i].RNG.Seed(b.Seed)
+		}
+	}
+	cam.to = nil
+	cam.x, cam.y, cam.zoom = st.Camera.X, st.Camera.Y, st.Camera.Zoom
+	cam.clamp()
+}
@@ -0,0 +1,345 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"opengl/life"
+)
+
+// noisyLife is Conway's Life with every cell flipped a time in 200, a rule
+// that takes chances, for checking saved runs carry on as they would have.
+type noisyLife struct{}
+
+func (n noisyLife) Step(g, next life.Grid, wrap bool) (births, deaths int) {
+	return n.StepRandom(g, next, wrap, new(life.PCG))
+}
+
+func (noisyLife) StepRandom(g, next life.Grid, wrap bool, rng *life.PCG) (births, deaths int) {
+	births, deaths = life.Conway.Step(g, next, wrap)
+	for y := 0; y < next.Rows(); y++ {
+		for x := 0; x < next.Columns(); x++ {
+			if rng.Float64() < 1.0/200 {
+				next.Set(x, y, !next.Alive(x, y))
+			}
+		}
+	}
+	return births, deaths
+}
+
+func init() {
+	life.Register(life.Automaton{
+		Name:        "noisy-life",
+		Description: "Conway's Life with a cell in 200 flipped each generation",
+		NewStepper:  func(string) (life.Stepper, error) { return noisyLife{}, nil },
+		States:      2,
+	})
+}
+
+// testRun returns the state of a run of cfg, for the test to use.
+func testRun(t *testing.T, cfg Config) *runState {
+	t.Helper()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return newRunState(cfg)
+}
+
+func testSims(t *testing.T, rs *runState, seeds ...int64) []*life.Simulation {
+	t.Helper()
+	r, err := life.ParseRule("noisy-life")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sims []*life.Simulation
+	for _, seed := range seeds {
+		sims = append(sims, life.NewSimulation(life.NewGrid(rs.config.GridWidth, rs.config.GridHeight), r, seed, 0.3, 0))
+	}
+	return sims
+}
+
+func stepAll(rs *runState, sims []*life.Simulation, generations int) {
+	for _, sim := range sims {
+		for i := 0; i < generations; i++ {
+			sim.Step(rs.config.Wrap)
+		}
+	}
+}
+
+func TestStateCarriesOn(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 40, 30, true
+	rs := testRun(t, cfg)
+
+	whole := testSims(t, rs, 1, 2)
+	stepAll(rs, whole, 1000)
+
+	for _, name := range []string{"state.json", "state.lifez"} {
+		t.Run(name, func(t *testing.T) {
+			half := testSims(t, rs, 1, 2)
+			stepAll(rs, half, 500)
+			path := filepath.Join(t.TempDir(), name)
+			if err := writeState(path, rs.captureState(half, rs.newCamera())); err != nil {
+				t.Fatal(err)
+			}
+			st, err := rs.readState(path, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded := testSims(t, rs, 7, 8)
+			st.restore(rs, loaded, rs.newCamera())
+			stepAll(rs, loaded, 500)
+			for i := range loaded {
+				if loaded[i].Generation != 1000 {
+					t.Errorf("board %d is at generation %d, want 1000", i+1, loaded[i].Generation)
+				}
+				if got, want := loaded[i].Cells.Hash(), whole[i].Cells.Hash(); got != want {
+					t.Errorf("board %d: 500 generations, saved, loaded and 500 more hash to %#x; 1000 straight hash to %#x", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStateLoadsVersion1(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 16, 8
+	rs := testRun(t, cfg)
+
+	sims := testSims(t, rs, 4)
+	st := rs.captureState(sims, rs.newCamera())
+	st.Version = 1
+	st.Boards[0].RNG = nil
+	dir := t.TempDir()
+
+	out, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "v1.json")
+	if err := os.WriteFile(jsonPath, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lifezPath := filepath.Join(dir, "v1.lifez")
+	if err := os.WriteFile(lifezPath, encodeLifezV1(t, st), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{jsonPath, lifezPath} {
+		got, err := rs.readState(path, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+		loaded := testSims(t, rs, 9)
+		got.restore(rs, loaded, rs.newCamera())
+		if loaded[0].RNG != *life.NewPCG(4) {
+			t.Errorf("%s: generator isn't seeded afresh from the board's seed", filepath.Base(path))
+		}
+		if loaded[0].Cells.Hash() != sims[0].Cells.Hash() {
+			t.Errorf("%s: cells don't match the saved board", filepath.Base(path))
+		}
+	}
+}
+
+// encodeLifezV1 encodes st as a version 1 .lifez file, whose boards had no
+// random number generator.
+func encodeLifezV1(t *testing.T, st state) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := &lifezWriter{w: zw}
+	w.write([]byte(lifezMagic))
+	w.write(lifezHeader{
+		Version:    1,
+		Columns:    uint32(st.Columns),
+		Rows:       uint32(st.Rows),
+		Wrap:       st.Wrap,
+		CameraZoom: st.Camera.Zoom,
+		Boards:     uint16(len(st.Boards)),
+	})
+	for _, b := range st.Boards {
+		w.write(uint16(len(b.Rule)))
+		w.write([]byte(b.Rule))
+		w.write(uint64(b.Generation))
+		w.write(b.Seed)
+		w.write(b.Density)
+		w.write(b.Cells)
+	}
+	if w.err != nil {
+		t.Fatal(w.err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 37, 23
+	rs := testRun(t, cfg)
+
+	sims := rs.newSimulations([]int64{11, 12}, []life.Rule{life.Conway, mustParseRule(t, "B36/S23")})
+	stepAll(rs, sims, 17)
+	cam := rs.newCamera()
+	cam.x, cam.y, cam.zoom = 0.25, -0.5, 3
+	for _, name := range []string{"state.json", "state.lifez"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := writeState(path, rs.captureState(sims, cam)); err != nil {
+			t.Fatal(err)
+		}
+		st, err := rs.readState(path, len(sims))
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := rs.newSimulations([]int64{1, 2}, []life.Rule{life.Conway, life.Conway})
+		loadedCam := rs.newCamera()
+		st.restore(rs, loaded, loadedCam)
+		for i, sim := range loaded {
+			if got, want := sim.Cells.Hash(), sims[i].Cells.Hash(); got != want {
+				t.Errorf("%s: board %d hashes to %#x, want %#x", name, i+1, got, want)
+			}
+			if sim.Generation != sims[i].Generation || sim.Rule.String() != sims[i].Rule.String() || sim.Seed != sims[i].Seed {
+				t.Errorf("%s: board %d is %s at generation %d from seed %d, want %s at %d from %d", name, i+1,
+					sim.Rule, sim.Generation, sim.Seed, sims[i].Rule, sims[i].Generation, sims[i].Seed)
+			}
+		}
+		if loadedCam.viewpoint != cam.viewpoint {
+			t.Errorf("%s: camera is %+v, want %+v", name, loadedCam.viewpoint, cam.viewpoint)
+		}
+	}
+}
+
+func mustParseRule(t *testing.T, s string) life.Rule {
+	t.Helper()
+	r, err := life.ParseRule(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestLoadTakesTheFilesBoard(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 40, 30, true
+	rs := testRun(t, cfg)
+	sims := rs.newSimulations([]int64{5}, []life.Rule{life.Conway})
+	path := filepath.Join(t.TempDir(), "big.lifez")
+	if err := writeState(path, rs.captureState(sims, rs.newCamera())); err != nil {
+		t.Fatal(err)
+	}
+
+	rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap = 10, 10, false
+	rs.config.Load = path
+	if err := rs.fitStateFile(1); err != nil {
+		t.Fatal(err)
+	}
+	if rs.config.GridWidth != 40 || rs.config.GridHeight != 30 || !rs.config.Wrap {
+		t.Fatalf("-load of a wrapped 40x30 board makes a %dx%d one, wrapped %v", rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap)
+	}
+	loaded := rs.newSimulations([]int64{6}, []life.Rule{life.Conway})
+	st, err := rs.readState(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.restore(rs, loaded, rs.newCamera())
+	if loaded[0].Cells.Hash() != sims[0].Cells.Hash() {
+		t.Error("board loaded onto the file's size doesn't hash as it was saved")
+	}
+}
+
+func TestLoadRefusesUnfittingBoards(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 8, 8
+	rs := testRun(t, cfg)
+	st := rs.captureState(rs.newSimulations([]int64{1}, []life.Rule{life.Conway}), rs.newCamera())
+	huge := st
+	huge.Columns, huge.Rows = maxGridSide, maxGridSide
+	for name, st := range map[string]state{"huge": huge, "two boards": {Version: stateVersion, Columns: 8, Rows: 8, Camera: st.Camera, Boards: append(st.Boards, st.Boards...)}} {
+		out, err := json.Marshal(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "state.json")
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rs.config.Load = path
+		if err := rs.fitStateFile(1); err == nil {
+			t.Errorf("%s: fitStateFile = nil, want an error", name)
+		}
+		if rs.config.GridWidth != 8 || rs.config.GridHeight != 8 {
+			t.Errorf("%s: refused file left the board %dx%d, want 8x8", name, rs.config.GridWidth, rs.config.GridHeight)
+		}
+	}
+}
+
+// useAutosave points autosavePath at a directory of the test's own and
+// turns -resume on, returning where the autosave goes.
+func useAutosave(t *testing.T, rs *runState) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := autosavePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rs.config.Resume = true
+	return path
+}
+
+func TestResumeTakesTheAutosavesBoard(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 40, 30, true
+	rs := testRun(t, cfg)
+	path := useAutosave(t, rs)
+	if err := writeState(path, rs.captureState(rs.newSimulations([]int64{5}, []life.Rule{life.Conway}), rs.newCamera())); err != nil {
+		t.Fatal(err)
+	}
+
+	rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap = 10, 10, false
+	if err := rs.fitStateFile(1); err != nil {
+		t.Fatal(err)
+	}
+	if rs.config.GridWidth != 40 || rs.config.GridHeight != 30 || !rs.config.Wrap {
+		t.Errorf("-resume of a wrapped 40x30 board makes a %dx%d one, wrapped %v", rs.config.GridWidth, rs.config.GridHeight, rs.config.Wrap)
+	}
+}
+
+func TestResumeKeepsUnusableAutosaves(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight = 10, 10
+	rs := testRun(t, cfg)
+	wrongBoards, err := encodeLifez(rs.captureState(rs.newSimulations([]int64{1, 2}, []life.Rule{life.Conway, life.Conway}), rs.newCamera()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string][]byte{"corrupt": []byte("not a state file"), "two boards": wrongBoards} {
+		path := useAutosave(t, rs)
+		if err := os.WriteFile(path, contents, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.fitStateFile(1); err != nil {
+			t.Fatalf("%s: fitStateFile = %v, want a fresh start", name, err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: the autosave is still where the next autosave would overwrite it", name)
+		}
+		aside, _ := filepath.Glob(path + ".unusable-*")
+		if len(aside) != 1 {
+			t.Fatalf("%s: the autosave was moved to %v, want one .unusable file", name, aside)
+		}
+		if kept, _ := os.ReadFile(aside[0]); !bytes.Equal(kept, contents) {
+			t.Errorf("%s: the autosave moved aside has changed", name)
+		}
+		if rs.config.GridWidth != 10 || rs.config.GridHeight != 10 || !rs.config.Resume {
+			t.Errorf("%s: a fresh start is %dx%d, resuming %v; want 10x10, resuming", name, rs.config.GridWidth, rs.config.GridHeight, rs.config.Resume)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"sync"
+
+	"opengl/life"
+)
+
+// StatsSample is one generation of the first board's statistics.
+type StatsSample struct {
+	Generation int `json:"generation"`
+	Population int `json:"population"`
+	// Births and Deaths count the cells that came to life and died getting
+	// to the generation, and Activity is the fraction of cells they are.
+	Births   int     `json:"births"`
+	Deaths   int     `json:"deaths"`
+	Activity float64 `json:"activity"`
+}
+
+// Stats is the history of the first board's statistics: a ring of the
+// latest generations' samples, oldest first, dropping the oldest once it's
+// full. The loop running the boards adds to it, and it's safe to read from
+// any goroutine, which is given a copy.
+type Stats struct {
+	mu       sync.Mutex
+	samples  []StatsSample
+	start, n int
+}
+
+// NewStats returns an empty history of up to capacity generations, for
+// WithStats.
+func NewStats(capacity int) *Stats {
+	return &Stats{samples: make([]StatsSample, max(capacity, 2))}
+}
+
+// Capacity returns the most generations the history holds.
+func (s *Stats) Capacity() int {
+	return len(s.samples)
+}
+
+// Len returns how many generations the history holds.
+func (s *Stats) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// Range returns the samples of the generations from from to to, inclusive,
+// that the history holds.
+func (s *Stats) Range(from, to int) []StatsSample {
+	return s.RangeN(from, to, 0)
+}
+
+// RangeN is Range, downsampled to at most points samples if there are more
+// and points is positive. Each is the mean of a run of neighbouring
+// generations, as the last of them; the runs are as even as they can be.
+func (s *Stats) RangeN(from, to, points int) []StatsSample {
+	s.mu.Lock()
+	in := []StatsSample{}
+	for i := 0; i < s.n; i++ {
+		if sample := s.samples[(s.start+i)%len(s.samples)]; sample.Generation >= from && sample.Generation <= to {
+			in = append(in, sample)
+		}
+	}
+	s.mu.Unlock()
+	if points <= 0 || len(in) <= points {
+		return in
+	}
+	out := make([]StatsSample, points)
+	for i := range out {
+		run := in[i*len(in)/points : (i+1)*len(in)/points]
+		var sum StatsSample
+		for _, sample := range run {
+			sum.Population += sample.Population
+			sum.Births += sample.Births
+			sum.Deaths += sample.Deaths
+			sum.Activity += sample.Activity
+		}
+		n := len(run)
+		out[i] = StatsSample{
+			Generation: run[n-1].Generation,
+			Population: (sum.Population + n/2) / n,
+			Births:     (sum.Births + n/2) / n,
+			Deaths:     (sum.Deaths + n/2) / n,
+			Activity:   sum.Activity / float64(n),
+		}
+	}
+	return out
+}
+
+// add records sim's latest generation.
+func (s *Stats) add(sim *life.Simulation) {
+	s.push(StatsSample{
+		Generation: sim.Generation,
+		Population: sim.Cells.Population(),
+		Births:     sim.Births,
+		Deaths:     sim.Deaths,
+		Activity:   sim.Activity(),
+	})
+}
+
+func (s *Stats) push(sample StatsSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := (s.start + s.n) % len(s.samples)
+	if s.n < len(s.samples) {
+		s.n++
+	} else {
+		s.start = (s.start + 1) % len(s.samples)
+	}
+	s.samples[i] = sample
+}
+
+// pop drops the newest sample.
+func (s *Stats) pop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n > 0 {
+		s.n--
+	}
+}
+
+// reset empties the history, for a board that starts afresh.
+func (s *Stats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = 0
+	s.n = 0
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// pushGenerations adds samples for generations from to to, each with a
+// population of its generation, twice as many births and a tenth of it as
+// its activity.
+func pushGenerations(s *Stats, from, to int) {
+	for gen := from; gen <= to; gen++ {
+		s.push(StatsSample{Generation: gen, Population: gen, Births: 2 * gen, Activity: float64(gen) / 10})
+	}
+}
+
+func generations(samples []StatsSample) []int {
+	gens := []int{}
+	for _, sample := range samples {
+		gens = append(gens, sample.Generation)
+	}
+	return gens
+}
+
+// TestStatsWraps checks a full history drops its oldest generations, and
+// Range gives what it still holds of a range, oldest first.
+func TestStatsWraps(t *testing.T) {
+	s := NewStats(5)
+	pushGenerations(s, 1, 8)
+	if s.Len() != 5 || s.Capacity() != 5 {
+		t.Errorf("history holds %d of %d, want 5 of 5", s.Len(), s.Capacity())
+	}
+	for _, c := range []struct {
+		from, to int
+		want     []int
+	}{
+		{0, 100, []int{4, 5, 6, 7, 8}},
+		{5, 6, []int{5, 6}},
+		{1, 4, []int{4}},
+		{1, 3, []int{}},
+		{7, 20, []int{7, 8}},
+		{9, 10, []int{}},
+		{6, 5, []int{}},
+	} {
+		if got := generations(s.Range(c.from, c.to)); !slices.Equal(got, c.want) {
+			t.Errorf("Range(%d, %d) gave generations %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+// TestStatsRangeN checks downsampling seven generations to three takes the
+// means of runs of two, two and three, each labelled by its last
+// generation, and that asking for as many points as there are samples, or
+// none, gives them all.
+func TestStatsRangeN(t *testing.T) {
+	s := NewStats(10)
+	pushGenerations(s, 1, 7)
+	got := s.RangeN(1, 7, 3)
+	want := []StatsSample{
+		{Generation: 2, Population: 2, Births: 3, Activity: 0.15},
+		{Generation: 4, Population: 4, Births: 7, Activity: 0.35},
+		{Generation: 7, Population: 6, Births: 12, Activity: 0.6},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("RangeN gave %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Generation != w.Generation || g.Population != w.Population || g.Births != w.Births || math.Abs(g.Activity-w.Activity) > 1e-9 {
+			t.Errorf("sample %d is %+v, want %+v", i, g, w)
+		}
+	}
+	for _, points := range []int{0, 7, 20} {
+		if got := generations(s.RangeN(1, 7, points)); !slices.Equal(got, []int{1, 2, 3, 4, 5, 6, 7}) {
+			t.Errorf("RangeN with %d points gave generations %v, want all seven", points, got)
+		}
+	}
+
+	// After wrapping, the runs are of what the history still holds.
+	pushGenerations(s, 8, 13)
+	if got := generations(s.RangeN(0, 100, 4)); !slices.Equal(got, []int{5, 8, 10, 13}) {
+		t.Errorf("RangeN of a wrapped history gave generations %v, want [5 8 10 13]", got)
+	}
+}
+
+// TestStatsPop checks dropping the newest sample of a history that has
+// wrapped leaves the rest in order, for the next to go after them, and
+// that an empty history stays empty.
+func TestStatsPop(t *testing.T) {
+	s := NewStats(3)
+	pushGenerations(s, 1, 5)
+	s.pop()
+	if got := generations(s.Range(0, 100)); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("after pop, generations %v, want [3 4]", got)
+	}
+	pushGenerations(s, 5, 6)
+	if got := generations(s.Range(0, 100)); !slices.Equal(got, []int{4, 5, 6}) {
+		t.Errorf("after pop and two more, generations %v, want [4 5 6]", got)
+	}
+	for i := 0; i < 4; i++ {
+		s.pop()
+	}
+	if s.Len() != 0 {
+		t.Errorf("after popping everything and more, %d samples left", s.Len())
+	}
+	pushGenerations(s, 7, 7)
+	if got := generations(s.Range(0, 100)); !slices.Equal(got, []int{7}) {
+		t.Errorf("pushed after emptying, generations %v, want [7]", got)
+	}
+	s.reset()
+	if s.Len() != 0 {
+		t.Errorf("after reset, %d samples left", s.Len())
+	}
+}
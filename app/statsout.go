@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"opengl/life"
+)
+
+const (
+	// statsQueue is how many rows can be waiting to be written before more
+	// are dropped, rather than hold up the simulation.
+	statsQueue = 4096
+	// statsFlush is how often written rows are flushed to the file, so a
+	// crash loses at most this much of the tail.
+	statsFlush = time.Second
+)
+
+// statsRow is one generation's line of -stats-out.
+type statsRow struct {
+	generation, population, births, deaths int
+	hash                                   uint64
+	// activity is the fraction of cells that changed, and entropy the
+	// board's 2×2 block entropy, in bits per cell.
+	activity, entropy float64
+	// clusters is the clusters last counted, or -1 if they aren't.
+	clusters int
+}
+
+// statsWriter appends rows to a CSV file from a goroutine of its own.
+type statsWriter struct {
+	rs *runState
+
+	rows    chan statsRow
+	done    chan error
+	dropped int
+}
+
+// newStatsWriter opens path for appending, writing the header row if it's
+// a new file.
+func (rs *runState) newStatsWriter(path string) (*statsWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	if fi.Size() == 0 {
+		fmt.Fprintln(w, "generation,population,births,deaths,hash,activity,entropy,clusters")
+	}
+	s := &statsWriter{rs: rs, rows: make(chan statsRow, statsQueue), done: make(chan error, 1)}
+	go func() {
+		tick := time.NewTicker(statsFlush)
+		defer tick.Stop()
+		var err error
+		for {
+			select {
+			case r, ok := <-s.rows:
+				if !ok {
+					if ferr := w.Flush(); err == nil {
+						err = ferr
+					}
+					if cerr := f.Close(); err == nil {
+						err = cerr
+					}
+					s.done <- err
+					return
+				}
+				if err == nil {
+					clusters := ""
+					if r.clusters >= 0 {
+						clusters = strconv.Itoa(r.clusters)
+					}
+					_, err = fmt.Fprintf(w, "%d,%d,%d,%d,%016x,%.6f,%.6f,%s\n",
+						r.generation, r.population, r.births, r.deaths, r.hash, r.activity, r.entropy, clusters)
+				}
+			case <-tick.C:
+				if err == nil {
+					err = w.Flush()
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+// add queues a row for sim's latest generation, dropping it if the queue is
+// full.
+func (s *statsWriter) add(sim *life.Simulation) {
+	r := statsRow{
+		generation: sim.Generation,
+		population: sim.Cells.Population(),
+		births:     sim.Births,
+		deaths:     sim.Deaths,
+		hash:       sim.Cells.Hash(),
+		activity:   sim.Activity(),
+		entropy:    life.BlockEntropy(sim.Cells),
+		clusters:   -1,
+	}
+	if n, ok := s.rs.clusterCount.latest(); ok {
+		r.clusters = n
+	}
+	select {
+	case s.rows <- r:
+	default:
+		s.dropped++
+	}
+}
+
+// close writes out the queued rows and closes the file.
+func (s *statsWriter) close() error {
+	close(s.rows)
+	err := <-s.done
+	if s.dropped > 0 {
+		slog.Warn("-stats-out fell behind and dropped rows", "rows", s.dropped)
+	}
+	return err
+}
@@ -0,0 +1,239 @@
+package app
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"math/bits"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"opengl/life"
+)
+
+//go:embed viewer.html
+var viewerPage []byte
+
+const (
+	// streamBuffer is how many frames a /stream client can fall behind by
+	// before what it's queued is dropped for a keyframe.
+	streamBuffer = 64
+	// streamWriteTimeout is how long a client has to take a frame before
+	// it's disconnected.
+	streamWriteTimeout = 10 * time.Second
+	// streamHashEvery is how many generations apart hash frames are sent.
+	streamHashEvery = 64
+)
+
+// boardStream sends the first board to /stream's WebSocket clients, each a
+// binary frame, little-endian:
+//
+//	'K', columns, rows, generation uint32, then the cells packed as in a
+//	state file: a keyframe of the whole board
+//	'D', generation uint32, then a uint32 per cell that changed, numbered
+//	column by column from the bottom left: a delta from the last frame
+//	'H', generation uint32, hash uint64: the board's life.Grid.Hash, every
+//	streamHashEvery generations, for clients to check they haven't
+//	diverged
+//
+// A client gets a keyframe on joining, then a delta every time the board
+// changes. One that falls behind has what it's queued dropped for a fresh
+// keyframe, so a slow client never holds the boards up.
+type boardStream struct {
+	rs *runState
+
+	mu      sync.Mutex
+	clients map[*streamClient]bool
+	// last is the board as last sent, packed, and generation its
+	// generation. Only the loop owning the boards changes them.
+	last       []uint64
+	generation int
+	quit       chan struct{}
+}
+
+type streamClient struct {
+	frames chan []byte
+}
+
+func (rs *runState) newBoardStream() *boardStream {
+	return &boardStream{rs: rs, clients: make(map[*streamClient]bool), quit: make(chan struct{})}
+}
+
+// publish sends the clients whatever's changed on sim since the last
+// frame. It's for the loop owning the boards to call whenever they might
+// have changed.
+func (b *boardStream) publish(sim *life.Simulation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.clients) == 0 {
+		b.last = nil
+		return
+	}
+	b.update(sim)
+}
+
+func (b *boardStream) update(sim *life.Simulation) {
+	alive := b.rs.newSavestate(sim).alive
+	if b.last == nil {
+		b.last, b.generation = alive, sim.Generation
+		return
+	}
+	if sim.Generation == b.generation && slices.Equal(alive, b.last) {
+		return
+	}
+	frames := [][]byte{encodeDelta(sim.Generation, b.last, alive)}
+	if sim.Generation != b.generation && sim.Generation%streamHashEvery == 0 {
+		frames = append(frames, encodeHash(sim.Generation, sim.Cells.Hash()))
+	}
+	b.last, b.generation = alive, sim.Generation
+	var key []byte
+	for c := range b.clients {
+		if c.send(frames) {
+			continue
+		}
+		if key == nil {
+			key = b.rs.encodeKeyframe(b.generation, b.last)
+		}
+		c.resync(key)
+	}
+}
+
+// send queues frames, reporting false if the client is too far behind to
+// take them all.
+func (c *streamClient) send(frames [][]byte) bool {
+	for _, frame := range frames {
+		select {
+		case c.frames <- frame:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// resync drops whatever the client has queued for key, a keyframe.
+func (c *streamClient) resync(key []byte) {
+	for len(c.frames) > 0 {
+		select {
+		case <-c.frames:
+		default:
+		}
+	}
+	c.frames <- key
+}
+
+// join adds a client, starting it off with a keyframe of sim. Like
+// publish, it's for the loop owning the boards to call.
+func (b *boardStream) join(sim *life.Simulation) *streamClient {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Everyone else is brought up to date first, so the next delta follows
+	// on from the new client's keyframe too.
+	b.update(sim)
+	c := &streamClient{frames: make(chan []byte, streamBuffer)}
+	c.frames <- b.rs.encodeKeyframe(b.generation, b.last)
+	b.clients[c] = true
+	return c
+}
+
+// resync starts c afresh from a keyframe, for a client that's found it's
+// diverged. Like publish, it's for the loop owning the boards to call.
+func (b *boardStream) resync(c *streamClient, sim *life.Simulation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update(sim)
+	c.resync(b.rs.encodeKeyframe(b.generation, b.last))
+}
+
+func (b *boardStream) leave(c *streamClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.clients, c)
+}
+
+// close disconnects every client.
+func (b *boardStream) close() {
+	close(b.quit)
+}
+
+func (rs *runState) encodeKeyframe(generation int, alive []uint64) []byte {
+	frame := []byte{'K'}
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(rs.config.GridWidth))
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(rs.config.GridHeight))
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(generation))
+	for _, word := range alive {
+		frame = binary.LittleEndian.AppendUint64(frame, word)
+	}
+	return frame
+}
+
+func encodeHash(generation int, hash uint64) []byte {
+	frame := []byte{'H'}
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(generation))
+	return binary.LittleEndian.AppendUint64(frame, hash)
+}
+
+// encodeDelta lists the cells that differ between two packed boards.
+func encodeDelta(generation int, from, to []uint64) []byte {
+	frame := []byte{'D'}
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(generation))
+	for i := range to {
+		for changed := from[i] ^ to[i]; changed != 0; changed &= changed - 1 {
+			frame = binary.LittleEndian.AppendUint32(frame, uint32(64*i+bits.TrailingZeros64(changed)))
+		}
+	}
+	return frame
+}
+
+// serveStream streams the board to a WebSocket client until it goes away,
+// falls too far behind to take a frame, or the server stops.
+func (s *apiServer) serveStream(w http.ResponseWriter, r *http.Request) {
+	joined, err := s.rc.do(r.Context(), func(c *boardControls) (any, error) { return s.rc.stream.join(c.sims[0]), nil })
+	if err != nil {
+		return
+	}
+	client := joined.(*streamClient)
+	defer s.rc.stream.leave(client)
+	conn, rw, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiErrorBody(err))
+		return
+	}
+	defer conn.Close()
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			opcode, _, err := readWSFrame(rw.Reader)
+			if err != nil || opcode == wsClose {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case frame := <-client.frames:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := writeWSFrame(rw.Writer, wsBinary, frame); err != nil {
+				return
+			}
+		case <-gone:
+			writeWSFrame(rw.Writer, wsClose, nil)
+			return
+		case <-s.rc.stream.quit:
+			writeWSFrame(rw.Writer, wsClose, nil)
+			return
+		}
+	}
+}
+
+// serveViewer serves a page that draws /stream on a canvas.
+func serveViewer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(viewerPage)
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"opengl/life"
+)
+
+// streamViewer follows a stream's frames as the viewer page does, keeping
+// the board they add up to.
+type streamViewer struct {
+	t             *testing.T
+	columns, rows int
+	generation    int
+	alive         []bool
+	// keyframes counts the keyframes read, and keyGeneration is the last
+	// one's generation.
+	keyframes, keyGeneration int
+}
+
+func (v *streamViewer) read(frame []byte) {
+	v.t.Helper()
+	le := binary.LittleEndian
+	switch frame[0] {
+	case 'K':
+		v.columns, v.rows, v.generation = int(le.Uint32(frame[1:])), int(le.Uint32(frame[5:])), int(le.Uint32(frame[9:]))
+		v.alive = make([]bool, v.columns*v.rows)
+		for i := range v.alive {
+			v.alive[i] = le.Uint64(frame[13+8*(i/64):])&(1<<(i%64)) != 0
+		}
+		v.keyframes++
+		v.keyGeneration = v.generation
+	case 'D':
+		v.generation = int(le.Uint32(frame[1:]))
+		for at := 5; at < len(frame); at += 4 {
+			i := le.Uint32(frame[at:])
+			v.alive[i] = !v.alive[i]
+		}
+	case 'H':
+		if gen := int(le.Uint32(frame[1:])); gen != v.generation {
+			v.t.Errorf("hash frame for generation %d after generation %d", gen, v.generation)
+		}
+		if hash := le.Uint64(frame[5:]); hash != v.grid().Hash() {
+			v.t.Errorf("generation %d: the viewer's board has diverged from the hash", v.generation)
+		}
+	default:
+		v.t.Fatalf("unknown frame %q", frame[0])
+	}
+}
+
+// readAll reads every frame c has queued.
+func (v *streamViewer) readAll(c *streamClient) {
+	for len(c.frames) > 0 {
+		v.read(<-c.frames)
+	}
+}
+
+// matches reports whether the viewer has sim's generation and live cells.
+func (v *streamViewer) matches(sim *life.Simulation) bool {
+	return v.generation == sim.Generation && v.grid().Hash() == sim.Cells.Hash()
+}
+
+func (v *streamViewer) grid() life.Grid {
+	g := life.NewGrid(v.columns, v.rows)
+	for i, a := range v.alive {
+		g.Set(i/v.rows, i%v.rows, a)
+	}
+	return g
+}
+
+// TestStreamRoundTrip follows a board stepping on, edited and reseeded,
+// for the viewer's board to keep up with it from the deltas alone.
+func TestStreamRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 37, 23, true
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 3)[0]
+	b := rs.newBoardStream()
+	v := &streamViewer{t: t}
+	c := b.join(sim)
+	v.readAll(c)
+	for gen := 1; gen <= 200; gen++ {
+		sim.Step(true)
+		switch gen {
+		case 50:
+			sim.Cells.Set(0, 0, !sim.Cells.Alive(0, 0))
+			sim.Cells.Set(36, 22, true)
+		case 120:
+			sim.Reseed(4)
+		}
+		b.publish(sim)
+		v.readAll(c)
+		if !v.matches(sim) {
+			t.Fatalf("generation %d: the viewer has generation %d and a different board", sim.Generation, v.generation)
+		}
+	}
+	if v.keyframes != 1 {
+		t.Errorf("%d keyframes sent, want only the first", v.keyframes)
+	}
+	// Publishing an unchanged board sends nothing.
+	b.publish(sim)
+	if len(c.frames) != 0 {
+		t.Errorf("%d frames queued for an unchanged board", len(c.frames))
+	}
+}
+
+// TestStreamSlowClient falls a client behind, for it to get a keyframe in
+// place of what it missed while another keeps up.
+func TestStreamSlowClient(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GridWidth, cfg.GridHeight, cfg.Wrap = 30, 20, true
+	rs := testRun(t, cfg)
+	sim := testSims(t, rs, 5)[0]
+	b := rs.newBoardStream()
+	slow, fast := &streamViewer{t: t}, &streamViewer{t: t}
+	slowClient, fastClient := b.join(sim), b.join(sim)
+	fast.readAll(fastClient)
+	for i := 0; i < 3*streamBuffer; i++ {
+		sim.Step(true)
+		b.publish(sim)
+		fast.readAll(fastClient)
+	}
+	if len(slowClient.frames) > streamBuffer {
+		t.Fatalf("%d frames queued, more than the buffer", len(slowClient.frames))
+	}
+	slow.readAll(slowClient)
+	// What the slow client had queued, its first keyframe included, was
+	// dropped for a later one.
+	if slow.keyframes != 1 || slow.keyGeneration == 0 || fast.keyframes != 1 || fast.keyGeneration != 0 {
+		t.Errorf("the slow client got %d keyframes, the last at generation %d, and the fast one %d, at %d; want one each, the slow client's later", slow.keyframes, slow.keyGeneration, fast.keyframes, fast.keyGeneration)
+	}
+	for _, v := range []*streamViewer{slow, fast} {
+		if !v.matches(sim) {
+			t.Errorf("a client is at generation %d of %d, or its board differs", v.generation, sim.Generation)
+		}
+	}
+}
+
+func TestEncodeDelta(t *testing.T) {
+	from := []uint64{0b1010, 1 << 63}
+	to := []uint64{0b0110, 1}
+	frame := encodeDelta(9, from, to)
+	want := []byte{'D', 9, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 64, 0, 0, 0, 127, 0, 0, 0}
+	if string(frame) != string(want) {
+		t.Errorf("delta is %v, want %v", frame, want)
+	}
+	if frame := encodeDelta(9, to, to); len(frame) != 5 {
+		t.Errorf("a delta between the same boards has %d bytes, want only the header's 5", len(frame))
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// encodeSVG draws the board as an SVG, one unit per cell, with the top row
+// first as it's shown. Runs of same-coloured live cells along a row share
+// one rect, which keeps the file small. grid adds lines between the cells.
+func encodeSVG(cells life.Grid, grid bool) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`+"\n", cells.Columns(), cells.Rows(), 10*cells.Columns(), 10*cells.Rows())
+	fmt.Fprintf(&out, `<rect width="%d" height="%d" fill="#000"/>`+"\n", cells.Columns(), cells.Rows())
+	hex := func(c life.Cell) string {
+		r, g, b := render.CellColour(c)
+		return fmt.Sprintf("#%02x%02x%02x", int(r*255), int(g*255), int(b*255))
+	}
+	for row := 0; row < cells.Rows(); row++ {
+		y := cells.Rows() - 1 - row
+		for x := 0; x < cells.Columns(); {
+			c := cells.At(x, y)
+			if !c.Alive {
+				x++
+				continue
+			}
+			n := 1
+			for x+n < cells.Columns() && cells.Alive(x+n, y) && cells.At(x+n, y).Team == c.Team {
+				n++
+			}
+			fmt.Fprintf(&out, `<rect x="%d" y="%d" width="%d" height="1" fill="%s"/>`+"\n", x, row, n, hex(c))
+			x += n
+		}
+	}
+	if grid {
+		out.WriteString(`<g stroke="#333" stroke-width="0.05">` + "\n")
+		for x := 1; x < cells.Columns(); x++ {
+			fmt.Fprintf(&out, `<line x1="%d" y1="0" x2="%d" y2="%d"/>`+"\n", x, x, cells.Rows())
+		}
+		for y := 1; y < cells.Rows(); y++ {
+			fmt.Fprintf(&out, `<line x1="0" y1="%d" x2="%d" y2="%d"/>`+"\n", y, cells.Columns(), y)
+		}
+		out.WriteString("</g>\n")
+	}
+	out.WriteString("</svg>\n")
+	return out.String()
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"opengl/life"
+)
+
+// svgElements parses an SVG, returning its root and the attributes of each
+// element by name.
+func svgElements(t *testing.T, src string) (xml.StartElement, map[string][]map[string]string) {
+	t.Helper()
+	var root xml.StartElement
+	elements := make(map[string][]map[string]string)
+	d := xml.NewDecoder(strings.NewReader(src))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return root, elements
+		}
+		if err != nil {
+			t.Fatalf("the SVG doesn't parse: %v\n%s", err, src)
+		}
+		if e, ok := tok.(xml.StartElement); ok {
+			if root.Name.Local == "" {
+				root = e
+			}
+			attrs := make(map[string]string)
+			for _, a := range e.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+			elements[e.Name.Local] = append(elements[e.Name.Local], attrs)
+		}
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	// A 10x6 board, drawn top row first: a run of four, a glider, and a
+	// run of three split between two teams.
+	cells := life.NewGrid(10, 6)
+	for x := 2; x <= 5; x++ {
+		cells.Set(x, 5, true)
+	}
+	cells.Stamp(life.Pattern{Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}, 1, 2, false)
+	for x := 6; x <= 8; x++ {
+		cells.SetCell(x, 0, life.Cell{Alive: true, Team: x / 8})
+	}
+
+	root, elements := svgElements(t, encodeSVG(cells, false))
+	if root.Name.Local != "svg" {
+		t.Fatalf("the root element is %s", root.Name.Local)
+	}
+	for _, a := range root.Attr {
+		if a.Name.Local == "viewBox" && a.Value != "0 0 10 6" {
+			t.Errorf("viewBox is %q, want the board's 0 0 10 6", a.Value)
+		}
+	}
+	// The background, then a rect per run: four, one, one, three on the
+	// glider's bottom row, and two and one for the teams.
+	rects := elements["rect"]
+	want := []struct{ x, y, width string }{
+		{"2", "0", "4"}, {"1", "2", "1"}, {"2", "3", "1"}, {"0", "4", "3"}, {"6", "5", "2"}, {"8", "5", "1"},
+	}
+	if len(rects) != 1+len(want) {
+		t.Fatalf("%d rects, want a background and %d runs", len(rects), len(want))
+	}
+	for i, w := range want {
+		r := rects[i+1]
+		if r["x"] != w.x || r["y"] != w.y || r["width"] != w.width || r["height"] != "1" {
+			t.Errorf("run %d is %v, want %s cells from %s, %s", i, r, w.width, w.x, w.y)
+		}
+	}
+	if rects[5]["fill"] == rects[6]["fill"] || rects[1]["fill"] != rects[2]["fill"] {
+		t.Error("the runs aren't coloured by team")
+	}
+	if len(elements["line"]) != 0 {
+		t.Error("lines drawn without the grid")
+	}
+
+	_, elements = svgElements(t, encodeSVG(cells, true))
+	if got := len(elements["line"]); got != 9+5 {
+		t.Errorf("%d grid lines, want 14", got)
+	}
+}
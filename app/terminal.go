@@ -0,0 +1,245 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+// runTerminal runs the boards like runHeadless, but at the tick rate,
+// drawing the first in this terminal and reading keys from it, until q is
+// pressed or it's interrupted.
+func (rs *runState) runTerminal(seeds []int64, rules []life.Rule, stdinPattern []byte) error {
+	// Keys are read from the terminal itself, so that a pattern can still be
+	// piped into standard input.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("-renderer terminal needs a terminal: %w", err)
+	}
+	defer tty.Close()
+	b, err := rs.newBareRun(seeds, rules, stdinPattern)
+	if err != nil {
+		return err
+	}
+	restore, err := rawMode(tty)
+	if err != nil {
+		b.close()
+		return err
+	}
+	defer restore()
+
+	bg := rs.config.Background
+	background := [3]float32{float32(bg.R) / 255, float32(bg.G) / 255, float32(bg.B) / 255}
+	fg := rs.config.Colour
+	render.LiveColour = [3]float32{float32(fg.R) / 255, float32(fg.G) / 255, float32(fg.B) / 255}
+	var t render.Renderer
+	graphics := rs.detectGraphics()
+	if graphics != 0 {
+		image := render.NewTerminalImage(tty, graphics)
+		image.Background = background
+		t = image
+	} else {
+		text := render.NewTerminal(tty, rs.config.TerminalBraille)
+		text.Background = background
+		t = text
+	}
+	if err := t.Init(rs.config.GridWidth, rs.config.GridHeight); err != nil {
+		b.close()
+		return err
+	}
+	defer t.Shutdown()
+	// The last line is kept for the status. Pictures are sized in pixels,
+	// characters in characters.
+	width, height := terminalSize(tty)
+	pixelWidth, pixelHeight := terminalPixels(tty, width, height)
+	resize := func() {
+		if graphics != 0 {
+			t.Resize(pixelWidth, pixelHeight*(height-1)/height)
+		} else {
+			t.Resize(width, height-1)
+		}
+	}
+	resize()
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := tty.Read(buf); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+	resized := time.NewTicker(time.Second)
+	defer resized.Stop()
+
+	rate := rs.config.TickRate
+	controls := b.controls()
+	// Pictures are sent at most at the tick rate, however often keys ask
+	// for them; one asked for too soon is sent when flush fires.
+	var sent time.Time
+	var flush <-chan time.Time
+	draw := func() {
+		sim := b.sims[0]
+		if wait := interval(rate) - time.Since(sent); graphics != 0 && wait > 0 {
+			if flush == nil {
+				flush = time.After(wait)
+			}
+		} else {
+			sent = time.Now()
+			if err := t.DrawFrame(sim.Cells, render.View{}); err != nil {
+				return
+			}
+		}
+		state := ""
+		if b.paused {
+			state = "  paused"
+		}
+		status := fmt.Sprintf("Generation %d  population %d  %g/s%s  space pause, n step, +/- speed, q quit", sim.Generation, sim.Cells.Population(), rate, state)
+		fmt.Fprintf(tty, "\x1b[%d;1H%.*s\x1b[K", height, width, status)
+	}
+	draw()
+	nextStep := time.Now().Add(interval(rate))
+	for {
+		wait := time.Until(nextStep)
+		if b.paused {
+			wait = time.Hour
+		}
+		select {
+		case k, ok := <-keys:
+			switch {
+			case !ok, k == 'q', k == 'Q', k == 3: // 3 is ctrl+C, which raw mode passes on as a key.
+				return b.close()
+			case k == ' ':
+				b.paused = !b.paused
+				nextStep = time.Now().Add(interval(rate))
+			case k == 'n' || k == '.':
+				if b.paused {
+					b.step()
+				}
+				if b.stopped {
+					return b.close()
+				}
+			case k == '+' || k == '=':
+				rate = min(rate*2, maxFPS)
+			case k == '-' || k == '_':
+				rate = max(rate/2, minFPS)
+			}
+			if time.Until(nextStep) > interval(rate) {
+				nextStep = time.Now().Add(interval(rate))
+			}
+			draw()
+		case req := <-rs.remote.pending():
+			rs.remote.run(req, controls)
+			if b.stopped {
+				return b.close()
+			}
+			draw()
+		case <-rs.shutdown:
+			return b.close()
+		case <-flush:
+			flush = nil
+			draw()
+		case <-resized.C:
+			w, h := terminalSize(tty)
+			pw, ph := terminalPixels(tty, w, h)
+			if w != width || h != height || pw != pixelWidth || ph != pixelHeight {
+				width, height, pixelWidth, pixelHeight = w, h, pw, ph
+				resize()
+				draw()
+			}
+		case <-time.After(wait):
+			b.step()
+			if b.stopped {
+				return b.close()
+			}
+			nextStep = nextStep.Add(interval(rate))
+			// After falling behind, carry on from now rather than catch up.
+			if time.Until(nextStep) < -interval(rate) {
+				nextStep = time.Now().Add(interval(rate))
+			}
+			draw()
+		}
+	}
+}
+
+// detectGraphics returns the graphics protocol -terminal-graphics names, or
+// for auto, unless -terminal-braille, the one the environment says the
+// terminal has, if any. TERM carries over SSH; the rest only say what the
+// terminal running this is.
+func (rs *runState) detectGraphics() render.Graphics {
+	switch rs.config.TerminalGraphics {
+	case "sixel":
+		return render.Sixel
+	case "kitty":
+		return render.Kitty
+	case "off":
+		return 0
+	}
+	if rs.config.TerminalBraille {
+		return 0
+	}
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case term == "xterm-kitty", term == "xterm-ghostty", os.Getenv("KITTY_WINDOW_ID") != "", program == "WezTerm", program == "ghostty":
+		return render.Kitty
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"), strings.HasPrefix(term, "yaft"), strings.HasPrefix(term, "contour"):
+		return render.Sixel
+	}
+	return 0
+}
+
+// rawMode puts tty into raw mode, without echo, with stty, returning a
+// function that puts it back how it was.
+func rawMode(tty *os.File) (func(), error) {
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("reading the terminal's settings: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("putting the terminal in raw mode: %w", err)
+	}
+	return func() { stty(tty, strings.TrimSpace(saved)) }, nil
+}
+
+// terminalSize returns tty's size in characters, or 80 by 24 if stty can't
+// tell.
+func terminalSize(tty *os.File) (width, height int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		_, err = fmt.Sscanf(out, "%d %d", &height, &width)
+	}
+	if err != nil || width < 1 || height < 2 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// terminalPixels returns tty's size in pixels, or, if it can't tell, a
+// width by height characters terminal's with characters of a common size.
+func terminalPixels(tty *os.File, width, height int) (int, int) {
+	if w, h, ok := ttyPixels(tty); ok {
+		return w, h
+	}
+	return width * 8, height * 16
+}
+
+// stty runs stty with args on tty, returning what it prints.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		err = errors.New(strings.TrimSpace(string(exit.Stderr)))
+	}
+	return string(out), err
+}
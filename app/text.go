@@ -0,0 +1,97 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	// textScale is the size, in window pixels, of one font pixel.
+	textScale = 2
+	// textAdvance and textLineHeight are the horizontal and vertical
+	// distances between characters, in font pixels.
+	textAdvance    = glyphWidth + 1
+	textLineHeight = glyphHeight + 3
+)
+
+// text draws strings in the bitmap font as screen-space quads, one per lit
+// font pixel. Strings are queued with print and all drawn at once.
+type text struct {
+	rs *runState
+
+	program *overlayProgram
+	quads   *lines
+	// scale is the size, in window pixels, of one font pixel.
+	scale float32
+}
+
+func (rs *runState) newText(program *overlayProgram, maxChars int) *text {
+	return &text{
+		rs:      rs,
+		program: program,
+		quads:   rs.newLines("text", 6*glyphWidth*glyphHeight*maxChars),
+		scale:   textScale,
+	}
+}
+
+func (t *text) reset() {
+	t.quads.reset()
+}
+
+// print queues s with its top-left corner at (x, y) in normalized device
+// coordinates. Newlines start a new line below.
+func (t *text) print(s string, x, y float32) {
+	px, py := 2*t.scale/float32(t.rs.config.WindowWidth), 2*t.scale/float32(t.rs.config.WindowHeight)
+	cx, cy := x, y
+	for _, r := range s {
+		if r == '\n' {
+			cx, cy = x, cy-textLineHeight*py
+			continue
+		}
+		rowBits := glyph(r)
+		for row, bits := range rowBits {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				x0 := cx + float32(col)*px
+				y1 := cy - float32(row)*py
+				x1, y0 := x0+px, y1-py
+				t.quads.add(x0, y0)
+				t.quads.add(x1, y0)
+				t.quads.add(x1, y1)
+				t.quads.add(x0, y0)
+				t.quads.add(x1, y1)
+				t.quads.add(x0, y1)
+			}
+		}
+		cx += textAdvance * px
+	}
+}
+
+// textSize returns the width and height s would take up when printed, in
+// normalized device coordinates.
+func (rs *runState) textSize(s string) (float32, float32) {
+	px, py := rs.textPixel()
+	lines, longest, n := 1, 0, 0
+	for _, r := range s {
+		if r == '\n' {
+			lines++
+			n = 0
+			continue
+		}
+		n++
+		longest = max(longest, n)
+	}
+	return float32(longest*textAdvance) * px, float32(lines*textLineHeight) * py
+}
+
+// textPixel returns the size of one font pixel in normalized device
+// coordinates.
+func (rs *runState) textPixel() (float32, float32) {
+	return 2 * textScale / float32(rs.config.WindowWidth), 2 * textScale / float32(rs.config.WindowHeight)
+}
+
+func (t *text) draw(r, g, b, a float32) {
+	t.program.use(r, g, b, a)
+	t.quads.draw(gl.TRIANGLES)
+}
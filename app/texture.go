@@ -0,0 +1,49 @@
+package app
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+)
+
+// boardTexture mirrors the alive state of every cell in a single-channel
+// texture, one texel per cell, with texel (0, 0) at the bottom-left cell.
+type boardTexture struct {
+	rs *runState
+
+	id     uint32
+	texels []uint8
+}
+
+func (rs *runState) newBoardTexture() *boardTexture {
+	t := &boardTexture{rs: rs, texels: make([]uint8, rs.config.GridWidth*rs.config.GridHeight)}
+
+	t.id = render.Gen(render.Texture, "board")
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(rs.config.GridWidth), int32(rs.config.GridHeight), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(t.texels))
+	render.SetSize(render.Texture, t.id, len(t.texels))
+	rs.freeOnClose(func() { render.Delete(render.Texture, t.id) })
+
+	return t
+}
+
+func (t *boardTexture) upload(cells life.Grid) {
+	for y := 0; y < cells.Rows(); y++ {
+		for x := 0; x < cells.Columns(); x++ {
+			var v uint8
+			if cells.Alive(x, y) {
+				v = 255
+			}
+			t.texels[y*t.rs.config.GridWidth+x] = v
+		}
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(t.rs.config.GridWidth), int32(t.rs.config.GridHeight), gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(t.texels))
+	render.Uploaded(len(t.texels))
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+
+	"opengl/life"
+)
+
+// generationCounter shows the board's generation in the bottom-left corner,
+// including in recordings, where a time-lapse would otherwise give no sign
+// of how far apart its frames are.
+type generationCounter struct {
+	rs *runState
+
+	sim  *life.Simulation
+	text *text
+}
+
+func (rs *runState) newGenerationCounter(sim *life.Simulation, program *overlayProgram) *generationCounter {
+	return &generationCounter{rs: rs, sim: sim, text: rs.newText(program, 32)}
+}
+
+func (g *generationCounter) draw() {
+	_, h := g.rs.textSize("")
+	g.text.reset()
+	g.text.print(fmt.Sprintf("Generation %d", g.sim.Generation), -1, -1+h)
+	g.text.draw(1, 1, 1, 1)
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+const (
+	timelineMinX = -0.9
+	timelineMaxX = 0.9
+	timelineMinY = -0.985
+	timelineMaxY = -0.955
+)
+
+// timeline is a bar along the bottom of the window spanning the generations
+// from when the boards were last edited or started afresh to the latest
+// they've reached since, with a marker at the one they're at. Dragging the
+// marker and letting go seeks to the generation it's at: the boards are
+// taken back to the nearest checkpoint before it, or to how they were at
+// the bar's start, and stepped flat out to it, then paused there.
+// Nothing before the bar's start is known to lead up to the boards, so it
+// can't be sought.
+//
+// Like checkpoints, a seek keeps which cells are alive but not their ages.
+type timeline struct {
+	rs *runState
+
+	visible bool
+
+	sims        []*life.Simulation
+	checkpoints *checkpointer
+	// origin is the boards at the start of the bar.
+	origin []savestate
+	// latest is the latest generation reached, the end of the bar.
+	latest int
+
+	// dragging is set while the marker is held at target, and seeking
+	// while the boards are being stepped from from on to target.
+	dragging, seeking bool
+	target, from      int
+
+	program *overlayProgram
+	bar     *lines
+	label   *text
+}
+
+func (rs *runState) newTimeline(sims []*life.Simulation, program *overlayProgram) *timeline {
+	t := &timeline{
+		rs:      rs,
+		sims:    sims,
+		program: program,
+		bar:     rs.newLines("timeline", 12),
+		label:   rs.newText(program, 64),
+	}
+	t.restart()
+	return t
+}
+
+// restart starts the bar again at the boards as they are, which have been
+// edited or started afresh, cancelling any seek.
+func (t *timeline) restart() {
+	t.origin = t.origin[:0]
+	for _, sim := range t.sims {
+		t.origin = append(t.origin, t.rs.newSavestate(sim))
+	}
+	t.latest = t.sims[0].Generation
+	t.dragging, t.seeking = false, false
+	t.checkpoints.restart()
+}
+
+// start is the generation at the start of the bar.
+func (t *timeline) start() int {
+	return t.origin[0].generation
+}
+
+// stepped moves the end of the bar on to the boards' generation.
+func (t *timeline) stepped() {
+	t.latest = max(t.latest, t.sims[0].Generation)
+}
+
+// contains reports whether the point, in normalized device coordinates, is
+// on the bar, or near enough to it to pick it up.
+func (t *timeline) contains(x, y float32) bool {
+	return t.visible && x >= timelineMinX && x <= timelineMaxX && y >= timelineMinY-0.02 && y <= timelineMaxY+0.02
+}
+
+// at returns the generation at x along the bar.
+func (t *timeline) at(x float32) int {
+	f := (clamp(x, timelineMinX, timelineMaxX) - timelineMinX) / (timelineMaxX - timelineMinX)
+	return t.start() + int(f*float32(t.latest-t.start())+0.5)
+}
+
+// x returns where along the bar generation is.
+func (t *timeline) x(generation int) float32 {
+	f := float32(0)
+	if t.latest > t.start() {
+		f = float32(generation-t.start()) / float32(t.latest-t.start())
+	}
+	return timelineMinX + clamp(f, 0, 1)*(timelineMaxX-timelineMinX)
+}
+
+// press picks up the marker, moving it to x.
+func (t *timeline) press(x float32) {
+	t.dragging, t.target = true, t.at(x)
+}
+
+func (t *timeline) drag(x float32) {
+	t.target = t.at(x)
+}
+
+// release lets go of the marker, returning the generation to seek to.
+func (t *timeline) release() int {
+	t.dragging = false
+	return t.target
+}
+
+// seek puts the boards where seeking to target starts from: the latest of
+// the boards as they are, the latest checkpoint and the start of the bar
+// that's no later than target.
+func (t *timeline) seek(target int) error {
+	target = min(max(target, t.start()), t.latest)
+	at := t.sims[0].Generation
+	path, generation, ok := t.checkpoints.latestBefore(target)
+	switch {
+	case at <= target && (!ok || at >= generation):
+	case ok && generation >= t.start():
+		st, err := t.rs.readState(path, len(t.sims))
+		if err != nil {
+			return fmt.Errorf("the checkpoint to seek from: %w", err)
+		}
+		// The camera stays where it is.
+		st.restore(t.rs, t.sims, t.rs.newCamera())
+	default:
+		for i, sim := range t.sims {
+			t.origin[i].restore(t.rs, sim)
+		}
+	}
+	t.seeking, t.target, t.from = true, target, t.sims[0].Generation
+	return nil
+}
+
+// advance steps the boards on towards the generation being sought for up
+// to budget, reporting whether they've reached it.
+func (t *timeline) advance(budget time.Duration) bool {
+	for start := time.Now(); t.sims[0].Generation < t.target && time.Since(start) < budget; {
+		for _, sim := range t.sims {
+			sim.Step(t.rs.config.Wrap)
+		}
+	}
+	if t.sims[0].Generation >= t.target {
+		t.seeking = false
+	}
+	return !t.seeking
+}
+
+// cancel stops seeking where the boards have got to, the next time they're
+// advanced.
+func (t *timeline) cancel() {
+	t.target = t.sims[0].Generation
+}
+
+func (t *timeline) draw() {
+	if !t.visible {
+		return
+	}
+	shade(t.bar, t.program, timelineMinX, timelineMinY, timelineMaxX, timelineMaxY, 0.6)
+	t.bar.reset()
+	t.bar.rect(timelineMinX, timelineMinY, timelineMaxX, timelineMaxY)
+	t.program.use(0.6, 0.6, 0.6, 1)
+	t.bar.draw(gl.LINE_LOOP)
+
+	at := t.sims[0].Generation
+	caption := fmt.Sprintf("%d", at)
+	switch {
+	case t.seeking:
+		done := float32(at-t.from) / float32(max(t.target-t.from, 1))
+		caption = fmt.Sprintf("Seeking %d: %d%% - space stops", t.target, int(100*done))
+		at = t.target
+	case t.dragging:
+		caption, at = fmt.Sprintf("%d", t.target), t.target
+	}
+	x := t.x(at)
+	t.bar.reset()
+	t.bar.add(x, timelineMinY-0.01)
+	t.bar.add(x, timelineMaxY+0.01)
+	t.program.use(1, 0.8, 0.2, 1)
+	t.bar.draw(gl.LINES)
+
+	w, h := t.rs.textSize(caption)
+	t.label.reset()
+	t.label.print(caption, clamp(x-w/2, -1, 1-w), timelineMaxY+0.01+h)
+	t.label.draw(1, 1, 1, 1)
+}
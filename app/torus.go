@@ -0,0 +1,150 @@
+package app
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+)
+
+const (
+	torusMajorRadius = 1.0
+	torusMinorRadius = 0.4
+	// torusSpeed is how fast, in radians per second, the torus turns.
+	torusSpeed = 0.3
+)
+
+// torusMesh builds a torus around the z axis as an indexed triangle list of
+// x, y, z, nx, ny, nz, u, v vertices. u runs once around the ring and v once
+// around the tube, so a texture of the board maps the cell columns around the
+// ring and the rows around the tube. The seams repeat their first ring of
+// vertices with u or v of 1 so the texture doesn't wrap back across a face.
+func torusMesh(major, minor int, R, r float32) ([]float32, []uint32) {
+	vertices := make([]float32, 0, 8*(major+1)*(minor+1))
+	for i := 0; i <= major; i++ {
+		u := float32(i) / float32(major)
+		theta := 2 * math.Pi * float64(u)
+		for j := 0; j <= minor; j++ {
+			v := float32(j) / float32(minor)
+			phi := 2 * math.Pi * float64(v)
+			nx := float32(math.Cos(phi) * math.Cos(theta))
+			ny := float32(math.Cos(phi) * math.Sin(theta))
+			nz := float32(math.Sin(phi))
+			ring := R + r*float32(math.Cos(phi))
+			vertices = append(vertices,
+				ring*float32(math.Cos(theta)), ring*float32(math.Sin(theta)), r*nz,
+				nx, ny, nz,
+				u, v,
+			)
+		}
+	}
+
+	indices := make([]uint32, 0, 6*major*minor)
+	for i := 0; i < major; i++ {
+		for j := 0; j < minor; j++ {
+			a := uint32(i*(minor+1) + j)
+			b := uint32((i+1)*(minor+1) + j)
+			indices = append(indices, a, b, a+1, a+1, b, b+1)
+		}
+	}
+	return vertices, indices
+}
+
+// torus wraps the board texture around a slowly turning 3D torus, which is
+// what a board with wrapped edges actually is.
+type torus struct {
+	rs *runState
+
+	angle float64
+
+	board   *boardTexture
+	program *render.ShaderProgram
+	vao     uint32
+	vbo     uint32
+	ebo     uint32
+	count   int32
+}
+
+func (rs *runState) newTorus(board *boardTexture) (*torus, error) {
+	program, err := render.NewProgram("torus")
+	if err != nil {
+		return nil, err
+	}
+
+	major, minor := rs.config.TorusMajor, rs.config.TorusMinor
+	if major == 0 {
+		major = 4 * rs.config.GridWidth
+	}
+	if minor == 0 {
+		minor = 2 * rs.config.GridHeight
+	}
+	vertices, indices := torusMesh(max(major, 3), max(minor, 3), torusMajorRadius, torusMinorRadius)
+	t := &torus{
+		rs:      rs,
+		board:   board,
+		program: program,
+		count:   int32(len(indices)),
+	}
+
+	t.vao = render.Gen(render.VertexArray, "torus")
+	gl.BindVertexArray(t.vao)
+
+	t.vbo = render.Gen(render.Buffer, "torus vertices")
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	render.SetSize(render.Buffer, t.vbo, 4*len(vertices))
+	t.ebo = render.Gen(render.Buffer, "torus indices")
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, t.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
+	render.SetSize(render.Buffer, t.ebo, 4*len(indices))
+
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 32, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 32, gl.PtrOffset(12))
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 32, gl.PtrOffset(24))
+	rs.freeOnClose(func() {
+		render.Delete(render.VertexArray, t.vao)
+		render.Delete(render.Buffer, t.vbo, t.ebo)
+		t.program.Delete()
+	})
+
+	return t, nil
+}
+
+func (t *torus) update(dt float64) {
+	t.angle += torusSpeed * dt
+}
+
+func (t *torus) draw(cells life.Grid) {
+	sin, cos := float32(math.Sin(t.angle)), float32(math.Cos(t.angle))
+	model := mat4{
+		cos, sin, 0, 0,
+		-sin, cos, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+	mvp := perspective(math.Pi/4, float32(t.rs.config.WindowWidth)/float32(t.rs.config.WindowHeight), 0.1, 10).
+		mul(lookAt(vec3{0, -2.6, 2.2}, vec3{0, 0, 0}, vec3{0, 0, 1})).
+		mul(model)
+
+	gl.Enable(gl.DEPTH_TEST)
+	t.program.Use()
+	t.program.SetMat4("mvp", mvp)
+	t.program.SetMat4("model", model)
+	t.program.SetVec2("board_size", float32(t.rs.config.GridWidth), float32(t.rs.config.GridHeight))
+	// The torus is textured rather than built from cells, so wireframe mode
+	// outlines the cells on its surface instead of its triangles.
+	var grid int32
+	if t.rs.wireframe {
+		grid = 1
+	}
+	t.program.SetInt("show_grid", grid)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, t.board.id)
+	gl.BindVertexArray(t.vao)
+	gl.DrawElements(gl.TRIANGLES, t.count, gl.UNSIGNED_INT, nil)
+	gl.Disable(gl.DEPTH_TEST)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTorusMeshCounts(t *testing.T) {
+	for _, size := range [][2]int{{3, 3}, {48, 24}, {64, 7}} {
+		major, minor := size[0], size[1]
+		vertices, indices := torusMesh(major, minor, 1, 0.4)
+		if want := 8 * (major + 1) * (minor + 1); len(vertices) != want {
+			t.Errorf("%dx%d mesh has %d floats, want %d", major, minor, len(vertices), want)
+		}
+		if want := 6 * major * minor; len(indices) != want {
+			t.Errorf("%dx%d mesh has %d indices, want %d", major, minor, len(indices), want)
+		}
+		for _, i := range indices {
+			if int(i) >= len(vertices)/8 {
+				t.Fatalf("%dx%d mesh indexes vertex %d of %d", major, minor, i, len(vertices)/8)
+			}
+		}
+	}
+}
+
+// TestTorusMeshSeams checks the vertices repeated along each seam sit where
+// the first ring does, with u or v of 1 rather than 0, and that every step
+// of u or v is the same so a board's cells each get as much of the torus.
+func TestTorusMeshSeams(t *testing.T) {
+	const major, minor = 12, 8
+	vertices, _ := torusMesh(major, minor, 1, 0.4)
+	vertex := func(i, j int) []float32 { k := 8 * (i*(minor+1) + j); return vertices[k : k+8] }
+	near := func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-5 }
+	samePlace := func(a, b []float32) bool {
+		for k := 0; k < 6; k++ {
+			if !near(a[k], b[k]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for j := 0; j <= minor; j++ {
+		first, last := vertex(0, j), vertex(major, j)
+		if !samePlace(first, last) || first[6] != 0 || last[6] != 1 || first[7] != last[7] {
+			t.Errorf("ring seam at v %d: %v doesn't meet %v", j, first, last)
+		}
+	}
+	for i := 0; i <= major; i++ {
+		first, last := vertex(i, 0), vertex(i, minor)
+		if !samePlace(first, last) || first[7] != 0 || last[7] != 1 || first[6] != last[6] {
+			t.Errorf("tube seam at u %d: %v doesn't meet %v", i, first, last)
+		}
+	}
+	for i := 0; i <= major; i++ {
+		for j := 0; j <= minor; j++ {
+			v := vertex(i, j)
+			if !near(v[6], float32(i)/major) || !near(v[7], float32(j)/minor) {
+				t.Fatalf("vertex %d, %d has uv %v, %v", i, j, v[6], v[7])
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package app
+
+import "os"
+
+// ttyPixels can't tell tty's size in pixels here.
+func ttyPixels(tty *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package app
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ttyPixels returns tty's size in pixels, as the terminal reports it, which
+// not all do.
+func ttyPixels(tty *os.File) (width, height int, ok bool) {
+	var size struct{ rows, columns, width, height uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	return int(size.width), int(size.height), errno == 0 && size.width > 0 && size.height > 0
+}
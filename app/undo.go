@@ -0,0 +1,147 @@
+package app
+
+import (
+	"opengl/life"
+)
+
+// cellState is what an edit can change about a cell.
+type cellState struct {
+	alive bool
+	age   int
+}
+
+// cellChange is one cell's state before and after an edit.
+type cellChange struct {
+	x, y          int
+	before, after cellState
+}
+
+// boardEdit is the cells an edit changed on one board.
+type boardEdit struct {
+	sim     *life.Simulation
+	changes []cellChange
+}
+
+// undoHistory records edits to the boards as the cells they changed, so
+// they can be undone and redone. An edit is everything between begin and
+// commit, which may be a single click or a whole drag.
+type undoHistory struct {
+	sims []*life.Simulation
+	// before holds a copy of each board as it was when the edit began, or
+	// is nil outside an edit.
+	before     []life.Grid
+	undo, redo [][]boardEdit
+	// changed, if set, is told of every edit, undo and redo, with whether
+	// the cells were left as they were after the edit or before it.
+	changed func(edit []boardEdit, after bool)
+}
+
+func newUndoHistory(sims []*life.Simulation) *undoHistory {
+	return &undoHistory{sims: sims}
+}
+
+// begin starts recording an edit. It does nothing if one is in progress,
+// so edits made up of other edits are undone as one.
+func (u *undoHistory) begin() {
+	if u.before != nil {
+		return
+	}
+	u.before = make([]life.Grid, len(u.sims))
+	for i, sim := range u.sims {
+		u.before[i] = sim.Cells.Clone()
+	}
+}
+
+// commit finishes the edit in progress, keeping it for undo if it changed
+// anything.
+func (u *undoHistory) commit() {
+	if u.before == nil {
+		return
+	}
+	var edit []boardEdit
+	for i, sim := range u.sims {
+		var changes []cellChange
+		for x := 0; x < sim.Cells.Columns(); x++ {
+			for y := 0; y < sim.Cells.Rows(); y++ {
+				b, a := u.before[i].At(x, y), sim.Cells.At(x, y)
+				if b.Alive != a.Alive {
+					changes = append(changes, cellChange{x, y, cellState{b.Alive, b.Age}, cellState{a.Alive, a.Age}})
+				}
+			}
+		}
+		if len(changes) > 0 {
+			edit = append(edit, boardEdit{sim, changes})
+		}
+	}
+	u.before = nil
+	if len(edit) > 0 {
+		u.undo = append(u.undo, edit)
+		u.redo = nil
+		u.notify(edit, true)
+	}
+}
+
+// edit records whatever f changes as one edit, or as part of the edit in
+// progress if there is one.
+func (u *undoHistory) edit(f func()) {
+	if u.before != nil {
+		f()
+		return
+	}
+	u.begin()
+	defer u.commit()
+	f()
+}
+
+// stepped forgets the redo history, since the boards have moved on from the
+// edits it would put back.
+func (u *undoHistory) stepped() {
+	u.redo = nil
+}
+
+// undoLast reverts the most recent edit, reporting whether there was one.
+func (u *undoHistory) undoLast() bool {
+	if len(u.undo) == 0 {
+		return false
+	}
+	edit := u.undo[len(u.undo)-1]
+	u.undo = u.undo[:len(u.undo)-1]
+	apply(edit, false)
+	u.notify(edit, false)
+	u.redo = append(u.redo, edit)
+	return true
+}
+
+// redoLast puts back the most recently undone edit, reporting whether there
+// was one.
+func (u *undoHistory) redoLast() bool {
+	if len(u.redo) == 0 {
+		return false
+	}
+	edit := u.redo[len(u.redo)-1]
+	u.redo = u.redo[:len(u.redo)-1]
+	apply(edit, true)
+	u.notify(edit, true)
+	u.undo = append(u.undo, edit)
+	return true
+}
+
+func (u *undoHistory) notify(edit []boardEdit, after bool) {
+	if u.changed != nil {
+		u.changed(edit, after)
+	}
+}
+
+// apply sets the edited cells to their state after the edit, or before it.
+func apply(edit []boardEdit, after bool) {
+	for _, e := range edit {
+		for _, ch := range e.changes {
+			state := ch.before
+			if after {
+				state = ch.after
+			}
+			team := e.sim.Cells.At(ch.x, ch.y).Team
+			e.sim.Cells.SetCell(ch.x, ch.y, life.Cell{Alive: state.alive, Age: state.age, Team: team})
+		}
+	}
+}
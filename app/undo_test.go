@@ -0,0 +1,111 @@
+package app
+
+import (
+	"testing"
+
+	"opengl/life"
+)
+
+func TestUndoRedo(t *testing.T) {
+	sim := life.NewSimulation(life.NewGrid(16, 16), life.Conway, 1, 0, 0)
+	u := newUndoHistory([]*life.Simulation{sim})
+	blank := sim.Cells.Clone()
+
+	u.edit(func() { sim.Cells.Set(3, 3, true) })
+	one := sim.Cells.Clone()
+	// A drag is one edit however many cells it paints.
+	u.begin()
+	for x := 5; x < 10; x++ {
+		u.edit(func() { sim.Cells.Set(x, 8, true) })
+	}
+	u.commit()
+	two := sim.Cells.Clone()
+	// An edit that changes nothing isn't kept.
+	u.edit(func() { sim.Cells.Set(3, 3, true) })
+	if len(u.undo) != 2 || len(u.undo[1][0].changes) != 5 {
+		t.Fatalf("%d edits kept, the last of %d cells, want 2 with the drag's 5", len(u.undo), len(u.undo[len(u.undo)-1][0].changes))
+	}
+
+	for _, step := range []struct {
+		do   func() bool
+		ok   bool
+		want life.Grid
+	}{
+		{u.undoLast, true, one},
+		{u.undoLast, true, blank},
+		{u.undoLast, false, blank},
+		{u.redoLast, true, one},
+		{u.redoLast, true, two},
+		{u.redoLast, false, two},
+		{u.undoLast, true, one},
+	} {
+		if ok := step.do(); ok != step.ok || !sim.Cells.Same(step.want) {
+			t.Fatalf("undo or redo reported %v, leaving %d cells", ok, sim.Cells.Population())
+		}
+	}
+
+	// A fresh edit drops what could have been redone.
+	u.edit(func() { sim.Cells.Set(0, 0, true) })
+	if u.redoLast() {
+		t.Fatal("an edit left something to redo")
+	}
+}
+
+// TestUndoAfterStepping interleaves edits, undos and steps: an undo after
+// a step puts back only the cells the edit changed, and a step drops what
+// could have been redone.
+func TestUndoAfterStepping(t *testing.T) {
+	sim := life.NewSimulation(life.NewGrid(16, 16), life.Conway, 1, 0, 0)
+	u := newUndoHistory([]*life.Simulation{sim})
+	step := func() {
+		sim.Step(false)
+		u.stepped()
+	}
+
+	// A blinker, then a lone cell far from it.
+	u.edit(func() {
+		for x := 2; x <= 4; x++ {
+			sim.Cells.Set(x, 3, true)
+		}
+	})
+	u.edit(func() { sim.Cells.Set(12, 12, true) })
+	step()
+	if sim.Cells.Population() != 3 || !sim.Cells.Alive(3, 4) || sim.Cells.Alive(12, 12) {
+		t.Fatal("the blinker didn't turn, or the lone cell lived")
+	}
+	// Undoing the lone cell's edit brings it back to before it was set,
+	// which is as it is now.
+	u.undoLast()
+	if sim.Cells.Population() != 3 {
+		t.Fatalf("undoing an edit a step ago left %d cells", sim.Cells.Population())
+	}
+	step()
+	if u.redoLast() {
+		t.Fatal("a step left something to redo")
+	}
+	// The blinker is back as it was drawn; undoing it kills the row it
+	// was drawn on and leaves the rest.
+	u.edit(func() { sim.Cells.Set(8, 8, true) })
+	u.undoLast()
+	u.undoLast()
+	if sim.Cells.Population() != 0 {
+		t.Fatalf("undoing the blinker left %d cells", sim.Cells.Population())
+	}
+	u.redoLast()
+	if sim.Cells.Population() != 3 || !sim.Cells.Alive(2, 3) {
+		t.Fatalf("redoing the blinker left %d cells", sim.Cells.Population())
+	}
+}
+
+func TestUndoNotifies(t *testing.T) {
+	sim := life.NewSimulation(life.NewGrid(8, 8), life.Conway, 1, 0, 0)
+	u := newUndoHistory([]*life.Simulation{sim})
+	var got []bool
+	u.changed = func(edit []boardEdit, after bool) { got = append(got, after) }
+	u.edit(func() { sim.Cells.Set(1, 1, true) })
+	u.undoLast()
+	u.redoLast()
+	if len(got) != 3 || !got[0] || got[1] || !got[2] {
+		t.Fatalf("notified %v, want after, before, after", got)
+	}
+}
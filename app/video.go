@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"opengl/render"
+)
+
+// videoQueue is how many frames can wait to be written to ffmpeg.
+const videoQueue = 4
+
+// videoRecorder streams frames to an ffmpeg process as raw RGBA video.
+type videoRecorder struct {
+	rs *runState
+
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	target   *render.Target
+	interval time.Duration
+	next     time.Time
+	dropped  int
+
+	frames  chan []byte
+	written sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+}
+
+// checkFFmpeg reports whether ffmpeg can be run, so a missing one is found
+// at startup rather than when the first frame is due.
+func checkFFmpeg() error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return errors.New("-record-video needs ffmpeg, which isn't on the PATH")
+	}
+	return nil
+}
+
+// newVideoRecorder starts ffmpeg encoding frames of the given size to path.
+func (rs *runState) newVideoRecorder(path string, width, height int) (*videoRecorder, error) {
+	fps := max(rs.config.VideoFPS, 1)
+	cmd := exec.Command("ffmpeg", "-loglevel", "error", "-y",
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", width, height),
+		"-r", strconv.FormatFloat(fps, 'g', -1, 64), "-i", "-",
+		"-pix_fmt", "yuv420p", path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	target, err := render.NewTarget(width, height)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		target.Delete()
+		return nil, err
+	}
+	v := &videoRecorder{
+		rs:       rs,
+		cmd:      cmd,
+		stdin:    stdin,
+		target:   target,
+		interval: time.Duration(float64(time.Second) / fps),
+		next:     time.Now(),
+		frames:   make(chan []byte, videoQueue),
+	}
+	v.written.Add(1)
+	go v.write()
+	return v, nil
+}
+
+func (v *videoRecorder) write() {
+	defer v.written.Done()
+	for frame := range v.frames {
+		if v.failed() != nil {
+			continue
+		}
+		if _, err := v.stdin.Write(frame); err != nil {
+			v.mu.Lock()
+			v.err = fmt.Errorf("ffmpeg stopped taking frames: %w", err)
+			v.mu.Unlock()
+		}
+	}
+}
+
+func (v *videoRecorder) failed() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.err
+}
+
+// capture renders the scene as the next frame once one is due.
+func (v *videoRecorder) capture(sc *scene, now time.Time) error {
+	if err := v.failed(); err != nil {
+		return err
+	}
+	if now.Before(v.next) {
+		return nil
+	}
+	v.next = v.next.Add(v.interval)
+	// Don't try to catch up on frames missed while the window was stalled.
+	if v.next.Before(now) {
+		v.next = now.Add(v.interval)
+	}
+	return v.frame(sc)
+}
+
+// frame renders the scene as the next frame straight away, for recordings
+// driven by something other than the clock.
+func (v *videoRecorder) frame(sc *scene) error {
+	if err := v.failed(); err != nil {
+		return err
+	}
+	frame := sc.recordFrame(v.target).Pix
+	if !v.rs.config.VideoDrop {
+		v.frames <- frame
+		return nil
+	}
+	select {
+	case v.frames <- frame:
+	default:
+		v.dropped++
+	}
+	return nil
+}
+
+// stop waits for the queued frames to be written and for ffmpeg to finish
+// the file.
+func (v *videoRecorder) stop() error {
+	close(v.frames)
+	v.written.Wait()
+	v.target.Delete()
+	v.stdin.Close()
+	if err := v.cmd.Wait(); err != nil && v.failed() == nil {
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	return v.failed()
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// parseSeeds parses the -compare-seeds list.
+func parseSeeds(list string) ([]int64, error) {
+	fields := strings.Split(list, ",")
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("-compare-seeds needs exactly two seeds, got %q", list)
+	}
+	seeds := make([]int64, len(fields))
+	for i, f := range fields {
+		s, err := strconv.ParseInt(strings.TrimSpace(f), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q in -compare-seeds: %v", f, err)
+		}
+		seeds[i] = s
+	}
+	return seeds, nil
+}
+
+// parseViews parses a -views layout such as "2x2" into columns and rows.
+func parseViews(layout string) (int, int, error) {
+	var columns, rows int
+	if _, err := fmt.Sscanf(strings.ToLower(layout), "%dx%d", &columns, &rows); err != nil || columns < 1 || rows < 1 {
+		return 0, 0, fmt.Errorf("invalid -views layout %q: want the form 2x2", layout)
+	}
+	return columns, rows, nil
+}
+
+// parseRules parses the comma-separated -rules list, which must name either
+// one rule for every view or n rules.
+func parseRules(list string, n int) ([]life.Rule, error) {
+	var rules []life.Rule
+	for _, f := range strings.Split(list, ",") {
+		r, err := life.ParseRule(f)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	if len(rules) == 1 {
+		for len(rules) < n {
+			rules = append(rules, rules[0])
+		}
+	}
+	if len(rules) != n {
+		return nil, fmt.Errorf("got %d rules for %d views", len(rules), n)
+	}
+	return rules, nil
+}
+
+// loadRuleScript loads the -rule-script file at path.
+func loadRuleScript(path string) (life.Rule, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return life.Rule{}, err
+	}
+	return life.ParseRuleScript(filepath.Base(path), string(src))
+}
+
+// gollyRules are the -golly-rule files registered, by path, with the
+// names of the automata they were registered as, since the flags are
+// checked before they're run.
+var gollyRules = make(map[string]string)
+
+// loadGollyRule registers the automaton of the -golly-rule file at path,
+// returning its name.
+func loadGollyRule(path string) (string, error) {
+	if name, ok := gollyRules[path]; ok {
+		return name, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	a, err := life.ParseGollyRule(f)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if _, taken := life.LookupAutomaton(a.Name); taken {
+		return "", fmt.Errorf("%s: there's already an automaton called %s", filepath.Base(path), a.Name)
+	}
+	life.Register(a)
+	gollyRules[path] = a.Name
+	return a.Name, nil
+}
+
+// layout arranges the views in a grid of columns by rows, filled left to
+// right and top to bottom.
+type layout struct {
+	columns, rows int
+}
+
+func (l layout) len() int {
+	return l.columns * l.rows
+}
+
+// viewport returns the framebuffer region view i is drawn in: the largest
+// square centred in its cell of the grid, or the whole framebuffer when
+// there's only one view.
+func (l layout) viewport(i, fbWidth, fbHeight int) (x, y, w, h int32) {
+	if l.len() <= 1 {
+		return 0, 0, int32(fbWidth), int32(fbHeight)
+	}
+	cellW, cellH := fbWidth/l.columns, fbHeight/l.rows
+	size := min(cellW, cellH)
+	col, row := i%l.columns, l.rows-1-i/l.columns
+	return int32(col*cellW + (cellW-size)/2), int32(row*cellH + (cellH-size)/2), int32(size), int32(size)
+}
+
+// viewAt returns the view under a point in normalized device coordinates
+// and the point relative to that view, so hit-testing within a view works
+// just as it does for a single full-window board. It returns false when the
+// point is in the letterboxing around the views.
+func (l layout) viewAt(x, y float32, fbWidth, fbHeight int) (int, float32, float32, bool) {
+	px, py := (x+1)/2*float32(fbWidth), (y+1)/2*float32(fbHeight)
+	for i := 0; i < l.len(); i++ {
+		vx, vy, vw, vh := l.viewport(i, fbWidth, fbHeight)
+		if px < float32(vx) || py < float32(vy) || px >= float32(vx+vw) || py >= float32(vy+vh) {
+			continue
+		}
+		return i, (px-float32(vx))/float32(vw)*2 - 1, (py-float32(vy))/float32(vh)*2 - 1, true
+	}
+	return 0, 0, 0, false
+}
+
+// viewsOverlay separates the views with divider lines and labels each one.
+type viewsOverlay struct {
+	rs *runState
+
+	layout  layout
+	names   []string
+	program *overlayProgram
+	divider *lines
+	labels  *text
+}
+
+func (rs *runState) newViewsOverlay(l layout, names []string, program *overlayProgram) *viewsOverlay {
+	o := &viewsOverlay{
+		rs:      rs,
+		layout:  l,
+		names:   names,
+		program: program,
+		divider: rs.newLines("view dividers", 2*(l.columns+l.rows)),
+		labels:  rs.newText(program, 32*l.len()),
+	}
+	for i := 1; i < l.columns; i++ {
+		x := -1 + 2*float32(i)/float32(l.columns)
+		o.divider.add(x, -1)
+		o.divider.add(x, 1)
+	}
+	for i := 1; i < l.rows; i++ {
+		y := -1 + 2*float32(i)/float32(l.rows)
+		o.divider.add(-1, y)
+		o.divider.add(1, y)
+	}
+	return o
+}
+
+func (o *viewsOverlay) draw() {
+	o.program.use(0.5, 0.5, 0.5, 1)
+	o.divider.draw(gl.LINES)
+
+	_, lineHeight := o.rs.textSize("")
+	o.labels.reset()
+	for i, name := range o.names {
+		x := -1 + 2*float32(i%o.layout.columns)/float32(o.layout.columns)
+		y := 1 - 2*float32(i/o.layout.columns)/float32(o.layout.rows)
+		o.labels.print(" "+name, x, y-lineHeight/2)
+	}
+	o.labels.draw(1, 0.8, 0.2, 1)
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// createWidget opens the -widget window. It returns nil if the platform
+// can't give it a transparent framebuffer, in which case the caller should
+// fall back to a normal window; chooseFeatures warns that it has.
+func (rs *runState) createWidget() *glfw.Window {
+	// Config.Validate has checked the size and position.
+	var w, h, x, y int
+	fmt.Sscanf(rs.config.WidgetSize, "%dx%d", &w, &h)
+	if rs.config.WidgetPos != "" {
+		fmt.Sscanf(rs.config.WidgetPos, "%d,%d", &x, &y)
+	}
+
+	glfw.WindowHint(glfw.TransparentFramebuffer, glfw.True)
+	glfw.WindowHint(glfw.Decorated, glfw.False)
+	glfw.WindowHint(glfw.Floating, glfw.True)
+	defer func() {
+		glfw.WindowHint(glfw.TransparentFramebuffer, glfw.False)
+		glfw.WindowHint(glfw.Decorated, glfw.True)
+		glfw.WindowHint(glfw.Floating, glfw.False)
+	}()
+
+	window, err := rs.createWindow(w, h)
+	if err != nil {
+		slog.Warn("can't create the widget window, falling back to a normal one", "err", err)
+		return nil
+	}
+	if window.GetAttrib(glfw.TransparentFramebuffer) != glfw.True {
+		window.Destroy()
+		return nil
+	}
+	if rs.config.WidgetPos != "" {
+		window.SetPos(x, y)
+	}
+	if rs.config.ClickThrough {
+		// Mouse passthrough arrived in GLFW 3.4; the 3.3 bindings can't ask
+		// for it.
+		slog.Warn("-click-through isn't supported by this GLFW version")
+	}
+	return window
+}
+
+// enableTransparency sets up blending for a transparent framebuffer: the
+// board is cleared to fully transparent so only live cells and overlays
+// show over the desktop. Colours are treated as premultiplied, which is what
+// compositors expect.
+func enableTransparency() {
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"opengl/life"
+	"opengl/render"
+)
+
+// maxWindows is the most windows -windows opens.
+const maxWindows = 8
+
+// extraWindowKeys are the keys held to move an extra window's camera, as
+// the main window's are by default.
+var extraWindowKeys = map[string]glfw.Key{
+	"zoom-in":   glfw.KeyEqual,
+	"zoom-out":  glfw.KeyMinus,
+	"pan-left":  glfw.KeyA,
+	"pan-right": glfw.KeyD,
+	"pan-down":  glfw.KeyS,
+	"pan-up":    glfw.KeyW,
+}
+
+// extraWindow is another window onto the boards, opened by -windows or the
+// console's window new, with a camera of its own. Its context shares the
+// main window's objects, so it draws with the main renderer's shaders and
+// buffers, making only the vertex arrays contexts can't share; closing it
+// frees only those, and closing the main window closes it too.
+//
+// It draws the boards alone, without overlays. Its input is its own: the
+// scroll wheel, +, -, WASD, middle drag and F move its camera, a left click
+// toggles the cell under its cursor, Space and N pause and step the boards
+// as in the main window, and Esc or Q closes it.
+type extraWindow struct {
+	rs *runState
+
+	// n numbers the window, from 2, and its context for render.Context.
+	n        int
+	window   *glfw.Window
+	renderer *render.GL
+	// sc is the boards as this window sees them, for finding the cell
+	// under its cursor.
+	sc           *scene
+	dragging     bool
+	dragX, dragY float32
+}
+
+// openExtraWindow opens the nth window onto sims, sharing main's context's
+// objects, with renderer's, and leaves main's context current. No two
+// windows open at once can have the same n. The window calls toggle to
+// toggle a cell clicked on, and key to run a main window command.
+func (rs *runState) openExtraWindow(n int, main *glfw.Window, renderer *render.GL, sims []*life.Simulation, views layout, toggle func(sim *life.Simulation, x, y int), key func(name string, action glfw.Action)) (*extraWindow, error) {
+	// Whatever -widget asked of the main window, this one is plain.
+	glfw.WindowHint(glfw.Visible, glfw.True)
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	glfw.WindowHint(glfw.Decorated, glfw.True)
+	glfw.WindowHint(glfw.Floating, glfw.False)
+	glfw.WindowHint(glfw.TransparentFramebuffer, glfw.False)
+	window, err := glfw.CreateWindow(rs.config.WindowWidth, rs.config.WindowHeight, fmt.Sprintf("Conway's Game of Life (window %d)", n), nil, main)
+	if err != nil {
+		return nil, fmt.Errorf("opening window %d: %w", n, err)
+	}
+	e := &extraWindow{rs: rs, n: n, window: window, sc: &scene{rs: rs, sims: sims, views: views, cam: rs.newCamera()}}
+	e.makeCurrent()
+	// Only the main window waits for vsync, so that one window's swap
+	// doesn't hold up the next's.
+	glfw.SwapInterval(0)
+	bg := rs.config.Background
+	gl.ClearColor(float32(bg.R)/255, float32(bg.G)/255, float32(bg.B)/255, 1)
+	e.renderer = renderer.Shared()
+	makeMainCurrent(main)
+
+	window.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		x, y := cursorNDC(w)
+		e.sc.cam.zoomAt(x, y, float32(math.Pow(scrollZoom, yoff)))
+	})
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		switch {
+		case action == glfw.Release:
+			e.dragging = false
+		case button == glfw.MouseButtonMiddle:
+			e.dragging = true
+			e.dragX, e.dragY = cursorNDC(w)
+		case button == glfw.MouseButtonLeft:
+			if sim, x, y, ok := e.sc.cellUnderCursor(w); ok {
+				toggle(sim, x, y)
+			}
+		}
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, _, _ float64) {
+		if e.dragging {
+			x, y := cursorNDC(w)
+			e.sc.cam.drag(x-e.dragX, y-e.dragY)
+			e.dragX, e.dragY = x, y
+		}
+	})
+	window.SetKeyCallback(func(w *glfw.Window, k glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		switch k {
+		case glfw.KeySpace:
+			key("pause", action)
+		case glfw.KeyN:
+			key("step", action)
+		}
+		if action != glfw.Press {
+			return
+		}
+		switch k {
+		case glfw.KeyEscape, glfw.KeyQ:
+			w.SetShouldClose(true)
+		case glfw.KeyF:
+			e.sc.cam.fit(sims[0].Cells.Bounds())
+		}
+	})
+	return e, nil
+}
+
+// held reports whether a camera command's key is held in the window.
+func (e *extraWindow) held(command string) bool {
+	k, ok := extraWindowKeys[command]
+	return ok && e.window.GetKey(k) == glfw.Press
+}
+
+// moving reports whether the window's camera is moving, so that frames
+// can't wait for input.
+func (e *extraWindow) moving() bool {
+	if e.sc.cam.to != nil || e.dragging {
+		return true
+	}
+	for command := range extraWindowKeys {
+		if e.held(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// show moves the camera on by dt seconds, draws the boards and shows them
+// in the window, leaving main's context current.
+func (e *extraWindow) show(main *glfw.Window, dt float64) {
+	e.sc.cam.update(e.held, dt)
+	e.makeCurrent()
+	fbWidth, fbHeight := e.window.GetFramebufferSize()
+	e.render(fbWidth, fbHeight)
+	render.CheckDebugError()
+	// The buffers are swapped with the window's context current, as some
+	// platforms need.
+	e.window.SwapBuffers()
+	makeMainCurrent(main)
+}
+
+// render draws the boards into the current context, the window's, which is
+// fbWidth by fbHeight pixels.
+func (e *extraWindow) render(fbWidth, fbHeight int) {
+	gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	view := render.View{Projection: e.sc.cam.projection(), Zoom: e.sc.cam.zoom}
+	for i, sim := range e.sc.sims {
+		gl.Viewport(e.sc.views.viewport(i, fbWidth, fbHeight))
+		if err := e.renderer.DrawFrame(sim.Cells, view); err != nil {
+			e.rs.reportError("drawing the board failed", err, "window", e.n)
+		}
+	}
+}
+
+// close frees the window's own objects, in its own context, and destroys
+// it, leaving main's context current.
+func (e *extraWindow) close(main *glfw.Window) {
+	e.makeCurrent()
+	e.renderer.Shutdown()
+	makeMainCurrent(main)
+	e.window.Destroy()
+}
+
+func (e *extraWindow) makeCurrent() {
+	e.window.MakeContextCurrent()
+	render.Context = e.n - 1
+}
+
+func makeMainCurrent(main *glfw.Window) {
+	main.MakeContextCurrent()
+	render.Context = 0
+}
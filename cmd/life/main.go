@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"opengl/app"
+)
+
+// version is set when building a release, with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+func main() {
+	cfg := app.DefaultConfig()
+	cfg.RegisterFlags(flag.CommandLine)
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	configPath := flag.String("config", "", "file of settings named like the flags, e.g. size = \"100x100\", overridden by any flags given (default life.toml in your config directory, if there is one)")
+	writeConfig := flag.String("write-config", "", "write the settings in effect to this file, as a starting point for -config, and exit")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintln(out, "Usage: life [flags]")
+		fmt.Fprintln(out, "       life [flags] diff a.json b.json")
+		app.PrintFlags(out, flag.CommandLine)
+	}
+	flag.Parse()
+	if *showVersion {
+		fmt.Println("life", version)
+		return
+	}
+	path, named := *configPath, *configPath != ""
+	if !named {
+		path, _ = app.DefaultConfigFile()
+	}
+	if path != "" {
+		if err := app.LoadConfigFile(flag.CommandLine, path); err != nil && (named || !errors.Is(err, fs.ErrNotExist)) {
+			fmt.Fprintln(flag.CommandLine.Output(), err)
+			os.Exit(2)
+		}
+	}
+	if *writeConfig != "" {
+		if err := app.WriteConfigFile(flag.CommandLine, *writeConfig); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// life diff a.json b.json compares two states, printing the counts and
+	// showing the difference.
+	switch {
+	case flag.Arg(0) == "diff" && flag.NArg() == 3:
+		cfg.Diff = [2]string{flag.Arg(1), flag.Arg(2)}
+	case flag.NArg() > 0:
+		flag.Usage()
+		os.Exit(2)
+	}
+	// Mistakes in the flags are reported like the flag package's own, before
+	// anything starts.
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		os.Exit(2)
+	}
+	if err := app.Run(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "life:", err)
+		os.Exit(1)
+	}
+}
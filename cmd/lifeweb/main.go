@@ -0,0 +1,132 @@
+//go:build js && wasm
+
+// Command lifeweb runs a board in a browser, drawn on the page's <canvas
+// id="board"> with WebGL 2. The page's query string sets it up like the
+// desktop flags, e.g. ?size=100x60&speed=10&rule=B36/S23&density=0.3. See
+// examples/wasm for a page to load it with.
+//
+// Keys are a subset of the desktop game's: space pauses, n steps while
+// paused, + and - change the speed, r reseeds and c clears; clicking a
+// cell toggles it.
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"syscall/js"
+	"time"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+func main() {
+	document := js.Global().Get("document")
+	canvas := document.Call("getElementById", "board")
+	if canvas.IsNull() {
+		fmt.Println("lifeweb: the page has no <canvas id=\"board\">")
+		return
+	}
+	query, _ := url.ParseQuery(js.Global().Get("location").Get("search").String())
+	columns, rows := 64, 64
+	if size := query.Get("size"); size != "" {
+		fmt.Sscanf(size, "%dx%d", &columns, &rows)
+	}
+	columns, rows = max(columns, 1), max(rows, 1)
+	rate := queryFloat(query, "speed", 10)
+	density := queryFloat(query, "density", 0.5)
+	rule := life.Conway
+	if s := query.Get("rule"); s != "" {
+		r, err := life.ParseRule(s)
+		if err != nil {
+			fmt.Println("lifeweb:", err)
+			return
+		}
+		rule = r
+	}
+
+	sim := life.NewSimulation(life.NewGrid(columns, rows), rule, time.Now().UnixNano(), density, 0)
+	renderer := render.NewWebGL(canvas)
+	if err := renderer.Init(columns, rows); err != nil {
+		fmt.Println("lifeweb:", err)
+		return
+	}
+	// The drawing buffer matches the canvas's size on the page, in device
+	// pixels.
+	resize := func() {
+		scale := js.Global().Get("devicePixelRatio").Float()
+		renderer.Resize(int(canvas.Get("clientWidth").Float()*scale), int(canvas.Get("clientHeight").Float()*scale))
+	}
+	resize()
+	view := render.View{Projection: [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}, Zoom: 1}
+
+	paused := false
+	document.Call("addEventListener", "keydown", js.FuncOf(func(this js.Value, args []js.Value) any {
+		switch args[0].Get("key").String() {
+		case " ":
+			paused = !paused
+		case "n", ".":
+			if paused {
+				sim.Step(false)
+			}
+		case "+", "=":
+			rate = min(rate*2, 1000)
+		case "-", "_":
+			rate = max(rate/2, 0.25)
+		case "r":
+			sim.Reseed(time.Now().UnixNano())
+		case "c":
+			sim.Clear()
+		default:
+			return nil
+		}
+		args[0].Call("preventDefault")
+		return nil
+	}))
+	canvas.Call("addEventListener", "click", js.FuncOf(func(this js.Value, args []js.Value) any {
+		e := args[0]
+		x := int(e.Get("offsetX").Float() / canvas.Get("clientWidth").Float() * float64(columns))
+		y := rows - 1 - int(e.Get("offsetY").Float()/canvas.Get("clientHeight").Float()*float64(rows))
+		if x >= 0 && x < columns && y >= 0 && y < rows {
+			sim.Cells.Set(x, y, !sim.Cells.Alive(x, y))
+		}
+		return nil
+	}))
+	js.Global().Call("addEventListener", "resize", js.FuncOf(func(this js.Value, args []js.Value) any {
+		resize()
+		return nil
+	}))
+
+	// Each animation frame steps however many generations have fallen due,
+	// and draws. After a stall, such as the tab being hidden, it carries on
+	// from a second ago rather than catch up on all of it.
+	var last float64
+	var frame js.Func
+	frame = js.FuncOf(func(this js.Value, args []js.Value) any {
+		now := args[0].Float()
+		if paused || last == 0 {
+			last = now
+		}
+		last = max(last, now-1000)
+		for interval := 1000 / rate; now-last >= interval; last += interval {
+			sim.Step(false)
+		}
+		if err := renderer.DrawFrame(sim.Cells, view); err != nil {
+			fmt.Println("lifeweb:", err)
+		}
+		js.Global().Call("requestAnimationFrame", frame)
+		return nil
+	})
+	js.Global().Call("requestAnimationFrame", frame)
+	select {}
+}
+
+// queryFloat returns query's number called name, or def if it has no
+// positive one.
+func queryFloat(query url.Values, name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(query.Get(name), 64); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
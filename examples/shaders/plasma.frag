@@ -0,0 +1,17 @@
+#version 430
+// Tints live cells with a slowly drifting plasma; dead cells stay black.
+uniform float u_time;
+uniform vec2 u_resolution;
+uniform vec2 u_cell;
+uniform float u_alive;
+out vec4 frag_colour;
+
+void main() {
+    vec2 p = gl_FragCoord.xy / u_resolution;
+    float v = sin(p.x * 10.0 + u_time)
+            + sin(p.y * 10.0 + u_time * 1.3)
+            + sin((p.x + p.y) * 7.0 + u_time * 0.7)
+            + sin(length(u_cell) * 0.3 - u_time);
+    vec3 colour = 0.5 + 0.5 * cos(v + vec3(0.0, 2.1, 4.2));
+    frag_colour = vec4(colour * u_alive, 1.0);
+}
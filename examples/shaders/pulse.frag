@@ -0,0 +1,11 @@
+// Flashes newborn cells bright orange, fading to white as they age, and
+// leaves a faint glow on dead cells.
+#include "cell_uniforms.glsl"
+
+void main() {
+    float fresh = exp(-0.8 * max(u_age - 1.0, 0.0));
+    float pulse = 0.75 + 0.25 * sin(u_time * 6.0);
+    vec3 born = vec3(1.0, 0.55, 0.1) * pulse;
+    vec3 colour = mix(vec3(1.0), born, fresh) * u_alive;
+    frag_colour = vec4(max(colour, vec3(0.03, 0.03, 0.06)), 1.0);
+}
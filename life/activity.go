@@ -0,0 +1,45 @@
+package life
+
+import "math"
+
+// Activity is the fraction of the board's cells that changed state in the
+// last step, from the births and deaths it counted. A settled board's is
+// zero, or a small value that repeats with the period of its oscillators.
+func (s *Simulation) Activity() float64 {
+	n := s.Cells.Columns() * s.Cells.Rows()
+	if n == 0 {
+		return 0
+	}
+	return float64(s.Births+s.Deaths) / float64(n)
+}
+
+// BlockEntropy estimates how disordered the board is: the Shannon entropy
+// of the patterns its 2×2 tiles are in, in bits per cell, from 0 for a
+// board of tiles all alike up to 1 for one where all 16 patterns are as
+// common. Tiles are laid from the bottom-left corner; an odd column or row
+// left over at the far edges isn't counted.
+func BlockEntropy(g Grid) float64 {
+	var counts [16]int
+	tiles := 0
+	for x := 0; x+1 < g.Columns(); x += 2 {
+		for y := 0; y+1 < g.Rows(); y += 2 {
+			tile := 0
+			for i, alive := range [4]bool{g.Alive(x, y), g.Alive(x+1, y), g.Alive(x, y+1), g.Alive(x+1, y+1)} {
+				if alive {
+					tile |= 1 << i
+				}
+			}
+			counts[tile]++
+			tiles++
+		}
+	}
+	var h float64
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(tiles)
+			h -= p * math.Log2(p)
+		}
+	}
+	// The entropy of a tile is up to 4 bits, one for each of its cells.
+	return h / 4
+}
@@ -0,0 +1,69 @@
+package life
+
+import (
+	"math"
+	"testing"
+)
+
+// tiled returns a board of columns by rows cells with the 2×2 tile at
+// (tx, ty) from the bottom left in the pattern tile(tx, ty), its bits the
+// bottom left, bottom right, top left and top right cells.
+func tiled(columns, rows int, tile func(tx, ty int) int) Grid {
+	g := NewGrid(columns, rows)
+	for tx := 0; 2*tx+1 < columns; tx++ {
+		for ty := 0; 2*ty+1 < rows; ty++ {
+			bits := tile(tx, ty)
+			for i, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				g.Set(2*tx+d[0], 2*ty+d[1], bits&(1<<i) != 0)
+			}
+		}
+	}
+	return g
+}
+
+// TestBlockEntropy checks the entropy of boards built tile by tile, from
+// none for tiles all alike to a bit a cell for every pattern as common,
+// with the cells past the last whole tile left out.
+func TestBlockEntropy(t *testing.T) {
+	// Every one of the 16 tile patterns once, on an 8 by 8 board.
+	all := func(tx, ty int) int { return ty*4 + tx }
+	withEdges := tiled(9, 9, all)
+	for i := 0; i < 9; i++ {
+		withEdges.Set(8, i, i%3 == 0)
+		withEdges.Set(i, 8, i%2 == 0)
+	}
+	for _, c := range []struct {
+		name string
+		g    Grid
+		want float64
+	}{
+		{"empty", NewGrid(8, 8), 0},
+		{"full", tiled(8, 8, func(int, int) int { return 15 }), 0},
+		{"every pattern as common", tiled(8, 8, all), 1},
+		{"odd edges", withEdges, 1},
+		{"half empty, half full", tiled(8, 8, func(tx, _ int) int { return 15 * (tx % 2) }), 0.25},
+		{"too small for a tile", NewGrid(1, 5), 0},
+	} {
+		if got := BlockEntropy(c.g); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s board: entropy %g, want %g", c.name, got, c.want)
+		}
+	}
+}
+
+// TestActivity checks a blinker, which has two cells born and two die
+// every step, changes 4 of a 10 by 10 board's cells.
+func TestActivity(t *testing.T) {
+	sim := NewSimulation(NewGrid(10, 10), Conway, 1, 0, 0)
+	if got := sim.Activity(); got != 0 {
+		t.Errorf("before stepping, activity %g, want 0", got)
+	}
+	for x := 4; x < 7; x++ {
+		sim.Cells.Set(x, 5, true)
+	}
+	for gen := 1; gen <= 3; gen++ {
+		sim.Step(false)
+		if got := sim.Activity(); math.Abs(got-0.04) > 1e-9 {
+			t.Errorf("generation %d: activity %g, want 0.04", gen, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Stepper works out the generation after g's into next, a board of the
+// same size, setting every one of its cells, and returns how many cells
+// were born and how many died. It mustn't change g. If wrap is set, the
+// board's edges wrap around into a torus. Steppers must be safe to use
+// from more than one goroutine at once, on different boards.
+type Stepper interface {
+	Step(g, next Grid, wrap bool) (births, deaths int)
+}
+
+// A RandomStepper is a Stepper for an automaton that takes chances, which
+// it takes by drawing from rng. Given the same board and generator state,
+// it must step the same way, so that a saved run carries on as it would
+// have. Its Step is what it does with a generator of its own.
+type RandomStepper interface {
+	Stepper
+	StepRandom(g, next Grid, wrap bool, rng *PCG) (births, deaths int)
+}
+
+// An Automaton is a kind of cellular automaton that boards can run, known
+// by name once it's registered. Its cells have Alive and Age as their
+// state, as Life's do.
+type Automaton struct {
+	Name, Description string
+	// Default is the configuration given to NewStepper when none is.
+	Default string
+	// NewStepper makes the stepper for the automaton configured by config,
+	// such as a rulestring, or returns why config isn't valid.
+	NewStepper func(config string) (Stepper, error)
+	// States is how many states a cell can be in, counting dead: 2 for
+	// dead or alive, or more for a live cell's state to be its age, up to
+	// States-1.
+	States int
+	// Palette, if set, is the colours to draw the live states in, from
+	// age 1 up.
+	Palette []color.RGBA
+	// Tiling is the shape of the cells the automaton runs on.
+	Tiling Tiling
+}
+
+var (
+	automataMu sync.Mutex
+	automata   = make(map[string]Automaton)
+)
+
+// Register adds a to the automata ParseRule, and so the rest of the game,
+// knows by name. It panics if a has no name or stepper, or its name is
+// taken.
+func Register(a Automaton) {
+	automataMu.Lock()
+	defer automataMu.Unlock()
+	switch _, taken := automata[a.Name]; {
+	case a.Name == "" || strings.ContainsAny(a.Name, ":,/ "):
+		panic(fmt.Sprintf("life: Register of automaton %q: names can't be empty or have :, /, commas or spaces", a.Name))
+	case a.NewStepper == nil:
+		panic("life: Register of automaton " + a.Name + " with no stepper")
+	case taken:
+		panic("life: Register of automaton " + a.Name + " twice")
+	}
+	automata[a.Name] = a
+}
+
+// Automata returns the registered automata, by name.
+func Automata() []Automaton {
+	automataMu.Lock()
+	defer automataMu.Unlock()
+	var as []Automaton
+	for _, a := range automata {
+		as = append(as, a)
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].Name < as[j].Name })
+	return as
+}
+
+// LookupAutomaton returns the automaton registered as name.
+func LookupAutomaton(name string) (Automaton, bool) {
+	automataMu.Lock()
+	defer automataMu.Unlock()
+	a, ok := automata[name]
+	return a, ok
+}
+
+// stepperRule is an automaton's stepper standing in for a Rule.
+type stepperRule struct {
+	// name is what the rule is called: the automaton's name, with its
+	// configuration after a colon unless that's the default.
+	name    string
+	stepper Stepper
+}
+
+// automatonRule returns the rule that runs the automaton s names, as name
+// or name:config, if one is registered as name.
+func automatonRule(s string) (Rule, bool, error) {
+	name, config, configured := strings.Cut(s, ":")
+	a, ok := LookupAutomaton(name)
+	if !ok {
+		return Rule{}, false, nil
+	}
+	if !configured {
+		config = a.Default
+	}
+	stepper, err := a.NewStepper(config)
+	if err != nil {
+		return Rule{}, true, fmt.Errorf("automaton %s: %w", name, err)
+	}
+	// Life-like automata are their rules.
+	if r, ok := stepper.(Rule); ok {
+		return r, true, nil
+	}
+	if config != a.Default {
+		name += ":" + config
+	}
+	return Rule{stepper: &stepperRule{name: name, stepper: stepper}}, true, nil
+}
+
+// randomStepper returns the rule's stepper if it takes chances.
+func (r Rule) randomStepper() (RandomStepper, bool) {
+	if r.stepper == nil {
+		return nil, false
+	}
+	rs, ok := r.stepper.stepper.(RandomStepper)
+	return rs, ok
+}
+
+// Automaton returns the automaton the rule runs, if it was made by one
+// other than Life.
+func (r Rule) Automaton() (Automaton, bool) {
+	if r.stepper == nil {
+		return Automaton{}, false
+	}
+	name, _, _ := strings.Cut(r.stepper.name, ":")
+	return LookupAutomaton(name)
+}
+
+func init() {
+	Register(Automaton{
+		Name:        "life",
+		Description: "Life-like rules in B/S notation, configured as life:B36/S23",
+		Default:     Conway.String(),
+		NewStepper: func(config string) (Stepper, error) {
+			return ParseRule(config)
+		},
+		States: 2,
+	})
+	Register(Automaton{
+		Name:        "brians-brain",
+		Description: "Brian's Brain: a dead cell with two firing neighbours fires, then it's dying a generation, then dead",
+		NewStepper:  func(string) (Stepper, error) { return briansBrain{}, nil },
+		States:      3,
+		Palette:     []color.RGBA{{255, 255, 255, 255}, {60, 90, 200, 255}},
+	})
+}
+
+// briansBrain is Brian's Brain, with firing cells alive at age 1 and dying
+// ones at age 2.
+type briansBrain struct{}
+
+func (briansBrain) Step(g, next Grid, wrap bool) (births, deaths int) {
+	for y := 0; y < g.Rows(); y++ {
+		for x := 0; x < g.Columns(); x++ {
+			switch c := g.At(x, y); {
+			case c.Alive && c.Age == 1:
+				c.Age = 2
+				next.SetCell(x, y, c)
+			case c.Alive:
+				deaths++
+				next.Set(x, y, false)
+			default:
+				firing := 0
+				g.Neighbours(x, y, wrap, func(n Cell) {
+					if n.Alive && n.Age == 1 {
+						firing++
+					}
+				})
+				if firing == 2 {
+					births++
+				}
+				next.Set(x, y, firing == 2)
+			}
+		}
+	}
+	return births, deaths
+}
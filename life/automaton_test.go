@@ -0,0 +1,42 @@
+package life
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRegisterRejects checks automata that couldn't be told apart by name,
+// or couldn't run, panic on registering rather than later.
+func TestRegisterRejects(t *testing.T) {
+	stepper := func(string) (Stepper, error) { return Conway, nil }
+	for _, c := range []struct {
+		a    Automaton
+		want string
+	}{
+		{Automaton{NewStepper: stepper}, "names can't be empty"},
+		{Automaton{Name: "life:2", NewStepper: stepper}, "names can't be empty or have :"},
+		{Automaton{Name: "no stepper"}, "names can't be empty"},
+		{Automaton{Name: "stepless"}, "with no stepper"},
+		{Automaton{Name: "brians-brain", NewStepper: stepper}, "Register of automaton brians-brain twice"},
+	} {
+		func() {
+			defer func() {
+				if p, _ := recover().(string); !strings.Contains(p, c.want) {
+					t.Errorf("registering %q panicked with %q, want %q", c.a.Name, p, c.want)
+				}
+			}()
+			Register(c.a)
+		}()
+	}
+	if _, ok := LookupAutomaton("stepless"); ok {
+		t.Error("an automaton that panicked was registered all the same")
+	}
+	names := []string{}
+	for _, a := range Automata() {
+		names = append(names, a.Name)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("the automata are %v, want them in name order", names)
+	}
+}
@@ -0,0 +1,233 @@
+package life
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// censusMaxCells is the most cells an object can have to be looked up in
+// the dictionary; anything bigger is unidentified without trying.
+const censusMaxCells = 40
+
+// censusObjects are the common objects a census recognises, in any phase,
+// as plaintext in the first phase. Phases that fall apart into more than
+// one cluster, like the toad's second, can't be told from separate objects
+// and aren't recognised.
+var censusObjects = []struct {
+	name, cells string
+}{
+	{"block", "OO\nOO"},
+	{"beehive", ".OO.\nO..O\n.OO."},
+	{"loaf", ".OO.\nO..O\n.O.O\n..O."},
+	{"boat", "OO.\nO.O\n.O."},
+	{"ship", "OO.\nO.O\n.OO"},
+	{"tub", ".O.\nO.O\n.O."},
+	{"pond", ".OO.\nO..O\nO..O\n.OO."},
+	{"long boat", "OO..\nO.O.\n.O.O\n..O."},
+	{"barge", ".O..\nO.O.\n.O.O\n..O."},
+	{"blinker", "OOO"},
+	{"toad", ".OOO\nOOO."},
+	{"beacon", "OO..\nOO..\n..OO\n..OO"},
+	{"glider", ".O.\n..O\nOOO"},
+	{"LWSS", ".O..O\nO....\nO...O\nOOOO."},
+	{"MWSS", "...O..\n.O...O\nO.....\nO....O\nOOOOO."},
+	{"HWSS", "...OO..\n.O....O\nO......\nO.....O\nOOOOOO."},
+}
+
+// censusDictionary maps the canonical form of every phase of each of
+// censusObjects to its name.
+var censusDictionary = buildCensusDictionary()
+
+func buildCensusDictionary() map[string]string {
+	dict := make(map[string]string)
+	for _, o := range censusObjects {
+		p, err := ParseCells(o.cells)
+		if err != nil {
+			panic(fmt.Sprintf("census object %s: %v", o.name, err))
+		}
+		phase := p.Cells
+		// Every object here repeats, up to moving, within four generations.
+		for i := 0; i < 4; i++ {
+			if len(clusters(phase, false, 0, 0)) == 1 {
+				dict[canonical(phase)] = o.name
+			}
+			phase = stepCells(phase, Conway)
+		}
+	}
+	return dict
+}
+
+// stepCells steps a set of live cells on an unbounded board.
+func stepCells(live [][2]int, r Rule) [][2]int {
+	alive := make(map[[2]int]bool, len(live))
+	neighbours := make(map[[2]int]int)
+	for _, c := range live {
+		alive[c] = true
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx != 0 || dy != 0 {
+					neighbours[[2]int{c[0] + dx, c[1] + dy}]++
+				}
+			}
+		}
+	}
+	var next [][2]int
+	for c, n := range neighbours {
+		if r.Next(alive[c], n) {
+			next = append(next, c)
+		}
+	}
+	// A live cell with no live neighbours isn't in neighbours at all.
+	for c := range alive {
+		if _, ok := neighbours[c]; !ok && r.Next(true, 0) {
+			next = append(next, c)
+		}
+	}
+	return next
+}
+
+// clusters splits live cells into groups connected through any of their
+// eight neighbours. With wrap set, the board is a columns by rows torus,
+// and each cluster is given unwrapped, as though it didn't cross an edge.
+func clusters(live [][2]int, wrap bool, columns, rows int) [][][2]int {
+	index := make(map[[2]int]int, len(live))
+	for i, c := range live {
+		index[c] = i
+	}
+	seen := make([]bool, len(live))
+	var out [][][2]int
+	for i := range live {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		// Each queued cell is its position on the board and its unwrapped
+		// position in the cluster.
+		queue := [][2][2]int{{live[i], live[i]}}
+		var cluster [][2]int
+		for len(queue) > 0 {
+			c := queue[0]
+			queue = queue[1:]
+			cluster = append(cluster, c[1])
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					n := [2]int{c[0][0] + dx, c[0][1] + dy}
+					if wrap {
+						n = [2]int{(n[0] + columns) % columns, (n[1] + rows) % rows}
+					}
+					if j, ok := index[n]; ok && !seen[j] {
+						seen[j] = true
+						queue = append(queue, [2][2]int{n, {c[1][0] + dx, c[1][1] + dy}})
+					}
+				}
+			}
+		}
+		out = append(out, cluster)
+	}
+	return out
+}
+
+// canonical returns a form of the cells that's the same wherever they are
+// and however they're rotated or reflected: the least, as text, of their
+// eight orientations each moved to the origin and sorted.
+func canonical(cells [][2]int) string {
+	best := ""
+	for o := 0; o < 8; o++ {
+		turned := make([][2]int, len(cells))
+		for i, c := range cells {
+			x, y := c[0], c[1]
+			if o&4 != 0 {
+				x = -x
+			}
+			for r := 0; r < o%4; r++ {
+				x, y = -y, x
+			}
+			turned[i] = [2]int{x, y}
+		}
+		minX, minY := turned[0][0], turned[0][1]
+		for _, c := range turned {
+			minX, minY = min(minX, c[0]), min(minY, c[1])
+		}
+		for i := range turned {
+			turned[i][0] -= minX
+			turned[i][1] -= minY
+		}
+		sort.Slice(turned, func(i, j int) bool {
+			return turned[i][0] < turned[j][0] || turned[i][0] == turned[j][0] && turned[i][1] < turned[j][1]
+		})
+		var b strings.Builder
+		for _, c := range turned {
+			fmt.Fprintf(&b, "%d,%d;", c[0], c[1])
+		}
+		if s := b.String(); o == 0 || s < best {
+			best = s
+		}
+	}
+	return best
+}
+
+// Census counts the objects found on a board by name, and the
+// unidentified ones by their number of cells.
+type Census struct {
+	Objects      map[string]int
+	Unidentified map[int]int
+}
+
+// TakeCensus identifies every cluster of live cells on the board.
+func TakeCensus(cells Grid, wrap bool) Census {
+	res := Census{Objects: make(map[string]int), Unidentified: make(map[int]int)}
+	minX, minY, maxX, maxY, ok := cells.Bounds()
+	if !ok {
+		return res
+	}
+	var live [][2]int
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			if cells.Alive(x, y) {
+				live = append(live, [2]int{x, y})
+			}
+		}
+	}
+	for _, cluster := range clusters(live, wrap, cells.Columns(), cells.Rows()) {
+		if name, ok := censusDictionary[canonical(cluster)]; ok && len(cluster) <= censusMaxCells {
+			res.Objects[name]++
+		} else {
+			res.Unidentified[len(cluster)]++
+		}
+	}
+	return res
+}
+
+func (r Census) String() string {
+	names := make([]string, 0, len(r.Objects))
+	for name := range r.Objects {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		return r.Objects[a] > r.Objects[b] || r.Objects[a] == r.Objects[b] && a < b
+	})
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%d %s", r.Objects[name], name))
+	}
+	if len(r.Unidentified) > 0 {
+		sizes := make([]int, 0, len(r.Unidentified))
+		n := 0
+		for size, count := range r.Unidentified {
+			sizes = append(sizes, size)
+			n += count
+		}
+		sort.Ints(sizes)
+		var bySize []string
+		for _, size := range sizes {
+			bySize = append(bySize, fmt.Sprintf("%d of %d cells", r.Unidentified[size], size))
+		}
+		parts = append(parts, fmt.Sprintf("%d unidentified (%s)", n, strings.Join(bySize, ", ")))
+	}
+	if len(parts) == 0 {
+		return "nothing alive"
+	}
+	return strings.Join(parts, ", ")
+}
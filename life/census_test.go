@@ -0,0 +1,126 @@
+package life
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	glider := canonical(testGlider.Cells)
+	p := testGlider
+	for i := 0; i < 4; i++ {
+		for _, q := range []Pattern{p, p.FlipX(), p.FlipY()} {
+			moved := make([][2]int, len(q.Cells))
+			for j, c := range q.Cells {
+				moved[len(moved)-1-j] = [2]int{c[0] - 7, c[1] + 30}
+			}
+			if got := canonical(moved); got != glider {
+				t.Errorf("a glider turned %d times, moved and reordered, is %s, want %s", i, got, glider)
+			}
+		}
+		p = p.Rotate90()
+	}
+	next := canonical(stepCells(testGlider.Cells, Conway))
+	if next == glider || canonical(LibraryPattern("r-pentomino").Cells) == glider {
+		t.Error("a glider's next phase, or an R-pentomino, has the glider's form")
+	}
+}
+
+func TestClusters(t *testing.T) {
+	live := [][2]int{{0, 0}, {1, 1}, {5, 5}, {5, 6}, {9, 0}, {9, 9}, {3, 8}}
+	if got := len(clusters(live, false, 10, 10)); got != 5 {
+		t.Errorf("%d clusters, want 5", got)
+	}
+	// Wrapped, the corners touch, which leaves them unwrapped as though
+	// beside each other.
+	found := false
+	for _, c := range clusters(live, true, 10, 10) {
+		if len(c) == 4 {
+			found = true
+			if got := canonical(c); got != canonical([][2]int{{0, 0}, {1, 1}, {-1, 0}, {-1, -1}}) {
+				t.Errorf("the corner cluster is %v", c)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("the corners don't join up across the edges: %v", clusters(live, true, 10, 10))
+	}
+}
+
+func TestTakeCensus(t *testing.T) {
+	g := NewGrid(80, 40)
+	place := func(p Pattern, cx, cy int) { g.Stamp(p, cx, cy, false) }
+	cells := func(src string) Pattern {
+		p, err := ParseCells(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	place(cells("OO\nOO"), 5, 5)
+	place(cells(".OO.\nO..O\n.OO."), 15, 5)
+	place(cells(".O.\nO.O\nO.O\n.O."), 25, 5)
+	place(cells("O\nO\nO"), 35, 5)
+	place(testGlider.Rotate90().FlipX(), 45, 5)
+	place(Pattern{Cells: stepCells(testGlider.Cells, Conway)}, 55, 5)
+	// The LWSS's first phase has a cell off on its own, so it's stepped on
+	// to one that doesn't.
+	place(Pattern{Cells: stepCells(LibraryPattern("lwss").FlipY().Cells, Conway)}, 65, 5)
+	place(cells(".OO.\nO..O\n.O.O\n..O."), 5, 20)
+	place(LibraryPattern("r-pentomino"), 15, 20)
+	place(cells("O"), 25, 20)
+
+	got := TakeCensus(g, false)
+	want := map[string]int{"block": 1, "beehive": 2, "blinker": 1, "glider": 2, "LWSS": 1, "loaf": 1}
+	if len(got.Objects) != len(want) {
+		t.Errorf("census found %v, want %v", got.Objects, want)
+	}
+	for name, n := range want {
+		if got.Objects[name] != n {
+			t.Errorf("census found %d of %s, want %d", got.Objects[name], name, n)
+		}
+	}
+	if got.Unidentified[5] != 1 || got.Unidentified[1] != 1 {
+		t.Errorf("census left %v unidentified, want an R-pentomino and a lone cell", got.Unidentified)
+	}
+	if s, want := got.String(), "2 beehive, 2 glider, 1 LWSS, 1 blinker, 1 block, 1 loaf, 2 unidentified (1 of 1 cells, 1 of 5 cells)"; s != want {
+		t.Errorf("census reads %q, want %q", s, want)
+	}
+	if got := TakeCensus(NewGrid(4, 4), false).String(); got != "nothing alive" {
+		t.Errorf("an empty board's census reads %q", got)
+	}
+}
+
+func TestTakeCensusWraps(t *testing.T) {
+	g := NewGrid(10, 10)
+	for _, c := range [][2]int{{0, 0}, {9, 0}, {0, 9}, {9, 9}} {
+		g.Set(c[0], c[1], true)
+	}
+	if got := TakeCensus(g, true); got.Objects["block"] != 1 || len(got.Unidentified) != 0 {
+		t.Errorf("wrapped, the corners are %v", got)
+	}
+	if got := TakeCensus(g, false); got.Unidentified[1] != 4 || len(got.Objects) != 0 {
+		t.Errorf("unwrapped, the corners are %v", got)
+	}
+	if Components(g, true) != 1 || Components(g, false) != 4 {
+		t.Errorf("the corners are %d components wrapped and %d not, want 1 and 4", Components(g, true), Components(g, false))
+	}
+}
+
+// TestComponentsCountsClusters checks Components agrees with the census's
+// clusters on random boards.
+func TestComponentsCountsClusters(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		g := NewSimulation(NewGrid(30, 20), Conway, seed, 0.2, 0).Cells
+		var live [][2]int
+		for x := 0; x < 30; x++ {
+			for y := 0; y < 20; y++ {
+				if g.Alive(x, y) {
+					live = append(live, [2]int{x, y})
+				}
+			}
+		}
+		for _, wrap := range []bool{false, true} {
+			if got, want := Components(g, wrap), len(clusters(live, wrap, 30, 20)); got != want {
+				t.Errorf("seed %d, wrap %v: %d components, want %d", seed, wrap, got, want)
+			}
+		}
+	}
+}
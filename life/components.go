@@ -0,0 +1,51 @@
+package life
+
+// Components counts the clusters of live cells on g, cells being in the
+// same cluster if they touch through any of their eight neighbours. If
+// wrap is set, cells touch across the edges, as on a torus.
+func Components(g Grid, wrap bool) int {
+	columns, rows := g.Columns(), g.Rows()
+	// parent links each live cell, by Index, towards the root of its
+	// cluster, a cell that's its own parent. Dead cells are -1.
+	parent := make([]int, columns*rows)
+	count := 0
+	for i := range parent {
+		parent[i] = -1
+		if g.b.view().alive[i] != 0 {
+			parent[i] = i
+			count++
+		}
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i := range parent {
+		if parent[i] < 0 {
+			continue
+		}
+		x, y := i%columns, i/columns
+		// Of each pair of neighbours, one is to the other's left, or
+		// straight below it, so these four join every pair.
+		for _, d := range [4][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}} {
+			nx, ny := x+d[0], y+d[1]
+			if wrap {
+				nx, ny = (nx+columns)%columns, (ny+rows)%rows
+			} else if nx < 0 || ny < 0 || ny >= rows {
+				continue
+			}
+			j := ny*columns + nx
+			if parent[j] < 0 {
+				continue
+			}
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[ri] = rj
+				count--
+			}
+		}
+	}
+	return count
+}
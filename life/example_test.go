@@ -0,0 +1,71 @@
+package life_test
+
+import (
+	"fmt"
+
+	"opengl/life"
+)
+
+func ExampleGrid() {
+	// A blinker, on its side, turns upright and back.
+	g := life.NewGrid(5, 5)
+	for x := 1; x <= 3; x++ {
+		g.Set(x, 2, true)
+	}
+	for i := 0; i < 3; i++ {
+		fmt.Print(life.EncodeCells(g.Pattern()))
+		fmt.Println("--")
+		g.Step(life.Conway, false)
+	}
+	fmt.Println(g.Population(), "cells")
+	// Output:
+	// OOO
+	// --
+	// O
+	// O
+	// O
+	// --
+	// OOO
+	// --
+	// 3 cells
+}
+
+func ExampleRule() {
+	r, err := life.ParseRule("B36/S23")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(r)
+	// HighLife has cells born on six neighbours, but not surviving on them.
+	fmt.Println(r.Next(false, 6), r.Next(true, 6))
+	// Output:
+	// B36/S23
+	// true false
+}
+
+func ExamplePattern() {
+	p, rule, err := life.ParseRLE("#N Glider\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(p.Name, rule)
+	fmt.Println(p.Size())
+
+	// Stamped on a board and stepped four generations, it's the same shape
+	// a cell down and to the right.
+	g := life.NewGrid(8, 8)
+	g.Stamp(p, 3, 4, false)
+	fmt.Println(g.Bounds())
+	for i := 0; i < 4; i++ {
+		g.Step(life.Conway, false)
+	}
+	fmt.Println(g.Bounds())
+	fmt.Print(life.EncodeRLE(g.Pattern(), rule))
+	// Output:
+	// Glider B3/S23
+	// 3 3
+	// 2 3 4 5 true
+	// 3 2 5 4 true
+	// x = 3, y = 3, rule = B3/S23
+	// bo$2bo$3o!
+}
@@ -0,0 +1,495 @@
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxPatternSide is the most cells across or down a pattern can be,
+	// and MaxPatternCells the most live cells it can have, in any format.
+	// A file saying it's bigger, in a header, a run count or where its
+	// cells are, is refused there and then, before anything that big is
+	// allocated.
+	MaxPatternSide  = 1 << 20
+	MaxPatternCells = 1 << 24
+)
+
+// checkPatternSize returns an error if a pattern w by h cells, or with
+// cells live cells, is bigger than a pattern can be.
+func checkPatternSize(w, h, cells int) error {
+	switch {
+	case w < 0 || h < 0:
+		return fmt.Errorf("invalid pattern size %dx%d", w, h)
+	case w > MaxPatternSide || h > MaxPatternSide:
+		return fmt.Errorf("pattern is %dx%d, bigger than the %d cells a side a pattern can be", w, h, MaxPatternSide)
+	case cells > MaxPatternCells:
+		return fmt.Errorf("pattern has more than the %d live cells a pattern can have", MaxPatternCells)
+	}
+	return nil
+}
+
+// newPatternScanner returns a scanner over src's lines, taking lines as
+// long as the widest pattern's, with room for indenting.
+func newPatternScanner(src string) *bufio.Scanner {
+	sc := bufio.NewScanner(strings.NewReader(src))
+	sc.Buffer(nil, 2*MaxPatternSide)
+	return sc
+}
+
+// ParsePattern parses a pattern in whichever format it looks to be in, for
+// text with no file name to go by. limit is as for ParseMacrocell.
+func ParsePattern(src string, limit int) (Pattern, string, error) {
+	if strings.HasPrefix(src, "#Life") {
+		p, err := ParseLife(src)
+		return p, "", err
+	}
+	if strings.HasPrefix(src, "[M2]") {
+		return ParseMacrocell(src, limit)
+	}
+	sc := newPatternScanner(src)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "!"), strings.Trim(line, ".Oo*") == "" && !strings.HasSuffix(line, "o"):
+			// A row of a plaintext pattern, or its comments, unless it
+			// could be RLE ending in a run of live cells.
+			p, err := ParseCells(src)
+			return p, "", err
+		}
+		return ParseRLE(src)
+	}
+	return ParseRLE(src)
+}
+
+// macrocellNode is a square of 2^level cells a side in a macrocell file:
+// either an 8x8 leaf of cells, or four quadrants given by node number.
+type macrocellNode struct {
+	level    int
+	cells    [][2]int
+	children [4]int
+	// population is the node's live cell count, stopping at the limit.
+	population int
+}
+
+// ParseMacrocell parses a two-state Golly macrocell file, a quadtree of
+// nodes listed leaves first, the last being the root. Since equal subtrees
+// are shared, a small file can describe an enormous pattern, so patterns
+// with more than limit live cells are refused before they're expanded.
+func ParseMacrocell(src string, limit int) (Pattern, string, error) {
+	var (
+		p    Pattern
+		rule string
+		// nodes is indexed by node number; node 0 is empty at any level.
+		nodes = []macrocellNode{{}}
+	)
+	sc := newPatternScanner(src)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "[M2]"):
+			continue
+		case strings.HasPrefix(line, "#"):
+			if r, ok := strings.CutPrefix(line, "#R"); ok {
+				rule = strings.TrimSpace(r)
+			} else if n, ok := strings.CutPrefix(line, "#N"); ok {
+				p.Name = strings.TrimSpace(n)
+			}
+			continue
+		case line[0] == '.' || line[0] == '*' || line[0] == '$':
+			n := macrocellNode{level: 3}
+			x, y := 0, 0
+			for _, r := range line {
+				switch r {
+				case '.':
+					x++
+				case '*':
+					if x >= 8 || y >= 8 {
+						return Pattern{}, "", fmt.Errorf("line %d: leaf node is bigger than 8x8", lineNum)
+					}
+					n.cells = append(n.cells, [2]int{x, y})
+					x++
+				case '$':
+					x, y = 0, y+1
+				default:
+					return Pattern{}, "", fmt.Errorf("line %d: unexpected %q in a leaf node", lineNum, r)
+				}
+			}
+			n.population = len(n.cells)
+			nodes = append(nodes, n)
+			continue
+		}
+		var n macrocellNode
+		if _, err := fmt.Sscanf(line, "%d %d %d %d %d", &n.level, &n.children[0], &n.children[1], &n.children[2], &n.children[3]); err != nil {
+			return Pattern{}, "", fmt.Errorf("line %d: want a node's level and four children, got %q", lineNum, line)
+		}
+		if n.level == 1 {
+			return Pattern{}, "", fmt.Errorf("line %d: multi-state macrocell patterns aren't supported", lineNum)
+		}
+		if n.level < 4 || n.level > 62 {
+			return Pattern{}, "", fmt.Errorf("line %d: invalid node level %d", lineNum, n.level)
+		}
+		for _, child := range n.children {
+			if child < 0 || child >= len(nodes) || child > 0 && nodes[child].level != n.level-1 {
+				return Pattern{}, "", fmt.Errorf("line %d: invalid child node %d", lineNum, child)
+			}
+			n.population = min(n.population+nodes[child].population, limit+1)
+		}
+		nodes = append(nodes, n)
+	}
+	if err := sc.Err(); err != nil {
+		return Pattern{}, "", err
+	}
+	if len(nodes) == 1 {
+		return Pattern{}, "", fmt.Errorf("no macrocell nodes found")
+	}
+	root := len(nodes) - 1
+	if nodes[root].population > limit {
+		return Pattern{}, "", fmt.Errorf("pattern has more than %d live cells", limit)
+	}
+
+	var expand func(i, x, y int)
+	expand = func(i, x, y int) {
+		n := &nodes[i]
+		if i == 0 || n.population == 0 {
+			return
+		}
+		if n.level == 3 {
+			for _, c := range n.cells {
+				p.Cells = append(p.Cells, [2]int{x + c[0], y + c[1]})
+			}
+			return
+		}
+		half := 1 << (n.level - 1)
+		expand(n.children[0], x, y)
+		expand(n.children[1], x+half, y)
+		expand(n.children[2], x, y+half)
+		expand(n.children[3], x+half, y+half)
+	}
+	expand(root, 0, 0)
+	p = p.Transform(func(x, y, w, h int) (int, int) { return x, y })
+	w, h := p.Size()
+	if err := checkPatternSize(w, h, len(p.Cells)); err != nil {
+		return Pattern{}, "", err
+	}
+	return p, rule, nil
+}
+
+// ParseRLE parses a pattern in run-length encoded form, returning the rule
+// from its header line, if any. Comment lines starting with # are skipped,
+// and any state other than dead counts as alive.
+func ParseRLE(src string) (Pattern, string, error) {
+	var (
+		p       Pattern
+		rule    string
+		x, y    int
+		count   string
+		header  bool
+		lineNum int
+	)
+	sc := newPatternScanner(src)
+	for sc.Scan() {
+		lineNum++
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if strings.HasPrefix(line, "#N ") && p.Name == "" {
+				p.Name = strings.TrimSpace(line[3:])
+			}
+			continue
+		case !header && strings.HasPrefix(line, "x"):
+			header = true
+			w, h := 0, 0
+			fields := strings.Split(line, ",")
+		attributes:
+			for i, field := range fields {
+				k, v, _ := strings.Cut(field, "=")
+				switch strings.TrimSpace(k) {
+				case "rule":
+					// The rule comes last, and can have commas of its
+					// own, as a bounded grid's does.
+					rule = strings.TrimSpace(strings.Join(append([]string{v}, fields[i+1:]...), ","))
+					break attributes
+				case "x":
+					w, _ = strconv.Atoi(strings.TrimSpace(v))
+				case "y":
+					h, _ = strconv.Atoi(strings.TrimSpace(v))
+				}
+			}
+			if err := checkPatternSize(w, h, 0); err != nil {
+				return Pattern{}, "", fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			continue
+		}
+		for _, r := range line {
+			switch {
+			case r >= '0' && r <= '9':
+				count += string(r)
+				continue
+			case r == ' ' || r == '\t':
+				continue
+			}
+			n := 1
+			if count != "" {
+				// A run too long to parse is too long for a pattern.
+				var err error
+				if n, err = strconv.Atoi(count); err != nil {
+					n = MaxPatternSide + 1
+				}
+				count = ""
+			}
+			switch {
+			case r == '!':
+				return p, rule, nil
+			case r == '$':
+				x, y = 0, y+min(n, MaxPatternSide+1)
+			case r == 'b' || r == '.':
+				x += min(n, MaxPatternSide+1)
+			case r == 'o' || r >= 'A' && r <= 'X':
+				if err := checkPatternSize(x+n, y+1, len(p.Cells)+n); err != nil {
+					return Pattern{}, "", fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				for i := 0; i < n; i++ {
+					p.Cells = append(p.Cells, [2]int{x + i, y})
+				}
+				x += n
+			default:
+				return Pattern{}, "", fmt.Errorf("line %d: unexpected %q in RLE", lineNum, r)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Pattern{}, "", err
+	}
+	if !header && len(p.Cells) == 0 {
+		return Pattern{}, "", fmt.Errorf("no RLE pattern found")
+	}
+	return p, rule, nil
+}
+
+// ParseCells parses a plaintext pattern: rows of . for dead and O or * for
+// alive, after comment lines starting with !.
+func ParseCells(src string) (Pattern, error) {
+	var p Pattern
+	y := 0
+	sc := newPatternScanner(src)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if strings.HasPrefix(line, "!") {
+			if name, ok := strings.CutPrefix(line, "!Name:"); ok {
+				p.Name = strings.TrimSpace(name)
+			}
+			continue
+		}
+		// Blank lines are empty rows, but only once the pattern has begun.
+		if line == "" && y == 0 {
+			continue
+		}
+		if err := checkPatternSize(len(line), y+1, len(p.Cells)+len(line)); err != nil {
+			return Pattern{}, fmt.Errorf("line %d: %w", y+1, err)
+		}
+		for x, r := range line {
+			switch r {
+			case 'O', 'o', '*':
+				p.Cells = append(p.Cells, [2]int{x, y})
+			case '.':
+			default:
+				return Pattern{}, fmt.Errorf("line %d: unexpected %q in plaintext pattern", y+1, r)
+			}
+		}
+		y++
+	}
+	if err := sc.Err(); err != nil {
+		return Pattern{}, err
+	}
+	return p, nil
+}
+
+// ParseLife parses a Life 1.06 list of live cell coordinates, or a Life 1.05
+// file of #P blocks drawn like plaintext patterns.
+func ParseLife(src string) (Pattern, error) {
+	var p Pattern
+	v105 := strings.HasPrefix(src, "#Life 1.05")
+	bx, by := 0, 0
+	// Cells can come in any order, and more than once, but must all fit in
+	// a pattern, wherever they are.
+	seen := make(map[[2]int]bool)
+	var minX, minY, maxX, maxY int
+	add := func(x, y int) error {
+		if seen[[2]int{x, y}] {
+			return nil
+		}
+		if len(p.Cells) == 0 {
+			minX, minY, maxX, maxY = x, y, x, y
+		}
+		minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x), max(maxY, y)
+		// Coordinates this far out would overflow working out the size.
+		if min(minX, minY) < -MaxPatternSide*MaxPatternSide || max(maxX, maxY) > MaxPatternSide*MaxPatternSide {
+			return fmt.Errorf("cell %d,%d is too far out for a pattern", x, y)
+		}
+		if err := checkPatternSize(maxX-minX+1, maxY-minY+1, len(p.Cells)+1); err != nil {
+			return err
+		}
+		seen[[2]int{x, y}] = true
+		p.Cells = append(p.Cells, [2]int{x, y})
+		return nil
+	}
+	sc := newPatternScanner(src)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if v105 && strings.HasPrefix(line, "#P") {
+			if _, err := fmt.Sscanf(line, "#P %d %d", &bx, &by); err != nil {
+				return Pattern{}, fmt.Errorf("line %d: invalid block position %q", lineNum, line)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if v105 {
+			for x, r := range line {
+				switch r {
+				case '*', 'O':
+					if err := add(bx+x, by); err != nil {
+						return Pattern{}, fmt.Errorf("line %d: %w", lineNum, err)
+					}
+				case '.':
+				default:
+					return Pattern{}, fmt.Errorf("line %d: unexpected %q in Life 1.05 pattern", lineNum, r)
+				}
+			}
+			by++
+			continue
+		}
+		var x, y int
+		if _, err := fmt.Sscanf(line, "%d %d", &x, &y); err != nil {
+			return Pattern{}, fmt.Errorf("line %d: want a cell's x and y, got %q", lineNum, line)
+		}
+		if err := add(x, y); err != nil {
+			return Pattern{}, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Pattern{}, err
+	}
+	// Life files are centred on the origin, so shift the cells to start at
+	// zero.
+	return p.Transform(func(x, y, w, h int) (int, int) { return x, y }), nil
+}
+
+// EncodeRLE writes the pattern in run-length encoded form, with a header
+// line giving its size and rule.
+func EncodeRLE(p Pattern, rule string) string {
+	w, h := p.Size()
+	var runs []string
+	run := func(n int, tag byte) {
+		if n > 1 {
+			runs = append(runs, strconv.Itoa(n)+string(tag))
+		} else {
+			runs = append(runs, string(tag))
+		}
+	}
+	// Only the live cells are visited, in reading order, so a sparse
+	// pattern as big as a pattern can be is as quick as a small one. Row
+	// ends are held back until something follows them, so trailing dead
+	// cells and empty rows are left implicit.
+	cells := readingOrder(p.Cells)
+	x, y := 0, 0
+	for i := 0; i < len(cells); {
+		c := cells[i]
+		if c[1] > y {
+			run(c[1]-y, '$')
+			x, y = 0, c[1]
+		}
+		if c[0] > x {
+			run(c[0]-x, 'b')
+		}
+		n := 1
+		for i+n < len(cells) && cells[i+n] == [2]int{c[0] + n, y} {
+			n++
+		}
+		run(n, 'o')
+		x, i = c[0]+n, i+n
+	}
+	runs = append(runs, "!")
+
+	var out strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&out, "#N %s\n", p.Name)
+	}
+	fmt.Fprintf(&out, "x = %d, y = %d, rule = %s\n", w, h, rule)
+	// Keep lines to the customary 70 characters, breaking them between
+	// runs as Golly does.
+	n := 0
+	for _, r := range runs {
+		if n > 0 && n+len(r) > 70 {
+			out.WriteByte('\n')
+			n = 0
+		}
+		out.WriteString(r)
+		n += len(r)
+	}
+	out.WriteByte('\n')
+	return out.String()
+}
+
+// EncodeCells writes the pattern in plaintext form, with its name in a
+// comment line.
+func EncodeCells(p Pattern) string {
+	var out strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&out, "!Name: %s\n", p.Name)
+	}
+	// Each row stops at its last live cell. Empty rows keep a dot, since
+	// leading blank lines are skipped.
+	x, y := 0, 0
+	for _, c := range readingOrder(p.Cells) {
+		for ; y < c[1]; y, x = y+1, 0 {
+			if x == 0 {
+				out.WriteByte('.')
+			}
+			out.WriteByte('\n')
+		}
+		out.WriteString(strings.Repeat(".", c[0]-x))
+		out.WriteByte('O')
+		x = c[0] + 1
+	}
+	if x > 0 {
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// EncodeLife writes the pattern in Life 1.06 form, one live cell per line
+// in reading order, centred on the origin.
+func EncodeLife(p Pattern) string {
+	w, h := p.Size()
+	var out strings.Builder
+	out.WriteString("#Life 1.06\n")
+	for _, c := range readingOrder(p.Cells) {
+		fmt.Fprintf(&out, "%d %d\n", c[0]-w/2, c[1]-h/2)
+	}
+	return out.String()
+}
+
+// readingOrder returns the cells sorted by row then column, each once.
+func readingOrder(cells [][2]int) [][2]int {
+	cells = slices.Clone(cells)
+	slices.SortFunc(cells, func(a, b [2]int) int {
+		if a[1] != b[1] {
+			return a[1] - b[1]
+		}
+		return a[0] - b[0]
+	})
+	return slices.Compact(cells)
+}
@@ -0,0 +1,387 @@
+package life
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// addCorpus adds the files each glob matches to f's seed corpus.
+func addCorpus(f *testing.F, globs ...string) {
+	f.Helper()
+	for _, glob := range globs {
+		paths, err := filepath.Glob(glob)
+		if err != nil {
+			f.Fatal(err)
+		}
+		if len(paths) == 0 {
+			f.Fatalf("no seed files match %s", glob)
+		}
+		for _, path := range paths {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				f.Fatal(err)
+			}
+			f.Add(string(src))
+		}
+	}
+}
+
+// checkSamePattern fails t unless got, read back from encoded, has the
+// cells and name want has.
+func checkSamePattern(t *testing.T, got, want Pattern, encoded string) {
+	t.Helper()
+	if !slices.Equal(readingOrder(got.Cells), readingOrder(want.Cells)) {
+		t.Fatalf("cells read back as %v, want %v, from\n%s", readingOrder(got.Cells), readingOrder(want.Cells), encoded)
+	}
+	if got.Name != want.Name {
+		t.Fatalf("name read back as %q, want %q, from\n%s", got.Name, want.Name, encoded)
+	}
+}
+
+func FuzzParseRLE(f *testing.F) {
+	addCorpus(f, "testdata/*.rle", "patterns/*.rle")
+	f.Add("x = 3, y = 3, rule = B3/S23:T10,10\nbo$2bo$3o!")
+	f.Add("0$3o2$o!")
+	f.Fuzz(func(t *testing.T, src string) {
+		p, rule, err := ParseRLE(src)
+		if err != nil {
+			return
+		}
+		encoded := EncodeRLE(p, rule)
+		q, reread, err := ParseRLE(encoded)
+		if err != nil {
+			t.Fatalf("encoded pattern doesn't parse: %v\n%s", err, encoded)
+		}
+		checkSamePattern(t, q, p, encoded)
+		if reread != rule {
+			t.Fatalf("rule read back as %q, want %q", reread, rule)
+		}
+	})
+}
+
+func FuzzParseCells(f *testing.F) {
+	addCorpus(f, "testdata/*.cells")
+	f.Add("\n\n....\n.O\n\n*o\n")
+	f.Fuzz(func(t *testing.T, src string) {
+		p, err := ParseCells(src)
+		if err != nil {
+			return
+		}
+		encoded := EncodeCells(p)
+		q, err := ParseCells(encoded)
+		if err != nil {
+			t.Fatalf("encoded pattern doesn't parse: %v\n%s", err, encoded)
+		}
+		checkSamePattern(t, q, p, encoded)
+	})
+}
+
+func FuzzParseLife(f *testing.F) {
+	addCorpus(f, "testdata/*.lif")
+	f.Add("#Life 1.06\n3 3\n3 3\n-5 2\n")
+	f.Fuzz(func(t *testing.T, src string) {
+		p, err := ParseLife(src)
+		if err != nil {
+			return
+		}
+		encoded := EncodeLife(p)
+		q, err := ParseLife(encoded)
+		if err != nil {
+			t.Fatalf("encoded pattern doesn't parse: %v\n%s", err, encoded)
+		}
+		checkSamePattern(t, q, p, encoded)
+	})
+}
+
+// FuzzParseMacrocell round-trips through RLE, there being no macrocell
+// encoder.
+func FuzzParseMacrocell(f *testing.F) {
+	addCorpus(f, "testdata/*.mc")
+	f.Add("[M2]\n$$$$$$$*$\n4 0 0 0 1\n5 0 0 0 2\n6 3 0 0 3\n")
+	f.Fuzz(func(t *testing.T, src string) {
+		p, rule, err := ParseMacrocell(src, 1<<16)
+		if err != nil {
+			return
+		}
+		encoded := EncodeRLE(p, rule)
+		q, reread, err := ParseRLE(encoded)
+		if err != nil {
+			t.Fatalf("encoded pattern doesn't parse: %v\n%s", err, encoded)
+		}
+		checkSamePattern(t, q, p, encoded)
+		if reread != rule {
+			t.Fatalf("rule read back as %q, want %q", reread, rule)
+		}
+	})
+}
+
+// readPattern parses the testdata file name with ParseRLE or ParseCells.
+func readPattern(t *testing.T, name string) (Pattern, string) {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		p    Pattern
+		rule string
+	)
+	if filepath.Ext(name) == ".cells" {
+		p, err = ParseCells(string(src))
+	} else {
+		p, rule, err = ParseRLE(string(src))
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return p, rule
+}
+
+func TestParseRLEFiles(t *testing.T) {
+	for _, c := range []struct {
+		file, name, rule string
+		w, h, cells      int
+		// library is the built-in pattern the file's cells should match.
+		library string
+	}{
+		{"glider.rle", "Glider", "B3/S23", 3, 3, 5, "glider"},
+		// Pulsar's rows wrap in the middle of a run.
+		{"pulsar.rle", "Pulsar", "B3/S23", 13, 13, 48, "pulsar"},
+		{"lwss.rle", "Lightweight spaceship", "B3/S23", 5, 4, 9, "lwss"},
+		{"gosperglidergun.rle", "Gosper glider gun", "B3/S23", 36, 9, 36, "gosper-gun"},
+		{"replicator.rle", "Replicator", "B36/S23", 5, 5, 12, ""},
+	} {
+		p, rule := readPattern(t, c.file)
+		if w, h := p.Size(); p.Name != c.name || rule != c.rule || w != c.w || h != c.h || len(p.Cells) != c.cells {
+			t.Errorf("%s is the %dx%d %q of %d cells in %q, want the %dx%d %q of %d in %q", c.file, w, h, p.Name, len(p.Cells), rule, c.w, c.h, c.name, c.cells, c.rule)
+		}
+		if c.library != "" && !slices.Equal(readingOrder(p.Cells), readingOrder(LibraryPattern(c.library).Cells)) {
+			t.Errorf("%s's cells aren't the built-in %s's", c.file, c.library)
+		}
+	}
+}
+
+func TestParseRLE(t *testing.T) {
+	row := func(n int) [][2]int {
+		var cells [][2]int
+		for x := 0; x < n; x++ {
+			cells = append(cells, [2]int{x, 0})
+		}
+		return cells
+	}
+	for _, c := range []struct {
+		src   string
+		cells [][2]int
+	}{
+		{"x = 12, y = 1\n12o!", row(12)},
+		// Lines can break in the middle of a run count.
+		{"x = 12, y = 1\n1\n2o!", row(12)},
+		{"x = 3, y = 3\r\nbo$\r\n2bo$3o!\r\n", [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}},
+		{"#C comment\n\n  x = 2, y = 11  \n o 10$ o !", [][2]int{{0, 0}, {0, 10}}},
+		{"x = 2, y = 2\nbo$o!\nignored", [][2]int{{1, 0}, {0, 1}}},
+		{"x = 4, y = 1, rule = B3/S23\n4A!", row(4)},
+	} {
+		p, _, err := ParseRLE(c.src)
+		if err != nil {
+			t.Fatalf("%q: %v", c.src, err)
+		}
+		if !slices.Equal(readingOrder(p.Cells), readingOrder(c.cells)) {
+			t.Errorf("%q parses to %v, want %v", c.src, p.Cells, c.cells)
+		}
+	}
+
+	for _, src := range []string{"", "#C only a comment\n", "x = 3, y = 3\nbo$2bq!", "x = -1, y = 3\no!", "x = 2000000, y = 1\no!", "99999999999999999999o!"} {
+		if _, _, err := ParseRLE(src); err == nil {
+			t.Errorf("%q parses", src)
+		}
+	}
+}
+
+// TestEncodeRLERoundTrips exports random boards, sparse to dense and some
+// wider than a line, and imports them back where they were.
+func TestEncodeRLERoundTrips(t *testing.T) {
+	for seed := int64(1); seed <= 40; seed++ {
+		columns, rows := 1+int(seed*7)%120, 1+int(seed*13)%50
+		g := NewSimulation(NewGrid(columns, rows), Conway, seed, float64(seed%10)/10, 0).Cells
+		minX, minY, maxX, maxY, ok := g.Bounds()
+		if !ok {
+			continue
+		}
+		p := g.Pattern()
+		encoded := EncodeRLE(p, "B36/S23")
+		lines := strings.Split(strings.TrimSuffix(encoded, "\n"), "\n")
+		if want := fmt.Sprintf("x = %d, y = %d, rule = B36/S23", maxX-minX+1, maxY-minY+1); lines[0] != want {
+			t.Fatalf("seed %d: header %q, want %q", seed, lines[0], want)
+		}
+		for _, line := range lines[1:] {
+			if len(line) > 70 {
+				t.Fatalf("seed %d: %d character line %q", seed, len(line), line)
+			}
+		}
+
+		q, rule, err := ParseRLE(encoded)
+		if err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, encoded)
+		}
+		w, h := q.Size()
+		back := NewGrid(columns, rows)
+		back.Stamp(q, minX+w/2, maxY-h/2, false)
+		if rule != "B36/S23" || !back.Same(g) {
+			t.Fatalf("seed %d: the %dx%d board didn't come back from\n%s", seed, columns, rows, encoded)
+		}
+	}
+}
+
+// TestEncodeRLEAsGolly checks the gun comes out as Golly writes it, lines
+// broken between runs at 70 characters.
+func TestEncodeRLEAsGolly(t *testing.T) {
+	p, rule := readPattern(t, "gosperglidergun.rle")
+	want := "#N Gosper glider gun\n" +
+		"x = 36, y = 9, rule = B3/S23\n" +
+		"24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$2o8bo3bob2o4b\n" +
+		"obo$10bo5bo7bo$11bo3bo$12b2o!\n"
+	if got := EncodeRLE(p, rule); got != want {
+		t.Errorf("the gun encodes as\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseCellsFiles(t *testing.T) {
+	for _, name := range []string{"glider", "gosperglidergun"} {
+		cells, _ := readPattern(t, name+".cells")
+		rle, _ := readPattern(t, name+".rle")
+		if cells.Name != rle.Name || !slices.Equal(readingOrder(cells.Cells), readingOrder(rle.Cells)) {
+			t.Errorf("%s.cells is %q with %v, want %s.rle's %q with %v", name, cells.Name, readingOrder(cells.Cells), name, rle.Name, readingOrder(rle.Cells))
+		}
+	}
+}
+
+func TestParseCells(t *testing.T) {
+	glider := [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}
+	for _, src := range []string{
+		".O\n..O\nOOO\n",
+		"!Name: a glider\r\n.O.\r\n..O\r\nOOO\r\n",
+		"\n\n  \n.O\n..O\nOOO",
+		"!comment\n\n.*\n..*\n***\n\n\n",
+		".O......\n..O\nOOO.   \n",
+	} {
+		p, err := ParseCells(src)
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		if !slices.Equal(readingOrder(p.Cells), readingOrder(glider)) {
+			t.Errorf("%q parses to %v, want a glider", src, readingOrder(p.Cells))
+		}
+	}
+	// Blank lines within a pattern are empty rows.
+	if p, _ := ParseCells("O\n\nO\n"); !slices.Equal(readingOrder(p.Cells), [][2]int{{0, 0}, {0, 2}}) {
+		t.Errorf("a blank row parses to %v", p.Cells)
+	}
+	if _, err := ParseCells(".O\n.X\n"); err == nil {
+		t.Error("a plaintext pattern with an X parses")
+	}
+}
+
+func TestEncodeCells(t *testing.T) {
+	got := EncodeCells(Pattern{Name: "Glider", Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}})
+	if want := "!Name: Glider\n.O\n..O\nOOO\n"; got != want {
+		t.Errorf("glider encodes as %q, want %q", got, want)
+	}
+	got = EncodeCells(Pattern{Cells: [][2]int{{0, 0}, {3, 3}}})
+	if want := "O\n.\n.\n...O\n"; got != want {
+		t.Errorf("a sparse pattern encodes as %q, want %q", got, want)
+	}
+}
+
+// TestParsePatternSniffs reads a glider in each format with nothing but
+// its text to go by.
+func TestParsePatternSniffs(t *testing.T) {
+	want := readingOrder(LibraryPattern("glider").Cells)
+	for _, name := range []string{"glider.rle", "glider.cells", "glider.lif", "glider-1.05.lif", "glider.mc"} {
+		src, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _, err := ParsePattern(string(src), 1<<16)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(readingOrder(p.Cells), want) {
+			t.Errorf("%s sniffed as %v, want a glider", name, readingOrder(p.Cells))
+		}
+	}
+	for _, src := range []string{".O\n..O\nOOO\n", "\n\n..O\nOOO", "bo$2bo$3o!", "3o!", "OOO\n"} {
+		p, _, err := ParsePattern(src, 1<<16)
+		if err != nil || len(p.Cells) == 0 {
+			t.Errorf("%q sniffed as %v, %v", src, p.Cells, err)
+		}
+	}
+}
+
+func TestParseMacrocellFiles(t *testing.T) {
+	for _, c := range []struct {
+		file, name string
+		cells      int
+		// same is a file of the same pattern in another format.
+		same string
+	}{
+		{"glider.mc", "Glider", 5, "glider.rle"},
+		{"gosperglidergun.mc", "Gosper glider gun", 36, "gosperglidergun.rle"},
+	} {
+		src, err := os.ReadFile(filepath.Join("testdata", c.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, rule, err := ParseMacrocell(string(src), 1<<16)
+		if err != nil {
+			t.Fatalf("%s: %v", c.file, err)
+		}
+		same, _ := readPattern(t, c.same)
+		if p.Name != c.name || rule != "B3/S23" || len(p.Cells) != c.cells || !slices.Equal(readingOrder(p.Cells), readingOrder(same.Cells)) {
+			t.Errorf("%s is %q in %q with %d cells, want %q in B3/S23 with %s's %d", c.file, p.Name, rule, len(p.Cells), c.name, c.same, c.cells)
+		}
+		if _, _, err := ParseMacrocell(string(src), c.cells-1); err == nil {
+			t.Errorf("%s parses with a limit of %d cells", c.file, c.cells-1)
+		}
+	}
+}
+
+func TestParseMacrocell(t *testing.T) {
+	// Four shared copies of a 16x16 node holding a block are 16 cells, and
+	// a block in every leaf of a node 2^24 cells across is too many to
+	// expand.
+	shared := "[M2]\n**$**$\n4 1 0 0 0\n5 2 2 2 2\n"
+	p, _, err := ParseMacrocell(shared, 1<<16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := p.Size(); len(p.Cells) != 16 || w != 18 || h != 18 {
+		t.Fatalf("four shared blocks are %dx%d with %d cells, want 18x18 with 16", w, h, len(p.Cells))
+	}
+	huge := "[M2]\n**$**$\n4 1 1 1 1\n"
+	for level := 5; level < 25; level++ {
+		n := level - 3
+		huge += fmt.Sprintf("%d %d %d %d %d\n", level, n, n, n, n)
+	}
+	if _, _, err := ParseMacrocell(huge, 1<<20); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Fatalf("a pattern of 2^44 cells: %v", err)
+	}
+
+	for src, want := range map[string]string{
+		"[M2]\n#R LifeHistory\n1 0 1 1 2\n":     "multi-state",
+		"[M2]\n**$**$\n4 1 0 0 7\n":             "invalid child",
+		"[M2]\n**$**$\n5 1 0 0 0\n":             "invalid child",
+		"[M2]\n*********$\n":                    "bigger than 8x8",
+		"[M2]\n**$**x\n":                        "unexpected",
+		"[M2]\n#N nothing\n":                    "no macrocell nodes",
+		"[M2]\n**$**$\n4 1 0\n":                 "four children",
+		"[M2]\n**$**$\n4 1 0 0 0\n70 2 0 0 0\n": "invalid node level",
+	} {
+		if _, _, err := ParseMacrocell(src, 1<<16); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want an error saying %s", src, err, want)
+		}
+	}
+}
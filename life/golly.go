@@ -0,0 +1,679 @@
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// gollyDenseTable is the most entries a Golly rule table's transitions
+	// are tabulated in up front, one for every state a cell and its
+	// neighbours can be in, and gollyDenseWork the most transitions tried
+	// doing it; bigger tables are looked up as they're met.
+	gollyDenseTable = 1 << 22
+	gollyDenseWork  = 1 << 24
+	// gollyMaxTransitions is the most transitions a table can expand to,
+	// its bound variables given every value and its symmetries applied,
+	// so a short file can't make one without limit.
+	gollyMaxTransitions = 1 << 16
+)
+
+// gollyNeighbourhoods are the neighbourhoods a Golly rule table can have,
+// clockwise from north, as Golly orders a transition's neighbours.
+var gollyNeighbourhoods = map[string][][2]int{
+	"vonneumann": {{0, 1}, {1, 0}, {0, -1}, {-1, 0}},
+	"moore":      {{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}},
+}
+
+// ParseGollyRule reads a Golly .rule file, returning the automaton its
+// @TABLE section defines, drawn in the colours of its @COLORS section, for
+// Register. Cells in state 0 are dead, and those in any other are alive
+// with that state as their age.
+//
+// Tables can have the von Neumann or Moore neighbourhoods and any of
+// Golly's symmetries. Variables are bound, as in Golly: one used more than
+// once in a transition has the same value everywhere it's used. A cell no
+// transition matches stays as it is. @ICONS and @NAMES are ignored, since
+// the game draws cells as squares and doesn't name states; @TREE, and any
+// other section, isn't supported.
+func ParseGollyRule(r io.Reader) (Automaton, error) {
+	p := gollyParser{states: 2, symmetries: "none"}
+	var colours []string
+	section := ""
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(text, "@") {
+			name, rest, _ := strings.Cut(text, " ")
+			switch section = strings.ToUpper(name); section {
+			case "@RULE":
+				p.name = strings.TrimSpace(rest)
+			case "@TABLE", "@COLORS", "@ICONS", "@NAMES":
+			default:
+				return Automaton{}, fmt.Errorf("line %d: the %s section is not supported", line, name)
+			}
+			continue
+		}
+		if i := strings.Index(text, "#"); i >= 0 && section != "@RULE" {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text == "" {
+			// The rule's description is its first paragraph.
+			p.described = p.described || section == "@RULE" && p.description != ""
+			continue
+		}
+		var err error
+		switch section {
+		case "":
+			err = fmt.Errorf("%q comes before any section", text)
+		case "@RULE":
+			if !p.described {
+				p.description = strings.TrimSpace(p.description + " " + text)
+			}
+		case "@TABLE":
+			p.sawTable = true
+			err = p.line(text)
+		case "@COLORS":
+			colours = append(colours, text)
+		}
+		if err != nil {
+			return Automaton{}, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Automaton{}, err
+	}
+	switch {
+	case p.name == "":
+		return Automaton{}, fmt.Errorf("no @RULE naming the rule")
+	case strings.ContainsAny(p.name, ":,/ "):
+		return Automaton{}, fmt.Errorf("rule %q: names can't have :, /, commas or spaces", p.name)
+	case !p.sawTable:
+		return Automaton{}, fmt.Errorf("rule %s: no @TABLE, which is the only way of defining a rule that's supported", p.name)
+	}
+	palette, err := gollyPalette(colours, p.states)
+	if err != nil {
+		return Automaton{}, fmt.Errorf("rule %s: @COLORS: %w", p.name, err)
+	}
+	t := p.table()
+	description := p.description
+	if description == "" {
+		description = "A Golly rule table"
+	}
+	return Automaton{
+		Name:        p.name,
+		Description: description,
+		NewStepper: func(config string) (Stepper, error) {
+			if config != "" {
+				return nil, fmt.Errorf("a Golly rule table takes no configuration, not %q", config)
+			}
+			return t, nil
+		},
+		States:  p.states,
+		Palette: palette,
+	}, nil
+}
+
+// EncodeGollyRule writes a as a Golly .rule file, for an automaton
+// ParseGollyRule made, which reads back as the same rule. The table is
+// written as the transitions it was expanded to, with a variable for each
+// set of states wherever it's used, so none is bound, and its symmetries
+// already applied unless they're permute.
+func EncodeGollyRule(a Automaton) (string, error) {
+	s, err := a.NewStepper("")
+	if err != nil {
+		return "", err
+	}
+	t, ok := s.(*gollyTable)
+	if !ok {
+		return "", fmt.Errorf("automaton %s isn't a Golly rule table", a.Name)
+	}
+	var out, vars, transitions strings.Builder
+	fmt.Fprintf(&out, "@RULE %s\n\n%s\n\n@TABLE\n\nn_states:%d\n", a.Name, a.Description, t.states)
+	// A table with no transitions needn't have said its neighbourhood.
+	switch len(t.neighbours) {
+	case 4:
+		out.WriteString("neighborhood:vonNeumann\n")
+	case 8:
+		out.WriteString("neighborhood:Moore\n")
+	}
+	if len(t.transitions) > 0 && t.transitions[0].permuted {
+		out.WriteString("symmetries:permute\n")
+	}
+	out.WriteByte('\n')
+	type use struct {
+		states gollyStates
+		at     int
+	}
+	names := make(map[use]string)
+	for _, tr := range t.transitions {
+		for i, in := range tr.in {
+			var states []string
+			for state := 0; state < t.states; state++ {
+				if in.has(uint8(state)) {
+					states = append(states, strconv.Itoa(state))
+				}
+			}
+			if len(states) == 1 {
+				transitions.WriteString(states[0])
+			} else {
+				name, ok := names[use{in, i}]
+				if !ok {
+					name = "v" + strconv.Itoa(len(names))
+					names[use{in, i}] = name
+					fmt.Fprintf(&vars, "var %s={%s}\n", name, strings.Join(states, ","))
+				}
+				transitions.WriteString(name)
+			}
+			transitions.WriteByte(',')
+		}
+		fmt.Fprintf(&transitions, "%d\n", tr.next)
+	}
+	out.WriteString(vars.String())
+	out.WriteString(transitions.String())
+	out.WriteString("\n@COLORS\n\n")
+	for i, c := range a.Palette {
+		fmt.Fprintf(&out, "%d %d %d %d\n", i+1, c.R, c.G, c.B)
+	}
+	return out.String(), nil
+}
+
+// gollyStates is a set of states, a bit for each.
+type gollyStates [4]uint64
+
+func (s *gollyStates) add(state int) {
+	s[state/64] |= 1 << (state % 64)
+}
+
+func (s gollyStates) has(state uint8) bool {
+	return s[state/64]&(1<<(state%64)) != 0
+}
+
+// gollyTransition is a transition with its bound variables given values:
+// the states the cell, then each neighbour, can be in for it to apply,
+// and the state the cell goes to.
+type gollyTransition struct {
+	in   []gollyStates
+	next uint8
+	// permuted is set if the neighbours can be in any order.
+	permuted bool
+}
+
+// matches reports whether the cell and its neighbours, in cells, are as
+// the transition needs.
+func (t *gollyTransition) matches(cells []uint8) bool {
+	if !t.in[0].has(cells[0]) {
+		return false
+	}
+	if !t.permuted {
+		for i, s := range t.in[1:] {
+			if !s.has(cells[i+1]) {
+				return false
+			}
+		}
+		return true
+	}
+	// Each neighbour must be matched to a different one of the
+	// transition's, found by augmenting paths.
+	n := len(t.in) - 1
+	owner := make([]int, n)
+	for i := range owner {
+		owner[i] = -1
+	}
+	var seen []bool
+	var assign func(cell int) bool
+	assign = func(cell int) bool {
+		for i := 0; i < n; i++ {
+			if seen[i] || !t.in[i+1].has(cells[cell+1]) {
+				continue
+			}
+			seen[i] = true
+			if owner[i] < 0 || assign(owner[i]) {
+				owner[i] = cell
+				return true
+			}
+		}
+		return false
+	}
+	for cell := 0; cell < n; cell++ {
+		seen = make([]bool, n)
+		if !assign(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+type gollyParser struct {
+	name, description string
+	described         bool
+	sawTable          bool
+	states            int
+	neighbourhood     string
+	symmetries        string
+	vars              map[string]gollyVar
+	transitions       []gollyTransition
+}
+
+// gollyVar is a variable's states, in the order they were given.
+type gollyVar []int
+
+// line reads a line of a @TABLE section.
+func (p *gollyParser) line(text string) error {
+	if key, value, ok := strings.Cut(text, ":"); ok {
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if len(p.transitions) > 0 {
+			return fmt.Errorf("%s has to come before the transitions", key)
+		}
+		switch key {
+		case "n_states":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 2 || n > 256 {
+				return fmt.Errorf("n_states must be from 2 to 256, not %q", value)
+			}
+			p.states = n
+		case "neighborhood":
+			if _, ok := gollyNeighbourhoods[strings.ToLower(value)]; !ok {
+				return fmt.Errorf("the %s neighborhood is not supported; only vonNeumann and Moore are", value)
+			}
+			p.neighbourhood = strings.ToLower(value)
+		case "symmetries":
+			p.symmetries = value
+		default:
+			return fmt.Errorf("%s is not supported", key)
+		}
+		return nil
+	}
+	if p.neighbourhood == "" {
+		return fmt.Errorf("no neighborhood before %q", text)
+	}
+	if _, err := gollySymmetries(p.symmetries, len(gollyNeighbourhoods[p.neighbourhood])); err != nil {
+		return err
+	}
+	if rest, ok := strings.CutPrefix(text, "var "); ok {
+		return p.variable(rest)
+	}
+	return p.transition(text)
+}
+
+// variable reads a var declaration, name={a,b,...}, whose states can be
+// given as earlier variables.
+func (p *gollyParser) variable(text string) error {
+	name, set, ok := strings.Cut(text, "=")
+	name, set = strings.TrimSpace(name), strings.TrimSpace(set)
+	if !ok || name == "" || !strings.HasPrefix(set, "{") || !strings.HasSuffix(set, "}") {
+		return fmt.Errorf("var %q: want the form var a={0,1,2}", text)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("var %s: a variable can't be named by a number", name)
+	}
+	// A state given twice, perhaps by way of variables, is there once, so
+	// variables made of variables can't grow without limit.
+	var (
+		v    gollyVar
+		seen gollyStates
+	)
+	for _, s := range strings.Split(set[1:len(set)-1], ",") {
+		states, err := p.lookup(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("var %s: %w", name, err)
+		}
+		for _, state := range states {
+			if !seen.has(uint8(state)) {
+				seen.add(state)
+				v = append(v, state)
+			}
+		}
+	}
+	if p.vars == nil {
+		p.vars = make(map[string]gollyVar)
+	}
+	p.vars[name] = v
+	return nil
+}
+
+// lookup returns the states s, a state or a variable, stands for.
+func (p *gollyParser) lookup(s string) (gollyVar, error) {
+	if v, ok := p.vars[s]; ok {
+		return v, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a state nor a variable", s)
+	}
+	if n < 0 || n >= p.states {
+		return nil, fmt.Errorf("state %d is out of range; there are %d states", n, p.states)
+	}
+	return gollyVar{n}, nil
+}
+
+// transition reads a transition: the cell's state, its neighbours', and
+// the state it goes to, separated by commas, or one digit each with no
+// commas if there are no more than ten states.
+func (p *gollyParser) transition(text string) error {
+	var fields []string
+	if strings.Contains(text, ",") {
+		for _, f := range strings.Split(text, ",") {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+	} else if p.states <= 10 {
+		for _, r := range strings.ReplaceAll(text, " ", "") {
+			fields = append(fields, string(r))
+		}
+	}
+	neighbours := len(gollyNeighbourhoods[p.neighbourhood])
+	if len(fields) != neighbours+2 {
+		return fmt.Errorf("transition %q: want %d states, the cell's, its %d neighbours' and the one it goes to", text, neighbours+2, neighbours)
+	}
+	// Variables used more than once, and the one the cell goes to, are
+	// bound: every combination of their values is a transition of its own.
+	used := make(map[string]int)
+	for _, f := range fields[:len(fields)-1] {
+		if _, ok := p.vars[f]; ok {
+			used[f]++
+		}
+	}
+	out := fields[len(fields)-1]
+	if _, ok := p.vars[out]; ok && used[out] == 0 {
+		return fmt.Errorf("transition %q: the cell goes to variable %s, which isn't among the states it comes from", text, out)
+	}
+	var bound []string
+	for name, n := range used {
+		if n > 1 || name == out {
+			bound = append(bound, name)
+		}
+	}
+	// Bound variables take values in the order the transition uses them.
+	order := make(map[string]int)
+	for i, f := range fields {
+		if _, ok := order[f]; !ok {
+			order[f] = i
+		}
+	}
+	for i := 1; i < len(bound); i++ {
+		for j := i; j > 0 && order[bound[j]] < order[bound[j-1]]; j-- {
+			bound[j], bound[j-1] = bound[j-1], bound[j]
+		}
+	}
+	perms, _ := gollySymmetries(p.symmetries, neighbours)
+	n := max(len(perms), 1)
+	for _, name := range bound {
+		if n *= len(p.vars[name]); len(p.transitions)+n > gollyMaxTransitions {
+			return fmt.Errorf("transition %q: the table has more than %d transitions", text, gollyMaxTransitions)
+		}
+	}
+	values := make(map[string]int)
+	var expand func(i int) error
+	expand = func(i int) error {
+		if i < len(bound) {
+			for _, v := range p.vars[bound[i]] {
+				values[bound[i]] = v
+				if err := expand(i + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		in := make([]gollyStates, len(fields)-1)
+		for j, f := range fields[:len(fields)-1] {
+			if v, ok := values[f]; ok {
+				in[j].add(v)
+				continue
+			}
+			states, err := p.lookup(f)
+			if err != nil {
+				return fmt.Errorf("transition %q: %w", text, err)
+			}
+			for _, s := range states {
+				in[j].add(s)
+			}
+		}
+		next, ok := values[out]
+		if !ok {
+			states, err := p.lookup(out)
+			if err != nil {
+				return fmt.Errorf("transition %q: %w", text, err)
+			}
+			next = states[0]
+		}
+		if perms == nil {
+			p.transitions = append(p.transitions, gollyTransition{in: in, next: uint8(next), permuted: true})
+			return nil
+		}
+		for _, perm := range perms {
+			t := gollyTransition{in: make([]gollyStates, len(in)), next: uint8(next)}
+			t.in[0] = in[0]
+			for j, k := range perm {
+				t.in[j+1] = in[k+1]
+			}
+			p.transitions = append(p.transitions, t)
+		}
+		return nil
+	}
+	return expand(0)
+}
+
+// gollySymmetries returns the orders a transition's neighbours are tried
+// in for symmetries, each given as which of the transition's neighbours
+// each of the cell's is matched to, or nil for permute, under which any
+// order matches.
+func gollySymmetries(symmetries string, neighbours int) ([][]int, error) {
+	rotate := func(by int) []int {
+		perm := make([]int, neighbours)
+		for i := range perm {
+			perm[i] = (i + by) % neighbours
+		}
+		return perm
+	}
+	// Reflecting is in the north-south axis.
+	reflect := func(perm []int) []int {
+		mirrored := make([]int, neighbours)
+		for i := range mirrored {
+			mirrored[i] = perm[(neighbours-i)%neighbours]
+		}
+		return mirrored
+	}
+	// A quarter turn is two neighbours on in the Moore neighbourhood and
+	// one in von Neumann's.
+	quarter := neighbours / 4
+	var perms [][]int
+	switch symmetries {
+	case "none":
+		perms = [][]int{rotate(0)}
+	case "rotate4":
+		for i := 0; i < 4; i++ {
+			perms = append(perms, rotate(i*quarter))
+		}
+	case "rotate8":
+		if neighbours != 8 {
+			return nil, fmt.Errorf("symmetries rotate8 needs the Moore neighborhood")
+		}
+		for i := 0; i < 8; i++ {
+			perms = append(perms, rotate(i))
+		}
+	case "reflect":
+		perms = [][]int{rotate(0), reflect(rotate(0))}
+	case "rotate4reflect":
+		for i := 0; i < 4; i++ {
+			perms = append(perms, rotate(i*quarter), reflect(rotate(i*quarter)))
+		}
+	case "rotate8reflect":
+		if neighbours != 8 {
+			return nil, fmt.Errorf("symmetries rotate8reflect needs the Moore neighborhood")
+		}
+		for i := 0; i < 8; i++ {
+			perms = append(perms, rotate(i), reflect(rotate(i)))
+		}
+	case "permute":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("symmetries %s is not supported", symmetries)
+	}
+	return perms, nil
+}
+
+// table compiles the transitions read.
+func (p *gollyParser) table() *gollyTable {
+	t := &gollyTable{states: p.states, neighbours: gollyNeighbourhoods[p.neighbourhood], transitions: p.transitions}
+	size := 1
+	for i := 0; i <= len(t.neighbours); i++ {
+		if size *= p.states; size > gollyDenseTable {
+			return t
+		}
+	}
+	if size*len(t.transitions) > gollyDenseWork {
+		return t
+	}
+	t.dense = make([]uint8, size)
+	cells := make([]uint8, len(t.neighbours)+1)
+	for i := range t.dense {
+		// The cell is the most significant digit, in base states.
+		for j, k := len(cells)-1, i; j >= 0; j, k = j-1, k/p.states {
+			cells[j] = uint8(k % p.states)
+		}
+		t.dense[i] = t.next(cells)
+	}
+	return t
+}
+
+// gollyTable steps a board by a Golly rule table.
+type gollyTable struct {
+	states     int
+	neighbours [][2]int
+	// transitions are tried in order, the first that matches applying.
+	transitions []gollyTransition
+	// dense, if the table is small enough, is the state a cell goes to for
+	// every state it and its neighbours can be in, indexed in base states.
+	dense []uint8
+}
+
+// next returns the state the cell in cells[0] goes to with the neighbours
+// after it.
+func (t *gollyTable) next(cells []uint8) uint8 {
+	for i := range t.transitions {
+		if t.transitions[i].matches(cells) {
+			return t.transitions[i].next
+		}
+	}
+	return cells[0]
+}
+
+func (t *gollyTable) Step(g, next Grid, wrap bool) (births, deaths int) {
+	columns, rows := g.Columns(), g.Rows()
+	state := make([]uint8, columns*rows)
+	cur := g.b.view()
+	for i := range state {
+		if cur.alive[i] != 0 {
+			state[i] = uint8(min(max(int(cur.age[i]), 1), t.states-1))
+		}
+	}
+	at := func(x, y int) uint8 {
+		if wrap {
+			x, y = (x+columns)%columns, (y+rows)%rows
+		} else if x < 0 || y < 0 || x >= columns || y >= rows {
+			return 0
+		}
+		return state[y*columns+x]
+	}
+	// Tables too big to tabulate up front are tabulated as they're met,
+	// for this generation.
+	var seen map[[9]uint8]uint8
+	if t.dense == nil {
+		seen = make(map[[9]uint8]uint8)
+	}
+	var cells [9]uint8
+	key := cells[:len(t.neighbours)+1]
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			key[0] = state[g.Index(x, y)]
+			for i, d := range t.neighbours {
+				key[i+1] = at(x+d[0], y+d[1])
+			}
+			var to uint8
+			if t.dense != nil {
+				i := 0
+				for _, s := range key {
+					i = i*t.states + int(s)
+				}
+				to = t.dense[i]
+			} else if n, ok := seen[cells]; ok {
+				to = n
+			} else {
+				to = t.next(key)
+				seen[cells] = to
+			}
+			switch c, was := g.At(x, y), key[0]; {
+			case to == was:
+				next.SetCell(x, y, c)
+			case to == 0:
+				deaths++
+				next.Set(x, y, false)
+			case was == 0:
+				births++
+				next.SetCell(x, y, Cell{Alive: true, Age: int(to)})
+			default:
+				c.Age = int(to)
+				next.SetCell(x, y, c)
+			}
+		}
+	}
+	return births, deaths
+}
+
+// gollyPalette returns the colours of the live states from a @COLORS
+// section's lines: each a state and its red, green and blue, or two
+// colours to shade the live states between. States not given are shaded
+// from red to yellow, as Golly's are by default. State 0's colour is
+// ignored, since dead cells are drawn in the background colour.
+func gollyPalette(lines []string, states int) ([]color.RGBA, error) {
+	palette := gollyGradient(color.RGBA{255, 0, 0, 255}, color.RGBA{255, 255, 0, 255}, states-1)
+	for _, line := range lines {
+		var n []int
+		for _, f := range strings.Fields(line) {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				// Golly allows a colour's name after its numbers.
+				break
+			}
+			n = append(n, v)
+		}
+		for _, v := range n {
+			if v < 0 || v > 255 {
+				return nil, fmt.Errorf("%q: %d isn't from 0 to 255", line, v)
+			}
+		}
+		switch len(n) {
+		case 4:
+			if n[0] >= states {
+				return nil, fmt.Errorf("%q: state %d is out of range; there are %d states", line, n[0], states)
+			}
+			if n[0] > 0 {
+				palette[n[0]-1] = color.RGBA{uint8(n[1]), uint8(n[2]), uint8(n[3]), 255}
+			}
+		case 6:
+			palette = gollyGradient(color.RGBA{uint8(n[0]), uint8(n[1]), uint8(n[2]), 255}, color.RGBA{uint8(n[3]), uint8(n[4]), uint8(n[5]), 255}, states-1)
+		default:
+			return nil, fmt.Errorf("%q: want a state and its red, green and blue, or two colours to shade the states between", line)
+		}
+	}
+	return palette, nil
+}
+
+// gollyGradient returns n colours shaded evenly from one to another.
+func gollyGradient(from, to color.RGBA, n int) []color.RGBA {
+	mix := func(a, b uint8, i int) uint8 {
+		if n == 1 {
+			return a
+		}
+		return uint8(int(a) + (int(b)-int(a))*i/(n-1))
+	}
+	colours := make([]color.RGBA, n)
+	for i := range colours {
+		colours[i] = color.RGBA{mix(from.R, to.R, i), mix(from.G, to.G, i), mix(from.B, to.B, i), 255}
+	}
+	return colours
+}
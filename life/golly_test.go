@@ -0,0 +1,166 @@
+package life
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func FuzzParseGollyRule(f *testing.F) {
+	addCorpus(f, "testdata/*.rule", "../examples/golly/*.rule")
+	f.Add("@RULE Empty\n@TABLE\nn_states:3\n")
+	f.Fuzz(func(t *testing.T, src string) {
+		a, err := ParseGollyRule(strings.NewReader(src))
+		if err != nil {
+			return
+		}
+		encoded, err := EncodeGollyRule(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseGollyRule(strings.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("encoded rule doesn't parse: %v\n%s", err, encoded)
+		}
+		if a.Name != b.Name || a.Description != b.Description || a.States != b.States || !reflect.DeepEqual(a.Palette, b.Palette) {
+			t.Fatalf("rule read back as %s %q with %d states and palette %v, want %s %q with %d and %v, from\n%s",
+				b.Name, b.Description, b.States, b.Palette, a.Name, a.Description, a.States, a.Palette, encoded)
+		}
+		at, _ := a.NewStepper("")
+		bt, _ := b.NewStepper("")
+		if !reflect.DeepEqual(at, bt) {
+			t.Fatalf("table reads back differently from\n%s", encoded)
+		}
+	})
+}
+
+// readGollyRule parses the .rule file at path and makes its stepper.
+func readGollyRule(t *testing.T, path string) (Automaton, Stepper) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	a, err := ParseGollyRule(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := a.NewStepper("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, s
+}
+
+// stepGolly steps g a generation by s, returning the next board.
+func stepGolly(s Stepper, g Grid, wrap bool) Grid {
+	next := NewGrid(g.Columns(), g.Rows())
+	s.Step(g, next, wrap)
+	return next
+}
+
+// TestGollyLifeTable checks Life written as a rule table steps a soup the
+// same as B3/S23 does, with and without wrapping.
+func TestGollyLifeTable(t *testing.T) {
+	a, s := readGollyRule(t, "testdata/LifeTable.rule")
+	if a.Name != "LifeTable" || a.States != 2 {
+		t.Errorf("read %s with %d states, want LifeTable with 2", a.Name, a.States)
+	}
+	for _, wrap := range []bool{false, true} {
+		table, native := NewGrid(32, 24), NewGrid(32, 24)
+		table.Randomize(rand.New(rand.NewSource(5)), 0.35)
+		native.CopyFrom(table)
+		for gen := 1; gen <= 40; gen++ {
+			table = stepGolly(s, table, wrap)
+			native.Step(Conway, wrap)
+			for i := 0; i < 32*24; i++ {
+				x, y := i%32, i/32
+				if table.Alive(x, y) != native.Alive(x, y) {
+					t.Fatalf("wrap %v, generation %d: cell (%d, %d) alive %v, want %v as B3/S23 has it", wrap, gen, x, y, table.Alive(x, y), native.Alive(x, y))
+				}
+			}
+		}
+	}
+}
+
+// TestGollyWireWorld checks an electron goes round a loop of wire, one
+// head and one tail all the way, back to where it started after as many
+// generations as the loop is long.
+func TestGollyWireWorld(t *testing.T) {
+	_, s := readGollyRule(t, "../examples/golly/WireWorld.rule")
+	const head, tail, wire = 1, 2, 3
+	// The loop is the edge of an 8 by 5 rectangle with its corners cut,
+	// which would split the electron, 18 cells round.
+	g := NewGrid(12, 9)
+	for x := 3; x < 9; x++ {
+		g.SetCell(x, 2, Cell{Alive: true, Age: wire})
+		g.SetCell(x, 6, Cell{Alive: true, Age: wire})
+	}
+	for y := 3; y < 6; y++ {
+		g.SetCell(2, y, Cell{Alive: true, Age: wire})
+		g.SetCell(9, y, Cell{Alive: true, Age: wire})
+	}
+	g.SetCell(5, 2, Cell{Alive: true, Age: head})
+	g.SetCell(4, 2, Cell{Alive: true, Age: tail})
+	start := g.Clone()
+
+	for gen := 1; gen <= 18; gen++ {
+		g = stepGolly(s, g, false)
+		count := map[int]int{}
+		for y := 0; y < g.Rows(); y++ {
+			for x := 0; x < g.Columns(); x++ {
+				if c := g.At(x, y); c.Alive {
+					count[c.Age]++
+				}
+			}
+		}
+		if count[head] != 1 || count[tail] != 1 || count[wire] != 16 {
+			t.Fatalf("generation %d: %d heads, %d tails and %d wire, want 1, 1 and 16", gen, count[head], count[tail], count[wire])
+		}
+	}
+	if !g.Same(start) {
+		t.Error("the electron isn't back where it started after going round the loop")
+	}
+}
+
+// TestGollyParity checks the von Neumann neighbourhood and rotate4: one
+// live cell gives the four beside it, and dies itself.
+func TestGollyParity(t *testing.T) {
+	a, s := readGollyRule(t, "testdata/Parity.rule")
+	if want := "Each cell becomes alive if an odd number of the four cells beside it are."; a.Description != want {
+		t.Errorf("description %q, want %q", a.Description, want)
+	}
+	g := NewGrid(5, 5)
+	g.Set(2, 2, true)
+	g = stepGolly(s, g, false)
+	want := NewGrid(5, 5)
+	for _, c := range [][2]int{{2, 3}, {3, 2}, {2, 1}, {1, 2}} {
+		want.Set(c[0], c[1], true)
+	}
+	if !g.Same(want) {
+		t.Errorf("a cell stepped to\n%s\nwant\n%s", EncodeCells(g.Pattern()), EncodeCells(want.Pattern()))
+	}
+	if _, err := a.NewStepper("x"); err == nil {
+		t.Error("a rule table took a configuration")
+	}
+}
+
+// TestGollyUnsupported checks rule files with what isn't supported say so.
+func TestGollyUnsupported(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		{"@RULE Tree\n@TREE\nnum_states=2\n", "line 2: the @TREE section is not supported"},
+		{"@RULE Odd\n@FOO\n", "line 2: the @FOO section is not supported"},
+		{"@RULE Hex\n@TABLE\nn_states:2\nneighborhood:hexagonal\n", "line 4: the hexagonal neighborhood is not supported"},
+		{"@RULE OneD\n@TABLE\nneighborhood:oneDimensional\n", "the oneDimensional neighborhood is not supported"},
+		{"@RULE Sym\n@TABLE\nneighborhood:vonNeumann\nsymmetries:rotate8\n0,1,0,0,0,1\n", "symmetries rotate8 needs the Moore neighborhood"},
+		{"@RULE NoTable\n", "no @TABLE"},
+	} {
+		_, err := ParseGollyRule(strings.NewReader(c.src))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("parsing %q: %v, want %q", c.src, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,528 @@
+// Package life runs Conway's Game of Life and other Life-like cellular
+// automata: boards of cells, the B/S rules they step by, and patterns to put
+// on them and read and write as files. It draws nothing; the render package
+// does that.
+package life
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// The teams a cell can be on.
+const (
+	NoTeam = iota
+	BlueTeam
+	RedTeam
+)
+
+// MaxAge is the oldest a cell's age is counted to; a cell alive longer
+// stays at it.
+const MaxAge = math.MaxUint16
+
+// Cell is one square of a board, as At reads it. It's a copy: changing it
+// changes nothing on the board, which Set and SetCell are for.
+type Cell struct {
+	Alive bool
+	// Age is the number of generations the cell has been alive for, up to
+	// MaxAge.
+	Age int
+	// Team is the player a live cell belongs to in a two-player game, or
+	// NoTeam. Cells born into a game join the team most of their parents
+	// were on.
+	Team int
+}
+
+// Grid is a board of cells (x, y), with (0, 0) at the bottom left. The
+// board is held as flat arrays, of whether each cell is alive, its age and
+// its team, each indexed by Index, so it's a few allocations and a few
+// bytes a cell however big it is. A Grid refers to its board as a slice
+// does to its array: copies of it share the cells, and Clone makes one
+// that doesn't. The zero Grid is an empty board.
+//
+// A board belongs to one goroutine at a time, its owner, which alone steps
+// it, edits it and reads its cells. Other goroutines see it through
+// Snapshot. Each generation is worked out into a buffer of its own, and
+// replaces the last all at once when it's done, so a snapshot is always of
+// a whole generation.
+type Grid struct {
+	b *board
+}
+
+type board struct {
+	columns, rows int
+	// cur is the generation last stepped or published, the one Snapshot
+	// hands out. A published buffer isn't written to again until it's been
+	// replaced, and then only if no snapshot has it.
+	cur atomic.Pointer[buffer]
+	// draft, if set, is cur with the edits made since it was published,
+	// which the owner sees in its place.
+	draft *buffer
+	// spare, if set, is a buffer no one has, for the next generation.
+	spare *buffer
+
+	// from and into are the boards a stepper sees the buffers it steps
+	// from and into as, and counts is Life's neighbour counts, kept from
+	// one step to the next so stepping allocates nothing.
+	from, into *board
+	counts     []uint8
+}
+
+// buffer is a board's cells at one generation.
+type buffer struct {
+	alive []uint8
+	age   []uint16
+	team  []uint8
+	// shared is set once Snapshot has handed the buffer out.
+	shared atomic.Bool
+}
+
+func newBuffer(cells int) *buffer {
+	return &buffer{alive: make([]uint8, cells), age: make([]uint16, cells), team: make([]uint8, cells)}
+}
+
+func (buf *buffer) copyFrom(o *buffer) {
+	copy(buf.alive, o.alive)
+	copy(buf.age, o.age)
+	copy(buf.team, o.team)
+}
+
+// NewGrid returns an all-dead board columns cells wide and rows high.
+func NewGrid(columns, rows int) Grid {
+	b := &board{columns: columns, rows: rows}
+	b.cur.Store(newBuffer(columns * rows))
+	return Grid{b}
+}
+
+// view is the buffer the owner sees.
+func (b *board) view() *buffer {
+	if b.draft != nil {
+		return b.draft
+	}
+	return b.cur.Load()
+}
+
+// edit returns the buffer edits go into, starting a draft of the board as
+// it is if there isn't one.
+func (b *board) edit() *buffer {
+	if b.draft == nil {
+		b.draft = b.take()
+		b.draft.copyFrom(b.cur.Load())
+	}
+	return b.draft
+}
+
+// rewrite is edit for changes to every cell, which needn't start from the
+// board as it is.
+func (b *board) rewrite() *buffer {
+	if b.draft == nil {
+		b.draft = b.take()
+	}
+	return b.draft
+}
+
+// take returns a buffer to write a new generation or draft into.
+func (b *board) take() *buffer {
+	if buf := b.spare; buf != nil {
+		b.spare = nil
+		// A snapshot taken as the buffer was being replaced marks it
+		// shared, and then gives up on it, but it isn't safe to say so.
+		if !buf.shared.Load() {
+			return buf
+		}
+	}
+	return newBuffer(b.columns * b.rows)
+}
+
+// publish makes buf the board's generation, dropping the draft, and keeps
+// what it replaces as the spare if no snapshot has it.
+func (b *board) publish(buf *buffer) {
+	old := b.cur.Load()
+	b.cur.Store(buf)
+	if b.draft != nil && b.draft != buf && b.spare == nil {
+		b.spare = b.draft
+	}
+	b.draft = nil
+	// Snapshot marks a buffer shared before looking again at which is
+	// current, and this looks at whether it's shared after replacing it,
+	// so either this sees the mark or Snapshot sees the new buffer.
+	if !old.shared.Load() && b.spare == nil {
+		b.spare = old
+	}
+}
+
+// stepView returns *v, made if need be, as a board showing buf.
+func (b *board) stepView(v **board, buf *buffer) Grid {
+	if *v == nil {
+		*v = &board{columns: b.columns, rows: b.rows}
+	}
+	(*v).draft = buf
+	return Grid{*v}
+}
+
+// Snapshot returns the board as it was last stepped or published, as a
+// board of its own that nothing that's done to g changes. Edits made since
+// the last Step aren't in it until the owner calls Publish. Unlike the rest
+// of a Grid's methods, any goroutine can call Snapshot, while the owner
+// carries on using the board; it takes no lock and copies nothing.
+func (g Grid) Snapshot() Grid {
+	if g.b == nil {
+		return Grid{}
+	}
+	for {
+		buf := g.b.cur.Load()
+		buf.shared.Store(true)
+		// If the board moved on meanwhile, buf may be about to be
+		// reused: try again with the one that replaced it.
+		if g.b.cur.Load() == buf {
+			s := &board{columns: g.b.columns, rows: g.b.rows}
+			s.cur.Store(buf)
+			return Grid{s}
+		}
+	}
+}
+
+// Publish makes the board with the edits made to it since it was last
+// stepped or published the one Snapshot hands out.
+func (g Grid) Publish() {
+	if g.b != nil && g.b.draft != nil {
+		g.b.publish(g.b.draft)
+	}
+}
+
+// adopt makes o's cells g's generation without copying them, which o's
+// snapshot means neither board then writes over.
+func (g Grid) adopt(o Grid) {
+	o.Publish()
+	g.b.publish(o.Snapshot().b.cur.Load())
+}
+
+// Clone returns a copy of g that shares none of its cells.
+func (g Grid) Clone() Grid {
+	if g.b == nil {
+		return Grid{}
+	}
+	c := NewGrid(g.Columns(), g.Rows())
+	c.b.cur.Load().copyFrom(g.b.view())
+	return c
+}
+
+// CopyFrom overwrites g's cells with o's, which must be a board of the same
+// size.
+func (g Grid) CopyFrom(o Grid) {
+	if g.b == nil || o.b == nil {
+		return
+	}
+	g.b.rewrite().copyFrom(o.b.view())
+}
+
+// Same reports whether g and o have the same cells, alive or dead, with the
+// same ages and teams.
+func (g Grid) Same(o Grid) bool {
+	if g.Columns() != o.Columns() || g.Rows() != o.Rows() {
+		return false
+	}
+	if g.b == nil {
+		return true
+	}
+	a, b := g.b.view(), o.b.view()
+	if a == b {
+		return true
+	}
+	for i, alive := range a.alive {
+		if alive != b.alive[i] || a.age[i] != b.age[i] || a.team[i] != b.team[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Columns returns the board's width in cells.
+func (g Grid) Columns() int {
+	if g.b == nil {
+		return 0
+	}
+	return g.b.columns
+}
+
+// Rows returns the board's height in cells.
+func (g Grid) Rows() int {
+	if g.b == nil {
+		return 0
+	}
+	return g.b.rows
+}
+
+// Index returns where cell (x, y) is in the board's arrays: y*columns+x.
+func (g Grid) Index(x, y int) int {
+	return y*g.b.columns + x
+}
+
+// At returns cell (x, y).
+func (g Grid) At(x, y int) Cell {
+	buf, i := g.b.view(), g.Index(x, y)
+	return Cell{Alive: buf.alive[i] != 0, Age: int(buf.age[i]), Team: int(buf.team[i])}
+}
+
+// Alive reports whether cell (x, y) is alive.
+func (g Grid) Alive(x, y int) bool {
+	return g.b.view().alive[g.Index(x, y)] != 0
+}
+
+// Set brings cell (x, y) to life or kills it straight away, on no team and
+// at age 1 if it's alive.
+func (g Grid) Set(x, y int, alive bool) {
+	buf, i := g.b.edit(), g.Index(x, y)
+	buf.alive[i], buf.age[i], buf.team[i] = 0, 0, NoTeam
+	if alive {
+		buf.alive[i], buf.age[i] = 1, 1
+	}
+}
+
+// SetCell overwrites cell (x, y) with c, its age held to MaxAge.
+func (g Grid) SetCell(x, y int, c Cell) {
+	buf, i := g.b.edit(), g.Index(x, y)
+	buf.alive[i] = 0
+	if c.Alive {
+		buf.alive[i] = 1
+	}
+	buf.age[i] = uint16(min(max(c.Age, 0), MaxAge))
+	buf.team[i] = uint8(c.Team)
+}
+
+// Step moves the board on a generation by r, returning how many cells were
+// born and how many died. If wrap is set, the board's edges wrap around into
+// a torus. A rule that takes chances draws them from a generator starting
+// over each time; Simulation.Step gives it the simulation's.
+func (g Grid) Step(r Rule, wrap bool) (births, deaths int) {
+	return g.StepRandom(r, wrap, nil)
+}
+
+// StepRandom is Step with rng for the rule to take its chances by, if it
+// does.
+func (g Grid) StepRandom(r Rule, wrap bool, rng *PCG) (births, deaths int) {
+	if g.b == nil {
+		return 0, 0
+	}
+	b := g.b
+	next := b.take()
+	from, into := b.stepView(&b.from, b.view()), b.stepView(&b.into, next)
+	if rs, ok := r.randomStepper(); ok {
+		if rng == nil {
+			rng = new(PCG)
+		}
+		births, deaths = rs.StepRandom(from, into, wrap, rng)
+	} else {
+		births, deaths = r.Step(from, into, wrap)
+	}
+	b.from.draft, b.into.draft = nil, nil
+	b.publish(next)
+	return births, deaths
+}
+
+// Step makes Rule a Stepper.
+func (r Rule) Step(g, next Grid, wrap bool) (births, deaths int) {
+	if r.stepper != nil {
+		return r.stepper.stepper.Step(g, next, wrap)
+	}
+	cur, into := g.b.view(), next.b.edit()
+	counts := g.neighbourCounts(wrap)
+	for i, a := range cur.alive {
+		alive := a != 0
+		if !r.NextAged(alive, int(counts[i]), int(cur.age[i])) {
+			into.alive[i], into.age[i], into.team[i] = 0, 0, NoTeam
+			if alive {
+				deaths++
+			}
+			continue
+		}
+		into.alive[i], into.age[i], into.team[i] = 1, min(cur.age[i], MaxAge-1)+1, cur.team[i]
+		if !alive {
+			births++
+			into.team[i] = uint8(g.birthTeam(i%g.b.columns, i/g.b.columns, wrap))
+		}
+	}
+	return births, deaths
+}
+
+// Neighbours calls f with each of the cells around (x, y), wrapping around
+// the edges if wrap is set.
+func (g Grid) Neighbours(x, y int, wrap bool, f func(c Cell)) {
+	columns, rows := g.Columns(), g.Rows()
+	for i := x - 1; i < x+2; i++ {
+		for j := y - 1; j < y+2; j++ {
+			if i == x && j == y {
+				continue
+			}
+			ni, nj := i, j
+			if wrap {
+				ni, nj = (i+columns)%columns, (j+rows)%rows
+			} else if i < 0 || j < 0 || i >= columns || j >= rows {
+				continue
+			}
+			f(g.At(ni, nj))
+		}
+	}
+}
+
+// neighbourCounts returns how many live neighbours each cell has, by Index.
+// They're added up from the live cells, each counting itself around its
+// neighbours, so the dead cells that most of a board is cost next to
+// nothing.
+func (g Grid) neighbourCounts(wrap bool) []uint8 {
+	b, cur := g.b, g.b.view()
+	columns, rows := b.columns, b.rows
+	if len(b.counts) != len(cur.alive) {
+		b.counts = make([]uint8, len(cur.alive))
+	}
+	counts := b.counts
+	clear(counts)
+	for c, a := range cur.alive {
+		if a == 0 {
+			continue
+		}
+		x, y := c%columns, c/columns
+		for j := y - 1; j < y+2; j++ {
+			nj := j
+			if wrap {
+				nj = (j + rows) % rows
+			} else if j < 0 || j >= rows {
+				continue
+			}
+			for i := x - 1; i < x+2; i++ {
+				if i == x && j == y {
+					continue
+				}
+				ni := i
+				if wrap {
+					ni = (i + columns) % columns
+				} else if i < 0 || i >= columns {
+					continue
+				}
+				counts[nj*columns+ni]++
+			}
+		}
+	}
+	return counts
+}
+
+// birthTeam returns the team a cell born at (x, y) joins: the one most of
+// its live neighbours are on. With three parents there's no tie unless some
+// are on no team, and then a tie goes to neither.
+func (g Grid) birthTeam(x, y int, wrap bool) int {
+	var count [3]int
+	g.Neighbours(x, y, wrap, func(c Cell) {
+		if c.Alive {
+			count[c.Team]++
+		}
+	})
+	switch {
+	case count[BlueTeam] > count[RedTeam]:
+		return BlueTeam
+	case count[RedTeam] > count[BlueTeam]:
+		return RedTeam
+	}
+	return NoTeam
+}
+
+// Randomize brings each cell to life with the given probability, drawing
+// for the cells column by column.
+func (g Grid) Randomize(rng *rand.Rand, density float64) {
+	for x := 0; x < g.Columns(); x++ {
+		for y := 0; y < g.Rows(); y++ {
+			g.Set(x, y, rng.Float64() < density)
+		}
+	}
+}
+
+// Population returns the number of live cells.
+func (g Grid) Population() int {
+	if g.b == nil {
+		return 0
+	}
+	count := 0
+	for _, a := range g.b.view().alive {
+		count += int(a)
+	}
+	return count
+}
+
+// Bounds returns the inclusive range of cells containing every live cell,
+// and false if there are none.
+func (g Grid) Bounds() (minX, minY, maxX, maxY int, ok bool) {
+	if g.b == nil {
+		return 0, 0, 0, 0, false
+	}
+	for i, a := range g.b.view().alive {
+		if a == 0 {
+			continue
+		}
+		x, y := i%g.b.columns, i/g.b.columns
+		if !ok {
+			minX, minY, maxX, maxY, ok = x, y, x, y, true
+			continue
+		}
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	}
+	return minX, minY, maxX, maxY, ok
+}
+
+// Hash is a hash of which cells are alive, the same for the same board.
+// The cells are hashed column by column, eight rows to a byte, as they
+// were when boards were held that way, so hashes written down then still
+// match.
+func (g Grid) Hash() uint64 {
+	h := fnv.New64a()
+	var b [1]byte
+	rows := g.Rows()
+	for x := 0; x < g.Columns(); x++ {
+		for y := 0; y < rows; y++ {
+			if g.Alive(x, y) {
+				b[0] |= 1 << (y % 8)
+			}
+			if y%8 == 7 || y == rows-1 {
+				h.Write(b[:])
+				b[0] = 0
+			}
+		}
+	}
+	return h.Sum64()
+}
+
+// Pattern returns the live cells of the board as a pattern, trimmed to
+// their bounding box.
+func (g Grid) Pattern() Pattern {
+	var p Pattern
+	for x := 0; x < g.Columns(); x++ {
+		for y := 0; y < g.Rows(); y++ {
+			if g.Alive(x, y) {
+				p.Cells = append(p.Cells, [2]int{x, g.Rows() - 1 - y})
+			}
+		}
+	}
+	return p.Transform(func(x, y, w, h int) (int, int) { return x, y })
+}
+
+// Region returns the live cells in the inclusive range of cells as a
+// pattern, with the range's top-left corner at the origin.
+func (g Grid) Region(minX, minY, maxX, maxY int) Pattern {
+	var p Pattern
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			if g.Alive(x, y) {
+				p.Cells = append(p.Cells, [2]int{x - minX, maxY - y})
+			}
+		}
+	}
+	return p
+}
+
+// Stamp brings the pattern to life centred on cell (cx, cy).
+func (g Grid) Stamp(p Pattern, cx, cy int, wrap bool) {
+	for _, c := range p.Placed(cx, cy, g.Columns(), g.Rows(), wrap) {
+		g.Set(c[0], c[1], true)
+	}
+}
@@ -0,0 +1,417 @@
+package life
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"testing"
+)
+
+// oldCell and oldGrid are the board as it was before it was laid out flat,
+// a pointer per cell, kept to check the flat one steps the same.
+type oldCell struct {
+	Alive, aliveNext bool
+	Age              int
+	Team, teamNext   int
+}
+
+type oldGrid [][]*oldCell
+
+func newOldGrid(g Grid) oldGrid {
+	o := make(oldGrid, g.Columns())
+	for x := range o {
+		o[x] = make([]*oldCell, g.Rows())
+		for y := range o[x] {
+			c := g.At(x, y)
+			o[x][y] = &oldCell{Alive: c.Alive, aliveNext: c.Alive, Age: c.Age, Team: c.Team}
+		}
+	}
+	return o
+}
+
+func (g oldGrid) step(r Rule, wrap bool) (births, deaths int) {
+	columns, rows := len(g), len(g[0])
+	neighbours := func(x, y int, f func(c *oldCell)) {
+		for i := x - 1; i < x+2; i++ {
+			for j := y - 1; j < y+2; j++ {
+				if i == x && j == y {
+					continue
+				}
+				ni, nj := i, j
+				if wrap {
+					ni, nj = (i+columns)%columns, (j+rows)%rows
+				} else if i < 0 || j < 0 || i >= columns || j >= rows {
+					continue
+				}
+				f(g[ni][nj])
+			}
+		}
+	}
+	for x := range g {
+		for y, c := range g[x] {
+			n := 0
+			var teams [3]int
+			neighbours(x, y, func(d *oldCell) {
+				if d.Alive {
+					n++
+					teams[d.Team]++
+				}
+			})
+			c.aliveNext = r.NextAged(c.Alive, n, c.Age)
+			switch {
+			case !c.aliveNext:
+				c.teamNext = NoTeam
+			case !c.Alive && teams[BlueTeam] > teams[RedTeam]:
+				c.teamNext = BlueTeam
+			case !c.Alive && teams[RedTeam] > teams[BlueTeam]:
+				c.teamNext = RedTeam
+			case !c.Alive:
+				c.teamNext = NoTeam
+			default:
+				c.teamNext = c.Team
+			}
+		}
+	}
+	for x := range g {
+		for _, c := range g[x] {
+			switch {
+			case c.aliveNext && !c.Alive:
+				births++
+			case c.Alive && !c.aliveNext:
+				deaths++
+			}
+			c.Alive, c.Team = c.aliveNext, c.teamNext
+			if c.Alive {
+				c.Age++
+			} else {
+				c.Age = 0
+			}
+		}
+	}
+	return births, deaths
+}
+
+func (g oldGrid) hash() uint64 {
+	h := fnv.New64a()
+	var b [1]byte
+	for x := range g {
+		for i, c := range g[x] {
+			if c.Alive {
+				b[0] |= 1 << (i % 8)
+			}
+			if i%8 == 7 || i == len(g[x])-1 {
+				h.Write(b[:])
+				b[0] = 0
+			}
+		}
+	}
+	return h.Sum64()
+}
+
+// randomTeams puts each live cell of g on a random team.
+func randomTeams(g Grid, rng *rand.Rand) {
+	for x := 0; x < g.Columns(); x++ {
+		for y := 0; y < g.Rows(); y++ {
+			if c := g.At(x, y); c.Alive {
+				c.Team = rng.Intn(3)
+				g.SetCell(x, y, c)
+			}
+		}
+	}
+}
+
+func TestGridStepsAsOldGrid(t *testing.T) {
+	for _, rule := range []string{"B3/S23", "B36/S23", "B3678/S34678", "B2/S", "B1357/S1357"} {
+		r, err := ParseRule(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, wrap := range []bool{false, true} {
+			rng := rand.New(rand.NewSource(1))
+			g := NewGrid(37, 23)
+			g.Randomize(rng, 0.35)
+			randomTeams(g, rng)
+			old := newOldGrid(g)
+			for gen := 0; gen < 60; gen++ {
+				births, deaths := g.Step(r, wrap)
+				oldBirths, oldDeaths := old.step(r, wrap)
+				if births != oldBirths || deaths != oldDeaths {
+					t.Fatalf("%s wrap %v generation %d: %d births %d deaths, want %d and %d", rule, wrap, gen, births, deaths, oldBirths, oldDeaths)
+				}
+				for x := range old {
+					for y, o := range old[x] {
+						if c := g.At(x, y); c.Alive != o.Alive || c.Age != o.Age || c.Team != o.Team {
+							t.Fatalf("%s wrap %v generation %d: cell %d,%d is %+v, want %+v", rule, wrap, gen, x, y, c, *o)
+						}
+					}
+				}
+				if g.Hash() != old.hash() {
+					t.Fatalf("%s wrap %v generation %d: hash differs", rule, wrap, gen)
+				}
+			}
+		}
+	}
+}
+
+func TestGridAgeStopsAtMaxAge(t *testing.T) {
+	g := NewGrid(4, 4)
+	g.SetCell(1, 1, Cell{Alive: true, Age: MaxAge - 1})
+	g.SetCell(2, 1, Cell{Alive: true, Age: MaxAge - 1})
+	g.SetCell(1, 2, Cell{Alive: true, Age: MaxAge - 1})
+	g.SetCell(2, 2, Cell{Alive: true, Age: MaxAge - 1})
+	for i := 0; i < 3; i++ {
+		g.Step(Conway, false)
+	}
+	if c := g.At(1, 1); !c.Alive || c.Age != MaxAge {
+		t.Errorf("block cell after 3 steps from MaxAge-1 is %+v, want alive at MaxAge", c)
+	}
+}
+
+func TestGridIndex(t *testing.T) {
+	g := NewGrid(5, 3)
+	g.Set(4, 2, true)
+	if i := g.Index(4, 2); i != 14 || g.b.view().alive[i] != 1 {
+		t.Errorf("Index(4, 2) = %d, want 14 and the cell there alive", i)
+	}
+	if minX, minY, maxX, maxY, ok := g.Bounds(); !ok || minX != 4 || minY != 2 || maxX != 4 || maxY != 2 {
+		t.Errorf("Bounds() = %d,%d %d,%d %v, want 4,2 4,2 true", minX, minY, maxX, maxY, ok)
+	}
+}
+
+func TestZeroGrid(t *testing.T) {
+	var g Grid
+	if g.Columns() != 0 || g.Rows() != 0 || g.Population() != 0 {
+		t.Errorf("zero Grid is %dx%d with %d alive, want empty", g.Columns(), g.Rows(), g.Population())
+	}
+	if _, _, _, _, ok := g.Bounds(); ok {
+		t.Error("zero Grid has bounds")
+	}
+	if !g.Same(Grid{}) || !g.Same(g.Clone()) {
+		t.Error("zero Grid isn't the same as another")
+	}
+}
+
+func benchmarkStep(b *testing.B, size int) {
+	g := NewGrid(size, size)
+	g.Randomize(rand.New(rand.NewSource(1)), 0.3)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Step(Conway, true)
+	}
+}
+
+func BenchmarkStep256(b *testing.B)  { benchmarkStep(b, 256) }
+func BenchmarkStep1000(b *testing.B) { benchmarkStep(b, 1000) }
+
+func BenchmarkNewGrid1000(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewGrid(1000, 1000)
+	}
+}
+
+// oldBriansBrain is Brian's Brain stepped in place, as it was before
+// boards had a buffer for the next generation.
+func (g oldGrid) oldBriansBrain(wrap bool) (births, deaths int) {
+	columns, rows := len(g), len(g[0])
+	next := make([][]int, columns)
+	for x := range g {
+		next[x] = make([]int, rows)
+		for y, c := range g[x] {
+			switch {
+			case c.Alive && c.Age == 1:
+				next[x][y] = 2
+			case c.Alive:
+			default:
+				firing := 0
+				for i := x - 1; i < x+2; i++ {
+					for j := y - 1; j < y+2; j++ {
+						ni, nj := i, j
+						if wrap {
+							ni, nj = (i+columns)%columns, (j+rows)%rows
+						} else if i < 0 || j < 0 || i >= columns || j >= rows {
+							continue
+						}
+						if (i != x || j != y) && g[ni][nj].Alive && g[ni][nj].Age == 1 {
+							firing++
+						}
+					}
+				}
+				if firing == 2 {
+					next[x][y] = 1
+				}
+			}
+		}
+	}
+	for x := range g {
+		for y, c := range g[x] {
+			switch age := next[x][y]; {
+			case age == 1:
+				births++
+				*c = oldCell{Alive: true, aliveNext: true, Age: 1}
+			case age == 0 && c.Alive:
+				deaths++
+				*c = oldCell{}
+			case age == 2:
+				c.Age = 2
+			}
+		}
+	}
+	return births, deaths
+}
+
+func TestBriansBrainStepsAsBefore(t *testing.T) {
+	r, err := ParseRule("brians-brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wrap := range []bool{false, true} {
+		g := NewGrid(31, 29)
+		g.Randomize(rand.New(rand.NewSource(2)), 0.3)
+		old := newOldGrid(g)
+		for gen := 0; gen < 40; gen++ {
+			births, deaths := g.Step(r, wrap)
+			if oldBirths, oldDeaths := old.oldBriansBrain(wrap); births != oldBirths || deaths != oldDeaths {
+				t.Fatalf("wrap %v generation %d: %d births %d deaths, want %d and %d", wrap, gen, births, deaths, oldBirths, oldDeaths)
+			}
+			for x := range old {
+				for y, o := range old[x] {
+					if c := g.At(x, y); c.Alive != o.Alive || c.Age != o.Age {
+						t.Fatalf("wrap %v generation %d: cell %d,%d is %+v, want %+v", wrap, gen, x, y, c, *o)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSnapshotsAreWholeGenerations(t *testing.T) {
+	const generations = 300
+	g := NewGrid(64, 48)
+	g.Randomize(rand.New(rand.NewSource(3)), 0.4)
+	// The soup is an edit, which the readers only see once it's published.
+	g.Publish()
+	// Every generation the board goes through, by hash, with its
+	// population, worked out beforehand on a clone.
+	want := make(map[uint64]int)
+	c := g.Clone()
+	for i := 0; i <= generations; i++ {
+		want[c.Hash()] = c.Population()
+		c.Step(Conway, true)
+	}
+
+	done := make(chan struct{})
+	errs := make(chan error, 4)
+	for r := 0; r < cap(errs); r++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					errs <- nil
+					return
+				default:
+				}
+				s := g.Snapshot()
+				if population, ok := want[s.Hash()]; !ok || population != s.Population() {
+					errs <- fmt.Errorf("snapshot of %d cells isn't any generation's", s.Population())
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < generations; i++ {
+		g.Step(Conway, true)
+	}
+	close(done)
+	for r := 0; r < cap(errs); r++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSnapshotsSeeEditsWhenPublished(t *testing.T) {
+	g := NewGrid(16, 16)
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				errs <- nil
+				return
+			default:
+			}
+			// Each edit fills or empties the first column, and it's only
+			// published once it's done.
+			s := g.Snapshot()
+			if n := s.Population(); n != 0 && n != s.Rows() {
+				errs <- fmt.Errorf("snapshot has %d cells of the column alive", n)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 2000; i++ {
+		for y := 0; y < g.Rows(); y++ {
+			g.Set(0, y, i%2 == 0)
+		}
+		if s := g.Snapshot(); i > 0 && s.Population() != g.Rows()*(i%2) {
+			t.Fatalf("edit %d: snapshot has the edit before it's published", i)
+		}
+		g.Publish()
+	}
+	close(done)
+	if err := <-errs; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnapshotStaysPut(t *testing.T) {
+	g := NewGrid(20, 20)
+	g.Stamp(Pattern{Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}, 10, 10, true)
+	g.Publish()
+	s := g.Snapshot()
+	hash := s.Hash()
+	for i := 0; i < 10; i++ {
+		g.Step(Conway, true)
+		g.Set(i, 0, true)
+	}
+	if s.Hash() != hash || s.Population() != 5 {
+		t.Error("snapshot changed as the board stepped and was edited")
+	}
+	s.Step(Conway, true)
+	if g.Snapshot().Hash() == s.Hash() {
+		t.Error("stepping a snapshot changed the board")
+	}
+}
+
+func TestStepToTakesTheCells(t *testing.T) {
+	sim := NewSimulation(NewGrid(24, 24), Conway, 1, 0.3, 4)
+	next := sim.Cells.Clone()
+	births, deaths := next.Step(Conway, false)
+	want := next.Hash()
+	sim.StepTo(next, births, deaths)
+	if sim.Cells.Hash() != want || !sim.Cells.Same(next) {
+		t.Fatal("StepTo didn't move the board on to next")
+	}
+	next.Set(0, 0, !next.Alive(0, 0))
+	next.Step(Conway, false)
+	if sim.Cells.Hash() != want {
+		t.Error("changing next after StepTo changed the board")
+	}
+	sim.Cells.Set(1, 1, !sim.Cells.Alive(1, 1))
+	if !sim.Rewind() || sim.Generation != 0 {
+		t.Error("couldn't rewind a StepTo")
+	}
+}
+
+func TestStepReusesBuffers(t *testing.T) {
+	g := NewGrid(32, 32)
+	g.Randomize(rand.New(rand.NewSource(4)), 0.3)
+	g.Step(Conway, true)
+	g.Step(Conway, true)
+	if allocs := testing.AllocsPerRun(20, func() { g.Step(Conway, true) }); allocs != 0 {
+		t.Errorf("Step allocates %v times a generation, want none", allocs)
+	}
+}
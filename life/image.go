@@ -0,0 +1,61 @@
+package life
+
+import (
+	"image"
+	"image/color"
+)
+
+// A Palette gives the colour a cell is drawn in, dead or alive.
+type Palette func(c Cell) color.RGBA
+
+// TwoColours is a Palette drawing live cells in alive and dead ones in dead.
+func TwoColours(alive, dead color.RGBA) Palette {
+	return func(c Cell) color.RGBA {
+		if c.Alive {
+			return alive
+		}
+		return dead
+	}
+}
+
+// GridImage is a board seen as an image.Image: each cell a square of Scale
+// pixels coloured by a Palette, the board's top row at the picture's top.
+// It reads the cells as they are each time a pixel is asked for and copies
+// nothing, so for a board that's still stepping, make it of a Clone.
+type GridImage struct {
+	cells   Grid
+	palette Palette
+	scale   int
+}
+
+// Image returns the board as a picture a pixel per cell.
+func (g Grid) Image(palette Palette) *GridImage {
+	return g.ScaledImage(palette, 1)
+}
+
+// ScaledImage returns the board as a picture, each cell a square of scale
+// pixels a side; a scale under 1 is taken as 1.
+func (g Grid) ScaledImage(palette Palette, scale int) *GridImage {
+	return &GridImage{cells: g, palette: palette, scale: max(scale, 1)}
+}
+
+func (m *GridImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (m *GridImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, m.cells.Columns()*m.scale, m.cells.Rows()*m.scale)
+}
+
+func (m *GridImage) At(x, y int) color.Color {
+	return m.RGBAAt(x, y)
+}
+
+// RGBAAt is At without the interface, transparent black outside the board,
+// as for an image.RGBA.
+func (m *GridImage) RGBAAt(x, y int) color.RGBA {
+	if !(image.Point{x, y}.In(m.Bounds())) {
+		return color.RGBA{}
+	}
+	return m.palette(m.cells.At(x/m.scale, m.cells.Rows()-1-y/m.scale))
+}
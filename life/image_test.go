@@ -0,0 +1,89 @@
+package life
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+var (
+	imageAlive = color.RGBA{255, 200, 0, 255}
+	imageDead  = color.RGBA{0, 0, 64, 255}
+)
+
+// TestGridImagePNG encodes a 3 by 2 board, with one live cell in its top
+// row and one in its bottom, to PNG at scales 1 and 2, for its top row to
+// be the picture's and every pixel to be its cell's colour.
+func TestGridImagePNG(t *testing.T) {
+	g := NewGrid(3, 2)
+	g.Set(0, 1, true)
+	g.Set(2, 0, true)
+	for _, scale := range []int{1, 2} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, g.ScaledImage(TwoColours(imageAlive, imageDead), scale)); err != nil {
+			t.Fatal(err)
+		}
+		m, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := image.Rect(0, 0, 3*scale, 2*scale); m.Bounds() != want {
+			t.Fatalf("scale %d: picture bounds %v, want %v", scale, m.Bounds(), want)
+		}
+		rows := []string{"#..", "..#"}
+		for y := 0; y < 2*scale; y++ {
+			for x := 0; x < 3*scale; x++ {
+				want := imageDead
+				if rows[y/scale][x/scale] == '#' {
+					want = imageAlive
+				}
+				if got := color.RGBAModel.Convert(m.At(x, y)); got != want {
+					t.Errorf("scale %d: pixel (%d, %d) is %v, want %v", scale, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestGridImageOutside checks pixels off the picture are transparent black,
+// and a scale under 1 is taken as 1.
+func TestGridImageOutside(t *testing.T) {
+	g := NewGrid(2, 2)
+	g.Set(0, 0, true)
+	g.Set(1, 1, true)
+	m := g.ScaledImage(TwoColours(imageAlive, imageDead), 0)
+	if want := image.Rect(0, 0, 2, 2); m.Bounds() != want {
+		t.Errorf("scale 0 gave bounds %v, want %v", m.Bounds(), want)
+	}
+	for _, p := range []image.Point{{-1, 0}, {0, -1}, {2, 0}, {0, 2}, {5, 5}} {
+		if got := m.RGBAAt(p.X, p.Y); got != (color.RGBA{}) {
+			t.Errorf("pixel %v, off the picture, is %v", p, got)
+		}
+	}
+	if got := m.RGBAAt(1, 0); got != imageAlive {
+		t.Errorf("pixel (1, 0) is %v, want the top right cell's %v", got, imageAlive)
+	}
+}
+
+// TestGridImageSnapshot checks a picture of a snapshot stays as it was
+// while the board goes on stepping.
+func TestGridImageSnapshot(t *testing.T) {
+	g := NewGrid(5, 5)
+	for x := 1; x < 4; x++ {
+		g.Set(x, 2, true)
+	}
+	g.Publish()
+	m := g.Snapshot().Image(TwoColours(imageAlive, imageDead))
+	g.Step(Conway, false)
+	for x := 0; x < 5; x++ {
+		want := imageDead
+		if x >= 1 && x < 4 {
+			want = imageAlive
+		}
+		if got := m.RGBAAt(x, 2); got != want {
+			t.Errorf("after stepping, the snapshot's pixel (%d, 2) is %v, want %v", x, got, want)
+		}
+	}
+}
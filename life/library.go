@@ -0,0 +1,69 @@
+package life
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed patterns/*.rle
+var libraryFiles embed.FS
+
+// LibraryEntry is one of the patterns that come built in, known by the name
+// of its file.
+type LibraryEntry struct {
+	ID          string
+	Description string
+	Pattern     Pattern
+}
+
+// library is the built-in patterns, by id.
+var library = loadLibrary()
+
+func loadLibrary() map[string]LibraryEntry {
+	names, err := libraryFiles.ReadDir("patterns")
+	if err != nil {
+		panic(err)
+	}
+	lib := make(map[string]LibraryEntry, len(names))
+	for _, f := range names {
+		src, err := libraryFiles.ReadFile(path.Join("patterns", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		p, _, err := ParseRLE(string(src))
+		if err != nil {
+			panic(fmt.Sprintf("built-in pattern %s: %v", f.Name(), err))
+		}
+		e := LibraryEntry{ID: strings.TrimSuffix(f.Name(), ".rle"), Pattern: p}
+		for _, line := range strings.Split(string(src), "\n") {
+			if c, ok := strings.CutPrefix(line, "#C "); ok {
+				e.Description = strings.TrimSpace(e.Description + " " + c)
+			}
+		}
+		lib[e.ID] = e
+	}
+	return lib
+}
+
+// LibraryPattern returns the built-in pattern with the given id, which must
+// exist.
+func LibraryPattern(id string) Pattern {
+	e, ok := library[id]
+	if !ok {
+		panic("no built-in pattern " + id)
+	}
+	return e.Pattern
+}
+
+// Library returns the built-in patterns, sorted by id.
+func Library() []LibraryEntry {
+	entries := make([]LibraryEntry, 0, len(library))
+	for _, e := range library {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
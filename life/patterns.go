@@ -0,0 +1,73 @@
+package life
+
+// Pattern is a shape of live cells, given as offsets from its top-left
+// corner with y increasing downwards, the way patterns are usually drawn.
+type Pattern struct {
+	Name  string
+	Cells [][2]int
+}
+
+// Size returns the width and height of the pattern's bounding box.
+func (p Pattern) Size() (int, int) {
+	w, h := 0, 0
+	for _, c := range p.Cells {
+		w, h = max(w, c[0]+1), max(h, c[1]+1)
+	}
+	return w, h
+}
+
+// Transform returns a copy of the pattern with f applied to each cell, given
+// the pattern's width and height, shifted back to start at the origin.
+func (p Pattern) Transform(f func(x, y, w, h int) (int, int)) Pattern {
+	w, h := p.Size()
+	out := Pattern{Name: p.Name, Cells: make([][2]int, len(p.Cells))}
+	minX, minY := 0, 0
+	for i, c := range p.Cells {
+		x, y := f(c[0], c[1], w, h)
+		out.Cells[i] = [2]int{x, y}
+		if i == 0 || x < minX {
+			minX = x
+		}
+		if i == 0 || y < minY {
+			minY = y
+		}
+	}
+	for i := range out.Cells {
+		out.Cells[i][0] -= minX
+		out.Cells[i][1] -= minY
+	}
+	return out
+}
+
+// Rotate90 turns the pattern a quarter turn clockwise.
+func (p Pattern) Rotate90() Pattern {
+	return p.Transform(func(x, y, w, h int) (int, int) { return h - 1 - y, x })
+}
+
+// FlipX mirrors the pattern left to right.
+func (p Pattern) FlipX() Pattern {
+	return p.Transform(func(x, y, w, h int) (int, int) { return w - 1 - x, y })
+}
+
+// FlipY mirrors the pattern top to bottom.
+func (p Pattern) FlipY() Pattern {
+	return p.Transform(func(x, y, w, h int) (int, int) { return x, h - 1 - y })
+}
+
+// Placed returns the board cells the pattern covers when centred on cell
+// (cx, cy) of a columns by rows board. Cells that land off the board wrap
+// around if wrap is set and are dropped otherwise.
+func (p Pattern) Placed(cx, cy, columns, rows int, wrap bool) [][2]int {
+	w, h := p.Size()
+	var cells [][2]int
+	for _, c := range p.Cells {
+		x, y := cx-w/2+c[0], cy+h/2-c[1]
+		if wrap {
+			x, y = (x%columns+columns)%columns, (y%rows+rows)%rows
+		} else if x < 0 || y < 0 || x >= columns || y >= rows {
+			continue
+		}
+		cells = append(cells, [2]int{x, y})
+	}
+	return cells
+}
@@ -0,0 +1,117 @@
+package life
+
+import (
+	"sort"
+	"testing"
+)
+
+var testGlider = Pattern{Name: "glider", Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}
+
+func sortedCells(cells [][2]int) [][2]int {
+	out := append([][2]int(nil), cells...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i][0] != out[j][0] {
+			return out[i][0] < out[j][0]
+		}
+		return out[i][1] < out[j][1]
+	})
+	return out
+}
+
+func sameCells(a, b [][2]int) bool {
+	a, b = sortedCells(a), sortedCells(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlaced(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		cx, cy int
+		wrap   bool
+		want   [][2]int
+	}{
+		// The pattern's rows run down and the board's up.
+		{"centred", 10, 10, false, [][2]int{{10, 11}, {11, 10}, {9, 9}, {10, 9}, {11, 9}}},
+		{"clipped at the bottom left", 0, 0, false, [][2]int{{0, 1}, {1, 0}}},
+		{"clipped at the top right", 19, 14, false, [][2]int{{18, 13}, {19, 13}}},
+		{"wrapped at the bottom left", 0, 0, true, [][2]int{{0, 1}, {1, 0}, {19, 14}, {0, 14}, {1, 14}}},
+		{"wrapped at the top right", 19, 14, true, [][2]int{{19, 0}, {0, 14}, {18, 13}, {19, 13}, {0, 13}}},
+	} {
+		if got := testGlider.Placed(c.cx, c.cy, 20, 15, c.wrap); !sameCells(got, c.want) {
+			t.Errorf("%s: placed on %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	g := NewGrid(20, 15)
+	g.Set(3, 3, true)
+	g.Stamp(testGlider, 0, 0, false)
+	g.Stamp(testGlider, 10, 10, true)
+	if got := g.Population(); got != 1+2+5 {
+		t.Fatalf("population %d after stamping a glider clipped and one whole, want 8", got)
+	}
+	for _, c := range testGlider.Placed(10, 10, 20, 15, false) {
+		if !g.Alive(c[0], c[1]) {
+			t.Fatalf("cell %v of the stamped glider isn't alive", c)
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	if w, h := testGlider.Size(); w != 3 || h != 3 {
+		t.Errorf("glider is %dx%d", w, h)
+	}
+	if w, h := (Pattern{}).Size(); w != 0 || h != 0 {
+		t.Errorf("empty pattern is %dx%d", w, h)
+	}
+}
+
+func TestRotate90(t *testing.T) {
+	for _, p := range []Pattern{testGlider, LibraryPattern("lwss"), LibraryPattern("r-pentomino"), LibraryPattern("gosper-gun")} {
+		turned := p
+		for i := 1; i <= 4; i++ {
+			turned = turned.Rotate90()
+			if w, h := p.Size(); i%2 == 1 {
+				if tw, th := turned.Size(); tw != h || th != w {
+					t.Fatalf("%s turned %d times is %dx%d, want %dx%d", p.Name, i, tw, th, h, w)
+				}
+			}
+		}
+		if !sameCells(turned.Cells, p.Cells) {
+			t.Errorf("%s turned four times is %v, want %v", p.Name, turned.Cells, p.Cells)
+		}
+	}
+
+	// A quarter turn clockwise heads a glider going down and right off
+	// down and left.
+	if got := testGlider.Rotate90().Cells; !sameCells(got, [][2]int{{2, 1}, {1, 2}, {0, 0}, {0, 1}, {0, 2}}) {
+		t.Errorf("glider turned is %v", got)
+	}
+}
+
+func TestFlips(t *testing.T) {
+	for _, p := range []Pattern{testGlider, LibraryPattern("lwss"), LibraryPattern("r-pentomino")} {
+		if !sameCells(p.FlipX().FlipX().Cells, p.Cells) || !sameCells(p.FlipY().FlipY().Cells, p.Cells) {
+			t.Errorf("%s flipped twice isn't itself", p.Name)
+		}
+		// Both flips are a half turn.
+		if !sameCells(p.FlipX().FlipY().Cells, p.Rotate90().Rotate90().Cells) {
+			t.Errorf("%s flipped both ways isn't turned half round", p.Name)
+		}
+	}
+	if got := testGlider.FlipX().Cells; !sameCells(got, [][2]int{{1, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}}) {
+		t.Errorf("glider flipped left to right is %v", got)
+	}
+	if got := testGlider.FlipY().Cells; !sameCells(got, [][2]int{{1, 2}, {2, 1}, {0, 0}, {1, 0}, {2, 0}}) {
+		t.Errorf("glider flipped top to bottom is %v", got)
+	}
+}
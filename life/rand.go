@@ -0,0 +1,81 @@
+package life
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// PCG is a random number generator whose whole state is its two words, so
+// a copy of it carries on exactly as it would, and it can be saved with
+// MarshalBinary and restored with UnmarshalBinary. It's a 128-bit
+// permuted congruential generator with the DXSM output function, as
+// math/rand/v2's PCG is, and gives the same numbers as that from the same
+// state. It's a math/rand.Source64. The zero PCG is ready to use.
+type PCG struct {
+	hi, lo uint64
+}
+
+// NewPCG returns a generator seeded with seed.
+func NewPCG(seed int64) *PCG {
+	p := new(PCG)
+	p.Seed(seed)
+	return p
+}
+
+// Seed starts the generator over from seed.
+func (p *PCG) Seed(seed int64) {
+	p.hi, p.lo = 0, uint64(seed)
+}
+
+// Uint64 returns a uniformly distributed 64-bit number.
+func (p *PCG) Uint64() uint64 {
+	// The state moves on by a 128-bit multiply and add.
+	const (
+		mulHi = 2549297995355413924
+		mulLo = 4865540595714422341
+		incHi = 6364136223846793005
+		incLo = 1442695040888963407
+	)
+	hi, lo := bits.Mul64(p.lo, mulLo)
+	hi += p.hi*mulLo + p.lo*mulHi
+	lo, c := bits.Add64(lo, incLo, 0)
+	hi, _ = bits.Add64(hi, incHi, c)
+	p.hi, p.lo = hi, lo
+
+	const cheapMul = 0xda942042e4dd58b5
+	hi ^= hi >> 32
+	hi *= cheapMul
+	hi ^= hi >> 48
+	hi *= lo | 1
+	return hi
+}
+
+// Int63 returns a uniformly distributed non-negative 63-bit number.
+func (p *PCG) Int63() int64 {
+	return int64(p.Uint64() >> 1)
+}
+
+// Float64 returns a uniformly distributed number in [0, 1).
+func (p *PCG) Float64() float64 {
+	return float64(p.Uint64()<<11>>11) / (1 << 53)
+}
+
+// MarshalBinary returns the generator's state, as math/rand/v2's PCG
+// writes it.
+func (p *PCG) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 20)
+	copy(b, "pcg:")
+	binary.BigEndian.PutUint64(b[4:], p.hi)
+	binary.BigEndian.PutUint64(b[12:], p.lo)
+	return b, nil
+}
+
+// UnmarshalBinary restores the state MarshalBinary returned.
+func (p *PCG) UnmarshalBinary(b []byte) error {
+	if len(b) != 20 || string(b[:4]) != "pcg:" {
+		return errors.New("invalid PCG state")
+	}
+	p.hi, p.lo = binary.BigEndian.Uint64(b[4:]), binary.BigEndian.Uint64(b[12:])
+	return nil
+}
@@ -0,0 +1,54 @@
+package life
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPCGAsMathRandV2(t *testing.T) {
+	// math/rand/v2's NewPCG(0, 42) gives these.
+	p := NewPCG(42)
+	for _, want := range []uint64{0x30f893fbbb6797f8, 0xb631cb0f1f9ec060, 0x52355329a0569b96} {
+		if got := p.Uint64(); got != want {
+			t.Fatalf("Uint64() = %#x, want %#x", got, want)
+		}
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(b), "7063673a7a96117ffe04a050dda7d393613abbd3"; got != want {
+		t.Errorf("MarshalBinary() = %s, want %s", got, want)
+	}
+}
+
+func TestPCGRoundTrip(t *testing.T) {
+	p := NewPCG(7)
+	for i := 0; i < 100; i++ {
+		p.Uint64()
+	}
+	b, _ := p.MarshalBinary()
+	var q PCG
+	if err := q.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if x, y := p.Uint64(), q.Uint64(); x != y {
+			t.Fatalf("draw %d after unmarshalling is %#x, want %#x", i, y, x)
+		}
+	}
+	for _, bad := range [][]byte{nil, b[:19], append([]byte("pcx:"), b[4:]...)} {
+		if err := q.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%x) = nil, want an error", bad)
+		}
+	}
+}
+
+func TestPCGFloat64(t *testing.T) {
+	p := NewPCG(1)
+	for i := 0; i < 10000; i++ {
+		if f := p.Float64(); f < 0 || f >= 1 {
+			t.Fatalf("Float64() = %g, want [0, 1)", f)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a Life-like rule: a dead cell with a neighbour count in birth comes
+// alive, and a live cell with a count in survive stays alive. A rule loaded
+// by ParseRuleScript may go by the cell's age instead, and one naming a
+// registered Automaton steps boards however that does.
+type Rule struct {
+	birth   [9]bool
+	survive [9]bool
+	script  *ruleScript
+	stepper *stepperRule
+}
+
+// Conway is the rule of Conway's Game of Life, B3/S23.
+var Conway = Rule{
+	birth:   [9]bool{3: true},
+	survive: [9]bool{2: true, 3: true},
+}
+
+// ParseRule parses a rulestring in B/S notation, e.g. "B3/S23". The parts may
+// come in either order and letters are case-insensitive. The older S/B
+// notation still found in pattern files, e.g. "23/3", is accepted too, as
+// is the name of a rule loaded by ParseRuleScript, or of a registered
+// Automaton, alone or as name:config.
+func ParseRule(s string) (Rule, error) {
+	if r, ok := scriptRule(strings.TrimSpace(s)); ok {
+		return r, nil
+	}
+	if r, ok, err := automatonRule(strings.TrimSpace(s)); ok {
+		return r, err
+	}
+	var r Rule
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid rule %q: want the form B3/S23", s)
+	}
+	if strings.Trim(parts[0], "012345678") == "" && strings.Trim(parts[1], "012345678") == "" {
+		parts[0], parts[1] = "S"+parts[0], "B"+parts[1]
+	}
+	seen := map[byte]bool{}
+	for _, part := range parts {
+		if part == "" {
+			return r, fmt.Errorf("invalid rule %q: empty part", s)
+		}
+		var counts *[9]bool
+		kind := part[0] | 0x20
+		switch kind {
+		case 'b':
+			counts = &r.birth
+		case 's':
+			counts = &r.survive
+		default:
+			return r, fmt.Errorf("invalid rule %q: part %q must start with B or S", s, part)
+		}
+		if seen[kind] {
+			return r, fmt.Errorf("invalid rule %q: %c given twice", s, part[0])
+		}
+		seen[kind] = true
+		for _, d := range part[1:] {
+			if d < '0' || d > '8' {
+				return r, fmt.Errorf("invalid rule %q: %q is not a neighbour count", s, d)
+			}
+			counts[d-'0'] = true
+		}
+	}
+	return r, nil
+}
+
+// NewRule returns the Life-like rule with the given birth and survival
+// neighbour counts.
+func NewRule(birth, survive [9]bool) Rule {
+	return Rule{birth: birth, survive: survive}
+}
+
+// Counts returns the neighbour counts a Life-like rule has cells born and
+// survive on. ok is false for a rule loaded by ParseRuleScript or naming an
+// Automaton, which don't have them.
+func (r Rule) Counts() (birth, survive [9]bool, ok bool) {
+	return r.birth, r.survive, r.script == nil && r.stepper == nil
+}
+
+func (r Rule) String() string {
+	if r.script != nil {
+		return r.script.name
+	}
+	if r.stepper != nil {
+		return r.stepper.name
+	}
+	var b strings.Builder
+	b.WriteByte('B')
+	for n, ok := range r.birth {
+		if ok {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	b.WriteString("/S")
+	for n, ok := range r.survive {
+		if ok {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	return b.String()
+}
+
+// Next reports whether a cell is alive in the next generation. A live cell
+// is taken to be a generation old by a rule that goes by age.
+func (r Rule) Next(alive bool, neighbors int) bool {
+	return r.NextAged(alive, neighbors, 1)
+}
+
+// NextAged reports whether a cell of the given age is alive in the next
+// generation. It's only for Life-like rules: an Automaton's, which
+// needn't go by neighbour counts, has every cell die.
+func (r Rule) NextAged(alive bool, neighbors, age int) bool {
+	if r.script != nil {
+		a := 0
+		if alive {
+			a = 1
+		}
+		return r.script.next[a][neighbors][min(max(age, 0), MaxScriptAge)]
+	}
+	if alive {
+		return r.survive[neighbors]
+	}
+	return r.birth[neighbors]
+}
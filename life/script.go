@@ -0,0 +1,506 @@
+package life
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MaxScriptAge is the oldest age a rule script tells apart: older cells
+// are treated as this old.
+const MaxScriptAge = 255
+
+// ruleScript is a scripted rule that depends on age, tabulated for every
+// state a cell can be in.
+type ruleScript struct {
+	name string
+	// next[alive][neighbors][age] is whether a cell is alive next.
+	next [2][9][MaxScriptAge + 1]bool
+}
+
+var (
+	scriptsMu sync.Mutex
+	// scripts are the rules loaded from scripts that depend on age, by name,
+	// so that ParseRule knows them.
+	scripts = make(map[string]Rule)
+)
+
+// ParseRuleScript compiles a rule written as an expression in src, named
+// name for error messages and Rule.String.
+//
+// The expression decides whether a cell is alive in the next generation
+// from alive, whether it's alive now, neighbors (or neighbours), its live
+// neighbour count, and age, the generations it's been alive for. It has
+// integers, true and false, the operators of Go's expressions other than
+// the bitwise ones, c ? a : b, and x in (a, b, ...). # starts a comment.
+// Conway's Life is
+//
+//	alive ? neighbors in (2, 3) : neighbors == 3
+//
+// Scripts can't reach anything else, and any error, including dividing by
+// zero, is found here, since the result for every cell state is worked out
+// up front. A script that doesn't use age is just a B/S rule, and comes
+// back as one. One that does is known to ParseRule by name from then on.
+func ParseRuleScript(name, src string) (Rule, error) {
+	p := &scriptParser{name: name}
+	if err := p.lex(src); err != nil {
+		return Rule{}, err
+	}
+	e, err := p.parse()
+	if err != nil {
+		return Rule{}, err
+	}
+	if e.kind != scriptBool {
+		return Rule{}, fmt.Errorf("%s: the rule must be true or false, not a number", name)
+	}
+	ages := 1
+	if p.usesAge {
+		ages = MaxScriptAge + 1
+	}
+	s := &ruleScript{name: name}
+	for alive := 0; alive < 2; alive++ {
+		for n := 0; n < 9; n++ {
+			for age := 0; age < ages; age++ {
+				// Dead cells have no age, and live ones are at least 1.
+				env := scriptEnv{alive: alive, neighbors: n, age: age}
+				if alive == 0 {
+					env.age = 0
+				} else if p.usesAge {
+					env.age = max(age, 1)
+				}
+				v, err := e.eval(&env)
+				if err != nil {
+					return Rule{}, fmt.Errorf("%s: with alive %t, neighbors %d and age %d: %w", name, alive == 1, n, env.age, err)
+				}
+				s.next[alive][n][age] = v != 0
+			}
+		}
+	}
+	if !p.usesAge {
+		var r Rule
+		for n := 0; n < 9; n++ {
+			r.birth[n], r.survive[n] = s.next[0][n][0], s.next[1][n][0]
+		}
+		return r, nil
+	}
+	for n := 0; n < 9; n++ {
+		s.next[0][n] = [MaxScriptAge + 1]bool{}
+		for age := range s.next[0][n] {
+			s.next[0][n][age] = s.next[0][n][0]
+		}
+	}
+	r := Rule{script: s}
+	scriptsMu.Lock()
+	scripts[name] = r
+	scriptsMu.Unlock()
+	return r, nil
+}
+
+// scriptRule returns the rule loaded from the script named name, if one
+// has been.
+func scriptRule(name string) (Rule, bool) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+	r, ok := scripts[name]
+	return r, ok
+}
+
+type scriptKind int
+
+const (
+	scriptInt scriptKind = iota
+	scriptBool
+)
+
+func (k scriptKind) String() string {
+	if k == scriptBool {
+		return "true or false"
+	}
+	return "a number"
+}
+
+type scriptEnv struct {
+	alive, neighbors, age int
+}
+
+// scriptExpr is a compiled expression, with booleans as 0 and 1.
+type scriptExpr struct {
+	kind scriptKind
+	eval func(env *scriptEnv) (int, error)
+}
+
+type scriptToken struct {
+	text string
+	pos  int
+}
+
+type scriptParser struct {
+	name    string
+	src     string
+	tokens  []scriptToken
+	at      int
+	usesAge bool
+}
+
+var scriptOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")", ","}
+
+func (p *scriptParser) lex(src string) error {
+	p.src = src
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			p.tokens = append(p.tokens, scriptToken{src[i:j], i})
+			i = j
+			continue
+		}
+		found := false
+		for _, op := range scriptOperators {
+			if strings.HasPrefix(src[i:], op) {
+				p.tokens = append(p.tokens, scriptToken{op, i})
+				i += len(op)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return p.errorAt(i, "unexpected %q", c)
+		}
+	}
+	return nil
+}
+
+func (p *scriptParser) errorAt(pos int, format string, args ...any) error {
+	line := 1 + strings.Count(p.src[:pos], "\n")
+	return fmt.Errorf("%s:%d: %s", p.name, line, fmt.Sprintf(format, args...))
+}
+
+func (p *scriptParser) peek() string {
+	if p.at < len(p.tokens) {
+		return p.tokens[p.at].text
+	}
+	return ""
+}
+
+func (p *scriptParser) pos() int {
+	if p.at < len(p.tokens) {
+		return p.tokens[p.at].pos
+	}
+	return len(p.src)
+}
+
+func (p *scriptParser) expect(text string) error {
+	if p.peek() != text {
+		if p.peek() == "" {
+			return p.errorAt(p.pos(), "want %q, not the end", text)
+		}
+		return p.errorAt(p.pos(), "want %q, not %q", text, p.peek())
+	}
+	p.at++
+	return nil
+}
+
+func (p *scriptParser) parse() (scriptExpr, error) {
+	if len(p.tokens) == 0 {
+		return scriptExpr{}, fmt.Errorf("%s: empty rule", p.name)
+	}
+	e, err := p.ternary()
+	if err != nil {
+		return e, err
+	}
+	if p.peek() != "" {
+		return e, p.errorAt(p.pos(), "unexpected %q", p.peek())
+	}
+	return e, nil
+}
+
+// want checks that e, at pos, is of kind.
+func (p *scriptParser) want(e scriptExpr, kind scriptKind, pos int) error {
+	if e.kind != kind {
+		return p.errorAt(pos, "want %v, not %v", kind, e.kind)
+	}
+	return nil
+}
+
+func (p *scriptParser) ternary() (scriptExpr, error) {
+	pos := p.pos()
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	if err := p.want(cond, scriptBool, pos); err != nil {
+		return cond, err
+	}
+	p.at++
+	yes, err := p.ternary()
+	if err != nil {
+		return yes, err
+	}
+	if err := p.expect(":"); err != nil {
+		return yes, err
+	}
+	pos = p.pos()
+	no, err := p.ternary()
+	if err != nil {
+		return no, err
+	}
+	if err := p.want(no, yes.kind, pos); err != nil {
+		return no, err
+	}
+	return scriptExpr{yes.kind, func(env *scriptEnv) (int, error) {
+		c, err := cond.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		if c != 0 {
+			return yes.eval(env)
+		}
+		return no.eval(env)
+	}}, nil
+}
+
+// scriptLevels are the binary operators by precedence, loosest first.
+var scriptLevels = [][]string{{"||"}, {"&&"}, {"==", "!=", "<", "<=", ">", ">=", "in"}, {"+", "-"}, {"*", "/", "%"}}
+
+func (p *scriptParser) binary(level int) (scriptExpr, error) {
+	if level == len(scriptLevels) {
+		return p.unary()
+	}
+	pos := p.pos()
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return left, err
+	}
+	for {
+		op := p.peek()
+		if !contains(scriptLevels[level], op) {
+			return left, nil
+		}
+		p.at++
+		if op == "in" {
+			if left, err = p.in(left, pos); err != nil {
+				return left, err
+			}
+			continue
+		}
+		rpos := p.pos()
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return right, err
+		}
+		if left, err = p.combine(op, left, right, pos, rpos); err != nil {
+			return left, err
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *scriptParser) combine(op string, left, right scriptExpr, pos, rpos int) (scriptExpr, error) {
+	switch op {
+	case "||", "&&":
+		if err := p.want(left, scriptBool, pos); err != nil {
+			return left, err
+		}
+		if err := p.want(right, scriptBool, rpos); err != nil {
+			return right, err
+		}
+		short := 0
+		if op == "||" {
+			short = 1
+		}
+		return scriptExpr{scriptBool, func(env *scriptEnv) (int, error) {
+			l, err := left.eval(env)
+			if err != nil || l == short {
+				return l, err
+			}
+			return right.eval(env)
+		}}, nil
+	case "==", "!=":
+		if err := p.want(right, left.kind, rpos); err != nil {
+			return right, err
+		}
+	default:
+		if err := p.want(left, scriptInt, pos); err != nil {
+			return left, err
+		}
+		if err := p.want(right, scriptInt, rpos); err != nil {
+			return right, err
+		}
+	}
+	kind := scriptBool
+	if op == "+" || op == "-" || op == "*" || op == "/" || op == "%" {
+		kind = scriptInt
+	}
+	return scriptExpr{kind, func(env *scriptEnv) (int, error) {
+		l, err := left.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		r, err := right.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		return scriptOp(op, l, r)
+	}}, nil
+}
+
+func scriptOp(op string, l, r int) (int, error) {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "==":
+		return b(l == r), nil
+	case "!=":
+		return b(l != r), nil
+	case "<":
+		return b(l < r), nil
+	case "<=":
+		return b(l <= r), nil
+	case ">":
+		return b(l > r), nil
+	case ">=":
+		return b(l >= r), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return 0, errors.New("division by zero")
+	}
+	if op == "/" {
+		return l / r, nil
+	}
+	return l % r, nil
+}
+
+// in parses the list after x in.
+func (p *scriptParser) in(x scriptExpr, pos int) (scriptExpr, error) {
+	if err := p.want(x, scriptInt, pos); err != nil {
+		return x, err
+	}
+	if err := p.expect("("); err != nil {
+		return x, err
+	}
+	var list []scriptExpr
+	for {
+		epos := p.pos()
+		e, err := p.binary(3)
+		if err != nil {
+			return e, err
+		}
+		if err := p.want(e, scriptInt, epos); err != nil {
+			return e, err
+		}
+		list = append(list, e)
+		if p.peek() != "," {
+			break
+		}
+		p.at++
+	}
+	if err := p.expect(")"); err != nil {
+		return x, err
+	}
+	return scriptExpr{scriptBool, func(env *scriptEnv) (int, error) {
+		v, err := x.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range list {
+			w, err := e.eval(env)
+			if err != nil || v == w {
+				return 1, err
+			}
+		}
+		return 0, nil
+	}}, nil
+}
+
+func (p *scriptParser) unary() (scriptExpr, error) {
+	pos := p.pos()
+	switch p.peek() {
+	case "!", "-":
+		op := p.peek()
+		p.at++
+		e, err := p.unary()
+		if err != nil {
+			return e, err
+		}
+		if op == "!" {
+			if err := p.want(e, scriptBool, pos); err != nil {
+				return e, err
+			}
+			return scriptExpr{scriptBool, func(env *scriptEnv) (int, error) {
+				v, err := e.eval(env)
+				return 1 - v, err
+			}}, nil
+		}
+		if err := p.want(e, scriptInt, pos); err != nil {
+			return e, err
+		}
+		return scriptExpr{scriptInt, func(env *scriptEnv) (int, error) {
+			v, err := e.eval(env)
+			return -v, err
+		}}, nil
+	case "(":
+		p.at++
+		e, err := p.ternary()
+		if err != nil {
+			return e, err
+		}
+		return e, p.expect(")")
+	case "":
+		return scriptExpr{}, p.errorAt(pos, "unexpected end")
+	}
+	t := p.peek()
+	p.at++
+	switch t {
+	case "true", "false":
+		v := 0
+		if t == "true" {
+			v = 1
+		}
+		return scriptExpr{scriptBool, func(*scriptEnv) (int, error) { return v, nil }}, nil
+	case "alive":
+		return scriptExpr{scriptBool, func(env *scriptEnv) (int, error) { return env.alive, nil }}, nil
+	case "neighbors", "neighbours":
+		return scriptExpr{scriptInt, func(env *scriptEnv) (int, error) { return env.neighbors, nil }}, nil
+	case "age":
+		p.usesAge = true
+		return scriptExpr{scriptInt, func(env *scriptEnv) (int, error) { return env.age, nil }}, nil
+	}
+	n, err := strconv.Atoi(t)
+	if err != nil {
+		return scriptExpr{}, p.errorAt(pos, "unknown name %q: want alive, neighbors or age", t)
+	}
+	return scriptExpr{scriptInt, func(*scriptEnv) (int, error) { return n, nil }}, nil
+}
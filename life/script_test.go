@@ -0,0 +1,80 @@
+package life
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func readRuleScript(t *testing.T, name string) Rule {
+	t.Helper()
+	src, err := os.ReadFile("../examples/rules/" + name + ".rule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseRuleScript(name, string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// TestRuleScriptExamples runs the example scripts of Life and HighLife
+// beside the native rules, for the same boards.
+func TestRuleScriptExamples(t *testing.T) {
+	for _, c := range []struct{ script, rule string }{{"conway", "B3/S23"}, {"highlife", "B36/S23"}} {
+		native, err := ParseRule(c.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scripted := readRuleScript(t, c.script)
+		if scripted.String() != native.String() {
+			t.Errorf("%s.rule is %s, want %s", c.script, scripted, native)
+		}
+		a := NewSimulation(NewGrid(48, 32), native, 3, 0.4, 0)
+		b := NewSimulation(NewGrid(48, 32), scripted, 3, 0.4, 0)
+		for i := 0; i < 200; i++ {
+			a.Step(true)
+			b.Step(true)
+			if a.Cells.Hash() != b.Cells.Hash() {
+				t.Fatalf("%s.rule and %s differ at generation %d", c.script, c.rule, a.Generation)
+			}
+		}
+	}
+}
+
+// TestRuleScriptAge runs mortal.rule on a block, which lives until its
+// cells are 50 generations old.
+func TestRuleScriptAge(t *testing.T) {
+	r := readRuleScript(t, "mortal")
+	if named, err := ParseRule("mortal"); err != nil || named.String() != "mortal" {
+		t.Errorf("ParseRule doesn't know the script by name: %v, %v", named, err)
+	}
+	sim := NewSimulation(NewGrid(8, 8), r, 1, 0, 0)
+	for _, c := range [][2]int{{3, 3}, {4, 3}, {3, 4}, {4, 4}} {
+		sim.Cells.Set(c[0], c[1], true)
+	}
+	oldest := 0
+	for sim.Cells.Population() == 4 && sim.Generation < 100 {
+		oldest = sim.Cells.At(3, 3).Age
+		sim.Step(false)
+	}
+	if oldest != 50 || sim.Cells.Population() != 0 {
+		t.Errorf("the block lived to age %d and died out to %d cells, want 50 and none", oldest, sim.Cells.Population())
+	}
+}
+
+func TestRuleScriptRejects(t *testing.T) {
+	for _, c := range []struct{ src, want string }{
+		{"neighbors + 1", "the rule must be true or false"},
+		{"alive ? neighbors / (neighbors - 3) > 0 : false", "with alive true, neighbors 3 and age 0: "},
+		{"exec(1)", "exec"},
+		{`open("/etc/passwd")`, "f.rule:1"},
+		{"alive &&", "f.rule"},
+		{"", "f.rule"},
+	} {
+		if _, err := ParseRuleScript("f.rule", c.src); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: %v, want an error containing %q", c.src, err, c.want)
+		}
+	}
+}
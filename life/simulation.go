@@ -0,0 +1,153 @@
+package life
+
+import (
+	"math/rand"
+)
+
+// Simulation is one independently running board.
+type Simulation struct {
+	Cells      Grid
+	Rule       Rule
+	Seed       int64
+	Generation int
+	// Density is the fraction of cells alive in a fresh random board.
+	Density float64
+	// Births and deaths count the cells that came to life and died in the
+	// last step.
+	Births, Deaths int
+	// RNG is where every random decision about the board comes from: the
+	// soups Reseed makes and the chances the rule takes. Reseed seeds it
+	// with Seed, and from there it moves on as the board does, so saved
+	// with the board it lets a run carry on exactly as it would have.
+	RNG PCG
+
+	// past holds the boards before the most recent steps, oldest first, so
+	// they can be rewound.
+	past        []snapshot
+	start, kept int
+}
+
+// snapshot is a board as it was at some generation.
+type snapshot struct {
+	generation     int
+	births, deaths int
+	rng            PCG
+	alive, team    []uint8
+	age            []uint16
+}
+
+// NewSimulation returns a simulation of cells, randomized from seed to the
+// given density, that can be rewound rewindLength generations.
+func NewSimulation(cells Grid, r Rule, seed int64, density float64, rewindLength int) *Simulation {
+	s := &Simulation{Cells: cells, Rule: r, Seed: seed, Density: density, past: make([]snapshot, rewindLength)}
+	s.RNG.Seed(seed)
+	cells.Randomize(rand.New(&s.RNG), density)
+	return s
+}
+
+// Step moves the board on a generation, keeping the last one to rewind to.
+// If wrap is set, the board's edges wrap around into a torus.
+func (s *Simulation) Step(wrap bool) {
+	s.record()
+	s.Births, s.Deaths = s.Cells.StepRandom(s.Rule, wrap, &s.RNG)
+	s.Generation++
+}
+
+// StepTo moves the board on to next, a copy of it stepped a generation
+// elsewhere with births and deaths, as Step would have. A rule that takes
+// chances should have been stepped with a copy of RNG, which is then to be
+// set to where that left off. next's cells become the board's, without
+// being copied; what's done to either board after doesn't change the other.
+func (s *Simulation) StepTo(next Grid, births, deaths int) {
+	s.record()
+	s.Cells.adopt(next)
+	s.Births, s.Deaths = births, deaths
+	s.Generation++
+}
+
+// record saves the board to the rewind buffer, overwriting the oldest save
+// once it's full.
+func (s *Simulation) record() {
+	if len(s.past) == 0 {
+		return
+	}
+	i := (s.start + s.kept) % len(s.past)
+	if s.kept < len(s.past) {
+		s.kept++
+	} else {
+		s.start = (s.start + 1) % len(s.past)
+	}
+
+	snap := &s.past[i]
+	snap.generation = s.Generation
+	snap.births, snap.deaths = s.Births, s.Deaths
+	snap.rng = s.RNG
+	if s.Cells.b != nil {
+		buf := s.Cells.b.view()
+		snap.alive = append(snap.alive[:0], buf.alive...)
+		snap.age = append(snap.age[:0], buf.age...)
+		snap.team = append(snap.team[:0], buf.team...)
+	}
+}
+
+// Rewind restores the board to the way it was before the last step. It
+// returns false, leaving the board alone, once there is nothing left to
+// rewind to.
+func (s *Simulation) Rewind() bool {
+	if s.kept == 0 {
+		return false
+	}
+	s.kept--
+	snap := &s.past[(s.start+s.kept)%len(s.past)]
+	if s.Cells.b != nil {
+		buf := s.Cells.b.rewrite()
+		copy(buf.alive, snap.alive)
+		copy(buf.age, snap.age)
+		copy(buf.team, snap.team)
+	}
+	s.Generation = snap.generation
+	s.Births, s.Deaths = snap.births, snap.deaths
+	s.RNG = snap.rng
+	return true
+}
+
+// CanRewind reports whether there is a saved board to rewind to.
+func (s *Simulation) CanRewind() bool {
+	return s.kept > 0
+}
+
+// SetRewind empties the rewind buffer and resizes it to hold length
+// generations.
+func (s *Simulation) SetRewind(length int) {
+	s.past = make([]snapshot, length)
+	s.Forget()
+}
+
+// Forget empties the rewind buffer.
+func (s *Simulation) Forget() {
+	s.start, s.kept = 0, 0
+}
+
+// Reseed replaces the board with a fresh random soup from seed, reusing the
+// existing cells.
+func (s *Simulation) Reseed(seed int64) {
+	s.RNG.Seed(seed)
+	s.Cells.Randomize(rand.New(&s.RNG), s.Density)
+	s.Seed = seed
+	s.Generation = 0
+	s.Births, s.Deaths = 0, 0
+	s.Forget()
+}
+
+// Clear kills every cell.
+func (s *Simulation) Clear() {
+	if s.Cells.b != nil {
+		buf := s.Cells.b.rewrite()
+		clear(buf.alive)
+		clear(buf.age)
+		clear(buf.team)
+	}
+	s.Generation = 0
+	s.Births, s.Deaths = 0, 0
+	s.Forget()
+}
@@ -0,0 +1,135 @@
+package life
+
+import "testing"
+
+// noisyLife is Conway's Life with every cell flipped a time in 200, a rule
+// that takes chances, for checking runs carry on the same after a save.
+type noisyLife struct{}
+
+func (n noisyLife) Step(g, next Grid, wrap bool) (births, deaths int) {
+	return n.StepRandom(g, next, wrap, new(PCG))
+}
+
+func (noisyLife) StepRandom(g, next Grid, wrap bool, rng *PCG) (births, deaths int) {
+	births, deaths = Conway.Step(g, next, wrap)
+	for y := 0; y < next.Rows(); y++ {
+		for x := 0; x < next.Columns(); x++ {
+			if rng.Float64() < 1.0/200 {
+				next.Set(x, y, !next.Alive(x, y))
+			}
+		}
+	}
+	return births, deaths
+}
+
+func init() {
+	Register(Automaton{
+		Name:        "noisy-life",
+		Description: "Conway's Life with a cell in 200 flipped each generation",
+		NewStepper:  func(string) (Stepper, error) { return noisyLife{}, nil },
+		States:      2,
+	})
+}
+
+func noisyRule(t testing.TB) Rule {
+	t.Helper()
+	r, err := ParseRule("noisy-life")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSimulationCarriesOnAfterSave(t *testing.T) {
+	r := noisyRule(t)
+	whole := NewSimulation(NewGrid(48, 32), r, 3, 0.3, 0)
+	for i := 0; i < 1000; i++ {
+		whole.Step(true)
+	}
+
+	half := NewSimulation(NewGrid(48, 32), r, 3, 0.3, 0)
+	for i := 0; i < 500; i++ {
+		half.Step(true)
+	}
+	state, err := half.RNG.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewSimulation(NewGrid(48, 32), r, 99, 0.3, 0)
+	for x := 0; x < 48; x++ {
+		for y := 0; y < 32; y++ {
+			loaded.Cells.SetCell(x, y, half.Cells.At(x, y))
+		}
+	}
+	if err := loaded.RNG.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 500; i++ {
+		loaded.Step(true)
+	}
+	if got, want := loaded.Cells.Hash(), whole.Cells.Hash(); got != want {
+		t.Errorf("500 generations, saved, loaded and 500 more hash to %#x; 1000 straight hash to %#x", got, want)
+	}
+}
+
+func TestSimulationRewindsRNG(t *testing.T) {
+	s := NewSimulation(NewGrid(16, 16), noisyRule(t), 5, 0.3, 4)
+	s.Step(false)
+	rng := s.RNG
+	s.Step(false)
+	hash := s.Cells.Hash()
+	if !s.Rewind() {
+		t.Fatal("Rewind() = false after a step")
+	}
+	if s.RNG != rng {
+		t.Error("Rewind didn't put the generator back")
+	}
+	s.Step(false)
+	if s.Cells.Hash() != hash {
+		t.Error("stepping again after Rewind took different chances")
+	}
+}
+
+// TestBirthsAndDeaths steps a blinker, for two cells to be born and two to
+// die every generation, and a block, for none to, with the counts going
+// back with the board on rewinding and starting again on reseeding.
+func TestBirthsAndDeaths(t *testing.T) {
+	block, _, err := ParseRLE("x = 2, y = 2\n2o$2o!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		pattern        string
+		p              Pattern
+		births, deaths int
+	}{{"blinker", LibraryPattern("blinker"), 2, 2}, {"block", block, 0, 0}} {
+		for _, wrap := range []bool{false, true} {
+			sim := NewSimulation(NewGrid(12, 12), Conway, 1, 0, 4)
+			for _, cell := range c.p.Placed(6, 6, 12, 12, false) {
+				sim.Cells.Set(cell[0], cell[1], true)
+			}
+			for gen := 1; gen <= 10; gen++ {
+				sim.Step(wrap)
+				if sim.Births != c.births || sim.Deaths != c.deaths {
+					t.Errorf("%s, wrap %v, generation %d: +%d -%d, want +%d -%d", c.pattern, wrap, gen, sim.Births, sim.Deaths, c.births, c.deaths)
+				}
+			}
+			sim.Rewind()
+			if sim.Generation != 9 || sim.Births != c.births || sim.Deaths != c.deaths {
+				t.Errorf("%s, rewound to generation %d: +%d -%d, want generation 9's +%d -%d", c.pattern, sim.Generation, sim.Births, sim.Deaths, c.births, c.deaths)
+			}
+		}
+	}
+
+	sim := NewSimulation(NewGrid(12, 12), Conway, 1, 0, 4)
+	sim.Rewind()
+	if sim.Births != 0 || sim.Deaths != 0 {
+		t.Errorf("before any generation, +%d -%d", sim.Births, sim.Deaths)
+	}
+	sim.Density = 0.4
+	sim.Step(false)
+	sim.Reseed(2)
+	if sim.Births != 0 || sim.Deaths != 0 {
+		t.Errorf("reseeded, +%d -%d", sim.Births, sim.Deaths)
+	}
+}
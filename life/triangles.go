@@ -0,0 +1,189 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tiling is the shape of a board's cells and how they fit together.
+type Tiling int
+
+const (
+	// Squares are cells in rows and columns, each with eight neighbours.
+	Squares Tiling = iota
+	// Triangles are cells in rows of triangles pointing up and down in
+	// turn, cell (x, y) pointing up if x+y is even, so each row's are
+	// half a triangle along from the row's below. A triangle shares an
+	// edge with three others and only a corner with nine more.
+	Triangles
+)
+
+func (t Tiling) String() string {
+	if t == Triangles {
+		return "triangles"
+	}
+	return "squares"
+}
+
+// Tiling returns the tiling the rule runs on: its automaton's, or squares
+// for a Life-like rule.
+func (r Rule) Tiling() Tiling {
+	a, ok := r.Automaton()
+	if !ok {
+		return Squares
+	}
+	return a.Tiling
+}
+
+// TriangleUp reports whether cell (x, y) of a board of triangles points
+// up, with its base along the bottom.
+func TriangleUp(x, y int) bool {
+	return (x+y)&1 == 0
+}
+
+var (
+	triangleEdges = [2][3][2]int{
+		// Pointing up, the one below shares its base.
+		{{-1, 0}, {1, 0}, {0, -1}},
+		{{-1, 0}, {1, 0}, {0, 1}},
+	}
+	triangleCorners = [2][9][2]int{
+		// Pointing up, three above share its top corner and four below,
+		// besides the one sharing its base, its bottom ones.
+		{{-2, 0}, {2, 0}, {-1, 1}, {0, 1}, {1, 1}, {-2, -1}, {-1, -1}, {1, -1}, {2, -1}},
+		{{-2, 0}, {2, 0}, {-1, -1}, {0, -1}, {1, -1}, {-2, 1}, {-1, 1}, {1, 1}, {2, 1}},
+	}
+)
+
+// TriangleNeighbours returns the offsets from a triangle to its
+// neighbours, which depend on whether it points up: the three it shares an
+// edge with, then, if corners is set, the nine it shares only a corner
+// with.
+func TriangleNeighbours(up, corners bool) [][2]int {
+	i := 0
+	if !up {
+		i = 1
+	}
+	offsets := append([][2]int(nil), triangleEdges[i][:]...)
+	if corners {
+		offsets = append(offsets, triangleCorners[i][:]...)
+	}
+	return offsets
+}
+
+// triangleRule is a Life-like rule for a board of triangles, counting the
+// neighbours sharing an edge, or a corner too if corners is set.
+type triangleRule struct {
+	birth, survive [13]bool
+	corners        bool
+}
+
+// parseTriangleRule parses a rule in B/S notation for a board of
+// triangles, with counts of 10 to 12 written a to c.
+func parseTriangleRule(s string, corners bool) (triangleRule, error) {
+	r := triangleRule{corners: corners}
+	most := 3
+	if corners {
+		most = 12
+	}
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid rule %q: want the form B4/S345", s)
+	}
+	seen := map[byte]bool{}
+	for _, part := range parts {
+		if part == "" {
+			return r, fmt.Errorf("invalid rule %q: empty part", s)
+		}
+		var counts *[13]bool
+		kind := part[0] | 0x20
+		switch kind {
+		case 'b':
+			counts = &r.birth
+		case 's':
+			counts = &r.survive
+		default:
+			return r, fmt.Errorf("invalid rule %q: part %q must start with B or S", s, part)
+		}
+		if seen[kind] {
+			return r, fmt.Errorf("invalid rule %q: %c given twice", s, part[0])
+		}
+		seen[kind] = true
+		for _, d := range strings.ToLower(part[1:]) {
+			n := -1
+			switch {
+			case d >= '0' && d <= '9':
+				n = int(d - '0')
+			case d >= 'a' && d <= 'c':
+				n = int(d-'a') + 10
+			}
+			if n < 0 || n > most {
+				return r, fmt.Errorf("invalid rule %q: %q is not a neighbour count; a triangle has %d neighbours", s, d, most)
+			}
+			counts[n] = true
+		}
+	}
+	return r, nil
+}
+
+func (r triangleRule) Step(g, next Grid, wrap bool) (births, deaths int) {
+	columns, rows := g.Columns(), g.Rows()
+	neighbours := [2][][2]int{TriangleNeighbours(true, r.corners), TriangleNeighbours(false, r.corners)}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			offsets := neighbours[0]
+			if !TriangleUp(x, y) {
+				offsets = neighbours[1]
+			}
+			n := 0
+			for _, d := range offsets {
+				nx, ny := x+d[0], y+d[1]
+				if wrap {
+					nx, ny = (nx+columns)%columns, (ny+rows)%rows
+				} else if nx < 0 || ny < 0 || nx >= columns || ny >= rows {
+					continue
+				}
+				if g.Alive(nx, ny) {
+					n++
+				}
+			}
+			switch c := g.At(x, y); {
+			case c.Alive && r.survive[n]:
+				c.Age++
+				next.SetCell(x, y, c)
+			case c.Alive:
+				deaths++
+				next.Set(x, y, false)
+			case r.birth[n]:
+				births++
+				next.Set(x, y, true)
+			default:
+				next.Set(x, y, false)
+			}
+		}
+	}
+	return births, deaths
+}
+
+func init() {
+	Register(Automaton{
+		Name:        "triangles",
+		Description: "Life-like rules on a board of triangles, counting the 12 neighbours sharing an edge or a corner, configured as triangles:B4/S345",
+		Default:     "B4/S345",
+		NewStepper: func(config string) (Stepper, error) {
+			return parseTriangleRule(config, true)
+		},
+		States: 2,
+		Tiling: Triangles,
+	})
+	Register(Automaton{
+		Name:        "triangles-edges",
+		Description: "Life-like rules on a board of triangles, counting only the 3 neighbours sharing an edge, configured as triangles-edges:B1/S12",
+		Default:     "B1/S12",
+		NewStepper: func(config string) (Stepper, error) {
+			return parseTriangleRule(config, false)
+		},
+		States: 2,
+		Tiling: Triangles,
+	})
+}
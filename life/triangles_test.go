@@ -0,0 +1,42 @@
+package life
+
+import "testing"
+
+// TestTriangleNeighbours checks each triangle has three neighbours sharing
+// an edge and nine more sharing a corner, all different, and that every
+// triangle is its neighbours' neighbour in the same way, whichever way
+// each points.
+func TestTriangleNeighbours(t *testing.T) {
+	for _, up := range []bool{true, false} {
+		edges, all := TriangleNeighbours(up, false), TriangleNeighbours(up, true)
+		if len(edges) != 3 || len(all) != 12 {
+			t.Fatalf("pointing up %v: %d edge neighbours and %d in all, want 3 and 12", up, len(edges), len(all))
+		}
+		seen := map[[2]int]bool{}
+		for i, d := range all {
+			if i < 3 && d != edges[i] {
+				t.Errorf("pointing up %v: neighbour %d is %v with corners, %v without", up, i, d, edges[i])
+			}
+			if d == [2]int{} || seen[d] {
+				t.Errorf("pointing up %v: neighbour %v is itself or given twice", up, d)
+			}
+			seen[d] = true
+		}
+	}
+
+	for _, x := range []int{0, 1} {
+		up := TriangleUp(x, 0)
+		for i, d := range TriangleNeighbours(up, true) {
+			back := TriangleNeighbours(TriangleUp(x+d[0], d[1]), true)
+			j := 0
+			for j < len(back) && back[j] != [2]int{-d[0], -d[1]} {
+				j++
+			}
+			if j == len(back) {
+				t.Errorf("triangle (%d, 0) has neighbour %v, which doesn't have it", x, d)
+			} else if (i < 3) != (j < 3) {
+				t.Errorf("triangle (%d, 0) shares an edge with %v only one way round", x, d)
+			}
+		}
+	}
+}
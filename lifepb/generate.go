@@ -0,0 +1,5 @@
+// Package lifepb is the gRPC control service -grpc serves, generated from
+// proto/life.proto.
+package lifepb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=opengl --go-grpc_out=.. --go-grpc_opt=module=opengl life.proto
@@ -0,0 +1,459 @@
+// The control service -grpc serves, mirroring the -api JSON endpoints.
+// opengl/lifepb is generated from it.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: life.proto
+
+package lifepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Life_GetState_FullMethodName             = "/life.v1.Life/GetState"
+	Life_Pause_FullMethodName                = "/life.v1.Life/Pause"
+	Life_Resume_FullMethodName               = "/life.v1.Life/Resume"
+	Life_StepN_FullMethodName                = "/life.v1.Life/StepN"
+	Life_SetCells_FullMethodName             = "/life.v1.Life/SetCells"
+	Life_GetBoard_FullMethodName             = "/life.v1.Life/GetBoard"
+	Life_SubscribeGenerations_FullMethodName = "/life.v1.Life/SubscribeGenerations"
+	Life_Save_FullMethodName                 = "/life.v1.Life/Save"
+	Life_Load_FullMethodName                 = "/life.v1.Life/Load"
+)
+
+// LifeClient is the client API for Life service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LifeClient interface {
+	// GetState describes the first board, like GET /state.
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error)
+	// Pause and Resume stop and start the boards stepping on their own, like
+	// POST /pause and /resume.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*State, error)
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*State, error)
+	// StepN steps every board n generations, like POST /step.
+	StepN(ctx context.Context, in *StepNRequest, opts ...grpc.CallOption) (*State, error)
+	// SetCells sets cells of the first board as one edit, like PUT /cells.
+	SetCells(ctx context.Context, in *SetCellsRequest, opts ...grpc.CallOption) (*State, error)
+	// GetBoard sends the first board in chunks of its packed cells, so big
+	// boards needn't fit one message.
+	GetBoard(ctx context.Context, in *GetBoardRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BoardChunk], error)
+	// SubscribeGenerations sends a summary of every generation the first
+	// board steps from now on. A client that falls behind misses
+	// generations rather than hold the boards up.
+	SubscribeGenerations(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Generation], error)
+	// Save and Load write and read named saves, like the console's save and
+	// load commands.
+	Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*SaveReply, error)
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*State, error)
+}
+
+type lifeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLifeClient(cc grpc.ClientConnInterface) LifeClient {
+	return &lifeClient{cc}
+}
+
+func (c *lifeClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_Resume_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) StepN(ctx context.Context, in *StepNRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_StepN_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) SetCells(ctx context.Context, in *SetCellsRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_SetCells_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) GetBoard(ctx context.Context, in *GetBoardRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BoardChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Life_ServiceDesc.Streams[0], Life_GetBoard_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetBoardRequest, BoardChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Life_GetBoardClient = grpc.ServerStreamingClient[BoardChunk]
+
+func (c *lifeClient) SubscribeGenerations(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Generation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Life_ServiceDesc.Streams[1], Life_SubscribeGenerations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Generation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Life_SubscribeGenerationsClient = grpc.ServerStreamingClient[Generation]
+
+func (c *lifeClient) Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*SaveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveReply)
+	err := c.cc.Invoke(ctx, Life_Save_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifeClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Life_Load_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LifeServer is the server API for Life service.
+// All implementations must embed UnimplementedLifeServer
+// for forward compatibility.
+type LifeServer interface {
+	// GetState describes the first board, like GET /state.
+	GetState(context.Context, *GetStateRequest) (*State, error)
+	// Pause and Resume stop and start the boards stepping on their own, like
+	// POST /pause and /resume.
+	Pause(context.Context, *PauseRequest) (*State, error)
+	Resume(context.Context, *ResumeRequest) (*State, error)
+	// StepN steps every board n generations, like POST /step.
+	StepN(context.Context, *StepNRequest) (*State, error)
+	// SetCells sets cells of the first board as one edit, like PUT /cells.
+	SetCells(context.Context, *SetCellsRequest) (*State, error)
+	// GetBoard sends the first board in chunks of its packed cells, so big
+	// boards needn't fit one message.
+	GetBoard(*GetBoardRequest, grpc.ServerStreamingServer[BoardChunk]) error
+	// SubscribeGenerations sends a summary of every generation the first
+	// board steps from now on. A client that falls behind misses
+	// generations rather than hold the boards up.
+	SubscribeGenerations(*SubscribeRequest, grpc.ServerStreamingServer[Generation]) error
+	// Save and Load write and read named saves, like the console's save and
+	// load commands.
+	Save(context.Context, *SaveRequest) (*SaveReply, error)
+	Load(context.Context, *LoadRequest) (*State, error)
+	mustEmbedUnimplementedLifeServer()
+}
+
+// UnimplementedLifeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLifeServer struct{}
+
+func (UnimplementedLifeServer) GetState(context.Context, *GetStateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedLifeServer) Pause(context.Context, *PauseRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedLifeServer) Resume(context.Context, *ResumeRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedLifeServer) StepN(context.Context, *StepNRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StepN not implemented")
+}
+func (UnimplementedLifeServer) SetCells(context.Context, *SetCellsRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCells not implemented")
+}
+func (UnimplementedLifeServer) GetBoard(*GetBoardRequest, grpc.ServerStreamingServer[BoardChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method GetBoard not implemented")
+}
+func (UnimplementedLifeServer) SubscribeGenerations(*SubscribeRequest, grpc.ServerStreamingServer[Generation]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeGenerations not implemented")
+}
+func (UnimplementedLifeServer) Save(context.Context, *SaveRequest) (*SaveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Save not implemented")
+}
+func (UnimplementedLifeServer) Load(context.Context, *LoadRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedLifeServer) mustEmbedUnimplementedLifeServer() {}
+func (UnimplementedLifeServer) testEmbeddedByValue()              {}
+
+// UnsafeLifeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LifeServer will
+// result in compilation errors.
+type UnsafeLifeServer interface {
+	mustEmbedUnimplementedLifeServer()
+}
+
+func RegisterLifeServer(s grpc.ServiceRegistrar, srv LifeServer) {
+	// If the following call pancis, it indicates UnimplementedLifeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Life_ServiceDesc, srv)
+}
+
+func _Life_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_StepN_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepNRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).StepN(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_StepN_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).StepN(ctx, req.(*StepNRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_SetCells_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCellsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).SetCells(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_SetCells_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).SetCells(ctx, req.(*SetCellsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_GetBoard_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetBoardRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LifeServer).GetBoard(m, &grpc.GenericServerStream[GetBoardRequest, BoardChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Life_GetBoardServer = grpc.ServerStreamingServer[BoardChunk]
+
+func _Life_SubscribeGenerations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LifeServer).SubscribeGenerations(m, &grpc.GenericServerStream[SubscribeRequest, Generation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Life_SubscribeGenerationsServer = grpc.ServerStreamingServer[Generation]
+
+func _Life_Save_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_Save_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).Save(ctx, req.(*SaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Life_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifeServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Life_Load_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifeServer).Load(ctx, req.(*LoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Life_ServiceDesc is the grpc.ServiceDesc for Life service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Life_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "life.v1.Life",
+	HandlerType: (*LifeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _Life_GetState_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _Life_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _Life_Resume_Handler,
+		},
+		{
+			MethodName: "StepN",
+			Handler:    _Life_StepN_Handler,
+		},
+		{
+			MethodName: "SetCells",
+			Handler:    _Life_SetCells_Handler,
+		},
+		{
+			MethodName: "Save",
+			Handler:    _Life_Save_Handler,
+		},
+		{
+			MethodName: "Load",
+			Handler:    _Life_Load_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetBoard",
+			Handler:       _Life_GetBoard_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeGenerations",
+			Handler:       _Life_SubscribeGenerations_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "life.proto",
+}
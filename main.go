@@ -1,266 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"log"
-	"math/rand"
-	"runtime"
-	"strings"
-	"time"
-
-	"github.com/go-gl/gl/v4.4-core/gl"
-	"github.com/go-gl/glfw/v3.3/glfw"
-)
-
-const (
-	width              = 500
-	height             = 500
-	rows               = 30
-	columns            = 30
-	fps                = 2
-	vertexShaderSource = `
-    #version 430
-    in vec3 vp;
-    void main() {
-        gl_Position = vec4(vp, 1.0);
-    }
-	` + "\x00"
-
-	fragmentShaderSource = `
-    #version 430
-    out vec4 frag_colour;
-    void main() {
-        frag_colour = vec4(1, 1, 1, 1);
-    }
-	` + "\x00"
-)
-
-type cell struct {
-	drawable uint32
-
-	alive     bool
-	aliveNext bool
-
-	x int
-	y int
-}
-
-var (
-	triangle = []float32{
-		-0.5, 0.5, 0,
-		-0.5, -0.5, 0,
-		0.5, -0.5, 0,
-	}
-	square = []float32{
-		-0.5, 0.5, 0,
-		-0.5, -0.5, 0,
-		0.5, -0.5, 0,
-
-		-0.5, 0.5, 0,
-		0.5, 0.5, 0,
-		0.5, -0.5, 0,
-	}
-)
-
-func init() {
-	runtime.LockOSThread()
-}
-
-func main() {
-	window := initGlfw()
-	defer glfw.Terminate()
-
-	program := initOpenGL()
-	cells := makeCells()
-
-	for !window.ShouldClose() {
-		t := time.Now()
-		draw(cells, window, program)
-		getNextState(cells)
-		time.Sleep(time.Second/time.Duration(fps) - time.Since(t))
-	}
-}
-
-func getNextState(cells [][]*cell) {
-	for x := range cells {
-		for y, c := range cells[x] {
-			neighborsAlive := aliveNeighbors(cells, x, y)
-			switch {
-			case !c.alive && neighborsAlive == 3:
-				c.aliveNext = true
-			case !c.alive:
-			case c.alive && (neighborsAlive < 2 || neighborsAlive > 3):
-				c.aliveNext = false
-			default:
-				c.aliveNext = true
-			}
-		}
-	}
-	for x := range cells {
-		for _, c := range cells[x] {
-			c.alive = c.aliveNext
-		}
-	}
-}
-func aliveNeighbors(cells [][]*cell, x int, y int) int {
-	count := 0
-	for i := x - 1; i < x+2; i++ {
-		for j := y - 1; j < y+2; j++ {
-			if (i == x && j == y) || i < 0 || j < 0 || i >= columns || j >= rows {
-				continue
-			}
-			if cells[i][j].alive {
-				count++
-			}
-
-		}
-	}
-	return count
-}
-
-func initGlfw() *glfw.Window {
-	if err := glfw.Init(); err != nil {
-		panic(err)
-	}
-
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 6)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-
-	window, err := glfw.CreateWindow(width, height, "Conway's Game of Life", nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	window.MakeContextCurrent()
-
-	return window
-}
-
-func initOpenGL() uint32 {
-	if err := gl.Init(); err != nil {
-		panic(err)
-	}
-	version := gl.GoStr(gl.GetString(gl.VERSION))
-	log.Println("OpenGL version", version)
-
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
-	}
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		panic(err)
-	}
-
-	program := gl.CreateProgram()
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-	return program
-}
-
-func draw(cells [][]*cell, window *glfw.Window, program uint32) {
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-	gl.UseProgram(program)
-
-	for x := range cells {
-		for _, c := range cells[x] {
-			if c.alive {
-				c.draw()
-			}
-		}
-	}
-
-	glfw.PollEvents()
-	window.SwapBuffers()
-}
-
-// makeVao initializes and returns a vertex array from the points provided.
-func makeVao(points []float32) uint32 {
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
-
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-	gl.EnableVertexAttribArray(0)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
-
-	return vao
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
-
-	return shader, nil
-}
-
-func makeCells() [][]*cell {
-	cells := make([][]*cell, rows, rows)
-	for x := 0; x < rows; x++ {
-		for y := 0; y < columns; y++ {
-			c := newCell(x, y)
-			cells[x] = append(cells[x], c)
-		}
-	}
-	return cells
-}
-
-func newCell(x, y int) *cell {
-	points := make([]float32, len(square), len(square))
-	copy(points, square)
-	for i := 0; i < len(points); i++ {
-		var pos float32
-		var size float32
-		switch i % 3 {
-		case 0:
-			size = 1.0 / float32(columns)
-			pos = float32(x) * size
-		case 1:
-			size = 1.0 / float32(rows)
-			pos = float32(y) * size
-		default:
-			continue
-		}
-		if points[i] < 0 {
-			points[i] = pos*2 - 1
-
-		} else {
-			points[i] = (pos+size)*2 - 1
-		}
-	}
-	alive := rand.Intn(2) == 1
-	return &cell{
-		drawable: makeVao(points),
-		x:        x,
-		y:        y,
-		alive:    alive,
-	}
-}
-
-func (c *cell) draw() {
-	gl.BindVertexArray(c.drawable)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(square)/3))
-}
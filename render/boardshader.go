@@ -0,0 +1,234 @@
+//go:build !js
+
+package render
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// startTime is when u_time started counting for custom board shaders.
+var startTime = time.Now()
+
+// boardProgram is the program the board's cells are drawn with. A custom
+// program, built from a user's fragment shader, is drawn for every cell,
+// dead or alive, and is given the standard uniforms:
+//
+//	u_time        seconds since startup
+//	u_resolution  viewport size in pixels
+//	u_cell        the cell's grid coordinates
+//	u_age         generations the cell has been alive, 0 when dead
+//	u_alive       1 for a live cell, 0 for a dead one
+//	u_colour      the colour the cell would be drawn in by default
+type boardProgram struct {
+	*ShaderProgram
+	projection Uniform
+
+	custom     bool
+	time       Uniform
+	resolution Uniform
+	cell       Uniform
+	age        Uniform
+	alive      Uniform
+	colour     Uniform
+}
+
+// newBoardProgram finds the board's uniforms in program. A custom program
+// needn't use any but projection, and others only u_colour.
+func newBoardProgram(program *ShaderProgram, custom bool) *boardProgram {
+	colour := program.Uniform
+	if custom {
+		colour = program.optionalUniform
+	}
+	return &boardProgram{
+		ShaderProgram: program,
+		projection:    program.Uniform("projection"),
+		custom:        custom,
+		time:          program.optionalUniform("u_time"),
+		resolution:    program.optionalUniform("u_resolution"),
+		cell:          program.optionalUniform("u_cell"),
+		age:           program.optionalUniform("u_age"),
+		alive:         program.optionalUniform("u_alive"),
+		colour:        colour("u_colour"),
+	}
+}
+
+// shaderReloadInterval is how often shader files are checked for changes.
+const shaderReloadInterval = 500 * time.Millisecond
+
+// shaderFile is a shader source file, or the built-in source to use in its
+// place when the file doesn't exist. Its includes are looked for beside it.
+type shaderFile struct {
+	path     string
+	fallback string
+	modTime  time.Time
+	// includes are when the files its includes were looked for in were
+	// last modified, as last read, or zero for those that weren't there.
+	includes map[string]time.Time
+}
+
+func (f *shaderFile) read() (shaderSource, error) {
+	info, err := os.Stat(f.path)
+	var text string
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && f.fallback != "":
+		f.modTime, text = time.Time{}, f.fallback
+	case err != nil:
+		return shaderSource{}, err
+	default:
+		source, err := os.ReadFile(f.path)
+		if err != nil {
+			return shaderSource{}, err
+		}
+		f.modTime, text = info.ModTime(), string(source)
+	}
+	source, err := preprocess(f.path, text, filepath.Dir(f.path))
+	f.includes = make(map[string]time.Time)
+	for _, path := range source.read {
+		f.includes[path] = time.Time{}
+		if info, err := os.Stat(path); err == nil {
+			f.includes[path] = info.ModTime()
+		}
+	}
+	return source, err
+}
+
+func (f *shaderFile) changed() bool {
+	for path, modTime := range f.includes {
+		info, err := os.Stat(path)
+		if err != nil && !modTime.IsZero() || err == nil && !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return !f.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(f.modTime)
+}
+
+func (f *shaderFile) compile(shaderType uint32) (uint32, error) {
+	source, err := f.read()
+	if err != nil {
+		return 0, err
+	}
+	return compileShader(source, shaderType)
+}
+
+// boardShaders builds the board program from shader files and rebuilds it
+// whenever one of them changes, so shaders can be edited while the game
+// runs. A rebuild that fails keeps the previous program.
+type boardShaders struct {
+	vertex   shaderFile
+	fragment shaderFile
+	custom   bool
+
+	program *boardProgram
+	checked time.Time
+	// err is why the files last failed to build, or nil if they didn't.
+	err error
+}
+
+// newBoardShaders loads cell.vert and cell.frag from dir, or a user's own
+// fragment shader in their place if customFragment is set. If the shaders
+// can't be built at startup, the built-in ones are used until the files are
+// fixed.
+func newBoardShaders(dir, customFragment string) (*boardShaders, error) {
+	s := &boardShaders{
+		vertex:   shaderFile{path: filepath.Join(dir, "cell.vert"), fallback: builtinSource("cell.vert")},
+		fragment: shaderFile{path: filepath.Join(dir, "cell.frag"), fallback: builtinSource("cell.frag")},
+		checked:  time.Now(),
+	}
+	if customFragment != "" {
+		s.fragment = shaderFile{path: customFragment}
+		s.custom = true
+	}
+
+	program, err := s.build()
+	if err != nil {
+		s.err = err
+		slog.Warn("using the built-in shaders", "err", err)
+		if program, err = MakeProgram("cell"); err != nil {
+			return nil, err
+		}
+	}
+	s.program = newBoardProgram(newShaderProgram(s.fragment.path, program, s.build), s.custom)
+	return s, nil
+}
+
+func (s *boardShaders) build() (uint32, error) {
+	vertexShader, err := s.vertex.compile(gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(vertexShader)
+	fragmentShader, err := s.fragment.compile(gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(fragmentShader)
+
+	return linkProgram(s.fragment.path, vertexShader, fragmentShader)
+}
+
+// reload rebuilds the program if a shader file has changed since it was last
+// built, returning why if that fails.
+func (s *boardShaders) reload() error {
+	if time.Since(s.checked) < shaderReloadInterval {
+		return nil
+	}
+	s.checked = time.Now()
+	if !s.vertex.changed() && !s.fragment.changed() {
+		return nil
+	}
+
+	s.err = s.program.Reload()
+	if s.err != nil {
+		slog.Warn("keeping the previous shaders", "err", s.err)
+		return s.err
+	}
+	slog.Info("reloaded shaders")
+	return nil
+}
+
+// use binds the program and sets the uniforms shared by every cell.
+func (p *boardProgram) use(projection [16]float32) {
+	p.Use()
+	p.projection.SetMat4(projection)
+	if !p.custom {
+		return
+	}
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	p.time.SetFloat(float32(time.Since(startTime).Seconds()))
+	p.resolution.SetVec2(float32(viewport[2]), float32(viewport[3]))
+}
+
+// drawCell draws c, the cell at (x, y) with its quad from vertex first of
+// the bound vertex array, if the program draws it, setting its per-cell
+// uniforms.
+func (p *boardProgram) drawCell(c life.Cell, x, y int, first int32) {
+	r, g, b := CellColour(c)
+	p.colour.SetVec3(r, g, b)
+	if !p.custom {
+		if c.Alive {
+			drawQuad(first)
+		}
+		return
+	}
+	var alive float32
+	if c.Alive {
+		alive = 1
+	}
+	p.cell.SetVec2(float32(x), float32(y))
+	p.age.SetFloat(float32(c.Age))
+	p.alive.SetFloat(alive)
+	drawQuad(first)
+}
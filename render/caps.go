@@ -0,0 +1,74 @@
+//go:build !js
+
+package render
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Caps are the optional OpenGL features a context has. Code using one
+// checks its field rather than the version, so that a feature a driver
+// offers as an extension is used, and one it lacks is left off before any
+// call can fail.
+type Caps struct {
+	Major, Minor int
+	ES           bool
+	// ObjectLabels and DebugOutput come with OpenGL 4.3, or KHR_debug on
+	// desktop OpenGL.
+	ObjectLabels, DebugOutput bool
+	// PolygonMode, for wireframes, and the PROGRAM_POINT_SIZE switch are
+	// desktop OpenGL's alone.
+	PolygonMode, ProgramPointSize bool
+	// ShaderStorage and Compute come with 4.3, or their ARB extensions.
+	ShaderStorage, Compute bool
+	// BufferStorage comes with 4.4, or ARB_buffer_storage.
+	BufferStorage bool
+	// TimerQuery, for timing passes on the GPU, comes with 3.3, or
+	// ARB_timer_query; ES has only an extension that can lose results.
+	TimerQuery bool
+	// SRGBFramebuffer is the FRAMEBUFFER_SRGB switch, which ES lacks.
+	SRGBFramebuffer bool
+	// MaxSamples is the most samples a multisampled framebuffer can have.
+	MaxSamples int
+}
+
+// NewCaps works out the Caps of an OpenGL major.minor context, or of an
+// OpenGL ES one if es is set, with extensions.
+func NewCaps(major, minor int, es bool, extensions []string, maxSamples int) Caps {
+	at := func(m, n int) bool { return !es && (major > m || major == m && minor >= n) }
+	has := func(ext string) bool { return !es && slices.Contains(extensions, ext) }
+	debug := at(4, 3) || has("GL_KHR_debug")
+	return Caps{
+		Major:            major,
+		Minor:            minor,
+		ES:               es,
+		ObjectLabels:     debug,
+		DebugOutput:      debug,
+		PolygonMode:      !es,
+		ProgramPointSize: !es,
+		ShaderStorage:    at(4, 3) || has("GL_ARB_shader_storage_buffer_object"),
+		Compute:          at(4, 3) || has("GL_ARB_compute_shader"),
+		BufferStorage:    at(4, 4) || has("GL_ARB_buffer_storage"),
+		TimerQuery:       at(3, 3) || has("GL_ARB_timer_query"),
+		SRGBFramebuffer:  !es,
+		MaxSamples:       maxSamples,
+	}
+}
+
+// CurrentCaps returns the current context's Caps, worked out the first
+// time it's called. Contexts sharing objects with the first are taken to
+// have the same.
+var CurrentCaps = sync.OnceValue(func() Caps {
+	major, minor := ContextVersion()
+	var n, samples int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	extensions := make([]string, n)
+	for i := range extensions {
+		extensions[i] = gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))
+	}
+	gl.GetIntegerv(gl.MAX_SAMPLES, &samples)
+	return NewCaps(major, minor, ES(), extensions, int(samples))
+})
@@ -0,0 +1,41 @@
+//go:build !js
+
+package render
+
+import "testing"
+
+// TestNewCaps works out the Caps of made up contexts, for features to come
+// with the version that has them or with their extension, and never on ES.
+func TestNewCaps(t *testing.T) {
+	for _, c := range []struct {
+		name         string
+		major, minor int
+		es           bool
+		extensions   []string
+		want         Caps
+	}{
+		{"3.3 core", 3, 3, false, nil, Caps{
+			Major: 3, Minor: 3, PolygonMode: true, ProgramPointSize: true, TimerQuery: true, SRGBFramebuffer: true, MaxSamples: 4,
+		}},
+		{"3.3 core with extensions", 3, 3, false, []string{"GL_KHR_debug", "GL_ARB_buffer_storage", "GL_ARB_compute_shader"}, Caps{
+			Major: 3, Minor: 3, ObjectLabels: true, DebugOutput: true, PolygonMode: true, ProgramPointSize: true,
+			Compute: true, BufferStorage: true, TimerQuery: true, SRGBFramebuffer: true, MaxSamples: 4,
+		}},
+		{"4.3 core", 4, 3, false, nil, Caps{
+			Major: 4, Minor: 3, ObjectLabels: true, DebugOutput: true, PolygonMode: true, ProgramPointSize: true,
+			ShaderStorage: true, Compute: true, TimerQuery: true, SRGBFramebuffer: true, MaxSamples: 4,
+		}},
+		{"4.6 core", 4, 6, false, nil, Caps{
+			Major: 4, Minor: 6, ObjectLabels: true, DebugOutput: true, PolygonMode: true, ProgramPointSize: true,
+			ShaderStorage: true, Compute: true, BufferStorage: true, TimerQuery: true, SRGBFramebuffer: true, MaxSamples: 4,
+		}},
+		// ES 3.0 takes none of desktop OpenGL's extensions, nor goes by
+		// desktop version numbers.
+		{"ES 3.0", 3, 0, true, []string{"GL_KHR_debug", "GL_ARB_timer_query"}, Caps{Major: 3, Minor: 0, ES: true, MaxSamples: 4}},
+		{"ES 3.2", 3, 2, true, nil, Caps{Major: 3, Minor: 2, ES: true, MaxSamples: 4}},
+	} {
+		if got := NewCaps(c.major, c.minor, c.es, c.extensions, 4); got != c.want {
+			t.Errorf("%s: %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"image/color"
+	"slices"
+
+	"opengl/life"
+)
+
+// LiveColour is the colour of live cells that aren't on a team.
+var LiveColour = [3]float32{1, 1, 1}
+
+// Palette, if set, is the colours of live cells that aren't on a team by
+// age, from 1 up, the last for any older, in place of LiveColour.
+var Palette [][3]float32
+
+// CellColour is the colour a live cell is drawn in: its team's, or
+// LiveColour.
+func CellColour(c life.Cell) (r, g, b float32) {
+	colour := cellColour(c, LiveColour, Palette)
+	return colour[0], colour[1], colour[2]
+}
+
+// cellColour is CellColour with live and ages for LiveColour and Palette.
+func cellColour(c life.Cell, live [3]float32, ages [][3]float32) [3]float32 {
+	switch c.Team {
+	case life.BlueTeam:
+		return [3]float32{0.3, 0.55, 1}
+	case life.RedTeam:
+		return [3]float32{1, 0.35, 0.3}
+	}
+	if len(ages) > 0 {
+		return ages[min(max(c.Age, 1), len(ages))-1]
+	}
+	return live
+}
+
+// CellPalette returns the colours cells are drawn in now as a life.Palette,
+// for life.Grid.Image: CellColour's for live cells, and background for dead
+// ones. LiveColour and Palette are copied, so the palette stays the same
+// when they change, as they do switching rules, and can be used from any
+// goroutine.
+func CellPalette(background [3]float32) life.Palette {
+	live, ages := LiveColour, slices.Clone(Palette)
+	return func(c life.Cell) color.RGBA {
+		colour := background
+		if c.Alive {
+			colour = cellColour(c, live, ages)
+		}
+		return color.RGBA{uint8(colour[0]*255 + 0.5), uint8(colour[1]*255 + 0.5), uint8(colour[2]*255 + 0.5), 255}
+	}
+}
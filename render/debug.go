@@ -0,0 +1,129 @@
+//go:build !js
+
+package render
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// DebugSeverities are the severities of OpenGL debug messages, most severe
+// first, as named to EnableDebugOutput.
+var DebugSeverities = []string{"high", "medium", "low", "notification"}
+
+var debugSeverities = []uint32{gl.DEBUG_SEVERITY_HIGH, gl.DEBUG_SEVERITY_MEDIUM, gl.DEBUG_SEVERITY_LOW, gl.DEBUG_SEVERITY_NOTIFICATION}
+
+// debugLevels are the slog levels debug messages are logged at, by
+// severity.
+var debugLevels = map[string]slog.Level{"high": slog.LevelError, "medium": slog.LevelWarn, "low": slog.LevelInfo, "notification": slog.LevelInfo, "unknown": slog.LevelInfo}
+
+var debugSources = map[uint32]string{
+	gl.DEBUG_SOURCE_API:             "API",
+	gl.DEBUG_SOURCE_WINDOW_SYSTEM:   "window system",
+	gl.DEBUG_SOURCE_SHADER_COMPILER: "shader compiler",
+	gl.DEBUG_SOURCE_THIRD_PARTY:     "third party",
+	gl.DEBUG_SOURCE_APPLICATION:     "application",
+	gl.DEBUG_SOURCE_OTHER:           "other",
+}
+
+var debugTypes = map[uint32]string{
+	gl.DEBUG_TYPE_ERROR:               "error",
+	gl.DEBUG_TYPE_DEPRECATED_BEHAVIOR: "deprecated behaviour",
+	gl.DEBUG_TYPE_UNDEFINED_BEHAVIOR:  "undefined behaviour",
+	gl.DEBUG_TYPE_PORTABILITY:         "portability",
+	gl.DEBUG_TYPE_PERFORMANCE:         "performance",
+	gl.DEBUG_TYPE_MARKER:              "marker",
+	gl.DEBUG_TYPE_PUSH_GROUP:          "push group",
+	gl.DEBUG_TYPE_POP_GROUP:           "pop group",
+	gl.DEBUG_TYPE_OTHER:               "other",
+}
+
+// A DebugError is an error the OpenGL driver reported through debug
+// output, panicked with by CheckDebugError if EnableDebugOutput was asked
+// to.
+type DebugError struct {
+	Source, Severity string
+	ID               uint32
+	Message          string
+	// Stack is the stack of the goroutine whose call caused the error, as
+	// runtime/debug.Stack has it, since the panic comes after the call.
+	Stack []byte
+}
+
+func (e *DebugError) Error() string {
+	return fmt.Sprintf("OpenGL %s error %d (%s): %s", e.Source, e.ID, e.Severity, e.Message)
+}
+
+// pendingDebugError is the first error debug output reported since
+// CheckDebugError last looked, if EnableDebugOutput was asked to panic on
+// errors. The callback can't panic itself: it's called from inside the
+// driver, and a panic can't unwind through the driver's frames.
+var pendingDebugError atomic.Pointer[DebugError]
+
+// CheckDebugError panics with the error debug output reported since it was
+// last called, if there was one and EnableDebugOutput was asked to panic on
+// errors. GL's methods call it once their OpenGL calls have returned, and
+// callers making their own should call it after them.
+func CheckDebugError() {
+	if e := pendingDebugError.Swap(nil); e != nil {
+		panic(e)
+	}
+}
+
+// DebugContext reports whether the current context is a debug context.
+func DebugContext() bool {
+	var flags int32
+	if !ES() {
+		gl.GetIntegerv(gl.CONTEXT_FLAGS, &flags)
+	}
+	return flags&gl.CONTEXT_FLAG_DEBUG_BIT != 0
+}
+
+// EnableDebugOutput logs the current context's debug messages as severe as
+// least, one of DebugSeverities, or more. With panicOnError, the next
+// CheckDebugError after an error panics with a *DebugError, and messages
+// are sent as soon as the call causing them is made, so that it's in the
+// error's stack.
+//
+// Debug output needs Caps.DebugOutput and a debug context, such as GLFW
+// makes with the OpenGLDebugContext hint; without them, EnableDebugOutput
+// does nothing and reports false.
+func EnableDebugOutput(least string, panicOnError bool) bool {
+	if !CurrentCaps().DebugOutput || !DebugContext() {
+		return false
+	}
+	gl.Enable(gl.DEBUG_OUTPUT)
+	if panicOnError {
+		gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+	}
+	gl.DebugMessageControl(gl.DONT_CARE, gl.DONT_CARE, gl.DONT_CARE, 0, nil, true)
+	if i := slices.Index(DebugSeverities, least); i >= 0 {
+		for _, severity := range debugSeverities[i+1:] {
+			gl.DebugMessageControl(gl.DONT_CARE, gl.DONT_CARE, severity, 0, nil, false)
+		}
+	}
+	gl.DebugMessageCallback(func(source, gltype, id, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+		debugMessage(source, gltype, id, severity, message, panicOnError)
+	}, nil)
+	return true
+}
+
+// debugMessage logs a debug message, and with panicOnError keeps it for
+// CheckDebugError if it's the first error since it last looked.
+func debugMessage(source, gltype, id, severity uint32, message string, panicOnError bool) {
+	level := "unknown"
+	if i := slices.Index(debugSeverities, severity); i >= 0 {
+		level = DebugSeverities[i]
+	}
+	slog.Log(context.Background(), debugLevels[level], message, "source", debugSources[source], "type", debugTypes[gltype], "id", id, "severity", level)
+	if gltype == gl.DEBUG_TYPE_ERROR && panicOnError {
+		pendingDebugError.CompareAndSwap(nil, &DebugError{Source: debugSources[source], Severity: level, ID: id, Message: message, Stack: debug.Stack()})
+	}
+}
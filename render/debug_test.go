@@ -0,0 +1,38 @@
+//go:build !js
+
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestDebugErrorsPanicAfterTheCall(t *testing.T) {
+	// As the driver would call back, part way through a GL call.
+	debugMessage(gl.DEBUG_SOURCE_API, gl.DEBUG_TYPE_ERROR, 1282, gl.DEBUG_SEVERITY_HIGH, "invalid operation", true)
+	debugMessage(gl.DEBUG_SOURCE_API, gl.DEBUG_TYPE_ERROR, 1280, gl.DEBUG_SEVERITY_HIGH, "invalid enum", true)
+
+	defer func() {
+		e, ok := recover().(*DebugError)
+		if !ok {
+			t.Fatal("CheckDebugError didn't panic with a *DebugError")
+		}
+		if e.ID != 1282 || e.Source != "API" || e.Severity != "high" || e.Message != "invalid operation" {
+			t.Errorf("CheckDebugError panicked with %+v, want the first error", e)
+		}
+		if !bytes.Contains(e.Stack, []byte("TestDebugErrorsPanicAfterTheCall")) {
+			t.Errorf("the error's stack doesn't have the call that caused it:\n%s", e.Stack)
+		}
+		// Once panicked with, it's gone.
+		CheckDebugError()
+	}()
+	CheckDebugError()
+}
+
+func TestDebugMessagesOnlyLogged(t *testing.T) {
+	debugMessage(gl.DEBUG_SOURCE_API, gl.DEBUG_TYPE_PERFORMANCE, 1, gl.DEBUG_SEVERITY_MEDIUM, "slow", true)
+	debugMessage(gl.DEBUG_SOURCE_API, gl.DEBUG_TYPE_ERROR, 1282, gl.DEBUG_SEVERITY_HIGH, "invalid operation", false)
+	CheckDebugError()
+}
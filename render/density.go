@@ -0,0 +1,149 @@
+//go:build !js
+
+package render
+
+import (
+	"log/slog"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// lodHysteresis is how much bigger than the threshold cells must get on
+// screen before a board drawn as its density goes back to cells, so one
+// at the threshold doesn't flicker between the two.
+const lodHysteresis = 1.5
+
+// densityRenderer draws a whole board as a texture of its cells, a texel
+// each in its colour, mipmapped, so that a board of cells smaller than a
+// pixel shows how dense it is in each part rather than which few cells
+// happen to land on pixels.
+type densityRenderer struct {
+	program    *ShaderProgram
+	projection Uniform
+
+	columns, rows int
+	// texture is made the first time it's drawn, since a board too big
+	// for one is drawn as points instead.
+	texture uint32
+	tooBig  bool
+	texels  []uint8
+	vao     uint32
+	vbo     uint32
+	// shared is set for one made by share, which doesn't own program.
+	shared bool
+}
+
+func newDensityRenderer(columns, rows int) (*densityRenderer, error) {
+	program, err := NewProgram("density")
+	if err != nil {
+		return nil, err
+	}
+	d := &densityRenderer{program: program, projection: program.Uniform("projection"), columns: columns, rows: rows}
+	d.makeQuad()
+	return d, nil
+}
+
+// share returns a density renderer for the current context, which shares
+// objects with d's, drawing with d's program.
+func (d *densityRenderer) share() *densityRenderer {
+	s := &densityRenderer{program: d.program, projection: d.projection, columns: d.columns, rows: d.rows, shared: true}
+	s.makeQuad()
+	return s
+}
+
+// makeQuad makes the board's quad the texture is drawn on.
+func (d *densityRenderer) makeQuad() {
+	quad := []float32{
+		-1, -1, 0, 0,
+		1, -1, 1, 0,
+		-1, 1, 0, 1,
+		1, 1, 1, 1,
+	}
+	d.vbo = Gen(Buffer, "density quad")
+	gl.BindBuffer(gl.ARRAY_BUFFER, d.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	SetSize(Buffer, d.vbo, 4*len(quad))
+
+	d.vao = Gen(VertexArray, "density quad")
+	gl.BindVertexArray(d.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 16, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 16, gl.PtrOffset(8))
+}
+
+// makeTexture makes the texture, reporting false if the board is too big
+// for one.
+func (d *densityRenderer) makeTexture() bool {
+	if d.texture != 0 || d.tooBig {
+		return !d.tooBig
+	}
+	var most int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &most)
+	if d.columns > int(most) || d.rows > int(most) {
+		slog.Warn("the board is too big for a texture, so it's drawn as points however far out it's zoomed", "most", most)
+		d.tooBig = true
+		return false
+	}
+	d.texels = make([]uint8, 4*d.columns*d.rows)
+	d.texture = Gen(Texture, "board density")
+	gl.BindTexture(gl.TEXTURE_2D, d.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(d.columns), int32(d.rows), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	// The mipmaps take a third as much again.
+	SetSize(Texture, d.texture, len(d.texels)*4/3)
+	return true
+}
+
+func (d *densityRenderer) delete() {
+	Delete(VertexArray, d.vao)
+	Delete(Buffer, d.vbo)
+	if d.texture != 0 {
+		Delete(Texture, d.texture)
+	}
+	if !d.shared {
+		d.program.Delete()
+	}
+}
+
+// draw draws cells as seen through projection, reporting false if the
+// board is too big to.
+func (d *densityRenderer) draw(cells life.Grid, projection [16]float32) bool {
+	if !d.makeTexture() {
+		return false
+	}
+	for y := 0; y < cells.Rows(); y++ {
+		for x := 0; x < cells.Columns(); x++ {
+			c := cells.At(x, y)
+			texel := d.texels[4*(y*d.columns+x):][:4]
+			if !c.Alive {
+				texel[0], texel[1], texel[2], texel[3] = 0, 0, 0, 0
+				continue
+			}
+			r, g, b := CellColour(c)
+			texel[0], texel[1], texel[2], texel[3] = uint8(r*255), uint8(g*255), uint8(b*255), 255
+		}
+	}
+	gl.BindTexture(gl.TEXTURE_2D, d.texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(d.columns), int32(d.rows), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(d.texels))
+	Uploaded(len(d.texels))
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+
+	wasBlending := gl.IsEnabled(gl.BLEND)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	d.program.Use()
+	d.projection.SetMat4(projection)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindVertexArray(d.vao)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	if !wasBlending {
+		gl.Disable(gl.BLEND)
+	}
+	return true
+}
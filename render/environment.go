@@ -0,0 +1,44 @@
+//go:build !js
+
+package render
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// Environment is what the current OpenGL context says of itself, for the
+// debug log and bug reports.
+type Environment struct {
+	Vendor, Renderer, Version, GLSLVersion string
+	Major, Minor                           int
+	ES                                     bool
+	// Extensions is how many extensions the context has.
+	Extensions     int
+	MaxTextureSize int
+	MaxViewport    [2]int
+	// MaxSSBOSize is the most bytes a shader storage block can have, if
+	// there are any.
+	MaxSSBOSize int64
+}
+
+// CurrentEnvironment describes the current OpenGL context.
+func CurrentEnvironment() Environment {
+	env := Environment{
+		Vendor:      gl.GoStr(gl.GetString(gl.VENDOR)),
+		Renderer:    gl.GoStr(gl.GetString(gl.RENDERER)),
+		Version:     gl.GoStr(gl.GetString(gl.VERSION)),
+		GLSLVersion: gl.GoStr(gl.GetString(gl.SHADING_LANGUAGE_VERSION)),
+		ES:          ES(),
+	}
+	env.Major, env.Minor = ContextVersion()
+	var n int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	env.Extensions = int(n)
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &n)
+	env.MaxTextureSize = int(n)
+	var dims [2]int32
+	gl.GetIntegerv(gl.MAX_VIEWPORT_DIMS, &dims[0])
+	env.MaxViewport = [2]int{int(dims[0]), int(dims[1])}
+	if CurrentCaps().ShaderStorage {
+		gl.GetInteger64v(gl.MAX_SHADER_STORAGE_BLOCK_SIZE, &env.MaxSSBOSize)
+	}
+	return env
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A ShaderError is a shader that failed to compile, with the messages in
+// the driver's info log picked out.
+type ShaderError struct {
+	// Name is the shader's file, and Stage "vertex" or "fragment".
+	Name, Stage string
+	// Files are the files the source was put together from, by source
+	// string number, if it included any.
+	Files []string
+	// Diagnostics are the info log's messages, in order.
+	Diagnostics []Diagnostic
+	// Log is the info log as the driver wrote it.
+	Log string
+}
+
+// newShaderError makes the ShaderError for name's stage shader failing to
+// compile with infoLog.
+func newShaderError(name, stage, infoLog string) *ShaderError {
+	return &ShaderError{Name: name, Stage: stage, Diagnostics: parseShaderLog(infoLog), Log: infoLog}
+}
+
+// A Diagnostic is a message in a shader's info log.
+type Diagnostic struct {
+	// Source is the number of the source string the message is about, and
+	// Line and Column where in it, counting from 1. Line is 0 for messages
+	// not about a line, and Column is 0 unless the driver gives one.
+	Source, Line, Column int
+	// Severity is "error" or "warning", or "" if the driver doesn't say.
+	Severity string
+	Message  string
+}
+
+// Error lists the messages about a line, like a compiler would, e.g.
+// "cell.frag:12: 'frag_colour' : undeclared identifier", or the rest of
+// the log if none are.
+func (e *ShaderError) Error() string {
+	var lines []string
+	for _, d := range e.Diagnostics {
+		if d.Line > 0 {
+			lines = append(lines, e.format(d))
+		}
+	}
+	if len(lines) == 0 {
+		for _, d := range e.Diagnostics {
+			lines = append(lines, e.format(d))
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("failed to compile %s %s", e.Stage, e.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary is the first message about a line, for a one-line notice,
+// saying how many more there are.
+func (e *ShaderError) Summary() string {
+	first, more := "", ""
+	if lines := strings.Split(e.Error(), "\n"); len(lines) > 0 {
+		first = lines[0]
+		if len(lines) > 1 {
+			more = fmt.Sprintf(" (and %d more)", len(lines)-1)
+		}
+	}
+	return first + more
+}
+
+func (e *ShaderError) format(d Diagnostic) string {
+	at := e.Name
+	if d.Source > 0 && d.Source < len(e.Files) {
+		at = e.Files[d.Source]
+	}
+	if d.Line > 0 {
+		at += ":" + strconv.Itoa(d.Line)
+		if d.Column > 0 {
+			at += ":" + strconv.Itoa(d.Column)
+		}
+	}
+	if d.Severity == "warning" {
+		return at + ": warning: " + d.Message
+	}
+	return at + ": " + d.Message
+}
+
+// The info log formats drivers write messages in:
+//
+//	Mesa:                 0:12(5): error: `frag_colour' undeclared
+//	NVIDIA:               0(12) : error C1008: undefined variable "frag_colour"
+//	AMD, Intel and Apple: ERROR: 0:12: 'frag_colour' : undeclared identifier
+var (
+	mesaLine   = regexp.MustCompile(`^(\d+):(\d+)\((\d+)\): ((?:\w+ )?(?:error|warning)): (.*)$`)
+	nvidiaLine = regexp.MustCompile(`^(\d+)\((\d+)\) : (error|warning) (\w+): (.*)$`)
+	amdLine    = regexp.MustCompile(`^(ERROR|WARNING): (\d+):(\d+): (.*)$`)
+	// amdSummary is the count AMD and others end their logs with.
+	amdSummary = regexp.MustCompile(`^(?:ERROR|WARNING): \d+ compilation (?:errors|warnings)\.`)
+)
+
+// parseShaderLog picks the messages out of a shader info log, in any of
+// the formats above. Lines in none of them are kept as messages about no
+// line, except the summaries at the ends of AMD's logs.
+func parseShaderLog(infoLog string) []Diagnostic {
+	var ds []Diagnostic
+	for _, line := range strings.Split(strings.TrimRight(infoLog, "\x00"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || amdSummary.MatchString(line) {
+			continue
+		}
+		var d Diagnostic
+		if m := mesaLine.FindStringSubmatch(line); m != nil {
+			d = Diagnostic{Source: atoi(m[1]), Line: atoi(m[2]), Column: atoi(m[3]), Severity: severity(m[4]), Message: m[5]}
+		} else if m := nvidiaLine.FindStringSubmatch(line); m != nil {
+			d = Diagnostic{Source: atoi(m[1]), Line: atoi(m[2]), Severity: m[3], Message: m[4] + ": " + m[5]}
+		} else if m := amdLine.FindStringSubmatch(line); m != nil {
+			d = Diagnostic{Source: atoi(m[2]), Line: atoi(m[3]), Severity: severity(m[1]), Message: m[4]}
+		} else {
+			d = Diagnostic{Message: line}
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+// severity turns a driver's name for a message's severity, e.g.
+// "preprocessor error" or "WARNING", into a Diagnostic's.
+func severity(s string) string {
+	if strings.Contains(strings.ToLower(s), "warning") {
+		return "warning"
+	}
+	return "error"
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// A LinkError is a program that failed to link.
+type LinkError struct {
+	// Name is the program's, and Log its info log.
+	Name, Log string
+}
+
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("failed to link %s:\n%s", e.Name, e.Log)
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseShaderLog parses the same broken fragment shader's info log as
+// Mesa, NVIDIA and AMD drivers write it, in testdata, for the messages to
+// come out at the same lines whichever wrote it.
+func TestParseShaderLog(t *testing.T) {
+	for _, c := range []struct {
+		driver string
+		want   []Diagnostic
+		error  []string
+	}{
+		{"mesa", []Diagnostic{
+			{Line: 7, Column: 2, Severity: "error", Message: "`frag_colour' undeclared"},
+			{Line: 7, Column: 2, Severity: "error", Message: "value of type vec4 cannot be assigned to variable of type error"},
+			{Line: 8, Column: 17, Severity: "error", Message: "no function with name 'undefined_fn'"},
+		}, []string{
+			"cell.frag:7:2: `frag_colour' undeclared",
+			"cell.frag:7:2: value of type vec4 cannot be assigned to variable of type error",
+			"cell.frag:8:17: no function with name 'undefined_fn'",
+		}},
+		{"nvidia", []Diagnostic{
+			{Line: 6, Severity: "warning", Message: `C7011: implicit cast from "int" to "float"`},
+			{Line: 7, Severity: "error", Message: `C1008: undefined variable "frag_colour"`},
+			{Line: 8, Severity: "error", Message: `C1115: unable to find compatible overloaded function "undefined_fn(float)"`},
+		}, []string{
+			`cell.frag:6: warning: C7011: implicit cast from "int" to "float"`,
+			`cell.frag:7: C1008: undefined variable "frag_colour"`,
+			`cell.frag:8: C1115: unable to find compatible overloaded function "undefined_fn(float)"`,
+		}},
+		// The count AMD ends with isn't a message.
+		{"amd", []Diagnostic{
+			{Line: 7, Severity: "error", Message: "'frag_colour' : undeclared identifier"},
+			{Line: 7, Severity: "error", Message: "'assign' :  cannot convert from '4-component vector of float' to 'float'"},
+			{Line: 8, Severity: "error", Message: "'undefined_fn' : no matching overloaded function found"},
+		}, []string{
+			"cell.frag:7: 'frag_colour' : undeclared identifier",
+			"cell.frag:7: 'assign' :  cannot convert from '4-component vector of float' to 'float'",
+			"cell.frag:8: 'undefined_fn' : no matching overloaded function found",
+		}},
+	} {
+		log, err := os.ReadFile(filepath.Join("testdata", c.driver+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Drivers hand the log back with its terminating NUL.
+		e := newShaderError("cell.frag", "fragment", string(log)+"\x00")
+		if !slices.Equal(e.Diagnostics, c.want) {
+			t.Errorf("%s: parsed\n%+v\nwant\n%+v", c.driver, e.Diagnostics, c.want)
+		}
+		if got := e.Error(); got != strings.Join(c.error, "\n") {
+			t.Errorf("%s: the error is\n%s\nwant\n%s", c.driver, got, strings.Join(c.error, "\n"))
+		}
+		if got, want := e.Summary(), c.error[0]+" (and 2 more)"; got != want {
+			t.Errorf("%s: summed up as %q, want %q", c.driver, got, want)
+		}
+	}
+}
+
+// TestShaderErrorFiles checks messages about an included file are put down
+// to it, by source string number, and a log with no line numbers in is
+// given as it is.
+func TestShaderErrorFiles(t *testing.T) {
+	e := newShaderError("cell.frag", "fragment", "0:3(1): error: syntax error, unexpected '}'\n1:12(5): warning: `tint' unused\n")
+	e.Files = []string{"cell.frag", "cell_uniforms.glsl"}
+	if got, want := e.Error(), "cell.frag:3:1: syntax error, unexpected '}'\ncell_uniforms.glsl:12:5: warning: `tint' unused"; got != want {
+		t.Errorf("the error is\n%s\nwant\n%s", got, want)
+	}
+
+	e = newShaderError("more.frag", "fragment", "Fragment shader failed to compile with the following errors:\ninternal compiler error\n")
+	if got, want := e.Error(), "more.frag: Fragment shader failed to compile with the following errors:\nmore.frag: internal compiler error"; got != want {
+		t.Errorf("the error is\n%s\nwant\n%s", got, want)
+	}
+	if got := newShaderError("empty.vert", "vertex", "").Error(); got != "failed to compile vertex empty.vert" {
+		t.Errorf("with an empty log, the error is %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"opengl/life"
+	"opengl/render"
+)
+
+func ExampleRenderer() {
+	g := life.NewGrid(8, 8)
+	p, _, _ := life.ParseRLE("bo$2bo$3o!")
+	g.Stamp(p, 3, 4, false)
+
+	// Any Renderer draws boards the same way; a Terminal needs no window.
+	var out bytes.Buffer
+	var r render.Renderer = render.NewTerminal(&out, true)
+	if err := r.Init(g.Columns(), g.Rows()); err != nil {
+		panic(err)
+	}
+	r.Resize(4, 2)
+	if err := r.DrawFrame(g, render.View{}); err != nil {
+		panic(err)
+	}
+	defer r.Shutdown()
+
+	// Without its escape codes, the frame is the board in Braille, a
+	// character for each two columns and four rows of cells.
+	frame := regexp.MustCompile("\x1b\\[[0-9]+;1H").ReplaceAllString(out.String(), "\n")
+	frame = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]").ReplaceAllString(frame, "")
+	fmt.Println(strings.TrimSpace(frame))
+	// Output:
+	// ⠀⠠⡀⠀
+	// ⠀⠉⠁⠀
+}
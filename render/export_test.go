@@ -0,0 +1,26 @@
+//go:build egl
+
+package render
+
+// The egl tests are outside the package, to make their contexts with
+// offscreen, which imports it; these let them look into a GL's pass timer.
+
+const GPUTimerQueries = gpuTimerQueries
+
+// GPUTimerWaiting returns the queries r's timer is waiting on, oldest
+// first.
+func GPUTimerWaiting(r *GL) []uint32 {
+	var waiting []uint32
+	for i := 0; i < r.timer.waiting; i++ {
+		waiting = append(waiting, r.timer.queries[(r.timer.oldest+i)%len(r.timer.queries)])
+	}
+	return waiting
+}
+
+// HoldGPUTimes keeps every timer from seeing results come back, as if the
+// GPU were running behind, until the returned function is called.
+func HoldGPUTimes() (release func()) {
+	available := queryAvailable
+	queryAvailable = func(uint32) bool { return false }
+	return func() { queryAvailable = available }
+}
@@ -0,0 +1,256 @@
+//go:build !js
+
+package render
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// square is the two triangles of a cell's quad, centred on the origin.
+var square = []float32{
+	-0.5, 0.5, 0,
+	-0.5, -0.5, 0,
+	0.5, -0.5, 0,
+
+	-0.5, 0.5, 0,
+	0.5, 0.5, 0,
+	0.5, -0.5, 0,
+}
+
+// GL is the OpenGL renderer. It draws boards a quad per cell with the board
+// shaders, or a point per live cell once cells are too small on screen for
+// quads to be worth it, into the current context's viewport. Smaller still,
+// under the level-of-detail threshold, it draws a board as its density.
+type GL struct {
+	shaderDir, customFragment string
+
+	shaders *boardShaders
+	points  *pointRenderer
+	density *densityRenderer
+	// lodThreshold is the on-screen cell size, in pixels, below which
+	// boards are drawn as their density, and dense is set while they are.
+	lodThreshold float32
+	dense        bool
+	// timer times the board passes on the GPU, if the context can.
+	timer *gpuTimer
+	// vbo holds every cell's quad, one after another by column, shared by
+	// every board drawn, and vao is the vertex array of it.
+	vao, vbo uint32
+	rows     int
+	// shared is set for a renderer made by Shared, which owns only its
+	// vertex array and points.
+	shared bool
+}
+
+// NewGL returns a renderer drawing cells with the cell.vert and cell.frag
+// shaders in shaderDir, or a user's own fragment shader if customFragment
+// is set. See newBoardShaders for the fallbacks.
+func NewGL(shaderDir, customFragment string) *GL {
+	return &GL{shaderDir: shaderDir, customFragment: customFragment, lodThreshold: 1}
+}
+
+// SetLODThreshold sets the on-screen cell size, in pixels, below which
+// boards are drawn as how dense their cells are, smoothly, rather than
+// cell by cell; 0 turns that off. Boards go back to cells once theirs are
+// half as big again, so they don't flicker between the two at the
+// threshold. It's 1 to begin with. Custom fragment shaders see every cell
+// however small.
+func (r *GL) SetLODThreshold(pixels float32) {
+	r.lodThreshold = pixels
+}
+
+func (r *GL) Init(columns, rows int) error {
+	defer CheckDebugError()
+	shaders, err := newBoardShaders(r.shaderDir, r.customFragment)
+	if err != nil {
+		return err
+	}
+	points, err := newPointRenderer(columns, rows)
+	if err != nil {
+		return err
+	}
+	density, err := newDensityRenderer(columns, rows)
+	if err != nil {
+		return err
+	}
+	r.shaders, r.points, r.density = shaders, points, density
+	r.timer = newGPUTimer()
+	// The quads go in one buffer, uploaded at once: a buffer a cell took
+	// seconds to make on large boards.
+	start := time.Now()
+	quads := make([]float32, len(square)*columns*rows)
+	corners := cellPoints
+	if Tiling == life.Triangles {
+		corners = trianglePoints
+	}
+	for x := 0; x < columns; x++ {
+		for y := 0; y < rows; y++ {
+			corners(quads[len(square)*(x*rows+y):], x, y, columns, rows)
+		}
+	}
+	r.vao, r.vbo = makeVao("cells", quads)
+	r.rows = rows
+	slog.Info("made the cells' quads", "cells", columns*rows, "took", time.Since(start).Round(time.Microsecond))
+	return nil
+}
+
+// Shared returns a renderer for the current context, which must share
+// objects with the one r was initialized in. It draws with r's shaders and
+// cell buffers, making only what contexts don't share, the vertex arrays,
+// and a buffer of its own to stream points through and texture of its own
+// for the boards' density. It doesn't time its boards on the GPU. Shutting
+// it down leaves
+// r's objects alone, but r must outlive it.
+func (r *GL) Shared() *GL {
+	s := &GL{shaderDir: r.shaderDir, customFragment: r.customFragment, shaders: r.shaders, vbo: r.vbo, rows: r.rows, lodThreshold: r.lodThreshold, shared: true}
+	s.points = r.points.share()
+	s.density = r.density.share()
+	s.vao = makeVertexArray("cells", r.vbo)
+	return s
+}
+
+// GPUTimes returns how long the GPU took over each board drawn whose time
+// has come back since it was last called, a frame or so after each was
+// drawn, oldest first. It returns none if the context has no timer queries.
+func (r *GL) GPUTimes() []time.Duration {
+	return r.timer.take()
+}
+
+// Reload rebuilds the board shaders if their files have changed,
+// returning why if they don't build; the previous ones are kept.
+func (r *GL) Reload() error {
+	return r.shaders.reload()
+}
+
+// ShaderError returns why the board shader files last failed to build, a
+// *ShaderError if they didn't compile, or nil if they're in use.
+func (r *GL) ShaderError() error {
+	return r.shaders.err
+}
+
+// DrawFrame draws the board into the current viewport, which it spans at a
+// zoom of 1. Only the cells in view are drawn, so a close-up of a big board
+// costs what the close-up does.
+func (r *GL) DrawFrame(cells life.Grid, view View) error {
+	minX, minY, maxX, maxY, ok := view.VisibleCells(cells.Columns(), cells.Rows())
+	if !ok {
+		return nil
+	}
+	if Tiling == life.Triangles {
+		// Triangles are wider than squares, and a row of them a little
+		// narrower.
+		minX, maxX = max(minX-2, 0), min(maxX+2, cells.Columns()-1)
+	}
+	defer CheckDebugError()
+	r.timer.begin()
+	defer r.timer.end()
+	program := r.shaders.program
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	size := float32(viewport[2]) / float32(cells.Columns()) * view.Zoom
+	if r.lodThreshold > 0 && !program.custom {
+		switch {
+		case size < r.lodThreshold:
+			r.dense = true
+		case size > r.lodThreshold*lodHysteresis:
+			r.dense = false
+		}
+		if r.dense && r.density.draw(cells, view.Projection) {
+			return nil
+		}
+	}
+	if size <= pointThreshold && !program.custom {
+		r.points.draw(cells, view.Projection, size)
+		return nil
+	}
+
+	program.use(view.Projection)
+	gl.BindVertexArray(r.vao)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			program.drawCell(cells.At(x, y), x, y, int32(len(square)/3*(x*r.rows+y)))
+		}
+	}
+	return nil
+}
+
+// Resize does nothing: the caller sets the viewport for each board.
+func (r *GL) Resize(width, height int) {}
+
+func (r *GL) Shutdown() {
+	Delete(VertexArray, r.vao)
+	if !r.shared {
+		Delete(Buffer, r.vbo)
+	}
+	r.vao, r.vbo = 0, 0
+	r.points.delete()
+	r.density.delete()
+	r.timer.delete()
+	if !r.shared {
+		r.shaders.program.Delete()
+	}
+}
+
+// makeVao initializes and returns a vertex array, and its buffer, from the
+// points provided, labelled name.
+func makeVao(name string, points []float32) (uint32, uint32) {
+	vbo := Gen(Buffer, name)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
+	SetSize(Buffer, vbo, 4*len(points))
+	return makeVertexArray(name, vbo), vbo
+}
+
+// makeVertexArray returns a vertex array, labelled name, of the points in
+// vbo.
+func makeVertexArray(name string, vbo uint32) uint32 {
+	vao := Gen(VertexArray, name)
+	gl.BindVertexArray(vao)
+	gl.EnableVertexAttribArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
+	return vao
+}
+
+// CellPoints returns the square's vertices moved to cell (x, y) of a
+// columns by rows board spanning normalized device coordinates.
+func CellPoints(x, y, columns, rows int) []float32 {
+	points := make([]float32, len(square))
+	cellPoints(points, x, y, columns, rows)
+	return points
+}
+
+// cellPoints is CellPoints, into the start of points.
+func cellPoints(points []float32, x, y, columns, rows int) {
+	copy(points, square)
+	for i := 0; i < len(square); i++ {
+		var pos float32
+		var size float32
+		switch i % 3 {
+		case 0:
+			size = 1.0 / float32(columns)
+			pos = float32(x) * size
+		case 1:
+			size = 1.0 / float32(rows)
+			pos = float32(y) * size
+		default:
+			continue
+		}
+		if points[i] < 0 {
+			points[i] = pos*2 - 1
+
+		} else {
+			points[i] = (pos+size)*2 - 1
+		}
+	}
+}
+
+// drawQuad draws the quad starting at vertex first of the bound vertex
+// array.
+func drawQuad(first int32) {
+	gl.DrawArrays(gl.TRIANGLES, first, int32(len(square)/3))
+}
@@ -0,0 +1,110 @@
+//go:build !js
+
+package render
+
+import (
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// gpuTimerQueries is how many passes a gpuTimer can have waiting on the
+// GPU at once: a few frames of a few boards each.
+const gpuTimerQueries = 16
+
+// gpuTimer times passes on the GPU with TIME_ELAPSED queries. A query's
+// result only comes back once the GPU has finished the pass, a frame or
+// more after it was drawn, so the queries are a ring: each pass begins the
+// next after the last one begun, and the oldest are read back once the GPU
+// says they're available, never waiting for it. Should every query still
+// be waiting, the pass goes untimed rather than stall. Queries belong to
+// the context they were made in.
+type gpuTimer struct {
+	queries [gpuTimerQueries]uint32
+	// waiting is how many queries there are results to come back for, the
+	// oldest at queries[oldest].
+	oldest, waiting int
+	// timing is set between a begin that began a query and its end.
+	timing bool
+	// times are the results come back and not yet taken.
+	times []time.Duration
+}
+
+// newGPUTimer returns a timer in the current context, or nil, which times
+// nothing, if it has no timer queries.
+func newGPUTimer() *gpuTimer {
+	if !CurrentCaps().TimerQuery {
+		return nil
+	}
+	t := &gpuTimer{}
+	for i := range t.queries {
+		t.queries[i] = Gen(Query, "board pass timer")
+	}
+	return t
+}
+
+// begin starts timing a pass, unless every query is still waiting.
+func (t *gpuTimer) begin() {
+	if t == nil {
+		return
+	}
+	t.collect()
+	if t.waiting == len(t.queries) {
+		return
+	}
+	gl.BeginQuery(gl.TIME_ELAPSED, t.queries[(t.oldest+t.waiting)%len(t.queries)])
+	t.timing = true
+}
+
+// end stops timing the pass begun.
+func (t *gpuTimer) end() {
+	if t == nil || !t.timing {
+		return
+	}
+	gl.EndQuery(gl.TIME_ELAPSED)
+	t.timing = false
+	t.waiting++
+}
+
+// collect reads back the results that have come back, oldest first,
+// stopping at the first that hasn't: the GPU finishes passes in order.
+func (t *gpuTimer) collect() {
+	for t.waiting > 0 {
+		query := t.queries[t.oldest]
+		if !queryAvailable(query) {
+			return
+		}
+		var nanos uint64
+		gl.GetQueryObjectui64v(query, gl.QUERY_RESULT, &nanos)
+		t.times = append(t.times, time.Duration(nanos))
+		t.oldest = (t.oldest + 1) % len(t.queries)
+		t.waiting--
+	}
+}
+
+// queryAvailable reports whether query's result has come back. Tests hold
+// results back with it, as a GPU running behind would.
+var queryAvailable = func(query uint32) bool {
+	var available int32
+	gl.GetQueryObjectiv(query, gl.QUERY_RESULT_AVAILABLE, &available)
+	return available != gl.FALSE
+}
+
+// take returns the times of the passes that have come back since it was
+// last called.
+func (t *gpuTimer) take() []time.Duration {
+	if t == nil {
+		return nil
+	}
+	t.collect()
+	times := t.times
+	t.times = nil
+	return times
+}
+
+func (t *gpuTimer) delete() {
+	if t == nil {
+		return
+	}
+	Delete(Query, t.queries[:]...)
+}
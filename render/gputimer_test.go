@@ -0,0 +1,117 @@
+//go:build egl
+
+package render_test
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+	"opengl/render/offscreen"
+)
+
+// liveQueries returns how many query objects the resource tracker has live.
+func liveQueries() int {
+	n := 0
+	for _, o := range render.LiveObjects() {
+		if o.Kind == render.Query {
+			n++
+		}
+	}
+	return n
+}
+
+// TestGPUTimer draws boards through GL, checking their times come back a
+// frame or so later without waiting on the GPU, that a GPU running behind
+// has at most a ring's worth of passes timed, the rest going untimed rather
+// than reusing a query still out, and that shutting down deletes every
+// query.
+func TestGPUTimer(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	c, err := offscreen.New()
+	if errors.Is(err, offscreen.ErrUnavailable) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if !render.CurrentCaps().TimerQuery {
+		t.Skip("the context has no timer queries")
+	}
+	target, err := render.NewTarget(64, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	target.Bind()
+
+	before := liveQueries()
+	r := render.NewGL("shaders", "")
+	if err := r.Init(16, 16); err != nil {
+		t.Fatal(err)
+	}
+	if got := liveQueries() - before; got != render.GPUTimerQueries {
+		t.Fatalf("the renderer made %d queries, want %d", got, render.GPUTimerQueries)
+	}
+	cells := life.NewGrid(16, 16)
+	cells.Set(3, 4, true)
+	view := render.View{Projection: [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1}, Zoom: 1}
+	draw := func() {
+		if err := r.DrawFrame(cells, view); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The times come back as the GPU gets through the passes, GPUTimes
+	// taking whatever's there each frame.
+	var times []time.Duration
+	for frame := 0; frame < 1000 && len(times) == 0; frame++ {
+		draw()
+		times = r.GPUTimes()
+		if waiting := len(render.GPUTimerWaiting(r)); waiting > render.GPUTimerQueries {
+			t.Fatalf("frame %d: %d passes waiting, more than the %d queries", frame, waiting, render.GPUTimerQueries)
+		}
+	}
+	if len(times) == 0 {
+		t.Fatal("no pass's time came back in 1000 frames")
+	}
+	if !slices.ContainsFunc(times, func(d time.Duration) bool { return d > 0 }) {
+		t.Errorf("passes took %v on the GPU, want some time", times)
+	}
+
+	// With the GPU behind, the ring fills, and passes after that go
+	// untimed, leaving the queries out alone.
+	release := render.HoldGPUTimes()
+	for i := 0; i < render.GPUTimerQueries; i++ {
+		draw()
+	}
+	full := render.GPUTimerWaiting(r)
+	if len(full) != render.GPUTimerQueries {
+		t.Fatalf("%d passes waiting after a ring's worth, want %d", len(full), render.GPUTimerQueries)
+	}
+	draw()
+	if got := render.GPUTimerWaiting(r); !slices.Equal(got, full) {
+		t.Errorf("a pass with the ring full left queries %v waiting, want %v", got, full)
+	}
+	if got := r.GPUTimes(); len(got) != 0 {
+		t.Errorf("%d times came back while held", len(got))
+	}
+	release()
+	// Waiting on the GPU here, and only here, brings every result back.
+	gl.Finish()
+	if got := r.GPUTimes(); len(got) != render.GPUTimerQueries {
+		t.Errorf("%d times came back once released, want %d", len(got), render.GPUTimerQueries)
+	}
+
+	r.Shutdown()
+	if got := liveQueries(); got != before {
+		t.Errorf("%d queries live after shutting down, want %d", got, before)
+	}
+}
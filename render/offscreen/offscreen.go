@@ -0,0 +1,115 @@
+//go:build egl
+
+// Package offscreen makes OpenGL contexts without a window or a display,
+// through EGL, so boards can be drawn into a render.Target and read back on
+// machines with no screen, such as CI runners with Mesa's software
+// rendering. It's built with the egl build tag, and links to libEGL.
+package offscreen
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+static EGLDisplay surfacelessDisplay(void) {
+	return eglGetPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+
+	"opengl/render"
+)
+
+// ErrUnavailable is wrapped by the errors New returns when there's no way
+// to make an offscreen context here, for callers to skip rather than fail.
+var ErrUnavailable = errors.New("no offscreen OpenGL context")
+
+// A Context is an offscreen OpenGL core context, current on the thread that
+// made it, which should be locked to its goroutine.
+type Context struct {
+	display C.EGLDisplay
+	context C.EGLContext
+}
+
+// New makes the newest of render.ContextVersions the driver has current,
+// with no surface, and loads OpenGL's functions for it. Rendering goes
+// to a render.Target, there being no default framebuffer.
+func New() (*Context, error) {
+	display := C.surfacelessDisplay()
+	if display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		display = C.eglGetDisplay(C.EGLNativeDisplayType(C.EGL_DEFAULT_DISPLAY))
+	}
+	if display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return nil, fmt.Errorf("%w: EGL has no display", ErrUnavailable)
+	}
+	if C.eglInitialize(display, nil, nil) == C.EGL_FALSE {
+		return nil, eglError("initializing EGL")
+	}
+	c := &Context{display: display}
+	if C.eglBindAPI(C.EGL_OPENGL_API) == C.EGL_FALSE {
+		c.Close()
+		return nil, eglError("binding OpenGL")
+	}
+
+	configAttribs := []C.EGLint{C.EGL_SURFACE_TYPE, C.EGL_PBUFFER_BIT, C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_BIT, C.EGL_NONE}
+	var config C.EGLConfig
+	var configs C.EGLint
+	if C.eglChooseConfig(display, &configAttribs[0], &config, 1, &configs) == C.EGL_FALSE || configs == 0 {
+		c.Close()
+		return nil, fmt.Errorf("%w: EGL has no OpenGL config", ErrUnavailable)
+	}
+	for _, v := range render.ContextVersions {
+		attribs := []C.EGLint{
+			C.EGL_CONTEXT_MAJOR_VERSION, C.EGLint(v[0]),
+			C.EGL_CONTEXT_MINOR_VERSION, C.EGLint(v[1]),
+			C.EGL_CONTEXT_OPENGL_PROFILE_MASK, C.EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+			C.EGL_NONE,
+		}
+		if c.context = C.eglCreateContext(display, config, C.EGLContext(C.EGL_NO_CONTEXT), &attribs[0]); c.context != C.EGLContext(C.EGL_NO_CONTEXT) {
+			break
+		}
+	}
+	if c.context == C.EGLContext(C.EGL_NO_CONTEXT) {
+		c.Close()
+		oldest := render.ContextVersions[len(render.ContextVersions)-1]
+		return nil, eglError(fmt.Sprintf("creating an OpenGL %d.%d core context or later", oldest[0], oldest[1]))
+	}
+	if C.eglMakeCurrent(display, C.EGLSurface(C.EGL_NO_SURFACE), C.EGLSurface(C.EGL_NO_SURFACE), c.context) == C.EGL_FALSE {
+		c.Close()
+		return nil, eglError("making the context current without a surface")
+	}
+	if err := gl.InitWithProcAddrFunc(getProcAddress); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("%w: loading OpenGL: %v", ErrUnavailable, err)
+	}
+	return c, nil
+}
+
+// Close frees the context and lets EGL go.
+func (c *Context) Close() {
+	C.eglMakeCurrent(c.display, C.EGLSurface(C.EGL_NO_SURFACE), C.EGLSurface(C.EGL_NO_SURFACE), C.EGLContext(C.EGL_NO_CONTEXT))
+	if c.context != C.EGLContext(C.EGL_NO_CONTEXT) {
+		C.eglDestroyContext(c.display, c.context)
+	}
+	C.eglTerminate(c.display)
+}
+
+func getProcAddress(name string) unsafe.Pointer {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return unsafe.Pointer(C.eglGetProcAddress(cname))
+}
+
+// eglError returns an ErrUnavailable saying what failed, with EGL's error
+// code.
+func eglError(what string) error {
+	return fmt.Errorf("%w: %s: EGL error 0x%x", ErrUnavailable, what, C.eglGetError())
+}
@@ -0,0 +1,173 @@
+//go:build egl
+
+package offscreen
+
+import (
+	"errors"
+	"flag"
+	"image/color"
+	"image/png"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+	"opengl/render"
+)
+
+var update = flag.Bool("update", false, "write the golden images afresh")
+
+// current makes an offscreen context current for the test, skipping it
+// if there's no way to here.
+func current(t testing.TB) {
+	t.Helper()
+	runtime.LockOSThread()
+	c, err := New()
+	if errors.Is(err, ErrUnavailable) {
+		runtime.UnlockOSThread()
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		runtime.UnlockOSThread()
+	})
+}
+
+// TestGLRendererGolden draws a known 8x8 board and compares it with
+// testdata/board8.png, a channel step either way apart for drivers'
+// rounding.
+func TestGLRendererGolden(t *testing.T) {
+	current(t)
+	target, err := render.NewTarget(64, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	r := render.NewGL("../shaders", "")
+	if err := r.Init(8, 8); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Shutdown()
+	// A glider at the top left, a block at the bottom right and a cell
+	// in each of the other corners.
+	cells := life.NewGrid(8, 8)
+	for _, c := range [][2]int{{1, 7}, {2, 6}, {0, 5}, {1, 5}, {2, 5}, {5, 1}, {6, 1}, {5, 2}, {6, 2}, {7, 7}, {0, 0}} {
+		cells.Set(c[0], c[1], true)
+	}
+	target.Bind()
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	if err := r.DrawFrame(cells, render.View{Projection: [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1}, Zoom: 1}); err != nil {
+		t.Fatal(err)
+	}
+	got := target.Read()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	const golden = "testdata/board8.png"
+	if *update {
+		if err := render.SavePNG(golden, got); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Bounds() != got.Bounds() {
+		t.Fatalf("drew %v, want %v", got.Bounds(), want.Bounds())
+	}
+	bad := 0
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if !near(got.At(x, y), want.At(x, y)) {
+				if bad++; bad <= 5 {
+					t.Errorf("pixel %d,%d is %v, want %v", x, y, got.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+	if bad > 0 {
+		t.Errorf("%d pixels differ", bad)
+	}
+}
+
+// near reports whether a and b are no more than a step apart in each
+// channel.
+func near(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	for _, d := range [][2]uint32{{ar, br}, {ag, bg}, {ab, bb}, {aa, ba}} {
+		if d[0]>>8 > d[1]>>8+1 || d[1]>>8 > d[0]>>8+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestGLRendererLeaksNothing draws a board offscreen with the GL renderer,
+// for shutting it down to leave no OpenGL objects behind.
+func TestGLRendererLeaksNothing(t *testing.T) {
+	current(t)
+	target, err := render.NewTarget(64, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := render.NewGL("../shaders", "")
+	if err := r.Init(32, 32); err != nil {
+		t.Fatal(err)
+	}
+	sim := life.NewSimulation(life.NewGrid(32, 32), life.Conway, 1, 0.3, 0)
+	target.Bind()
+	for _, zoom := range []float32{1, 0.1, 8} {
+		view := render.View{Projection: [16]float32{zoom, 0, 0, 0, 0, zoom, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1}, Zoom: zoom}
+		if err := r.DrawFrame(sim.Cells, view); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(render.LiveObjects()) == 0 {
+		t.Fatal("nothing is live while drawing")
+	}
+	r.Shutdown()
+	target.Delete()
+	for _, o := range render.LiveObjects() {
+		t.Errorf("left behind: %s", o)
+	}
+}
+
+// BenchmarkGLQuadsZoomedIn draws a 2000 by 2000 board zoomed in far enough
+// for cells to be drawn as quads, which costs what the few hundred cells
+// in view do, not the four million on the board.
+func BenchmarkGLQuadsZoomedIn(b *testing.B) {
+	current(b)
+	target, err := render.NewTarget(256, 256)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer target.Delete()
+	r := render.NewGL("../shaders", "")
+	if err := r.Init(2000, 2000); err != nil {
+		b.Fatal(err)
+	}
+	defer r.Shutdown()
+	sim := life.NewSimulation(life.NewGrid(2000, 2000), life.Conway, 1, 0.3, 0)
+	target.Bind()
+	// Cells 12.8 pixels across, well past the points' and density's.
+	const zoom = 100
+	view := render.View{Projection: [16]float32{zoom, 0, 0, 0, 0, zoom, 0, 0, 0, 0, -1, 0, 0.3 * zoom, -0.2 * zoom, 0, 1}, Zoom: zoom}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.DrawFrame(sim.Cells, view); err != nil {
+			b.Fatal(err)
+		}
+	}
+	gl.Finish()
+}
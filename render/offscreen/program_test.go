@@ -0,0 +1,84 @@
+//go:build egl
+
+package offscreen
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/render"
+)
+
+// TestShaderProgram checks a program's uniforms and attributes are found
+// where the driver has them, names it hasn't got are warned about once
+// and set nothing, and reloading keeps Uniforms working in the new one.
+func TestShaderProgram(t *testing.T) {
+	current(t)
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	p, err := render.NewProgram("cell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Delete()
+	p.Use()
+	projection := p.Uniform("projection")
+	if p.Uniform("projection") != projection {
+		t.Error("asking for a uniform again gave another")
+	}
+	if got, want := p.Attrib("vp"), gl.GetAttribLocation(p.ID, gl.Str("vp\x00")); got != want {
+		t.Errorf("vp is at %d, want the driver's %d", got, want)
+	}
+	m := [16]float32{2, 0, 0, 0, 0, 3, 0, 0, 0, 0, 1, 0, 0.5, 0, 0, 1}
+	projection.SetMat4(m)
+	p.SetVec3("u_colour", 1, 0.5, 0.25)
+	readBack := func(name string, n int) []float32 {
+		v := make([]float32, 16)
+		gl.GetUniformfv(p.ID, gl.GetUniformLocation(p.ID, gl.Str(name+"\x00")), &v[0])
+		return v[:n]
+	}
+	if got := readBack("projection", 16); [16]float32(got) != m {
+		t.Errorf("projection is %v, want %v", got, m)
+	}
+	if got := readBack("u_colour", 3); got[0] != 1 || got[1] != 0.5 || got[2] != 0.25 {
+		t.Errorf("u_colour is %v, want 1, 0.5, 0.25", got)
+	}
+	if logged.Len() != 0 {
+		t.Errorf("warned with every name there: %s", logged.String())
+	}
+
+	for i := 0; i < 3; i++ {
+		p.SetFloat("u_colur", 1)
+		p.Attrib("position")
+	}
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		t.Errorf("setting a missing uniform is GL error %#x", e)
+	}
+	if n := strings.Count(logged.String(), "u_colur"); n != 1 {
+		t.Errorf("the missing uniform was warned about %d times, want once:\n%s", n, logged.String())
+	}
+	if n := strings.Count(logged.String(), "attribute=position"); n != 1 {
+		t.Errorf("the missing attribute was warned about %d times, want once:\n%s", n, logged.String())
+	}
+
+	old := p.ID
+	if err := p.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	// The old program, current until now, goes once it no longer is.
+	p.Use()
+	if p.ID == old || gl.IsProgram(old) {
+		t.Errorf("reloaded to program %d from %d, which is still there", p.ID, old)
+	}
+	m[12] = -0.5
+	projection.SetMat4(m)
+	if got := readBack("projection", 16); [16]float32(got) != m {
+		t.Errorf("after reloading, projection is %v, want %v", got, m)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build !js
+
+package render
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"opengl/life"
+)
+
+// pointThreshold is the on-screen cell size, in pixels, at or below which
+// cells are drawn as points rather than quads.
+const pointThreshold = 2.0
+
+// pointRenderer draws each live cell as a single square point sprite sized to
+// the cell's on-screen size, which is far cheaper than a quad per cell once
+// cells are only a pixel or two across.
+type pointRenderer struct {
+	program    *ShaderProgram
+	projection Uniform
+	pointSize  Uniform
+
+	vao  uint32
+	vbo  uint32
+	data []float32
+	// shared is set for one made by share, which doesn't own program.
+	shared bool
+}
+
+func newPointRenderer(columns, rows int) (*pointRenderer, error) {
+	program, err := NewProgram("points")
+	if err != nil {
+		return nil, err
+	}
+	p := &pointRenderer{
+		program:    program,
+		projection: program.Uniform("projection"),
+		pointSize:  program.Uniform("point_size"),
+		data:       make([]float32, 0, 5*rows*columns),
+	}
+	p.makeArrays()
+	return p, nil
+}
+
+// share returns a point renderer for the current context, which shares
+// objects with p's, drawing with p's program.
+func (p *pointRenderer) share() *pointRenderer {
+	s := &pointRenderer{program: p.program, projection: p.projection, pointSize: p.pointSize, data: make([]float32, 0, cap(p.data)), shared: true}
+	s.makeArrays()
+	return s
+}
+
+// makeArrays makes the buffer and vertex array points are drawn from.
+func (p *pointRenderer) makeArrays() {
+	p.vbo = Gen(Buffer, "cell points")
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*cap(p.data), nil, gl.DYNAMIC_DRAW)
+	SetSize(Buffer, p.vbo, 4*cap(p.data))
+
+	p.vao = Gen(VertexArray, "cell points")
+	gl.BindVertexArray(p.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 20, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 20, gl.PtrOffset(8))
+}
+
+func (p *pointRenderer) delete() {
+	Delete(VertexArray, p.vao)
+	Delete(Buffer, p.vbo)
+	if !p.shared {
+		p.program.Delete()
+	}
+}
+
+func (p *pointRenderer) draw(cells life.Grid, projection [16]float32, size float32) {
+	cellW, cellH := float32(2)/float32(cells.Columns()), float32(2)/float32(cells.Rows())
+	p.data = p.data[:0]
+	for y := 0; y < cells.Rows(); y++ {
+		for x := 0; x < cells.Columns(); x++ {
+			c := cells.At(x, y)
+			if !c.Alive {
+				continue
+			}
+			r, g, b := CellColour(c)
+			p.data = append(p.data, (float32(x)+0.5)*cellW-1, (float32(y)+0.5)*cellH-1, r, g, b)
+		}
+	}
+	if len(p.data) == 0 {
+		return
+	}
+
+	// ES always takes gl_PointSize, and has no switch for it.
+	if CurrentCaps().ProgramPointSize {
+		gl.Enable(gl.PROGRAM_POINT_SIZE)
+	}
+	p.program.Use()
+	p.projection.SetMat4(projection)
+	p.pointSize.SetFloat(size)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(p.data), gl.Ptr(p.data))
+	Uploaded(4 * len(p.data))
+	gl.BindVertexArray(p.vao)
+	gl.DrawArrays(gl.POINTS, 0, int32(len(p.data)/5))
+	if CurrentCaps().ProgramPointSize {
+		gl.Disable(gl.PROGRAM_POINT_SIZE)
+	}
+}
@@ -0,0 +1,199 @@
+//go:build !js
+
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth is how deeply #includes can nest.
+const maxIncludeDepth = 8
+
+// A shaderSource is GLSL ready to compile.
+type shaderSource struct {
+	// text is the source, NUL-terminated for gl.Strs.
+	text string
+	// files are the files the source was put together from, indexed by
+	// the source string numbers its #line directives give them: the
+	// shader's own file first, then each it includes.
+	files []string
+	// read are the files looked for on disk, whether they were there or
+	// not, for noticing them change.
+	read []string
+}
+
+// preprocess prepares name's GLSL source for compiling:
+//
+//   - Unless it starts with a #version directive, after any comments, it
+//     gets GLSLVersion's.
+//   - After the #version, it gets featureDefines, so that one source can
+//     serve contexts with and without a feature.
+//   - Each #include "file.glsl" line is replaced with the file, found in
+//     dir if that's set and it's there, or else among the built-in
+//     shaders. Included files can include others, up to maxIncludeDepth
+//     deep but not in a cycle, and don't get a #version of their own.
+//
+// #line directives keep error line numbers matching the files, with each
+// file numbered as a source string.
+func preprocess(name, source, dir string) (shaderSource, error) {
+	return preprocessFor(name, source, dir, GLSLVersion(), CurrentCaps())
+}
+
+// preprocessFor is preprocess for a context with version's shaders and
+// caps, rather than the current one.
+func preprocessFor(name, source, dir, version string, caps Caps) (shaderSource, error) {
+	p := &preprocessor{dir: dir, out: shaderSource{files: []string{name}}}
+	source = strings.TrimRight(source, "\x00")
+	var b strings.Builder
+	rest, first := source, 1
+	if trimmed := source[leadingComments(source):]; strings.HasPrefix(trimmed, "#version") {
+		skipped := source[:len(source)-len(trimmed)]
+		version, after, _ := strings.Cut(trimmed, "\n")
+		b.WriteString(skipped + version + "\n")
+		rest, first = after, strings.Count(skipped, "\n")+2
+	} else {
+		b.WriteString(version + "\n")
+	}
+	b.WriteString(featureDefines(caps))
+	if err := p.expand(&b, name, rest, first, 0, nil); err != nil {
+		return shaderSource{}, err
+	}
+	p.out.text = b.String() + "\x00"
+	return p.out, nil
+}
+
+// leadingComments is the length of the whitespace and comments src starts
+// with, which are all GLSL allows before a #version.
+func leadingComments(src string) int {
+	i := 0
+	for {
+		rest := strings.TrimLeft(src[i:], " \t\r\n")
+		i = len(src) - len(rest)
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				return len(src)
+			}
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest, "*/")
+			if end < 0 {
+				return len(src)
+			}
+			i += end + 2
+		default:
+			return i
+		}
+	}
+}
+
+type preprocessor struct {
+	dir string
+	out shaderSource
+}
+
+// expand writes src, the text of file from line first on, to b, with its
+// includes expanded. stack is the files including it.
+func (p *preprocessor) expand(b *strings.Builder, file, src string, first, depth int, stack []string) error {
+	number := p.number(file)
+	fmt.Fprintf(b, "#line %d %d\n", first, number)
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		at := fmt.Sprintf("%s:%d", file, first+i)
+		directive := strings.TrimSpace(line)
+		if !strings.HasPrefix(directive, "#include") {
+			b.WriteString(line)
+			if i < len(lines)-1 {
+				b.WriteByte('\n')
+			}
+			continue
+		}
+		included, err := strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(directive, "#include")))
+		if err != nil || included == "" {
+			return fmt.Errorf("%s: want #include \"file.glsl\"", at)
+		}
+		chain := append(slices.Clip(stack), file)
+		for j, f := range chain {
+			if f == included {
+				return fmt.Errorf("%s: #include cycle: %s", at, strings.Join(append(chain[j:], included), " includes "))
+			}
+		}
+		if depth+1 > maxIncludeDepth {
+			return fmt.Errorf("%s: #include %q nests more than %d deep", at, included, maxIncludeDepth)
+		}
+		text, err := p.read(included)
+		if err != nil {
+			return fmt.Errorf("%s: #include: %w", at, err)
+		}
+		if strings.HasPrefix(strings.TrimSpace(text), "#version") {
+			return fmt.Errorf("%s: #include %q: included files can't have a #version", at, included)
+		}
+		if err := p.expand(b, included, strings.TrimRight(text, "\n"), 1, depth+1, chain); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\n#line %d %d\n", first+i+1, number)
+	}
+	return nil
+}
+
+// number returns file's source string number.
+func (p *preprocessor) number(file string) int {
+	for i, f := range p.out.files {
+		if f == file {
+			return i
+		}
+	}
+	p.out.files = append(p.out.files, file)
+	return len(p.out.files) - 1
+}
+
+// read returns an included file's text, from p.dir or the built-in
+// shaders.
+func (p *preprocessor) read(name string) (string, error) {
+	if p.dir != "" {
+		path := filepath.Join(p.dir, name)
+		p.out.read = append(p.out.read, path)
+		src, err := os.ReadFile(path)
+		if err == nil {
+			return string(src), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	src, err := builtinShaders.ReadFile("shaders/" + name)
+	if err != nil && p.dir != "" {
+		return "", fmt.Errorf("no %s in %s or the built-in shaders", name, p.dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("no built-in %s", name)
+	}
+	return string(src), nil
+}
+
+// featureDefines are the #defines telling shaders which of caps' optional
+// features they can use.
+func featureDefines(caps Caps) string {
+	var b strings.Builder
+	for _, d := range []struct {
+		name string
+		on   bool
+	}{
+		{"HAS_SSBO", caps.ShaderStorage},
+		{"HAS_COMPUTE", caps.Compute},
+		{"HAS_BUFFER_STORAGE", caps.BufferStorage},
+	} {
+		if d.on {
+			fmt.Fprintf(&b, "#define %s 1\n", d.name)
+		}
+	}
+	return b.String()
+}
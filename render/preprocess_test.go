@@ -0,0 +1,120 @@
+//go:build !js
+
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeShaders writes files, by name, to a fresh directory.
+func writeShaders(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestPreprocessLines checks every line of a shader put together from
+// nested includes, from disk and built in, is put down by its #line
+// directives to the file and line it came from, as a compiler reads them.
+func TestPreprocessLines(t *testing.T) {
+	files := map[string]string{
+		"main.frag":    "// A shader.\n/* Two\n   lines. */\n#version 330 core\n#include \"colours.glsl\"\nout vec4 colour;\n\n  #include \"cell_uniforms.glsl\"\nvoid main() {\n    colour = tinted(vec4(1.0));\n}\n",
+		"colours.glsl": "#include \"mix.glsl\"\nvec4 tinted(vec4 c) {\n    return mixed(c);\n}\n",
+		"mix.glsl":     "vec4 mixed(vec4 c) { return c; }\n",
+	}
+	dir := writeShaders(t, files)
+	builtin, err := builtinShaders.ReadFile("shaders/cell_uniforms.glsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["cell_uniforms.glsl"] = string(builtin)
+
+	out, err := preprocessFor("main.frag", files["main.frag"], dir, "#version 430", Caps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.text, "// A shader.\n/* Two\n   lines. */\n#version 330 core\n#line 5 0\n") {
+		t.Errorf("the shader's own #version wasn't kept first:\n%s", out.text)
+	}
+	if want := []string{"main.frag", "colours.glsl", "mix.glsl", "cell_uniforms.glsl"}; !slices.Equal(out.files, want) {
+		t.Errorf("source strings %v, want %v", out.files, want)
+	}
+	if want := []string{filepath.Join(dir, "colours.glsl"), filepath.Join(dir, "mix.glsl"), filepath.Join(dir, "cell_uniforms.glsl")}; !slices.Equal(out.read, want) {
+		t.Errorf("looked on disk for %v, want %v", out.read, want)
+	}
+
+	source, line := -1, 0
+	seen := map[string]bool{}
+	for _, l := range strings.Split(strings.TrimRight(out.text, "\x00"), "\n") {
+		if _, err := fmt.Sscanf(l, "#line %d %d", &line, &source); err == nil {
+			continue
+		}
+		if source >= 0 {
+			file := out.files[source]
+			if src := strings.Split(files[file], "\n"); line < 1 || line > len(src) || src[line-1] != l {
+				t.Errorf("%q is put down to %s:%d", l, file, line)
+			}
+			seen[file] = true
+		}
+		line++
+	}
+	for name := range files {
+		if !seen[name] {
+			t.Errorf("no line is put down to %s", name)
+		}
+	}
+}
+
+// TestPreprocessVersion checks a shader without a #version gets the
+// context's, and the defines for its features, before its first line.
+func TestPreprocessVersion(t *testing.T) {
+	out, err := preprocessFor("plain.frag", "void main() {}\n\x00", "", "#version 300 es\nprecision highp float;", Caps{ShaderStorage: true, BufferStorage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#version 300 es\nprecision highp float;\n#define HAS_SSBO 1\n#define HAS_BUFFER_STORAGE 1\n#line 1 0\nvoid main() {}\n\x00"
+	if out.text != want {
+		t.Errorf("preprocessed to %q, want %q", out.text, want)
+	}
+}
+
+func TestPreprocessRejects(t *testing.T) {
+	deep := map[string]string{}
+	for i := 0; i < maxIncludeDepth+1; i++ {
+		deep[fmt.Sprintf("d%d.glsl", i)] = fmt.Sprintf("#include \"d%d.glsl\"\n", i+1)
+	}
+	deep[fmt.Sprintf("d%d.glsl", maxIncludeDepth+1)] = "float deepest;\n"
+	for _, c := range []struct {
+		name  string
+		files map[string]string
+		src   string
+		want  string
+	}{
+		{"cycle", map[string]string{"a.glsl": "#include \"b.glsl\"\n", "b.glsl": "float b;\n#include \"a.glsl\"\n"}, "#include \"a.glsl\"\n",
+			"b.glsl:2: #include cycle: a.glsl includes b.glsl includes a.glsl"},
+		{"itself", nil, "void main() {}\n#include \"main.frag\"\n", "main.frag:2: #include cycle: main.frag includes main.frag"},
+		{"too deep", deep, "#include \"d0.glsl\"\n", fmt.Sprintf("d%d.glsl:1: #include \"d%d.glsl\" nests more than %d deep", maxIncludeDepth-1, maxIncludeDepth, maxIncludeDepth)},
+		{"unquoted", nil, "\n#include <common.glsl>\n", `main.frag:2: want #include "file.glsl"`},
+		{"missing", nil, "#include \"nowhere.glsl\"\n", "main.frag:1: #include: no nowhere.glsl in "},
+		{"versioned", map[string]string{"v.glsl": "\n#version 330 core\n"}, "#include \"v.glsl\"\n", `main.frag:1: #include "v.glsl": included files can't have a #version`},
+	} {
+		dir := writeShaders(t, c.files)
+		_, err := preprocessFor("main.frag", c.src, dir, "#version 330", Caps{})
+		if err == nil || !strings.HasPrefix(err.Error(), c.want) {
+			t.Errorf("%s: preprocessed with %v, want %q", c.name, err, c.want)
+		}
+	}
+	if _, err := preprocessFor("main.frag", "#include \"nowhere.glsl\"\n", "", "#version 330", Caps{}); err == nil || err.Error() != "main.frag:1: #include: no built-in nowhere.glsl" {
+		t.Errorf("with no directory, preprocessed with %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+//go:build !js
+
+package render
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// A ShaderProgram is a linked program with its uniforms' and attributes'
+// locations looked up by name, once, and kept for as long as it's linked.
+// Asking for a name the program doesn't have is warned about the first
+// time, rather than setting nothing in silence. Reload rebuilds it in
+// place, so what holds it, or its Uniforms, needn't change.
+type ShaderProgram struct {
+	// ID is the linked program.
+	ID   uint32
+	name string
+	// build makes the program again, for Reload.
+	build func() (uint32, error)
+
+	// names are the uniforms asked for, and locations where they are in
+	// ID, by Uniform index.
+	names     []string
+	locations []int32
+	// active are ID's active uniforms' and attributes' locations.
+	active, attribs map[string]int32
+	// quiet are the uniforms not warned about when missing, and warned the
+	// names that have been.
+	quiet, warned map[string]bool
+}
+
+// NewProgram builds the program from the built-in name.vert and
+// name.frag shaders.
+func NewProgram(name string) (*ShaderProgram, error) {
+	build := func() (uint32, error) { return MakeProgram(name) }
+	id, err := build()
+	if err != nil {
+		return nil, err
+	}
+	return newShaderProgram(name, id, build), nil
+}
+
+// newShaderProgram wraps id, already linked, in a ShaderProgram that build
+// makes again when it's reloaded.
+func newShaderProgram(name string, id uint32, build func() (uint32, error)) *ShaderProgram {
+	p := &ShaderProgram{
+		name:   name,
+		build:  build,
+		quiet:  make(map[string]bool),
+		warned: make(map[string]bool),
+	}
+	p.link(id)
+	return p
+}
+
+// link makes id the program, looking up its uniforms again.
+func (p *ShaderProgram) link(id uint32) {
+	p.ID = id
+	p.active = make(map[string]int32)
+	p.attribs = make(map[string]int32)
+	var count, length int32
+	gl.GetProgramiv(id, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(id, gl.ACTIVE_UNIFORM_MAX_LENGTH, &length)
+	for i := uint32(0); i < uint32(count); i++ {
+		name := activeName(length, func(buf *uint8, n *int32) {
+			var size int32
+			var xtype uint32
+			gl.GetActiveUniform(id, i, length, n, &size, &xtype, buf)
+		})
+		// Arrays are named for their first element.
+		name = strings.TrimSuffix(name, "[0]")
+		p.active[name] = gl.GetUniformLocation(id, gl.Str(name+"\x00"))
+	}
+	gl.GetProgramiv(id, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(id, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &length)
+	for i := uint32(0); i < uint32(count); i++ {
+		name := activeName(length, func(buf *uint8, n *int32) {
+			var size int32
+			var xtype uint32
+			gl.GetActiveAttrib(id, i, length, n, &size, &xtype, buf)
+		})
+		p.attribs[name] = gl.GetAttribLocation(id, gl.Str(name+"\x00"))
+	}
+	for i, name := range p.names {
+		p.locations[i] = p.lookup(name)
+	}
+}
+
+// activeName reads an active uniform's or attribute's name, of up to
+// length bytes, with get.
+func activeName(length int32, get func(buf *uint8, n *int32)) string {
+	buf := make([]uint8, max(length, 1))
+	var n int32
+	get(&buf[0], &n)
+	return string(buf[:n])
+}
+
+// lookup returns the location of the uniform called name, or -1, warning
+// the first time it isn't there.
+func (p *ShaderProgram) lookup(name string) int32 {
+	location, ok := p.active[name]
+	if !ok {
+		p.warn("uniform", name)
+		return -1
+	}
+	return location
+}
+
+func (p *ShaderProgram) warn(kind, name string) {
+	if p.quiet[name] || p.warned[name] {
+		return
+	}
+	p.warned[name] = true
+	slog.Warn(kind+" isn't in the program: misspelt, or unused and optimized out", "program", p.name, kind, name)
+}
+
+// Uniform returns the uniform called name, to set without looking it up
+// by name again. If the program has no such uniform, setting it does
+// nothing.
+func (p *ShaderProgram) Uniform(name string) Uniform {
+	for i, n := range p.names {
+		if n == name {
+			return Uniform{p, i}
+		}
+	}
+	p.names = append(p.names, name)
+	p.locations = append(p.locations, p.lookup(name))
+	return Uniform{p, len(p.names) - 1}
+}
+
+// optionalUniform is Uniform, for a uniform the program needn't use: none
+// of the standard uniforms given to custom board shaders has to be.
+func (p *ShaderProgram) optionalUniform(name string) Uniform {
+	p.quiet[name] = true
+	return p.Uniform(name)
+}
+
+// Attrib returns the location of the vertex attribute called name, or -1,
+// warning the first time if the program has no such attribute.
+func (p *ShaderProgram) Attrib(name string) int32 {
+	location, ok := p.attribs[name]
+	if !ok {
+		p.warn("attribute", name)
+		return -1
+	}
+	return location
+}
+
+// Use makes the program current, for its uniforms to be set and to draw
+// with.
+func (p *ShaderProgram) Use() {
+	gl.UseProgram(p.ID)
+}
+
+// SetFloat, SetVec2, SetVec3, SetVec4, SetMat4 and SetInt set the uniform
+// called name, in the program, which must be current.
+func (p *ShaderProgram) SetFloat(name string, v float32) { p.Uniform(name).SetFloat(v) }
+
+func (p *ShaderProgram) SetVec2(name string, x, y float32) { p.Uniform(name).SetVec2(x, y) }
+
+func (p *ShaderProgram) SetVec3(name string, x, y, z float32) { p.Uniform(name).SetVec3(x, y, z) }
+
+func (p *ShaderProgram) SetVec4(name string, x, y, z, w float32) {
+	p.Uniform(name).SetVec4(x, y, z, w)
+}
+
+func (p *ShaderProgram) SetMat4(name string, m [16]float32) { p.Uniform(name).SetMat4(m) }
+
+func (p *ShaderProgram) SetInt(name string, v int32) { p.Uniform(name).SetInt(v) }
+
+// Reload builds the program again, in place: on success its ID is the new
+// program, the old one deleted, and its Uniforms are found in the new one.
+// On failure it keeps the program it has.
+func (p *ShaderProgram) Reload() error {
+	id, err := p.build()
+	if err != nil {
+		return err
+	}
+	Delete(Program, p.ID)
+	p.link(id)
+	return nil
+}
+
+// Delete deletes the program.
+func (p *ShaderProgram) Delete() {
+	Delete(Program, p.ID)
+	p.ID = 0
+}
+
+// A Uniform is a ShaderProgram's uniform, looked up by name once. Its
+// setters set it in the program, which must be current, and do nothing if
+// the program has no such uniform.
+type Uniform struct {
+	p *ShaderProgram
+	i int
+}
+
+func (u Uniform) location() int32 {
+	return u.p.locations[u.i]
+}
+
+func (u Uniform) SetFloat(v float32) {
+	gl.Uniform1f(u.location(), v)
+}
+
+func (u Uniform) SetVec2(x, y float32) {
+	gl.Uniform2f(u.location(), x, y)
+}
+
+func (u Uniform) SetVec3(x, y, z float32) {
+	gl.Uniform3f(u.location(), x, y, z)
+}
+
+func (u Uniform) SetVec4(x, y, z, w float32) {
+	gl.Uniform4f(u.location(), x, y, z, w)
+}
+
+func (u Uniform) SetMat4(m [16]float32) {
+	gl.UniformMatrix4fv(u.location(), 1, false, &m[0])
+}
+
+func (u Uniform) SetInt(v int32) {
+	gl.Uniform1i(u.location(), v)
+}
@@ -0,0 +1,62 @@
+// Package render draws life boards. A Renderer draws them somewhere: GL
+// draws them with OpenGL, in a current OpenGL 3.3 core context or later it
+// leaves the caller to create, Terminal in a terminal, and, built for
+// js/wasm instead of GL, WebGL on a canvas in a browser.
+package render
+
+import (
+	"math"
+
+	"opengl/life"
+)
+
+// A Renderer draws boards. Everything it needs to colour a cell, such as
+// its age and team, is in the cells themselves.
+type Renderer interface {
+	// Init prepares to draw columns by rows boards.
+	Init(columns, rows int) error
+	// DrawFrame draws cells as seen from view.
+	DrawFrame(cells life.Grid, view View) error
+	// Resize tells the renderer its output is now width by height, in
+	// whatever units it draws in.
+	Resize(width, height int)
+	// Shutdown frees what Init allocated.
+	Shutdown()
+}
+
+// View is how a board is seen: through Projection, a column-major matrix
+// from the board's coordinates, which span -1 to 1, to the output's, at a
+// magnification of Zoom.
+type View struct {
+	Projection [16]float32
+	Zoom       float32
+}
+
+// VisibleCells returns the inclusive range of a columns by rows board's
+// cells that view puts any part of in the output, which spans -1 to 1 on
+// each axis, with a cell's margin around it and clamped to the board. It
+// reports false if none are in view. A projection that isn't flat, such as
+// a perspective one, has every cell in view.
+func (v View) VisibleCells(columns, rows int) (minX, minY, maxX, maxY int, ok bool) {
+	m := v.Projection
+	det := m[0]*m[5] - m[4]*m[1]
+	if m[3] != 0 || m[7] != 0 || m[15] != 1 || det == 0 {
+		return 0, 0, columns - 1, rows - 1, columns > 0 && rows > 0
+	}
+	// The output's corners, taken back through the projection to the
+	// board's coordinates, bound what's in view.
+	lowX, lowY := float32(math.Inf(1)), float32(math.Inf(1))
+	highX, highY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, corner := range [4][2]float32{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		cx, cy := corner[0]-m[12], corner[1]-m[13]
+		x, y := (m[5]*cx-m[4]*cy)/det, (m[0]*cy-m[1]*cx)/det
+		lowX, lowY, highX, highY = min(lowX, x), min(lowY, y), max(highX, x), max(highY, y)
+	}
+	// Cell x spans x*2/columns-1 to (x+1)*2/columns-1.
+	cell := func(at float32, n int) int {
+		return int(math.Floor(float64((at + 1) * float32(n) / 2)))
+	}
+	minX, maxX = max(cell(lowX, columns)-1, 0), min(cell(highX, columns)+1, columns-1)
+	minY, maxY = max(cell(lowY, rows)-1, 0), min(cell(highY, rows)+1, rows-1)
+	return minX, minY, maxX, maxY, minX <= maxX && minY <= maxY
+}
@@ -0,0 +1,46 @@
+//go:build !js
+
+package render
+
+import "testing"
+
+// flat returns the projection scaling the board by zoom, then moving it by
+// (dx, dy) in the output.
+func flat(zoom, dx, dy float32) [16]float32 {
+	return [16]float32{zoom, 0, 0, 0, 0, zoom, 0, 0, 0, 0, -1, 0, dx, dy, 0, 1}
+}
+
+// TestVisibleCells checks the cells in view of projections looking at the
+// whole of a board, its centre, its corners and edges, past them, and
+// through projections that aren't flat.
+func TestVisibleCells(t *testing.T) {
+	rotated := [16]float32{0, 4, 0, 0, -4, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 1}
+	perspective := [16]float32{5, 0, 0, 0, 0, 5, 0, 0, 0, 0, -1, -1, 0, 0, -1, 0}
+	for _, c := range []struct {
+		name                   string
+		projection             [16]float32
+		columns, rows          int
+		minX, minY, maxX, maxY int
+		ok                     bool
+	}{
+		{"whole board", flat(1, 0, 0), 10, 10, 0, 0, 9, 9, true},
+		{"zoomed out", flat(0.25, 0, 0), 10, 10, 0, 0, 9, 9, true},
+		{"centre", flat(4, 0, 0), 10, 10, 2, 2, 7, 7, true},
+		{"bottom left corner", flat(4, 4.5, 4.5), 10, 10, 0, 0, 1, 1, true},
+		{"top right corner", flat(4, -4.5, -4.5), 10, 10, 8, 8, 9, 9, true},
+		{"left edge, wide board", flat(4, 4.5, 0), 20, 10, 0, 2, 2, 7, true},
+		{"within a cell past the left edge", flat(4, 5.4, 0), 10, 10, 0, 2, 0, 7, true},
+		{"further past the left edge", flat(4, 6.5, 0), 10, 10, 0, 0, 0, 0, false},
+		{"far off the top", flat(4, 0, -20), 10, 10, 0, 0, 0, 0, false},
+		{"rotated", rotated, 10, 10, 2, 2, 7, 7, true},
+		{"perspective", perspective, 10, 8, 0, 0, 9, 7, true},
+		{"flattened to nothing", flat(0, 0, 0), 10, 8, 0, 0, 9, 7, true},
+		{"empty board", perspective, 0, 0, 0, 0, 0, 0, false},
+	} {
+		minX, minY, maxX, maxY, ok := View{Projection: c.projection, Zoom: c.projection[0]}.VisibleCells(c.columns, c.rows)
+		if ok != c.ok || ok && (minX != c.minX || minY != c.minY || maxX != c.maxX || maxY != c.maxY) {
+			t.Errorf("%s: cells (%d, %d) to (%d, %d), in view %v, want (%d, %d) to (%d, %d), %v",
+				c.name, minX, minY, maxX, maxY, ok, c.minX, c.minY, c.maxX, c.maxY, c.ok)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+//go:build !js
+
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// An ObjectKind is a kind of OpenGL object.
+type ObjectKind int
+
+const (
+	Buffer ObjectKind = iota
+	VertexArray
+	Texture
+	Framebuffer
+	Renderbuffer
+	Program
+	Query
+)
+
+var objectKinds = [...]struct {
+	name string
+	// identifier is the kind's namespace for glObjectLabel.
+	identifier uint32
+}{
+	Buffer:       {"buffer", gl.BUFFER},
+	VertexArray:  {"vertex array", gl.VERTEX_ARRAY},
+	Texture:      {"texture", gl.TEXTURE},
+	Framebuffer:  {"framebuffer", gl.FRAMEBUFFER},
+	Renderbuffer: {"renderbuffer", gl.RENDERBUFFER},
+	Program:      {"program", gl.PROGRAM},
+	Query:        {"query object", gl.QUERY},
+}
+
+func (k ObjectKind) String() string {
+	return objectKinds[k].name
+}
+
+// A LiveObject is an OpenGL object made and not yet deleted, with the
+// label it was made with and the bytes of storage it was last given, if
+// known.
+type LiveObject struct {
+	Kind  ObjectKind
+	ID    uint32
+	Label string
+	Size  int
+}
+
+func (o LiveObject) String() string {
+	s := fmt.Sprintf("%s %d %q", o.Kind, o.ID, o.Label)
+	if o.Size > 0 {
+		s += fmt.Sprintf(", %d bytes", o.Size)
+	}
+	return s
+}
+
+type objectKey struct {
+	kind ObjectKind
+	id   uint32
+	// context is Context for the kinds contexts don't share, which each
+	// context numbers from 1.
+	context int
+}
+
+// Context tells apart the contexts sharing objects that callers switch
+// between, for LiveObjects: whoever makes one current sets it to a number
+// of its own, 0 for the first.
+var Context int
+
+func keyOf(kind ObjectKind, id uint32) objectKey {
+	if kind == VertexArray || kind == Framebuffer || kind == Query {
+		return objectKey{kind, id, Context}
+	}
+	return objectKey{kind, id, 0}
+}
+
+// live is every object made with Gen and not yet deleted
+// with Delete. All of them should be made and deleted that way, so that
+// what's left at shutdown is a leak.
+var live = make(map[objectKey]*LiveObject)
+
+// Gen makes an object of kind, labelled name for debuggers such as
+// RenderDoc and apitrace as well as LiveObjects.
+func Gen(kind ObjectKind, name string) uint32 {
+	var id uint32
+	switch kind {
+	case Buffer:
+		gl.GenBuffers(1, &id)
+	case VertexArray:
+		gl.GenVertexArrays(1, &id)
+	case Texture:
+		gl.GenTextures(1, &id)
+	case Framebuffer:
+		gl.GenFramebuffers(1, &id)
+	case Renderbuffer:
+		gl.GenRenderbuffers(1, &id)
+	case Program:
+		id = gl.CreateProgram()
+	case Query:
+		gl.GenQueries(1, &id)
+	}
+	o := &LiveObject{Kind: kind, ID: id, Label: name}
+	live[keyOf(kind, id)] = o
+	label(o)
+	return id
+}
+
+// label names o for debuggers. Most kinds of object only come into being
+// when first bound, so o is bound, and whatever was bound before put back.
+func label(o *LiveObject) {
+	if o.Label == "" || !CurrentCaps().ObjectLabels {
+		return
+	}
+	var was int32
+	switch o.Kind {
+	case Query:
+		// A query only comes into being when it's first begun, and there's
+		// no binding it without that.
+		return
+	case Buffer:
+		// Before its _BINDING alias, in 4.3, the binding was queried by the
+		// target's own name.
+		gl.GetIntegerv(gl.COPY_WRITE_BUFFER, &was)
+		gl.BindBuffer(gl.COPY_WRITE_BUFFER, o.ID)
+		defer gl.BindBuffer(gl.COPY_WRITE_BUFFER, uint32(was))
+	case VertexArray:
+		gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &was)
+		gl.BindVertexArray(o.ID)
+		defer gl.BindVertexArray(uint32(was))
+	case Texture:
+		gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &was)
+		gl.BindTexture(gl.TEXTURE_2D, o.ID)
+		defer gl.BindTexture(gl.TEXTURE_2D, uint32(was))
+	case Framebuffer:
+		gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &was)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, o.ID)
+		defer gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(was))
+	case Renderbuffer:
+		gl.GetIntegerv(gl.RENDERBUFFER_BINDING, &was)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, o.ID)
+		defer gl.BindRenderbuffer(gl.RENDERBUFFER, uint32(was))
+	}
+	gl.ObjectLabel(objectKinds[o.Kind].identifier, o.ID, int32(len(o.Label)), gl.Str(o.Label+"\x00"))
+}
+
+// SetSize records that the object of kind has size bytes of storage.
+func SetSize(kind ObjectKind, id uint32, size int) {
+	if o := live[keyOf(kind, id)]; o != nil {
+		o.Size = size
+	}
+}
+
+// Delete deletes objects of kind.
+func Delete(kind ObjectKind, ids ...uint32) {
+	for _, id := range ids {
+		delete(live, keyOf(kind, id))
+	}
+	if len(ids) == 0 {
+		return
+	}
+	n := int32(len(ids))
+	switch kind {
+	case Buffer:
+		gl.DeleteBuffers(n, &ids[0])
+	case VertexArray:
+		gl.DeleteVertexArrays(n, &ids[0])
+	case Texture:
+		gl.DeleteTextures(n, &ids[0])
+	case Framebuffer:
+		gl.DeleteFramebuffers(n, &ids[0])
+	case Renderbuffer:
+		gl.DeleteRenderbuffers(n, &ids[0])
+	case Program:
+		for _, id := range ids {
+			gl.DeleteProgram(id)
+		}
+	case Query:
+		gl.DeleteQueries(n, &ids[0])
+	}
+}
+
+// uploaded is the bytes Uploaded has counted.
+var uploaded atomic.Int64
+
+// Uploaded counts bytes uploaded to a buffer or texture to draw a frame.
+func Uploaded(bytes int) {
+	uploaded.Add(int64(bytes))
+}
+
+// UploadedBytes returns the bytes Uploaded has counted so far. It's safe to
+// call from any goroutine.
+func UploadedBytes() int64 {
+	return uploaded.Load()
+}
+
+// LiveObjects returns the objects made and not yet deleted, by kind and
+// then ID.
+func LiveObjects() []LiveObject {
+	objects := make([]LiveObject, 0, len(live))
+	for _, o := range live {
+		objects = append(objects, *o)
+	}
+	slices.SortFunc(objects, func(a, b LiveObject) int {
+		if a.Kind != b.Kind {
+			return cmp.Compare(a.Kind, b.Kind)
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return objects
+}
@@ -0,0 +1,47 @@
+//go:build !js
+
+package render
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestLiveObjects checks the objects left live are listed by kind and then
+// ID, with the kinds contexts don't share told apart by context.
+func TestLiveObjects(t *testing.T) {
+	t.Cleanup(func() { live = make(map[objectKey]*LiveObject) })
+	live = make(map[objectKey]*LiveObject)
+	track := func(context int, kind ObjectKind, id uint32, name string) {
+		Context = context
+		live[keyOf(kind, id)] = &LiveObject{Kind: kind, ID: id, Label: name}
+	}
+	track(0, Texture, 3, "density")
+	track(0, Buffer, 7, "points")
+	track(0, VertexArray, 1, "cells")
+	track(1, VertexArray, 1, "cells")
+	track(1, Buffer, 2, "cells")
+	// Contexts share buffers, so this is the same buffer as before.
+	track(1, Buffer, 7, "points")
+	Context = 0
+	SetSize(Buffer, 2, 4096)
+
+	var got []string
+	for _, o := range LiveObjects() {
+		got = append(got, o.String())
+	}
+	want := []string{
+		`buffer 2 "cells", 4096 bytes`,
+		`buffer 7 "points"`,
+		`vertex array 1 "cells"`,
+		`vertex array 1 "cells"`,
+		`texture 3 "density"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("live objects are\n%q\nwant\n%q", got, want)
+	}
+	Delete(Texture)
+	if len(LiveObjects()) != 5 {
+		t.Error("deleting nothing deleted something")
+	}
+}
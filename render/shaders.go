@@ -0,0 +1,163 @@
+//go:build !js
+
+package render
+
+import (
+	"embed"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Debug turns on checks that cost too much to make all the time, like
+// validating each program once it's linked.
+var Debug bool
+
+// builtinShaders holds the built-in shaders, a name.vert and name.frag pair
+// per program, written without a #version line, and name.glsl files for
+// them and users' shaders to #include.
+//
+//go:embed shaders/*.vert shaders/*.frag shaders/*.glsl
+var builtinShaders embed.FS
+
+// builtinPrograms are validated at startup so a broken built-in shader fails
+// fast, all at once, rather than when its feature is first used.
+var builtinPrograms = []string{"cell", "overlay", "minimap", "skyline", "torus", "points", "density"}
+
+// builtinSource returns the source of a built-in shader file.
+func builtinSource(file string) string {
+	source, err := builtinShaders.ReadFile("shaders/" + file)
+	if err != nil {
+		panic(err)
+	}
+	return string(source)
+}
+
+// MakeProgram compiles and links the built-in name.vert and name.frag
+// shaders.
+func MakeProgram(name string) (uint32, error) {
+	vertexShader, err := compileBuiltin(name+".vert", gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(vertexShader)
+	fragmentShader, err := compileBuiltin(name+".frag", gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(fragmentShader)
+
+	return linkProgram(name, vertexShader, fragmentShader)
+}
+
+// linkProgram links the shaders into a program called name, reporting a
+// failure with the info log.
+func linkProgram(name string, vertexShader, fragmentShader uint32) (uint32, error) {
+	program := Gen(Program, name)
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+	// Detached, the shaders are freed as soon as their owners delete them
+	// rather than living on with the program.
+	gl.DetachShader(program, vertexShader)
+	gl.DetachShader(program, fragmentShader)
+	if err := checkProgram(name, program); err != nil {
+		Delete(Program, program)
+		return 0, err
+	}
+	if Debug {
+		gl.ValidateProgram(program)
+		var status int32
+		gl.GetProgramiv(program, gl.VALIDATE_STATUS, &status)
+		if status == gl.FALSE {
+			slog.Warn("program doesn't validate", "program", name, "log", programLog(program))
+		}
+	}
+	return program, nil
+}
+
+// checkProgram returns a LinkError if program, called name, didn't link.
+func checkProgram(name string, program uint32) error {
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		return &LinkError{Name: name, Log: programLog(program)}
+	}
+	return nil
+}
+
+// programLog returns program's info log.
+func programLog(program uint32) string {
+	var logLength int32
+	gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+	log := strings.Repeat("\x00", int(logLength+1))
+	gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+	return strings.TrimRight(log, "\x00")
+}
+
+// ValidateShaders builds every built-in program and reports all the ones
+// that fail.
+func ValidateShaders() error {
+	var errs []error
+	for _, name := range builtinPrograms {
+		program, err := MakeProgram(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		Delete(Program, program)
+	}
+	return errors.Join(errs...)
+}
+
+// compileBuiltin compiles the built-in shader file.
+func compileBuiltin(file string, shaderType uint32) (uint32, error) {
+	source, err := preprocess(file, builtinSource(file), "")
+	if err != nil {
+		return 0, err
+	}
+	return compileShader(source, shaderType)
+}
+
+// compileShader compiles source, reporting failures as a *ShaderError
+// whose messages are attributed to the files it came from.
+func compileShader(source shaderSource, shaderType uint32) (uint32, error) {
+	shader, log, ok := compile(source.text, shaderType)
+	if !ok {
+		stage := "fragment"
+		if shaderType == gl.VERTEX_SHADER {
+			stage = "vertex"
+		}
+		e := newShaderError(source.files[0], stage, log)
+		e.Files = source.files
+		return 0, e
+	}
+	return shader, nil
+}
+
+// compile compiles a shader, returning its info log and false on failure.
+func compile(source string, shaderType uint32) (uint32, string, bool) {
+	shader := gl.CreateShader(shaderType)
+
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+
+		gl.DeleteShader(shader)
+		return 0, log, false
+	}
+
+	return shader, "", true
+}
@@ -0,0 +1,5 @@
+uniform vec3 u_colour;
+out vec4 frag_colour;
+void main() {
+    frag_colour = vec4(u_colour, 1);
+}
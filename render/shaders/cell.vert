@@ -0,0 +1,5 @@
+uniform mat4 projection;
+in vec3 vp;
+void main() {
+    gl_Position = projection * vec4(vp, 1.0);
+}
@@ -0,0 +1,12 @@
+// The uniforms a -frag-shader is given, for it to #include "cell_uniforms.glsl"
+// rather than declare: the seconds since the game started, the viewport's
+// size in pixels, the cell's column and row, how many generations it has
+// lived, whether it's alive (1) or not (0), and the colour it would be
+// drawn in.
+uniform float u_time;
+uniform vec2 u_resolution;
+uniform vec2 u_cell;
+uniform float u_age;
+uniform float u_alive;
+uniform vec3 u_colour;
+out vec4 frag_colour;
@@ -0,0 +1,8 @@
+uniform sampler2D board;
+in vec2 tex_coord;
+out vec4 frag_colour;
+void main() {
+    // Texels are premultiplied by how alive they are, so the mipmaps
+    // average live cells' colours with the dead's nothing.
+    frag_colour = texture(board, tex_coord);
+}
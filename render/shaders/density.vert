@@ -0,0 +1,8 @@
+uniform mat4 projection;
+layout(location = 0) in vec2 vp;
+layout(location = 1) in vec2 uv;
+out vec2 tex_coord;
+void main() {
+    tex_coord = uv;
+    gl_Position = projection * vec4(vp, 0.0, 1.0);
+}
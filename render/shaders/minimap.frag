@@ -0,0 +1,7 @@
+uniform sampler2D board;
+in vec2 tex_coord;
+out vec4 frag_colour;
+void main() {
+    float alive = texture(board, tex_coord).r;
+    frag_colour = vec4(vec3(0.15 + 0.85 * alive), 1);
+}
@@ -0,0 +1,5 @@
+in vec3 cell_colour;
+out vec4 frag_colour;
+void main() {
+    frag_colour = vec4(cell_colour, 1);
+}
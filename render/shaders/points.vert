@@ -0,0 +1,10 @@
+uniform mat4 projection;
+uniform float point_size;
+layout(location = 0) in vec2 vp;
+layout(location = 1) in vec3 colour;
+out vec3 cell_colour;
+void main() {
+    cell_colour = colour;
+    gl_PointSize = point_size;
+    gl_Position = projection * vec4(vp, 0.0, 1.0);
+}
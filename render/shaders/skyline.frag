@@ -0,0 +1,5 @@
+in float shade;
+out vec4 frag_colour;
+void main() {
+    frag_colour = vec4(vec3(shade), 1);
+}
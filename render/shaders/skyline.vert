@@ -0,0 +1,11 @@
+layout(location = 0) in vec3 vp;
+layout(location = 1) in vec3 normal;
+layout(location = 2) in vec3 instance;
+uniform mat4 mvp;
+uniform vec2 cell_size;
+out float shade;
+void main() {
+    vec3 p = vec3(instance.xy + vp.xy * cell_size, vp.z * instance.z);
+    shade = 0.35 + 0.65 * max(dot(normal, normalize(vec3(0.4, 0.3, 0.9))), 0.0);
+    gl_Position = mvp * vec4(p, 1.0);
+}
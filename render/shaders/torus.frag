@@ -0,0 +1,16 @@
+uniform sampler2D board;
+uniform vec2 board_size;
+uniform bool show_grid;
+in vec2 tex_coord;
+in float shade;
+out vec4 frag_colour;
+void main() {
+    float alive = texture(board, tex_coord).r;
+    vec3 colour = mix(vec3(0.05, 0.08, 0.2), vec3(1), alive);
+    if (show_grid) {
+        vec2 cell = tex_coord * board_size;
+        vec2 edge = abs(fract(cell - 0.5) - 0.5) / fwidth(cell);
+        colour = mix(vec3(0.9, 0.3, 0.2), colour, clamp(min(edge.x, edge.y), 0.0, 1.0));
+    }
+    frag_colour = vec4(colour * shade, 1);
+}
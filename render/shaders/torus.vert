@@ -0,0 +1,13 @@
+layout(location = 0) in vec3 vp;
+layout(location = 1) in vec3 normal;
+layout(location = 2) in vec2 uv;
+uniform mat4 mvp;
+uniform mat4 model;
+out vec2 tex_coord;
+out float shade;
+void main() {
+    vec3 n = mat3(model) * normal;
+    shade = 0.35 + 0.65 * max(dot(n, normalize(vec3(0.3, 0.5, 0.8))), 0.0);
+    tex_coord = uv;
+    gl_Position = mvp * vec4(vp, 1.0);
+}
@@ -0,0 +1,8 @@
+#version 300 es
+precision highp float;
+uniform sampler2D board;
+in vec2 tex_coord;
+out vec4 frag_colour;
+void main() {
+    frag_colour = texture(board, tex_coord);
+}
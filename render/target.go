@@ -0,0 +1,107 @@
+//go:build !js
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Target is an offscreen framebuffer with a colour texture and depth
+// buffer, so frames can be rendered at any resolution independent of the
+// window.
+type Target struct {
+	fbo     uint32
+	texture uint32
+	depth   uint32
+	Width   int
+	Height  int
+}
+
+// NewTarget returns a width by height target.
+func NewTarget(width, height int) (*Target, error) {
+	t := &Target{}
+	t.fbo = Gen(Framebuffer, "render target")
+	t.texture = Gen(Texture, "render target colour")
+	t.depth = Gen(Renderbuffer, "render target depth")
+	if err := t.Resize(width, height); err != nil {
+		t.Delete()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Resize reallocates the target's storage.
+func (t *Target) Resize(width, height int) error {
+	var maxSize int32
+	gl.GetIntegerv(gl.MAX_RENDERBUFFER_SIZE, &maxSize)
+	if width <= 0 || height <= 0 || width > int(maxSize) || height > int(maxSize) {
+		return fmt.Errorf("render target size %dx%d is outside 1x1 to %dx%d", width, height, maxSize, maxSize)
+	}
+	t.Width, t.Height = width, height
+
+	gl.BindTexture(gl.TEXTURE_2D, t.texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	SetSize(Texture, t.texture, 4*width*height)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, int32(width), int32(height))
+	SetSize(Renderbuffer, t.depth, 3*width*height)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, t.texture, 0)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, t.depth)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("render target %dx%d is incomplete: status 0x%x", width, height, status)
+	}
+	return nil
+}
+
+// Bind directs rendering into the target until the default framebuffer is
+// bound again.
+func (t *Target) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	gl.Viewport(0, 0, int32(t.Width), int32(t.Height))
+}
+
+// Read returns the target's contents, top row first. Rows are read back one
+// at a time straight into place, so even a very large target is only held in
+// memory once.
+func (t *Target) Read() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, t.Width, t.Height))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, t.fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	for y := 0; y < t.Height; y++ {
+		row := img.Pix[(t.Height-1-y)*img.Stride:]
+		gl.ReadPixels(0, int32(y), int32(t.Width), 1, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(row))
+	}
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	return img
+}
+
+func (t *Target) Delete() {
+	Delete(Framebuffer, t.fbo)
+	Delete(Texture, t.texture)
+	Delete(Renderbuffer, t.depth)
+}
+
+// SavePNG encodes img to a new file at path. The encoder works a row at a
+// time, so this doesn't make another full copy of the image.
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"opengl/life"
+)
+
+// Terminal draws boards in a terminal with ANSI escape codes, redrawing
+// every frame in place from the top left. By default each character is
+// half blocks, one column and two rows of cells each in its own colour;
+// with Braille each is a monochrome two columns and four rows. Boards too
+// big for the terminal are scaled down to fit, a character's cell showing
+// alive if any of the cells it covers are.
+type Terminal struct {
+	// Background is the colour of dead cells.
+	Background [3]float32
+
+	w       io.Writer
+	braille bool
+	// width and height are the terminal's size in characters.
+	width, height int
+	buf           bytes.Buffer
+}
+
+// NewTerminal returns a Terminal that writes to w, normally a terminal of
+// 80 by 24 characters until Resize says otherwise.
+func NewTerminal(w io.Writer, braille bool) *Terminal {
+	return &Terminal{w: w, braille: braille, width: 80, height: 24}
+}
+
+// Init hides the cursor and clears the terminal.
+func (t *Terminal) Init(columns, rows int) error {
+	_, err := io.WriteString(t.w, "\x1b[?25l\x1b[2J")
+	return err
+}
+
+// DrawFrame draws the whole of cells, whatever view says.
+func (t *Terminal) DrawFrame(cells life.Grid, view View) error {
+	columns, rows := cells.Columns(), cells.Rows()
+	// Characters cover cw by ch cells, before scaling.
+	cw, ch := 1, 2
+	if t.braille {
+		cw, ch = 2, 4
+	}
+	scale := max(1, ceilDiv(columns, t.width*cw), ceilDiv(rows, t.height*ch))
+	// cell returns the sample of the board at (x, y), counting down from the
+	// top, or a dead one if nothing there is alive.
+	cell := func(x, y int) life.Cell {
+		for bx := x * scale; bx < min((x+1)*scale, columns); bx++ {
+			for by := y * scale; by < min((y+1)*scale, rows); by++ {
+				if c := cells.At(bx, rows-1-by); c.Alive {
+					return c
+				}
+			}
+		}
+		return life.Cell{}
+	}
+	across := min(t.width, ceilDiv(ceilDiv(columns, scale), cw))
+	down := min(t.height, ceilDiv(ceilDiv(rows, scale), ch))
+
+	t.buf.Reset()
+	for row := 0; row < down; row++ {
+		fmt.Fprintf(&t.buf, "\x1b[%d;1H", row+1)
+		if t.braille {
+			t.buf.WriteString(colourCode(38, LiveColour) + colourCode(48, t.Background))
+		}
+		// The colours last set, so that runs of the same aren't set again.
+		var fg, bg [3]float32
+		for col := 0; col < across; col++ {
+			if t.braille {
+				t.buf.WriteRune(brailleChar(func(dx, dy int) bool {
+					return cell(col*2+dx, row*4+dy).Alive
+				}))
+				continue
+			}
+			top, bottom := cellOrBackground(cell(col, row*2), t.Background), cellOrBackground(cell(col, row*2+1), t.Background)
+			if col == 0 || top != fg {
+				t.buf.WriteString(colourCode(38, top))
+			}
+			if col == 0 || bottom != bg {
+				t.buf.WriteString(colourCode(48, bottom))
+			}
+			fg, bg = top, bottom
+			t.buf.WriteString("▀")
+		}
+		t.buf.WriteString("\x1b[0m\x1b[K")
+	}
+	// Clear whatever's left below from a bigger board or terminal.
+	t.buf.WriteString("\x1b[0J")
+	_, err := t.w.Write(t.buf.Bytes())
+	return err
+}
+
+// Resize sets the terminal's size in characters.
+func (t *Terminal) Resize(width, height int) {
+	t.width, t.height = max(1, width), max(1, height)
+}
+
+// Shutdown resets the colours and shows the cursor again, below the board.
+func (t *Terminal) Shutdown() {
+	fmt.Fprintf(t.w, "\x1b[0m\x1b[%d;1H\x1b[?25h\r\n", t.height)
+}
+
+// brailleChar returns the Braille character with the dots of a two by four
+// block set where alive says, counting from the top left.
+func brailleChar(alive func(dx, dy int) bool) rune {
+	dots := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+	r := rune(0x2800)
+	for dy := range dots {
+		for dx := range dots[dy] {
+			if alive(dx, dy) {
+				r |= dots[dy][dx]
+			}
+		}
+	}
+	return r
+}
+
+// cellOrBackground returns the colour c is drawn in, or bg if it's dead.
+func cellOrBackground(c life.Cell, bg [3]float32) [3]float32 {
+	if !c.Alive {
+		return bg
+	}
+	r, g, b := CellColour(c)
+	return [3]float32{r, g, b}
+}
+
+// colourCode returns the escape code setting the 24-bit foreground (38) or
+// background (48) colour to c.
+func colourCode(layer int, c [3]float32) string {
+	return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, int(c[0]*255), int(c[1]*255), int(c[2]*255))
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,266 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"opengl/life"
+)
+
+// Graphics is a protocol for drawing pictures in a terminal.
+type Graphics int
+
+const (
+	// Sixel is DEC's, in xterm, mlterm, foot and others: up to 256
+	// colours, in bands six pixels high.
+	Sixel Graphics = iota + 1
+	// Kitty is kitty's, in WezTerm and Ghostty too: RGB pixels, compressed
+	// and sent in base64 chunks.
+	Kitty
+)
+
+func (g Graphics) String() string {
+	switch g {
+	case Sixel:
+		return "sixel"
+	case Kitty:
+		return "kitty"
+	}
+	return "none"
+}
+
+// kittyChunk is the most base64 a Kitty graphics escape code carries.
+const kittyChunk = 4096
+
+// TerminalImage draws boards as pictures in a terminal that understands a
+// graphics protocol, from the top left, each cell a square of as many of
+// the terminal's pixels as fit. Boards too big for the terminal are scaled
+// down, a pixel showing alive if any of the cells it covers are. A frame
+// the same as the last one drawn isn't sent again.
+type TerminalImage struct {
+	// Background is the colour of dead cells.
+	Background [3]float32
+
+	w        io.Writer
+	graphics Graphics
+	// width and height are the terminal's size in pixels.
+	width, height int
+	// pixels is the frame being drawn, RGB, and last the one drawn before,
+	// cols by rows pixels.
+	pixels, last []uint8
+	cols, rows   int
+	// resized is set until the picture sent before a Resize is cleared.
+	resized bool
+	buf     bytes.Buffer
+}
+
+// NewTerminalImage returns a TerminalImage that writes to w with graphics,
+// normally a terminal of 640 by 384 pixels until Resize says otherwise.
+func NewTerminalImage(w io.Writer, graphics Graphics) *TerminalImage {
+	return &TerminalImage{w: w, graphics: graphics, width: 640, height: 384}
+}
+
+// Init hides the cursor and clears the terminal.
+func (t *TerminalImage) Init(columns, rows int) error {
+	_, err := io.WriteString(t.w, "\x1b[?25l\x1b[2J")
+	return err
+}
+
+// DrawFrame draws the whole of cells, whatever view says.
+func (t *TerminalImage) DrawFrame(cells life.Grid, view View) error {
+	t.compose(cells)
+	if bytes.Equal(t.pixels, t.last) {
+		return nil
+	}
+	t.buf.Reset()
+	if t.resized {
+		t.buf.WriteString("\x1b[2J")
+		t.resized = false
+	}
+	t.buf.WriteString("\x1b[1;1H")
+	if t.graphics == Kitty {
+		encodeKitty(&t.buf, t.pixels, t.cols, t.rows)
+	} else {
+		encodeSixel(&t.buf, t.pixels, t.cols, t.rows)
+	}
+	t.last = append(t.last[:0], t.pixels...)
+	_, err := t.w.Write(t.buf.Bytes())
+	return err
+}
+
+// compose draws cells into pixels, sized to fit the terminal.
+func (t *TerminalImage) compose(cells life.Grid) {
+	columns, rows := cells.Columns(), cells.Rows()
+	// A cell is zoom pixels across, or a pixel shrink cells across.
+	zoom, shrink := max(1, min(t.width/max(columns, 1), t.height/max(rows, 1))), 1
+	if columns > t.width || rows > t.height {
+		shrink = max(ceilDiv(columns, t.width), ceilDiv(rows, t.height))
+	}
+	w, h := ceilDiv(columns, shrink)*zoom, ceilDiv(rows, shrink)*zoom
+	if w != t.cols || h != t.rows {
+		// There's nothing to compare the first frame at a new size with.
+		t.last = t.last[:0]
+	}
+	t.cols, t.rows = w, h
+	t.pixels = t.pixels[:0]
+	for py := 0; py < h; py++ {
+		// Pictures count rows down from the top; boards up from the
+		// bottom.
+		y := (h - 1 - py) / zoom * shrink
+		for px := 0; px < w; px++ {
+			x := px / zoom * shrink
+			colour := t.Background
+		sample:
+			for bx := x; bx < min(x+shrink, columns); bx++ {
+				for by := y; by < min(y+shrink, rows); by++ {
+					if c := cells.At(bx, by); c.Alive {
+						colour = cellOrBackground(c, t.Background)
+						break sample
+					}
+				}
+			}
+			t.pixels = append(t.pixels, uint8(colour[0]*255), uint8(colour[1]*255), uint8(colour[2]*255))
+		}
+	}
+}
+
+// Resize sets the terminal's size in pixels. The next frame clears the
+// picture sent at the old size.
+func (t *TerminalImage) Resize(width, height int) {
+	t.width, t.height = max(1, width), max(1, height)
+	t.last, t.resized = t.last[:0], true
+}
+
+// Shutdown deletes the picture, for Kitty, whose pictures outlive the text
+// around them, resets the colours and shows the cursor again.
+func (t *TerminalImage) Shutdown() {
+	if t.graphics == Kitty {
+		io.WriteString(t.w, "\x1b_Ga=d,q=2\x1b\\")
+	}
+	io.WriteString(t.w, "\x1b[0m\x1b[999;1H\x1b[?25h\r\n")
+}
+
+// encodeKitty writes the Kitty escape codes drawing a w by h RGB picture at
+// the cursor, in place of the last one drawn, without moving the cursor or
+// asking for a reply, which would arrive as keys.
+func encodeKitty(buf *bytes.Buffer, pixels []uint8, w, h int) {
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	z.Write(pixels)
+	z.Close()
+	payload := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	for i := 0; i == 0 || i < len(payload); i += kittyChunk {
+		chunk := payload[i:min(i+kittyChunk, len(payload))]
+		more := 0
+		if i+kittyChunk < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(buf, "\x1b_Ga=T,f=24,o=z,s=%d,v=%d,i=1,p=1,q=2,C=1,m=%d;%s\x1b\\", w, h, more, chunk)
+		} else {
+			fmt.Fprintf(buf, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+}
+
+// encodeSixel writes the Sixel escape code drawing a w by h RGB picture at
+// the cursor. Past 256 colours, the rest are drawn in the nearest of the
+// first 256.
+func encodeSixel(buf *bytes.Buffer, pixels []uint8, w, h int) {
+	var palette [][3]uint8
+	index := map[[3]uint8]int{}
+	colours := make([]int, w*h)
+	for i := range colours {
+		c := [3]uint8{pixels[3*i], pixels[3*i+1], pixels[3*i+2]}
+		n, ok := index[c]
+		if !ok {
+			if len(palette) < 256 {
+				n = len(palette)
+				palette = append(palette, c)
+			} else {
+				n = nearestColour(palette, c)
+			}
+			index[c] = n
+		}
+		colours[i] = n
+	}
+
+	// Raster attributes give square pixels and the picture's size.
+	fmt.Fprintf(buf, "\x1bPq\"1;1;%d;%d", w, h)
+	for n, c := range palette {
+		fmt.Fprintf(buf, "#%d;2;%d;%d;%d", n, percent(c[0]), percent(c[1]), percent(c[2]))
+	}
+	sixels := make([]byte, w)
+	for top := 0; top < h; top += 6 {
+		used := map[int]bool{}
+		for y := top; y < min(top+6, h); y++ {
+			for x := 0; x < w; x++ {
+				used[colours[y*w+x]] = true
+			}
+		}
+		// Each colour in the band is drawn over the last, from its left.
+		first := true
+		for n := range palette {
+			if !used[n] {
+				continue
+			}
+			for x := range sixels {
+				bits := byte(0)
+				for dy := 0; dy < 6 && top+dy < h; dy++ {
+					if colours[(top+dy)*w+x] == n {
+						bits |= 1 << dy
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			if !first {
+				buf.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(buf, "#%d", n)
+			writeSixelRuns(buf, bytes.TrimRight(sixels, "?"))
+		}
+		buf.WriteByte('-')
+	}
+	buf.WriteString("\x1b\\")
+}
+
+// writeSixelRuns writes sixels, with runs of more than three the same
+// repeated by count.
+func writeSixelRuns(buf *bytes.Buffer, sixels []byte) {
+	for i := 0; i < len(sixels); {
+		j := i
+		for j < len(sixels) && sixels[j] == sixels[i] {
+			j++
+		}
+		if j-i > 3 {
+			fmt.Fprintf(buf, "!%d%c", j-i, sixels[i])
+		} else {
+			buf.Write(sixels[i:j])
+		}
+		i = j
+	}
+}
+
+// nearestColour returns the index of the colour in palette closest to c.
+func nearestColour(palette [][3]uint8, c [3]uint8) int {
+	best, bestDistance := 0, -1
+	for n, p := range palette {
+		d := 0
+		for i := range p {
+			d += (int(p[i]) - int(c[i])) * (int(p[i]) - int(c[i]))
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = n, d
+		}
+	}
+	return best
+}
+
+// percent converts a colour component to Sixel's percentages.
+func percent(v uint8) int {
+	return (int(v)*100 + 127) / 255
+}
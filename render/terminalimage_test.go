@@ -0,0 +1,206 @@
+//go:build !js
+
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"opengl/life"
+)
+
+// TestSixel checks a 6 by 7 picture, a run of white along its top and one
+// white pixel in its second band, is encoded band by band with runs of more
+// than three repeated.
+func TestSixel(t *testing.T) {
+	const w, h = 6, 7
+	pixels := make([]uint8, 3*w*h)
+	for x := 0; x < 5; x++ {
+		copy(pixels[3*x:], []uint8{255, 255, 255})
+	}
+	copy(pixels[3*6*w:], []uint8{255, 255, 255})
+
+	var buf bytes.Buffer
+	encodeSixel(&buf, pixels, w, h)
+	want := "\x1bPq\"1;1;6;7#0;2;100;100;100#1;2;0;0;0" +
+		"#0!5@$#1!5}~-" +
+		"#0@$#1?!5@-" +
+		"\x1b\\"
+	if got := buf.String(); got != want {
+		t.Errorf("encodeSixel wrote\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestSixelRuns checks runs of three or fewer are written out in full.
+func TestSixelRuns(t *testing.T) {
+	var buf bytes.Buffer
+	writeSixelRuns(&buf, []byte("@@@~~~~A"))
+	if got, want := buf.String(), "@@@!4~A"; got != want {
+		t.Errorf("writeSixelRuns wrote %q, want %q", got, want)
+	}
+}
+
+var kittyCode = regexp.MustCompile("^\x1b_G([^;]*);([^\x1b]*)\x1b\\\\")
+
+// TestKittyChunks checks a picture whose payload is more than a chunk is
+// sent in chunks, the first with the picture's size and all but the last
+// saying more follow, that put back together decompress to its pixels.
+func TestKittyChunks(t *testing.T) {
+	const w, h = 40, 40
+	pixels := make([]uint8, 3*w*h)
+	rand.New(rand.NewSource(1)).Read(pixels)
+
+	var buf bytes.Buffer
+	encodeKitty(&buf, pixels, w, h)
+	var keys []string
+	var payload string
+	for rest := buf.String(); rest != ""; {
+		m := kittyCode.FindStringSubmatch(rest)
+		if m == nil {
+			t.Fatalf("not a Kitty escape code: %q", rest[:min(len(rest), 40)])
+		}
+		if len(m[2]) > kittyChunk {
+			t.Errorf("chunk of %d bytes, more than %d", len(m[2]), kittyChunk)
+		}
+		keys = append(keys, m[1])
+		payload += m[2]
+		rest = rest[len(m[0]):]
+	}
+	if len(keys) < 2 {
+		t.Fatalf("payload of %d bytes sent in %d chunks", len(payload), len(keys))
+	}
+	if want := "a=T,f=24,o=z,s=40,v=40,i=1,p=1,q=2,C=1,m=1"; keys[0] != want {
+		t.Errorf("first chunk's keys %q, want %q", keys[0], want)
+	}
+	for i, k := range keys[1:] {
+		want := "m=1"
+		if i == len(keys)-2 {
+			want = "m=0"
+		}
+		if k != want {
+			t.Errorf("chunk %d's keys %q, want %q", i+1, k, want)
+		}
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pixels) {
+		t.Error("the chunks put back together aren't the picture's pixels")
+	}
+}
+
+// TestKittySmall checks a picture small enough for one chunk is sent in
+// one that says nothing follows.
+func TestKittySmall(t *testing.T) {
+	var buf bytes.Buffer
+	encodeKitty(&buf, make([]uint8, 3*2*2), 2, 2)
+	m := kittyCode.FindStringSubmatch(buf.String())
+	if m == nil || len(m[0]) != buf.Len() {
+		t.Fatalf("not one Kitty escape code: %q", buf.String())
+	}
+	if want := "a=T,f=24,o=z,s=2,v=2,i=1,p=1,q=2,C=1,m=0"; m[1] != want {
+		t.Errorf("keys %q, want %q", m[1], want)
+	}
+}
+
+// composed draws cells on a terminal of width by height pixels, returning
+// the picture's size and its pixels as rows of '#' for alive and '.' for
+// dead, top first.
+func composed(t *testing.T, cells life.Grid, width, height int) (int, int, []string) {
+	t.Helper()
+	ti := NewTerminalImage(io.Discard, Sixel)
+	ti.Resize(width, height)
+	ti.compose(cells)
+	var rows []string
+	for y := 0; y < ti.rows; y++ {
+		row := make([]byte, ti.cols)
+		for x := range row {
+			row[x] = '.'
+			if ti.pixels[3*(y*ti.cols+x)] != 0 {
+				row[x] = '#'
+			}
+		}
+		rows = append(rows, string(row))
+	}
+	return ti.cols, ti.rows, rows
+}
+
+// TestComposeZoom checks a board drawn on a terminal with room for each cell
+// to be two pixels across, top row first.
+func TestComposeZoom(t *testing.T) {
+	cells := life.NewGrid(3, 2)
+	cells.Set(0, 1, true)
+	cells.Set(2, 0, true)
+	w, h, rows := composed(t, cells, 7, 5)
+	if w != 6 || h != 4 {
+		t.Fatalf("picture %dx%d, want 6x4", w, h)
+	}
+	want := []string{"##....", "##....", "....##", "....##"}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("picture\n%q\nwant\n%q", rows, want)
+			break
+		}
+	}
+
+	ti := NewTerminalImage(io.Discard, Sixel)
+	ti.compose(cells)
+	got := [3]uint8{ti.pixels[0], ti.pixels[1], ti.pixels[2]}
+	r, g, b := CellColour(cells.At(0, 1))
+	if want := [3]uint8{uint8(r * 255), uint8(g * 255), uint8(b * 255)}; got != want {
+		t.Errorf("live cell drawn %v, want its colour %v", got, want)
+	}
+}
+
+// TestComposeShrink checks a board too big for the terminal is shrunk, a
+// pixel alive if any of the cells it covers are, including the part-covered
+// ones at the edges.
+func TestComposeShrink(t *testing.T) {
+	cells := life.NewGrid(5, 4)
+	cells.Set(1, 3, true)
+	cells.Set(4, 0, true)
+	w, h, rows := composed(t, cells, 3, 2)
+	if w != 3 || h != 2 {
+		t.Fatalf("picture %dx%d, want 3x2", w, h)
+	}
+	if want := []string{"#..", "..#"}; rows[0] != want[0] || rows[1] != want[1] {
+		t.Errorf("picture %q, want %q", rows, want)
+	}
+}
+
+// TestDrawFrameUnchanged checks a frame the same as the last isn't sent
+// again.
+func TestDrawFrameUnchanged(t *testing.T) {
+	var out bytes.Buffer
+	ti := NewTerminalImage(&out, Sixel)
+	cells := life.NewGrid(4, 4)
+	cells.Set(1, 1, true)
+	if err := ti.DrawFrame(cells, View{}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() == 0 {
+		t.Fatal("the first frame wasn't sent")
+	}
+	out.Reset()
+	if err := ti.DrawFrame(cells, View{}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("an unchanged frame sent %d bytes", out.Len())
+	}
+}
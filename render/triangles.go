@@ -0,0 +1,65 @@
+package render
+
+import (
+	"math"
+
+	"opengl/life"
+)
+
+// Tiling is the shape of the cells drawn, set before Init. WebGL draws
+// only squares.
+var Tiling life.Tiling
+
+// TriangleCorners returns the corners of triangle (x, y) of a columns by
+// rows board of triangles spanning normalized device coordinates: its
+// base's, left then right, then its tip. A row's triangles overlap by
+// half, so the row is columns+1 half-triangles wide, and its ends, where
+// the row's above or below has half a triangle, are left bare.
+func TriangleCorners(x, y, columns, rows int) [3][2]float32 {
+	half, height := 2/float32(columns+1), 2/float32(rows)
+	left, tip, right := float32(x)*half-1, float32(x+1)*half-1, float32(x+2)*half-1
+	bottom, top := float32(y)*height-1, float32(y+1)*height-1
+	if life.TriangleUp(x, y) {
+		return [3][2]float32{{left, bottom}, {right, bottom}, {tip, top}}
+	}
+	return [3][2]float32{{left, top}, {right, top}, {tip, bottom}}
+}
+
+// TriangleAt returns the triangle of a columns by rows board of triangles
+// that the point (px, py), in normalized device coordinates, is in,
+// reporting false if it's in none. A point on an edge is in the triangle
+// to its left, or below.
+func TriangleAt(px, py float32, columns, rows int) (x, y int, ok bool) {
+	y = int(math.Floor(float64((py + 1) / 2 * float32(rows))))
+	// The point is over two triangles, half-triangle u and the one before.
+	u := int(math.Floor(float64((px + 1) / 2 * float32(columns+1))))
+	x = u
+	if inTriangle(px, py, TriangleCorners(u-1, y, columns, rows)) {
+		x = u - 1
+	}
+	if x < 0 || y < 0 || x >= columns || y >= rows {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// inTriangle reports whether (px, py) is in t or on its edges: on the same
+// side of all three as the others.
+func inTriangle(px, py float32, t [3][2]float32) bool {
+	side := func(a, b [2]float32) float32 {
+		return (b[0]-a[0])*(py-a[1]) - (b[1]-a[1])*(px-a[0])
+	}
+	d0, d1, d2 := side(t[0], t[1]), side(t[1], t[2]), side(t[2], t[0])
+	return !((d0 < 0 || d1 < 0 || d2 < 0) && (d0 > 0 || d1 > 0 || d2 > 0))
+}
+
+// trianglePoints is cellPoints for a board of triangles: the triangle,
+// then a second with no area, so that each cell takes the vertices a
+// square's quad does.
+func trianglePoints(points []float32, x, y, columns, rows int) {
+	corners := TriangleCorners(x, y, columns, rows)
+	for i := 0; i < 6; i++ {
+		c := corners[min(i, 2)]
+		points[3*i], points[3*i+1], points[3*i+2] = c[0], c[1], 0
+	}
+}
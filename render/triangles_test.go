@@ -0,0 +1,118 @@
+//go:build !js
+
+package render
+
+import (
+	"testing"
+
+	"opengl/life"
+)
+
+// sharedCorners returns how many corners triangles a and b have in common.
+func sharedCorners(a, b [3][2]float32) int {
+	n := 0
+	for _, p := range a {
+		for _, q := range b {
+			if p == q {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// TestTriangleNeighboursTouch checks, on a board of triangles as drawn,
+// that the neighbours life counts sharing an edge share two corners, those
+// sharing only a corner share one, and no other triangle nearby touches.
+func TestTriangleNeighboursTouch(t *testing.T) {
+	const columns, rows = 9, 6
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			want := map[[2]int]int{}
+			for i, d := range life.TriangleNeighbours(life.TriangleUp(x, y), true) {
+				want[d] = 1
+				if i < 3 {
+					want[d] = 2
+				}
+			}
+			corners := TriangleCorners(x, y, columns, rows)
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -3; dx <= 3; dx++ {
+					nx, ny := x+dx, y+dy
+					if (dx == 0 && dy == 0) || nx < 0 || ny < 0 || nx >= columns || ny >= rows {
+						continue
+					}
+					if got := sharedCorners(corners, TriangleCorners(nx, ny, columns, rows)); got != want[[2]int{dx, dy}] {
+						t.Errorf("triangles (%d, %d) and (%d, %d) share %d corners, want %d", x, y, nx, ny, got, want[[2]int{dx, dy}])
+					}
+				}
+			}
+		}
+	}
+}
+
+// centroid returns the middle of t.
+func centroid(t [3][2]float32) [2]float32 {
+	return [2]float32{(t[0][0] + t[1][0] + t[2][0]) / 3, (t[0][1] + t[1][1] + t[2][1]) / 3}
+}
+
+// TestTriangleAt checks points in the middle of each triangle, and just
+// either side of the middle of each edge it shares, are found in the
+// triangle they're in, and points in the bare ends of the rows and off the
+// board in none.
+func TestTriangleAt(t *testing.T) {
+	const columns, rows = 5, 4
+	const nudge = 1e-3
+	at := func(p [2]float32, x, y int, what string) {
+		t.Helper()
+		if gx, gy, ok := TriangleAt(p[0], p[1], columns, rows); !ok || gx != x || gy != y {
+			t.Errorf("%s (%g, %g) is in (%d, %d), %v, want (%d, %d)", what, p[0], p[1], gx, gy, ok, x, y)
+		}
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			corners := TriangleCorners(x, y, columns, rows)
+			middle := centroid(corners)
+			at(middle, x, y, "the middle")
+			for _, d := range life.TriangleNeighbours(life.TriangleUp(x, y), false) {
+				nx, ny := x+d[0], y+d[1]
+				if nx < 0 || ny < 0 || nx >= columns || ny >= rows {
+					continue
+				}
+				// The middle of the shared edge, nudged towards each
+				// triangle's middle.
+				var edge [2]float32
+				other := TriangleCorners(nx, ny, columns, rows)
+				for _, p := range corners {
+					for _, q := range other {
+						if p == q {
+							edge[0], edge[1] = edge[0]+p[0]/2, edge[1]+p[1]/2
+						}
+					}
+				}
+				towards := func(c [2]float32) [2]float32 {
+					return [2]float32{edge[0] + (c[0]-edge[0])*nudge, edge[1] + (c[1]-edge[1])*nudge}
+				}
+				at(towards(middle), x, y, "just inside the edge at")
+				at(towards(centroid(other)), nx, ny, "just across the edge at")
+			}
+		}
+	}
+
+	height := float32(2) / rows
+	for _, p := range [][2]float32{
+		// Row 0 starts and ends with triangles pointing up, bare at the
+		// top; row 1 with ones pointing down, bare at the bottom.
+		{-1 + nudge, -1 + height - nudge},
+		{1 - nudge, -1 + height - nudge},
+		{-1 + nudge, -1 + height + nudge},
+		{1 - nudge, -1 + height + nudge},
+		{-1.1, 0}, {1.1, 0}, {0, -1.1}, {0, 1.1},
+	} {
+		if x, y, ok := TriangleAt(p[0], p[1], columns, rows); ok {
+			t.Errorf("(%g, %g), in no triangle, is in (%d, %d)", p[0], p[1], x, y)
+		}
+	}
+	at([2]float32{-1 + 2*nudge, -1 + nudge}, 0, 0, "the bottom left corner of row 0,")
+	at([2]float32{1 - 2*nudge, -1 + nudge}, columns-1, 0, "the bottom right corner of row 0,")
+}
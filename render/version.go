@@ -0,0 +1,53 @@
+//go:build !js
+
+package render
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// ContextVersions are the OpenGL core versions to ask for, newest first,
+// until the driver makes a context of one.
+var ContextVersions = [][2]int{{4, 6}, {4, 4}, {4, 3}, {4, 1}, {3, 3}}
+
+// ContextVersion returns the current OpenGL context's version.
+var ContextVersion = sync.OnceValues(func() (major, minor int) {
+	var m, n int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &m)
+	gl.GetIntegerv(gl.MINOR_VERSION, &n)
+	return int(m), int(n)
+})
+
+// ES reports whether the current context is OpenGL ES rather than desktop
+// OpenGL.
+var ES = sync.OnceValue(func() bool {
+	return strings.HasPrefix(gl.GoStr(gl.GetString(gl.VERSION)), "OpenGL ES")
+})
+
+// AtLeast reports whether the current context is OpenGL major.minor or
+// later, for features newer than 3.3 core, the earliest context asked for:
+// object labels and debug output, for example, need 4.3. ES contexts are
+// never later than 3.3.
+func AtLeast(major, minor int) bool {
+	m, n := ContextVersion()
+	return m > major || m == major && n >= minor
+}
+
+// GLSLVersion returns the #version directive injected at the top of every
+// shader that doesn't declare its own: that of the context, up to the GLSL
+// 4.30 the built-in shaders were first written for. They use nothing newer
+// than 3.30, or than GLSL ES 3.00, which gets default precisions too.
+func GLSLVersion() string {
+	if ES() {
+		return "#version 300 es\nprecision highp float;\nprecision highp int;"
+	}
+	major, minor := ContextVersion()
+	if AtLeast(4, 3) {
+		major, minor = 4, 3
+	}
+	return fmt.Sprintf("#version %d%d0", major, minor)
+}
@@ -0,0 +1,184 @@
+//go:build js && wasm
+
+package render
+
+import (
+	_ "embed"
+	"errors"
+	"syscall/js"
+
+	"opengl/life"
+)
+
+var (
+	//go:embed shaders/webgl.vert
+	webglVertex string
+	//go:embed shaders/webgl.frag
+	webglFragment string
+)
+
+// WebGL draws boards on a canvas with WebGL 2, as a texture of a texel per
+// cell stretched over a quad, which needs the fewest features of any way of
+// drawing them.
+type WebGL struct {
+	// Background is the colour of dead cells.
+	Background [3]float32
+
+	canvas, gl      js.Value
+	program, vao    js.Value
+	buffer, texture js.Value
+	projection      js.Value
+	columns, rows   int
+	texels          []byte
+	upload, matrix  js.Value
+	width, height   int
+}
+
+// NewWebGL returns a WebGL that draws on canvas, a <canvas> element.
+func NewWebGL(canvas js.Value) *WebGL {
+	return &WebGL{canvas: canvas, width: canvas.Get("width").Int(), height: canvas.Get("height").Int()}
+}
+
+// Init gets the canvas's WebGL 2 context and builds the program, quad and
+// texture for columns by rows boards.
+func (r *WebGL) Init(columns, rows int) error {
+	gl := r.canvas.Call("getContext", "webgl2")
+	if gl.IsNull() {
+		return errors.New("this browser has no WebGL 2")
+	}
+	if Tiling != life.Squares {
+		return errors.New("WebGL draws only square cells, not " + Tiling.String())
+	}
+	r.gl, r.columns, r.rows = gl, columns, rows
+
+	vertex, err := r.compile("webgl.vert", webglVertex, gl.Get("VERTEX_SHADER"))
+	if err != nil {
+		return err
+	}
+	defer gl.Call("deleteShader", vertex)
+	fragment, err := r.compile("webgl.frag", webglFragment, gl.Get("FRAGMENT_SHADER"))
+	if err != nil {
+		return err
+	}
+	defer gl.Call("deleteShader", fragment)
+	r.program = gl.Call("createProgram")
+	gl.Call("attachShader", r.program, vertex)
+	gl.Call("attachShader", r.program, fragment)
+	gl.Call("linkProgram", r.program)
+	if !gl.Call("getProgramParameter", r.program, gl.Get("LINK_STATUS")).Bool() {
+		return &LinkError{Name: "webgl", Log: gl.Call("getProgramInfoLog", r.program).String()}
+	}
+	r.projection = gl.Call("getUniformLocation", r.program, "projection")
+
+	// A triangle strip over the board, each corner with its texture
+	// coordinate.
+	quad := []float32{
+		-1, -1, 0, 0,
+		1, -1, 1, 0,
+		-1, 1, 0, 1,
+		1, 1, 1, 1,
+	}
+	vertices := js.Global().Get("Float32Array").New(len(quad))
+	for i, v := range quad {
+		vertices.SetIndex(i, v)
+	}
+	r.vao = gl.Call("createVertexArray")
+	gl.Call("bindVertexArray", r.vao)
+	r.buffer = gl.Call("createBuffer")
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), r.buffer)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), vertices, gl.Get("STATIC_DRAW"))
+	gl.Call("enableVertexAttribArray", 0)
+	gl.Call("vertexAttribPointer", 0, 2, gl.Get("FLOAT"), false, 16, 0)
+	gl.Call("enableVertexAttribArray", 1)
+	gl.Call("vertexAttribPointer", 1, 2, gl.Get("FLOAT"), false, 16, 8)
+
+	r.texels = make([]byte, 4*columns*rows)
+	r.upload = js.Global().Get("Uint8Array").New(len(r.texels))
+	r.matrix = js.Global().Get("Float32Array").New(16)
+	r.texture = gl.Call("createTexture")
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), r.texture)
+	for _, p := range []string{"TEXTURE_MIN_FILTER", "TEXTURE_MAG_FILTER"} {
+		gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get(p), gl.Get("NEAREST"))
+	}
+	for _, p := range []string{"TEXTURE_WRAP_S", "TEXTURE_WRAP_T"} {
+		gl.Call("texParameteri", gl.Get("TEXTURE_2D"), gl.Get(p), gl.Get("CLAMP_TO_EDGE"))
+	}
+	gl.Call("texImage2D", gl.Get("TEXTURE_2D"), 0, gl.Get("RGBA8"), columns, rows, 0, gl.Get("RGBA"), gl.Get("UNSIGNED_BYTE"), r.upload)
+	return nil
+}
+
+// compile compiles a shader of shaderType, reporting failures with its
+// info log.
+func (r *WebGL) compile(name, source string, shaderType js.Value) (js.Value, error) {
+	shader := r.gl.Call("createShader", shaderType)
+	r.gl.Call("shaderSource", shader, source)
+	r.gl.Call("compileShader", shader)
+	if !r.gl.Call("getShaderParameter", shader, r.gl.Get("COMPILE_STATUS")).Bool() {
+		log := r.gl.Call("getShaderInfoLog", shader).String()
+		r.gl.Call("deleteShader", shader)
+		stage := "fragment"
+		if shaderType.Equal(r.gl.Get("VERTEX_SHADER")) {
+			stage = "vertex"
+		}
+		return js.Null(), newShaderError(name, stage, log)
+	}
+	return shader, nil
+}
+
+// DrawFrame uploads cells as the texture and draws it through
+// view.Projection. Only the cells in view are uploaded; the rest of the
+// texture is left as it was, out of sight.
+func (r *WebGL) DrawFrame(cells life.Grid, view View) error {
+	minX, minY, maxX, maxY, visible := view.VisibleCells(min(r.columns, cells.Columns()), min(r.rows, cells.Rows()))
+	for x := minX; visible && x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			colour := cellOrBackground(cells.At(x, y), r.Background)
+			i := 4 * (y*r.columns + x)
+			r.texels[i], r.texels[i+1], r.texels[i+2], r.texels[i+3] = byte(colour[0]*255), byte(colour[1]*255), byte(colour[2]*255), 255
+		}
+	}
+	// The rows in view are copied over and uploaded, skipping the columns
+	// either side of it.
+	start, end := 4*minY*r.columns, 4*(maxY+1)*r.columns
+	upload := r.upload.Call("subarray", start, end)
+	if visible {
+		js.CopyBytesToJS(upload, r.texels[start:end])
+	}
+	for i, v := range view.Projection {
+		r.matrix.SetIndex(i, v)
+	}
+
+	gl := r.gl
+	gl.Call("viewport", 0, 0, r.width, r.height)
+	gl.Call("clearColor", r.Background[0], r.Background[1], r.Background[2], 1)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT"))
+	gl.Call("useProgram", r.program)
+	gl.Call("uniformMatrix4fv", r.projection, false, r.matrix)
+	gl.Call("activeTexture", gl.Get("TEXTURE0"))
+	gl.Call("bindTexture", gl.Get("TEXTURE_2D"), r.texture)
+	if visible {
+		gl.Call("pixelStorei", gl.Get("UNPACK_ROW_LENGTH"), r.columns)
+		gl.Call("pixelStorei", gl.Get("UNPACK_SKIP_PIXELS"), minX)
+		gl.Call("texSubImage2D", gl.Get("TEXTURE_2D"), 0, minX, minY, maxX-minX+1, maxY-minY+1, gl.Get("RGBA"), gl.Get("UNSIGNED_BYTE"), upload)
+		gl.Call("pixelStorei", gl.Get("UNPACK_ROW_LENGTH"), 0)
+		gl.Call("pixelStorei", gl.Get("UNPACK_SKIP_PIXELS"), 0)
+	}
+	gl.Call("bindVertexArray", r.vao)
+	gl.Call("drawArrays", gl.Get("TRIANGLE_STRIP"), 0, 4)
+	return nil
+}
+
+// Resize sets the canvas's drawing buffer to width by height pixels.
+func (r *WebGL) Resize(width, height int) {
+	r.width, r.height = max(1, width), max(1, height)
+	r.canvas.Set("width", r.width)
+	r.canvas.Set("height", r.height)
+}
+
+// Shutdown frees the program, quad and texture.
+func (r *WebGL) Shutdown() {
+	r.gl.Call("deleteTexture", r.texture)
+	r.gl.Call("deleteBuffer", r.buffer)
+	r.gl.Call("deleteVertexArray", r.vao)
+	r.gl.Call("deleteProgram", r.program)
+}